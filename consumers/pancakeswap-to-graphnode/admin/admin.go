@@ -0,0 +1,165 @@
+// Package admin lets operators act on a running pipeline: pause and resume
+// it, take a snapshot of its outputs at a block boundary and change log
+// levels, without restarting a long backfill.
+package admin
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned to snapshot requests still pending when the pipeline
+// stops.
+var ErrClosed = errors.New("pipeline stopped")
+
+// Snapshot describes the outputs of the pipeline once flushed at a block
+// boundary, every block up to `BlockNum` included is in the outputs and none
+// after it. `Cursor` resumes the stream right after that block.
+type Snapshot struct {
+	BlockNum uint64 `json:"block_num"`
+	BlockID  string `json:"block_id"`
+	Cursor   string `json:"cursor"`
+	Path     string `json:"path,omitempty"`
+}
+
+// TakeSnapshot flushes the outputs and records a snapshot, it's called by the
+// pipeline between two blocks.
+type TakeSnapshot func(ctx context.Context) (*Snapshot, error)
+
+// Controller is shared by the admin server and the pipeline, which calls
+// `Boundary` between every block.
+type Controller struct {
+	lock      sync.Mutex
+	paused    bool
+	resumed   chan struct{}
+	requested chan struct{}
+	pending   []chan snapshotResult
+	lastBlock uint64
+	closed    bool
+}
+
+type snapshotResult struct {
+	snapshot *Snapshot
+	err      error
+}
+
+func NewController() *Controller {
+	return &Controller{
+		resumed:   make(chan struct{}),
+		requested: make(chan struct{}, 1),
+	}
+}
+
+// Pause stops the pipeline at the next block boundary, until `Resume`.
+func (c *Controller) Pause() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.resumed = make(chan struct{})
+	}
+}
+
+func (c *Controller) Resume() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.paused {
+		c.paused = false
+		close(c.resumed)
+	}
+}
+
+type Status struct {
+	Paused    bool   `json:"paused"`
+	LastBlock uint64 `json:"last_block"`
+}
+
+func (c *Controller) Status() Status {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Status{Paused: c.paused, LastBlock: c.lastBlock}
+}
+
+// Snapshot waits for the pipeline to take a snapshot at its next block
+// boundary, right away when it's paused.
+func (c *Controller) Snapshot(ctx context.Context) (*Snapshot, error) {
+	result := make(chan snapshotResult, 1)
+
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil, ErrClosed
+	}
+	c.pending = append(c.pending, result)
+	c.lock.Unlock()
+
+	select {
+	case c.requested <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		return r.snapshot, r.err
+	}
+}
+
+// Boundary is called by the pipeline once block `blockNum` is fully written to
+// the outputs. It takes the requested snapshots and blocks while the pipeline
+// is paused, still taking snapshots requested in the meantime.
+func (c *Controller) Boundary(ctx context.Context, blockNum uint64, take TakeSnapshot) error {
+	c.lock.Lock()
+	c.lastBlock = blockNum
+	c.lock.Unlock()
+
+	for {
+		c.takeSnapshots(ctx, take)
+
+		c.lock.Lock()
+		paused, resumed := c.paused, c.resumed
+		c.lock.Unlock()
+		if !paused {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resumed:
+		case <-c.requested:
+		}
+	}
+}
+
+func (c *Controller) takeSnapshots(ctx context.Context, take TakeSnapshot) {
+	c.lock.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.lock.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	// All the requests pending at the same boundary share the same snapshot
+	snapshot, err := take(ctx)
+	for _, result := range pending {
+		result <- snapshotResult{snapshot, err}
+	}
+}
+
+// Close fails the pending snapshot requests, and the ones made afterward.
+func (c *Controller) Close() {
+	c.lock.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.closed = true
+	c.lock.Unlock()
+
+	for _, result := range pending {
+		result <- snapshotResult{err: ErrClosed}
+	}
+}
@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeline simulates the run loop, calling `Boundary` after each block until
+// `blocks` are processed.
+func pipeline(ctx context.Context, c *Controller, blocks uint64, processed *uint64, snapshots *int32) chan error {
+	done := make(chan error, 1)
+	go func() {
+		for num := uint64(1); num <= blocks; num++ {
+			atomic.StoreUint64(processed, num)
+			err := c.Boundary(ctx, num, func(ctx context.Context) (*Snapshot, error) {
+				atomic.AddInt32(snapshots, 1)
+				return &Snapshot{BlockNum: num}, nil
+			})
+			if err != nil {
+				done <- err
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		done <- nil
+	}()
+	return done
+}
+
+func TestController(t *testing.T) {
+	ctx := context.Background()
+	c := NewController()
+
+	var processed uint64
+	var snapshots int32
+	c.Pause()
+	done := pipeline(ctx, c, 20, &processed, &snapshots)
+
+	// paused at the first boundary, snapshots are still taken
+	snapshot, err := c.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), snapshot.BlockNum)
+	assert.Equal(t, Status{Paused: true, LastBlock: 1}, c.Status())
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&processed), "no block processed while paused")
+
+	c.Resume()
+	snapshot, err = c.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, snapshot.BlockNum, uint64(1))
+
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&snapshots))
+
+	c.Close()
+	_, err = c.Snapshot(ctx)
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestController_CanceledWhilePaused(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewController()
+	c.Pause()
+
+	var processed uint64
+	var snapshots int32
+	done := pipeline(ctx, c, 5, &processed, &snapshots)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+type testParams struct {
+	values map[string]string
+}
+
+func (p *testParams) Values() map[string]string { return p.values }
+
+func (p *testParams) Stage(values map[string]string) error {
+	for name := range values {
+		if _, found := p.values[name]; !found {
+			return fmt.Errorf("unknown param %q", name)
+		}
+	}
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	switcher := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in logLevelRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		if in.Level == "loud" {
+			http.Error(w, `invalid level value "loud"`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer switcher.Close()
+
+	c := NewController()
+	s := NewServer(c, strings.TrimPrefix(switcher.URL, "http://"))
+	s.SetParams(&testParams{values: map[string]string{"block-pair": ""}})
+	s.SetSchema(map[string]string{"store_pairs": "pair:{pair}"})
+	s.SetChainHead(func() interface{} { return map[string]uint64{"head": 12, "lib": 10} })
+	require.NoError(t, s.Listen("127.0.0.1:0"))
+	defer s.Close()
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"pause", http.MethodPost, "/pause", "", 200, `{"paused":true,"last_block":0}`},
+		{"status", http.MethodGet, "/status", "", 200, `{"paused":true,"last_block":0}`},
+		{"resume", http.MethodPost, "/resume", "", 200, `{"paused":false,"last_block":0}`},
+		{"wrong method", http.MethodGet, "/pause", "", 405, ""},
+		{"log level", http.MethodPut, "/log-level", `{"level":"debug","inputs":"exchange"}`, 200, `{"level":"debug","inputs":"exchange"}`},
+		{"invalid log level", http.MethodPut, "/log-level", `{"level":"loud"}`, 400, ""},
+		{"malformed log level", http.MethodPut, "/log-level", `{`, 400, ""},
+		{"params", http.MethodGet, "/params", "", 200, `{"block-pair":""}`},
+		{"set params", http.MethodPut, "/params", `{"block-pair":"0xab"}`, 200, `{"block-pair":""}`},
+		{"unknown params", http.MethodPut, "/params", `{"whale-threshold":"10"}`, 400, ""},
+		{"schema", http.MethodGet, "/schema", "", 200, `{"store_pairs":"pair:{pair}"}`},
+		{"chain head", http.MethodGet, "/chain-head", "", 200, `{"head":12,"lib":10}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, "http://"+s.Addr()+test.path, strings.NewReader(test.body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+			if test.expectedBody != "" {
+				var body json.RawMessage
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.JSONEq(t, test.expectedBody, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package admin
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.admin", "github.com/streamingfast/substream-pancakeswap/admin")
@@ -0,0 +1,255 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogLevelSwitcherAddr is where the logging library serves its level
+// switcher, `PUT /log-level` requests are forwarded to it.
+const LogLevelSwitcherAddr = "127.0.0.1:1065"
+
+// Server serves the admin operations over HTTP:
+//
+//	GET  /status     paused state and last block written
+//	POST /pause      pause at the next block boundary
+//	POST /resume
+//	POST /snapshot   flush the outputs at the next block boundary and record a snapshot
+//	PUT  /log-level  {"level": "debug", "inputs": "exchange|substreams.sink.*"}
+//	GET  /params     current params values
+//	PUT  /params     {"block-pair": "0x...,0x..."}, applied at the next block boundary
+//	GET  /schema     keys and value types of the stores, see the `schema` package
+//	GET  /chain-head head and last irreversible block of the chain, see the `chainhead` package
+type Server struct {
+	controller *Controller
+	switcher   string
+	params     Params
+	schema     interface{}
+	chainHead  func() interface{}
+	tls        *tls.Config
+	server     *http.Server
+	listener   net.Listener
+}
+
+// Params are the values that can be changed while running, see the `params`
+// package.
+type Params interface {
+	Values() map[string]string
+	Stage(values map[string]string) error
+}
+
+func NewServer(controller *Controller, switcherAddr string) *Server {
+	s := &Server{controller: controller, switcher: switcherAddr}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handle(http.MethodGet, s.status))
+	mux.HandleFunc("/pause", s.handle(http.MethodPost, s.pause))
+	mux.HandleFunc("/resume", s.handle(http.MethodPost, s.resume))
+	mux.HandleFunc("/snapshot", s.handle(http.MethodPost, s.snapshot))
+	mux.HandleFunc("/log-level", s.handle(http.MethodPut, s.logLevel))
+	mux.HandleFunc("/params", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			s.handle(http.MethodPut, s.setParams)(w, r)
+			return
+		}
+		s.handle(http.MethodGet, s.getParams)(w, r)
+	})
+	mux.HandleFunc("/schema", s.handle(http.MethodGet, s.getSchema))
+	mux.HandleFunc("/chain-head", s.handle(http.MethodGet, s.getChainHead))
+	s.server = &http.Server{Handler: mux}
+
+	return s
+}
+
+// SetParams enables the `/params` endpoints, it must be called before `Listen`.
+func (s *Server) SetParams(params Params) {
+	s.params = params
+}
+
+// SetSchema enables the `/schema` endpoint serving `schema` as JSON, it must be
+// called before `Listen`.
+func (s *Server) SetSchema(schema interface{}) {
+	s.schema = schema
+}
+
+// SetChainHead enables the `/chain-head` endpoint serving what `status`
+// returns as JSON, it must be called before `Listen`.
+func (s *Server) SetChainHead(status func() interface{}) {
+	s.chainHead = status
+}
+
+// SetTLS serves over TLS, it must be called before `Listen`.
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tls = config
+}
+
+// Listen starts serving on `addr` in the background.
+func (s *Server) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("admin listen on %q: %w", addr, err)
+	}
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+	s.listener = listener
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			zlog.Warn("admin server failed", zap.Error(err))
+		}
+	}()
+	zlog.Info("admin server listening", zap.String("addr", listener.Addr().String()), zap.Bool("tls", s.tls != nil))
+	return nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) handle(method string, fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			http.Error(w, fmt.Sprintf("method %s not allowed, use %s", r.Method, method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		out, err := fn(r)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if _, ok := err.(badRequest); ok {
+				code = http.StatusBadRequest
+			}
+			zlog.Warn("admin request failed", zap.String("path", r.URL.Path), zap.Error(err))
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			zlog.Debug("writing admin response", zap.Error(err))
+		}
+	}
+}
+
+func (s *Server) status(r *http.Request) (interface{}, error) {
+	return s.controller.Status(), nil
+}
+
+func (s *Server) pause(r *http.Request) (interface{}, error) {
+	s.controller.Pause()
+	zlog.Info("pipeline paused by admin request")
+	return s.controller.Status(), nil
+}
+
+func (s *Server) resume(r *http.Request) (interface{}, error) {
+	s.controller.Resume()
+	zlog.Info("pipeline resumed by admin request")
+	return s.controller.Status(), nil
+}
+
+func (s *Server) snapshot(r *http.Request) (interface{}, error) {
+	return s.controller.Snapshot(r.Context())
+}
+
+type badRequest struct {
+	error
+}
+
+type logLevelRequest struct {
+	Level  string `json:"level"`
+	Inputs string `json:"inputs"`
+}
+
+func (s *Server) logLevel(r *http.Request) (interface{}, error) {
+	var in logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		return nil, badRequest{fmt.Errorf("invalid request: %w", err)}
+	}
+	if in.Inputs == "" {
+		in.Inputs = ".*"
+	}
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPut, "http://"+s.switcher, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("log level switcher: %w", err)
+	}
+	defer resp.Body.Close()
+
+	message, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, badRequest{fmt.Errorf("%s", bytes.TrimSpace(message))}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("log level switcher: %s", bytes.TrimSpace(message))
+	}
+
+	zlog.Info("log level changed by admin request", zap.String("level", in.Level), zap.String("inputs", in.Inputs))
+	return in, nil
+}
+
+var errNoParams = badRequest{fmt.Errorf("no params can be changed while running")}
+
+func (s *Server) getParams(r *http.Request) (interface{}, error) {
+	if s.params == nil {
+		return nil, errNoParams
+	}
+	return s.params.Values(), nil
+}
+
+// setParams stages the values, the response is the values before they're
+// applied.
+func (s *Server) setParams(r *http.Request) (interface{}, error) {
+	if s.params == nil {
+		return nil, errNoParams
+	}
+
+	var values map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		return nil, badRequest{fmt.Errorf("invalid request: %w", err)}
+	}
+	if err := s.params.Stage(values); err != nil {
+		return nil, badRequest{err}
+	}
+
+	zlog.Info("params staged by admin request, applying at next block boundary", zap.Any("params", values))
+	return s.params.Values(), nil
+}
+
+func (s *Server) getSchema(r *http.Request) (interface{}, error) {
+	if s.schema == nil {
+		return nil, badRequest{fmt.Errorf("no store schema loaded, see --store-schema")}
+	}
+	return s.schema, nil
+}
+
+func (s *Server) getChainHead(r *http.Request) (interface{}, error) {
+	if s.chainHead == nil {
+		return nil, badRequest{fmt.Errorf("chain head not tracked")}
+	}
+	return s.chainHead(), nil
+}
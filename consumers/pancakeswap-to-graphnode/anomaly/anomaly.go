@@ -0,0 +1,357 @@
+// Package anomaly flags the blocks where the volume or the price of a pair
+// moves far from its usual values. Each pair keeps an exponentially weighted
+// moving average and variance of its USD volume per block, from the swaps of
+// `map_burn_swaps_events`, and of the log returns of its token0 price, from
+// the reserves of `map_reserves`. An observation more than the configured
+// number of standard deviations away from the average is an anomaly.
+//
+// Like the leaderboards, the averages have to be read back to be updated, so
+// they are kept by the consumer and the anomalies are written to the outputs
+// as the deltas of an extra store, `anomalies`, holding the last anomaly of
+// each pair and metric. Sinks publishing store deltas (nats, pubsub) make it
+// a topic alerting pipelines can subscribe to.
+package anomaly
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	SwapsModule    = "map_burn_swaps_events"
+	ReservesModule = "map_reserves"
+
+	// Store is the name of the store deltas output holding the anomalies,
+	// keyed by `<metric>:<pair>`.
+	Store = "anomalies"
+)
+
+// Metrics watched per pair.
+const (
+	MetricVolume = "volume_usd"
+	MetricPrice  = "price"
+)
+
+// undoDepth is the number of reversible blocks whose changes can be undone.
+const undoDepth = 512
+
+type Config struct {
+	// Alpha is the weight of a new observation in the moving average and
+	// variance, between 0 and 1.
+	Alpha float64
+	// VolumeSigma and PriceSigma are the number of standard deviations from
+	// the average past which an observation is an anomaly, the metric isn't
+	// watched when 0.
+	VolumeSigma float64
+	PriceSigma  float64
+	// Warmup is the number of observations of a pair and metric before its
+	// anomalies are reported.
+	Warmup int
+}
+
+func (c Config) Validate() error {
+	if c.Alpha <= 0 || c.Alpha > 1 {
+		return fmt.Errorf("alpha %v out of (0, 1]", c.Alpha)
+	}
+	if c.VolumeSigma < 0 || c.PriceSigma < 0 {
+		return fmt.Errorf("sigma thresholds can't be negative")
+	}
+	if c.VolumeSigma == 0 && c.PriceSigma == 0 {
+		return fmt.Errorf("no metric watched, set a volume or a price sigma threshold")
+	}
+	return nil
+}
+
+// Anomaly is the value of a store delta of `Store`, JSON encoded. The value of
+// the price metric is the log return of the token0 price since the previous
+// block the pair synced in.
+type Anomaly struct {
+	Pair      string    `json:"pair"`
+	Metric    string    `json:"metric"`
+	BlockNum  uint64    `json:"block_num"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+	Mean      float64   `json:"mean"`
+	StdDev    float64   `json:"stddev"`
+	// Sigma is how many standard deviations the value is from the mean,
+	// negative below it.
+	Sigma float64 `json:"sigma"`
+}
+
+// series is the moving average and variance of a pair's metric, `last` is the
+// last price of the price metric.
+type series struct {
+	count    int
+	mean     float64
+	variance float64
+	last     float64
+}
+
+// observe folds `x` into the series, see "Incremental calculation of
+// weighted mean and variance" by Tony Finch.
+func (s *series) observe(x, alpha float64) {
+	s.count++
+	if s.count == 1 {
+		s.mean = x
+		return
+	}
+
+	diff := x - s.mean
+	incr := alpha * diff
+	s.mean += incr
+	s.variance = (1 - alpha) * (s.variance + diff*incr)
+}
+
+// change is what a block changed, to be undone: the previous series, nil for
+// the ones it created, and the previous anomalies.
+type change struct {
+	series    map[string]*series
+	anomalies map[string][]byte
+}
+
+// Detector isn't safe for concurrent use.
+type Detector struct {
+	config    Config
+	series    map[string]*series
+	anomalies map[string][]byte
+	changes   map[uint64]*change
+}
+
+func New(config Config) (*Detector, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Detector{
+		config:    config,
+		series:    map[string]*series{},
+		anomalies: map[string][]byte{},
+		changes:   map[uint64]*change{},
+	}, nil
+}
+
+// Apply folds the volumes and prices of `data` into the series, or undoes the
+// block on an undo step, and returns `data` with a `Store` output holding the
+// anomalies found, `data` itself when there are none.
+func (d *Detector) Apply(data *pbsubstreams.BlockScopedData) (*pbsubstreams.BlockScopedData, error) {
+	var deltas []*pbsubstreams.StoreDelta
+	if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		deltas = d.undo(data.Clock.GetNumber())
+	} else {
+		var err error
+		if deltas, err = d.observe(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(deltas) == 0 {
+		return data, nil
+	}
+
+	out := proto.Clone(data).(*pbsubstreams.BlockScopedData)
+	out.Outputs = append(out.Outputs, &pbsubstreams.ModuleOutput{
+		Name: Store,
+		Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}},
+	})
+	return out, nil
+}
+
+func (d *Detector) observe(data *pbsubstreams.BlockScopedData) ([]*pbsubstreams.StoreDelta, error) {
+	volumes, prices, err := extract(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var observations []observation
+	if d.config.VolumeSigma > 0 {
+		for pair, volume := range volumes {
+			observations = append(observations, observation{metric: MetricVolume, pair: pair, value: volume, sigma: d.config.VolumeSigma})
+		}
+	}
+	if d.config.PriceSigma > 0 {
+		for pair, price := range prices {
+			observations = append(observations, observation{metric: MetricPrice, pair: pair, value: price, sigma: d.config.PriceSigma})
+		}
+	}
+	sort.Slice(observations, func(i, j int) bool { return observations[i].key() < observations[j].key() })
+
+	var blockChange *change
+	if data.Step == pbsubstreams.ForkStep_STEP_NEW {
+		blockChange = &change{series: map[string]*series{}, anomalies: map[string][]byte{}}
+		d.changes[data.Clock.GetNumber()] = blockChange
+		d.forget(data.Clock.GetNumber())
+	}
+
+	var deltas []*pbsubstreams.StoreDelta
+	for _, o := range observations {
+		key := o.key()
+		s, found := d.series[key]
+		if blockChange != nil {
+			if found {
+				previous := *s
+				blockChange.series[key] = &previous
+			} else {
+				blockChange.series[key] = nil
+			}
+		}
+		if !found {
+			s = &series{}
+			d.series[key] = s
+		}
+
+		value := o.value
+		if o.metric == MetricPrice {
+			last := s.last
+			s.last = o.value
+			if last <= 0 || o.value <= 0 {
+				continue
+			}
+			value = math.Log(o.value / last)
+		}
+
+		if s.count >= d.config.Warmup && s.variance > 0 {
+			stddev := math.Sqrt(s.variance)
+			if sigma := (value - s.mean) / stddev; math.Abs(sigma) >= o.sigma {
+				delta, err := d.record(blockChange, key, &Anomaly{
+					Pair:      o.pair,
+					Metric:    o.metric,
+					BlockNum:  data.Clock.GetNumber(),
+					Timestamp: data.Clock.GetTimestamp().AsTime(),
+					Value:     value,
+					Mean:      s.mean,
+					StdDev:    stddev,
+					Sigma:     sigma,
+				})
+				if err != nil {
+					return nil, err
+				}
+				deltas = append(deltas, delta)
+			}
+		}
+		s.observe(value, d.config.Alpha)
+	}
+	return deltas, nil
+}
+
+func (d *Detector) record(blockChange *change, key string, anomaly *Anomaly) (*pbsubstreams.StoreDelta, error) {
+	value, err := json.Marshal(anomaly)
+	if err != nil {
+		return nil, fmt.Errorf("encoding anomaly: %w", err)
+	}
+
+	previous, found := d.anomalies[key]
+	if blockChange != nil {
+		if _, recorded := blockChange.anomalies[key]; !recorded {
+			blockChange.anomalies[key] = previous
+		}
+	}
+	d.anomalies[key] = value
+
+	if !found {
+		return &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: key, NewValue: value}, nil
+	}
+	return &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key, OldValue: previous, NewValue: value}, nil
+}
+
+// undo restores the series and anomalies as they were before `blockNum`, it
+// returns the deltas reverting its anomalies.
+func (d *Detector) undo(blockNum uint64) []*pbsubstreams.StoreDelta {
+	blockChange, found := d.changes[blockNum]
+	if !found {
+		return nil
+	}
+	delete(d.changes, blockNum)
+
+	for key, previous := range blockChange.series {
+		if previous == nil {
+			delete(d.series, key)
+			continue
+		}
+		d.series[key] = previous
+	}
+
+	var keys []string
+	for key := range blockChange.anomalies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var deltas []*pbsubstreams.StoreDelta
+	for _, key := range keys {
+		current, previous := d.anomalies[key], blockChange.anomalies[key]
+		if previous == nil {
+			delete(d.anomalies, key)
+			deltas = append(deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_DELETE, Key: key, OldValue: current})
+			continue
+		}
+		d.anomalies[key] = previous
+		deltas = append(deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key, OldValue: current, NewValue: previous})
+	}
+	return deltas
+}
+
+// forget drops the changes of the blocks too old to be undone.
+func (d *Detector) forget(blockNum uint64) {
+	for num := range d.changes {
+		if num+undoDepth < blockNum {
+			delete(d.changes, num)
+		}
+	}
+}
+
+type observation struct {
+	metric string
+	pair   string
+	value  float64
+	sigma  float64
+}
+
+func (o observation) key() string {
+	return o.metric + ":" + o.pair
+}
+
+// extract returns the USD volume of the swaps of each pair in the block and
+// the token0 price of each pair at the end of the block.
+func extract(data *pbsubstreams.BlockScopedData) (volumes, prices map[string]float64, err error) {
+	volumes, prices = map[string]float64{}, map[string]float64{}
+
+	for _, output := range data.Outputs {
+		mapOutput := output.GetMapOutput()
+		if mapOutput == nil || (output.Name != SwapsModule && output.Name != ReservesModule) {
+			continue
+		}
+
+		msg, err := anypb.UnmarshalNew(mapOutput, proto.UnmarshalOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding %s output: %w", output.Name, err)
+		}
+
+		switch m := msg.(type) {
+		case *pbpcs.Events:
+			for _, event := range m.Events {
+				if swap := event.GetSwap(); swap != nil {
+					if usd, err := strconv.ParseFloat(swap.AmountUsd, 64); err == nil {
+						volumes[event.PairAddress] += usd
+					}
+				}
+			}
+		case *pbpcs.Reserves:
+			// in block order, the last one is the price the block ends with
+			for _, reserve := range m.Reserves {
+				if price, err := strconv.ParseFloat(reserve.Token0Price, 64); err == nil {
+					prices[reserve.PairAddress] = price
+				}
+			}
+		}
+	}
+	return volumes, prices, nil
+}
@@ -0,0 +1,98 @@
+package anomaly
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func block(t *testing.T, num uint64, step pbsubstreams.ForkStep, volume, price string) *pbsubstreams.BlockScopedData {
+	t.Helper()
+
+	events, err := anypb.New(&pbpcs.Events{Events: []*pbpcs.Event{
+		{PairAddress: "0xp", Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: volume}}},
+	}})
+	require.NoError(t, err)
+	reserves, err := anypb.New(&pbpcs.Reserves{Reserves: []*pbpcs.Reserve{
+		{PairAddress: "0xp", Token0Price: "1"},
+		{PairAddress: "0xp", Token0Price: price},
+	}})
+	require.NoError(t, err)
+
+	return &pbsubstreams.BlockScopedData{
+		Step:  step,
+		Clock: &pbsubstreams.Clock{Number: num, Timestamp: timestamppb.New(time.Unix(int64(num)*3, 0))},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: SwapsModule, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: events}},
+			{Name: ReservesModule, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: reserves}},
+		},
+	}
+}
+
+func anomalies(t *testing.T, data *pbsubstreams.BlockScopedData) map[string]*pbsubstreams.StoreDelta {
+	t.Helper()
+
+	out := map[string]*pbsubstreams.StoreDelta{}
+	for _, output := range data.Outputs {
+		if output.Name == Store {
+			for _, delta := range output.GetStoreDeltas().Deltas {
+				out[delta.Key] = delta
+			}
+		}
+	}
+	return out
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Config{Alpha: 0.1, VolumeSigma: 3}.Validate())
+	assert.Error(t, Config{Alpha: 0, VolumeSigma: 3}.Validate())
+	assert.Error(t, Config{Alpha: 1.5, VolumeSigma: 3}.Validate())
+	assert.Error(t, Config{Alpha: 0.1}.Validate())
+	assert.Error(t, Config{Alpha: 0.1, PriceSigma: -1}.Validate())
+}
+
+func TestDetector_Apply(t *testing.T) {
+	d, err := New(Config{Alpha: 0.2, VolumeSigma: 4, PriceSigma: 4, Warmup: 5})
+	require.NoError(t, err)
+
+	volumes := []string{"100", "110", "90", "105", "95", "100", "102", "98"}
+	prices := []string{"1.00", "1.01", "1.00", "1.01", "1.00", "1.01", "1.00", "1.01"}
+	for i := range volumes {
+		data := block(t, uint64(i+1), pbsubstreams.ForkStep_STEP_IRREVERSIBLE, volumes[i], prices[i])
+		out, err := d.Apply(data)
+		require.NoError(t, err)
+		assert.Same(t, data, out, "block %d", i+1)
+	}
+
+	out, err := d.Apply(block(t, 9, pbsubstreams.ForkStep_STEP_NEW, "5000", "1.00"))
+	require.NoError(t, err)
+	found := anomalies(t, out)
+	require.Len(t, found, 1)
+	require.Contains(t, found, "volume_usd:0xp")
+	assert.Equal(t, pbsubstreams.StoreDelta_CREATE, found["volume_usd:0xp"].Operation)
+
+	anomaly := &Anomaly{}
+	require.NoError(t, json.Unmarshal(found["volume_usd:0xp"].NewValue, anomaly))
+	assert.Equal(t, "0xp", anomaly.Pair)
+	assert.Equal(t, uint64(9), anomaly.BlockNum)
+	assert.Equal(t, float64(5000), anomaly.Value)
+	assert.Greater(t, anomaly.Sigma, float64(4))
+
+	// undone, the anomaly is deleted and the same block is flagged again
+	out, err = d.Apply(block(t, 9, pbsubstreams.ForkStep_STEP_UNDO, "5000", "1.00"))
+	require.NoError(t, err)
+	assert.Equal(t, pbsubstreams.StoreDelta_DELETE, anomalies(t, out)["volume_usd:0xp"].Operation)
+
+	out, err = d.Apply(block(t, 9, pbsubstreams.ForkStep_STEP_NEW, "100", "2.00"))
+	require.NoError(t, err)
+	found = anomalies(t, out)
+	require.Len(t, found, 1)
+	assert.Contains(t, found, "price:0xp")
+}
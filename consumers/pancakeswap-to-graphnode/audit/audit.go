@@ -0,0 +1,273 @@
+// Package audit compares the state of a sample of pairs, as recorded by our
+// stores, with a reference source like the PancakeSwap subgraph, at several
+// blocks. Each value differing by more than the tolerance is a discrepancy,
+// the report lists them: a confidence check for teams moving off the subgraph,
+// run again as the stores grow.
+//
+// The volumes are cumulative on both sides, they only match when the stores
+// were computed from the creation of the pairs.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"sort"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+)
+
+// Fields compared, in the order they're reported.
+const (
+	FieldReserve0     = "reserve0"
+	FieldReserve1     = "reserve1"
+	FieldVolumeToken0 = "volume_token0"
+	FieldVolumeToken1 = "volume_token1"
+	FieldVolumeUSD    = "volume_usd"
+)
+
+var fields = []string{FieldReserve0, FieldReserve1, FieldVolumeToken0, FieldVolumeToken1, FieldVolumeUSD}
+
+// Pair is the state of a pair at a block, amounts are decimals in token units.
+type Pair struct {
+	Address string
+	Token0  string
+	Token1  string
+
+	Reserve0     string
+	Reserve1     string
+	VolumeToken0 string
+	VolumeToken1 string
+	VolumeUSD    string
+}
+
+func (p *Pair) field(name string) string {
+	switch name {
+	case FieldReserve0:
+		return p.Reserve0
+	case FieldReserve1:
+		return p.Reserve1
+	case FieldVolumeToken0:
+		return p.VolumeToken0
+	case FieldVolumeToken1:
+		return p.VolumeToken1
+	case FieldVolumeUSD:
+		return p.VolumeUSD
+	}
+	panic(fmt.Errorf("unknown field %q", name))
+}
+
+// Source gives the state of pairs at past blocks.
+type Source interface {
+	// Pairs returns the state of the pairs of `addresses` once `block` is
+	// applied, by address. Pairs unknown to the source are left out.
+	Pairs(ctx context.Context, block uint64, addresses []string) (map[string]*Pair, error)
+}
+
+type Config struct {
+	// Pairs are the addresses of the pairs compared, when empty `Samples`
+	// pairs are picked at random among the ones of the stores at the first
+	// block, with `Seed`.
+	Pairs   []string
+	Samples int
+	Seed    int64
+
+	Blocks []uint64
+	// Tolerance is the relative difference allowed between two values, like
+	// 0.001 for 0.1%.
+	Tolerance float64
+}
+
+// Discrepancy is a value of a pair differing between the stores and the
+// reference, or a pair missing from one of them, `Field` being `pair` then and
+// the missing side empty.
+type Discrepancy struct {
+	Block     uint64  `json:"block"`
+	Pair      string  `json:"pair"`
+	Field     string  `json:"field"`
+	Ours      string  `json:"ours"`
+	Reference string  `json:"reference"`
+	Diff      float64 `json:"diff"`
+}
+
+type Report struct {
+	Blocks    []uint64 `json:"blocks"`
+	Pairs     []string `json:"pairs"`
+	Tolerance float64  `json:"tolerance"`
+	// Compared is the number of values compared.
+	Compared      int            `json:"compared"`
+	Discrepancies []*Discrepancy `json:"discrepancies"`
+}
+
+// Run compares the pairs of `config` between `ours` and `reference` at each
+// of its blocks.
+func Run(ctx context.Context, ours *Stores, reference Source, config Config) (*Report, error) {
+	if len(config.Blocks) == 0 {
+		return nil, fmt.Errorf("no block to audit")
+	}
+	blocks := append([]uint64(nil), config.Blocks...)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+
+	pairs := config.Pairs
+	if len(pairs) == 0 {
+		candidates, err := ours.PairAddresses(ctx, blocks[0])
+		if err != nil {
+			return nil, err
+		}
+		pairs = Sample(candidates, config.Samples, config.Seed)
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no pair to audit, the stores have no reserves at block %d", blocks[0])
+	}
+
+	report := &Report{Blocks: blocks, Pairs: pairs, Tolerance: config.Tolerance, Discrepancies: []*Discrepancy{}}
+	for _, block := range blocks {
+		ourPairs, err := ours.Pairs(ctx, block, pairs)
+		if err != nil {
+			return nil, fmt.Errorf("our pairs at block %d: %w", block, err)
+		}
+		referencePairs, err := reference.Pairs(ctx, block, pairs)
+		if err != nil {
+			return nil, fmt.Errorf("reference pairs at block %d: %w", block, err)
+		}
+
+		before := len(report.Discrepancies)
+		for _, address := range pairs {
+			report.compare(block, address, ourPairs[address], referencePairs[address])
+		}
+		zlog.Info("block audited", zap.Uint64("block", block), zap.Int("discrepancies", len(report.Discrepancies)-before))
+	}
+	return report, nil
+}
+
+func (r *Report) compare(block uint64, address string, ours, reference *Pair) {
+	if ours == nil || reference == nil {
+		if ours == nil && reference == nil {
+			return
+		}
+
+		discrepancy := &Discrepancy{Block: block, Pair: address, Field: "pair", Diff: 1}
+		if ours != nil {
+			discrepancy.Ours = "found"
+		} else {
+			discrepancy.Reference = "found"
+		}
+		r.Discrepancies = append(r.Discrepancies, discrepancy)
+		return
+	}
+
+	for _, field := range fields {
+		r.Compared++
+		ourValue, referenceValue := ours.field(field), reference.field(field)
+		diff := relativeDiff(ourValue, referenceValue)
+		if diff > r.Tolerance {
+			r.Discrepancies = append(r.Discrepancies, &Discrepancy{
+				Block:     block,
+				Pair:      address,
+				Field:     field,
+				Ours:      ourValue,
+				Reference: referenceValue,
+				Diff:      diff,
+			})
+		}
+	}
+}
+
+// relativeDiff returns |a - b| / max(|a|, |b|), 0 when both are zero, 1 when
+// one of them isn't a decimal.
+func relativeDiff(a, b string) float64 {
+	x, okA := parseDecimal(a)
+	y, okB := parseDecimal(b)
+	if !okA || !okB {
+		return 1
+	}
+
+	scale := new(big.Float).Abs(x)
+	if absY := new(big.Float).Abs(y); absY.Cmp(scale) > 0 {
+		scale = absY
+	}
+	if scale.Sign() == 0 {
+		return 0
+	}
+
+	diff := new(big.Float).Sub(x, y)
+	diff.Abs(diff)
+	out, _ := new(big.Float).Quo(diff, scale).Float64()
+	return out
+}
+
+// parseDecimal parses `value`, empty being zero.
+func parseDecimal(value string) (*big.Float, bool) {
+	if value == "" {
+		return new(big.Float), true
+	}
+	out, ok := new(big.Float).SetPrec(256).SetString(value)
+	return out, ok
+}
+
+// Sample picks `n` of `addresses` at random with `seed`, all of them when
+// there aren't more, sorted.
+func Sample(addresses []string, n int, seed int64) []string {
+	sorted := append([]string(nil), addresses...)
+	sort.Strings(sorted)
+	if n < len(sorted) {
+		random := rand.New(rand.NewSource(seed))
+		random.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+		sorted = sorted[:n]
+		sort.Strings(sorted)
+	}
+	return sorted
+}
+
+// Blocks returns `count` blocks evenly spread over [start, stop], `stop` when
+// `count` is 1.
+func Blocks(start, stop uint64, count int) []uint64 {
+	if count <= 1 || start >= stop {
+		return []uint64{stop}
+	}
+
+	out := make([]uint64, 0, count)
+	step := float64(stop-start) / float64(count-1)
+	for i := 0; i < count; i++ {
+		block := start + uint64(float64(i)*step+0.5)
+		if len(out) > 0 && out[len(out)-1] == block {
+			continue
+		}
+		out = append(out, block)
+	}
+	out[len(out)-1] = stop
+	return out
+}
+
+// WriteText writes the report as a table followed by a summary line.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "BLOCK\tPAIR\tFIELD\tOURS\tREFERENCE\tDIFF\n")
+	for _, d := range r.Discrepancies {
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\t%s\t%.4f%%\n", d.Block, d.Pair, d.Field, orDash(d.Ours), orDash(d.Reference), d.Diff*100)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d pairs at %d blocks, %d values compared, %d discrepancies above %g%%\n",
+		len(r.Pairs), len(r.Blocks), r.Compared, len(r.Discrepancies), r.Tolerance*100)
+	return err
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func set(key, value string) *pbsubstreams.StoreDelta {
+	return &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key, NewValue: []byte(value)}
+}
+
+func newStores(t *testing.T) *Stores {
+	t.Helper()
+	ctx := context.Background()
+	storeURL := "file://" + filepath.Join(t.TempDir(), "log")
+
+	s, err := deltalog.New(&deltalog.Config{StoreURL: storeURL, SegmentSize: 100})
+	require.NoError(t, err)
+	block := func(num uint64, reserves, volumes []*pbsubstreams.StoreDelta) *pbsubstreams.BlockScopedData {
+		return &pbsubstreams.BlockScopedData{
+			Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+			Clock: &pbsubstreams.Clock{Number: num},
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_reserves", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: reserves}}},
+				{Name: "store_volumes", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: volumes}}},
+			},
+		}
+	}
+	require.NoError(t, s.Write(ctx, block(10,
+		[]*pbsubstreams.StoreDelta{
+			set("reserve:0xaa:0x01:reserve0", "100"),
+			set("reserve:0xaa:0x02:reserve1", "200"),
+			set("reserve:0xbb:0x01:reserve0", "5"),
+			set("reserve:0xbb:0x03:reserve1", "7"),
+			set("price:0xaa:0x01:token0", "2"),
+		},
+		nil,
+	)))
+	require.NoError(t, s.Write(ctx, block(20,
+		[]*pbsubstreams.StoreDelta{set("reserve:0xaa:0x01:reserve0", "110")},
+		[]*pbsubstreams.StoreDelta{
+			set("pair:0xaa:token0", "10"),
+			set("pair:0xaa:token1", "20"),
+			set("pair:0xaa:usd", "40"),
+		},
+	)))
+	require.NoError(t, s.Close())
+
+	reader, err := deltalog.NewReader(storeURL)
+	require.NoError(t, err)
+	return NewStores(reader, "")
+}
+
+func TestStores(t *testing.T) {
+	ctx := context.Background()
+	stores := newStores(t)
+
+	addresses, err := stores.PairAddresses(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xaa", "0xbb"}, addresses)
+
+	pairs, err := stores.Pairs(ctx, 20, []string{"0xaa"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Pair{"0xaa": {
+		Address:      "0xaa",
+		Token0:       "0x01",
+		Token1:       "0x02",
+		Reserve0:     "110",
+		Reserve1:     "200",
+		VolumeToken0: "10",
+		VolumeToken1: "20",
+		VolumeUSD:    "40",
+	}}, pairs)
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	var blocks []uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Variables struct {
+				Block uint64   `json:"block"`
+				IDs   []string `json:"ids"`
+			} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		blocks = append(blocks, request.Variables.Block)
+		assert.Equal(t, []string{"0xaa", "0xbb"}, request.Variables.IDs)
+
+		pairs := []map[string]interface{}{{
+			"id":           "0xaa",
+			"token0":       map[string]string{"id": "0x01"},
+			"token1":       map[string]string{"id": "0x02"},
+			"reserve0":     "100.00001",
+			"reserve1":     "200",
+			"volumeToken0": "0",
+			"volumeToken1": "0",
+			"volumeUSD":    "0",
+		}}
+		if request.Variables.Block == 20 {
+			pairs[0]["reserve0"], pairs[0]["volumeToken0"], pairs[0]["volumeToken1"], pairs[0]["volumeUSD"] = "110", "10", "25", "40"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"pairs": pairs}})
+	}))
+	defer server.Close()
+
+	report, err := Run(ctx, newStores(t), NewSubgraph(server.Client(), server.URL), Config{
+		Samples:   10,
+		Blocks:    []uint64{20, 10},
+		Tolerance: 0.001,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{10, 20}, blocks)
+	assert.Equal(t, []string{"0xaa", "0xbb"}, report.Pairs)
+	assert.Equal(t, 10, report.Compared)
+
+	assert.Equal(t, []*Discrepancy{
+		{Block: 10, Pair: "0xbb", Field: "pair", Ours: "found", Diff: 1},
+		{Block: 20, Pair: "0xaa", Field: FieldVolumeToken1, Ours: "20", Reference: "25", Diff: 0.2},
+		{Block: 20, Pair: "0xbb", Field: "pair", Ours: "found", Diff: 1},
+	}, report.Discrepancies)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, report.WriteText(out))
+	assert.Contains(t, out.String(), "#20    0xaa  volume_token1  20     25         20.0000%")
+	assert.Contains(t, out.String(), "2 pairs at 2 blocks, 10 values compared, 3 discrepancies above 0.1%")
+}
+
+func TestRun_SubgraphErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"Failed to decode block.number value: subgraph has only indexed up to block 15"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := Run(context.Background(), newStores(t), NewSubgraph(server.Client(), server.URL), Config{Pairs: []string{"0xaa"}, Blocks: []uint64{20}})
+	assert.EqualError(t, err, "reference pairs at block 20: query subgraph: Failed to decode block.number value: subgraph has only indexed up to block 15")
+}
+
+func TestBlocks(t *testing.T) {
+	assert.Equal(t, []uint64{100, 125, 150, 175, 200}, Blocks(100, 200, 5))
+	assert.Equal(t, []uint64{200}, Blocks(100, 200, 1))
+	assert.Equal(t, []uint64{10, 11, 12}, Blocks(10, 12, 10))
+}
+
+func TestSample(t *testing.T) {
+	addresses := []string{"0xdd", "0xaa", "0xcc", "0xbb"}
+	assert.Equal(t, []string{"0xaa", "0xbb", "0xcc", "0xdd"}, Sample(addresses, 10, 1))
+
+	sample := Sample(addresses, 2, 1)
+	assert.Len(t, sample, 2)
+	assert.Equal(t, sample, Sample(addresses, 2, 1), "same seed, same sample")
+}
@@ -0,0 +1,7 @@
+package audit
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.audit", "github.com/streamingfast/substream-pancakeswap/audit")
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"context"
+	"sort"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	"github.com/streamingfast/substream-pancakeswap/state"
+)
+
+// Stores reads the pairs from the `store_reserves` and `store_volumes` deltas
+// persisted by the `deltalog` output.
+type Stores struct {
+	reader    *deltalog.Reader
+	namespace string
+}
+
+// NewStores reads the stores of `namespace` in the log of `reader`, the root
+// namespace when empty.
+func NewStores(reader *deltalog.Reader, namespace string) *Stores {
+	return &Stores{reader: reader, namespace: namespace}
+}
+
+// PairAddresses returns the pairs having reserves once `block` is applied,
+// sorted.
+func (s *Stores) PairAddresses(ctx context.Context, block uint64) ([]string, error) {
+	reserves, err := s.values(ctx, "reserves", block)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for key := range reserves {
+		if address, _, _, ok := parseReserveKey(key); ok {
+			seen[address] = true
+		}
+	}
+
+	out := make([]string, 0, len(seen))
+	for address := range seen {
+		out = append(out, address)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Pairs returns the pairs having reserves once `block` is applied, with their
+// `reserve:<pair>:<token>:reserve0|1` and `pair:<pair>:token0|token1|usd`
+// values. A volume without swaps yet is zero.
+func (s *Stores) Pairs(ctx context.Context, block uint64, addresses []string) (map[string]*Pair, error) {
+	reserves, err := s.values(ctx, "reserves", block)
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := s.values(ctx, "volumes", block)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		wanted[address] = true
+	}
+
+	out := map[string]*Pair{}
+	for key, value := range reserves {
+		address, token, field, ok := parseReserveKey(key)
+		if !ok || !wanted[address] {
+			continue
+		}
+
+		pair := out[address]
+		if pair == nil {
+			pair = &Pair{
+				Address:      address,
+				VolumeToken0: orZero(volumes[keys.Key("pair", address, "token0").String()]),
+				VolumeToken1: orZero(volumes[keys.Key("pair", address, "token1").String()]),
+				VolumeUSD:    orZero(volumes[keys.Key("pair", address, "usd").String()]),
+			}
+			out[address] = pair
+		}
+		if field == "reserve0" {
+			pair.Token0, pair.Reserve0 = token, value
+		} else {
+			pair.Token1, pair.Reserve1 = token, value
+		}
+	}
+	return out, nil
+}
+
+func (s *Stores) values(ctx context.Context, store string, block uint64) (map[string]string, error) {
+	result, err := state.Get(ctx, s.reader, state.Query{Store: deltalog.JoinTopic(s.namespace, store), Block: block})
+	if err != nil {
+		return nil, err
+	}
+	return result.Values, nil
+}
+
+// keys reads and writes the addresses of the keys as the strings they are in
+// the log, the pairs being compared as written.
+var keys = sdk.NewKeyCodec(nil)
+
+// parseReserveKey splits a `reserve:<pair>:<token>:<reserve0|reserve1>` key.
+func parseReserveKey(key string) (address, token, field string, ok bool) {
+	k, err := keys.Parse(key)
+	if err != nil || !k.Is("reserve", 4) {
+		return "", "", "", false
+	}
+	if field = k.Segment(3); field != "reserve0" && field != "reserve1" {
+		return "", "", "", false
+	}
+	return k.Segment(1), k.Segment(2), field, true
+}
+
+func orZero(value string) string {
+	if value == "" {
+		return "0"
+	}
+	return value
+}
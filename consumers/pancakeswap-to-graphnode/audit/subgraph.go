@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// subgraphBatch is the number of pairs queried at once, the subgraph returns
+// at most 1000 entities per query.
+const subgraphBatch = 100
+
+const subgraphQuery = `query pairs($block: Int!, $ids: [ID!]!) {
+  pairs(first: 1000, block: {number: $block}, where: {id_in: $ids}) {
+    id
+    token0 { id }
+    token1 { id }
+    reserve0
+    reserve1
+    volumeToken0
+    volumeToken1
+    volumeUSD
+  }
+}`
+
+// Subgraph reads the pairs from a PancakeSwap exchange subgraph, like
+// `https://api.thegraph.com/subgraphs/name/pancakeswap/exchange`, through
+// time-travel queries: the subgraph must have indexed the blocks audited and
+// not pruned them.
+type Subgraph struct {
+	client *http.Client
+	url    string
+}
+
+func NewSubgraph(client *http.Client, url string) *Subgraph {
+	return &Subgraph{client: client, url: url}
+}
+
+type subgraphPair struct {
+	ID     string `json:"id"`
+	Token0 struct {
+		ID string `json:"id"`
+	} `json:"token0"`
+	Token1 struct {
+		ID string `json:"id"`
+	} `json:"token1"`
+	Reserve0     string `json:"reserve0"`
+	Reserve1     string `json:"reserve1"`
+	VolumeToken0 string `json:"volumeToken0"`
+	VolumeToken1 string `json:"volumeToken1"`
+	VolumeUSD    string `json:"volumeUSD"`
+}
+
+type subgraphResponse struct {
+	Data struct {
+		Pairs []*subgraphPair `json:"pairs"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (s *Subgraph) Pairs(ctx context.Context, block uint64, addresses []string) (map[string]*Pair, error) {
+	out := make(map[string]*Pair, len(addresses))
+	for start := 0; start < len(addresses); start += subgraphBatch {
+		end := start + subgraphBatch
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		ids := make([]string, 0, end-start)
+		for _, address := range addresses[start:end] {
+			// entity IDs are lowercase
+			ids = append(ids, strings.ToLower(address))
+		}
+
+		pairs, err := s.query(ctx, block, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			out[pair.ID] = &Pair{
+				Address:      pair.ID,
+				Token0:       pair.Token0.ID,
+				Token1:       pair.Token1.ID,
+				Reserve0:     pair.Reserve0,
+				Reserve1:     pair.Reserve1,
+				VolumeToken0: pair.VolumeToken0,
+				VolumeToken1: pair.VolumeToken1,
+				VolumeUSD:    pair.VolumeUSD,
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *Subgraph) query(ctx context.Context, block uint64, ids []string) ([]*subgraphPair, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     subgraphQuery,
+		"variables": map[string]interface{}{"block": block, "ids": ids},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query subgraph: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query subgraph: %s", resp.Status)
+	}
+
+	var out subgraphResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("query subgraph: decode response: %w", err)
+	}
+	if len(out.Errors) > 0 {
+		return nil, fmt.Errorf("query subgraph: %s", out.Errors[0].Message)
+	}
+	return out.Data.Pairs, nil
+}
@@ -0,0 +1,52 @@
+package bench
+
+import (
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	"github.com/stretchr/testify/require"
+)
+
+func generateBlocks(b *testing.B, count int) []*pbeth.Block {
+	g, err := NewGenerator(testConfig())
+	require.NoError(b, err)
+
+	blocks := make([]*pbeth.Block, count)
+	for i := range blocks {
+		blocks[i] = g.Next()
+	}
+	return blocks
+}
+
+func BenchmarkReadBlocks(b *testing.B) {
+	dir := b.TempDir()
+	blocks := generateBlocks(b, 500)
+	i := 0
+	require.NoError(b, WriteBlocks(dir, uint64(len(blocks)), func() *pbeth.Block {
+		i++
+		return blocks[i-1]
+	}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		require.NoError(b, ReadBlocks(dir, 0, 0, func(block *pbeth.Block) error { return nil }))
+	}
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	blocks := generateBlocks(b, 500)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		pipeline, err := modules.NewPipeline(ReservesModule, VolumesModule)
+		require.NoError(b, err)
+		for _, block := range blocks {
+			if _, err := pipeline.ProcessBlock(block); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package bench
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/streamingfast/dbin"
+	pbbstream "github.com/streamingfast/pbgo/sf/bstream/v1"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// BundleSize is the number of blocks per file, files are named after their
+// first block number like merged blocks files are.
+const BundleSize = 100
+
+const bundleSuffix = ".dbin"
+
+// BundleName is the name of the file of the bundle starting at `base`.
+func BundleName(base uint64) string {
+	return fmt.Sprintf("%010d%s", base, bundleSuffix)
+}
+
+// WriteBlocks writes `count` blocks produced by `next` into bundles in `dir`.
+// Bundles use the merged blocks format, a dbin file of `sf.bstream.v1.Block`
+// wrapping the Ethereum blocks, uncompressed.
+func WriteBlocks(dir string, count uint64, next func() *pbeth.Block) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create blocks directory %q: %w", dir, err)
+	}
+
+	var file *os.File
+	var writer *dbin.Writer
+	closeBundle := func() error {
+		if file == nil {
+			return nil
+		}
+		err := file.Close()
+		file = nil
+		return err
+	}
+
+	for i := uint64(0); i < count; i++ {
+		block := next()
+
+		if file == nil || block.Number%BundleSize == 0 {
+			if err := closeBundle(); err != nil {
+				return fmt.Errorf("close bundle: %w", err)
+			}
+
+			base := block.Number - block.Number%BundleSize
+			path := filepath.Join(dir, BundleName(base))
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("create bundle %q: %w", path, err)
+			}
+			file = f
+			zlog.Debug("writing bundle", zap.String("path", path))
+
+			writer = dbin.NewWriter(file)
+			if err := writer.WriteHeader("ETH", 1); err != nil {
+				closeBundle()
+				return fmt.Errorf("write bundle header %q: %w", path, err)
+			}
+		}
+
+		content, err := encodeBlock(block)
+		if err != nil {
+			closeBundle()
+			return err
+		}
+
+		if err := writer.WriteMessage(content); err != nil {
+			closeBundle()
+			return fmt.Errorf("write block %d: %w", block.Number, err)
+		}
+	}
+
+	return closeBundle()
+}
+
+func encodeBlock(block *pbeth.Block) ([]byte, error) {
+	payload, err := proto.Marshal(block)
+	if err != nil {
+		return nil, fmt.Errorf("marshal block %d: %w", block.Number, err)
+	}
+
+	return proto.Marshal(&pbbstream.Block{
+		Number:         block.Number,
+		Id:             hex.EncodeToString(block.Hash),
+		PreviousId:     hex.EncodeToString(block.GetHeader().GetParentHash()),
+		Timestamp:      block.GetHeader().GetTimestamp(),
+		LibNum:         block.Number,
+		PayloadKind:    pbbstream.Protocol_ETH,
+		PayloadVersion: 1,
+		PayloadBuffer:  payload,
+	})
+}
+
+// ReadBlocks calls `handle` with the blocks of `dir` in [start, stop[, in
+// order, all of them when `stop` is 0.
+func ReadBlocks(dir string, start, stop uint64, handle func(block *pbeth.Block) error) error {
+	bundles, err := listBundles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, base := range bundles {
+		if base+BundleSize <= start || (stop != 0 && base >= stop) {
+			continue
+		}
+
+		if err := readBundle(filepath.Join(dir, BundleName(base)), start, stop, handle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listBundles(dir string) ([]uint64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read blocks directory %q: %w", dir, err)
+	}
+
+	var bundles []uint64
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), bundleSuffix) {
+			continue
+		}
+
+		base, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), bundleSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		bundles = append(bundles, base)
+	}
+
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no blocks bundle found in %q", dir)
+	}
+
+	sort.Slice(bundles, func(i, j int) bool { return bundles[i] < bundles[j] })
+	return bundles, nil
+}
+
+func readBundle(path string, start, stop uint64, handle func(block *pbeth.Block) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open bundle %q: %w", path, err)
+	}
+	defer file.Close()
+
+	reader := bundleReaders.Get().(*bufio.Reader)
+	reader.Reset(file)
+	defer bundleReaders.Put(reader)
+
+	contentType, _, err := dbin.NewReader(reader).ReadHeader()
+	if err != nil {
+		return fmt.Errorf("read bundle header %q: %w", path, err)
+	}
+	if contentType != "ETH" {
+		return fmt.Errorf("bundle %q holds %q blocks, expected ETH", path, contentType)
+	}
+
+	// Messages are read in a pooled buffer, the payload is borrowed from it
+	// instead of unmarshalling the whole `sf.bstream.v1.Block`, only the
+	// Ethereum block is decoded, which copies what it keeps.
+	bufPtr := messageBuffers.Get().(*[]byte)
+	defer messageBuffers.Put(bufPtr)
+
+	var lengthBytes [4]byte
+	for {
+		if _, err := io.ReadFull(reader, lengthBytes[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("read bundle %q: %w", path, err)
+		}
+
+		length := int(binary.BigEndian.Uint32(lengthBytes[:]))
+		if cap(*bufPtr) < length {
+			*bufPtr = make([]byte, length)
+		}
+		content := (*bufPtr)[:length]
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return fmt.Errorf("read bundle %q: %w", path, err)
+		}
+
+		num, payload, err := decodeWrapper(content)
+		if err != nil {
+			return fmt.Errorf("decode block in %q: %w", path, err)
+		}
+
+		if num < start || (stop != 0 && num >= stop) {
+			continue
+		}
+
+		block := &pbeth.Block{}
+		if err := proto.Unmarshal(payload, block); err != nil {
+			return fmt.Errorf("unmarshal block %d payload: %w", num, err)
+		}
+
+		if err := handle(block); err != nil {
+			return err
+		}
+	}
+}
+
+var bundleReaders = sync.Pool{
+	New: func() interface{} {
+		return bufio.NewReaderSize(nil, 1024*1024)
+	},
+}
+
+var messageBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 256*1024)
+		return &buf
+	},
+}
+
+const (
+	wrapperNumberField  = 1
+	wrapperPayloadField = 8
+)
+
+// decodeWrapper extracts the number and payload of a `sf.bstream.v1.Block`,
+// the payload is a sub-slice of `content`.
+func decodeWrapper(content []byte) (num uint64, payload []byte, err error) {
+	for len(content) > 0 {
+		field, wireType, n := protowire.ConsumeTag(content)
+		if n < 0 {
+			return 0, nil, protowire.ParseError(n)
+		}
+		content = content[n:]
+
+		switch {
+		case field == wrapperNumberField && wireType == protowire.VarintType:
+			num, n = protowire.ConsumeVarint(content)
+		case field == wrapperPayloadField && wireType == protowire.BytesType:
+			payload, n = protowire.ConsumeBytes(content)
+		default:
+			n = protowire.ConsumeFieldValue(field, wireType, content)
+		}
+		if n < 0 {
+			return 0, nil, protowire.ParseError(n)
+		}
+		content = content[n:]
+	}
+
+	return num, payload, nil
+}
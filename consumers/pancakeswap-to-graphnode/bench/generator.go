@@ -0,0 +1,248 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"time"
+
+	eth "github.com/streamingfast/eth-go"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	sdktesting "github.com/streamingfast/substream-pancakeswap/sdk/testing"
+)
+
+const (
+	FactoryAddress = "0xca143ce32fe78f1f7019d7d551a6402fc5350c73"
+	WBNBAddress    = "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c"
+)
+
+var (
+	PairCreatedTopic = eth.Keccak256([]byte("PairCreated(address,address,address,uint256)"))
+	MintTopic        = eth.Keccak256([]byte("Mint(address,uint256,uint256)"))
+	SyncTopic        = eth.Keccak256([]byte("Sync(uint112,uint112)"))
+	SwapTopic        = eth.Keccak256([]byte("Swap(address,uint256,uint256,uint256,uint256,address)"))
+)
+
+// Distribution is how swaps are spread over the existing pairs.
+type Distribution string
+
+const (
+	// Uniform picks every pair with the same probability.
+	Uniform Distribution = "uniform"
+	// Zipf concentrates the activity on the oldest pairs, a few pairs get most
+	// of the swaps like on mainnet.
+	Zipf Distribution = "zipf"
+)
+
+type GeneratorConfig struct {
+	StartBlock uint64
+	BlockTime  time.Duration
+
+	// InitialPairs are created in the first block so swaps have pairs to hit.
+	InitialPairs   int
+	PairsPerSecond float64
+	SwapsPerSecond float64
+
+	Distribution Distribution
+	// MaxSwapSize is the largest share of the input reserve a swap moves, swap
+	// sizes are log-uniform between a millionth of it and this value.
+	MaxSwapSize float64
+
+	Seed int64
+}
+
+func (c GeneratorConfig) Validate() error {
+	if c.BlockTime <= 0 {
+		return fmt.Errorf("block time must be positive")
+	}
+	if c.InitialPairs <= 0 && c.SwapsPerSecond > 0 && c.PairsPerSecond <= 0 {
+		return fmt.Errorf("swaps require pairs, set initial pairs or pairs per second")
+	}
+	if c.PairsPerSecond < 0 || c.SwapsPerSecond < 0 {
+		return fmt.Errorf("rates must not be negative")
+	}
+	if c.MaxSwapSize <= 0 || c.MaxSwapSize >= 1 {
+		return fmt.Errorf("max swap size must be in ]0, 1[, got %v", c.MaxSwapSize)
+	}
+	switch c.Distribution {
+	case Uniform, Zipf:
+	default:
+		return fmt.Errorf("unknown distribution %q, valid values are %q and %q", c.Distribution, Uniform, Zipf)
+	}
+	return nil
+}
+
+// Generator synthesizes PancakeSwap blocks: pairs created by the v2 factory
+// against WBNB, each seeded with liquidity, then swapped against with Sync and
+// Swap logs that follow the constant product of the pair. The same config
+// always produces the same blocks.
+type Generator struct {
+	config GeneratorConfig
+	rand   *rand.Rand
+	zipf   *rand.Zipf
+	next   uint64
+	start  time.Time
+	pairs  []*pair
+}
+
+type pair struct {
+	address  string
+	token    string
+	reserve0 *big.Int
+	reserve1 *big.Int
+}
+
+var genesis = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func NewGenerator(config GeneratorConfig) (*Generator, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Generator{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)),
+		next:   config.StartBlock,
+		start:  genesis.Add(time.Duration(config.StartBlock) * config.BlockTime),
+	}, nil
+}
+
+// Next generates the next block.
+func (g *Generator) Next() *pbeth.Block {
+	num := g.next
+	g.next++
+
+	builder := sdktesting.NewBlock(num).At(g.start.Add(time.Duration(num-g.config.StartBlock) * g.config.BlockTime))
+
+	pairCount := g.count(g.config.PairsPerSecond)
+	if num == g.config.StartBlock {
+		pairCount += g.config.InitialPairs
+	}
+	for i := 0; i < pairCount; i++ {
+		g.createPair(builder)
+	}
+
+	if len(g.pairs) > 0 {
+		for i := g.count(g.config.SwapsPerSecond); i > 0; i-- {
+			g.swap(builder, g.pickPair())
+		}
+	}
+
+	return builder.Build()
+}
+
+// count draws the number of events of a block for a per second `rate`, the
+// fractional part being the probability of an extra event.
+func (g *Generator) count(rate float64) int {
+	expected := rate * g.config.BlockTime.Seconds()
+	count := int(expected)
+	if g.rand.Float64() < expected-float64(count) {
+		count++
+	}
+	return count
+}
+
+func (g *Generator) createPair(builder *sdktesting.BlockBuilder) {
+	index := len(g.pairs)
+	p := &pair{
+		address:  derivedAddress("pair", index),
+		token:    derivedAddress("token", index),
+		reserve0: g.liquidity(),
+		reserve1: g.liquidity(),
+	}
+	g.pairs = append(g.pairs, p)
+	g.zipf = nil
+
+	token0, token1 := p.token, WBNBAddress
+	if token1 < token0 {
+		token0, token1 = token1, token0
+	}
+
+	sender := derivedAddress("user", g.rand.Intn(1000))
+	builder.
+		Trx(sender, FactoryAddress).
+		Log(FactoryAddress, words(addressWord(p.address), big.NewInt(int64(index+1))), PairCreatedTopic, addressWord(token0), addressWord(token1)).
+		Log(p.address, words(p.reserve0, p.reserve1), SyncTopic).
+		Log(p.address, words(p.reserve0, p.reserve1), MintTopic, addressWord(sender))
+}
+
+func (g *Generator) pickPair() *pair {
+	if g.config.Distribution == Uniform || len(g.pairs) == 1 {
+		return g.pairs[g.rand.Intn(len(g.pairs))]
+	}
+
+	if g.zipf == nil {
+		g.zipf = rand.NewZipf(g.rand, 1.1, 1, uint64(len(g.pairs)-1))
+	}
+	return g.pairs[g.zipf.Uint64()]
+}
+
+func (g *Generator) swap(builder *sdktesting.BlockBuilder, p *pair) {
+	zeroForOne := g.rand.Intn(2) == 0
+	reserveIn, reserveOut := p.reserve0, p.reserve1
+	if !zeroForOne {
+		reserveIn, reserveOut = p.reserve1, p.reserve0
+	}
+
+	// log-uniform share of the input reserve
+	share := math.Exp(math.Log(1e-6) + g.rand.Float64()*(math.Log(g.config.MaxSwapSize)-math.Log(1e-6)))
+	amountIn, _ := new(big.Float).Mul(new(big.Float).SetInt(reserveIn), big.NewFloat(share)).Int(nil)
+	if amountIn.Sign() == 0 {
+		amountIn.SetInt64(1)
+	}
+
+	// amountOut = amountIn * 9975 * reserveOut / (reserveIn * 10000 + amountIn * 9975)
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(9975))
+	amountOut := new(big.Int).Mul(amountInWithFee, reserveOut)
+	amountOut.Div(amountOut, new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(10000)), amountInWithFee))
+
+	reserveIn.Add(reserveIn, amountIn)
+	reserveOut.Sub(reserveOut, amountOut)
+
+	zero := big.NewInt(0)
+	amount0In, amount1In, amount0Out, amount1Out := amountIn, zero, zero, amountOut
+	if !zeroForOne {
+		amount0In, amount1In, amount0Out, amount1Out = zero, amountIn, amountOut, zero
+	}
+
+	trader := derivedAddress("user", g.rand.Intn(1000))
+	builder.
+		Trx(trader, p.address).
+		Log(p.address, words(p.reserve0, p.reserve1), SyncTopic).
+		Log(p.address, words(amount0In, amount1In, amount0Out, amount1Out), SwapTopic, addressWord(trader), addressWord(trader))
+}
+
+// liquidity draws an initial reserve between 1e18 and 1e24 wei.
+func (g *Generator) liquidity() *big.Int {
+	exp := 18 + g.rand.Intn(7)
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+func derivedAddress(kind string, index int) string {
+	return eth.Address(eth.Keccak256([]byte(fmt.Sprintf("%s:%d", kind, index)))[12:]).Pretty()
+}
+
+func addressWord(address string) []byte {
+	word := make([]byte, 32)
+	copy(word[12:], eth.MustNewAddress(address))
+	return word
+}
+
+// words ABI encodes static values, integers or already encoded 32 bytes words.
+func words(values ...interface{}) []byte {
+	out := make([]byte, 0, 32*len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case []byte:
+			out = append(out, v...)
+		case *big.Int:
+			word := make([]byte, 32)
+			v.FillBytes(word)
+			out = append(out, word...)
+		default:
+			panic(fmt.Sprintf("unsupported value type %T", value))
+		}
+	}
+	return out
+}
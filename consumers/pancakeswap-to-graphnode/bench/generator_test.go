@@ -0,0 +1,138 @@
+package bench
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func testConfig() GeneratorConfig {
+	return GeneratorConfig{
+		StartBlock:     250,
+		BlockTime:      3 * time.Second,
+		InitialPairs:   5,
+		PairsPerSecond: 0.1,
+		SwapsPerSecond: 4,
+		Distribution:   Zipf,
+		MaxSwapSize:    0.05,
+		Seed:           42,
+	}
+}
+
+func countLogs(block *pbeth.Block, topic []byte) (count int) {
+	for _, trx := range block.TransactionTraces {
+		for _, log := range trx.Receipt.Logs {
+			if bytes.Equal(log.Topics[0], topic) {
+				count++
+			}
+		}
+	}
+	return
+}
+
+func TestGenerator(t *testing.T) {
+	g, err := NewGenerator(testConfig())
+	require.NoError(t, err)
+
+	var swaps, pairs int
+	reserves := map[string][2]*big.Int{}
+	for i := 0; i < 500; i++ {
+		block := g.Next()
+		assert.Equal(t, uint64(250+i), block.Number)
+		swaps += countLogs(block, SwapTopic)
+		pairs += countLogs(block, PairCreatedTopic)
+
+		for _, trx := range block.TransactionTraces {
+			for _, log := range trx.Receipt.Logs {
+				if bytes.Equal(log.Topics[0], SyncTopic) {
+					reserves[string(log.Address)] = [2]*big.Int{new(big.Int).SetBytes(log.Data[:32]), new(big.Int).SetBytes(log.Data[32:])}
+				}
+			}
+		}
+	}
+
+	// 500 blocks of 3 seconds
+	assert.InDelta(t, 6000, swaps, 300)
+	assert.InDelta(t, 5+150, pairs, 30)
+	assert.Len(t, reserves, pairs)
+	for _, reserve := range reserves {
+		assert.Equal(t, 1, reserve[0].Sign())
+		assert.Equal(t, 1, reserve[1].Sign())
+	}
+}
+
+func TestGenerator_Deterministic(t *testing.T) {
+	a, err := NewGenerator(testConfig())
+	require.NoError(t, err)
+	b, err := NewGenerator(testConfig())
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		require.True(t, proto.Equal(a.Next(), b.Next()))
+	}
+}
+
+func TestGeneratorConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *GeneratorConfig)
+	}{
+		{"no pairs", func(c *GeneratorConfig) { c.InitialPairs, c.PairsPerSecond = 0, 0 }},
+		{"negative rate", func(c *GeneratorConfig) { c.SwapsPerSecond = -1 }},
+		{"swap size", func(c *GeneratorConfig) { c.MaxSwapSize = 1 }},
+		{"distribution", func(c *GeneratorConfig) { c.Distribution = "normal" }},
+		{"block time", func(c *GeneratorConfig) { c.BlockTime = 0 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := testConfig()
+			test.modify(&config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
+
+func TestWriteReadBlocks(t *testing.T) {
+	dir := t.TempDir()
+	g, err := NewGenerator(testConfig())
+	require.NoError(t, err)
+
+	var written []*pbeth.Block
+	require.NoError(t, WriteBlocks(dir, 230, func() *pbeth.Block {
+		block := g.Next()
+		written = append(written, block)
+		return block
+	}))
+
+	tests := []struct {
+		name        string
+		start, stop uint64
+		expected    []*pbeth.Block
+	}{
+		{"all", 0, 0, written},
+		{"range across bundles", 290, 310, written[40:60]},
+		{"open ended", 470, 0, written[220:]},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var read []*pbeth.Block
+			require.NoError(t, ReadBlocks(dir, test.start, test.stop, func(block *pbeth.Block) error {
+				read = append(read, block)
+				return nil
+			}))
+
+			require.Len(t, read, len(test.expected))
+			for i := range read {
+				assert.True(t, proto.Equal(test.expected[i], read[i]), "block %d", read[i].Number)
+			}
+		})
+	}
+}
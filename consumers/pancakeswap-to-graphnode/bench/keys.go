@@ -0,0 +1,46 @@
+package bench
+
+import (
+	"fmt"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sdk/evm"
+)
+
+// keys writes the addresses of the benchmark stores keys in hex without the
+// `0x` prefix, as they always were.
+var keys = sdk.NewKeyCodec(evm.BareHexAddresses)
+
+// PairKey is the key of a pair in the pairs store, `pair:<pair>`, its value
+// being `<token0>:<token1>`.
+func PairKey(pair []byte) string {
+	return keys.Key("pair", sdk.Address(pair)).String()
+}
+
+// ParsePairKey returns the pair address of a key of the pairs store.
+func ParsePairKey(key string) ([]byte, error) {
+	k, err := keys.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+	if !k.Is("pair", 2) {
+		return nil, fmt.Errorf("invalid pair key %q", key)
+	}
+	return k.Address(1)
+}
+
+// ReserveKey is the key of the reserve of the token `index`, 0 or 1, of a pair
+// in the reserves store, `reserve<index>:<pair>`.
+func ReserveKey(index int, pair []byte) string {
+	return keys.Key(fmt.Sprintf("reserve%d", index), sdk.Address(pair)).String()
+}
+
+// VolumeKey is the key of the volume of the token `index`, 0 or 1, of a pair
+// in the volumes store, `volume<index>:<pair>`.
+func VolumeKey(index int, pair []byte) string {
+	return keys.Key(fmt.Sprintf("volume%d", index), sdk.Address(pair)).String()
+}
+
+func pairTokens(token0, token1 []byte) []byte {
+	return []byte(keys.Key(sdk.Address(token0), sdk.Address(token1)).String())
+}
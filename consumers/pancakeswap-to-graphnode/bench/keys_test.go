@@ -0,0 +1,23 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	pair := mustDecodeAddress("0x0eD7e52944161450477ee417DE9Cd3a859b14fD0")
+
+	assert.Equal(t, "pair:0ed7e52944161450477ee417de9cd3a859b14fd0", PairKey(pair))
+	assert.Equal(t, "reserve1:0ed7e52944161450477ee417de9cd3a859b14fd0", ReserveKey(1, pair))
+	assert.Equal(t, "volume0:0ed7e52944161450477ee417de9cd3a859b14fd0", VolumeKey(0, pair))
+
+	parsed, err := ParsePairKey(PairKey(pair))
+	require.NoError(t, err)
+	assert.Equal(t, pair, parsed)
+
+	_, err = ParsePairKey(ReserveKey(0, pair))
+	assert.EqualError(t, err, `invalid pair key "reserve0:0ed7e52944161450477ee417de9cd3a859b14fd0"`)
+}
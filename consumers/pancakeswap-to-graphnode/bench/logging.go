@@ -0,0 +1,7 @@
+package bench
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.bench", "github.com/streamingfast/substream-pancakeswap/bench")
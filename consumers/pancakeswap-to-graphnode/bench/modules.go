@@ -0,0 +1,128 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// The benchmark modules mirror the shape of the PancakeSwap substreams, a pairs
+// store fed by the factory, then reserves and volumes of the known pairs, so
+// the pipeline does the same kind of work as in production.
+const (
+	PairsModule    = "bench_pairs"
+	ReservesModule = "bench_reserves"
+	VolumesModule  = "bench_volumes"
+)
+
+func init() {
+	modules.Register(&modules.Module{
+		Name:  PairsModule,
+		Map:   mapPairs,
+		Store: storePairs,
+	})
+	modules.Register(&modules.Module{
+		Name:   ReservesModule,
+		Inputs: []modules.Input{{Module: PairsModule, Mode: modules.InputGet}},
+		Map:    mapPairLogs(SyncTopic),
+		Store:  storeReserves,
+	})
+	modules.Register(&modules.Module{
+		Name:   VolumesModule,
+		Inputs: []modules.Input{{Module: PairsModule, Mode: modules.InputGet}},
+		Map:    mapPairLogs(SwapTopic),
+		Store:  storeVolumes,
+	})
+}
+
+var factory = mustDecodeAddress(FactoryAddress)
+
+func mustDecodeAddress(address string) []byte {
+	out, err := hex.DecodeString(address[2:])
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func mapPairs(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+	var pairs []*pbeth.Log
+	for _, log := range intr.Logs() {
+		if bytes.Equal(log.Address, factory) && len(log.Topics) == 3 && bytes.Equal(log.Topics[0], PairCreatedTopic) {
+			if len(log.Data) < 64 {
+				return nil, fmt.Errorf("invalid PairCreated data length %d", len(log.Data))
+			}
+			pairs = append(pairs, log)
+		}
+	}
+	return pairs, nil
+}
+
+func storePairs(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, store sdk.Store) error {
+	for _, log := range output.([]*pbeth.Log) {
+		store.Set(PairKey(log.Data[12:32]), pairTokens(log.Topics[1][12:], log.Topics[2][12:]))
+	}
+	return nil
+}
+
+// mapPairLogs returns the `topic` logs emitted by known pairs.
+func mapPairLogs(topic []byte) sdk.MapFunc {
+	return func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+		pairs, err := intr.Store(PairsModule)
+		if err != nil {
+			return nil, err
+		}
+
+		var logs []*pbeth.Log
+		for _, log := range intr.Logs() {
+			if len(log.Topics) == 0 || !bytes.Equal(log.Topics[0], topic) {
+				continue
+			}
+			if _, found := pairs.GetLast(PairKey(log.Address)); found {
+				logs = append(logs, log)
+			}
+		}
+		return logs, nil
+	}
+}
+
+func storeReserves(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, store sdk.Store) error {
+	for _, log := range output.([]*pbeth.Log) {
+		if len(log.Data) != 64 {
+			return fmt.Errorf("invalid Sync data length %d", len(log.Data))
+		}
+
+		store.Set(ReserveKey(0, log.Address), []byte(new(big.Int).SetBytes(log.Data[:32]).String()))
+		store.Set(ReserveKey(1, log.Address), []byte(new(big.Int).SetBytes(log.Data[32:]).String()))
+	}
+	return nil
+}
+
+func storeVolumes(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, store sdk.Store) error {
+	for _, log := range output.([]*pbeth.Log) {
+		if len(log.Data) != 128 {
+			return fmt.Errorf("invalid Swap data length %d", len(log.Data))
+		}
+
+		for i := 0; i < 2; i++ {
+			key := VolumeKey(i, log.Address)
+			amount := new(big.Int).SetBytes(log.Data[i*32 : (i+1)*32])
+			amount.Add(amount, new(big.Int).SetBytes(log.Data[(i+2)*32:(i+3)*32]))
+
+			if value, found := store.Get(key); found {
+				previous, ok := new(big.Int).SetString(string(value), 10)
+				if !ok {
+					return fmt.Errorf("invalid volume %q", value)
+				}
+				amount.Add(amount, previous)
+			}
+			store.Set(key, []byte(amount.String()))
+		}
+	}
+	return nil
+}
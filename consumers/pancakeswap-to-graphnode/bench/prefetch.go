@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"errors"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+)
+
+var errPrefetchStopped = errors.New("prefetch stopped")
+
+// PrefetchBlocks is `ReadBlocks` with the reading and decoding done by a
+// separate goroutine, up to `ahead` blocks in advance of `handle`, so I/O and
+// decoding overlap with the processing of the current block. It's plain
+// `ReadBlocks` when `ahead` is 0.
+func PrefetchBlocks(dir string, start, stop uint64, ahead int, handle func(block *pbeth.Block) error) error {
+	if ahead <= 0 {
+		return ReadBlocks(dir, start, stop, handle)
+	}
+
+	blocks := make(chan *pbeth.Block, ahead)
+	done := make(chan struct{})
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(blocks)
+		readErr <- ReadBlocks(dir, start, stop, func(block *pbeth.Block) error {
+			select {
+			case blocks <- block:
+				return nil
+			case <-done:
+				return errPrefetchStopped
+			}
+		})
+	}()
+
+	for block := range blocks {
+		if err := handle(block); err != nil {
+			close(done)
+			// drain so the reader is never blocked on a full channel
+			for range blocks {
+			}
+			return err
+		}
+	}
+
+	return <-readErr
+}
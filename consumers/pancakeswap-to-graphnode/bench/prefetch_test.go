@@ -0,0 +1,53 @@
+package bench
+
+import (
+	"errors"
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchBlocks(t *testing.T) {
+	dir := t.TempDir()
+	g, err := NewGenerator(testConfig())
+	require.NoError(t, err)
+	require.NoError(t, WriteBlocks(dir, 250, g.Next))
+
+	tests := []struct {
+		name          string
+		ahead         int
+		failAt        uint64
+		expectedCount int
+		expectedErr   bool
+	}{
+		{"inline", 0, 0, 250, false},
+		{"single block ahead", 1, 0, 250, false},
+		{"more than a bundle ahead", 150, 0, 250, false},
+		{"handler failure stops the reader", 8, 270, 20, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var read []uint64
+			err := PrefetchBlocks(dir, 0, 0, test.ahead, func(block *pbeth.Block) error {
+				if block.Number == test.failAt {
+					return errors.New("boom")
+				}
+				read = append(read, block.Number)
+				return nil
+			})
+			if test.expectedErr {
+				require.EqualError(t, err, "boom")
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Len(t, read, test.expectedCount)
+			for i := 1; i < len(read); i++ {
+				assert.Equal(t, read[i-1]+1, read[i])
+			}
+		})
+	}
+}
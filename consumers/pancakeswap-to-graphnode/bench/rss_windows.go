@@ -0,0 +1,6 @@
+package bench
+
+// peakRSS is not measured on Windows.
+func peakRSS() uint64 {
+	return 0
+}
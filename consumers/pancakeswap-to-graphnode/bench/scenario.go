@@ -0,0 +1,388 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/ethrpc"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	"github.com/streamingfast/substream-pancakeswap/rpccache"
+	"github.com/streamingfast/substream-pancakeswap/rpcusage"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"go.uber.org/zap"
+)
+
+// Scenario is a standardized benchmark, results of the same scenario are
+// comparable across versions.
+type Scenario struct {
+	Name        string
+	Description string
+	Blocks      uint64
+	Generator   GeneratorConfig
+	Modules     []string
+}
+
+var defaultGenerator = GeneratorConfig{
+	StartBlock:     0,
+	BlockTime:      3 * time.Second,
+	InitialPairs:   100,
+	PairsPerSecond: 0.05,
+	SwapsPerSecond: 30,
+	Distribution:   Zipf,
+	MaxSwapSize:    0.02,
+	Seed:           1,
+}
+
+var scenarios = map[string]*Scenario{}
+
+func registerScenario(s *Scenario) {
+	scenarios[s.Name] = s
+}
+
+func init() {
+	registerScenario(&Scenario{
+		Name:        "backfill-10k",
+		Description: "10,000 blocks of mainnet-like activity, quick local check",
+		Blocks:      10_000,
+		Generator:   defaultGenerator,
+		Modules:     []string{ReservesModule, VolumesModule},
+	})
+	registerScenario(&Scenario{
+		Name:        "backfill-100k",
+		Description: "100,000 blocks of mainnet-like activity",
+		Blocks:      100_000,
+		Generator:   defaultGenerator,
+		Modules:     []string{ReservesModule, VolumesModule},
+	})
+
+	swapHeavy := defaultGenerator
+	swapHeavy.SwapsPerSecond = 300
+	swapHeavy.Distribution = Uniform
+	registerScenario(&Scenario{
+		Name:        "swap-heavy-10k",
+		Description: "10,000 blocks with 10 times the mainnet swap rate spread over all pairs",
+		Blocks:      10_000,
+		Generator:   swapHeavy,
+		Modules:     []string{ReservesModule, VolumesModule},
+	})
+
+	pairHeavy := defaultGenerator
+	pairHeavy.PairsPerSecond = 10
+	registerScenario(&Scenario{
+		Name:        "pair-heavy-10k",
+		Description: "10,000 blocks creating 30 pairs per block, stores grow fast",
+		Blocks:      10_000,
+		Generator:   pairHeavy,
+		Modules:     []string{ReservesModule, VolumesModule},
+	})
+}
+
+func GetScenario(name string) (*Scenario, error) {
+	s, found := scenarios[name]
+	if !found {
+		return nil, fmt.Errorf("unknown scenario %q, valid scenarios are %v", name, ScenarioNames())
+	}
+	return s, nil
+}
+
+func ScenarioNames() (out []string) {
+	for name := range scenarios {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return
+}
+
+// Result is the machine readable outcome of a scenario run. Durations are in
+// nanoseconds. Durations and allocations only account for the pipeline, not
+// for producing or reading the blocks.
+type Result struct {
+	Scenario        string                        `json:"scenario"`
+	Source          string                        `json:"source"`
+	Blocks          uint64                        `json:"blocks"`
+	SkippedBlocks   uint64                        `json:"skipped_blocks,omitempty"`
+	Quarantined     map[string]uint64             `json:"quarantined,omitempty"`
+	Duration        time.Duration                 `json:"duration_ns"`
+	BlocksPerSecond float64                       `json:"blocks_per_second"`
+	PeakRSSBytes    uint64                        `json:"peak_rss_bytes"`
+	Allocations     uint64                        `json:"allocations"`
+	AllocatedBytes  uint64                        `json:"allocated_bytes"`
+	Deltas          uint64                        `json:"deltas"`
+	Modules         map[string]*ModuleStats       `json:"modules"`
+	Spill           map[string]modules.SpillStats `json:"spill,omitempty"`
+	Bloom           map[string]modules.BloomStats `json:"bloom,omitempty"`
+	RPC             *rpcusage.Report              `json:"rpc,omitempty"`
+	RPCCache        *rpccache.Stats               `json:"rpc_cache,omitempty"`
+	GoVersion       string                        `json:"go_version"`
+	GOMAXPROCS      int                           `json:"gomaxprocs"`
+}
+
+type ModuleStats struct {
+	Duration         time.Duration `json:"duration_ns"`
+	DurationPerBlock time.Duration `json:"duration_per_block_ns"`
+	Share            float64       `json:"share"`
+}
+
+type RunOptions struct {
+	// BlocksDir is where blocks are read from, limited to the scenario's block
+	// count, they are generated on the fly when empty.
+	BlocksDir string
+
+	// PrefetchBlocks is the number of blocks read and decoded ahead of the
+	// pipeline when reading from BlocksDir, blocks are read inline when 0.
+	// Allocations of the prefetching goroutine are counted in the report.
+	PrefetchBlocks int
+
+	// MemoryBudget is the number of bytes each store keeps in memory, spilling
+	// to SpillDir when over it, stores are fully in memory when 0.
+	MemoryBudget int64
+	SpillDir     string
+
+	// BloomStores are the stores put behind a bloom filter of BloomKeys keys,
+	// answering the lookups of missing keys, see `modules.BloomState`.
+	BloomStores []string
+	BloomKeys   int
+
+	// Mocks replaces the execution of store modules by the deltas recorded in
+	// a directory, per module name, see `modules.Pipeline.Mock`.
+	Mocks map[string]string
+
+	// Hooks are the registered hooks run around the modules of every block, in
+	// order, see `modules.RegisterHook`.
+	Hooks []string
+
+	// Record writes the deltas of modules to a directory, per module name,
+	// for later runs to mock them.
+	Record map[string]string
+
+	// RPCEndpoint is the JSON-RPC node the modules' calls are sent to, pinned
+	// to the block being processed, calls fail when empty. The responses are
+	// cached in memory when RPCCacheSize is positive, calls not pinned to the
+	// current block fail when StrictRPC is set. RPCPrices prices the calls of
+	// the usage report.
+	RPCEndpoint  string
+	RPCCacheSize int
+	StrictRPC    bool
+	RPCPrices    rpcusage.Prices
+
+	// RPCConcurrency splits the batches of calls in concurrent requests to
+	// the node when its `BatchSize` is positive, see `ethrpc.Concurrency`.
+	RPCConcurrency ethrpc.Concurrency
+
+	// ErrorPolicies handle the failures of modules, per module name, the
+	// blocks skipped recorded in the Quarantine store, a dstore URL, see
+	// `modules.Pipeline.SetErrorPolicy`.
+	ErrorPolicies map[string]modules.ErrorPolicy
+	Quarantine    string
+}
+
+// bloomFalsePositiveRate is the rate the bloom filters of `BloomStores` are
+// sized for, the report tells the actual one.
+const bloomFalsePositiveRate = 0.01
+
+func (s *Scenario) Run(opts RunOptions) (*Result, error) {
+	pipeline, err := modules.NewPipeline(s.Modules...)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline setup: %w", err)
+	}
+	defer pipeline.Close()
+
+	if opts.MemoryBudget > 0 {
+		if err := pipeline.SetMemoryBudget(opts.SpillDir, opts.MemoryBudget); err != nil {
+			return nil, fmt.Errorf("pipeline setup: %w", err)
+		}
+	}
+	if len(opts.BloomStores) > 0 {
+		if err := pipeline.SetBloomFilter(opts.BloomStores, opts.BloomKeys, bloomFalsePositiveRate); err != nil {
+			return nil, fmt.Errorf("pipeline setup: %w", err)
+		}
+	}
+
+	var meter *rpcusage.Meter
+	var cache *rpccache.Cache
+	if opts.RPCEndpoint != "" {
+		meter = rpcusage.NewMeter()
+		client := ethrpc.NewClient(opts.RPCEndpoint, &http.Client{Transport: rpcusage.NewTransport(meter, nil)})
+		if opts.RPCConcurrency.BatchSize > 0 {
+			if err := client.SetConcurrency(opts.RPCConcurrency); err != nil {
+				return nil, fmt.Errorf("pipeline setup: %w", err)
+			}
+		}
+		var backend sdk.RPC = client
+		if opts.RPCCacheSize > 0 {
+			if cache, err = rpccache.New(backend, opts.RPCCacheSize); err != nil {
+				return nil, fmt.Errorf("pipeline setup: %w", err)
+			}
+			backend = cache
+		}
+		pipeline.SetRPC(backend, opts.StrictRPC)
+	}
+
+	if opts.Quarantine != "" {
+		quarantine, err := modules.OpenQuarantine(opts.Quarantine)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline setup: %w", err)
+		}
+		pipeline.SetQuarantine(quarantine)
+	}
+	for name, policy := range opts.ErrorPolicies {
+		if err := pipeline.SetErrorPolicy(name, policy); err != nil {
+			return nil, fmt.Errorf("pipeline setup: %w", err)
+		}
+	}
+
+	for _, name := range opts.Hooks {
+		if err := pipeline.AddHook(name); err != nil {
+			return nil, fmt.Errorf("pipeline setup: %w", err)
+		}
+	}
+
+	for name, dir := range opts.Mocks {
+		if err := pipeline.Mock(name, dir); err != nil {
+			return nil, fmt.Errorf("pipeline setup: %w", err)
+		}
+	}
+
+	recorders := map[string]*modules.Recorder{}
+	defer func() {
+		for _, recorder := range recorders {
+			recorder.Close()
+		}
+	}()
+	for name, dir := range opts.Record {
+		if _, found := pipeline.State(name); !found {
+			return nil, fmt.Errorf("cannot record module %q, it's not a store module of the pipeline", name)
+		}
+		recorder, err := modules.NewRecorder(dir)
+		if err != nil {
+			return nil, fmt.Errorf("module %q: %w", name, err)
+		}
+		recorders[name] = recorder
+	}
+
+	result := &Result{
+		Scenario:   s.Name,
+		Source:     "generated",
+		Modules:    map[string]*ModuleStats{},
+		GoVersion:  runtime.Version(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+	for _, name := range pipeline.Modules() {
+		result.Modules[name] = &ModuleStats{}
+	}
+
+	var before, after runtime.MemStats
+	var firstBlock, lastBlock uint64
+	process := func(block *pbeth.Block) error {
+		if result.Blocks == 0 {
+			firstBlock = block.Number
+		}
+		lastBlock = block.Number
+
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		out, err := pipeline.ProcessBlock(block)
+		if err != nil {
+			return err
+		}
+		result.Duration += time.Since(start)
+		runtime.ReadMemStats(&after)
+
+		result.Blocks++
+		if out.Skipped {
+			result.SkippedBlocks++
+		}
+		for _, name := range out.Quarantined {
+			if result.Quarantined == nil {
+				result.Quarantined = map[string]uint64{}
+			}
+			result.Quarantined[name]++
+		}
+		result.Allocations += after.Mallocs - before.Mallocs
+		result.AllocatedBytes += after.TotalAlloc - before.TotalAlloc
+
+		for name, duration := range out.Durations {
+			result.Modules[name].Duration += duration
+		}
+		for _, deltas := range out.Deltas {
+			result.Deltas += uint64(len(deltas))
+		}
+		for name, recorder := range recorders {
+			if err := recorder.Record(block, out.Deltas[name]); err != nil {
+				return fmt.Errorf("module %q: %w", name, err)
+			}
+		}
+
+		if result.Blocks%10_000 == 0 {
+			zlog.Info("scenario progress", zap.String("scenario", s.Name), zap.Uint64("blocks", result.Blocks))
+		}
+		return nil
+	}
+
+	if opts.BlocksDir != "" {
+		result.Source = opts.BlocksDir
+		err = PrefetchBlocks(opts.BlocksDir, 0, 0, opts.PrefetchBlocks, func(block *pbeth.Block) error {
+			if result.Blocks >= s.Blocks {
+				return errStop
+			}
+			return process(block)
+		})
+		if err == errStop {
+			err = nil
+		}
+	} else {
+		generator, genErr := NewGenerator(s.Generator)
+		if genErr != nil {
+			return nil, fmt.Errorf("generator setup: %w", genErr)
+		}
+		for i := uint64(0); i < s.Blocks && err == nil; i++ {
+			err = process(generator.Next())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for name, recorder := range recorders {
+		delete(recorders, name)
+		if err := recorder.Close(); err != nil {
+			return nil, fmt.Errorf("module %q recording: %w", name, err)
+		}
+	}
+
+	result.PeakRSSBytes = peakRSS()
+	if meter != nil {
+		result.RPC = meter.Report(opts.RPCPrices, firstBlock, lastBlock+1)
+	}
+	if cache != nil {
+		stats := cache.Stats()
+		result.RPCCache = &stats
+	}
+	if opts.MemoryBudget > 0 {
+		result.Spill = pipeline.SpillStats()
+	}
+	if len(opts.BloomStores) > 0 {
+		result.Bloom = pipeline.BloomStats()
+	}
+
+	if seconds := result.Duration.Seconds(); seconds > 0 {
+		result.BlocksPerSecond = float64(result.Blocks) / seconds
+	}
+	for _, stats := range result.Modules {
+		if result.Blocks > 0 {
+			stats.DurationPerBlock = stats.Duration / time.Duration(result.Blocks)
+		}
+		if result.Duration > 0 {
+			stats.Share = float64(stats.Duration) / float64(result.Duration)
+		}
+	}
+
+	return result, nil
+}
+
+var errStop = errors.New("stop")
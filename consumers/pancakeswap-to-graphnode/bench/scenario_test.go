@@ -0,0 +1,117 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenario_Run(t *testing.T) {
+	s := &Scenario{Name: "test", Blocks: 150, Generator: testConfig(), Modules: []string{ReservesModule, VolumesModule}}
+
+	dir := t.TempDir()
+	g, err := NewGenerator(s.Generator)
+	require.NoError(t, err)
+	require.NoError(t, WriteBlocks(dir, 200, g.Next))
+	recording := t.TempDir()
+
+	tests := []struct {
+		name string
+		opts RunOptions
+	}{
+		{"generated", RunOptions{}},
+		{"from blocks directory", RunOptions{BlocksDir: dir}},
+		{"memory budget", RunOptions{MemoryBudget: 4096, SpillDir: t.TempDir()}},
+		{"prefetched from blocks directory", RunOptions{BlocksDir: dir, PrefetchBlocks: 16}},
+		{"recording pairs", RunOptions{Record: map[string]string{PairsModule: recording}}},
+		{"mocked pairs", RunOptions{Mocks: map[string]string{PairsModule: recording}}},
+		{"bloom filtered pairs", RunOptions{MemoryBudget: 4096, SpillDir: t.TempDir(), BloomStores: []string{PairsModule}, BloomKeys: 1000}},
+	}
+
+	var deltas []uint64
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := s.Run(test.opts)
+			require.NoError(t, err)
+
+			assert.Equal(t, uint64(150), result.Blocks)
+			assert.Greater(t, result.BlocksPerSecond, 0.0)
+			assert.Greater(t, result.Allocations, uint64(0))
+			assert.Equal(t, []string{PairsModule, ReservesModule, VolumesModule}, sortedKeys(result.Modules))
+			assert.Greater(t, result.Modules[VolumesModule].Duration.Nanoseconds(), int64(0))
+			assert.Equal(t, test.opts.MemoryBudget > 0, result.Spill[PairsModule].Spills > 0)
+			assert.Equal(t, len(test.opts.BloomStores) > 0, result.Bloom[PairsModule].Skipped > 0)
+			deltas = append(deltas, result.Deltas)
+		})
+	}
+
+	assert.Equal(t, deltas[0], deltas[1], "generated and stored blocks are the same")
+	assert.Equal(t, deltas[0], deltas[2], "spilling is transparent")
+	assert.Equal(t, deltas[0], deltas[3], "prefetching is transparent")
+	assert.Equal(t, deltas[0], deltas[5], "mocking is transparent")
+	assert.Equal(t, deltas[0], deltas[6], "bloom filters are transparent")
+}
+
+func TestBenchModules(t *testing.T) {
+	g, err := NewGenerator(testConfig())
+	require.NoError(t, err)
+
+	pipeline, err := modules.NewPipeline(ReservesModule, VolumesModule)
+	require.NoError(t, err)
+
+	volumes := map[string]*big.Int{}
+	reserves := map[string]string{}
+	for i := 0; i < 20; i++ {
+		block := g.Next()
+		_, err := pipeline.ProcessBlock(block)
+		require.NoError(t, err)
+
+		for _, trx := range block.TransactionTraces {
+			for _, log := range trx.Receipt.Logs {
+				pair := hex.EncodeToString(log.Address)
+				switch {
+				case bytes.Equal(log.Topics[0], SyncTopic):
+					reserves[pair] = new(big.Int).SetBytes(log.Data[:32]).String()
+				case bytes.Equal(log.Topics[0], SwapTopic):
+					if volumes[pair] == nil {
+						volumes[pair] = new(big.Int)
+					}
+					volumes[pair].Add(volumes[pair], new(big.Int).SetBytes(log.Data[:32]))
+					volumes[pair].Add(volumes[pair], new(big.Int).SetBytes(log.Data[64:96]))
+				}
+			}
+		}
+	}
+
+	require.NotEmpty(t, volumes)
+
+	state, found := pipeline.State(VolumesModule)
+	require.True(t, found)
+	for pair, expected := range volumes {
+		value, found := state.Get("volume0:" + pair)
+		require.True(t, found)
+		assert.Equal(t, expected.String(), string(value))
+	}
+
+	state, found = pipeline.State(ReservesModule)
+	require.True(t, found)
+	for pair, expected := range reserves {
+		value, found := state.Get("reserve0:" + pair)
+		require.True(t, found)
+		assert.Equal(t, expected, string(value))
+	}
+}
+
+func sortedKeys(m map[string]*ModuleStats) (out []string) {
+	for key := range m {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return
+}
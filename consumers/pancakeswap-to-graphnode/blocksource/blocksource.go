@@ -0,0 +1,165 @@
+// Package blocksource delivers the EVM blocks processed by the commands
+// decoding blocks themselves from an ordered list of sources: local merged
+// blocks files while they last, then a remote firehose, then an RPC node
+// polled at the head of the chain, handing over from one to the next with a
+// single cursor.
+package blocksource
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"go.uber.org/zap"
+)
+
+// Source delivers blocks in order, from `from` to `stop` excluded, without
+// end when `stop` is 0.
+type Source interface {
+	// Name names the source in the logs and metrics, like `local`.
+	Name() string
+	// Blocks calls `handle` with the blocks of the source from `from` on. It
+	// returns nil once `stop` is reached, `ErrExhausted` when the source has
+	// no more blocks, like local files ending before `stop`, and the error of
+	// `handle` as is.
+	Blocks(ctx context.Context, from, stop uint64, handle func(block *pbeth.Block) error) error
+}
+
+// ErrExhausted is returned by a `Source` having no more blocks to deliver,
+// the next source of the `Chain` takes over.
+var ErrExhausted = errors.New("no more blocks in the source")
+
+// Cursor is the position of the consumer of a `Chain`, the last block it
+// processed, shared by all the sources: a local file, a firehose and an RPC
+// node all start from the block after it. The zero cursor is the position
+// before the start block.
+type Cursor struct {
+	Num uint64
+	// ID is the hash of the block, in hex without the `0x` prefix, checked
+	// against the parent hash of the next block.
+	ID string
+}
+
+func (c Cursor) IsZero() bool {
+	return c == Cursor{}
+}
+
+// String writes the cursor as `<num>:<id>`, empty for the zero cursor.
+func (c Cursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d:%s", c.Num, c.ID)
+}
+
+// ParseCursor reads a cursor written by `Cursor.String`.
+func ParseCursor(in string) (Cursor, error) {
+	if in == "" {
+		return Cursor{}, nil
+	}
+
+	parts := strings.Split(in, ":")
+	if len(parts) != 2 {
+		return Cursor{}, fmt.Errorf("invalid cursor %q, expected <num>:<id>", in)
+	}
+	num, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor %q: %w", in, err)
+	}
+	return Cursor{Num: num, ID: parts[1]}, nil
+}
+
+// Chain delivers the blocks of its sources, in order: a source is consumed
+// until it's exhausted or fails, then the next one continues from the block
+// after the last one delivered. A source starting below it has the blocks
+// already delivered skipped, one skipping blocks or delivering a block whose
+// parent isn't the last one delivered fails, and is handed over like any
+// other failure. The errors of the last source end the chain, its exhaustion
+// ends it without error.
+type Chain struct {
+	sources []Source
+}
+
+func NewChain(sources ...Source) (*Chain, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no block source")
+	}
+	return &Chain{sources: sources}, nil
+}
+
+// errStopReached ends a source delivering blocks past the stop block.
+var errStopReached = errors.New("stop block reached")
+
+// handlerError carries the errors of the consumer, which end the chain
+// whatever the source.
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string { return e.err.Error() }
+
+// Blocks calls `handle` with the blocks after `cursor`, or from `start` when
+// it's the zero cursor, until `stop` excluded, without end when 0. `handle`
+// gets the cursor of each block, to be persisted with what it produced.
+func (c *Chain) Blocks(ctx context.Context, cursor Cursor, start, stop uint64, handle func(block *pbeth.Block, cursor Cursor) error) error {
+	next := start
+	if !cursor.IsZero() {
+		next = cursor.Num + 1
+	}
+
+	for i, source := range c.sources {
+		if stop != 0 && next >= stop {
+			return nil
+		}
+
+		zlog.Info("streaming blocks from source", zap.String("source", source.Name()), zap.Uint64("from", next))
+		activeSource.SetUint64(uint64(i))
+
+		err := source.Blocks(ctx, next, stop, func(block *pbeth.Block) error {
+			if block.Number < next {
+				return nil
+			}
+			if stop != 0 && block.Number >= stop {
+				return errStopReached
+			}
+			if block.Number > next {
+				return fmt.Errorf("block %d delivered, expected %d", block.Number, next)
+			}
+			if parent := hex.EncodeToString(block.GetHeader().GetParentHash()); cursor.ID != "" && cursor.Num+1 == block.Number && parent != cursor.ID {
+				return fmt.Errorf("block %d has parent %s, expected %s", block.Number, parent, cursor.ID)
+			}
+
+			blockCursor := Cursor{Num: block.Number, ID: hex.EncodeToString(block.Hash)}
+			if err := handle(block, blockCursor); err != nil {
+				return &handlerError{err}
+			}
+			cursor, next = blockCursor, block.Number+1
+			return nil
+		})
+
+		var handlerErr *handlerError
+		switch {
+		case err == nil, errors.Is(err, errStopReached):
+			return nil
+		case errors.As(err, &handlerErr):
+			return handlerErr.err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, ErrExhausted) && i == len(c.sources)-1:
+			zlog.Info("all block sources exhausted", zap.Stringer("cursor", cursor))
+			return nil
+		case i == len(c.sources)-1:
+			return fmt.Errorf("source %s: %w", source.Name(), err)
+		case errors.Is(err, ErrExhausted):
+			zlog.Info("block source exhausted, handing over to the next one", zap.String("source", source.Name()), zap.Stringer("cursor", cursor))
+		default:
+			zlog.Warn("block source failed, handing over to the next one", zap.String("source", source.Name()), zap.Stringer("cursor", cursor), zap.Error(err))
+		}
+		handovers.Inc(source.Name())
+	}
+	return nil
+}
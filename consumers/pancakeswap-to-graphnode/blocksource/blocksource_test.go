@@ -0,0 +1,294 @@
+package blocksource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/bench"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlock is block `num` of a chain whose block hashes are their number.
+func testBlock(num uint64) *pbeth.Block {
+	return &pbeth.Block{
+		Number: num,
+		Hash:   []byte{byte(num)},
+		Header: &pbeth.BlockHeader{Number: num, ParentHash: []byte{byte(num - 1)}},
+	}
+}
+
+// fakeSource delivers blocks `first` to `last` then ends with `err`, nil
+// meaning exhausted.
+type fakeSource struct {
+	name        string
+	first, last uint64
+	err         error
+	// forked delivers blocks from another chain
+	forked bool
+
+	requested []uint64
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) Blocks(ctx context.Context, from, stop uint64, handle func(block *pbeth.Block) error) error {
+	s.requested = append(s.requested, from)
+	for num := s.first; num <= s.last; num++ {
+		if stop != 0 && num >= stop {
+			return nil
+		}
+		block := testBlock(num)
+		if s.forked {
+			block.Header.ParentHash = []byte{0xff}
+		}
+		if err := handle(block); err != nil {
+			return err
+		}
+	}
+	if s.err != nil {
+		return s.err
+	}
+	return ErrExhausted
+}
+
+func collect(t *testing.T, chain *Chain, cursor Cursor, start, stop uint64) ([]uint64, Cursor, error) {
+	t.Helper()
+
+	var nums []uint64
+	err := chain.Blocks(context.Background(), cursor, start, stop, func(block *pbeth.Block, blockCursor Cursor) error {
+		nums = append(nums, block.Number)
+		cursor = blockCursor
+		return nil
+	})
+	return nums, cursor, err
+}
+
+func TestChain_Blocks(t *testing.T) {
+	local := &fakeSource{name: "local", first: 1, last: 4}
+	// the firehose starts earlier than asked, the blocks already delivered are
+	// skipped
+	firehose := &fakeSource{name: "firehose", first: 3, last: 7, err: errors.New("connection reset")}
+	rpc := &fakeSource{name: "rpc", first: 8, last: 9}
+
+	chain, err := NewChain(local, firehose, rpc)
+	require.NoError(t, err)
+
+	nums, cursor, err := collect(t, chain, Cursor{}, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 3, 4, 5, 6, 7, 8, 9}, nums)
+	assert.Equal(t, Cursor{Num: 9, ID: "09"}, cursor)
+	assert.Equal(t, []uint64{2}, local.requested)
+	assert.Equal(t, []uint64{5}, firehose.requested)
+	assert.Equal(t, []uint64{8}, rpc.requested)
+}
+
+func TestChain_Blocks_Cursor(t *testing.T) {
+	local := &fakeSource{name: "local", first: 1, last: 4}
+	rpc := &fakeSource{name: "rpc", first: 5, last: 9}
+
+	chain, err := NewChain(local, rpc)
+	require.NoError(t, err)
+
+	nums, _, err := collect(t, chain, Cursor{Num: 6, ID: "06"}, 1, 9)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{7, 8}, nums)
+	assert.Equal(t, []uint64{7}, local.requested)
+	assert.Equal(t, []uint64{7}, rpc.requested)
+
+	// the cursor is past the stop block
+	nums, _, err = collect(t, chain, Cursor{Num: 9, ID: "09"}, 1, 9)
+	require.NoError(t, err)
+	assert.Empty(t, nums)
+}
+
+func TestChain_Blocks_Failures(t *testing.T) {
+	tests := []struct {
+		name          string
+		sources       []Source
+		expectedNums  []uint64
+		expectedError string
+	}{
+		{
+			name:          "last source fails",
+			sources:       []Source{&fakeSource{name: "local", first: 1, last: 2}, &fakeSource{name: "rpc", first: 3, last: 3, err: errors.New("boom")}},
+			expectedNums:  []uint64{1, 2, 3},
+			expectedError: "source rpc: boom",
+		},
+		{
+			name:          "gap",
+			sources:       []Source{&fakeSource{name: "local", first: 1, last: 2}, &fakeSource{name: "rpc", first: 4, last: 5}},
+			expectedNums:  []uint64{1, 2},
+			expectedError: "source rpc: block 4 delivered, expected 3",
+		},
+		{
+			name:          "fork",
+			sources:       []Source{&fakeSource{name: "local", first: 1, last: 2}, &fakeSource{name: "rpc", first: 3, last: 5, forked: true}},
+			expectedNums:  []uint64{1, 2},
+			expectedError: "source rpc: block 3 has parent ff, expected 02",
+		},
+		{
+			name:         "failed source handed over",
+			sources:      []Source{&fakeSource{name: "local", first: 1, last: 2, err: errors.New("corrupted file")}, &fakeSource{name: "rpc", first: 3, last: 4}},
+			expectedNums: []uint64{1, 2, 3, 4},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain, err := NewChain(test.sources...)
+			require.NoError(t, err)
+
+			nums, _, err := collect(t, chain, Cursor{}, 1, 0)
+			assert.Equal(t, test.expectedNums, nums)
+			if test.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestChain_Blocks_HandlerError(t *testing.T) {
+	rpc := &fakeSource{name: "rpc", first: 1, last: 5}
+	chain, err := NewChain(&fakeSource{name: "local", first: 1, last: 5}, rpc)
+	require.NoError(t, err)
+
+	err = chain.Blocks(context.Background(), Cursor{}, 1, 0, func(block *pbeth.Block, cursor Cursor) error {
+		return fmt.Errorf("writing block %d", block.Number)
+	})
+	assert.EqualError(t, err, "writing block 1")
+	assert.Empty(t, rpc.requested)
+}
+
+func TestNewChain(t *testing.T) {
+	_, err := NewChain()
+	assert.EqualError(t, err, "no block source")
+}
+
+func TestParseCursor(t *testing.T) {
+	cursor, err := ParseCursor("6810000:ab12")
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{Num: 6810000, ID: "ab12"}, cursor)
+	assert.Equal(t, "6810000:ab12", cursor.String())
+
+	cursor, err = ParseCursor("")
+	require.NoError(t, err)
+	assert.True(t, cursor.IsZero())
+	assert.Equal(t, "", cursor.String())
+
+	_, err = ParseCursor("6810000")
+	assert.EqualError(t, err, `invalid cursor "6810000", expected <num>:<id>`)
+	_, err = ParseCursor("abc:ab12")
+	assert.Error(t, err)
+}
+
+func TestLocal_Blocks(t *testing.T) {
+	dir := t.TempDir()
+	next := uint64(100)
+	require.NoError(t, bench.WriteBlocks(dir, 150, func() *pbeth.Block {
+		next++
+		return testBlock(next - 1)
+	}))
+	local := NewLocal(dir)
+
+	var nums []uint64
+	err := local.Blocks(context.Background(), 245, 0, func(block *pbeth.Block) error {
+		nums = append(nums, block.Number)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrExhausted)
+	assert.Equal(t, []uint64{245, 246, 247, 248, 249}, nums)
+
+	nums = nil
+	err = local.Blocks(context.Background(), 245, 248, func(block *pbeth.Block) error {
+		nums = append(nums, block.Number)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{245, 246, 247}, nums)
+}
+
+// node is a JSON-RPC node at block `head`, every block holding two logs of
+// transaction 1 and a log removed by a reorg.
+func node(t *testing.T, head *uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		switch req.Method {
+		case "eth_blockNumber":
+			fmt.Fprintf(w, `{"result":"0x%x"}`, atomic.LoadUint64(head))
+		case "eth_getBlockByNumber":
+			var num string
+			require.NoError(t, json.Unmarshal(req.Params[0], &num))
+			n, err := parseQuantity(num)
+			require.NoError(t, err)
+			fmt.Fprintf(w, `{"result":{"number":"%s","hash":"0x%02x","parentHash":"0x%02x","timestamp":"0x64"}}`, num, n, n-1)
+		case "eth_getLogs":
+			var filter map[string]string
+			require.NoError(t, json.Unmarshal(req.Params[0], &filter))
+			fmt.Fprintf(w, `{"result":[
+				{"address":"0xaa","topics":["0x01","0x02"],"data":"0x","logIndex":"0x3","transactionHash":"0xbb","transactionIndex":"0x1"},
+				{"address":"0xaa","topics":["0x01"],"data":"0xff","logIndex":"0x4","transactionHash":"0xbb","transactionIndex":"0x1"},
+				{"address":"0xcc","topics":[],"data":"0x","logIndex":"0x5","transactionHash":"0xdd","transactionIndex":"0x2","removed":true}
+			]}`)
+		default:
+			fmt.Fprintf(w, `{"error":{"code":-32601,"message":"method not found"}}`)
+		}
+	}))
+}
+
+func TestRPC_Blocks(t *testing.T) {
+	head := uint64(11)
+	server := node(t, &head)
+	defer server.Close()
+
+	rpc := NewRPC(server.URL, nil, time.Millisecond, 2)
+
+	var blocks []*pbeth.Block
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := rpc.Blocks(ctx, 8, 0, func(block *pbeth.Block) error {
+		blocks = append(blocks, block)
+		return nil
+	})
+	// blocks past 9 wait for confirmations
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, blocks, 2)
+
+	block := blocks[1]
+	assert.Equal(t, uint64(9), block.Number)
+	assert.Equal(t, []byte{0x09}, block.Hash)
+	assert.Equal(t, []byte{0x08}, block.Header.ParentHash)
+	assert.Equal(t, int64(100), block.Header.Timestamp.Seconds)
+
+	require.Len(t, block.TransactionTraces, 1)
+	trace := block.TransactionTraces[0]
+	assert.Equal(t, []byte{0xbb}, trace.Hash)
+	assert.Equal(t, uint32(1), trace.Index)
+	assert.Equal(t, pbeth.TransactionTraceStatus_SUCCEEDED, trace.Status)
+	require.Len(t, trace.Receipt.Logs, 2)
+	assert.Equal(t, &pbeth.Log{Address: []byte{0xaa}, Topics: [][]byte{{0x01}}, Data: []byte{0xff}, Index: 1, BlockIndex: 4}, trace.Receipt.Logs[1])
+
+	atomic.StoreUint64(&head, 20)
+	blocks = nil
+	err = rpc.Blocks(context.Background(), 10, 12, func(block *pbeth.Block) error {
+		blocks = append(blocks, block)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, blocks, 2)
+}
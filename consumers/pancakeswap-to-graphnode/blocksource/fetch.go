@@ -0,0 +1,189 @@
+package blocksource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/streamingfast/dstore"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/bench"
+	"go.uber.org/zap"
+)
+
+// FetchStats counts the bundles of a fetch.
+type FetchStats struct {
+	Fetched int
+	// Present are the bundles already in the directory, left as is, a fetch
+	// interrupted is resumed by running it again.
+	Present int
+}
+
+// bundles returns the bases of the bundles holding the blocks of [start,
+// stop[, whole bundles are always fetched.
+func bundles(start, stop uint64) []uint64 {
+	var bases []uint64
+	for base := start - start%bench.BundleSize; base < stop; base += bench.BundleSize {
+		bases = append(bases, base)
+	}
+	return bases
+}
+
+func bundleExists(dir string, base uint64) (bool, error) {
+	_, err := os.Stat(filepath.Join(dir, bench.BundleName(base)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FetchBundles writes into `dir` the bundles of the blocks of [start, stop[
+// delivered by `source`, like a firehose streaming them, in the format read
+// by `Local`. Runs of missing bundles are fetched with a single call to the
+// source, each bundle is written once complete, a fetch interrupted never
+// leaves a partial bundle behind.
+func FetchBundles(ctx context.Context, source Source, dir string, start, stop uint64) (*FetchStats, error) {
+	if stop <= start {
+		return nil, fmt.Errorf("invalid range %d:%d, the stop block must be after the start block", start, stop)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create blocks directory %q: %w", dir, err)
+	}
+
+	stats := &FetchStats{}
+	var missing []uint64
+	for _, base := range bundles(start, stop) {
+		exists, err := bundleExists(dir, base)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			stats.Present++
+			continue
+		}
+		missing = append(missing, base)
+	}
+
+	for len(missing) > 0 {
+		// the run of consecutive missing bundles
+		run := 1
+		for run < len(missing) && missing[run] == missing[run-1]+bench.BundleSize {
+			run++
+		}
+		from, to := missing[0], missing[run-1]+bench.BundleSize
+		missing = missing[run:]
+
+		var blocks []*pbeth.Block
+		err := source.Blocks(ctx, from, to, func(block *pbeth.Block) error {
+			if block.Number < from || block.Number >= to {
+				return nil
+			}
+			if expected := from + uint64(len(blocks)); block.Number != expected {
+				return fmt.Errorf("block %d delivered, expected %d", block.Number, expected)
+			}
+
+			blocks = append(blocks, block)
+			if len(blocks) < bench.BundleSize {
+				return nil
+			}
+			if err := writeBundle(dir, blocks); err != nil {
+				return err
+			}
+			zlog.Info("fetched blocks bundle", zap.String("source", source.Name()), zap.Uint64("base", from))
+			stats.Fetched++
+			from, blocks = from+bench.BundleSize, nil
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrExhausted) {
+			return stats, fmt.Errorf("source %s: %w", source.Name(), err)
+		}
+		if from < to {
+			return stats, fmt.Errorf("source %s ended at block %d, before %d", source.Name(), from+uint64(len(blocks)), to)
+		}
+	}
+	return stats, nil
+}
+
+// writeBundle writes a complete bundle through a temporary directory renamed
+// into `dir`.
+func writeBundle(dir string, blocks []*pbeth.Block) error {
+	tmp, err := os.MkdirTemp(dir, ".fetch-")
+	if err != nil {
+		return fmt.Errorf("create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	i := 0
+	err = bench.WriteBlocks(tmp, uint64(len(blocks)), func() *pbeth.Block {
+		i++
+		return blocks[i-1]
+	})
+	if err != nil {
+		return err
+	}
+
+	name := bench.BundleName(blocks[0].Number)
+	return os.Rename(filepath.Join(tmp, name), filepath.Join(dir, name))
+}
+
+// FetchMergedFiles copies into `dir` the merged blocks files of `store`, like
+// a public bucket of `.dbin.zst` files opened with `dstore.NewDBinStore`,
+// holding the blocks of [start, stop[. They are decompressed into the bundles
+// read by `Local`, their content is left as is.
+func FetchMergedFiles(ctx context.Context, store dstore.Store, dir string, start, stop uint64) (*FetchStats, error) {
+	if stop <= start {
+		return nil, fmt.Errorf("invalid range %d:%d, the stop block must be after the start block", start, stop)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create blocks directory %q: %w", dir, err)
+	}
+
+	stats := &FetchStats{}
+	for _, base := range bundles(start, stop) {
+		exists, err := bundleExists(dir, base)
+		if err != nil {
+			return stats, err
+		}
+		if exists {
+			stats.Present++
+			continue
+		}
+
+		if err := copyMergedFile(ctx, store, dir, base); err != nil {
+			return stats, err
+		}
+		zlog.Info("fetched merged blocks file", zap.String("url", store.ObjectURL(fmt.Sprintf("%010d", base))))
+		stats.Fetched++
+	}
+	return stats, nil
+}
+
+func copyMergedFile(ctx context.Context, store dstore.Store, dir string, base uint64) error {
+	name := fmt.Sprintf("%010d", base)
+	reader, err := store.OpenObject(ctx, name)
+	if err != nil {
+		if errors.Is(err, dstore.ErrNotFound) {
+			return fmt.Errorf("merged blocks file %s not found", store.ObjectURL(name))
+		}
+		return fmt.Errorf("open merged blocks file %s: %w", store.ObjectURL(name), err)
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp(dir, ".fetch-")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return fmt.Errorf("download merged blocks file %s: %w", store.ObjectURL(name), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, bench.BundleName(base)))
+}
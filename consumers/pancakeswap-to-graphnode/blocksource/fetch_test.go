@@ -0,0 +1,101 @@
+package blocksource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/bench"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readNums(t *testing.T, dir string) []uint64 {
+	t.Helper()
+
+	var nums []uint64
+	require.NoError(t, bench.ReadBlocks(dir, 0, 0, func(block *pbeth.Block) error {
+		nums = append(nums, block.Number)
+		return nil
+	}))
+	return nums
+}
+
+func TestFetchBundles(t *testing.T) {
+	dir := t.TempDir()
+	source := &fakeSource{name: "firehose", first: 0, last: 1000}
+
+	stats, err := FetchBundles(context.Background(), source, dir, 150, 320)
+	require.NoError(t, err)
+	assert.Equal(t, &FetchStats{Fetched: 3}, stats)
+	assert.Equal(t, []uint64{100}, source.requested)
+
+	nums := readNums(t, dir)
+	require.Len(t, nums, 300)
+	assert.Equal(t, uint64(100), nums[0])
+	assert.Equal(t, uint64(399), nums[299])
+
+	// resumed, only the missing bundles are fetched
+	require.NoError(t, os.Remove(filepath.Join(dir, bench.BundleName(200))))
+	source.requested = nil
+	stats, err = FetchBundles(context.Background(), source, dir, 0, 500)
+	require.NoError(t, err)
+	assert.Equal(t, &FetchStats{Fetched: 3, Present: 2}, stats)
+	assert.Equal(t, []uint64{0, 200, 400}, source.requested)
+	assert.Len(t, readNums(t, dir), 500)
+}
+
+func TestFetchBundles_Incomplete(t *testing.T) {
+	dir := t.TempDir()
+	source := &fakeSource{name: "firehose", first: 0, last: 149}
+
+	stats, err := FetchBundles(context.Background(), source, dir, 0, 200)
+	assert.EqualError(t, err, "source firehose ended at block 150, before 200")
+	assert.Equal(t, &FetchStats{Fetched: 1}, stats)
+
+	// the partial bundle isn't written
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, bench.BundleName(0), entries[0].Name())
+
+	_, err = FetchBundles(context.Background(), source, dir, 10, 10)
+	assert.EqualError(t, err, "invalid range 10:10, the stop block must be after the start block")
+}
+
+func TestFetchMergedFiles(t *testing.T) {
+	// a bucket of compressed merged blocks files
+	generated := t.TempDir()
+	next := uint64(0)
+	require.NoError(t, bench.WriteBlocks(generated, 300, func() *pbeth.Block {
+		next++
+		return testBlock(next - 1)
+	}))
+	store, err := dstore.NewDBinStore("file://" + t.TempDir())
+	require.NoError(t, err)
+	for _, base := range []uint64{0, 100, 200} {
+		file, err := os.Open(filepath.Join(generated, bench.BundleName(base)))
+		require.NoError(t, err)
+		require.NoError(t, store.WriteObject(context.Background(), bench.BundleName(base)[:10], file))
+		file.Close()
+	}
+
+	dir := t.TempDir()
+	stats, err := FetchMergedFiles(context.Background(), store, dir, 100, 300)
+	require.NoError(t, err)
+	assert.Equal(t, &FetchStats{Fetched: 2}, stats)
+
+	nums := readNums(t, dir)
+	require.Len(t, nums, 200)
+	assert.Equal(t, uint64(100), nums[0])
+
+	stats, err = FetchMergedFiles(context.Background(), store, dir, 0, 300)
+	require.NoError(t, err)
+	assert.Equal(t, &FetchStats{Fetched: 1, Present: 2}, stats)
+
+	_, err = FetchMergedFiles(context.Background(), store, dir, 0, 400)
+	assert.ErrorContains(t, err, "0000000300.dbin.zst not found")
+}
@@ -0,0 +1,55 @@
+package blocksource
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v1"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"google.golang.org/grpc"
+)
+
+// Firehose delivers the irreversible blocks of a firehose stream.
+type Firehose struct {
+	client   pbfirehose.StreamClient
+	callOpts []grpc.CallOption
+}
+
+func NewFirehose(client pbfirehose.StreamClient, callOpts ...grpc.CallOption) *Firehose {
+	return &Firehose{client: client, callOpts: callOpts}
+}
+
+func (s *Firehose) Name() string { return "firehose" }
+
+func (s *Firehose) Blocks(ctx context.Context, from, stop uint64, handle func(block *pbeth.Block) error) error {
+	stream, err := s.client.Blocks(ctx, &pbfirehose.Request{
+		StartBlockNum: int64(from),
+		StopBlockNum:  stop,
+		ForkSteps:     []pbfirehose.ForkStep{pbfirehose.ForkStep_STEP_IRREVERSIBLE},
+	}, s.callOpts...)
+	if err != nil {
+		return fmt.Errorf("call sf.firehose.v1.Stream/Blocks: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				if stop != 0 {
+					return nil
+				}
+				return ErrExhausted
+			}
+			return err
+		}
+
+		block := &pbeth.Block{}
+		if err := resp.Block.UnmarshalTo(block); err != nil {
+			return fmt.Errorf("unmarshal block: %w", err)
+		}
+		if err := handle(block); err != nil {
+			return err
+		}
+	}
+}
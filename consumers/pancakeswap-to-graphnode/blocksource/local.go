@@ -0,0 +1,41 @@
+package blocksource
+
+import (
+	"context"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/bench"
+)
+
+// Local delivers the blocks of the merged blocks files of a directory, like
+// the ones written by 'bench generate', it's exhausted past the last file.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (s *Local) Name() string { return "local" }
+
+func (s *Local) Blocks(ctx context.Context, from, stop uint64, handle func(block *pbeth.Block) error) error {
+	next := from
+	err := bench.ReadBlocks(s.dir, from, stop, func(block *pbeth.Block) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := handle(block); err != nil {
+			return err
+		}
+		next = block.Number + 1
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if stop != 0 && next >= stop {
+		return nil
+	}
+	return ErrExhausted
+}
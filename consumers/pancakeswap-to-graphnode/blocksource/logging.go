@@ -0,0 +1,7 @@
+package blocksource
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.blocksource", "github.com/streamingfast/substream-pancakeswap/blocksource")
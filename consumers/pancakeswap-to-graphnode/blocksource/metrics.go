@@ -0,0 +1,16 @@
+package blocksource
+
+import (
+	"github.com/streamingfast/dmetrics"
+)
+
+var metrics = dmetrics.NewSet()
+
+var (
+	activeSource = metrics.NewGauge("block_source_active", "position in the list of block sources of the one blocks are streamed from, 0 for the first one")
+	handovers    = metrics.NewCounterVec("block_source_handovers", []string{"source"}, "block sources exhausted or failed, handing over to the next one")
+)
+
+func init() {
+	metrics.Register()
+}
@@ -0,0 +1,263 @@
+package blocksource
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RPC delivers the blocks of a JSON-RPC node, polling its head every
+// `interval`. Blocks are only delivered once `confirmations` blocks are on
+// top of them, like the irreversible blocks of a firehose they are never
+// undone.
+//
+// The blocks are rebuilt from `eth_getBlockByNumber` and `eth_getLogs`: they
+// only hold their header and the receipt logs of their successful
+// transactions, what the modules reading logs need, without calls nor state
+// changes.
+type RPC struct {
+	url           string
+	client        *http.Client
+	interval      time.Duration
+	confirmations uint64
+}
+
+// NewRPC polls the node at `url` through `client`, `http.DefaultClient` when
+// nil.
+func NewRPC(url string, client *http.Client, interval time.Duration, confirmations uint64) *RPC {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RPC{url: url, client: client, interval: interval, confirmations: confirmations}
+}
+
+func (s *RPC) Name() string { return "rpc" }
+
+func (s *RPC) Blocks(ctx context.Context, from, stop uint64, handle func(block *pbeth.Block) error) error {
+	head := uint64(0)
+	for num := from; stop == 0 || num < stop; num++ {
+		for head < num+s.confirmations {
+			var err error
+			if head, err = s.head(ctx); err != nil {
+				return err
+			}
+			if head >= num+s.confirmations {
+				break
+			}
+
+			zlog.Debug("waiting for the head of the chain", zap.Uint64("head", head), zap.Uint64("next", num))
+			select {
+			case <-time.After(s.interval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		block, err := s.block(ctx, num)
+		if err != nil {
+			return err
+		}
+		if err := handle(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type rpcBlock struct {
+	Number     string `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Timestamp  string `json:"timestamp"`
+}
+
+type rpcLog struct {
+	Address          string   `json:"address"`
+	Topics           []string `json:"topics"`
+	Data             string   `json:"data"`
+	LogIndex         string   `json:"logIndex"`
+	TransactionHash  string   `json:"transactionHash"`
+	TransactionIndex string   `json:"transactionIndex"`
+	Removed          bool     `json:"removed"`
+}
+
+func (s *RPC) head(ctx context.Context) (uint64, error) {
+	var result string
+	if err := s.call(ctx, "eth_blockNumber", &result); err != nil {
+		return 0, err
+	}
+	return parseQuantity(result)
+}
+
+func (s *RPC) block(ctx context.Context, num uint64) (*pbeth.Block, error) {
+	var header *rpcBlock
+	if err := s.call(ctx, "eth_getBlockByNumber", &header, "0x"+strconv.FormatUint(num, 16), false); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %d not found", num)
+	}
+
+	var logs []*rpcLog
+	if err := s.call(ctx, "eth_getLogs", &logs, map[string]string{"blockHash": header.Hash}); err != nil {
+		return nil, err
+	}
+	return toBlock(num, header, logs)
+}
+
+func toBlock(num uint64, header *rpcBlock, logs []*rpcLog) (*pbeth.Block, error) {
+	hash, err := decodeHex(header.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("block %d hash: %w", num, err)
+	}
+	parentHash, err := decodeHex(header.ParentHash)
+	if err != nil {
+		return nil, fmt.Errorf("block %d parent hash: %w", num, err)
+	}
+	timestamp, err := parseQuantity(header.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("block %d timestamp: %w", num, err)
+	}
+
+	block := &pbeth.Block{
+		Number: num,
+		Hash:   hash,
+		Header: &pbeth.BlockHeader{
+			Number:     num,
+			Hash:       hash,
+			ParentHash: parentHash,
+			Timestamp:  timestamppb.New(time.Unix(int64(timestamp), 0)),
+		},
+	}
+
+	traces := map[string]*pbeth.TransactionTrace{}
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+
+		trace, found := traces[l.TransactionHash]
+		if !found {
+			trxHash, err := decodeHex(l.TransactionHash)
+			if err != nil {
+				return nil, fmt.Errorf("block %d transaction hash: %w", num, err)
+			}
+			index, err := parseQuantity(l.TransactionIndex)
+			if err != nil {
+				return nil, fmt.Errorf("block %d transaction index: %w", num, err)
+			}
+
+			trace = &pbeth.TransactionTrace{Hash: trxHash, Index: uint32(index), Status: pbeth.TransactionTraceStatus_SUCCEEDED, Receipt: &pbeth.TransactionReceipt{}}
+			traces[l.TransactionHash] = trace
+			block.TransactionTraces = append(block.TransactionTraces, trace)
+		}
+
+		log, err := toLog(l)
+		if err != nil {
+			return nil, fmt.Errorf("block %d log: %w", num, err)
+		}
+		log.Index = uint32(len(trace.Receipt.Logs))
+		trace.Receipt.Logs = append(trace.Receipt.Logs, log)
+	}
+	return block, nil
+}
+
+func toLog(l *rpcLog) (*pbeth.Log, error) {
+	address, err := decodeHex(l.Address)
+	if err != nil {
+		return nil, fmt.Errorf("address: %w", err)
+	}
+	data, err := decodeHex(l.Data)
+	if err != nil {
+		return nil, fmt.Errorf("data: %w", err)
+	}
+	blockIndex, err := parseQuantity(l.LogIndex)
+	if err != nil {
+		return nil, fmt.Errorf("log index: %w", err)
+	}
+
+	log := &pbeth.Log{Address: address, Data: data, BlockIndex: uint32(blockIndex)}
+	for _, topic := range l.Topics {
+		decoded, err := decodeHex(topic)
+		if err != nil {
+			return nil, fmt.Errorf("topic: %w", err)
+		}
+		log.Topics = append(log.Topics, decoded)
+	}
+	return log, nil
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (s *RPC) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(&rpcRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+
+	var out rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("%s: decode response: %w", method, err)
+	}
+	if out.Error != nil {
+		return fmt.Errorf("%s: %s (%d)", method, out.Error.Message, out.Error.Code)
+	}
+	if err := json.Unmarshal(out.Result, result); err != nil {
+		return fmt.Errorf("%s: decode result: %w", method, err)
+	}
+	return nil
+}
+
+func parseQuantity(in string) (uint64, error) {
+	out, err := strconv.ParseUint(strings.TrimPrefix(in, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q: %w", in, err)
+	}
+	return out, nil
+}
+
+func decodeHex(in string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(in, "0x"))
+}
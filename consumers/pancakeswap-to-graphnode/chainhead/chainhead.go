@@ -0,0 +1,112 @@
+// Package chainhead tracks the head and the last irreversible block (LIB) of
+// the chain while streaming, as reported by the cursors of the stream and
+// optionally by an RPC node, and holds back blocks from the outputs that must
+// only see confirmed data.
+package chainhead
+
+import (
+	"sync"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+type BlockRef struct {
+	Num uint64 `json:"num"`
+	ID  string `json:"id,omitempty"`
+}
+
+type Status struct {
+	// Head is the head of the chain as seen by the stream, it's the last
+	// block received unless the server reports a head ahead of it.
+	Head BlockRef `json:"head"`
+	LIB  BlockRef `json:"lib"`
+	// Block is the last block received.
+	Block BlockRef `json:"block"`
+	// NetworkHead is the head reported by the RPC node, 0 when not polled.
+	NetworkHead uint64    `json:"network_head,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// Tracker keeps the head and LIB of the stream up to date, it's safe for
+// concurrent use.
+type Tracker struct {
+	lock   sync.Mutex
+	status Status
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Observe updates the head and LIB with the block about to be written. The
+// cursor carries both when it's a firehose cursor, the clock and step of the
+// block are used otherwise.
+func (t *Tracker) Observe(data *pbsubstreams.BlockScopedData) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	block := BlockRef{Num: data.Clock.GetNumber(), ID: data.Clock.GetId()}
+	t.status.Block = block
+	t.status.UpdatedAt = time.Now()
+
+	if cursor, err := bstream.CursorFromOpaque(data.Cursor); err == nil && !cursor.IsEmpty() {
+		t.status.Head = BlockRef{Num: cursor.HeadBlock.Num(), ID: cursor.HeadBlock.ID()}
+		if cursor.LIB.Num() > t.status.LIB.Num {
+			t.status.LIB = BlockRef{Num: cursor.LIB.Num(), ID: cursor.LIB.ID()}
+		}
+	} else {
+		switch data.Step {
+		case pbsubstreams.ForkStep_STEP_NEW:
+			t.status.Head = block
+		case pbsubstreams.ForkStep_STEP_IRREVERSIBLE:
+			if block.Num >= t.status.Head.Num {
+				t.status.Head = block
+			}
+			if block.Num > t.status.LIB.Num {
+				t.status.LIB = block
+			}
+		}
+	}
+
+	headBlockNum.SetUint64(t.status.Head.Num)
+	libNum.SetUint64(t.status.LIB.Num)
+	lastBlockNum.SetUint64(block.Num)
+}
+
+// SetNetworkHead records the head reported by the RPC node.
+func (t *Tracker) SetNetworkHead(num uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.status.NetworkHead = num
+	networkHeadBlockNum.SetUint64(num)
+}
+
+func (t *Tracker) Status() Status {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.status
+}
+
+// Confirmed returns true once block `num` is irreversible or has `depth`
+// blocks on top of it, a `depth` of 0 waits for irreversibility. The highest
+// of the stream and network heads is used.
+func (t *Tracker) Confirmed(num uint64, depth uint64) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if num <= t.status.LIB.Num {
+		return true
+	}
+	if depth == 0 {
+		return false
+	}
+
+	head := t.status.Head.Num
+	if t.status.NetworkHead > head {
+		head = t.status.NetworkHead
+	}
+	return head >= num+depth
+}
@@ -0,0 +1,137 @@
+package chainhead
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	written []string
+}
+
+func (s *recordingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	s.written = append(s.written, fmt.Sprintf("%d%s", data.Clock.Number, stepSuffix(data.Step)))
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func stepSuffix(step pbsubstreams.ForkStep) string {
+	switch step {
+	case pbsubstreams.ForkStep_STEP_UNDO:
+		return "u"
+	case pbsubstreams.ForkStep_STEP_IRREVERSIBLE:
+		return "i"
+	}
+	return ""
+}
+
+func block(num uint64, branch string, step pbsubstreams.ForkStep) *pbsubstreams.BlockScopedData {
+	return &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%d%s", num, branch)},
+		Step:  step,
+	}
+}
+
+func TestTracker_Observe(t *testing.T) {
+	tracker := NewTracker()
+
+	cursor := &bstream.Cursor{
+		Step:      bstream.StepNew,
+		Block:     bstream.NewBlockRef("0a", 10),
+		HeadBlock: bstream.NewBlockRef("0c", 12),
+		LIB:       bstream.NewBlockRef("05", 5),
+	}
+	tracker.Observe(&pbsubstreams.BlockScopedData{Clock: &pbsubstreams.Clock{Number: 10, Id: "0a"}, Step: pbsubstreams.ForkStep_STEP_NEW, Cursor: cursor.ToOpaque()})
+
+	status := tracker.Status()
+	assert.Equal(t, BlockRef{Num: 12, ID: "0c"}, status.Head)
+	assert.Equal(t, BlockRef{Num: 5, ID: "05"}, status.LIB)
+	assert.Equal(t, BlockRef{Num: 10, ID: "0a"}, status.Block)
+
+	// Without a firehose cursor, the clock and step are used
+	tracker = NewTracker()
+	tracker.Observe(block(20, "", pbsubstreams.ForkStep_STEP_IRREVERSIBLE))
+	tracker.Observe(block(21, "", pbsubstreams.ForkStep_STEP_NEW))
+	tracker.Observe(block(21, "", pbsubstreams.ForkStep_STEP_UNDO))
+	status = tracker.Status()
+	assert.Equal(t, uint64(21), status.Head.Num)
+	assert.Equal(t, uint64(20), status.LIB.Num)
+	assert.Equal(t, uint64(21), status.Block.Num)
+}
+
+func TestTracker_Confirmed(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Observe(block(100, "", pbsubstreams.ForkStep_STEP_IRREVERSIBLE))
+	tracker.Observe(block(110, "", pbsubstreams.ForkStep_STEP_NEW))
+
+	tests := []struct {
+		num, depth uint64
+		expected   bool
+	}{
+		{100, 0, true},
+		{101, 0, false},
+		{105, 5, true},
+		{106, 5, false},
+		{99, 50, true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, tracker.Confirmed(test.num, test.depth), "block %d, depth %d", test.num, test.depth)
+	}
+
+	tracker.SetNetworkHead(120)
+	assert.True(t, tracker.Confirmed(115, 5))
+}
+
+func TestGate(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewTracker()
+	recorder := &recordingSink{}
+	out := NewSink(tracker, NewGate(tracker, 2, recorder))
+
+	steps := []*pbsubstreams.BlockScopedData{
+		block(1, "a", pbsubstreams.ForkStep_STEP_NEW),
+		block(2, "a", pbsubstreams.ForkStep_STEP_NEW),
+		block(3, "a", pbsubstreams.ForkStep_STEP_NEW), // confirms 1
+		block(3, "a", pbsubstreams.ForkStep_STEP_UNDO),
+		block(2, "a", pbsubstreams.ForkStep_STEP_UNDO),
+		block(2, "b", pbsubstreams.ForkStep_STEP_NEW),
+		block(3, "b", pbsubstreams.ForkStep_STEP_NEW),
+		block(4, "b", pbsubstreams.ForkStep_STEP_NEW), // confirms 2
+		block(4, "b", pbsubstreams.ForkStep_STEP_UNDO),
+		block(3, "b", pbsubstreams.ForkStep_STEP_UNDO),
+		block(2, "b", pbsubstreams.ForkStep_STEP_UNDO), // deeper than the gate
+		block(2, "c", pbsubstreams.ForkStep_STEP_NEW),
+		block(3, "c", pbsubstreams.ForkStep_STEP_IRREVERSIBLE), // releases 2
+	}
+	for _, data := range steps {
+		require.NoError(t, out.Write(ctx, data))
+	}
+
+	assert.Equal(t, []string{"1", "2", "2u", "2", "3i"}, recorder.written)
+	require.NoError(t, out.Close())
+}
+
+func TestPollRPC(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x12d687"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tracker := NewTracker()
+	go PollRPC(ctx, http.DefaultClient, tracker, server.URL, time.Hour)
+
+	assert.Eventually(t, func() bool { return tracker.Status().NetworkHead == 1234567 }, time.Second, 10*time.Millisecond)
+}
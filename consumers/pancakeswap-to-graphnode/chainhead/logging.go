@@ -0,0 +1,7 @@
+package chainhead
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.chainhead", "github.com/streamingfast/substream-pancakeswap/chainhead")
@@ -0,0 +1,19 @@
+package chainhead
+
+import (
+	"github.com/streamingfast/dmetrics"
+)
+
+var metrics = dmetrics.NewSet()
+
+var (
+	headBlockNum        = metrics.NewGauge("chain_head_block_num", "head block number of the stream")
+	libNum              = metrics.NewGauge("chain_lib_num", "last irreversible block number of the stream")
+	lastBlockNum        = metrics.NewGauge("chain_last_block_num", "number of the last block written to the outputs")
+	networkHeadBlockNum = metrics.NewGauge("chain_network_head_block_num", "head block number reported by the RPC node")
+	gatedBlocks         = metrics.NewGauge("chain_gated_blocks", "blocks held back from the confirmed outputs until they have enough confirmations")
+)
+
+func init() {
+	metrics.Register()
+}
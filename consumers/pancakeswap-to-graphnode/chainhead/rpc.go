@@ -0,0 +1,78 @@
+package chainhead
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PollRPC sets the network head of `tracker` from the `eth_blockNumber` of the
+// JSON-RPC node at `url` every `interval`, until `ctx` is done. Failures are
+// logged, the last known head is kept.
+func PollRPC(ctx context.Context, client *http.Client, tracker *Tracker, url string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		num, err := blockNumber(ctx, client, url)
+		if err != nil {
+			zlog.Warn("polling the network head", zap.String("url", url), zap.Error(err))
+		} else {
+			tracker.SetNetworkHead(num)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type rpcResponse struct {
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func blockNumber(ctx context.Context, client *http.Client, url string) (uint64, error) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("eth_blockNumber: %s", resp.Status)
+	}
+
+	var out rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("eth_blockNumber: decode response: %w", err)
+	}
+	if out.Error != nil {
+		return 0, fmt.Errorf("eth_blockNumber: %s (%d)", out.Error.Message, out.Error.Code)
+	}
+
+	num, err := strconv.ParseUint(strings.TrimPrefix(out.Result, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("eth_blockNumber: invalid result %q: %w", out.Result, err)
+	}
+	return num, nil
+}
@@ -0,0 +1,150 @@
+package chainhead
+
+import (
+	"context"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// Sink records the head and LIB of every block in the tracker before handing
+// the block to the wrapped sinks, it wraps all the other sinks so they see
+// the tracker up to date.
+type Sink struct {
+	tracker *Tracker
+	sinks   []sink.Sink
+}
+
+func NewSink(tracker *Tracker, sinks ...sink.Sink) *Sink {
+	return &Sink{tracker: tracker, sinks: sinks}
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	s.tracker.Observe(data)
+	for _, s := range s.sinks {
+		if err := s.Write(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sink) Flush(ctx context.Context) error {
+	return flushAll(ctx, s.sinks)
+}
+
+func (s *Sink) Close() error {
+	return closeAll(s.sinks)
+}
+
+// Gate holds back new blocks from the wrapped sinks until they're confirmed,
+// see `Tracker.Confirmed`, so they never see a block that gets undone. Undos
+// of held back blocks just drop them, undos of blocks already released, when
+// a reorg is deeper than the confirmation depth, are forwarded.
+//
+// Held back blocks aren't flushed: a run resumed from the cursor of a later
+// block doesn't write them to the wrapped sinks.
+type Gate struct {
+	tracker *Tracker
+	depth   uint64
+	sinks   []sink.Sink
+	pending []*pbsubstreams.BlockScopedData
+}
+
+func NewGate(tracker *Tracker, depth uint64, sinks ...sink.Sink) *Gate {
+	return &Gate{tracker: tracker, depth: depth, sinks: sinks}
+}
+
+// Add adds a sink, it must be called before the first `Write`.
+func (g *Gate) Add(s sink.Sink) {
+	g.sinks = append(g.sinks, s)
+}
+
+func (g *Gate) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	switch data.Step {
+	case pbsubstreams.ForkStep_STEP_NEW:
+		g.pending = append(g.pending, data)
+
+	case pbsubstreams.ForkStep_STEP_UNDO:
+		if last := len(g.pending) - 1; last >= 0 && g.pending[last].Clock.GetId() == data.Clock.GetId() {
+			g.pending = g.pending[:last]
+			break
+		}
+
+		zlog.Warn("undo of a block already released to the confirmed outputs, the confirmation depth is smaller than the reorg",
+			zap.Uint64("block_num", data.Clock.GetNumber()),
+			zap.String("block_id", data.Clock.GetId()),
+			zap.Uint64("depth", g.depth),
+		)
+		if err := g.write(ctx, data); err != nil {
+			return err
+		}
+
+	default:
+		if err := g.release(ctx); err != nil {
+			return err
+		}
+		if err := g.write(ctx, data); err != nil {
+			return err
+		}
+	}
+
+	return g.release(ctx)
+}
+
+// release writes the pending blocks confirmed by now, in order.
+func (g *Gate) release(ctx context.Context) error {
+	for len(g.pending) > 0 && g.tracker.Confirmed(g.pending[0].Clock.GetNumber(), g.depth) {
+		if err := g.write(ctx, g.pending[0]); err != nil {
+			return err
+		}
+		g.pending = g.pending[1:]
+	}
+	gatedBlocks.SetUint64(uint64(len(g.pending)))
+	return nil
+}
+
+func (g *Gate) write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	for _, s := range g.sinks {
+		if err := s.Write(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of blocks held back.
+func (g *Gate) Pending() int {
+	return len(g.pending)
+}
+
+func (g *Gate) Flush(ctx context.Context) error {
+	return flushAll(ctx, g.sinks)
+}
+
+func (g *Gate) Close() error {
+	if len(g.pending) > 0 {
+		zlog.Info("dropping blocks not confirmed yet", zap.Int("blocks", len(g.pending)), zap.Uint64("first_block", g.pending[0].Clock.GetNumber()))
+	}
+	return closeAll(g.sinks)
+}
+
+func flushAll(ctx context.Context, sinks []sink.Sink) error {
+	for _, s := range sinks {
+		if err := sink.Flush(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func closeAll(sinks []sink.Sink) error {
+	var firstErr error
+	for _, s := range sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,118 @@
+package exchange
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/audit"
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	"go.uber.org/zap"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit <store url>",
+	Short: "compare the reserves and volumes of a sample of pairs with the PancakeSwap subgraph at several blocks",
+	Long: `Compare the reserves and cumulative volumes of pairs, as recorded by the
+'store_reserves' and 'store_volumes' modules in a delta log written by the
+'deltalog' output, with a PancakeSwap exchange subgraph queried at the same
+blocks. The pairs are --pairs, or --samples pairs picked at random among the
+ones with reserves at the first block, the blocks are --heights blocks evenly
+spread over [--start-block, --stop-block].
+
+Values differing by more than --tolerance are reported, along with the pairs
+missing on one side. The volumes only match when the stores were computed from
+the creation of the pairs.`,
+	RunE:         runAudit,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	auditCmd.Flags().String("subgraph-url", "https://api.thegraph.com/subgraphs/name/pancakeswap/exchange", "GraphQL endpoint of the reference subgraph, it must serve the blocks audited")
+	auditCmd.Flags().String("namespace", "", "namespace of the stores in the delta log")
+	auditCmd.Flags().StringSlice("pairs", nil, "addresses of the pairs compared, --samples pairs picked at random when empty")
+	auditCmd.Flags().Int("samples", 20, "number of pairs picked at random")
+	auditCmd.Flags().Int64("seed", 0, "seed of the random pick, a new one each run when 0, it's logged to pick the same pairs again")
+	auditCmd.Flags().Uint64("start-block", 0, "first block audited")
+	auditCmd.Flags().Uint64("stop-block", 0, "last block audited, included")
+	auditCmd.Flags().Int("heights", 5, "number of blocks audited, evenly spread over [--start-block, --stop-block]")
+	auditCmd.Flags().Float64("tolerance", 0.001, "relative difference allowed between two values, 0.001 being 0.1%")
+	auditCmd.Flags().String("format", "text", "report format, 'text' or 'json'")
+	auditCmd.Flags().StringP("output", "o", "", "write the report to this file instead of stdout")
+	auditCmd.Flags().Bool("fail-on-discrepancy", false, "exit with an error when there are discrepancies, for scheduled audits")
+
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	var write func(report *audit.Report, w io.Writer) error
+	switch format := mustGetString(cmd, "format"); format {
+	case "text":
+		write = (*audit.Report).WriteText
+	case "json":
+		write = (*audit.Report).WriteJSON
+	default:
+		return fmt.Errorf("invalid --format %q, expected one of: text, json", format)
+	}
+
+	stopBlock := mustGetUint64(cmd, "stop-block")
+	if stopBlock == 0 {
+		return fmt.Errorf("--stop-block is required")
+	}
+	startBlock := mustGetUint64(cmd, "start-block")
+	if startBlock > stopBlock {
+		return fmt.Errorf("--start-block %d is after --stop-block %d", startBlock, stopBlock)
+	}
+
+	seed := mustGetInt64(cmd, "seed")
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	subgraphURL := mustGetString(cmd, "subgraph-url")
+	zlog.Info("auditing the stores", zap.String("subgraph_url", subgraphURL), zap.Int64("seed", seed))
+	report, err := audit.Run(cmd.Context(), audit.NewStores(reader, mustGetString(cmd, "namespace")), audit.NewSubgraph(http.DefaultClient, subgraphURL), audit.Config{
+		Pairs:     mustGetStringSlice(cmd, "pairs"),
+		Samples:   mustGetInt(cmd, "samples"),
+		Seed:      seed,
+		Blocks:    audit.Blocks(startBlock, stopBlock, mustGetInt(cmd, "heights")),
+		Tolerance: mustGetFloat64(cmd, "tolerance"),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeAuditReport(report, mustGetString(cmd, "output"), write); err != nil {
+		return err
+	}
+
+	if mustGetBool(cmd, "fail-on-discrepancy") && len(report.Discrepancies) > 0 {
+		return fmt.Errorf("%d discrepancies with the subgraph", len(report.Discrepancies))
+	}
+	return nil
+}
+
+func writeAuditReport(report *audit.Report, output string, write func(report *audit.Report, w io.Writer) error) error {
+	if output == "" {
+		return write(report, os.Stdout)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("create report file: %w", err)
+	}
+	if err := write(report, f); err != nil {
+		f.Close()
+		return fmt.Errorf("write report: %w", err)
+	}
+	return f.Close()
+}
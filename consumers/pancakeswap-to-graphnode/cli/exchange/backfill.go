@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// streamMode tells the fork steps a run streams, and hands a run backfilling
+// irreversible blocks off to following the head, see --live-after-backfill.
+type streamMode struct {
+	backfilling   bool
+	liveForkSteps []pbsubstreams.ForkStep
+	batched       []sink.Sink
+	handoffLag    time.Duration
+
+	flushInterval time.Duration
+	lastFlush     time.Time
+}
+
+// newStreamMode follows the head, reversible blocks included, when `following`,
+// the run having an undo buffer, and only streams irreversible blocks otherwise.
+// With `backfill` the irreversible blocks are streamed first, `batched` batching
+// their writes and flushed every `flushInterval`, until the backfill catches up,
+// see `backfillStream` for `handoffLag`.
+func newStreamMode(following, backfill bool, batched []sink.Sink, flushInterval, handoffLag time.Duration) (*streamMode, error) {
+	m := &streamMode{
+		liveForkSteps: []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_IRREVERSIBLE},
+		batched:       batched,
+		handoffLag:    handoffLag,
+		flushInterval: flushInterval,
+	}
+	if following {
+		m.liveForkSteps = []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_NEW, pbsubstreams.ForkStep_STEP_UNDO}
+	}
+
+	if backfill {
+		if !following {
+			return nil, fmt.Errorf("--live-after-backfill requires --undo-buffer-dir")
+		}
+		m.backfilling = true
+		m.lastFlush = time.Now()
+		for _, s := range batched {
+			sink.SetBatching(s, true)
+		}
+	}
+	return m, nil
+}
+
+// ForkSteps returns the fork steps of the first stream of the run. While
+// backfilling they include the steps of the head, the first block that isn't
+// irreversible hands off to live streaming, see `backfillStream`, whatever the
+// lag of the LIB.
+func (m *streamMode) ForkSteps() []pbsubstreams.ForkStep {
+	if m.backfilling {
+		return []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_IRREVERSIBLE, pbsubstreams.ForkStep_STEP_NEW, pbsubstreams.ForkStep_STEP_UNDO}
+	}
+	return m.liveForkSteps
+}
+
+// Wrap ends `stream` with `errCaughtUp` once the backfill caught up.
+func (m *streamMode) Wrap(stream pbsubstreams.Stream_BlocksClient) pbsubstreams.Stream_BlocksClient {
+	if m.backfilling {
+		return &backfillStream{Stream_BlocksClient: stream, handoffLag: m.handoffLag}
+	}
+	return stream
+}
+
+// Boundary flushes `out` every flush interval while backfilling, batched
+// outputs are still written regularly.
+func (m *streamMode) Boundary(ctx context.Context, out sink.Sink) error {
+	if !m.backfilling || time.Since(m.lastFlush) < m.flushInterval {
+		return nil
+	}
+	if err := sink.Flush(ctx, out); err != nil {
+		return fmt.Errorf("flushing outputs: %w", err)
+	}
+	m.lastFlush = time.Now()
+	return nil
+}
+
+// GoLive flushes the outputs batched while backfilling, and sets `req` to
+// stream again after `last`, following the head. `last` is nil when no block
+// was written, the start block not being irreversible.
+func (m *streamMode) GoLive(ctx context.Context, out sink.Sink, req *pbsubstreams.Request, last *pbsubstreams.BlockScopedData) error {
+	m.backfilling = false
+	for _, s := range m.batched {
+		sink.SetBatching(s, false)
+	}
+	if err := sink.Flush(ctx, out); err != nil {
+		return fmt.Errorf("flushing outputs: %w", err)
+	}
+	zlog.Info("backfill caught up, switching to live streaming", zap.Uint64("after_block", last.GetClock().GetNumber()))
+
+	if last != nil {
+		req.StartCursor = last.Cursor
+	}
+	req.ForkSteps = m.liveForkSteps
+	return nil
+}
+
+// errCaughtUp stops the backfill of --live-after-backfill.
+var errCaughtUp = errors.New("backfill caught up with the chain head")
+
+// backfillStream ends the backfill of --live-after-backfill with
+// `errCaughtUp` at the first block that isn't irreversible, before it's
+// written: the stream then reached the blocks past the LIB, only streamed
+// by following the head. It doesn't depend on how far behind the head the
+// LIB is.
+//
+// Servers streaming irreversible blocks only, whatever the fork steps
+// requested, never send such a block. The backfill then also ends at the first
+// block less than `handoffLag` old, see --handoff-lag, 0 turning it off.
+type backfillStream struct {
+	pbsubstreams.Stream_BlocksClient
+	handoffLag time.Duration
+}
+
+func (s *backfillStream) Recv() (*pbsubstreams.Response, error) {
+	resp, err := s.Stream_BlocksClient.Recv()
+	if err != nil {
+		return nil, err
+	}
+	data := resp.GetData()
+	if data == nil {
+		return resp, nil
+	}
+	if data.Step != pbsubstreams.ForkStep_STEP_IRREVERSIBLE {
+		return nil, errCaughtUp
+	}
+	if s.handoffLag > 0 && time.Since(data.Clock.GetTimestamp().AsTime()) <= s.handoffLag {
+		return nil, errCaughtUp
+	}
+	return resp, nil
+}
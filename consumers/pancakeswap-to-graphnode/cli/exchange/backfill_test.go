@@ -0,0 +1,189 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// replayedStream returns `responses` then io.EOF.
+type replayedStream struct {
+	pbsubstreams.Stream_BlocksClient
+	responses []*pbsubstreams.Response
+}
+
+func (s *replayedStream) Recv() (*pbsubstreams.Response, error) {
+	if len(s.responses) == 0 {
+		return nil, io.EOF
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+func blockResponse(num uint64, step pbsubstreams.ForkStep) *pbsubstreams.Response {
+	return &pbsubstreams.Response{Message: &pbsubstreams.Response_Data{Data: &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Number: num},
+		Step:  step,
+	}}}
+}
+
+// batchingSink records whether it's batching and how many times it's flushed.
+type batchingSink struct {
+	batching bool
+	flushes  int
+}
+
+func (s *batchingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	return nil
+}
+
+func (s *batchingSink) Flush(ctx context.Context) error {
+	s.flushes++
+	return nil
+}
+
+func (s *batchingSink) SetBatching(enabled bool) { s.batching = enabled }
+func (s *batchingSink) Close() error             { return nil }
+
+func TestBackfillStream(t *testing.T) {
+	stream := &backfillStream{Stream_BlocksClient: &replayedStream{responses: []*pbsubstreams.Response{
+		blockResponse(1, pbsubstreams.ForkStep_STEP_IRREVERSIBLE),
+		{Message: &pbsubstreams.Response_Progress{}},
+		blockResponse(2, pbsubstreams.ForkStep_STEP_IRREVERSIBLE),
+		blockResponse(3, pbsubstreams.ForkStep_STEP_NEW),
+		blockResponse(4, pbsubstreams.ForkStep_STEP_NEW),
+	}}}
+
+	var received []uint64
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			assert.Equal(t, errCaughtUp, err)
+			break
+		}
+		if data := resp.GetData(); data != nil {
+			received = append(received, data.Clock.Number)
+		}
+	}
+	assert.Equal(t, []uint64{1, 2}, received, "the first block past the LIB isn't written while backfilling")
+
+	stream = &backfillStream{Stream_BlocksClient: &replayedStream{responses: []*pbsubstreams.Response{
+		blockResponse(1, pbsubstreams.ForkStep_STEP_IRREVERSIBLE),
+	}}}
+	_, err := stream.Recv()
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err, "the end of the stream isn't a hand off")
+}
+
+func TestBackfillStream_IrreversibleOnly(t *testing.T) {
+	// the block `num`, irreversible, produced `age` ago
+	irreversible := func(num uint64, age time.Duration) *pbsubstreams.Response {
+		resp := blockResponse(num, pbsubstreams.ForkStep_STEP_IRREVERSIBLE)
+		resp.GetData().Clock.Timestamp = timestamppb.New(time.Now().Add(-age))
+		return resp
+	}
+
+	// the server only streams irreversible blocks, whatever the fork steps requested
+	responses := []*pbsubstreams.Response{
+		irreversible(1, 2*time.Hour),
+		irreversible(2, time.Hour),
+		irreversible(3, 30*time.Second),
+		irreversible(4, 27*time.Second),
+	}
+
+	stream := &backfillStream{Stream_BlocksClient: &replayedStream{responses: responses}, handoffLag: time.Minute}
+	var received []uint64
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			assert.Equal(t, errCaughtUp, err)
+			break
+		}
+		received = append(received, resp.GetData().Clock.Number)
+	}
+	assert.Equal(t, []uint64{1, 2}, received, "the first block within the handoff lag hands off")
+
+	stream = &backfillStream{Stream_BlocksClient: &replayedStream{responses: responses}}
+	received = nil
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			assert.Equal(t, io.EOF, err, "never caught up without a handoff lag")
+			break
+		}
+		received = append(received, resp.GetData().Clock.Number)
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4}, received)
+}
+
+func TestStreamMode(t *testing.T) {
+	irreversible := []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_IRREVERSIBLE}
+	live := []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_NEW, pbsubstreams.ForkStep_STEP_UNDO}
+	backfill := []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_IRREVERSIBLE, pbsubstreams.ForkStep_STEP_NEW, pbsubstreams.ForkStep_STEP_UNDO}
+
+	tests := []struct {
+		name              string
+		following         bool
+		backfill          bool
+		expectedForkSteps []pbsubstreams.ForkStep
+		expectedErr       string
+	}{
+		{"irreversible blocks", false, false, irreversible, ""},
+		{"following the head", true, false, live, ""},
+		{"backfill then live", true, true, backfill, ""},
+		{"backfill without undo buffer", false, true, nil, "--live-after-backfill requires --undo-buffer-dir"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			batched := &batchingSink{}
+			mode, err := newStreamMode(test.following, test.backfill, []sink.Sink{batched}, time.Hour, time.Minute)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedForkSteps, mode.ForkSteps())
+			assert.Equal(t, test.backfill, batched.batching)
+
+			_, wrapped := mode.Wrap(&replayedStream{}).(*backfillStream)
+			assert.Equal(t, test.backfill, wrapped, "the stream ends once the backfill caught up")
+		})
+	}
+}
+
+func TestStreamMode_GoLive(t *testing.T) {
+	ctx := context.Background()
+	batched := &batchingSink{}
+	out := sink.NewFanout(1, batched)
+
+	mode, err := newStreamMode(true, true, []sink.Sink{batched}, 0, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, mode.Boundary(ctx, out))
+	assert.Equal(t, 1, batched.flushes, "flushed every interval while backfilling")
+
+	req := &pbsubstreams.Request{StartCursor: "start", ForkSteps: mode.ForkSteps()}
+	require.NoError(t, mode.GoLive(ctx, out, req, nil))
+	assert.Equal(t, "start", req.StartCursor, "no block written yet")
+
+	last := &pbsubstreams.BlockScopedData{Step: pbsubstreams.ForkStep_STEP_IRREVERSIBLE, Cursor: "cursor-12", Clock: &pbsubstreams.Clock{Number: 12}}
+	require.NoError(t, mode.GoLive(ctx, out, req, last))
+	assert.Equal(t, "cursor-12", req.StartCursor)
+	assert.Equal(t, []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_NEW, pbsubstreams.ForkStep_STEP_UNDO}, req.ForkSteps)
+	assert.False(t, batched.batching)
+	assert.Equal(t, 3, batched.flushes, "the batched blocks are flushed when going live")
+
+	require.NoError(t, mode.Boundary(ctx, out))
+	assert.Equal(t, 3, batched.flushes, "outputs aren't flushed by the mode once live")
+	_, wrapped := mode.Wrap(&replayedStream{}).(*backfillStream)
+	assert.False(t, wrapped)
+}
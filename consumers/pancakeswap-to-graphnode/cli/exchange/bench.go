@@ -0,0 +1,360 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/bench"
+	"github.com/streamingfast/substream-pancakeswap/ethrpc"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	"github.com/streamingfast/substream-pancakeswap/reorg"
+	"github.com/streamingfast/substream-pancakeswap/report"
+	"github.com/streamingfast/substream-pancakeswap/rpcusage"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/sqlsink"
+	"github.com/streamingfast/substream-pancakeswap/sink/undo"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "performance benchmarking helpers",
+}
+
+var benchGenerateBlocksCmd = &cobra.Command{
+	Use:          "generate-blocks",
+	Short:        "synthesize PancakeSwap blocks (pair creations, swaps and reserve updates) into a local blocks directory",
+	RunE:         runBenchGenerateBlocks,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+var benchRunCmd = &cobra.Command{
+	Use:          "run",
+	Short:        "run a standardized scenario through the Go modules pipeline and print its performance report as JSON",
+	RunE:         runBenchRun,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+var benchReorgCmd = &cobra.Command{
+	Use:   "reorg",
+	Short: "play a scripted sequence of forks through the stream handler and check the outputs converge to the canonical chain",
+	Long: `Play a script like 'advance 5, undo 2, advance 3' as a stream of new and undo
+blocks carrying synthetic store deltas, the blocks re-advanced after an undo
+being on another branch. The stream goes through the same handler as 'run',
+into an in-memory state and optionally the --sql database, which must both end
+up with the state of the canonical chain alone.
+
+With --bare-undos, undo blocks are sent without their deltas as a server
+not resending them would, only the undo buffer (see --undo-buffer-size) can
+then revert the blocks.`,
+	RunE:         runBenchReorg,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+func init() {
+	benchGenerateBlocksCmd.Flags().StringP("output-dir", "o", "./blocks", "directory where the blocks bundles are written")
+	benchGenerateBlocksCmd.Flags().Uint64P("start-block", "s", 0, "number of the first generated block")
+	benchGenerateBlocksCmd.Flags().Uint64P("blocks", "n", 1000, "number of blocks to generate")
+	benchGenerateBlocksCmd.Flags().Duration("block-time", 3*time.Second, "time between two blocks")
+	benchGenerateBlocksCmd.Flags().Int("initial-pairs", 100, "number of pairs created in the first block")
+	benchGenerateBlocksCmd.Flags().Float64("pairs-per-second", 0.05, "rate of pair creations")
+	benchGenerateBlocksCmd.Flags().Float64("swaps-per-second", 30, "rate of swaps, each swap updates the reserves of its pair")
+	benchGenerateBlocksCmd.Flags().String("distribution", string(bench.Zipf), "how swaps are spread over pairs, 'uniform' or 'zipf' (a few hot pairs)")
+	benchGenerateBlocksCmd.Flags().Float64("max-swap-size", 0.02, "largest share of a pair reserve moved by a single swap")
+	benchGenerateBlocksCmd.Flags().Int64("seed", 1, "random seed, the same flags always generate the same blocks")
+
+	benchRunCmd.Flags().String("scenario", "backfill-10k", fmt.Sprintf("scenario to run, one of %s", strings.Join(bench.ScenarioNames(), ", ")))
+	benchRunCmd.Flags().String("blocks-dir", "", "read the blocks from a directory written by 'bench generate-blocks' instead of generating them in memory")
+	benchRunCmd.Flags().Int("prefetch-blocks", 0, "number of blocks read and decoded from --blocks-dir ahead of the modules execution, read inline when 0")
+	benchRunCmd.Flags().Int64("store-memory-budget", 0, "bytes of keys and values each store keeps in memory, least recently used keys spill to disk past it, unlimited when 0")
+	benchRunCmd.Flags().String("spill-dir", os.TempDir(), "directory of the stores overflow files, see --store-memory-budget")
+	benchRunCmd.Flags().StringSlice("bloom-store", nil, "store module whose lookups of missing keys are answered by a bloom filter of its keys, like 'bench_pairs' checked for every log, the report tells the false positive rate, can be repeated")
+	benchRunCmd.Flags().Int("bloom-keys", 100_000, "number of keys the filters of --bloom-store are sized for, their false positive rate grows past it")
+	benchRunCmd.Flags().String("rpc-endpoint", "", "JSON-RPC node the modules' eth_calls are sent to, pinned to the block being processed, the calls fail when empty")
+	benchRunCmd.Flags().Int("rpc-batch-size", 20, "eth_calls per request to --rpc-endpoint, the calls of a module beyond it, like the metadata lookups of the tokens of many new pairs, being sent as concurrent requests, all of them in a single request when 0")
+	benchRunCmd.Flags().Int("rpc-max-concurrency", 16, "requests in flight to --rpc-endpoint, halved whenever the node rate limits (HTTP 429) or times out a request and raised back as it answers, see --rpc-batch-size")
+	benchRunCmd.Flags().Duration("rpc-timeout", 10*time.Second, "how long a request to --rpc-endpoint is waited on before being sent again, see --rpc-batch-size")
+	benchRunCmd.Flags().Int("rpc-max-retries", 5, "times a request to --rpc-endpoint rate limited or timing out is sent again before the block fails, see --rpc-batch-size")
+	benchRunCmd.Flags().Int("rpc-cache-size", 10_000, "number of eth_call responses kept in memory, concurrent identical calls sharing a single request, no cache when 0")
+	benchRunCmd.Flags().Bool("strict-rpc", false, "fail the run when a module makes an eth_call against another block than the one being processed, or against the head of the chain")
+	benchRunCmd.Flags().StringSlice("rpc-price", nil, "cost of a JSON-RPC call as '<method>=<price>', '*' pricing the other methods, for the usage in the report")
+	benchRunCmd.Flags().StringSlice("record", nil, "write the deltas of a store module to a directory, as '<module>=<dir>', for later runs to --mock it")
+	benchRunCmd.Flags().StringSlice("mock", nil, "replay the deltas recorded by --record instead of running a store module, as '<module>=<dir>', the modules only it depends on don't run either")
+	benchRunCmd.Flags().StringSlice("module-error-policy", nil, "how the failures of a module are handled, as '<module>=<policy>', the policy being 'halt', 'retry[:<retries>]' or 'skip' (recording the block in --quarantine-store), every module halting the run unless set, can be repeated")
+	benchRunCmd.Flags().Duration("module-retry-backoff", time.Second, "wait before the first retry of a module with a retry policy, doubling for the next ones, see --module-error-policy")
+	benchRunCmd.Flags().String("quarantine-store", "", "dstore URL, or local directory, where the blocks skipped by the modules with a skip policy are recorded, see --module-error-policy")
+	benchRunCmd.Flags().StringSlice("hook", nil, "registered hook run around the modules of every block, in the order given, see modules.RegisterHook")
+	benchRunCmd.Flags().StringP("output", "o", "", "write the report to this file instead of stdout")
+
+	benchReorgCmd.Flags().String("script", "advance 5, undo 2, advance 3", "comma separated steps, each 'advance <blocks>' or 'undo <blocks>'")
+	benchReorgCmd.Flags().Uint64("start-block", 1000, "number of the first block")
+	benchReorgCmd.Flags().Int64("seed", 1, "random seed of the store deltas")
+	benchReorgCmd.Flags().Bool("bare-undos", false, "send the undo blocks without their deltas")
+	benchReorgCmd.Flags().Int("undo-buffer-size", 0, "write through an undo buffer of this many blocks, kept in a temporary directory, none when 0")
+	benchReorgCmd.Flags().String("sql", "", "also write the stores to this SQL database, see 'run --sql', it must be empty")
+	benchReorgCmd.Flags().StringP("output", "o", "", "write the report to this file instead of stdout")
+
+	benchCmd.AddCommand(benchGenerateBlocksCmd)
+	benchCmd.AddCommand(benchRunCmd)
+	benchCmd.AddCommand(benchReorgCmd)
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBenchGenerateBlocks(cmd *cobra.Command, args []string) error {
+	generator, err := bench.NewGenerator(bench.GeneratorConfig{
+		StartBlock:     mustGetUint64(cmd, "start-block"),
+		BlockTime:      mustGetDuration(cmd, "block-time"),
+		InitialPairs:   mustGetInt(cmd, "initial-pairs"),
+		PairsPerSecond: mustGetFloat64(cmd, "pairs-per-second"),
+		SwapsPerSecond: mustGetFloat64(cmd, "swaps-per-second"),
+		Distribution:   bench.Distribution(mustGetString(cmd, "distribution")),
+		MaxSwapSize:    mustGetFloat64(cmd, "max-swap-size"),
+		Seed:           mustGetInt64(cmd, "seed"),
+	})
+	if err != nil {
+		return fmt.Errorf("generator setup: %w", err)
+	}
+
+	outputDir := mustGetString(cmd, "output-dir")
+	count := mustGetUint64(cmd, "blocks")
+	if err := bench.WriteBlocks(outputDir, count, generator.Next); err != nil {
+		return fmt.Errorf("generating blocks: %w", err)
+	}
+
+	fmt.Printf("Wrote %d blocks to %s\n", count, outputDir)
+	return nil
+}
+
+// rpcRetryBackoff is the wait before the first retry of a throttled request,
+// doubling for the next ones.
+const rpcRetryBackoff = 200 * time.Millisecond
+
+func runBenchRun(cmd *cobra.Command, args []string) error {
+	scenario, err := bench.GetScenario(mustGetString(cmd, "scenario"))
+	if err != nil {
+		return err
+	}
+
+	record, err := parseModuleDirs("record", mustGetStringSlice(cmd, "record"))
+	if err != nil {
+		return err
+	}
+	mocks, err := parseModuleDirs("mock", mustGetStringSlice(cmd, "mock"))
+	if err != nil {
+		return err
+	}
+
+	rpcPrices, err := rpcusage.ParsePrices(mustGetStringSlice(cmd, "rpc-price"))
+	if err != nil {
+		return err
+	}
+
+	policies, err := parseErrorPolicies(mustGetStringSlice(cmd, "module-error-policy"), mustGetDuration(cmd, "module-retry-backoff"))
+	if err != nil {
+		return err
+	}
+
+	zlog.Info("running scenario", zap.String("scenario", scenario.Name), zap.String("description", scenario.Description))
+	result, err := scenario.Run(bench.RunOptions{
+		BlocksDir:      mustGetString(cmd, "blocks-dir"),
+		PrefetchBlocks: mustGetInt(cmd, "prefetch-blocks"),
+		MemoryBudget:   mustGetInt64(cmd, "store-memory-budget"),
+		SpillDir:       mustGetString(cmd, "spill-dir"),
+		BloomStores:    mustGetStringSlice(cmd, "bloom-store"),
+		BloomKeys:      mustGetInt(cmd, "bloom-keys"),
+		Hooks:          mustGetStringSlice(cmd, "hook"),
+		Mocks:          mocks,
+		Record:         record,
+		RPCEndpoint:    mustGetString(cmd, "rpc-endpoint"),
+		RPCCacheSize:   mustGetInt(cmd, "rpc-cache-size"),
+		StrictRPC:      mustGetBool(cmd, "strict-rpc"),
+		RPCPrices:      rpcPrices,
+		RPCConcurrency: ethrpc.Concurrency{
+			BatchSize:   mustGetInt(cmd, "rpc-batch-size"),
+			MinRequests: 1,
+			MaxRequests: mustGetInt(cmd, "rpc-max-concurrency"),
+			Timeout:     mustGetDuration(cmd, "rpc-timeout"),
+			MaxRetries:  mustGetInt(cmd, "rpc-max-retries"),
+			Backoff:     rpcRetryBackoff,
+		},
+		ErrorPolicies: policies,
+		Quarantine:    mustGetString(cmd, "quarantine-store"),
+	})
+	if err != nil {
+		return fmt.Errorf("running scenario %q: %w", scenario.Name, err)
+	}
+
+	content, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+
+	if output := mustGetString(cmd, "output"); output != "" {
+		if err := os.WriteFile(output, content, 0644); err != nil {
+			return fmt.Errorf("write report %q: %w", output, err)
+		}
+		return nil
+	}
+
+	fmt.Println(string(content))
+	return nil
+}
+
+type reorgReport struct {
+	Script  string         `json:"script"`
+	Blocks  int            `json:"blocks"`
+	Undos   int            `json:"undos"`
+	Targets []*reorgTarget `json:"targets"`
+}
+
+type reorgTarget struct {
+	Name      string   `json:"name"`
+	Converged bool     `json:"converged"`
+	Diffs     []string `json:"diffs,omitempty"`
+}
+
+// parseModuleDirs parses the '<module>=<dir>' values of flag `name`.
+func parseModuleDirs(name string, values []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --%s %q, expected '<module>=<dir>'", name, value)
+		}
+		if _, found := out[parts[0]]; found {
+			return nil, fmt.Errorf("invalid --%s, module %q given twice", name, parts[0])
+		}
+		out[parts[0]] = parts[1]
+	}
+	return out, nil
+}
+
+// parseErrorPolicies parses the '<module>=<policy>' values of
+// --module-error-policy, the retries waiting `backoff`.
+func parseErrorPolicies(values []string, backoff time.Duration) (map[string]modules.ErrorPolicy, error) {
+	out := map[string]modules.ErrorPolicy{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --module-error-policy %q, expected '<module>=<policy>'", value)
+		}
+		if _, found := out[parts[0]]; found {
+			return nil, fmt.Errorf("invalid --module-error-policy, module %q given twice", parts[0])
+		}
+
+		policy, err := modules.ParseErrorPolicy(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid --module-error-policy for module %q: %w", parts[0], err)
+		}
+		policy.Backoff = backoff
+		out[parts[0]] = policy
+	}
+	return out, nil
+}
+
+func runBenchReorg(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	script := mustGetString(cmd, "script")
+	steps, err := reorg.ParseScript(script)
+	if err != nil {
+		return err
+	}
+
+	chain := reorg.NewChain(reorg.Config{
+		StartBlock: mustGetUint64(cmd, "start-block"),
+		Seed:       mustGetInt64(cmd, "seed"),
+		BareUndos:  mustGetBool(cmd, "bare-undos"),
+	})
+	blocks, err := chain.Play(steps)
+	if err != nil {
+		return fmt.Errorf("playing %q: %w", script, err)
+	}
+
+	stateSink := reorg.NewStateSink()
+	var sqlSink *sqlsink.Sink
+	var out sink.Sink = stateSink
+	if spec := mustGetString(cmd, "sql"); spec != "" {
+		s, err := newSQLSink(ctx, spec, nil)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if sqlSink, ok = s.(*sqlsink.Sink); !ok {
+			s.Close()
+			return fmt.Errorf("--sql %q is not a SQL database", spec)
+		}
+		out = sink.NewFanout(1, stateSink, sqlSink)
+	}
+
+	if size := mustGetInt(cmd, "undo-buffer-size"); size > 0 {
+		dir, err := os.MkdirTemp("", "reorg-undo-")
+		if err != nil {
+			return fmt.Errorf("create undo buffer directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		undoLog, err := undo.Open(dir, size)
+		if err != nil {
+			return err
+		}
+		out = undo.NewSink(undoLog, out)
+	}
+	defer out.Close()
+
+	if err := processStream(ctx, reorg.NewStream(ctx, blocks), nil, nil, out, report.NewSummary(), nil, func(*pbsubstreams.BlockScopedData) error { return nil }); err != nil {
+		return fmt.Errorf("processing stream: %w", err)
+	}
+
+	result := &reorgReport{Script: script, Blocks: len(blocks)}
+	for _, data := range blocks {
+		if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+			result.Undos++
+		}
+	}
+
+	actual := map[string]reorg.State{"state": stateSink.State()}
+	targets := []string{"state"}
+	if sqlSink != nil {
+		state, err := sqlSink.State(ctx)
+		if err != nil {
+			return err
+		}
+		actual["sql"] = state
+		targets = append(targets, "sql")
+	}
+
+	diverged := false
+	for _, name := range targets {
+		target := &reorgTarget{Name: name, Diffs: reorg.Diff(chain.Expected(), actual[name])}
+		target.Converged = len(target.Diffs) == 0
+		diverged = diverged || !target.Converged
+		result.Targets = append(result.Targets, target)
+	}
+
+	content, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+
+	if output := mustGetString(cmd, "output"); output != "" {
+		if err := os.WriteFile(output, content, 0644); err != nil {
+			return fmt.Errorf("write report %q: %w", output, err)
+		}
+	} else {
+		fmt.Println(string(content))
+	}
+
+	if diverged {
+		return fmt.Errorf("outputs diverged from the canonical chain")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+var deltalogCmd = &cobra.Command{
+	Use:   "deltalog",
+	Short: "stores deltas persisted by the 'deltalog' output",
+}
+
+var deltalogReplayCmd = &cobra.Command{
+	Use:          "replay <store url> [<store module>...]",
+	Short:        "write the persisted deltas of the given stores, all of them when none, to the configured outputs without re-running the modules",
+	RunE:         runDeltalogReplay,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+}
+
+var deltalogTopicsCmd = &cobra.Command{
+	Use:          "topics <store url>",
+	Short:        "list the stores of the log per namespace, see the 'namespace' parameter of the 'deltalog' output",
+	RunE:         runDeltalogTopics,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+var deltalogMergeCmd = &cobra.Command{
+	Use:          "merge <output store url> <input store url>...",
+	Short:        "copy the delta logs written by the shards of a backfill, see 'plan shard', into a single log",
+	RunE:         runDeltalogMerge,
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+}
+
+func init() {
+	deltalogReplayCmd.Flags().Uint64P("start-block", "s", 0, "first block replayed")
+	deltalogReplayCmd.Flags().Uint64P("stop-block", "t", 0, "block at which the replay stops, exclusive, until the end of the log when 0")
+	deltalogReplayCmd.Flags().String("group", "", "replay as this consumer group, resuming after the blocks the group already acknowledged, the whole range otherwise")
+	deltalogReplayCmd.Flags().StringSliceP("output", "o", []string{"jsonl"}, "where deltas are written, in the form <scheme>[:<params>], can be repeated, see 'run --output'")
+	deltalogReplayCmd.Flags().String("output-format", sink.FormatJSON, "how the 'jsonl' outputs write the deltas, 'json', 'console' or 'text', see 'run --output-format'")
+
+	deltalogCmd.AddCommand(deltalogReplayCmd)
+	deltalogCmd.AddCommand(deltalogTopicsCmd)
+	deltalogCmd.AddCommand(deltalogMergeCmd)
+	rootCmd.AddCommand(deltalogCmd)
+}
+
+func runDeltalogReplay(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	topics := args[1:]
+	if len(topics) == 0 {
+		topics, err = reader.Topics(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	renderer, err := sink.NewRenderer(mustGetString(cmd, "output-format"))
+	if err != nil {
+		return fmt.Errorf("--output-format: %w", err)
+	}
+
+	out := sink.NewFanout(0)
+	defer func() {
+		if err := out.Close(); err != nil {
+			zlog.Warn("closing sinks", zap.Error(err))
+		}
+	}()
+	for _, spec := range mustGetStringSlice(cmd, "output") {
+		s, err := sink.New(ctx, spec)
+		if err != nil {
+			return err
+		}
+		sink.SetRenderer(s, renderer)
+		out.Add(s)
+	}
+
+	// Topics are replayed one after the other, each in block order.
+	start, stop := mustGetUint64(cmd, "start-block"), mustGetUint64(cmd, "stop-block")
+	group := mustGetString(cmd, "group")
+	for _, topic := range topics {
+		var blocks uint64
+		write := func(data *pbsubstreams.BlockScopedData) error {
+			blocks++
+			return out.Write(ctx, data)
+		}
+
+		if group != "" {
+			err = replayAsGroup(ctx, reader, deltalog.GroupConfig{Group: group, Topic: topic, Start: start}, stop, write)
+		} else {
+			err = reader.Read(ctx, topic, start, stop, write)
+		}
+		if err != nil {
+			return fmt.Errorf("replaying %q: %w", topic, err)
+		}
+		zlog.Info("topic replayed", zap.String("topic", topic), zap.Uint64("blocks", blocks))
+	}
+
+	return nil
+}
+
+func runDeltalogTopics(cmd *cobra.Command, args []string) error {
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	namespaces, err := reader.Namespaces(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "NAMESPACE\tSTORES\n")
+	for _, namespace := range names {
+		fmt.Fprintf(tw, "%s\t%s\n", "/"+namespace, strings.Join(namespaces[namespace], ", "))
+	}
+	return tw.Flush()
+}
+
+func runDeltalogMerge(cmd *cobra.Command, args []string) error {
+	copied, err := deltalog.Merge(cmd.Context(), args[0], args[1:])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged %d segments of %d logs into %s\n", copied, len(args)-1, args[0])
+	return nil
+}
+
+// replayAsGroup writes the messages of a group to `write` one at a time,
+// acknowledging each once written.
+func replayAsGroup(ctx context.Context, reader *deltalog.Reader, config deltalog.GroupConfig, stop uint64, write func(data *pbsubstreams.BlockScopedData) error) error {
+	group, err := reader.Group(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg, err := group.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			group.Close()
+			return err
+		}
+		if stop != 0 && msg.Data.Clock.GetNumber() >= stop {
+			break
+		}
+
+		if err := write(msg.Data); err != nil {
+			group.Close()
+			return err
+		}
+		if err := msg.Ack(); err != nil {
+			group.Close()
+			return err
+		}
+	}
+
+	return group.Close()
+}
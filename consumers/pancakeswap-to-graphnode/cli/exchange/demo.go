@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/demo"
+	"go.uber.org/zap"
+)
+
+var demoCmd = &cobra.Command{
+	Use:   "demo",
+	Short: "self-contained demos of the playground",
+}
+
+var demoUICmd = &cobra.Command{
+	Use:   "ui",
+	Short: "serve a web page plotting the prices of a pair live, from the 'ws' output of a run",
+	Long: `Serve a web page plotting the prices of a pair as they are streamed, the
+browser connecting to the WebSocket endpoint of the 'ws' output of a run
+streaming the 'store_reserves' module, like:
+
+  run substreams.yaml store_reserves -o ws::8095 &
+  demo ui --feed-url ws://localhost:8095/
+
+then open http://localhost:8096. The pair plotted can be changed from the page.`,
+	RunE:         runDemoUI,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+func init() {
+	demoUICmd.Flags().String("listen-addr", "localhost:8096", "address the page is served on")
+	demoUICmd.Flags().String("feed-url", "ws://localhost:8095/", "WebSocket endpoint of the 'ws' output of the run, as reached from the browser")
+	demoUICmd.Flags().String("pair", "0x0ed7e52944161450477ee417de9cd3a859b14fd0", "address of the pair plotted when the page opens, CAKE/WBNB by default")
+	demoUICmd.Flags().String("module", "store_reserves", "store module whose 'price:<pair>:<token>:token0|token1' keys are plotted")
+	demoUICmd.Flags().Int("points", 500, "number of prices kept on the chart")
+
+	demoCmd.AddCommand(demoUICmd)
+	rootCmd.AddCommand(demoCmd)
+}
+
+func runDemoUI(cmd *cobra.Command, args []string) error {
+	points := mustGetInt(cmd, "points")
+	if points <= 0 {
+		return fmt.Errorf("invalid --points %d, expected a positive number", points)
+	}
+
+	handler := demo.Handler(demo.Config{
+		FeedURL: mustGetString(cmd, "feed-url"),
+		Pair:    mustGetString(cmd, "pair"),
+		Module:  mustGetString(cmd, "module"),
+		Points:  points,
+	})
+
+	listenAddr := mustGetString(cmd, "listen-addr")
+	zlog.Info("serving the demo ui", zap.String("listen_addr", listenAddr), zap.String("feed_url", mustGetString(cmd, "feed-url")))
+	return http.ListenAndServe(listenAddr, handler)
+}
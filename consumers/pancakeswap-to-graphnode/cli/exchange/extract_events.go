@@ -0,0 +1,156 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	eth "github.com/streamingfast/eth-go"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/blocksource"
+	"github.com/streamingfast/substream-pancakeswap/events"
+)
+
+var extractEventsCmd = &cobra.Command{
+	Use:          "extract-events",
+	Short:        "stream blocks and print events of arbitrary contracts decoded from an ABI, as JSON lines",
+	RunE:         runExtractEvents,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+func init() {
+	extractEventsCmd.Flags().Int64P("start-block", "s", -1, "Start block for blockchain firehose")
+	extractEventsCmd.Flags().Uint64P("stop-block", "t", 0, "Stop block for blockchain firehose")
+
+	extractEventsCmd.Flags().String("abi", "", "path to the contract ABI JSON file, every event it declares is extracted")
+	extractEventsCmd.Flags().StringSlice("address", nil, "contract address(es) to extract events from, all contracts when empty")
+
+	extractEventsCmd.Flags().StringSlice("block-source", []string{"firehose"}, "ordered block sources, each one taking over when the one before is exhausted or fails: 'local:<dir>' for merged blocks files, 'firehose' for the firehose of --firehose-endpoint, 'rpc:<url>' for a JSON-RPC node polled at the head of the chain")
+	extractEventsCmd.Flags().String("cursor-file", "", "file the cursor of the last block processed is saved to, and resumed from when it exists, --start-block is then ignored")
+	extractEventsCmd.Flags().Duration("rpc-poll-interval", 3*time.Second, "interval the head of the chain is polled at by the 'rpc:<url>' block source")
+	extractEventsCmd.Flags().Uint64("rpc-confirmations", 15, "blocks on top of a block before the 'rpc:<url>' block source delivers it")
+
+	extractEventsCmd.Flags().String("firehose-endpoint", "api.streamingfast.io:443", "firehose GRPC endpoint")
+	extractEventsCmd.Flags().String("substreams-api-key-envvar", "FIREHOSE_API_KEY", "name of variable containing firehose authentication token (JWT)")
+	extractEventsCmd.Flags().BoolP("insecure", "k", false, "Skip certificate validation on GRPC connection")
+	extractEventsCmd.Flags().BoolP("plaintext", "p", false, "Establish GRPC connection in plaintext")
+	rootCmd.AddCommand(extractEventsCmd)
+}
+
+func runExtractEvents(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	abiPath := mustGetString(cmd, "abi")
+	if abiPath == "" {
+		return fmt.Errorf("flag --abi is required")
+	}
+
+	abi, err := eth.ParseABI(abiPath)
+	if err != nil {
+		return fmt.Errorf("parse abi %q: %w", abiPath, err)
+	}
+
+	addresses := mustGetStringSlice(cmd, "address")
+
+	extractor, err := events.NewExtractor(abi, addresses...)
+	if err != nil {
+		return fmt.Errorf("creating extractor: %w", err)
+	}
+
+	sources, err := newBlockSources(cmd)
+	if err != nil {
+		return err
+	}
+	chain, err := blocksource.NewChain(sources...)
+	if err != nil {
+		return err
+	}
+
+	cursorFile := mustGetString(cmd, "cursor-file")
+	cursor, err := loadBlockCursor(cursorFile)
+	if err != nil {
+		return err
+	}
+
+	startBlock := mustGetInt64(cmd, "start-block")
+	if startBlock < 0 && cursor.IsZero() {
+		return fmt.Errorf("flag --start-block is required")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	return chain.Blocks(ctx, cursor, uint64(startBlock), mustGetUint64(cmd, "stop-block"), func(block *pbeth.Block, cursor blocksource.Cursor) error {
+		for _, ev := range extractor.Extract(block) {
+			if err := encoder.Encode(ev); err != nil {
+				return fmt.Errorf("writing event: %w", err)
+			}
+		}
+		return saveBlockCursor(cursorFile, cursor)
+	})
+}
+
+// newBlockSources returns the sources of --block-source, in order.
+func newBlockSources(cmd *cobra.Command) ([]blocksource.Source, error) {
+	var sources []blocksource.Source
+	for _, spec := range mustGetStringSlice(cmd, "block-source") {
+		kind, arg := spec, ""
+		if i := strings.Index(spec, ":"); i != -1 {
+			kind, arg = spec[:i], spec[i+1:]
+		}
+
+		switch {
+		case kind == "local" && arg != "":
+			sources = append(sources, blocksource.NewLocal(arg))
+		case kind == "firehose" && arg == "":
+			fhClient, callOpts, err := newFirehoseClient(
+				mustGetString(cmd, "firehose-endpoint"),
+				os.Getenv(mustGetString(cmd, "substreams-api-key-envvar")),
+				mustGetBool(cmd, "insecure"),
+				mustGetBool(cmd, "plaintext"),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("firehose client setup: %w", err)
+			}
+			sources = append(sources, blocksource.NewFirehose(fhClient, callOpts...))
+		case kind == "rpc" && arg != "":
+			sources = append(sources, blocksource.NewRPC(arg, nil, mustGetDuration(cmd, "rpc-poll-interval"), mustGetUint64(cmd, "rpc-confirmations")))
+		default:
+			return nil, fmt.Errorf("invalid block source %q, expected 'local:<dir>', 'firehose' or 'rpc:<url>'", spec)
+		}
+	}
+	return sources, nil
+}
+
+// loadBlockCursor reads the cursor saved in `path`, the zero cursor when
+// there's none.
+func loadBlockCursor(path string) (blocksource.Cursor, error) {
+	if path == "" {
+		return blocksource.Cursor{}, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return blocksource.Cursor{}, nil
+	}
+	if err != nil {
+		return blocksource.Cursor{}, fmt.Errorf("read cursor file: %w", err)
+	}
+	return blocksource.ParseCursor(strings.TrimSpace(string(content)))
+}
+
+// saveBlockCursor replaces the cursor saved in `path` through a rename, a
+// crash never leaving it half written.
+func saveBlockCursor(path string, cursor blocksource.Cursor) error {
+	if path == "" {
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(cursor.String()+"\n"), 0644); err != nil {
+		return fmt.Errorf("write cursor file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,100 @@
+package exchange
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/substream-pancakeswap/blocksource"
+)
+
+var fetchBlocksCmd = &cobra.Command{
+	Use:   "fetch-blocks",
+	Short: "download the merged blocks files of a block range into a local blocks directory",
+	Long: `Download the blocks of --range into --output-dir, in the bundles of 100 blocks
+read by 'bench run --blocks-dir' and the 'local:<dir>' block source of
+'extract-events', for a tutorial run without operating a firehose.
+
+--from is either the URL of a bucket of merged blocks files, like
+'gs://<bucket>/<path>', 's3://...' or 'file:///...', copied and decompressed,
+or a firehose endpoint like 'api.streamingfast.io:443', streamed into bundles.
+
+Bundles already in --output-dir are left as is, an interrupted download is
+resumed by running the command again.`,
+	Example:      "  exchange fetch-blocks --range 6810000:6900000 --from api.streamingfast.io:443",
+	RunE:         runFetchBlocks,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+func init() {
+	fetchBlocksCmd.Flags().String("range", "", "blocks to download as '<start>:<stop>', the stop block excluded, widened to whole bundles of 100 blocks")
+	fetchBlocksCmd.Flags().String("from", "", "bucket URL of merged blocks files or firehose endpoint the blocks are downloaded from")
+	fetchBlocksCmd.Flags().StringP("output-dir", "o", "./blocks", "directory where the blocks bundles are written")
+
+	fetchBlocksCmd.Flags().String("substreams-api-key-envvar", "FIREHOSE_API_KEY", "name of variable containing firehose authentication token (JWT)")
+	fetchBlocksCmd.Flags().BoolP("insecure", "k", false, "Skip certificate validation on GRPC connection")
+	fetchBlocksCmd.Flags().BoolP("plaintext", "p", false, "Establish GRPC connection in plaintext")
+	rootCmd.AddCommand(fetchBlocksCmd)
+}
+
+func runFetchBlocks(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	start, stop, err := parseBlockRange(mustGetString(cmd, "range"))
+	if err != nil {
+		return err
+	}
+
+	from := mustGetString(cmd, "from")
+	if from == "" {
+		return fmt.Errorf("flag --from is required")
+	}
+	outputDir := mustGetString(cmd, "output-dir")
+
+	var stats *blocksource.FetchStats
+	var fetchErr error
+	if strings.Contains(from, "://") {
+		store, err := dstore.NewDBinStore(strings.TrimSuffix(from, "/"))
+		if err != nil {
+			return fmt.Errorf("merged blocks store %q: %w", from, err)
+		}
+		stats, fetchErr = blocksource.FetchMergedFiles(ctx, store, outputDir, start, stop)
+	} else {
+		fhClient, callOpts, err := newFirehoseClient(
+			from,
+			os.Getenv(mustGetString(cmd, "substreams-api-key-envvar")),
+			mustGetBool(cmd, "insecure"),
+			mustGetBool(cmd, "plaintext"),
+		)
+		if err != nil {
+			return fmt.Errorf("firehose client setup: %w", err)
+		}
+		stats, fetchErr = blocksource.FetchBundles(ctx, blocksource.NewFirehose(fhClient, callOpts...), outputDir, start, stop)
+	}
+	if stats != nil {
+		fmt.Printf("Fetched %d bundles to %s, %d already there\n", stats.Fetched, outputDir, stats.Present)
+	}
+	return fetchErr
+}
+
+// parseBlockRange reads a '<start>:<stop>' block range.
+func parseBlockRange(in string) (start, stop uint64, err error) {
+	parts := strings.Split(in, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid block range %q, expected <start>:<stop>", in)
+	}
+	if start, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid block range %q: %w", in, err)
+	}
+	if stop, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid block range %q: %w", in, err)
+	}
+	if stop <= start {
+		return 0, 0, fmt.Errorf("invalid block range %q, the stop block must be after the start block", in)
+	}
+	return start, stop, nil
+}
@@ -0,0 +1,45 @@
+package exchange
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/streamingfast/dgrpc"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v1"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials/oauth"
+)
+
+// newFirehoseClient mirrors `client.NewSubstreamsClient` but for the raw blocks
+// firehose stream, used by the commands that decode blocks themselves.
+func newFirehoseClient(endpoint, jwt string, useInsecureTLSConnection, usePlainTextConnection bool) (pbfirehose.StreamClient, []grpc.CallOption, error) {
+	if useInsecureTLSConnection && usePlainTextConnection {
+		return nil, nil, fmt.Errorf("option --insecure and --plaintext are mutually exclusive, they cannot be both specified at the same time")
+	}
+
+	skipAuth := jwt == "" || usePlainTextConnection
+
+	var dialOptions []grpc.DialOption
+	switch {
+	case usePlainTextConnection:
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	case useInsecureTLSConnection:
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))}
+	}
+
+	conn, err := dgrpc.NewExternalClient(endpoint, dialOptions...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create external gRPC client: %w", err)
+	}
+
+	var callOpts []grpc.CallOption
+	if !skipAuth {
+		creds := oauth.NewOauthAccess(&oauth2.Token{AccessToken: jwt, TokenType: "Bearer"})
+		callOpts = append(callOpts, grpc.PerRPCCredentials(creds))
+	}
+
+	return pbfirehose.NewStreamClient(conn), callOpts, nil
+}
@@ -0,0 +1,88 @@
+package exchange
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/flowgraph"
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	"github.com/streamingfast/substream-pancakeswap/state"
+)
+
+var flowsCmd = &cobra.Command{
+	Use:   "flows",
+	Short: "token flows of the swaps, from the token sold to the token bought",
+}
+
+var flowsExportCmd = &cobra.Command{
+	Use:   "export <store url>",
+	Short: "write the token flows graph of 'store_token_flows' as DOT or GraphML",
+	Long: `Write the graph of the token flows recorded by the 'store_token_flows'
+module in a delta log written by the 'deltalog' output: tokens are the nodes,
+an edge goes from the token sold to the token bought with the USD volume, the
+amounts of both tokens and the number of swaps between them, summed over the
+days [--from-day, --to-day].`,
+	RunE:         runFlowsExport,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	flowsExportCmd.Flags().Uint64("block", 0, "block at which the store is read, included")
+	flowsExportCmd.Flags().Int64("from-day", 0, "first day summed, as days since the unix epoch")
+	flowsExportCmd.Flags().Int64("to-day", 0, "last day summed, included, every day from --from-day when 0")
+	flowsExportCmd.Flags().Float64("min-usd", 0, "drop the edges with less USD volume")
+	flowsExportCmd.Flags().String("format", "dot", "graph format, 'dot' or 'graphml'")
+	flowsExportCmd.Flags().StringP("output", "o", "", "write the graph to this file instead of stdout")
+
+	flowsCmd.AddCommand(flowsExportCmd)
+	rootCmd.AddCommand(flowsCmd)
+}
+
+func runFlowsExport(cmd *cobra.Command, args []string) error {
+	var write func(w io.Writer, g *flowgraph.Graph) error
+	switch format := mustGetString(cmd, "format"); format {
+	case "dot":
+		write = flowgraph.WriteDOT
+	case "graphml":
+		write = flowgraph.WriteGraphML
+	default:
+		return fmt.Errorf("invalid --format %q, expected one of: dot, graphml", format)
+	}
+
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	result, err := state.Get(cmd.Context(), reader, state.Query{Store: "token_flows", Block: mustGetUint64(cmd, "block")})
+	if err != nil {
+		return err
+	}
+
+	graph, err := flowgraph.Build(result.Values, flowgraph.Filter{
+		FromDay: mustGetInt64(cmd, "from-day"),
+		ToDay:   mustGetInt64(cmd, "to-day"),
+		MinUSD:  mustGetFloat64(cmd, "min-usd"),
+	})
+	if err != nil {
+		return err
+	}
+
+	output := mustGetString(cmd, "output")
+	if output == "" {
+		return write(os.Stdout, graph)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("create graph file: %w", err)
+	}
+	if err := write(f, graph); err != nil {
+		f.Close()
+		return fmt.Errorf("write graph: %w", err)
+	}
+	return f.Close()
+}
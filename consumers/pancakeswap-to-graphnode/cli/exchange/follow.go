@@ -0,0 +1,69 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	"github.com/streamingfast/substream-pancakeswap/state"
+	"go.uber.org/zap"
+)
+
+var followCmd = &cobra.Command{
+	Use:   "follow <store url>",
+	Short: "serve the queries of 'state serve' without processing blocks, keeping the latest state of the stores from the delta log written by a 'run --output deltalog:<store url>' elsewhere",
+	Long: `Serve the queries of 'state serve' from a delta log shared with the
+indexer writing it, as many followers as needed to scale the reads out.
+
+Each store is loaded from its last snapshot, see 'state snapshot', then the
+segments written to the log are applied as they appear, polled every
+--poll-interval. Reads without 'block', or with 'block=head', are answered
+from this state, at the last block of the log, reads of past blocks from the
+log like 'state serve'. The head lags the indexer by up to a segment of the
+deltalog output.`,
+	RunE:         runFollow,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	followCmd.Flags().String("listen-addr", "localhost:8091", "address the HTTP server listens on")
+	followCmd.Flags().String("auth-file", "", "file of '<namespace> <token>' lines, see 'state serve --auth-file'")
+	addServerTLSFlags(followCmd.Flags())
+	followCmd.Flags().Duration("poll-interval", 5*time.Second, "how often the delta log is checked for new segments")
+
+	rootCmd.AddCommand(followCmd)
+}
+
+func runFollow(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	follower := state.NewFollower(reader)
+	if err := follower.Poll(ctx); err != nil {
+		return err
+	}
+	zlog.Info("following the delta log", zap.String("store_url", args[0]), zap.Uint64("head", follower.Head()))
+	go follower.Run(ctx, mustGetDuration(cmd, "poll-interval"))
+
+	server := state.NewServer(reader, follower)
+	if err := requireTokens(cmd, server); err != nil {
+		return err
+	}
+	tlsConfig, err := loadServerTLS(cmd)
+	if err != nil {
+		return err
+	}
+	server.SetTLS(tlsConfig)
+	if err := server.Listen(mustGetString(cmd, "listen-addr")); err != nil {
+		return err
+	}
+	defer server.Close()
+
+	<-ctx.Done()
+	return nil
+}
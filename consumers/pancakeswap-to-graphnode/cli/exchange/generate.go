@@ -0,0 +1,104 @@
+package exchange
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	eth "github.com/streamingfast/eth-go"
+	"github.com/streamingfast/substream-pancakeswap/codegen"
+	"go.uber.org/zap"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "code generation helpers",
+}
+
+var generateModuleCmd = &cobra.Command{
+	Use:          "module",
+	Short:        "generate the Go decoding structs, map and state builder stubs of a module from an ABI",
+	RunE:         runGenerateModule,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+func init() {
+	generateModuleCmd.Flags().String("abi", "", "path to the contract ABI JSON file")
+	generateModuleCmd.Flags().StringSlice("event", nil, "name of the ABI event(s) the module extracts")
+	generateModuleCmd.Flags().String("name", "", "module name, defaults to the snake cased name of the first event")
+	generateModuleCmd.Flags().StringP("output-dir", "o", ".", "directory where the files are written")
+	generateModuleCmd.Flags().String("package", "", "Go package name of the generated files, defaults to the output directory name")
+	generateModuleCmd.Flags().Bool("overwrite", false, "overwrite the module stub file if it already exists, the events file is always regenerated")
+
+	generateCmd.AddCommand(generateModuleCmd)
+	rootCmd.AddCommand(generateCmd)
+}
+
+func runGenerateModule(cmd *cobra.Command, args []string) error {
+	abiPath := mustGetString(cmd, "abi")
+	if abiPath == "" {
+		return fmt.Errorf("flag --abi is required")
+	}
+
+	abi, err := eth.ParseABI(abiPath)
+	if err != nil {
+		return fmt.Errorf("parse abi %q: %w", abiPath, err)
+	}
+
+	eventNames := mustGetStringSlice(cmd, "event")
+
+	outputDir, err := filepath.Abs(mustGetString(cmd, "output-dir"))
+	if err != nil {
+		return fmt.Errorf("output dir: %w", err)
+	}
+
+	pkg := mustGetString(cmd, "package")
+	if pkg == "" {
+		pkg = filepath.Base(outputDir)
+	}
+
+	files, err := codegen.Generate(abi, codegen.Config{
+		Package: pkg,
+		Module:  mustGetString(cmd, "name"),
+		Events:  eventNames,
+	})
+	if err != nil {
+		return fmt.Errorf("generate module: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating output dir %q: %w", outputDir, err)
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	overwrite := mustGetBool(cmd, "overwrite")
+	for _, name := range names {
+		path := filepath.Join(outputDir, name)
+		if !overwrite && isModuleStub(name) {
+			if _, err := os.Stat(path); err == nil {
+				zlog.Info("module stub already exists, skipping", zap.String("path", path))
+				continue
+			}
+		}
+
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			return fmt.Errorf("writing %q: %w", path, err)
+		}
+		fmt.Println("Wrote", path)
+	}
+
+	return nil
+}
+
+func isModuleStub(name string) bool {
+	return strings.HasSuffix(name, "_module.go")
+}
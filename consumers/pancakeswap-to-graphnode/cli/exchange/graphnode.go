@@ -6,11 +6,13 @@ import (
 	"github.com/streamingfast/bstream"
 	_ "github.com/streamingfast/sf-ethereum/types"
 	"github.com/streamingfast/substream-pancakeswap/cli/exchange/graphnode"
+	"github.com/streamingfast/substream-pancakeswap/entity"
 	"github.com/streamingfast/substream-pancakeswap/graph-node/metrics"
 	"github.com/streamingfast/substream-pancakeswap/graph-node/storage/postgres"
 	"github.com/streamingfast/substreams/client"
 	"github.com/streamingfast/substreams/manifest"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
 	"io"
 	"os"
 )
@@ -28,6 +30,7 @@ func init() {
 	loadGraphNodeCmd.Flags().Int64P("start-block", "s", -1, "Start block for blockchain firehose")
 	loadGraphNodeCmd.Flags().Uint64P("stop-block", "t", 0, "Stop block for blockchain firehose")
 	loadGraphNodeCmd.Flags().Bool("no-return-handler", false, "Avoid printing output for module")
+	loadGraphNodeCmd.Flags().String("entities", "", "YAML file mapping the stores deltas and map outputs to the entities (e.g. 'modules/pancakeswap/entities.yaml'), loaded instead of the changes of the 'db_out' module")
 
 	loadGraphNodeCmd.Flags().String("firehose-endpoint", "api.streamingfast.io:443", "firehose GRPC endpoint")
 	loadGraphNodeCmd.Flags().String("substreams-api-key-envvar", "FIREHOSE_API_KEY", "name of variable containing firehose authentication token (JWT)")
@@ -39,6 +42,7 @@ func init() {
 	loadGraphNodeCmd.Flags().String("pg-schema", "", "postgres schema name")
 	loadGraphNodeCmd.Flags().Bool("pg-disable-transactions", false, "disable postgres transactions for faster inserts")
 	loadGraphNodeCmd.Flags().String("pg-deployment", "", "subgraph deployment name")
+	loadGraphNodeCmd.Flags().Bool("pg-ignore-cursor", false, "start from --start-block even when the database holds the cursor of a previous run")
 	rootCmd.AddCommand(loadGraphNodeCmd)
 }
 
@@ -67,6 +71,24 @@ func runLoadGraphnode(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("store: registaring entities:%w", err)
 	}
 
+	// The cursor is saved in the same transaction as the entities of its block,
+	// resuming from it continues right after the last block saved.
+	cursor, err := storage.LoadCursor(ctx)
+	if err != nil {
+		return fmt.Errorf("store: loading cursor: %w", err)
+	}
+	if cursor != "" {
+		if mustGetBool(cmd, "pg-ignore-cursor") {
+			zlog.Info("ignoring cursor saved in database", zap.String("cursor", cursor))
+			cursor = ""
+		} else {
+			if transactionsDisabled {
+				zlog.Warn("resuming with transactions disabled, the last block may have been partially saved")
+			}
+			zlog.Info("resuming from cursor saved in database, --start-block is ignored", zap.String("cursor", cursor))
+		}
+	}
+
 	loader := graphnode.NewLoader(storage, graphnode.Definition.Entities)
 
 	manifestPath := args[0]
@@ -86,12 +108,22 @@ func runLoadGraphnode(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("substreams client setup: %w", err)
 	}
 
+	outputModules := []string{"db_out", "pairs", "totals"}
+	var mapping *entity.Mapping
+	if path := mustGetString(cmd, "entities"); path != "" {
+		if mapping, err = entity.Load(path); err != nil {
+			return err
+		}
+		outputModules = mapping.Modules()
+	}
+
 	req := &pbsubstreams.Request{
 		StartBlockNum: mustGetInt64(cmd, "start-block"),
 		StopBlockNum:  mustGetUint64(cmd, "stop-block"),
+		StartCursor:   cursor,
 		ForkSteps:     []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_IRREVERSIBLE},
 		Modules:       pkg.Modules,
-		OutputModules: []string{"db_out", "pairs", "totals"},
+		OutputModules: outputModules,
 	}
 
 	stream, err := ssClient.Blocks(ctx, req, callOpts...)
@@ -117,13 +149,26 @@ func runLoadGraphnode(cmd *cobra.Command, args []string) error {
 			_ = r.SnapshotComplete
 		case *pbsubstreams.Response_Data:
 
+			if mapping != nil {
+				changes, err := mapping.Map(r.Data)
+				if err != nil {
+					return fmt.Errorf("mapping block %d: %w", r.Data.Clock.GetNumber(), err)
+				}
+				if err := loader.HandleChanges(changes, r.Data.Cursor, r.Data.Clock); err != nil {
+					return fmt.Errorf("loading block %d: %w", r.Data.Clock.GetNumber(), err)
+				}
+				continue
+			}
+
 			for _, output := range r.Data.Outputs {
 				for _, log := range output.Logs {
 					fmt.Println("LOG: ", log)
 				}
 				if output.Name == "db_out" {
+					// Skipping a block would save the cursor of the next one
+					// without its changes, the load stops instead.
 					if err := loader.ReturnHandler(output.GetMapOutput().GetValue(), r.Data.Step, r.Data.Cursor, r.Data.Clock); err != nil {
-						fmt.Printf("RETURN HANDLER ERROR: %s\n", err)
+						return fmt.Errorf("loading block %d: %w", r.Data.Clock.GetNumber(), err)
 					}
 				}
 			}
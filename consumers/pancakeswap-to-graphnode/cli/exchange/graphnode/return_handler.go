@@ -113,9 +113,6 @@ func (l *Loader) Flush(cursor string, blockNum uint64, blockID string, blockTime
 func (l *Loader) ReturnHandler(data []byte, step pbsubstreams.ForkStep, cursor string, clock *pbsubstreams.Clock) error {
 	databaseChanges := &database.DatabaseChanges{}
 
-	l.current = make(map[string]map[string]graphnode.Entity)
-	l.updates = make(map[string]map[string]graphnode.Entity)
-
 	err := proto.Unmarshal(data, databaseChanges)
 	zlog.Debug("unmarshalled database changes", zap.Int("number_of_db_changes", len(databaseChanges.TableChanges)))
 
@@ -123,8 +120,17 @@ func (l *Loader) ReturnHandler(data []byte, step pbsubstreams.ForkStep, cursor s
 		return fmt.Errorf("unmarshaling database changes proto: %w", err)
 	}
 
+	return l.HandleChanges(databaseChanges, cursor, clock)
+}
+
+// HandleChanges saves the entity changes of a block, like the ones of the
+// `db_out` module or of an `entity.Mapping`.
+func (l *Loader) HandleChanges(databaseChanges *database.DatabaseChanges, cursor string, clock *pbsubstreams.Clock) error {
+	l.current = make(map[string]map[string]graphnode.Entity)
+	l.updates = make(map[string]map[string]graphnode.Entity)
+
 	//todo: should be applied in a transform inside the firehose, not here.
-	err = databaseChanges.Squash()
+	err := databaseChanges.Squash()
 	if err != nil {
 		return fmt.Errorf("squashing database changes: %w", err)
 	}
@@ -1,12 +1,23 @@
 package exchange
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
 
 func Main() {
 	setup()
 
-	err := rootCmd.Execute()
+	// Canceling the context on signals lets commands flush their outputs before
+	// exiting.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		fmt.Println("Error:", err)
+		os.Exit(exitCode(err))
 	}
 }
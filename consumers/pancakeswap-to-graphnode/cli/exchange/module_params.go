@@ -0,0 +1,58 @@
+package exchange
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/streamingfast/substream-pancakeswap/modparams"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// errModuleParamsChanged stops the stream when the parameters of the modules
+// changed while running, it's opened again after the last block written with
+// the new parameters pushed to the modules.
+var errModuleParamsChanged = errors.New("module parameters changed")
+
+// moduleParams pushes the parameters of the run read by the modules, like the
+// pair lists, into the binaries of the request, see the `modparams` package.
+type moduleParams struct {
+	modules *pbsubstreams.Modules
+	values  func() map[string]string
+	pushed  map[string]string
+}
+
+func newModuleParams(modules *pbsubstreams.Modules, values func() map[string]string) *moduleParams {
+	return &moduleParams{modules: modules, values: values}
+}
+
+// Push sets the modules of `req` with the current parameters. Modules built
+// without parameters, like the ones of other packages, are only accepted as
+// long as no parameter is set.
+func (p *moduleParams) Push(req *pbsubstreams.Request) error {
+	values := p.values()
+	modules, err := modparams.Set(p.modules, values)
+	if err == modparams.ErrNotEmbedded && allEmpty(values) {
+		modules, err = p.modules, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	req.Modules = modules
+	p.pushed = values
+	return nil
+}
+
+// Changed returns whether the parameters changed since they were pushed.
+func (p *moduleParams) Changed() bool {
+	return !reflect.DeepEqual(p.values(), p.pushed)
+}
+
+func allEmpty(values map[string]string) bool {
+	for _, value := range values {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
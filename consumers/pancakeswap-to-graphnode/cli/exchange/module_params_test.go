@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/modparams"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleParams(t *testing.T) {
+	unset := append([]byte(modparams.Marker), bytes.Repeat([]byte{'\n'}, modparams.Size-len(modparams.Marker))...)
+	modules := &pbsubstreams.Modules{Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1", Content: unset}}}
+
+	values := map[string]string{"block-pair": "0xaa"}
+	params := newModuleParams(modules, func() map[string]string { return values })
+
+	req := &pbsubstreams.Request{}
+	require.NoError(t, params.Push(req))
+	assert.True(t, bytes.HasPrefix(req.Modules.Binaries[0].Content, []byte(modparams.Marker+"block-pair=0xaa\n")))
+	assert.False(t, params.Changed())
+
+	values = map[string]string{"block-pair": "0xaa,0xbb"}
+	assert.True(t, params.Changed())
+
+	require.NoError(t, params.Push(req))
+	assert.True(t, bytes.HasPrefix(req.Modules.Binaries[0].Content, []byte(modparams.Marker+"block-pair=0xaa,0xbb\n")))
+	assert.False(t, params.Changed())
+	assert.Equal(t, unset, modules.Binaries[0].Content, "the modules of the run are left untouched")
+}
+
+func TestModuleParams_NotEmbedded(t *testing.T) {
+	modules := &pbsubstreams.Modules{Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1", Content: []byte("\x00asm code")}}}
+
+	values := map[string]string{"block-pair": ""}
+	params := newModuleParams(modules, func() map[string]string { return values })
+
+	req := &pbsubstreams.Request{}
+	require.NoError(t, params.Push(req), "no parameter set")
+	assert.Equal(t, modules, req.Modules)
+
+	values = map[string]string{"block-pair": "0xaa"}
+	assert.ErrorIs(t, params.Push(req), modparams.ErrNotEmbedded)
+}
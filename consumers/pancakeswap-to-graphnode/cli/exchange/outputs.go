@@ -0,0 +1,281 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/chainhead"
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/commit"
+	"github.com/streamingfast/substream-pancakeswap/sink/dlq"
+	"github.com/streamingfast/substream-pancakeswap/sink/history"
+	"github.com/streamingfast/substream-pancakeswap/sink/queue"
+	"github.com/streamingfast/substream-pancakeswap/sink/sqlsink"
+	"github.com/streamingfast/substream-pancakeswap/sink/undo"
+	"go.uber.org/zap"
+)
+
+// outputsConfig is what the outputs of a run are opened from, see
+// `outputsConfigFromFlags` for the flags setting them.
+type outputsConfig struct {
+	Outputs          []string
+	ConfirmedOutputs []string
+	Confirmations    uint64
+	Concurrency      int
+	Renderer         sink.Renderer
+	DeadLetters      *dlq.Store
+	Queue            *queue.Config
+
+	TrackKeys   []string
+	HistoryFile string
+
+	SQL           string
+	CommitJournal string
+
+	UndoBufferDir  string
+	UndoBufferSize int
+
+	StoreSchema    *schema.Schema
+	ValidateStores string
+}
+
+// outputsConfigFromFlags reads the outputs of `run` from its flags, `storeSchema`
+// is the one of --store-schema, nil when not set.
+func outputsConfigFromFlags(cmd *cobra.Command, storeSchema *schema.Schema) (*outputsConfig, error) {
+	queueConfig, err := outputQueueConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var deadLetters *dlq.Store
+	if url := mustGetString(cmd, "dead-letter-store"); url != "" {
+		if deadLetters, err = dlq.Open(url); err != nil {
+			return nil, err
+		}
+	}
+	renderer, err := sink.NewRenderer(mustGetString(cmd, "output-format"))
+	if err != nil {
+		return nil, fmt.Errorf("--output-format: %w", err)
+	}
+
+	return &outputsConfig{
+		Outputs:          mustGetStringSlice(cmd, "output"),
+		ConfirmedOutputs: mustGetStringSlice(cmd, "confirmed-output"),
+		Confirmations:    mustGetUint64(cmd, "confirmations"),
+		Concurrency:      mustGetInt(cmd, "sink-concurrency"),
+		Renderer:         renderer,
+		DeadLetters:      deadLetters,
+		Queue:            queueConfig,
+		TrackKeys:        mustGetStringSlice(cmd, "track-key"),
+		HistoryFile:      mustGetString(cmd, "history-file"),
+		SQL:              mustGetString(cmd, "sql"),
+		CommitJournal:    mustGetString(cmd, "commit-journal"),
+		UndoBufferDir:    mustGetString(cmd, "undo-buffer-dir"),
+		UndoBufferSize:   mustGetInt(cmd, "undo-buffer-size"),
+		StoreSchema:      storeSchema,
+		ValidateStores:   mustGetString(cmd, "validate-stores"),
+	}, nil
+}
+
+// outputChain is the chain of sinks the blocks of a run are written to.
+type outputChain struct {
+	// Out is the head of the chain, closing it closes every output.
+	Out sink.Sink
+	// Batched are the outputs batching their writes while backfilling, see
+	// --live-after-backfill.
+	Batched []sink.Sink
+	// UndoLog is the buffer of --undo-buffer-dir, nil when the run only
+	// streams irreversible blocks.
+	UndoLog *undo.Log
+	// ResumeCursor is the cursor of the last block committed to the commit
+	// journal, empty when there is none.
+	ResumeCursor string
+}
+
+// newOutputChain opens the outputs of `config` and chains them, from the
+// stream down:
+//
+//	chainhead → schema validation → undo buffer → commit journal → fanout
+//
+// The fanout writes the outputs, the sql one, the history of the tracked keys
+// and the confirmation gate of the confirmed outputs. With a commit journal,
+// the sql output is written by the journal after the fanout instead. The
+// sinks opened are closed when it fails.
+func newOutputChain(ctx context.Context, config *outputsConfig, tracker *chainhead.Tracker) (chain *outputChain, err error) {
+	fanout := sink.NewFanout(config.Concurrency)
+	chain = &outputChain{Out: fanout}
+	defer func() {
+		if err != nil {
+			if closeErr := chain.Out.Close(); closeErr != nil {
+				zlog.Warn("closing sinks", zap.Error(closeErr))
+			}
+			chain = nil
+		}
+	}()
+
+	for _, spec := range config.Outputs {
+		s, err := newOutput(ctx, spec, config.Renderer, config.DeadLetters, config.Queue)
+		if err != nil {
+			return chain, err
+		}
+		fanout.Add(s)
+		chain.Batched = append(chain.Batched, s)
+	}
+
+	if len(config.TrackKeys) > 0 {
+		s, err := history.NewSink(config.HistoryFile, config.TrackKeys)
+		if err != nil {
+			return chain, err
+		}
+		fanout.Add(s)
+	}
+
+	if len(config.ConfirmedOutputs) > 0 {
+		gate := chainhead.NewGate(tracker, config.Confirmations)
+		fanout.Add(gate)
+		for _, spec := range config.ConfirmedOutputs {
+			s, err := newOutput(ctx, spec, config.Renderer, config.DeadLetters, config.Queue)
+			if err != nil {
+				return chain, err
+			}
+			gate.Add(s)
+			chain.Batched = append(chain.Batched, s)
+		}
+	}
+
+	switch {
+	case config.CommitJournal != "":
+		if config.SQL == "" {
+			return chain, fmt.Errorf("--commit-journal requires --sql")
+		}
+
+		journal, err := commit.OpenJournal(config.CommitJournal)
+		if err != nil {
+			return chain, err
+		}
+		store, err := newSQLSink(ctx, config.SQL, config.StoreSchema)
+		if err != nil {
+			return chain, err
+		}
+
+		committed := commit.NewSink(journal, store, fanout)
+		chain.Out = committed
+		if chain.ResumeCursor = committed.ResumeCursor(); chain.ResumeCursor != "" {
+			zlog.Info("resuming from the commit journal, --start-block is ignored", zap.Uint64("after_block", journal.State().Committed.Num))
+		}
+
+	case config.SQL != "":
+		s, err := newSQLSink(ctx, config.SQL, config.StoreSchema)
+		if err != nil {
+			return chain, err
+		}
+		if s, err = wrapOutput(sinkScheme(config.SQL), s, config.DeadLetters, config.Queue); err != nil {
+			return chain, err
+		}
+		fanout.Add(s)
+		chain.Batched = append(chain.Batched, s)
+	}
+
+	if config.UndoBufferDir != "" {
+		chain.UndoLog, err = undo.Open(config.UndoBufferDir, config.UndoBufferSize)
+		if err != nil {
+			return chain, err
+		}
+		zlog.Info("undo buffer loaded", zap.String("dir", config.UndoBufferDir), zap.Int("blocks", chain.UndoLog.Len()))
+
+		chain.Out = undo.NewSink(chain.UndoLog, chain.Out)
+	}
+
+	switch mode := config.ValidateStores; mode {
+	case "", "off":
+	case "warn", "fail":
+		if config.StoreSchema == nil {
+			return chain, fmt.Errorf("--validate-stores requires --store-schema")
+		}
+		chain.Out = schema.NewSink(config.StoreSchema, mode == "fail", chain.Out)
+	default:
+		return chain, fmt.Errorf("invalid --validate-stores %q, expected one of: off, warn, fail", mode)
+	}
+	chain.Out = chainhead.NewSink(tracker, chain.Out)
+
+	return chain, nil
+}
+
+// newSQLSink opens the --sql sink, writing the values of the stores of
+// `storeSchema`, when set, as their value type.
+func newSQLSink(ctx context.Context, spec string, storeSchema *schema.Schema) (sink.Sink, error) {
+	s, err := sink.New(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	if sqlSink, ok := s.(*sqlsink.Sink); ok && storeSchema != nil {
+		sqlSink.SetSchema(storeSchema)
+	}
+	return s, nil
+}
+
+// outputQueueConfig returns the config of the queues of the outputs, nil when
+// they aren't queued.
+func outputQueueConfig(cmd *cobra.Command) (*queue.Config, error) {
+	size := mustGetInt(cmd, "output-queue-size")
+	flushBlocks, flushInterval := mustGetInt(cmd, "output-flush-blocks"), mustGetDuration(cmd, "output-flush-interval")
+	if size <= 0 {
+		if flushBlocks > 0 || flushInterval > 0 {
+			return nil, fmt.Errorf("--output-flush-blocks and --output-flush-interval require --output-queue-size")
+		}
+		return nil, nil
+	}
+
+	policy, err := queue.ParsePolicy(mustGetString(cmd, "output-queue-policy"))
+	if err != nil {
+		return nil, fmt.Errorf("--output-queue-policy: %w", err)
+	}
+	return &queue.Config{
+		Size:          size,
+		Policy:        policy,
+		SpillDir:      mustGetString(cmd, "output-queue-spill-dir"),
+		FlushBlocks:   flushBlocks,
+		FlushInterval: flushInterval,
+	}, nil
+}
+
+// newOutput opens the output of `spec`, writing its records with `renderer`
+// when it prints them, see `wrapOutput`.
+func newOutput(ctx context.Context, spec string, renderer sink.Renderer, deadLetters *dlq.Store, queueConfig *queue.Config) (sink.Sink, error) {
+	s, err := sink.New(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	sink.SetRenderer(s, renderer)
+	return wrapOutput(sinkScheme(spec), s, deadLetters, queueConfig)
+}
+
+// wrapOutput puts the blocks `s` permanently fails to write in `deadLetters`,
+// and queues them when `queueConfig` is set, either can be nil. A failing
+// block goes to the dead-letter store without stopping the queue.
+func wrapOutput(name string, s sink.Sink, deadLetters *dlq.Store, queueConfig *queue.Config) (sink.Sink, error) {
+	if deadLetters != nil {
+		s = dlq.NewSink(deadLetters, name, s)
+	}
+	if queueConfig == nil {
+		return s, nil
+	}
+
+	q, err := queue.New(name, s, *queueConfig)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// sinkScheme names an output in the logs and metrics by its scheme, its
+// params may hold credentials.
+func sinkScheme(spec string) string {
+	if i := strings.Index(spec, ":"); i >= 0 {
+		return spec[:i]
+	}
+	return spec
+}
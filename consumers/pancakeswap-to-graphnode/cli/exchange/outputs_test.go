@@ -0,0 +1,84 @@
+package exchange
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/chainhead"
+	"github.com/streamingfast/substream-pancakeswap/internal/testfixture"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOutputChain(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "out.jsonl")
+
+	config := &outputsConfig{
+		Outputs:        []string{"jsonl:" + jsonlPath},
+		Renderer:       sink.JSONRenderer{},
+		SQL:            "sqlite:" + filepath.Join(dir, "out.db"),
+		CommitJournal:  filepath.Join(dir, "commit.json"),
+		UndoBufferDir:  filepath.Join(dir, "undo"),
+		UndoBufferSize: 10,
+	}
+
+	chain, err := newOutputChain(ctx, config, chainhead.NewTracker())
+	require.NoError(t, err)
+	assert.Len(t, chain.Batched, 1, "the sql output is written by the commit journal")
+	assert.Empty(t, chain.ResumeCursor)
+	require.NotNil(t, chain.UndoLog)
+
+	for num := uint64(1); num <= 2; num++ {
+		require.NoError(t, chain.Out.Write(ctx, testfixture.PairsBlock(num, pbsubstreams.ForkStep_STEP_NEW)))
+	}
+	require.NoError(t, chain.Out.Close())
+	assert.Equal(t, 2, chain.UndoLog.Len())
+
+	content, err := os.ReadFile(jsonlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "pair:2")
+
+	chain, err = newOutputChain(ctx, config, chainhead.NewTracker())
+	require.NoError(t, err)
+	assert.Equal(t, "cursor-2", chain.ResumeCursor)
+	require.NoError(t, chain.Out.Close())
+
+	// without a journal the sql output is one of the outputs
+	config.CommitJournal = ""
+	config.UndoBufferDir = ""
+	chain, err = newOutputChain(ctx, config, chainhead.NewTracker())
+	require.NoError(t, err)
+	assert.Len(t, chain.Batched, 2)
+	assert.Nil(t, chain.UndoLog)
+	require.NoError(t, chain.Out.Close())
+}
+
+func TestNewOutputChain_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		config      *outputsConfig
+		expectedErr string
+	}{
+		{"journal without sql", &outputsConfig{CommitJournal: filepath.Join(dir, "commit.json")}, "--commit-journal requires --sql"},
+		{"validation without schema", &outputsConfig{ValidateStores: "warn"}, "--validate-stores requires --store-schema"},
+		{"invalid validation", &outputsConfig{ValidateStores: "strict"}, `invalid --validate-stores "strict", expected one of: off, warn, fail`},
+		{"unknown output", &outputsConfig{Outputs: []string{"jsonl", "carrier-pigeon:"}}, "carrier-pigeon"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain, err := newOutputChain(context.Background(), test.config, chainhead.NewTracker())
+			require.Error(t, err)
+			assert.Nil(t, chain)
+			assert.Contains(t, err.Error(), test.expectedErr)
+		})
+	}
+}
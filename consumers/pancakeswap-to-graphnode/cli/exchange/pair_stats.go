@@ -0,0 +1,63 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/pairstats"
+)
+
+var pairStatsCmd = &cobra.Command{
+	Use:   "pair-stats <file>",
+	Short: "list the hottest pairs from the processing statistics of runs",
+	Long: `List the pairs taking the most processing from <file>, the statistics written
+by 'run --pair-stats-file', ranked by --by:
+
+  time     share of the processing time of the blocks, split between the
+           pairs of a block by their events and store deltas
+  events   swap, mint and burn events
+  swaps    swap events
+  deltas   store deltas whose key references the pair
+
+The top of the list are the candidates for --pair-filter-file, the pairs a
+sharding key must spread, and where optimizing the modules pays off.`,
+	RunE:         runPairStats,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	pairStatsCmd.Flags().String("by", string(pairstats.ByTime), "what the pairs are ranked by, 'time', 'events', 'swaps' or 'deltas'")
+	pairStatsCmd.Flags().IntP("top", "n", 20, "number of pairs listed, all of them when 0")
+	pairStatsCmd.Flags().Bool("json", false, "print the pairs as JSON instead of a table")
+
+	rootCmd.AddCommand(pairStatsCmd)
+}
+
+func runPairStats(cmd *cobra.Command, args []string) error {
+	order, err := pairstats.ParseOrder(mustGetString(cmd, "by"))
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(args[0]); err != nil {
+		return fmt.Errorf("pair stats: %w", err)
+	}
+	stats, err := pairstats.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	if !mustGetBool(cmd, "json") {
+		return stats.PrintTop(cmd.OutOrStdout(), mustGetInt(cmd, "top"), order)
+	}
+
+	content, err := json.MarshalIndent(stats.Top(mustGetInt(cmd, "top"), order), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal pairs: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(content))
+	return nil
+}
@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/pairfilter"
+	"github.com/streamingfast/substream-pancakeswap/params"
+	"github.com/streamingfast/substream-pancakeswap/replay"
+	"go.uber.org/zap"
+)
+
+// runParams registers the `run` flags that can be changed while running. The
+// modules themselves run remotely, the pair lists reach them as module
+// parameters, pushed again when they change, see `moduleParams`.
+func runParams(filter *pairfilter.Filter, allow, block []string, pacer *replay.Pacer) *params.Registry {
+	registry := params.NewRegistry()
+
+	setLists := func() {
+		if err := filter.SetLists(allow, block); err != nil {
+			zlog.Warn("applying pair lists, keeping previous lists", zap.Error(err))
+		}
+	}
+	registry.Register(&params.Param{
+		Name:  "allow-pair",
+		Usage: "comma separated pair or token addresses, see --allow-pair",
+		Value: strings.Join(allow, ","),
+		Parse: func(value string) (func(), error) {
+			list := splitList(value)
+			if err := pairfilter.ValidateList(list); err != nil {
+				return nil, err
+			}
+			return func() { allow = list; setLists() }, nil
+		},
+	})
+	registry.Register(&params.Param{
+		Name:  "block-pair",
+		Usage: "comma separated pair or token addresses, see --block-pair",
+		Value: strings.Join(block, ","),
+		Parse: func(value string) (func(), error) {
+			list := splitList(value)
+			if err := pairfilter.ValidateList(list); err != nil {
+				return nil, err
+			}
+			return func() { block = list; setLists() }, nil
+		},
+	})
+
+	if pacer != nil {
+		registry.Register(&params.Param{
+			Name:  "replay-speed",
+			Usage: "see --replay-speed, it can't be changed when started without it",
+			Value: strconv.FormatFloat(pacer.Speed(), 'g', -1, 64),
+			Parse: func(value string) (func(), error) {
+				speed, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, err
+				}
+				if err := replay.ValidateSpeed(speed); err != nil {
+					return nil, err
+				}
+				return func() { pacer.SetSpeed(speed) }, nil
+			},
+		})
+	}
+
+	return registry
+}
+
+func splitList(value string) (out []string) {
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/plan"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "plan runs to orchestrate on your own infrastructure",
+}
+
+var planShardCmd = &cobra.Command{
+	Use:          "shard <manifest> <output_module> [<output_module>...] [-- <run flags>...]",
+	Short:        "split a backfill into shards of contiguous block ranges, each writing its own delta log, plus the step merging them",
+	RunE:         runPlanShard,
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+}
+
+func init() {
+	planShardCmd.Flags().Uint64("start", 0, "first block of the backfill")
+	planShardCmd.Flags().Uint64("stop", 0, "block at which the backfill stops, exclusive")
+	planShardCmd.Flags().Int("shards", 10, "number of shards, ranges are of the same size give or take a block")
+	planShardCmd.Flags().String("name", "backfill", "prefix of the jobs names")
+	planShardCmd.Flags().String("state-url", "file:///data/backfill", "store URL under which each shard writes its delta log, 'shard-000', 'shard-001', ..., merged into 'merged'")
+	planShardCmd.Flags().String("format", "json", "'json' for the plan, 'jobs' for a list of Kubernetes Jobs to 'kubectl apply -f'")
+	planShardCmd.Flags().String("image", "", "container image of the Kubernetes Jobs, with the 'exchange' binary in its path, required with '--format jobs'")
+	planShardCmd.Flags().Int("backoff-limit", 3, "retries of a failed Kubernetes Job")
+	planShardCmd.Flags().StringP("output", "o", "", "write the plan to this file instead of stdout")
+
+	planCmd.AddCommand(planShardCmd)
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlanShard(cmd *cobra.Command, args []string) error {
+	positional, runArgs := args, []string(nil)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		positional, runArgs = args[:dash], args[dash:]
+	}
+	if len(positional) < 2 {
+		return fmt.Errorf("a manifest and at least one output module are required")
+	}
+
+	shardPlan, err := plan.NewShardPlan(plan.ShardConfig{
+		Name:     mustGetString(cmd, "name"),
+		Start:    mustGetUint64(cmd, "start"),
+		Stop:     mustGetUint64(cmd, "stop"),
+		Shards:   mustGetInt(cmd, "shards"),
+		Manifest: positional[0],
+		Modules:  positional[1:],
+		StateURL: mustGetString(cmd, "state-url"),
+		Args:     runArgs,
+	})
+	if err != nil {
+		return err
+	}
+
+	var out interface{} = shardPlan
+	switch format := mustGetString(cmd, "format"); format {
+	case "json":
+	case "jobs":
+		image := mustGetString(cmd, "image")
+		if image == "" {
+			return fmt.Errorf("flag --image is required with '--format jobs'")
+		}
+		out = shardPlan.Jobs(image, mustGetInt(cmd, "backoff-limit"))
+	default:
+		return fmt.Errorf("unknown format %q, valid formats are: json, jobs", format)
+	}
+
+	content, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal plan: %w", err)
+	}
+
+	if output := mustGetString(cmd, "output"); output != "" {
+		if err := os.WriteFile(output, content, 0644); err != nil {
+			return fmt.Errorf("write plan %q: %w", output, err)
+		}
+		return nil
+	}
+
+	fmt.Println(string(content))
+	return nil
+}
@@ -0,0 +1,89 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/rebuild"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/swaparchive"
+	"go.uber.org/zap"
+)
+
+var rebuildStoreCmd = &cobra.Command{
+	Use:   "rebuild-store <swaps store url>",
+	Short: "compute a store again from the swaps archived by the 'swaps' output, without streaming the modules, and write its deltas to the configured outputs",
+	Long: `Compute a store again from the swaps archived by a run with
+'--output swaps:<store url>', without streaming the modules: no block is
+fetched, no log decoded and no RPC call made, only the builder of the store
+runs, to rebuild it quickly once its logic is fixed.
+
+The swaps of the blocks before --from needed by the store, like the last 24
+hours of swaps for volume24h, are read as well. The content of the store they
+produce is written as created at the first block written, followed by the
+deltas of every block.`,
+	Example:      `  exchange rebuild-store file:///data/swaps --store volume24h --from 6810000 --to 7200000 --output deltalog:file:///data/deltas`,
+	RunE:         runRebuildStore,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	rebuildStoreCmd.Flags().String("store", "", fmt.Sprintf("store rebuilt, the 'store_' prefix can be omitted, one of: %s", strings.Join(rebuild.Stores(), ", ")))
+	rebuildStoreCmd.Flags().Uint64("from", 0, "first block written")
+	rebuildStoreCmd.Flags().Uint64("to", 0, "block at which the rebuild stops, exclusive, until the end of the archive when 0")
+	rebuildStoreCmd.Flags().StringSliceP("output", "o", []string{"jsonl"}, "where the deltas are written, in the form <scheme>[:<params>], can be repeated, see 'run --output'")
+	rebuildStoreCmd.Flags().String("output-format", sink.FormatJSON, "how the 'jsonl' outputs write the deltas, 'json', 'console' or 'text', see 'run --output-format'")
+
+	rootCmd.AddCommand(rebuildStoreCmd)
+}
+
+func runRebuildStore(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	store := mustGetString(cmd, "store")
+	if store == "" {
+		return fmt.Errorf("--store is required, one of: %s", strings.Join(rebuild.Stores(), ", "))
+	}
+	builder, module, err := rebuild.New(store)
+	if err != nil {
+		return err
+	}
+	from, to := mustGetUint64(cmd, "from"), mustGetUint64(cmd, "to")
+	if to != 0 && to <= from {
+		return fmt.Errorf("--to %d must be after --from %d", to, from)
+	}
+
+	reader, err := swaparchive.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	renderer, err := sink.NewRenderer(mustGetString(cmd, "output-format"))
+	if err != nil {
+		return fmt.Errorf("--output-format: %w", err)
+	}
+
+	out := sink.NewFanout(0)
+	defer func() {
+		if err := out.Close(); err != nil {
+			zlog.Warn("closing sinks", zap.Error(err))
+		}
+	}()
+	for _, spec := range mustGetStringSlice(cmd, "output") {
+		s, err := sink.New(ctx, spec)
+		if err != nil {
+			return err
+		}
+		sink.SetRenderer(s, renderer)
+		out.Add(s)
+	}
+
+	blocks, err := rebuild.Rebuild(ctx, reader, builder, module, from, to, out)
+	if err != nil {
+		return fmt.Errorf("rebuilding %s: %w", module, err)
+	}
+	zlog.Info("store rebuilt", zap.String("store", module), zap.Uint64("from", from), zap.Uint64("to", to), zap.Uint64("blocks", blocks))
+	return nil
+}
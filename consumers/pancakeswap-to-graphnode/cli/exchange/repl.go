@@ -0,0 +1,44 @@
+package exchange
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/repl"
+)
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "explore the stores of a running pipeline interactively, like 'get pairs pair:0x...', 'prefix prices price:' or 'subscribe volume24h'",
+	Long: `Read commands from stdin against the stores of a running pipeline, 'help'
+lists them.
+
+Keys are read from the state server at --state-url, a 'follow' of the delta
+log of the run ('run --output deltalog:<store url>') answering at the head,
+or 'state serve' answering at the block given. Subscriptions follow the
+deltas broadcast by the WebSocket output of the run at --feed-url
+('run --output ws::8095').`,
+	Example: `  exchange run --output deltalog:file:///data/deltas --output ws::8095 ...
+  exchange follow file:///data/deltas
+  exchange repl`,
+	RunE:         runREPL,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+func init() {
+	replCmd.Flags().String("state-url", "http://localhost:8091", "base URL of the state server the keys are read from, 'follow' or 'state serve'")
+	replCmd.Flags().String("state-token-envvar", "STATE_TOKEN", "name of the variable containing the token of the state server, for the namespaces requiring one, see 'state serve --auth-file'")
+	replCmd.Flags().String("feed-url", "ws://localhost:8095/", "WebSocket endpoint of the 'ws' output of the run, followed by 'subscribe'")
+
+	rootCmd.AddCommand(replCmd)
+}
+
+func runREPL(cmd *cobra.Command, args []string) error {
+	r := repl.New(repl.Config{
+		StateURL: mustGetString(cmd, "state-url"),
+		Token:    os.Getenv(mustGetString(cmd, "state-token-envvar")),
+		FeedURL:  mustGetString(cmd, "feed-url"),
+	}, cmd.OutOrStdout())
+	return r.Run(cmd.Context(), cmd.InOrStdin())
+}
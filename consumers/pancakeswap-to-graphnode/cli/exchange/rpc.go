@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/rpcusage"
+)
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "JSON-RPC usage helpers",
+}
+
+var rpcEstimateCmd = &cobra.Command{
+	Use:   "estimate <sample>",
+	Short: "project the JSON-RPC calls and cost of a block range from the usage sampled by a run",
+	Long: `Project the JSON-RPC calls and cost of the blocks [--start-block, --stop-block)
+from the calls per block of each method in <sample>, the JSON summary of a run
+written by 'run --summary-file', the report of 'bench run --rpc-endpoint' or a
+usage report written by this command.
+
+The sample prices are ignored, the projection is priced with --rpc-price.`,
+	RunE:         runRPCEstimate,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	rpcEstimateCmd.Flags().Uint64("start-block", 0, "first block of the projected range")
+	rpcEstimateCmd.Flags().Uint64("stop-block", 0, "block the projected range stops at, exclusive")
+	rpcEstimateCmd.Flags().StringSlice("rpc-price", nil, "cost of a JSON-RPC call as '<method>=<price>', '*' pricing the other methods")
+	rpcEstimateCmd.Flags().StringP("output", "o", "", "also write the projected usage report as JSON to this file")
+
+	rpcCmd.AddCommand(rpcEstimateCmd)
+	rootCmd.AddCommand(rpcCmd)
+}
+
+func runRPCEstimate(cmd *cobra.Command, args []string) error {
+	prices, err := rpcusage.ParsePrices(mustGetStringSlice(cmd, "rpc-price"))
+	if err != nil {
+		return err
+	}
+
+	sample, err := readRPCSample(args[0])
+	if err != nil {
+		return err
+	}
+
+	estimate, err := rpcusage.Estimate(sample, mustGetUint64(cmd, "start-block"), mustGetUint64(cmd, "stop-block"), prices)
+	if err != nil {
+		return fmt.Errorf("estimating from %q: %w", args[0], err)
+	}
+	estimate.Print(cmd.OutOrStdout())
+
+	if output := mustGetString(cmd, "output"); output != "" {
+		content, err := json.MarshalIndent(estimate, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal report: %w", err)
+		}
+		if err := os.WriteFile(output, content, 0644); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	}
+	return nil
+}
+
+// readRPCSample reads the usage report of a run summary, or a bare usage
+// report.
+func readRPCSample(path string) (*rpcusage.Report, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read sample: %w", err)
+	}
+
+	var summary struct {
+		RPC *rpcusage.Report `json:"rpc"`
+	}
+	if err := json.Unmarshal(content, &summary); err != nil {
+		return nil, fmt.Errorf("decode sample %q: %w", path, err)
+	}
+	// summaries report the runs without calls as well
+	if summary.RPC != nil && summary.RPC.Calls > 0 {
+		return summary.RPC, nil
+	}
+
+	report := &rpcusage.Report{}
+	if err := json.Unmarshal(content, report); err != nil {
+		return nil, fmt.Errorf("decode sample %q: %w", path, err)
+	}
+	if report.Methods == nil {
+		return nil, fmt.Errorf("sample %q holds no RPC usage, was the run making JSON-RPC calls?", path)
+	}
+	return report, nil
+}
@@ -0,0 +1,771 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/admin"
+	"github.com/streamingfast/substream-pancakeswap/anomaly"
+	"github.com/streamingfast/substream-pancakeswap/chainhead"
+	"github.com/streamingfast/substream-pancakeswap/dag"
+	"github.com/streamingfast/substream-pancakeswap/entity"
+	"github.com/streamingfast/substream-pancakeswap/leader"
+	"github.com/streamingfast/substream-pancakeswap/leaderboard"
+	"github.com/streamingfast/substream-pancakeswap/lineage"
+	"github.com/streamingfast/substream-pancakeswap/pairfilter"
+	"github.com/streamingfast/substream-pancakeswap/pairstats"
+	"github.com/streamingfast/substream-pancakeswap/params"
+	"github.com/streamingfast/substream-pancakeswap/replay"
+	"github.com/streamingfast/substream-pancakeswap/report"
+	"github.com/streamingfast/substream-pancakeswap/rpcusage"
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/streamingfast/substream-pancakeswap/servertls"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/arrowflight"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/bigquery"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/csv"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/jsonl"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/kinesis"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/mqtt"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/natsjs"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/pubsub"
+	"github.com/streamingfast/substream-pancakeswap/sink/swaparchive"
+	_ "github.com/streamingfast/substream-pancakeswap/sink/wsfeed"
+	"github.com/streamingfast/substream-pancakeswap/watchdog"
+	"github.com/streamingfast/substreams/client"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+var runCmd = &cobra.Command{
+	Use:          "run [manifest] [<output_module>...]",
+	Short:        "stream the outputs of the given modules to the configured outputs",
+	RunE:         runRun,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	runCmd.Flags().Int64P("start-block", "s", -1, "Start block for blockchain firehose")
+	runCmd.Flags().Uint64P("stop-block", "t", 0, "Stop block for blockchain firehose")
+	runCmd.Flags().StringSlice("output-modules", nil, "output modules, added to the ones given as arguments, only them and the modules they depend on are sent to the server (e.g. 'map_burn_swaps_events,store_volumes')")
+	runCmd.Flags().StringSliceP("output", "o", []string{"jsonl"}, "where module outputs are written, in the form <scheme>[:<params>], can be repeated (e.g. 'jsonl' for stdout, 'jsonl:./out.jsonl', 'flight::8815?batch-size=1024', 'flight:0.0.0.0:8815?tokens-file=./tokens&jwt-secret-env=FLIGHT_JWT_SECRET&rate=10000&quota=1000000&quota-window=1h' to authenticate its clients and limit the rows they receive, 'nats:nats://localhost:4222?stream=SUBSTREAMS', 'deltalog:file:///data/deltas' to keep the stores deltas for 'deltalog replay', with '?namespace=bsc/pancake' to share the log with the pipelines of other protocols or chains, 'ws::8095?path=/&modules=store_reserves' to broadcast them to WebSocket clients like 'demo ui', 'bigquery:my-project/pancake?deltas-table=store_deltas&swaps-table=swaps' to stream the stores deltas and the swaps to BigQuery tables partitioned by block date and clustered by pair, 'kinesis:deltas?region=us-east-1' or 'firehose:deltas' to put the stores deltas to a Kinesis data stream, partitioned by key, or delivery stream, 'mqtt:tcp://localhost:1883?qos=1&retain=true&modules=store_prices' to publish them to the '<prefix>/<store>/<key segments>' topics of an MQTT broker, 'swaps:file:///data/swaps?segment-size=10000' to archive the swaps for 'swaps cat' and 'rebuild-store', through --confirmed-output when following the head, adds map_burn_swaps_events to the output modules)")
+
+	runCmd.Flags().String("output-format", sink.FormatJSON, "how the 'jsonl' outputs write the entities: 'json' an object per line, 'console' a line per entity for a terminal, store deltas as '<key>: <old> -> <new>', 'text' the protobuf text format")
+	runCmd.Flags().String("sql", "", "mirror the stores deltas into a SQL database, in the form <dialect>:<dsn> (e.g. 'sqlite:./out.db', 'postgres:<dsn>' with the tables prepared by 'sink pg init')")
+	runCmd.Flags().String("commit-journal", "", "keep --sql and the outputs in step through this journal file, each block is flushed to the outputs before being committed to the database, and the run resumes from the last committed block")
+
+	runCmd.Flags().StringSlice("track-key", nil, "store key whose every value is appended to --history-file, a trailing '*' tracks every key with that prefix (e.g. 'price:0xab*'), can be repeated, see 'state history'")
+	runCmd.Flags().String("history-file", "./history.dbin", "file where the values of --track-key are appended")
+	runCmd.Flags().String("store-schema", "", "YAML file declaring the keys and value types of the stores (e.g. 'modules/pancakeswap/schema.yaml'), checked against the manifest and served by the admin API")
+	runCmd.Flags().String("entities", "", "YAML file mapping the stores deltas and map outputs to entity changes (e.g. 'modules/pancakeswap/entities.yaml'), written to the outputs as the 'entity_changes' module")
+	runCmd.Flags().String("validate-stores", "off", "validate the stores deltas against --store-schema, 'warn' logs the violations, 'fail' stops the run at the first one")
+	runCmd.Flags().Int("sink-concurrency", 4, "number of outputs written at the same time for each block, all of them when 0, 1 writes them one after the other")
+	runCmd.Flags().String("dead-letter-store", "", "store URL (like 'gs://bucket/dlq' or a local directory) where the blocks --output, --confirmed-output and --sql permanently fail to write (a row the database rejects, a table not matching) are kept with their error instead of stopping the run, see 'sink replay-dlq'")
+	runCmd.Flags().Int("output-queue-size", 0, "queue up to this many blocks in front of each of --output, --confirmed-output and --sql, written from a goroutine of their own so a slow output doesn't hold back the others, outputs are written in step with the stream when 0")
+	runCmd.Flags().String("output-queue-policy", "block", "what a full output queue does with a new block: 'block' holds back the stream until there's room, 'drop' discards it (for outputs tolerating gaps, like live feeds), 'spill' appends it to a file of --output-queue-spill-dir, read back once the output caught up")
+	runCmd.Flags().String("output-queue-spill-dir", "", "directory of the files of --output-queue-policy=spill, the temporary directory when empty")
+	runCmd.Flags().Int("output-flush-blocks", 0, "flush the queued outputs every this many blocks, batching their writes in between (jsonl isn't flushed at each block, sql commits many blocks per transaction), requires --output-queue-size")
+	runCmd.Flags().Duration("output-flush-interval", 0, "flush the queued outputs at this interval, batching their writes in between, requires --output-queue-size")
+	runCmd.Flags().String("undo-buffer-dir", "", "follow the chain head, reversible blocks included, keeping the deltas of the last blocks in this directory so outputs can be rolled back precisely on reorgs, irreversible blocks only when empty. The sql outputs revert the deltas of an undone block, the others write them as records with the 'UNDO' step, for their consumers to revert")
+	runCmd.Flags().Int("undo-buffer-size", 200, "number of blocks kept in --undo-buffer-dir")
+	runCmd.Flags().Bool("live-after-backfill", false, "backfill irreversible blocks with the outputs batching their writes (jsonl isn't flushed at each block, sql commits many blocks per transaction), then switch to following the head once the stream reaches the blocks that aren't irreversible yet, requires --undo-buffer-dir")
+	runCmd.Flags().Duration("backfill-flush-interval", 30*time.Second, "how often the outputs batched by --live-after-backfill are flushed while backfilling")
+	runCmd.Flags().Duration("handoff-lag", time.Minute, "how close to the current time a backfilled block must be for --live-after-backfill to switch to live streaming, for servers only streaming irreversible blocks, 0 to only switch at the first block that isn't irreversible")
+	runCmd.Flags().StringSlice("confirmed-output", nil, "like --output, but blocks are only written once confirmed (see --confirmations) so these outputs never see a block undone, can be repeated")
+	runCmd.Flags().Uint64("confirmations", 15, "number of blocks on top of a block for --confirmed-output to write it, irreversible blocks are always written, only irreversible blocks when 0")
+	runCmd.Flags().String("network-head-rpc", "", "JSON-RPC endpoint polled for the head of the chain, ahead of the stream head while catching up, reported by the chain head metrics and counted for --confirmations")
+	runCmd.Flags().Duration("network-head-poll-interval", 5*time.Second, "how often --network-head-rpc is polled")
+	runCmd.Flags().StringSlice("rpc-price", nil, "cost of a JSON-RPC call as '<method>=<price>', '*' pricing the other methods, the calls and their cost are reported in the run summary, see 'rpc estimate'")
+
+	runCmd.Flags().StringSlice("allow-pair", nil, "only let the modules process these pairs, or the pairs of these tokens, pushed to them as module parameters, can be repeated")
+	runCmd.Flags().StringSlice("block-pair", nil, "have the modules skip these pairs, or the pairs of these tokens (scam or fee-on-transfer pairs), in their reserves, prices, volumes and totals, pushed to them as module parameters, can be repeated")
+	runCmd.Flags().String("pair-filter-file", "", "file with 'allow <address>' and 'block <address>' lines, added to --allow-pair and --block-pair and reloaded when it changes, the stream is then opened again after the last block with the new lists pushed to the modules")
+	runCmd.Flags().Duration("pair-filter-reload-interval", 10*time.Second, "how often --pair-filter-file is checked for changes")
+	runCmd.Flags().Bool("adjusted-accounting", true, "have store_prices price the fee-on-transfer tokens net of their detected transfer tax, pushed to the modules as a module parameter, false to keep the raw reserve based prices")
+
+	runCmd.Flags().Float64("replay-speed", 0, "deliver blocks at the cadence they were produced on chain, multiplied by this factor (1 for real time, 2 for twice as fast), as fast as possible when 0")
+	runCmd.Flags().Int("leaderboard-size", 0, "rank the top pairs and traders by volume and by number of swaps over --leaderboard-window, written to the outputs as the 'leaderboard' store whenever a ranking changes, adds map_burn_swaps_events and map_router_trades to the output modules, disabled when 0")
+	runCmd.Flags().Duration("leaderboard-window", 24*time.Hour, "rolling window of --leaderboard-size, rounded up to the hour")
+	runCmd.Flags().Float64("anomaly-volume-sigma", 0, "flag the blocks where the USD volume of a pair is this many standard deviations from its moving average, written to the outputs as the 'anomalies' store, adds map_burn_swaps_events to the output modules, disabled when 0")
+	runCmd.Flags().Float64("anomaly-price-sigma", 0, "flag the blocks where the log return of the price of a pair is this many standard deviations from its moving average, written to the outputs as the 'anomalies' store, adds map_reserves to the output modules, disabled when 0")
+	runCmd.Flags().Float64("anomaly-alpha", 0.05, "weight of a block in the moving averages of --anomaly-volume-sigma and --anomaly-price-sigma, between 0 and 1")
+	runCmd.Flags().Int("anomaly-warmup", 50, "number of blocks with swaps, or syncs, of a pair before its anomalies are flagged")
+	runCmd.Flags().Duration("stall-timeout", 0, "open the stream again from the last cursor when no block was received for this long, a firehose or an RPC node behind it hanging without failing the stream, reported by the 'stream_stalled' metric, disabled when 0")
+	runCmd.Flags().Int("stall-max-restarts", 5, "stop the run when the stream stalls this many times in a row without delivering a block in between, see --stall-timeout")
+	runCmd.Flags().String("summary-file", "", "also write the summary printed at the end of the run as JSON to this file")
+	runCmd.Flags().String("pair-stats-file", "", "count the events, store deltas and processing time of every pair into this file, added to what it holds at the end of the run, listed by 'pair-stats', adds map_pairs and map_burn_swaps_events to the output modules")
+	runCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "how long outputs are given to flush once the run completes or is interrupted, the run fails past it")
+
+	runCmd.Flags().String("admin-listen-addr", "", "serve the admin API (pause, resume, snapshot, log level, chain head) on this address, like 'localhost:8090', disabled when empty")
+	runCmd.Flags().String("metrics-listen-addr", "", "serve the Prometheus metrics (chain head, LIB, blocks held back by --confirmed-output, stream stalls) on this address, like 'localhost:9102', disabled when empty")
+	runCmd.Flags().String("params-file", "", "JSON object of params changed while running, like '{\"block-pair\": \"0x...\"}', applied at the next block boundary whenever the file changes, see the admin API for the params")
+	runCmd.Flags().Duration("params-reload-interval", 10*time.Second, "how often --params-file is checked for changes")
+	runCmd.Flags().String("snapshot-dir", "./snapshots", "directory where snapshots requested through the admin API are recorded")
+	runCmd.Flags().String("on-lineage-mismatch", "fail", "what to do when the state of --commit-journal, --undo-buffer-dir or --snapshot-dir was produced by other modules: 'fail' refuses to resume, 'ignore' resumes anyway, 'rebuild' streams again from the last snapshot of --snapshot-dir produced by the modules of this run")
+
+	runCmd.Flags().String("leader-election", "", "store URL (like 'gs://bucket/leader' or a local directory) of the lease electing, among instances running the same command, the one streaming blocks while the others stand by, disabled when empty")
+	runCmd.Flags().String("instance-id", "", "name of this instance in the leader lease, '<hostname>-<pid>' when empty")
+	runCmd.Flags().Duration("leader-lease-ttl", 15*time.Second, "how long the leader lease is valid without being renewed, a standby takes over past it")
+
+	addServerTLSFlags(runCmd.Flags())
+
+	runCmd.Flags().String("firehose-endpoint", "api.streamingfast.io:443", "firehose GRPC endpoint")
+	runCmd.Flags().String("substreams-api-key-envvar", "FIREHOSE_API_KEY", "name of variable containing firehose authentication token (JWT)")
+	runCmd.Flags().BoolP("insecure", "k", false, "Skip certificate validation on GRPC connection")
+	runCmd.Flags().BoolP("plaintext", "p", false, "Establish GRPC connection in plaintext")
+	rootCmd.AddCommand(runCmd)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	manifestPath := args[0]
+	manifestReader := manifest.NewReader(manifestPath)
+	pkg, err := manifestReader.Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+
+	// outputs serving clients, like 'flight', take it from the context
+	tlsConfig, err := loadServerTLS(cmd)
+	if err != nil {
+		return err
+	}
+	ctx = servertls.WithConfig(ctx, tlsConfig)
+
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	defer cancelStream()
+
+	// Standby instances wait here, before any output is opened.
+	leaseTTL := mustGetDuration(cmd, "leader-lease-ttl")
+	var elector *leader.Elector
+	var leaderCursor string
+	if storeURL := mustGetString(cmd, "leader-election"); storeURL != "" {
+		elector, err = leader.New(storeURL, instanceID(mustGetString(cmd, "instance-id")), leaseTTL)
+		if err != nil {
+			return err
+		}
+
+		checkpoint, err := elector.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		if checkpoint != nil {
+			leaderCursor = checkpoint.Cursor
+			zlog.Info("resuming from the previous leader checkpoint, --start-block is ignored", zap.Uint64("after_block", checkpoint.BlockNum))
+		}
+
+		go elector.Keep(streamCtx)
+		go func() {
+			select {
+			case <-elector.Lost():
+				cancelStream()
+			case <-streamCtx.Done():
+			}
+		}()
+	}
+
+	var storeSchema *schema.Schema
+	if path := mustGetString(cmd, "store-schema"); path != "" {
+		storeSchema, err = schema.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := storeSchema.CheckModules(pkg.Modules); err != nil {
+			return fmt.Errorf("store schema %q: %w", path, err)
+		}
+	}
+
+	outputs, err := outputsConfigFromFlags(cmd, storeSchema)
+	if err != nil {
+		return err
+	}
+	tracker := chainhead.NewTracker()
+	chain, err := newOutputChain(ctx, outputs, tracker)
+	if err != nil {
+		return err
+	}
+	out := chain.Out
+	shutdownTimeout := mustGetDuration(cmd, "shutdown-timeout")
+	closed := false
+	defer func() {
+		if closed {
+			return
+		}
+		if err := closeSink(out, shutdownTimeout); err != nil {
+			zlog.Warn("closing sinks", zap.Error(err))
+		}
+	}()
+
+	rpcPrices, err := rpcusage.ParsePrices(mustGetStringSlice(cmd, "rpc-price"))
+	if err != nil {
+		return err
+	}
+	rpcMeter := rpcusage.NewMeter()
+	rpcClient := &http.Client{Transport: rpcusage.NewTransport(rpcMeter, nil)}
+
+	if url := mustGetString(cmd, "network-head-rpc"); url != "" {
+		go chainhead.PollRPC(ctx, rpcClient, tracker, url, mustGetDuration(cmd, "network-head-poll-interval"))
+	}
+	if addr := mustGetString(cmd, "metrics-listen-addr"); addr != "" {
+		go serveMetrics(addr, tlsConfig)
+	}
+
+	// The lease is shared by all instances, it's ahead of a local journal
+	// written while another instance was leading.
+	startCursor := chain.ResumeCursor
+	if leaderCursor != "" {
+		startCursor = leaderCursor
+	}
+
+	mode, err := newStreamMode(chain.UndoLog != nil, mustGetBool(cmd, "live-after-backfill"), chain.Batched, mustGetDuration(cmd, "backfill-flush-interval"), mustGetDuration(cmd, "handoff-lag"))
+	if err != nil {
+		return err
+	}
+
+	allow, block := mustGetStringSlice(cmd, "allow-pair"), mustGetStringSlice(cmd, "block-pair")
+	filter, err := pairfilter.New(mustGetString(cmd, "pair-filter-file"), allow, block)
+	if err != nil {
+		return fmt.Errorf("pair filter setup: %w", err)
+	}
+	go filter.Watch(ctx, mustGetDuration(cmd, "pair-filter-reload-interval"))
+
+	var pacer *replay.Pacer
+	if speed := mustGetFloat64(cmd, "replay-speed"); speed != 0 {
+		pacer, err = replay.NewPacer(speed)
+		if err != nil {
+			return err
+		}
+	}
+
+	liveParams := runParams(filter, allow, block, pacer)
+	if path := mustGetString(cmd, "params-file"); path != "" {
+		file := params.NewFile(path, liveParams)
+		if _, err := file.Load(); err != nil {
+			return err
+		}
+		for _, change := range liveParams.Apply(0) {
+			zlog.Info("param set from params file", zap.String("name", change.Name), zap.String("value", change.New))
+		}
+		go file.Watch(ctx, mustGetDuration(cmd, "params-reload-interval"))
+	}
+
+	ssClient, callOpts, err := client.NewSubstreamsClient(
+		mustGetString(cmd, "firehose-endpoint"),
+		os.Getenv(mustGetString(cmd, "substreams-api-key-envvar")),
+		mustGetBool(cmd, "insecure"),
+		mustGetBool(cmd, "plaintext"),
+	)
+	if err != nil {
+		return fmt.Errorf("substreams client setup: %w", err)
+	}
+
+	outputModules := append(append([]string(nil), args[1:]...), mustGetStringSlice(cmd, "output-modules")...)
+	if len(outputModules) == 0 {
+		return fmt.Errorf("no output module, give them as arguments or with --output-modules")
+	}
+
+	var stages []blockStage
+	if size := mustGetInt(cmd, "leaderboard-size"); size > 0 {
+		stages = append(stages, leaderboard.New(size, mustGetDuration(cmd, "leaderboard-window")))
+		outputModules = addModules(outputModules, leaderboard.SwapsModule, leaderboard.TradesModule)
+	}
+	if volumeSigma, priceSigma := mustGetFloat64(cmd, "anomaly-volume-sigma"), mustGetFloat64(cmd, "anomaly-price-sigma"); volumeSigma != 0 || priceSigma != 0 {
+		detector, err := anomaly.New(anomaly.Config{
+			Alpha:       mustGetFloat64(cmd, "anomaly-alpha"),
+			VolumeSigma: volumeSigma,
+			PriceSigma:  priceSigma,
+			Warmup:      mustGetInt(cmd, "anomaly-warmup"),
+		})
+		if err != nil {
+			return fmt.Errorf("anomaly detection setup: %w", err)
+		}
+		stages = append(stages, detector)
+		if volumeSigma != 0 {
+			outputModules = addModules(outputModules, anomaly.SwapsModule)
+		}
+		if priceSigma != 0 {
+			outputModules = addModules(outputModules, anomaly.ReservesModule)
+		}
+	}
+	if path := mustGetString(cmd, "entities"); path != "" {
+		mapping, err := entity.Load(path)
+		if err != nil {
+			return err
+		}
+		stages = append(stages, mapping)
+		outputModules = addModules(outputModules, mapping.Modules()...)
+	}
+	if mustGetString(cmd, "pair-stats-file") != "" {
+		outputModules = addModules(outputModules, pairstats.PairsModule, pairstats.EventsModule)
+	}
+	for _, spec := range append(mustGetStringSlice(cmd, "output"), mustGetStringSlice(cmd, "confirmed-output")...) {
+		if sinkScheme(spec) == "swaps" {
+			outputModules = addModules(outputModules, swaparchive.EventsModule)
+		}
+	}
+	modules, err := dag.Select(pkg.Modules, outputModules)
+	if err != nil {
+		return err
+	}
+	zlog.Info("modules selected", zap.Strings("outputs", outputModules), zap.Int("modules", len(modules.Modules)), zap.Int("skipped", len(pkg.Modules.Modules)-len(modules.Modules)))
+
+	// The state left on disk is checked against the modules of this run
+	// before any block is streamed.
+	runLineage, err := lineage.New(manifestPath, mustGetString(cmd, "firehose-endpoint"), modules, outputModules, &lineage.Run{
+		StartBlock: mustGetInt64(cmd, "start-block"),
+		StopBlock:  mustGetUint64(cmd, "stop-block"),
+		Params:     liveParams.Values(),
+	})
+	if err != nil {
+		return err
+	}
+	var lineagePaths []string
+	if outputs.CommitJournal != "" {
+		lineagePaths = append(lineagePaths, outputs.CommitJournal+".lineage.json")
+	}
+	if outputs.UndoBufferDir != "" {
+		lineagePaths = append(lineagePaths, filepath.Join(outputs.UndoBufferDir, lineage.FileName))
+	}
+	if mustGetString(cmd, "admin-listen-addr") != "" {
+		lineagePaths = append(lineagePaths, filepath.Join(mustGetString(cmd, "snapshot-dir"), lineage.FileName))
+	}
+
+	mismatchMode := mustGetString(cmd, "on-lineage-mismatch")
+	switch mismatchMode {
+	case "fail", "ignore", "rebuild":
+	default:
+		return fmt.Errorf("invalid --on-lineage-mismatch %q, expected one of: fail, ignore, rebuild", mismatchMode)
+	}
+
+	var mismatch *lineage.MismatchError
+	for _, path := range lineagePaths {
+		previous, err := lineage.Read(path)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			continue
+		}
+		if err := runLineage.Check(previous); err != nil {
+			if mismatchMode == "fail" || !errors.As(err, &mismatch) {
+				return fmt.Errorf("lineage %q: %w, see --on-lineage-mismatch", path, err)
+			}
+			zlog.Warn("state produced by other modules", zap.String("lineage", path), zap.Strings("invalid_stores", mismatch.Stores))
+		}
+	}
+
+	if mismatch != nil && mismatchMode == "rebuild" {
+		snapshotDir := mustGetString(cmd, "snapshot-dir")
+		snapshot, err := lastCompatibleSnapshot(snapshotDir, runLineage)
+		if err != nil {
+			return err
+		}
+		if snapshot == nil {
+			return fmt.Errorf("no snapshot in %q produced by the modules of this run to rebuild stores %v from, run from their initial block with empty outputs instead", snapshotDir, mismatch.Stores)
+		}
+
+		// The blocks after the snapshot were produced by the other modules.
+		if chain.UndoLog != nil {
+			if err := chain.UndoLog.Truncate(snapshot.BlockNum); err != nil {
+				return err
+			}
+		}
+		startCursor = snapshot.Cursor
+		zlog.Warn("rebuilding stores from the last compatible snapshot", zap.Strings("stores", mismatch.Stores), zap.Uint64("after_block", snapshot.BlockNum), zap.String("snapshot", snapshot.Path))
+	}
+
+	var lineageFiles []*lineage.File
+	for _, path := range lineagePaths {
+		f, err := lineage.Open(path, runLineage, mismatchMode != "fail")
+		if err != nil {
+			return err
+		}
+		lineageFiles = append(lineageFiles, f)
+	}
+	updateLineage := func(lastBlock uint64) error {
+		for _, f := range lineageFiles {
+			if err := f.Update(lastBlock); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The lineage holds the modules as built, the parameters pushed to them
+	// are recorded with the run.
+	adjustedAccounting := mustGetBool(cmd, "adjusted-accounting")
+	moduleParams := newModuleParams(modules, func() map[string]string {
+		values := filter.Params()
+		// only pushed when it isn't the default of the modules, the modules
+		// of other packages don't read it
+		if !adjustedAccounting {
+			values["adjusted-accounting"] = "false"
+		}
+		return values
+	})
+	req := &pbsubstreams.Request{
+		StartBlockNum: mustGetInt64(cmd, "start-block"),
+		StartCursor:   startCursor,
+		StopBlockNum:  mustGetUint64(cmd, "stop-block"),
+		ForkSteps:     mode.ForkSteps(),
+		OutputModules: outputModules,
+	}
+	if err := moduleParams.Push(req); err != nil {
+		return fmt.Errorf("module parameters: %w", err)
+	}
+
+	var watch *watchdog.Watchdog
+	if timeout := mustGetDuration(cmd, "stall-timeout"); timeout > 0 {
+		if watch, err = watchdog.New(timeout); err != nil {
+			return err
+		}
+		go watch.Run(streamCtx)
+	}
+	maxRestarts := mustGetInt(cmd, "stall-max-restarts")
+
+	summary := report.NewSummary()
+
+	var pairStats *pairstats.Stats
+	pairStatsFile := mustGetString(cmd, "pair-stats-file")
+	if pairStatsFile != "" {
+		if pairStats, err = pairstats.Load(pairStatsFile); err != nil {
+			return err
+		}
+	}
+
+	var controller *admin.Controller
+	if addr := mustGetString(cmd, "admin-listen-addr"); addr != "" {
+		controller = admin.NewController()
+		defer controller.Close()
+
+		server := admin.NewServer(controller, admin.LogLevelSwitcherAddr)
+		server.SetParams(liveParams)
+		if storeSchema != nil {
+			server.SetSchema(storeSchema)
+		}
+		server.SetChainHead(func() interface{} { return tracker.Status() })
+		server.SetTLS(tlsConfig)
+		if err := server.Listen(addr); err != nil {
+			return err
+		}
+		defer server.Close()
+	}
+
+	snapshotDir := mustGetString(cmd, "snapshot-dir")
+	var lastCheckpoint time.Time
+	var last *pbsubstreams.BlockScopedData
+
+	boundary := func(data *pbsubstreams.BlockScopedData) error {
+		num := data.Clock.GetNumber()
+		last = data
+
+		// Outputs are flushed before checkpointing, the next leader never
+		// skips blocks that weren't written.
+		if elector != nil && time.Since(lastCheckpoint) >= leaseTTL/3 {
+			if err := sink.Flush(ctx, out); err != nil {
+				return fmt.Errorf("flushing outputs: %w", err)
+			}
+			elector.Checkpoint(leader.Checkpoint{BlockNum: num, Cursor: data.Cursor})
+			lastCheckpoint = time.Now()
+		}
+
+		if controller != nil {
+			err := controller.Boundary(ctx, num, func(ctx context.Context) (*admin.Snapshot, error) {
+				snapshot, err := takeSnapshot(ctx, out, snapshotDir, data, summary, runLineage)
+				if err != nil {
+					return nil, err
+				}
+				return snapshot, updateLineage(num)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Applied once paused pipelines resume, so changes made while paused
+		// are part of the next block.
+		for _, change := range liveParams.Apply(num) {
+			zlog.Info("param changed", zap.String("name", change.Name), zap.String("old", change.Old), zap.String("new", change.New), zap.Uint64("after_block", num))
+			summary.ParamChanges = append(summary.ParamChanges, change)
+		}
+
+		if err := mode.Boundary(ctx, out); err != nil {
+			return err
+		}
+		if moduleParams.Changed() {
+			return errModuleParamsChanged
+		}
+		return nil
+	}
+
+	// streamBlocks streams the blocks of `req` to the outputs, opening the
+	// stream again from the last cursor when the watchdog finds it stalled.
+	streamBlocks := func() error {
+		restarts := 0
+		var restartedAfter *pbsubstreams.BlockScopedData
+		for {
+			attemptCtx, cancelAttempt := context.WithCancel(streamCtx)
+			stream, err := ssClient.Blocks(attemptCtx, req, callOpts...)
+			if err != nil {
+				cancelAttempt()
+				return fmt.Errorf("call sf.substreams.v1.Stream/Blocks: %w", err)
+			}
+			if watch != nil {
+				stream = watch.Watch(stream, cancelAttempt)
+			}
+			stream = mode.Wrap(stream)
+
+			// the outputs are written with the context of the run, a
+			// stalled stream is torn down between blocks
+			err = processStream(streamCtx, stream, stages, pacer, out, summary, pairStats, boundary)
+			cancelAttempt()
+			if watch == nil || !watch.Stalled() || streamCtx.Err() != nil {
+				return err
+			}
+
+			if last != restartedAfter {
+				restarts = 0
+			}
+			if restarts++; restarts > maxRestarts {
+				return fmt.Errorf("stream stalled %d times in a row, see --stall-timeout", restarts)
+			}
+			restartedAfter = last
+			if last != nil {
+				req.StartCursor = last.Cursor
+			}
+			zlog.Warn("stream stalled, opening it again from the last cursor", zap.Uint64("after_block", last.GetClock().GetNumber()), zap.Int("restarts", restarts))
+		}
+	}
+
+	// Once the backfill caught up, the outputs batched are flushed and the
+	// blocks streamed again from the last one, following the head. The same
+	// goes when the parameters of the modules changed, with the new ones.
+	err = streamBlocks()
+	for err == errCaughtUp || err == errModuleParamsChanged {
+		if err == errCaughtUp {
+			err = mode.GoLive(ctx, out, req, last)
+		} else {
+			zlog.Info("module parameters changed, streaming again with them", zap.Uint64("after_block", last.GetClock().GetNumber()))
+			if last != nil {
+				req.StartCursor = last.Cursor
+			}
+			err = moduleParams.Push(req)
+		}
+		if err == nil {
+			err = streamBlocks()
+		}
+	}
+	reason := stopReasonOf(ctx, err)
+	if elector != nil && streamCtx.Err() != nil && ctx.Err() == nil {
+		err = leader.ErrLost
+	}
+
+	// Outputs are flushed whatever the reason, what was written stays consistent
+	// up to the last block handed to them.
+	closed = true
+	if closeErr := closeSink(out, shutdownTimeout); closeErr != nil {
+		if err != nil {
+			zlog.Warn("closing sinks", zap.Error(closeErr))
+		} else {
+			err = fmt.Errorf("flushing outputs: %w", closeErr)
+			reason = StopFailed
+		}
+	}
+
+	if last != nil && reason != StopFailed {
+		if lineageErr := updateLineage(last.Clock.GetNumber()); lineageErr != nil {
+			zlog.Warn("updating lineage", zap.Error(lineageErr))
+		}
+	}
+
+	if elector != nil && reason != StopFailed {
+		if last != nil {
+			elector.Checkpoint(leader.Checkpoint{BlockNum: last.Clock.GetNumber(), Cursor: last.Cursor})
+		}
+		if releaseErr := elector.Release(context.Background()); releaseErr != nil {
+			zlog.Warn("releasing leader lease", zap.Error(releaseErr))
+		}
+	}
+
+	summary.Done()
+	summary.StopReason = reason.String()
+	summary.RPC = rpcMeter.Report(rpcPrices, summary.FirstBlock, summary.LastBlock+1)
+	summary.Print(os.Stderr)
+	if path := mustGetString(cmd, "summary-file"); path != "" {
+		if writeErr := summary.WriteJSON(path); writeErr != nil && err == nil {
+			err = writeErr
+			reason = StopFailed
+		}
+	}
+	if pairStatsFile != "" {
+		if saveErr := pairStats.Save(pairStatsFile); saveErr != nil && err == nil {
+			err = saveErr
+			reason = StopFailed
+		}
+	}
+
+	zlog.Info("run stopped", zap.Stringer("reason", reason))
+	if err != nil {
+		return &StopError{Reason: reason, Err: err}
+	}
+	return nil
+}
+
+// blockStage derives outputs of its own from the blocks, like the
+// leaderboards or the anomalies, before they are written.
+type blockStage interface {
+	Apply(data *pbsubstreams.BlockScopedData) (*pbsubstreams.BlockScopedData, error)
+}
+
+// processStream writes the blocks of `stream` to `out` until the end of the
+// stream, which is a nil error. `boundary` is called between blocks.
+// `pairStats`, optional, gets the time each block took from its reception to
+// its write, the pacer's wait excluded.
+func processStream(ctx context.Context, stream pbsubstreams.Stream_BlocksClient, stages []blockStage, pacer *replay.Pacer, out sink.Sink, summary *report.Summary, pairStats *pairstats.Stats, boundary func(data *pbsubstreams.BlockScopedData) error) error {
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		data := resp.GetData()
+		if data == nil {
+			continue
+		}
+		started := time.Now()
+
+		for _, stage := range stages {
+			data, err = stage.Apply(data)
+			if err != nil {
+				return fmt.Errorf("processing block %d: %w", resp.GetData().Clock.GetNumber(), err)
+			}
+		}
+
+		if pacer != nil {
+			waitStarted := time.Now()
+			if err := pacer.Wait(ctx, data.Clock); err != nil {
+				return err
+			}
+			started = started.Add(time.Since(waitStarted))
+		}
+
+		if err := out.Write(ctx, data); err != nil {
+			return fmt.Errorf("writing block %d: %w", data.Clock.GetNumber(), err)
+		}
+		summary.Observe(data)
+		pairStats.Observe(data, time.Since(started))
+
+		if err := boundary(data); err != nil {
+			return err
+		}
+	}
+}
+
+type snapshotFile struct {
+	*admin.Snapshot
+	TakenAt time.Time         `json:"taken_at"`
+	Summary *report.Summary   `json:"summary"`
+	Lineage *lineage.Manifest `json:"lineage"`
+}
+
+// takeSnapshot flushes `out` once `data` is written to it and records the
+// position of the outputs in `dir`, along with the lineage of the run.
+func takeSnapshot(ctx context.Context, out sink.Sink, dir string, data *pbsubstreams.BlockScopedData, summary *report.Summary, runLineage *lineage.Manifest) (*admin.Snapshot, error) {
+	if err := sink.Flush(ctx, out); err != nil {
+		return nil, fmt.Errorf("flushing outputs: %w", err)
+	}
+
+	snapshot := &admin.Snapshot{
+		BlockNum: data.Clock.GetNumber(),
+		BlockID:  data.Clock.GetId(),
+		Cursor:   data.Cursor,
+		Path:     filepath.Join(dir, fmt.Sprintf("snapshot-%010d.json", data.Clock.GetNumber())),
+	}
+
+	content, err := json.MarshalIndent(&snapshotFile{Snapshot: snapshot, TakenAt: time.Now().UTC(), Summary: summary, Lineage: runLineage}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create snapshot directory %q: %w", dir, err)
+	}
+	if err := os.WriteFile(snapshot.Path, content, 0644); err != nil {
+		return nil, fmt.Errorf("write snapshot: %w", err)
+	}
+
+	zlog.Info("snapshot taken", zap.Uint64("block_num", snapshot.BlockNum), zap.String("path", snapshot.Path))
+	return snapshot, nil
+}
+
+// lastCompatibleSnapshot returns the last snapshot of `dir` taken by a run of
+// the modules of `runLineage`, nil when there is none.
+func lastCompatibleSnapshot(dir string, runLineage *lineage.Manifest) (*admin.Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "snapshot-*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+
+	// Names are zero padded, the last one is the most recent block.
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read snapshot: %w", err)
+		}
+
+		file := &snapshotFile{}
+		if err := json.Unmarshal(content, file); err != nil {
+			return nil, fmt.Errorf("decode snapshot %q: %w", path, err)
+		}
+		if file.Snapshot == nil || file.Lineage == nil || runLineage.Check(file.Lineage) != nil {
+			continue
+		}
+
+		file.Snapshot.Path = path
+		return file.Snapshot, nil
+	}
+	return nil, nil
+}
+
+// instanceID returns `id`, or `<hostname>-<pid>` when empty.
+func instanceID(id string) string {
+	if id != "" {
+		return id
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// addModules appends the modules missing from `modules`.
+func addModules(modules []string, add ...string) []string {
+next:
+	for _, module := range add {
+		for _, m := range modules {
+			if m == module {
+				continue next
+			}
+		}
+		modules = append(modules, module)
+	}
+	return modules
+}
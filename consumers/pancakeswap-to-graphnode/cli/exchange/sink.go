@@ -0,0 +1,171 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/dlq"
+	"github.com/streamingfast/substream-pancakeswap/sink/sqlsink"
+	"go.uber.org/zap"
+)
+
+var sinkCmd = &cobra.Command{
+	Use:   "sink",
+	Short: "set up the databases written by the 'run' outputs, and write the blocks they failed to write again",
+}
+
+var sinkReplayDLQCmd = &cobra.Command{
+	Use:   "replay-dlq <dead-letter-store> <output>",
+	Short: "write the blocks an output failed to write during 'run --dead-letter-store' to it again, once the cause is fixed",
+	Long: `Write the blocks kept in the dead-letter store for an output to it again, in the
+order they failed, and remove the letters written. The output is given like
+'run --output' or 'run --sql' (e.g. 'postgres:<dsn>'), its letters are found
+under its scheme unless --letters is given.
+
+Replayed blocks don't move the cursor of the sql outputs, and the keys written
+by a later block since are left as is. Blocks failing again are kept, the
+command fails once all letters were tried.`,
+	RunE:         runSinkReplayDLQ,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+}
+
+var sinkPgCmd = &cobra.Command{
+	Use:   "pg",
+	Short: "Postgres database written by 'run --sql postgres:<dsn>'",
+}
+
+var sinkPgInitCmd = &cobra.Command{
+	Use:   "init <dsn>",
+	Short: "create the tables of the stores of the store schema, with typed values and indexed key components, migrating them when the schema changed",
+	Long: `Create the tables of the stores declared in the store schema, the value column
+being typed after the store value type and each key component being an indexed
+column generated from the key, like 'pair' for 'pair_day:{day}:{pair}:usd'.
+
+The statements are applied as a migration recorded in the '_migrations' table,
+running init again after a schema change updates the key component columns. The
+value column of existing tables isn't changed. Requires Postgres 12.`,
+	RunE:         runSinkPgInit,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	sinkPgInitCmd.Flags().String("store-schema", "", "YAML file declaring the keys and value types of the stores, see 'run --store-schema'")
+	sinkPgInitCmd.Flags().Bool("dry-run", false, "print the statements instead of applying them")
+
+	sinkReplayDLQCmd.Flags().String("letters", "", "output name the letters are filed under, the scheme of <output> when empty")
+	sinkReplayDLQCmd.Flags().String("store-schema", "", "YAML file declaring the keys and value types of the stores, see 'run --store-schema'")
+	sinkReplayDLQCmd.Flags().Bool("dry-run", false, "list the letters instead of writing them")
+
+	sinkPgCmd.AddCommand(sinkPgInitCmd)
+	sinkCmd.AddCommand(sinkPgCmd)
+	sinkCmd.AddCommand(sinkReplayDLQCmd)
+	rootCmd.AddCommand(sinkCmd)
+}
+
+func runSinkPgInit(cmd *cobra.Command, args []string) error {
+	path := mustGetString(cmd, "store-schema")
+	if path == "" {
+		return fmt.Errorf("--store-schema is required")
+	}
+	storeSchema, err := schema.Load(path)
+	if err != nil {
+		return err
+	}
+
+	migration, err := sqlsink.NewPostgresMigration(storeSchema)
+	if err != nil {
+		return err
+	}
+
+	if mustGetBool(cmd, "dry-run") {
+		fmt.Fprintf(cmd.OutOrStdout(), "-- migration %s\n%s", migration.Version, migration.SQL())
+		return nil
+	}
+
+	applied, err := migration.Apply(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	zlog.Info("postgres sink initialized", zap.String("migration", migration.Version), zap.Bool("applied", applied), zap.Int("stores", len(storeSchema.Stores)))
+	return nil
+}
+
+func runSinkReplayDLQ(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	store, err := dlq.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	name := mustGetString(cmd, "letters")
+	if name == "" {
+		name = sinkScheme(args[1])
+	}
+	letters, err := store.List(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(letters) == 0 {
+		zlog.Info("no letter to replay", zap.String("output", name))
+		return nil
+	}
+
+	if mustGetBool(cmd, "dry-run") {
+		for _, letter := range letters {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%d\t%s\t%s\t%s\n", letter.Name(), letter.BlockNum, letter.Step, letter.FailedAt.Format(time.RFC3339), letter.Error)
+		}
+		return nil
+	}
+
+	var storeSchema *schema.Schema
+	if path := mustGetString(cmd, "store-schema"); path != "" {
+		if storeSchema, err = schema.Load(path); err != nil {
+			return err
+		}
+	}
+	out, err := newSQLSink(ctx, args[1], storeSchema)
+	if err != nil {
+		return err
+	}
+
+	var written []*dlq.Letter
+	failed := 0
+	replayCtx := sink.WithReplay(ctx)
+	for _, letter := range letters {
+		data, err := letter.Data()
+		if err == nil {
+			err = out.Write(replayCtx, data)
+		}
+		if err != nil {
+			if !sink.IsPermanent(err) {
+				out.Close()
+				return fmt.Errorf("replaying letter %q: %w", letter.Name(), err)
+			}
+			zlog.Warn("letter failed again", zap.String("letter", letter.Name()), zap.Error(err))
+			failed++
+			continue
+		}
+		written = append(written, letter)
+	}
+
+	// Letters are removed once their blocks are durable.
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+	for _, letter := range written {
+		if err := store.Delete(ctx, letter); err != nil {
+			return err
+		}
+	}
+
+	zlog.Info("letters replayed", zap.String("output", name), zap.Int("written", len(written)), zap.Int("failed", failed))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d letters failed again, they are kept", failed, len(letters))
+	}
+	return nil
+}
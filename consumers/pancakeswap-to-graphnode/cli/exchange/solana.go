@@ -0,0 +1,124 @@
+//go:build solana
+// +build solana
+
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v1"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	pbsol "github.com/streamingfast/substream-pancakeswap/pb/sf/solana/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/solswap"
+	"go.uber.org/zap"
+)
+
+var solanaSwapsCmd = &cobra.Command{
+	Use:   "solana-swaps",
+	Short: "stream Solana blocks and print the swaps of the Orca and Raydium pools, as JSON lines",
+	Long: `Stream Solana blocks from a firehose through the 'solana_volumes' module and
+print the swaps of the Orca and Raydium pools as JSON lines, the volumes swapped
+per mint and the swaps per DEX being logged at the end of the stream.
+
+This command is only built with the 'solana' build tag.`,
+	RunE:         runSolanaSwaps,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+}
+
+func init() {
+	solanaSwapsCmd.Flags().Int64P("start-block", "s", -1, "Start slot for blockchain firehose")
+	solanaSwapsCmd.Flags().Uint64P("stop-block", "t", 0, "Stop slot for blockchain firehose")
+
+	solanaSwapsCmd.Flags().String("firehose-endpoint", "mainnet.sol.streamingfast.io:443", "firehose GRPC endpoint")
+	solanaSwapsCmd.Flags().String("substreams-api-key-envvar", "FIREHOSE_API_KEY", "name of variable containing firehose authentication token (JWT)")
+	solanaSwapsCmd.Flags().BoolP("insecure", "k", false, "Skip certificate validation on GRPC connection")
+	solanaSwapsCmd.Flags().BoolP("plaintext", "p", false, "Establish GRPC connection in plaintext")
+	rootCmd.AddCommand(solanaSwapsCmd)
+}
+
+func runSolanaSwaps(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	pipeline, err := modules.NewPipeline(solswap.VolumesModule)
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	fhClient, callOpts, err := newFirehoseClient(
+		mustGetString(cmd, "firehose-endpoint"),
+		os.Getenv(mustGetString(cmd, "substreams-api-key-envvar")),
+		mustGetBool(cmd, "insecure"),
+		mustGetBool(cmd, "plaintext"),
+	)
+	if err != nil {
+		return fmt.Errorf("firehose client setup: %w", err)
+	}
+
+	stream, err := fhClient.Blocks(ctx, &pbfirehose.Request{
+		StartBlockNum: mustGetInt64(cmd, "start-block"),
+		StopBlockNum:  mustGetUint64(cmd, "stop-block"),
+		ForkSteps:     []pbfirehose.ForkStep{pbfirehose.ForkStep_STEP_IRREVERSIBLE},
+	}, callOpts...)
+	if err != nil {
+		return fmt.Errorf("call sf.firehose.v1.Stream/Blocks: %w", err)
+	}
+
+	mints := map[string]bool{}
+	encoder := json.NewEncoder(os.Stdout)
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				logSolanaVolumes(pipeline, mints)
+				return nil
+			}
+			return err
+		}
+
+		block := &pbsol.Block{}
+		if err := resp.Block.UnmarshalTo(block); err != nil {
+			return fmt.Errorf("unmarshal block: %w", err)
+		}
+
+		out, err := pipeline.Process(block)
+		if err != nil {
+			return err
+		}
+
+		for _, swap := range out.Outputs[solswap.VolumesModule].([]*solswap.Swap) {
+			mints[swap.MintIn], mints[swap.MintOut] = true, true
+			if err := encoder.Encode(swap); err != nil {
+				return fmt.Errorf("writing swap: %w", err)
+			}
+		}
+	}
+}
+
+func logSolanaVolumes(pipeline *modules.Pipeline, mints map[string]bool) {
+	state, found := pipeline.State(solswap.VolumesModule)
+	if !found {
+		return
+	}
+
+	for _, dex := range []string{"orca", "raydium"} {
+		count, _ := state.Get("swaps:" + dex)
+		zlog.Info("swaps", zap.String("dex", dex), zap.ByteString("count", count))
+	}
+
+	sorted := make([]string, 0, len(mints))
+	for mint := range mints {
+		sorted = append(sorted, mint)
+	}
+	sort.Strings(sorted)
+	for _, mint := range sorted {
+		volume, _ := state.Get("volume:" + mint)
+		zlog.Info("volume", zap.String("mint", mint), zap.ByteString("volume", volume))
+	}
+}
@@ -0,0 +1,201 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	"github.com/streamingfast/substream-pancakeswap/sink/history"
+	"github.com/streamingfast/substream-pancakeswap/state"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "read the stores at any past block out of the deltas persisted by the 'deltalog' output",
+}
+
+var stateGetCmd = &cobra.Command{
+	Use:          "get <store url>",
+	Short:        "print a key, or every key, of a store as it was once --block was applied",
+	RunE:         runStateGet,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+var stateSnapshotCmd = &cobra.Command{
+	Use:   "snapshot <store url> [<store module>...]",
+	Short: "record the state of the given stores, all of them when none, every --interval blocks so reads don't apply the log from its beginning",
+	Long: `Record the state of the stores every --interval blocks, from their last
+snapshot to the end of the log, running it again after the log grew adds the
+new snapshots. Reads start from the closest snapshot at or before the block
+read. Snapshots must only cover irreversible blocks.`,
+	RunE:         runStateSnapshot,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+}
+
+var stateServeCmd = &cobra.Command{
+	Use:          "serve <store url>",
+	Short:        "serve 'state get' over HTTP, like 'GET /state?store=prices&key=price:0x..:usd&block=7000000'",
+	RunE:         runStateServe,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+var stateHistoryCmd = &cobra.Command{
+	Use:          "history <key>",
+	Short:        "print the values taken by a key tracked by 'run --track-key', a trailing '*' prints every key with that prefix",
+	RunE:         runStateHistory,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	stateGetCmd.Flags().String("store", "", "store module read, the 'store_' prefix can be omitted")
+	stateGetCmd.Flags().String("key", "", "key read, every key of the store when empty")
+	stateGetCmd.Flags().Uint64("block", 0, "block at which the store is read, included")
+
+	stateSnapshotCmd.Flags().Uint64("interval", 100_000, "number of blocks between two snapshots")
+	stateSnapshotCmd.Flags().Int("compress-above", 0, "compress the values larger than this many bytes with snappy, shrinking the snapshots of stores holding serialized entities, disabled when 0")
+
+	stateServeCmd.Flags().String("listen-addr", "localhost:8091", "address the HTTP server listens on")
+	stateServeCmd.Flags().String("auth-file", "", "file of '<namespace> <token>' lines, the reads of a namespace, and of the ones under it, then require 'Authorization: Bearer <token>', '/' being the root namespace, every namespace is open when empty")
+	addServerTLSFlags(stateServeCmd.Flags())
+
+	stateHistoryCmd.Flags().String("history-file", "./history.dbin", "history file written by 'run --history-file'")
+	stateHistoryCmd.Flags().String("store", "", "only print the values of this store module")
+
+	stateCmd.AddCommand(stateGetCmd)
+	stateCmd.AddCommand(stateSnapshotCmd)
+	stateCmd.AddCommand(stateServeCmd)
+	stateCmd.AddCommand(stateHistoryCmd)
+	rootCmd.AddCommand(stateCmd)
+}
+
+func runStateGet(cmd *cobra.Command, args []string) error {
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	store := mustGetString(cmd, "store")
+	if store == "" {
+		return fmt.Errorf("--store is required")
+	}
+
+	result, err := state.Get(cmd.Context(), reader, state.Query{
+		Store: store,
+		Key:   mustGetString(cmd, "key"),
+		Block: mustGetUint64(cmd, "block"),
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+func runStateSnapshot(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	topics := args[1:]
+	if len(topics) == 0 {
+		topics, err = reader.Topics(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	reader.SetCompressionThreshold(mustGetInt(cmd, "compress-above"))
+
+	interval := mustGetUint64(cmd, "interval")
+	for _, topic := range topics {
+		written, err := reader.WriteSnapshots(ctx, topic, interval)
+		if err != nil {
+			return fmt.Errorf("snapshots of %q: %w", topic, err)
+		}
+		zlog.Info("snapshots written", zap.String("topic", topic), zap.Int("snapshots", written))
+	}
+	return nil
+}
+
+func runStateServe(cmd *cobra.Command, args []string) error {
+	reader, err := deltalog.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	server := state.NewServer(reader, nil)
+	if err := requireTokens(cmd, server); err != nil {
+		return err
+	}
+	tlsConfig, err := loadServerTLS(cmd)
+	if err != nil {
+		return err
+	}
+	server.SetTLS(tlsConfig)
+	if err := server.Listen(mustGetString(cmd, "listen-addr")); err != nil {
+		return err
+	}
+	defer server.Close()
+
+	<-cmd.Context().Done()
+	return nil
+}
+
+func runStateHistory(cmd *cobra.Command, args []string) error {
+	entries, err := history.Read(mustGetString(cmd, "history-file"), args)
+	if err != nil {
+		return err
+	}
+
+	store := mustGetString(cmd, "store")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "BLOCK\tTIME\tSTORE\tKEY\tOPERATION\tVALUE\n")
+	for _, entry := range entries {
+		if store != "" && entry.Store != store {
+			continue
+		}
+
+		value := entry.Delta.NewValue
+		if entry.Delta.Operation == pbsubstreams.StoreDelta_DELETE {
+			value = nil
+		}
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\t%s\t%s\n",
+			entry.BlockNum,
+			time.Unix(entry.Timestamp, 0).UTC().Format(time.RFC3339),
+			entry.Store,
+			entry.Delta.Key,
+			entry.Delta.Operation,
+			state.FormatValue(value),
+		)
+	}
+	return tw.Flush()
+}
+
+func requireTokens(cmd *cobra.Command, server *state.Server) error {
+	path := mustGetString(cmd, "auth-file")
+	if path == "" {
+		return nil
+	}
+
+	tokens, err := state.ReadTokens(path)
+	if err != nil {
+		return err
+	}
+	server.RequireTokens(tokens)
+	return nil
+}
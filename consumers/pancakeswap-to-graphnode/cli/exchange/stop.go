@@ -0,0 +1,102 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+)
+
+// StopReason tells why a run ended, it's reflected in the exit code of the
+// process so scripts can tell a completed range from a failed or interrupted
+// one without parsing logs.
+type StopReason int
+
+const (
+	// StopCompleted is a run that reached its stop block, or the end of the
+	// stream, with all outputs flushed.
+	StopCompleted StopReason = iota
+	StopFailed
+	// StopInterrupted is a run canceled by SIGINT or SIGTERM, outputs are still
+	// flushed up to the last fully written block.
+	StopInterrupted
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopCompleted:
+		return "completed"
+	case StopFailed:
+		return "failed"
+	case StopInterrupted:
+		return "interrupted"
+	}
+	return fmt.Sprintf("StopReason(%d)", int(r))
+}
+
+func (r StopReason) ExitCode() int {
+	switch r {
+	case StopCompleted:
+		return 0
+	case StopInterrupted:
+		return 130
+	}
+	return 1
+}
+
+// StopError is returned by commands that did not complete, with the reason
+// they stopped.
+type StopError struct {
+	Reason StopReason
+	Err    error
+}
+
+func (e *StopError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Reason, e.Err)
+}
+
+func (e *StopError) Unwrap() error {
+	return e.Err
+}
+
+func stopReasonOf(ctx context.Context, err error) StopReason {
+	switch {
+	case err == nil:
+		return StopCompleted
+	case ctx.Err() != nil:
+		return StopInterrupted
+	}
+	return StopFailed
+}
+
+// exitCode is the exit code of the process for the error returned by a
+// command.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var stopErr *StopError
+	if errors.As(err, &stopErr) {
+		return stopErr.Reason.ExitCode()
+	}
+	return 1
+}
+
+// closeSink closes `s`, flushing what it buffered, and gives up after
+// `timeout` so a stuck output can't hold the process forever.
+func closeSink(s sink.Sink, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("outputs not flushed after %s", timeout)
+	}
+}
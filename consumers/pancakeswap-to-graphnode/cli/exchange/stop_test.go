@@ -0,0 +1,93 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closingSink fails to close with `err`, or never closes when `hang` is set.
+type closingSink struct {
+	err  error
+	hang bool
+}
+
+func (s *closingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	return nil
+}
+
+func (s *closingSink) Close() error {
+	if s.hang {
+		select {}
+	}
+	return s.err
+}
+
+func TestStopReason(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the context of the commands is canceled on signals, see `Main`
+	signaled, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGTERM))
+	select {
+	case <-signaled.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal not received")
+	}
+
+	sinkErr := fmt.Errorf("writing block 12: %w", errors.New("connection refused"))
+
+	tests := []struct {
+		name             string
+		ctx              context.Context
+		err              error
+		expectedReason   StopReason
+		expectedExitCode int
+	}{
+		{"stop block reached", context.Background(), nil, StopCompleted, 0},
+		{"context canceled", canceled, context.Canceled, StopInterrupted, 130},
+		{"signal", signaled, context.Canceled, StopInterrupted, 130},
+		{"sink error", context.Background(), sinkErr, StopFailed, 1},
+		{"sink error while interrupted", signaled, sinkErr, StopInterrupted, 130},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason := stopReasonOf(test.ctx, test.err)
+			assert.Equal(t, test.expectedReason, reason)
+
+			if test.err == nil {
+				assert.Equal(t, test.expectedExitCode, exitCode(nil))
+				return
+			}
+			runErr := &StopError{Reason: reason, Err: test.err}
+			assert.Equal(t, test.expectedExitCode, exitCode(runErr))
+			assert.Equal(t, test.expectedExitCode, exitCode(fmt.Errorf("run: %w", runErr)), "wrapped")
+		})
+	}
+
+	assert.Equal(t, 1, exitCode(errors.New("unknown flag")), "errors of the command line")
+}
+
+func TestCloseSink(t *testing.T) {
+	require.NoError(t, closeSink(&closingSink{}, time.Second))
+
+	closeErr := errors.New("flushing batch: disk full")
+	assert.Equal(t, closeErr, closeSink(&closingSink{err: closeErr}, time.Second))
+
+	err := closeSink(&closingSink{hang: true}, 10*time.Millisecond)
+	assert.EqualError(t, err, "outputs not flushed after 10ms")
+}
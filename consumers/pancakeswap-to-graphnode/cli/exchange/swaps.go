@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/swaparchive"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+var swapsCmd = &cobra.Command{
+	Use:   "swaps",
+	Short: "swaps archived by the 'swaps' output",
+}
+
+var swapsCatCmd = &cobra.Command{
+	Use:          "cat <store url>",
+	Short:        "print the archived swaps of a block range, the whole archive when no --range",
+	Example:      `  exchange swaps cat file:///data/swaps --range 20000000:20010000 --output-format console`,
+	RunE:         runSwapsCat,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+}
+
+func init() {
+	swapsCatCmd.Flags().String("range", "", "blocks printed, as <start>:<stop>, the stop block excluded")
+	swapsCatCmd.Flags().String("output-format", sink.FormatJSON, "how the swaps are printed, 'json', 'console' or 'text', see 'run --output-format'")
+
+	swapsCmd.AddCommand(swapsCatCmd)
+	rootCmd.AddCommand(swapsCmd)
+}
+
+func runSwapsCat(cmd *cobra.Command, args []string) error {
+	reader, err := swaparchive.NewReader(args[0])
+	if err != nil {
+		return err
+	}
+
+	var start, stop uint64
+	if blockRange := mustGetString(cmd, "range"); blockRange != "" {
+		if start, stop, err = parseBlockRange(blockRange); err != nil {
+			return fmt.Errorf("--range: %w", err)
+		}
+	}
+
+	renderer, err := sink.NewRenderer(mustGetString(cmd, "output-format"))
+	if err != nil {
+		return fmt.Errorf("--output-format: %w", err)
+	}
+
+	out := bufio.NewWriter(cmd.OutOrStdout())
+	var swaps uint64
+	err = reader.Read(cmd.Context(), start, stop, func(swap *swaparchive.Swap) error {
+		swaps++
+		return renderer.Render(out, &sink.Record{
+			ID:        fmt.Sprintf("%s:%d", swap.BlockID, swap.Event.LogOrdinal),
+			Module:    swaparchive.EventsModule,
+			BlockNum:  swap.BlockNum,
+			BlockID:   swap.BlockID,
+			Timestamp: swap.BlockTime,
+			Step:      pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+			Entity:    swap.Event,
+		})
+	})
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return err
+	}
+
+	zlog.Info("swaps printed", zap.Uint64("swaps", swaps))
+	return nil
+}
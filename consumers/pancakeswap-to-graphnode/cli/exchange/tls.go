@@ -0,0 +1,42 @@
+package exchange
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/streamingfast/substream-pancakeswap/servertls"
+	"go.uber.org/zap"
+)
+
+func addServerTLSFlags(flags *pflag.FlagSet) {
+	flags.String("tls-cert", "", "PEM certificate the servers (admin, metrics, state, flight outputs) are served over TLS with, reloaded when it changes, plain TCP when empty")
+	flags.String("tls-key", "", "PEM key of --tls-cert, reloaded when it changes")
+	flags.String("tls-client-ca", "", "PEM CA the client certificates must be signed by, reloaded when it changes, client certificates aren't requested when empty")
+}
+
+// loadServerTLS returns the TLS configuration of the servers, nil when TLS
+// isn't enabled.
+func loadServerTLS(cmd *cobra.Command) (*tls.Config, error) {
+	return servertls.Load(servertls.Config{
+		CertFile:     mustGetString(cmd, "tls-cert"),
+		KeyFile:      mustGetString(cmd, "tls-key"),
+		ClientCAFile: mustGetString(cmd, "tls-client-ca"),
+	})
+}
+
+// serveMetrics serves the Prometheus metrics like `dmetrics.Serve`, over TLS
+// when `config` is set.
+func serveMetrics(addr string, config *tls.Config) {
+	server := &http.Server{Addr: addr, Handler: promhttp.Handler(), TLSConfig: config}
+
+	var err error
+	if config != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	zlog.Warn("metrics server stopped", zap.String("addr", addr), zap.Error(err))
+}
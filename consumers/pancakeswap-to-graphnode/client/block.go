@@ -0,0 +1,119 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Block is a block of the stream with the outputs of the requested modules.
+// Undone blocks, see `Undo`, come with the outputs they had when new: their
+// deltas are to be reverted, from last to first, and their map outputs
+// forgotten.
+type Block struct {
+	Number    uint64
+	ID        string
+	Timestamp time.Time
+	Step      pbsubstreams.ForkStep
+	Cursor    string
+
+	data   *pbsubstreams.BlockScopedData
+	schema *schema.Schema
+}
+
+func newBlock(data *pbsubstreams.BlockScopedData, schema *schema.Schema) *Block {
+	return &Block{
+		Number:    data.Clock.GetNumber(),
+		ID:        data.Clock.GetId(),
+		Timestamp: data.Clock.GetTimestamp().AsTime(),
+		Step:      data.Step,
+		Cursor:    data.Cursor,
+		data:      data,
+		schema:    schema,
+	}
+}
+
+// Undo tells if the block was forked out, its outputs must be reverted.
+func (b *Block) Undo() bool {
+	return b.Step == pbsubstreams.ForkStep_STEP_UNDO
+}
+
+// Data is the block as received.
+func (b *Block) Data() *pbsubstreams.BlockScopedData {
+	return b.data
+}
+
+// Output decodes the output of the map `module` into `into`, false when the
+// module has no output for the block.
+func (b *Block) Output(module string, into proto.Message) (bool, error) {
+	output := b.output(module)
+	if output.GetMapOutput() == nil {
+		return false, nil
+	}
+	if err := output.GetMapOutput().UnmarshalTo(into); err != nil {
+		return false, fmt.Errorf("decoding output of %s: %w", module, err)
+	}
+	return true, nil
+}
+
+// Delta is a change of a store, with the values decoded as the store's value
+// type of the schema, see `schema.DecodeValue`, or bytes when there's no
+// schema. Values missing from the change are nil.
+type Delta struct {
+	Operation pbsubstreams.StoreDelta_Operation
+	Ordinal   uint64
+	Key       string
+	OldValue  interface{}
+	NewValue  interface{}
+}
+
+// Deltas returns the changes of the store `module` for the block.
+func (b *Block) Deltas(module string) ([]*Delta, error) {
+	var store *schema.Store
+	if b.schema != nil {
+		store = b.schema.Stores[module]
+	}
+
+	var out []*Delta
+	for _, delta := range b.output(module).GetStoreDeltas().GetDeltas() {
+		oldValue, err := decodeValue(store, delta.OldValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s key %q old value: %w", module, delta.Key, err)
+		}
+		newValue, err := decodeValue(store, delta.NewValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s key %q new value: %w", module, delta.Key, err)
+		}
+
+		out = append(out, &Delta{
+			Operation: delta.Operation,
+			Ordinal:   delta.Ordinal,
+			Key:       delta.Key,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+		})
+	}
+	return out, nil
+}
+
+func (b *Block) output(module string) *pbsubstreams.ModuleOutput {
+	for _, output := range b.data.Outputs {
+		if output.Name == module {
+			return output
+		}
+	}
+	return nil
+}
+
+func decodeValue(store *schema.Store, value []byte) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if store == nil {
+		return value, nil
+	}
+	return store.Decode(value)
+}
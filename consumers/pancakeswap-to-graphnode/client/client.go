@@ -0,0 +1,233 @@
+// Package client consumes the blocks of the substreams `Blocks` API for
+// programs built on the exchange's modules, without the exchange itself:
+//
+//	c, err := client.New(&client.Config{
+//		Endpoint:      "bsc-dev.streamingfast.io:443",
+//		APIKey:        os.Getenv("SUBSTREAMS_API_TOKEN"),
+//		Modules:       manifest.Modules,
+//		OutputModules: []string{"store_pairs"},
+//		Schema:        storeSchema,
+//	})
+//	...
+//	err = c.Run(ctx, func(ctx context.Context, block *client.Block) error {
+//		deltas, err := block.Deltas("store_pairs")
+//		...
+//		for _, delta := range deltas {
+//			...
+//		}
+//		return saveCursor(block.Cursor)
+//	})
+//
+// The stream is reconnected on transient errors, resuming after the last block
+// handled without error, so a handler sees every block once. Programs that
+// outlive a stream persist the `Cursor` of the blocks they handled and start
+// from it.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	ssclient "github.com/streamingfast/substreams/client"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Config struct {
+	Endpoint string
+	APIKey   string
+	// Insecure skips the verification of the server's certificate, Plaintext
+	// doesn't use TLS at all.
+	Insecure  bool
+	Plaintext bool
+
+	Modules       *pbsubstreams.Modules
+	OutputModules []string
+	StartBlock    int64
+	// StopBlock is exclusive, 0 streams forever.
+	StopBlock uint64
+	// Cursor resumes after the block it was given with, `StartBlock` is then
+	// ignored.
+	Cursor string
+	// ForkSteps defaults to new and undo blocks.
+	ForkSteps []pbsubstreams.ForkStep
+
+	// MaxRetries is the number of consecutive reconnections before giving up,
+	// 0 retries forever. The count resets once a block is received.
+	MaxRetries int
+	// RetryDelay is the wait before the first reconnection, 1s by default,
+	// doubled on each further one up to MaxRetryDelay, 30s by default.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// Schema decodes the values of the deltas of the stores it knows, values
+	// are left as bytes without it.
+	Schema *schema.Schema
+}
+
+// HandleFunc handles a block. An error stops `Run`, the block isn't considered
+// handled.
+type HandleFunc func(ctx context.Context, block *Block) error
+
+type Client struct {
+	config   Config
+	stream   pbsubstreams.StreamClient
+	callOpts []grpc.CallOption
+
+	cursor string
+}
+
+func New(config *Config) (*Client, error) {
+	if config.Modules == nil {
+		return nil, fmt.Errorf("no modules")
+	}
+	if len(config.OutputModules) == 0 {
+		return nil, fmt.Errorf("no output module")
+	}
+
+	stream, callOpts, err := ssclient.NewSubstreamsClient(config.Endpoint, config.APIKey, config.Insecure, config.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("substreams client setup: %w", err)
+	}
+	return newClient(stream, callOpts, config), nil
+}
+
+func newClient(stream pbsubstreams.StreamClient, callOpts []grpc.CallOption, config *Config) *Client {
+	c := &Client{
+		config:   *config,
+		stream:   stream,
+		callOpts: callOpts,
+		cursor:   config.Cursor,
+	}
+	if len(c.config.ForkSteps) == 0 {
+		c.config.ForkSteps = []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_NEW, pbsubstreams.ForkStep_STEP_UNDO}
+	}
+	if c.config.RetryDelay == 0 {
+		c.config.RetryDelay = time.Second
+	}
+	if c.config.MaxRetryDelay == 0 {
+		c.config.MaxRetryDelay = 30 * time.Second
+	}
+	return c
+}
+
+// Cursor is the cursor of the last block handled without error, the one to
+// resume from.
+func (c *Client) Cursor() string {
+	return c.cursor
+}
+
+// Run streams the blocks to `handle` until the stop block, which is a nil
+// error, an error of `handle`, the cancellation of `ctx` or a stream error
+// that isn't transient or outlasting `MaxRetries` reconnections.
+func (c *Client) Run(ctx context.Context, handle HandleFunc) error {
+	retries := 0
+	delay := c.config.RetryDelay
+
+	for {
+		received, err := c.runStream(ctx, handle)
+		if err == nil {
+			return nil
+		}
+		var handleErr *handleError
+		if errors.As(err, &handleErr) {
+			return handleErr.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryable(err) {
+			return err
+		}
+
+		if received {
+			retries = 0
+			delay = c.config.RetryDelay
+		}
+		retries++
+		if c.config.MaxRetries > 0 && retries > c.config.MaxRetries {
+			return fmt.Errorf("giving up after %d reconnections: %w", c.config.MaxRetries, err)
+		}
+
+		zlog.Warn("stream failed, reconnecting", zap.Error(err), zap.Int("retries", retries), zap.Duration("delay", delay))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > c.config.MaxRetryDelay {
+			delay = c.config.MaxRetryDelay
+		}
+	}
+}
+
+// handleError keeps the errors of the handler apart from the stream's.
+type handleError struct {
+	err error
+}
+
+func (e *handleError) Error() string { return e.err.Error() }
+
+// runStream streams from the cursor, `received` tells if any block came in.
+func (c *Client) runStream(ctx context.Context, handle HandleFunc) (received bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req := &pbsubstreams.Request{
+		StartBlockNum: c.config.StartBlock,
+		StartCursor:   c.cursor,
+		StopBlockNum:  c.config.StopBlock,
+		ForkSteps:     c.config.ForkSteps,
+		Modules:       c.config.Modules,
+		OutputModules: c.config.OutputModules,
+	}
+	stream, err := c.stream.Blocks(ctx, req, c.callOpts...)
+	if err != nil {
+		return false, fmt.Errorf("call sf.substreams.v1.Stream/Blocks: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return received, nil
+			}
+			return received, err
+		}
+
+		data := resp.GetData()
+		if data == nil {
+			continue
+		}
+		received = true
+
+		if err := handle(ctx, newBlock(data, c.config.Schema)); err != nil {
+			return received, &handleError{fmt.Errorf("handling block %d: %w", data.Clock.GetNumber(), err)}
+		}
+		c.cursor = data.Cursor
+	}
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	// status.Code doesn't unwrap, and reports errors that aren't gRPC statuses
+	// as Unknown.
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return false
+	}
+	switch grpcErr.GRPCStatus().Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	pcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// fakeStreamClient serves a stream per call to Blocks, each a list of
+// responses followed by an error, io.EOF ending the stream.
+type fakeStreamClient struct {
+	streams [][]*pbsubstreams.Response
+	errs    []error
+
+	requests []*pbsubstreams.Request
+}
+
+func (c *fakeStreamClient) Blocks(ctx context.Context, in *pbsubstreams.Request, opts ...grpc.CallOption) (pbsubstreams.Stream_BlocksClient, error) {
+	i := len(c.requests)
+	c.requests = append(c.requests, proto.Clone(in).(*pbsubstreams.Request))
+	if i >= len(c.streams) {
+		return nil, errors.New("no more streams")
+	}
+	return &fakeStream{responses: c.streams[i], err: c.errs[i]}, nil
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	responses []*pbsubstreams.Response
+	err       error
+}
+
+func (s *fakeStream) Recv() (*pbsubstreams.Response, error) {
+	if len(s.responses) == 0 {
+		return nil, s.err
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+func block(num uint64, step pbsubstreams.ForkStep, outputs ...*pbsubstreams.ModuleOutput) *pbsubstreams.Response {
+	return &pbsubstreams.Response{Message: &pbsubstreams.Response_Data{Data: &pbsubstreams.BlockScopedData{
+		Clock:   &pbsubstreams.Clock{Number: num},
+		Step:    step,
+		Cursor:  "cursor-" + strconv.FormatUint(num, 10),
+		Outputs: outputs,
+	}}}
+}
+
+func testConfig() *Config {
+	return &Config{
+		Modules:       &pbsubstreams.Modules{},
+		OutputModules: []string{"store_pairs"},
+		StartBlock:    10,
+		RetryDelay:    time.Millisecond,
+	}
+}
+
+func TestClient_Run_Reconnects(t *testing.T) {
+	stream := &fakeStreamClient{
+		streams: [][]*pbsubstreams.Response{
+			{block(10, pbsubstreams.ForkStep_STEP_NEW), block(11, pbsubstreams.ForkStep_STEP_NEW)},
+			{},
+			{block(12, pbsubstreams.ForkStep_STEP_NEW)},
+		},
+		errs: []error{
+			status.Error(codes.Unavailable, "connection reset"),
+			status.Error(codes.Unavailable, "connection refused"),
+			io.EOF,
+		},
+	}
+	c := newClient(stream, nil, testConfig())
+
+	var handled []uint64
+	require.NoError(t, c.Run(context.Background(), func(ctx context.Context, block *Block) error {
+		handled = append(handled, block.Number)
+		return nil
+	}))
+
+	assert.Equal(t, []uint64{10, 11, 12}, handled)
+	assert.Equal(t, "cursor-12", c.Cursor())
+	require.Len(t, stream.requests, 3)
+	assert.Equal(t, "", stream.requests[0].StartCursor)
+	assert.Equal(t, "cursor-11", stream.requests[1].StartCursor)
+	assert.Equal(t, "cursor-11", stream.requests[2].StartCursor)
+	assert.Equal(t, []pbsubstreams.ForkStep{pbsubstreams.ForkStep_STEP_NEW, pbsubstreams.ForkStep_STEP_UNDO}, stream.requests[0].ForkSteps)
+}
+
+func TestClient_Run_Errors(t *testing.T) {
+	t.Run("not retryable", func(t *testing.T) {
+		stream := &fakeStreamClient{
+			streams: [][]*pbsubstreams.Response{{}},
+			errs:    []error{status.Error(codes.InvalidArgument, "unknown module")},
+		}
+		err := newClient(stream, nil, testConfig()).Run(context.Background(), func(ctx context.Context, block *Block) error { return nil })
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Len(t, stream.requests, 1)
+	})
+
+	t.Run("max retries", func(t *testing.T) {
+		unavailable := status.Error(codes.Unavailable, "connection refused")
+		stream := &fakeStreamClient{
+			streams: [][]*pbsubstreams.Response{{}, {}, {}},
+			errs:    []error{unavailable, unavailable, unavailable},
+		}
+		config := testConfig()
+		config.MaxRetries = 2
+
+		err := newClient(stream, nil, config).Run(context.Background(), func(ctx context.Context, block *Block) error { return nil })
+		assert.ErrorIs(t, err, unavailable)
+		assert.Len(t, stream.requests, 3)
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		stream := &fakeStreamClient{
+			streams: [][]*pbsubstreams.Response{{block(10, pbsubstreams.ForkStep_STEP_NEW), block(11, pbsubstreams.ForkStep_STEP_NEW)}},
+			errs:    []error{io.EOF},
+		}
+		failed := errors.New("disk full")
+
+		c := newClient(stream, nil, testConfig())
+		err := c.Run(context.Background(), func(ctx context.Context, block *Block) error {
+			if block.Number == 11 {
+				return failed
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, failed)
+		assert.Equal(t, "cursor-10", c.Cursor(), "block 11 wasn't handled")
+	})
+}
+
+func TestBlock(t *testing.T) {
+	pair := &pcs.Pair{Address: "0xaa", Token0Address: "0x01", Token1Address: "0x02"}
+	rawPair, err := proto.Marshal(pair)
+	require.NoError(t, err)
+	pairs, err := anypb.New(&pcs.Pairs{Pairs: []*pcs.Pair{pair}})
+	require.NoError(t, err)
+
+	storeSchema := &schema.Schema{Stores: map[string]*schema.Store{
+		"store_pairs":  {ValueType: "proto:pcs.types.v1.Pair"},
+		"store_totals": {ValueType: "bigint"},
+	}}
+
+	resp := block(10, pbsubstreams.ForkStep_STEP_UNDO,
+		&pbsubstreams.ModuleOutput{Name: "map_pairs", Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: pairs}},
+		&pbsubstreams.ModuleOutput{Name: "store_pairs", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+			{Operation: pbsubstreams.StoreDelta_CREATE, Ordinal: 3, Key: "pair:0xaa", NewValue: rawPair},
+		}}}},
+		&pbsubstreams.ModuleOutput{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+			{Operation: pbsubstreams.StoreDelta_UPDATE, Ordinal: 4, Key: "pair_count", OldValue: []byte("1"), NewValue: []byte("2")},
+		}}}},
+		&pbsubstreams.ModuleOutput{Name: "store_volumes", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+			{Operation: pbsubstreams.StoreDelta_DELETE, Ordinal: 5, Key: "pair:0xaa", OldValue: []byte("1.5")},
+		}}}},
+	)
+	b := newBlock(resp.GetData(), storeSchema)
+	assert.True(t, b.Undo())
+
+	var out pcs.Pairs
+	found, err := b.Output("map_pairs", &out)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "0xaa", out.Pairs[0].Address)
+
+	found, err = b.Output("map_volumes", &out)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	deltas, err := b.Deltas("store_pairs")
+	require.NoError(t, err)
+	require.Len(t, deltas, 1)
+	assert.Nil(t, deltas[0].OldValue)
+	assert.True(t, proto.Equal(pair, deltas[0].NewValue.(proto.Message)))
+
+	deltas, err = b.Deltas("store_totals")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), deltas[0].OldValue)
+	assert.Equal(t, big.NewInt(2), deltas[0].NewValue)
+
+	deltas, err = b.Deltas("store_volumes")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1.5"), deltas[0].OldValue, "not in the schema")
+	assert.Nil(t, deltas[0].NewValue)
+}
@@ -0,0 +1,155 @@
+// Command csv-dump writes the deltas of stores to a CSV file, one row per
+// change:
+//
+//	block_num,block_id,step,store,ordinal,operation,key,old_value,new_value
+//
+// Values are decoded with the store schema when one is given, protobuf values
+// are written as JSON, bytes as hex. The cursor of the last block written is
+// kept in `-cursor-file`, a new run appends to the file from there.
+//
+//	go run ./client/examples/csv-dump -manifest ../../modules/pancakeswap/substreams.yaml \
+//		-schema ../../modules/pancakeswap/schema.yaml -start-block 6810706 -stop-block 6811000 \
+//		-out deltas.csv store_pairs store_totals
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/streamingfast/substream-pancakeswap/client"
+	_ "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/streamingfast/substreams/manifest"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func main() {
+	endpoint := flag.String("endpoint", "bsc-dev.streamingfast.io:443", "substreams endpoint")
+	apiKeyEnv := flag.String("api-key-envvar", "SUBSTREAMS_API_TOKEN", "environment variable of the API key")
+	plaintext := flag.Bool("plaintext", false, "connect without TLS")
+	manifestPath := flag.String("manifest", "", "substreams manifest")
+	schemaPath := flag.String("schema", "", "store schema decoding the values, raw bytes are written without it")
+	startBlock := flag.Int64("start-block", 0, "first block, ignored when resuming from the cursor file")
+	stopBlock := flag.Uint64("stop-block", 0, "exclusive last block, 0 streams forever")
+	out := flag.String("out", "deltas.csv", "CSV file, appended to")
+	cursorFile := flag.String("cursor-file", "csv-dump.cursor", "file keeping the cursor of the last block written")
+	flag.Parse()
+
+	if err := run(*endpoint, os.Getenv(*apiKeyEnv), *plaintext, *manifestPath, *schemaPath, *startBlock, *stopBlock, *out, *cursorFile, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(endpoint, apiKey string, plaintext bool, manifestPath, schemaPath string, startBlock int64, stopBlock uint64, out, cursorFile string, stores []string) error {
+	if len(stores) == 0 {
+		return fmt.Errorf("no store given")
+	}
+
+	pkg, err := manifest.NewReader(manifestPath).Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+
+	var storeSchema *schema.Schema
+	if schemaPath != "" {
+		if storeSchema, err = schema.Load(schemaPath); err != nil {
+			return err
+		}
+	}
+
+	cursor, err := os.ReadFile(cursorFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read cursor: %w", err)
+	}
+
+	c, err := client.New(&client.Config{
+		Endpoint:      endpoint,
+		APIKey:        apiKey,
+		Plaintext:     plaintext,
+		Modules:       pkg.Modules,
+		OutputModules: stores,
+		StartBlock:    startBlock,
+		StopBlock:     stopBlock,
+		Cursor:        string(cursor),
+		Schema:        storeSchema,
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		w.Write([]string{"block_num", "block_id", "step", "store", "ordinal", "operation", "key", "old_value", "new_value"})
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	return c.Run(ctx, func(ctx context.Context, block *client.Block) error {
+		for _, store := range stores {
+			deltas, err := block.Deltas(store)
+			if err != nil {
+				return err
+			}
+			for _, delta := range deltas {
+				oldValue, err := format(delta.OldValue)
+				if err != nil {
+					return err
+				}
+				newValue, err := format(delta.NewValue)
+				if err != nil {
+					return err
+				}
+
+				w.Write([]string{
+					strconv.FormatUint(block.Number, 10),
+					block.ID,
+					block.Step.String(),
+					store,
+					strconv.FormatUint(delta.Ordinal, 10),
+					delta.Operation.String(),
+					delta.Key,
+					oldValue,
+					newValue,
+				})
+			}
+		}
+
+		// the rows must be on disk before the cursor moves past them
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		return os.WriteFile(cursorFile, []byte(block.Cursor), 0644)
+	})
+}
+
+func format(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []byte:
+		return fmt.Sprintf("%x", v), nil
+	case proto.Message:
+		raw, err := protojson.Marshal(v)
+		return string(raw), err
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,206 @@
+// Command pg-load keeps the latest state of stores in Postgres, a table per
+// store:
+//
+//	CREATE TABLE <store> (key text PRIMARY KEY, value text NOT NULL, block_num bigint NOT NULL)
+//
+// Values are decoded with the store schema when one is given, protobuf values
+// are written as JSON, bytes as hex. The deltas of a block and its cursor, kept
+// in the `cursor` table, are written in one transaction, a new run resumes from
+// the cursor. Undone blocks are reverted.
+//
+//	go run ./client/examples/pg-load -manifest ../../modules/pancakeswap/substreams.yaml \
+//		-schema ../../modules/pancakeswap/schema.yaml -start-block 6810706 \
+//		-dsn "postgres://localhost/pcs?sslmode=disable" store_pairs store_totals
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/lib/pq"
+	"github.com/streamingfast/substream-pancakeswap/client"
+	_ "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func main() {
+	endpoint := flag.String("endpoint", "bsc-dev.streamingfast.io:443", "substreams endpoint")
+	apiKeyEnv := flag.String("api-key-envvar", "SUBSTREAMS_API_TOKEN", "environment variable of the API key")
+	plaintext := flag.Bool("plaintext", false, "connect without TLS")
+	manifestPath := flag.String("manifest", "", "substreams manifest")
+	schemaPath := flag.String("schema", "", "store schema decoding the values, hex bytes are written without it")
+	startBlock := flag.Int64("start-block", 0, "first block, ignored when resuming from the cursor")
+	stopBlock := flag.Uint64("stop-block", 0, "exclusive last block, 0 streams forever")
+	dsn := flag.String("dsn", "postgres://localhost/substreams?sslmode=disable", "Postgres DSN")
+	flag.Parse()
+
+	if err := run(*endpoint, os.Getenv(*apiKeyEnv), *plaintext, *manifestPath, *schemaPath, *startBlock, *stopBlock, *dsn, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(endpoint, apiKey string, plaintext bool, manifestPath, schemaPath string, startBlock int64, stopBlock uint64, dsn string, stores []string) error {
+	if len(stores) == 0 {
+		return fmt.Errorf("no store given")
+	}
+
+	pkg, err := manifest.NewReader(manifestPath).Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+
+	var storeSchema *schema.Schema
+	if schemaPath != "" {
+		if storeSchema, err = schema.Load(schemaPath); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := createTables(ctx, db, stores); err != nil {
+		return err
+	}
+
+	var cursor string
+	err = db.QueryRowContext(ctx, `SELECT cursor FROM cursor WHERE id = 1`).Scan(&cursor)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("read cursor: %w", err)
+	}
+
+	c, err := client.New(&client.Config{
+		Endpoint:      endpoint,
+		APIKey:        apiKey,
+		Plaintext:     plaintext,
+		Modules:       pkg.Modules,
+		OutputModules: stores,
+		StartBlock:    startBlock,
+		StopBlock:     stopBlock,
+		Cursor:        cursor,
+		Schema:        storeSchema,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Run(ctx, func(ctx context.Context, block *client.Block) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, store := range stores {
+			deltas, err := block.Deltas(store)
+			if err != nil {
+				return err
+			}
+			if block.Undo() {
+				for i := len(deltas) - 1; i >= 0; i-- {
+					if err := revert(ctx, tx, store, block.Number, deltas[i]); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			for _, delta := range deltas {
+				if err := apply(ctx, tx, store, block.Number, delta); err != nil {
+					return err
+				}
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO cursor (id, cursor, block_num) VALUES (1, $1, $2)
+			ON CONFLICT (id) DO UPDATE SET cursor = excluded.cursor, block_num = excluded.block_num`,
+			block.Cursor, block.Number,
+		); err != nil {
+			return fmt.Errorf("write cursor: %w", err)
+		}
+		return tx.Commit()
+	})
+}
+
+func createTables(ctx context.Context, db *sql.DB, stores []string) error {
+	statements := []string{`CREATE TABLE IF NOT EXISTS cursor (id integer PRIMARY KEY, cursor text NOT NULL, block_num bigint NOT NULL)`}
+	for _, store := range stores {
+		statements = append(statements, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (key text PRIMARY KEY, value text NOT NULL, block_num bigint NOT NULL)`, pq.QuoteIdentifier(store)))
+	}
+
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("create tables: %w", err)
+		}
+	}
+	return nil
+}
+
+func apply(ctx context.Context, tx *sql.Tx, store string, blockNum uint64, delta *client.Delta) error {
+	if delta.Operation == pbsubstreams.StoreDelta_DELETE {
+		return remove(ctx, tx, store, delta.Key)
+	}
+	return upsert(ctx, tx, store, blockNum, delta.Key, delta.NewValue)
+}
+
+// revert puts back the value the key had before `delta`.
+func revert(ctx context.Context, tx *sql.Tx, store string, blockNum uint64, delta *client.Delta) error {
+	if delta.Operation == pbsubstreams.StoreDelta_CREATE {
+		return remove(ctx, tx, store, delta.Key)
+	}
+	return upsert(ctx, tx, store, blockNum, delta.Key, delta.OldValue)
+}
+
+func upsert(ctx context.Context, tx *sql.Tx, store string, blockNum uint64, key string, value interface{}) error {
+	formatted, err := format(value)
+	if err != nil {
+		return fmt.Errorf("%s key %q: %w", store, key, err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (key, value, block_num) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, block_num = excluded.block_num`,
+		pq.QuoteIdentifier(store),
+	)
+	if _, err := tx.ExecContext(ctx, query, key, formatted, blockNum); err != nil {
+		return fmt.Errorf("%s key %q: %w", store, key, err)
+	}
+	return nil
+}
+
+func remove(ctx context.Context, tx *sql.Tx, store string, key string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE key = $1`, pq.QuoteIdentifier(store))
+	if _, err := tx.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("%s key %q: %w", store, key, err)
+	}
+	return nil
+}
+
+func format(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []byte:
+		return fmt.Sprintf("%x", v), nil
+	case proto.Message:
+		raw, err := protojson.Marshal(v)
+		return string(raw), err
+	}
+	return fmt.Sprint(value), nil
+}
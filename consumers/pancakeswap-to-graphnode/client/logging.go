@@ -0,0 +1,7 @@
+package client
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.client", "github.com/streamingfast/substream-pancakeswap/client")
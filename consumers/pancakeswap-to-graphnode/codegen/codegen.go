@@ -0,0 +1,178 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	eth "github.com/streamingfast/eth-go"
+)
+
+// Config drives the generation of a module out of an ABI.
+type Config struct {
+	// Package is the Go package name of the generated files.
+	Package string
+	// Module is the name under which the module is registered, derived from the first event when empty.
+	Module string
+	// Events are the ABI event names to generate decoders for.
+	Events []string
+}
+
+type module struct {
+	Package string
+	Name    string
+	Func    string
+	UsesBig bool
+	Events  []*event
+}
+
+type event struct {
+	Name      string
+	Signature string
+	HasData   bool
+	Params    []*param
+}
+
+type param struct {
+	Name     string
+	Field    string
+	TypeName string
+	GoType   string
+	Indexed  bool
+}
+
+// Generate renders the Go files of a module decoding `config.Events` from `abi`,
+// keyed by file name. The `_events.go` file holds the decoding code and is meant
+// to be regenerated, the `_module.go` file holds the map and state builder stubs
+// registered in the modules registry and is meant to be edited.
+func Generate(abi *eth.ABI, config Config) (map[string][]byte, error) {
+	if len(config.Events) == 0 {
+		return nil, fmt.Errorf("at least one event is required")
+	}
+
+	if config.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+
+	mod := &module{Package: config.Package, Name: config.Module}
+	if mod.Name == "" {
+		mod.Name = snakeCase(config.Events[0])
+	}
+	mod.Func = goName(mod.Name)
+
+	for _, name := range config.Events {
+		def, found := abi.LogEventsByNameMap[name]
+		if !found {
+			return nil, fmt.Errorf("event %q not found in abi, available events: %s", name, strings.Join(eventNames(abi), ", "))
+		}
+
+		ev := &event{Name: goName(def.Name)}
+		types := make([]string, len(def.Parameters))
+		for i, p := range def.Parameters {
+			types[i] = p.TypeName
+			goType := goTypeOf(p.TypeName)
+			if goType == "*big.Int" {
+				mod.UsesBig = true
+			}
+			if !p.Indexed {
+				ev.HasData = true
+			}
+
+			ev.Params = append(ev.Params, &param{
+				Name:     p.GetName(i),
+				Field:    goName(p.GetName(i)),
+				TypeName: p.TypeName,
+				GoType:   goType,
+				Indexed:  p.Indexed,
+			})
+		}
+		ev.Signature = fmt.Sprintf("%s(%s)", def.Name, strings.Join(types, ","))
+		mod.Events = append(mod.Events, ev)
+	}
+
+	out := map[string][]byte{}
+	for suffix, tpl := range map[string]*template.Template{"_events.go": eventsTemplate, "_module.go": moduleTemplate} {
+		buf := bytes.NewBuffer(nil)
+		if err := tpl.Execute(buf, mod); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", tpl.Name(), err)
+		}
+
+		content, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("formatting %s: %w", tpl.Name(), err)
+		}
+		out[mod.Name+suffix] = content
+	}
+
+	return out, nil
+}
+
+func eventNames(abi *eth.ABI) (out []string) {
+	for name := range abi.LogEventsByNameMap {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return
+}
+
+// goTypeOf maps an ABI type to the Go type returned by the eth-go decoder,
+// types it does not know about are kept as `interface{}`.
+func goTypeOf(typeName string) string {
+	switch typeName {
+	case "address":
+		return "eth.Address"
+	case "bool":
+		return "bool"
+	case "string":
+		return "string"
+	case "bytes", "bytes32":
+		return "[]byte"
+	case "uint8", "uint16", "uint32":
+		return typeName
+	case "uint24":
+		return "uint32"
+	case "uint40", "uint48", "uint56", "uint64":
+		return "uint64"
+	}
+
+	if strings.HasPrefix(typeName, "uint") && !strings.HasSuffix(typeName, "]") {
+		return "*big.Int"
+	}
+
+	return "interface{}"
+}
+
+func goName(in string) string {
+	var out strings.Builder
+	upper := true
+	for _, r := range in {
+		if r == '_' || r == '-' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+func snakeCase(in string) string {
+	var out strings.Builder
+	for i, r := range in {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				out.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
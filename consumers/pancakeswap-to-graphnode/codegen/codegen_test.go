@@ -0,0 +1,110 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	eth "github.com/streamingfast/eth-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const factoryABI = `[
+  {
+    "anonymous": false,
+    "inputs": [
+      {"indexed": true, "internalType": "address", "name": "token0", "type": "address"},
+      {"indexed": true, "internalType": "address", "name": "token1", "type": "address"},
+      {"indexed": false, "internalType": "address", "name": "pair", "type": "address"},
+      {"indexed": false, "internalType": "uint256", "name": "", "type": "uint256"}
+    ],
+    "name": "PairCreated",
+    "type": "event"
+  }
+]`
+
+func TestGenerate(t *testing.T) {
+	abi, err := eth.ParseABIFromBytes([]byte(factoryABI))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		config        Config
+		expectedFiles []string
+		expectedDecls []string
+		expectedErr   bool
+	}{
+		{
+			"pair created",
+			Config{Package: "factory", Events: []string{"PairCreated"}},
+			[]string{"pair_created_events.go", "pair_created_module.go"},
+			[]string{"PairCreatedTopic", "PairCreatedEvent", "IsPairCreatedEvent", "NewPairCreatedEvent", "PairCreatedOutput", "MapPairCreated", "StorePairCreated"},
+			false,
+		},
+		{
+			"custom module name",
+			Config{Package: "factory", Module: "pairs", Events: []string{"PairCreated"}},
+			[]string{"pairs_events.go", "pairs_module.go"},
+			[]string{"PairCreatedEvent", "MapPairs", "StorePairs"},
+			false,
+		},
+		{
+			"unknown event",
+			Config{Package: "factory", Events: []string{"Swap"}},
+			nil,
+			nil,
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := Generate(abi, test.config)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var decls []string
+			for _, name := range test.expectedFiles {
+				content, found := files[name]
+				require.True(t, found, "missing file %q", name)
+
+				file, err := parser.ParseFile(token.NewFileSet(), name, content, 0)
+				require.NoError(t, err)
+				assert.Equal(t, test.config.Package, file.Name.Name)
+
+				for name := range file.Scope.Objects {
+					decls = append(decls, name)
+				}
+			}
+			assert.Len(t, files, len(test.expectedFiles))
+			assert.Subset(t, decls, test.expectedDecls)
+		})
+	}
+}
+
+func Test_goTypeOf(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"address", "eth.Address"},
+		{"uint8", "uint8"},
+		{"uint24", "uint32"},
+		{"uint64", "uint64"},
+		{"uint112", "*big.Int"},
+		{"uint256", "*big.Int"},
+		{"bytes32", "[]byte"},
+		{"uint256[]", "interface{}"},
+		{"int256", "interface{}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			assert.Equal(t, test.expected, goTypeOf(test.in))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package codegen
+
+import (
+	"text/template"
+)
+
+var eventsTemplate = template.Must(template.New("events").Parse(`// Code generated by 'exchange generate module'. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"bytes"
+	"fmt"
+	{{- if .UsesBig}}
+	"math/big"
+	{{- end}}
+
+	eth "github.com/streamingfast/eth-go"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+)
+{{range .Events}}
+// {{.Name}}Topic is the signature topic of ` + "`{{.Signature}}`" + `.
+var {{.Name}}Topic = eth.Keccak256([]byte("{{.Signature}}"))
+
+type {{.Name}}Event struct {
+	{{- range .Params}}
+	{{.Field}} {{.GoType}}
+	{{- end}}
+}
+
+func Is{{.Name}}Event(log *pbeth.Log) bool {
+	return len(log.Topics) > 0 && bytes.Equal(log.Topics[0], {{.Name}}Topic)
+}
+
+func New{{.Name}}Event(log *pbeth.Log) (*{{.Name}}Event, error) {
+	decoder := eth.NewLogDecoder(&eth.Log{Address: log.Address, Topics: log.Topics, Data: log.Data})
+	if _, err := decoder.ReadTopic(); err != nil {
+		return nil, fmt.Errorf("reading signature topic: %w", err)
+	}
+	{{- if .HasData}}
+	if decoder.DataDecoder == nil {
+		return nil, fmt.Errorf("log has no data")
+	}
+	{{- end}}
+
+	ev := &{{.Name}}Event{}
+	{{- if .Params}}
+	var value interface{}
+	var err error
+	{{- end}}
+	{{- range .Params}}
+
+	{{if .Indexed}}value, err = decoder.ReadTypedTopic("{{.TypeName}}"){{else}}value, err = decoder.ReadData("{{.TypeName}}"){{end}}
+	if err != nil {
+		return nil, fmt.Errorf("reading {{.Name}}: %w", err)
+	}
+	ev.{{.Field}} = value{{if ne .GoType "interface{}"}}.({{.GoType}}){{end}}
+	{{- end}}
+
+	return ev, nil
+}
+{{end}}`))
+
+var moduleTemplate = template.Must(template.New("module").Parse(`package {{.Package}}
+
+import (
+	"fmt"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+func init() {
+	modules.Register(&modules.Module{
+		Name:  "{{.Name}}",
+		Map:   Map{{.Func}},
+		Store: Store{{.Func}},
+	})
+}
+
+type {{.Func}}Output struct {
+	{{- range .Events}}
+	{{.Name}}Events []*{{.Name}}Event
+	{{- end}}
+}
+
+// Map{{.Func}} extracts the events of the module from the successful transactions of the block.
+func Map{{.Func}}(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+	out := &{{.Func}}Output{}
+	for _, log := range intr.Logs() {
+		switch {
+		{{- range .Events}}
+		case Is{{.Name}}Event(log):
+			ev, err := New{{.Name}}Event(log)
+			if err != nil {
+				return nil, fmt.Errorf("decoding {{.Name}} event at block %d: %w", block.Number, err)
+			}
+			out.{{.Name}}Events = append(out.{{.Name}}Events, ev)
+		{{- end}}
+		}
+	}
+
+	return out, nil
+}
+
+// Store{{.Func}} builds the module state out of Map{{.Func}} output.
+func Store{{.Func}}(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, store sdk.Store) error {
+	events := output.(*{{.Func}}Output)
+
+	// TODO: fold the events into the state, for example with ` + "`store.Set(key, value)`" + `.
+	_ = events
+
+	return nil
+}
+`))
@@ -0,0 +1,75 @@
+// Package dag selects the part of the modules graph of a substreams package
+// needed to compute some output modules.
+package dag
+
+import (
+	"fmt"
+	"sort"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Select returns the modules of `modules` needed to compute `outputs`, the
+// outputs themselves and their transitive inputs, in the order of the package.
+// Binaries are kept as is, the modules refer to them by index.
+func Select(modules *pbsubstreams.Modules, outputs []string) (*pbsubstreams.Modules, error) {
+	byName := map[string]*pbsubstreams.Module{}
+	for _, module := range modules.Modules {
+		byName[module.Name] = module
+	}
+
+	selected := map[string]bool{}
+	var visit func(name, from string) error
+	visit = func(name, from string) error {
+		if selected[name] {
+			return nil
+		}
+		module, found := byName[name]
+		if !found {
+			if from != "" {
+				return fmt.Errorf("module %q: input module %q not found", from, name)
+			}
+			return fmt.Errorf("module %q not found, the package has %v", name, names(modules))
+		}
+
+		selected[name] = true
+		for _, input := range module.Inputs {
+			var upstream string
+			switch in := input.Input.(type) {
+			case *pbsubstreams.Module_Input_Map_:
+				upstream = in.Map.ModuleName
+			case *pbsubstreams.Module_Input_Store_:
+				upstream = in.Store.ModuleName
+			default:
+				continue
+			}
+			if err := visit(upstream, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, output := range outputs {
+		if err := visit(output, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	out := &pbsubstreams.Modules{Binaries: modules.Binaries}
+	for _, module := range modules.Modules {
+		if selected[module.Name] {
+			out.Modules = append(out.Modules, module)
+		}
+	}
+	return out, nil
+}
+
+func names(modules *pbsubstreams.Modules) []string {
+	out := make([]string, 0, len(modules.Modules))
+	for _, module := range modules.Modules {
+		out = append(out, module.Name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,68 @@
+package dag
+
+import (
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func module(name string, inputs ...string) *pbsubstreams.Module {
+	m := &pbsubstreams.Module{Name: name, Inputs: []*pbsubstreams.Module_Input{
+		{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.ethereum.type.v1.Block"}}},
+	}}
+	for _, input := range inputs {
+		if input[0] == '@' {
+			m.Inputs = append(m.Inputs, &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Store_{Store: &pbsubstreams.Module_Input_Store{ModuleName: input[1:]}}})
+			continue
+		}
+		m.Inputs = append(m.Inputs, &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: input}}})
+	}
+	return m
+}
+
+func TestSelect(t *testing.T) {
+	modules := &pbsubstreams.Modules{
+		Modules: []*pbsubstreams.Module{
+			module("map_pairs"),
+			module("store_pairs", "map_pairs"),
+			module("store_totals", "map_pairs"),
+			module("map_reserves", "@store_pairs"),
+			module("store_reserves", "map_reserves"),
+			module("map_swaps", "@store_pairs", "@store_reserves"),
+			module("store_volumes", "map_swaps"),
+		},
+		Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1"}},
+	}
+
+	tests := []struct {
+		name      string
+		outputs   []string
+		expected  []string
+		expectErr bool
+	}{
+		{"leaf", []string{"map_pairs"}, []string{"map_pairs"}, false},
+		{"transitive", []string{"store_volumes"}, []string{"map_pairs", "store_pairs", "map_reserves", "store_reserves", "map_swaps", "store_volumes"}, false},
+		{"several", []string{"store_totals", "store_reserves"}, []string{"map_pairs", "store_pairs", "store_totals", "map_reserves", "store_reserves"}, false},
+		{"unknown", []string{"store_fees"}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected, err := Select(modules, test.outputs)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, m := range selected.Modules {
+				names = append(names, m.Name)
+			}
+			assert.Equal(t, test.expected, names)
+			assert.Equal(t, modules.Binaries, selected.Binaries)
+		})
+	}
+}
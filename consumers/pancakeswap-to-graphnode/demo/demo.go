@@ -0,0 +1,47 @@
+// Package demo serves the demo UI of the playground: a page plotting the
+// prices of a pair live, from the records broadcast by the `ws` output, see
+// the `sink/wsfeed` package.
+package demo
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed ui
+var ui embed.FS
+
+type Config struct {
+	// FeedURL is the WebSocket endpoint of the `ws` output, like
+	// `ws://localhost:8095/`, the browser connects to it directly.
+	FeedURL string `json:"feed_url"`
+	// Pair is the address of the pair plotted when the page opens, it can be
+	// changed from the page.
+	Pair string `json:"pair"`
+	// Module is the store whose `price:<pair>:<token>:token0|token1` keys
+	// are plotted.
+	Module string `json:"module"`
+	// Points is the number of prices kept on the chart.
+	Points int `json:"points"`
+}
+
+// Handler serves the page at `/` and its config at `/config.json`.
+func Handler(config Config) http.Handler {
+	config.Pair = strings.ToLower(config.Pair)
+
+	static, err := fs.Sub(ui, "ui")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(config)
+	})
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	return mux
+}
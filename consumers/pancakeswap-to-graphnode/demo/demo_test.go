@@ -0,0 +1,35 @@
+package demo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, server *httptest.Server, path string) (string, string) {
+	t.Helper()
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body), resp.Header.Get("Content-Type")
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler(Config{FeedURL: "ws://localhost:8095/", Pair: "0x0ED7e52944161450477ee417de9cd3a859b14fd0", Module: "store_reserves", Points: 500}))
+	defer server.Close()
+
+	page, contentType := get(t, server, "/")
+	assert.Equal(t, "text/html; charset=utf-8", contentType)
+	assert.Contains(t, page, "<title>PancakeSwap live prices</title>")
+
+	config, _ := get(t, server, "/config.json")
+	assert.JSONEq(t, `{"feed_url":"ws://localhost:8095/","pair":"0x0ed7e52944161450477ee417de9cd3a859b14fd0","module":"store_reserves","points":500}`, config)
+}
@@ -0,0 +1,495 @@
+// Package entity maps the stores deltas and the map outputs to the changes of
+// typed entities, like the `pair`, `token` and `swap` rows of a subgraph, as
+// `pcs.database.v1.DatabaseChanges`. The key conventions of the stores are
+// written down once, in a mapping file, instead of in every sink writing
+// entities:
+//
+//	entities:
+//	  pair:
+//	    - store: store_pairs
+//	      key: pair:{id}
+//	      type: pcs.types.v1.Pair
+//	      fields:
+//	        token_0: token0_address
+//	        token_1: token1_address
+//	    - store: store_volumes
+//	      key: pair:{id}:{volume=usd|token0|token1}
+//	      field: volume_{volume}
+//	  swap:
+//	    - module: map_burn_swaps_events
+//	      type: pcs.types.v1.Event
+//	      when: swap
+//	      fields:
+//	        pair: pair_address
+//	        amount_usd: swap.amount_usd
+//
+// A store rule maps the deltas of the keys matching its pattern, see
+// `schema.Pattern`, to the entity identified by the `{id}` component, or by
+// its `id` template, like `{pair}-{day}`. A delta of a scalar value updates
+// the entity field named by `field`, a template too, deleting the key leaves
+// the field as it was. A delta of a `type` message creates, updates or
+// deletes the entity, its `fields` being paths in the message.
+//
+// A module rule creates an entity per message of a map output of type `type`,
+// identified by its record ID, see `sink.EntityID`, `when` limiting it to the
+// messages where that path is set, like a `oneof` case.
+package entity
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	pbdatabase "github.com/streamingfast/substream-pancakeswap/pb/pcs/database/v1"
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+	"gopkg.in/yaml.v3"
+)
+
+// Output is the name of the map output holding the entity changes of a block,
+// see `Mapping.Apply`.
+const Output = "entity_changes"
+
+type Mapping struct {
+	stores  map[string][]*Rule
+	modules map[string][]*Rule
+}
+
+type Rule struct {
+	Entity string
+	Store  string
+	Module string
+	Key    *schema.Pattern
+	ID     string
+	Field  string
+	Type   protoreflect.MessageType
+	Fields map[string]string
+	When   string
+}
+
+type file struct {
+	Entities map[string][]struct {
+		Store  string            `yaml:"store"`
+		Module string            `yaml:"module"`
+		Key    string            `yaml:"key"`
+		ID     string            `yaml:"id"`
+		Field  string            `yaml:"field"`
+		Type   string            `yaml:"type"`
+		Fields map[string]string `yaml:"fields"`
+		When   string            `yaml:"when"`
+	} `yaml:"entities"`
+}
+
+func Load(path string) (*Mapping, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read entity mapping %q: %w", path, err)
+	}
+
+	m, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("entity mapping %q: %w", path, err)
+	}
+	return m, nil
+}
+
+func Parse(content []byte) (*Mapping, error) {
+	var in file
+	if err := yaml.Unmarshal(content, &in); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	m := &Mapping{stores: map[string][]*Rule{}, modules: map[string][]*Rule{}}
+	entities := make([]string, 0, len(in.Entities))
+	for entity := range in.Entities {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+
+	for _, entity := range entities {
+		for i, declared := range in.Entities[entity] {
+			rule := &Rule{Entity: entity, Store: declared.Store, Module: declared.Module, ID: declared.ID, Field: declared.Field, Fields: declared.Fields, When: declared.When}
+			if declared.Key != "" {
+				pattern, err := schema.ParsePattern(declared.Key)
+				if err != nil {
+					return nil, fmt.Errorf("entity %q, rule %d: %w", entity, i+1, err)
+				}
+				rule.Key = pattern
+			}
+			if declared.Type != "" {
+				msgType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(declared.Type))
+				if err != nil {
+					return nil, fmt.Errorf("entity %q, rule %d: message type %q: %w", entity, i+1, declared.Type, err)
+				}
+				rule.Type = msgType
+			}
+			if err := rule.check(); err != nil {
+				return nil, fmt.Errorf("entity %q, rule %d: %w", entity, i+1, err)
+			}
+
+			if rule.Store != "" {
+				m.stores[rule.Store] = append(m.stores[rule.Store], rule)
+			} else {
+				m.modules[rule.Module] = append(m.modules[rule.Module], rule)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (r *Rule) check() error {
+	if (r.Store == "") == (r.Module == "") {
+		return fmt.Errorf("exactly one of store and module is expected")
+	}
+
+	if r.Module != "" {
+		if r.Type == nil || len(r.Fields) == 0 {
+			return fmt.Errorf("module rules map the fields of a message, type and fields are expected")
+		}
+		if r.Key != nil || r.ID != "" || r.Field != "" {
+			return fmt.Errorf("key, id and field are only expected in store rules")
+		}
+		if r.When != "" {
+			if _, err := fieldPath(r.Type.Descriptor(), r.When); err != nil {
+				return fmt.Errorf("when: %w", err)
+			}
+		}
+		return r.checkFields()
+	}
+
+	if r.Key == nil {
+		return fmt.Errorf("store rules map keys, key is expected")
+	}
+	if r.When != "" {
+		return fmt.Errorf("when is only expected in module rules")
+	}
+	if r.ID == "" {
+		r.ID = "{id}"
+	}
+	if err := checkTemplate(r.ID, r.Key); err != nil {
+		return fmt.Errorf("id: %w", err)
+	}
+
+	if r.Type != nil {
+		if r.Field != "" || len(r.Fields) == 0 {
+			return fmt.Errorf("rules of message values map fields, not a field")
+		}
+		return r.checkFields()
+	}
+	if r.Field == "" || len(r.Fields) > 0 {
+		return fmt.Errorf("rules of scalar values map a field, not fields")
+	}
+	return checkTemplate(r.Field, r.Key)
+}
+
+func (r *Rule) checkFields() error {
+	for field, path := range r.Fields {
+		if _, err := fieldPath(r.Type.Descriptor(), path); err != nil {
+			return fmt.Errorf("field %q: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// checkTemplate verifies that the components of `template` are components of
+// `key`.
+func checkTemplate(template string, key *schema.Pattern) error {
+	for _, component := range templateComponents(template) {
+		if key.Position(component) == 0 {
+			return fmt.Errorf("%q uses %q, not a component of key %q", template, component, key.Raw)
+		}
+	}
+	return nil
+}
+
+func templateComponents(template string) (out []string) {
+	for {
+		start := strings.Index(template, "{")
+		if start < 0 {
+			return out
+		}
+		end := strings.Index(template[start:], "}")
+		if end < 0 {
+			return out
+		}
+		out = append(out, template[start+1:start+end])
+		template = template[start+end+1:]
+	}
+}
+
+func expand(template string, components map[string]string) string {
+	for name, value := range components {
+		template = strings.ReplaceAll(template, "{"+name+"}", value)
+	}
+	return template
+}
+
+// Modules returns the stores and map modules the rules map the outputs of,
+// sorted.
+func (m *Mapping) Modules() (out []string) {
+	for store := range m.stores {
+		out = append(out, store)
+	}
+	for module := range m.modules {
+		out = append(out, module)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Map returns the entity changes of the outputs of a block, in the order of
+// the ordinals of the deltas. Deltas and messages matching no rule are
+// skipped.
+func (m *Mapping) Map(data *pbsubstreams.BlockScopedData) (*pbdatabase.DatabaseChanges, error) {
+	records, err := sink.Records(data)
+	if err != nil {
+		return nil, fmt.Errorf("records: %w", err)
+	}
+
+	changes := &pbdatabase.DatabaseChanges{}
+	for _, record := range records {
+		var change *pbdatabase.TableChange
+		if delta, ok := record.Entity.(*pbsubstreams.StoreDelta); ok {
+			change, err = m.mapDelta(record.Module, delta)
+		} else {
+			change, err = m.mapMessage(record)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("module %q at block %d: %w", record.Module, record.BlockNum, err)
+		}
+		if change == nil {
+			continue
+		}
+		change.BlockNum = record.BlockNum
+		changes.TableChanges = append(changes.TableChanges, change)
+	}
+
+	sort.SliceStable(changes.TableChanges, func(i, j int) bool {
+		return changes.TableChanges[i].Ordinal < changes.TableChanges[j].Ordinal
+	})
+	return changes, nil
+}
+
+func (m *Mapping) mapDelta(store string, delta *pbsubstreams.StoreDelta) (*pbdatabase.TableChange, error) {
+	for _, rule := range m.stores[store] {
+		components, ok := rule.Key.Match(delta.Key)
+		if !ok {
+			continue
+		}
+
+		change := &pbdatabase.TableChange{
+			Table:     rule.Entity,
+			Pk:        expand(rule.ID, components),
+			Ordinal:   delta.Ordinal,
+			Operation: pbdatabase.TableChange_UPDATE,
+		}
+
+		if rule.Type == nil {
+			if delta.Operation == pbsubstreams.StoreDelta_DELETE {
+				return nil, nil
+			}
+			change.Fields = []*pbdatabase.Field{{Name: expand(rule.Field, components), NewValue: scalar(delta.NewValue), OldValue: scalar(delta.OldValue)}}
+			return change, nil
+		}
+
+		switch delta.Operation {
+		case pbsubstreams.StoreDelta_CREATE:
+			change.Operation = pbdatabase.TableChange_CREATE
+		case pbsubstreams.StoreDelta_DELETE:
+			change.Operation = pbdatabase.TableChange_DELETE
+		}
+
+		newMsg, err := rule.decode(delta.NewValue)
+		if err != nil {
+			return nil, fmt.Errorf("new value of key %q: %w", delta.Key, err)
+		}
+		oldMsg, err := rule.decode(delta.OldValue)
+		if err != nil {
+			return nil, fmt.Errorf("old value of key %q: %w", delta.Key, err)
+		}
+		if delta.Operation == pbsubstreams.StoreDelta_DELETE {
+			newMsg = nil
+		}
+
+		if change.Fields, err = rule.fields(newMsg, oldMsg); err != nil {
+			return nil, fmt.Errorf("key %q: %w", delta.Key, err)
+		}
+		return change, nil
+	}
+	return nil, nil
+}
+
+func (m *Mapping) mapMessage(record *sink.Record) (*pbdatabase.TableChange, error) {
+	for _, rule := range m.modules[record.Module] {
+		msg := record.Entity.ProtoReflect()
+		if msg.Descriptor().FullName() != rule.Type.Descriptor().FullName() {
+			continue
+		}
+		if rule.When != "" {
+			if _, set := get(msg, rule.When); !set {
+				continue
+			}
+		}
+
+		fields, err := rule.fields(record.Entity, nil)
+		if err != nil {
+			return nil, fmt.Errorf("record %q: %w", record.ID, err)
+		}
+		return &pbdatabase.TableChange{
+			Table:     rule.Entity,
+			Pk:        record.ID,
+			Operation: pbdatabase.TableChange_CREATE,
+			Fields:    fields,
+		}, nil
+	}
+	return nil, nil
+}
+
+func (r *Rule) decode(value []byte) (proto.Message, error) {
+	if value == nil {
+		return nil, nil
+	}
+	msg := r.Type.New().Interface()
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", r.Type.Descriptor().FullName(), err)
+	}
+	return msg, nil
+}
+
+// fields returns the fields of the entity out of the new and old message,
+// either can be nil. They are sorted by name.
+func (r *Rule) fields(newMsg, oldMsg proto.Message) ([]*pbdatabase.Field, error) {
+	names := make([]string, 0, len(r.Fields))
+	for name := range r.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]*pbdatabase.Field, len(names))
+	for i, name := range names {
+		field := &pbdatabase.Field{Name: name}
+		var err error
+		if newMsg != nil {
+			if field.NewValue, err = format(newMsg.ProtoReflect(), r.Fields[name]); err != nil {
+				return nil, err
+			}
+		}
+		if oldMsg != nil {
+			if field.OldValue, err = format(oldMsg.ProtoReflect(), r.Fields[name]); err != nil {
+				return nil, err
+			}
+		}
+		out[i] = field
+	}
+	return out, nil
+}
+
+// fieldPath resolves a `.` separated path of fields, like `swap.amount_usd`,
+// to their descriptors.
+func fieldPath(desc protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	var out []protoreflect.FieldDescriptor
+	for i, name := range strings.Split(path, ".") {
+		if desc == nil {
+			return nil, fmt.Errorf("%q: %q is not a message", path, strings.Join(strings.Split(path, ".")[:i], "."))
+		}
+		field := desc.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return nil, fmt.Errorf("%q: no field %q in %s", path, name, desc.FullName())
+		}
+		out = append(out, field)
+		desc = field.Message()
+	}
+	return out, nil
+}
+
+// get returns the value at `path` of `msg`, and whether every field of the
+// path is set.
+func get(msg protoreflect.Message, path string) (protoreflect.Value, bool) {
+	fields, _ := fieldPath(msg.Descriptor(), path)
+	var value protoreflect.Value
+	for i, field := range fields {
+		if !msg.Has(field) {
+			return field.Default(), false
+		}
+		value = msg.Get(field)
+		if i < len(fields)-1 {
+			msg = value.Message()
+		}
+	}
+	return value, true
+}
+
+func format(msg protoreflect.Message, path string) (string, error) {
+	fields, err := fieldPath(msg.Descriptor(), path)
+	if err != nil {
+		return "", err
+	}
+	value, set := get(msg, path)
+	if !set {
+		// the zero value, like protobuf readers see it
+		value = fields[len(fields)-1].Default()
+	}
+
+	field := fields[len(fields)-1]
+	switch {
+	case field.IsList() || field.IsMap():
+		return "", fmt.Errorf("%q: repeated fields are not supported", path)
+	case field.Kind() == protoreflect.BytesKind:
+		return "0x" + hex.EncodeToString(value.Bytes()), nil
+	case field.Kind() == protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name()), nil
+		}
+		return fmt.Sprint(value.Enum()), nil
+	case field.Message() != nil:
+		if !set {
+			return "", nil
+		}
+		content, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(value.Message().Interface())
+		return string(content), err
+	}
+	return value.String(), nil
+}
+
+// scalar renders a scalar store value, the values that aren't text are
+// rendered in hex.
+func scalar(in []byte) string {
+	if utf8.Valid(in) {
+		return string(in)
+	}
+	return "0x" + hex.EncodeToString(in)
+}
+
+// Apply adds the entity changes of the block, when there are some, as the
+// output of an `entity_changes` map module to the ones of the block, for the
+// outputs to write them along with the deltas they come from.
+func (m *Mapping) Apply(data *pbsubstreams.BlockScopedData) (*pbsubstreams.BlockScopedData, error) {
+	changes, err := m.Map(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes.TableChanges) == 0 {
+		return data, nil
+	}
+
+	mapOutput, err := anypb.New(changes)
+	if err != nil {
+		return nil, fmt.Errorf("encoding entity changes: %w", err)
+	}
+
+	out := proto.Clone(data).(*pbsubstreams.BlockScopedData)
+	out.Outputs = append(out.Outputs, &pbsubstreams.ModuleOutput{
+		Name: Output,
+		Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: mapOutput},
+	})
+	return out, nil
+}
@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"testing"
+
+	pbdatabase "github.com/streamingfast/substream-pancakeswap/pb/pcs/database/v1"
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const mapping = `
+entities:
+  pair:
+    - store: store_pairs
+      key: pair:{id}
+      type: pcs.types.v1.Pair
+      fields:
+        token_0: token0_address
+        block: block_num
+    - store: store_volumes
+      key: pair:{id}:{volume=usd|token0}
+      field: volume_{volume}
+  pair_day_data:
+    - store: store_volumes
+      key: pair_day:{day}:{pair}:usd
+      id: "{pair}-{day}"
+      field: daily_volume_usd
+  swap:
+    - module: map_burn_swaps_events
+      type: pcs.types.v1.Event
+      when: swap
+      fields:
+        pair: pair_address
+        amount_usd: swap.amount_usd
+`
+
+func mustMarshal(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+	content, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return content
+}
+
+func blockData(t *testing.T) *pbsubstreams.BlockScopedData {
+	events, err := anypb.New(&pbpcs.Events{Events: []*pbpcs.Event{
+		{Id: "bsc:abc:0x01:4", PairAddress: "0xaa", Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: "12.5"}}},
+		{Id: "bsc:abc:0x01:5", PairAddress: "0xaa", Type: &pbpcs.Event_Mint{Mint: &pbpcs.Mint{}}},
+	}})
+	require.NoError(t, err)
+
+	return &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Id: "abc", Number: 10},
+		Step:  pbsubstreams.ForkStep_STEP_NEW,
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_volumes", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+				{Operation: pbsubstreams.StoreDelta_UPDATE, Ordinal: 7, Key: "pair:0xaa:usd", OldValue: []byte("1"), NewValue: []byte("2")},
+				{Operation: pbsubstreams.StoreDelta_CREATE, Ordinal: 8, Key: "pair_day:19000:0xaa:usd", NewValue: []byte("2")},
+				{Operation: pbsubstreams.StoreDelta_DELETE, Ordinal: 9, Key: "pair:0xaa:token0", OldValue: []byte("3")},
+				{Operation: pbsubstreams.StoreDelta_UPDATE, Ordinal: 10, Key: "global:usd", NewValue: []byte("5")},
+			}}}},
+			{Name: "store_pairs", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+				{Operation: pbsubstreams.StoreDelta_CREATE, Ordinal: 3, Key: "pair:0xaa", NewValue: mustMarshal(t, &pbpcs.Pair{Address: "0xaa", Token0Address: "0x01", BlockNum: 10})},
+			}}}},
+			{Name: "map_burn_swaps_events", Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: events}},
+		},
+	}
+}
+
+func TestMapping_Map(t *testing.T) {
+	m, err := Parse([]byte(mapping))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"map_burn_swaps_events", "store_pairs", "store_volumes"}, m.Modules())
+
+	changes, err := m.Map(blockData(t))
+	require.NoError(t, err)
+
+	expected := []*pbdatabase.TableChange{
+		{Table: "swap", Pk: "bsc:abc:0x01:4", BlockNum: 10, Operation: pbdatabase.TableChange_CREATE, Fields: []*pbdatabase.Field{
+			{Name: "amount_usd", NewValue: "12.5"},
+			{Name: "pair", NewValue: "0xaa"},
+		}},
+		{Table: "pair", Pk: "0xaa", BlockNum: 10, Ordinal: 3, Operation: pbdatabase.TableChange_CREATE, Fields: []*pbdatabase.Field{
+			{Name: "block", NewValue: "10"},
+			{Name: "token_0", NewValue: "0x01"},
+		}},
+		{Table: "pair", Pk: "0xaa", BlockNum: 10, Ordinal: 7, Operation: pbdatabase.TableChange_UPDATE, Fields: []*pbdatabase.Field{
+			{Name: "volume_usd", NewValue: "2", OldValue: "1"},
+		}},
+		{Table: "pair_day_data", Pk: "0xaa-19000", BlockNum: 10, Ordinal: 8, Operation: pbdatabase.TableChange_UPDATE, Fields: []*pbdatabase.Field{
+			{Name: "daily_volume_usd", NewValue: "2"},
+		}},
+	}
+	require.Len(t, changes.TableChanges, len(expected))
+	for i, change := range changes.TableChanges {
+		assert.True(t, proto.Equal(expected[i], change), "change %d: %s", i, change)
+	}
+}
+
+func TestMapping_Apply(t *testing.T) {
+	m, err := Parse([]byte(mapping))
+	require.NoError(t, err)
+
+	out, err := m.Apply(blockData(t))
+	require.NoError(t, err)
+
+	records, err := sink.Records(out)
+	require.NoError(t, err)
+	var changes []string
+	for _, record := range records {
+		if record.Module == Output {
+			changes = append(changes, record.Entity.(*pbdatabase.TableChange).Table)
+		}
+	}
+	assert.Equal(t, []string{"swap", "pair", "pair", "pair_day_data"}, changes)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+	}{
+		{"store and module", "entities: {pair: [{store: store_pairs, module: map_pairs, key: 'pair:{id}', field: a}]}"},
+		{"no key", "entities: {pair: [{store: store_pairs, field: a}]}"},
+		{"unknown component", "entities: {pair: [{store: store_volumes, key: 'pair:{id}', field: 'volume_{volume}'}]}"},
+		{"unknown type", "entities: {pair: [{store: store_pairs, key: 'pair:{id}', type: pcs.types.v1.Unknown, fields: {a: b}}]}"},
+		{"unknown field", "entities: {pair: [{store: store_pairs, key: 'pair:{id}', type: pcs.types.v1.Pair, fields: {a: unknown}}]}"},
+		{"unknown when", "entities: {swap: [{module: map_burn_swaps_events, type: pcs.types.v1.Event, when: trade, fields: {pair: pair_address}}]}"},
+		{"scalar fields", "entities: {pair: [{store: store_volumes, key: 'pair:{id}', fields: {a: b}}]}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.mapping))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoad_PancakeSwap(t *testing.T) {
+	m, err := Load("../../../modules/pancakeswap/entities.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"map_burn_swaps_events", "store_pairs", "store_reserves", "store_totals", "store_volumes"}, m.Modules())
+}
@@ -0,0 +1,259 @@
+// Package ethrpc is the JSON-RPC backend of the modules' `eth_call`s, sending
+// each batch of calls as a JSON-RPC batch against the block they are pinned
+// to, or split in concurrent batches backing off when the node throttles
+// them.
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"go.uber.org/zap"
+)
+
+// Client is a `sdk.RPC` calling the JSON-RPC node at `url`.
+type Client struct {
+	url    string
+	client *http.Client
+
+	concurrency *Concurrency
+	limiter     *Limiter
+}
+
+// Concurrency spreads the calls of a batch over concurrent requests, like the
+// metadata lookups of the tokens of the many pairs created in a block, instead
+// of a single request the node answers as a whole.
+type Concurrency struct {
+	// BatchSize is the number of calls per request.
+	BatchSize int
+	// MinRequests and MaxRequests bound the requests in flight, adapted to
+	// the throttling of the node, see `Limiter`.
+	MinRequests int
+	MaxRequests int
+	// Timeout is how long a request is waited on, without limit when 0.
+	Timeout time.Duration
+	// MaxRetries is how many times a request throttled, rate limited or
+	// timing out, is sent again, after a backoff doubling from `Backoff`.
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+// NewClient returns a client sending its requests through `client`,
+// `http.DefaultClient` when nil.
+func NewClient(url string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{url: url, client: client}
+}
+
+// SetConcurrency sends the batches of calls as concurrent requests, the
+// requests in flight of all the batches being bounded together.
+func (c *Client) SetConcurrency(config Concurrency) error {
+	if config.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", config.BatchSize)
+	}
+	limiter, err := NewLimiter(config.MinRequests, config.MaxRequests)
+	if err != nil {
+		return err
+	}
+
+	c.concurrency = &config
+	c.limiter = limiter
+	return nil
+}
+
+type request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type callParams struct {
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+type response struct {
+	ID     int    `json:"id"`
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Call sends the calls in a single batch, or in concurrent batches of
+// `Concurrency.BatchSize` calls once set. Calls are made against their
+// `BlockNum`, or the head of the chain when `Latest` is set. Reverted calls
+// get their `CallError` set, any other failure fails the whole batch.
+func (c *Client) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	if c.limiter == nil {
+		return c.send(context.Background(), calls)
+	}
+
+	out := make([]*sdk.RPCResponse, len(calls))
+	errs := make(chan error, len(calls)/c.concurrency.BatchSize+1)
+	var wg sync.WaitGroup
+	for start := 0; start < len(calls); start += c.concurrency.BatchSize {
+		end := start + c.concurrency.BatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			responses, err := c.sendLimited(calls[start:end])
+			if err != nil {
+				errs <- err
+				return
+			}
+			copy(out[start:end], responses)
+		}(start, end)
+	}
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// sendLimited sends `calls` once the limiter allows it, again when throttled.
+func (c *Client) sendLimited(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	backoff := c.concurrency.Backoff
+	for attempt := 0; ; attempt++ {
+		permit, err := c.limiter.Acquire(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if c.concurrency.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, c.concurrency.Timeout)
+		}
+		responses, err := c.send(ctx, calls)
+		cancel()
+
+		isThrottled := throttled(err)
+		c.limiter.Release(permit, isThrottled)
+		if !isThrottled || attempt >= c.concurrency.MaxRetries {
+			return responses, err
+		}
+
+		zlog.Debug("eth_call request throttled, retrying", zap.Int("calls", len(calls)), zap.Int("attempt", attempt+1), zap.Duration("backoff", backoff), zap.Error(err))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// errThrottled is a request rate limited by the node.
+var errThrottled = errors.New("rate limited by the node")
+
+func throttled(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, errThrottled) || errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+func (c *Client) send(ctx context.Context, calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	requests := make([]request, len(calls))
+	for i, call := range calls {
+		block := fmt.Sprintf("0x%x", call.BlockNum)
+		if call.Latest {
+			block = "latest"
+		}
+		requests[i] = request{
+			JSONRPC: "2.0",
+			ID:      i,
+			Method:  "eth_call",
+			Params:  []interface{}{callParams{To: call.ToAddr, Data: "0x" + hex.EncodeToString(call.Data)}, block},
+		}
+	}
+
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("marshal eth_call batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("eth_call: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("eth_call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("eth_call: %s: %w", resp.Status, errThrottled)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("eth_call: %s", resp.Status)
+	}
+
+	var responses []response
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("eth_call: decode response: %w", err)
+	}
+
+	out := make([]*sdk.RPCResponse, len(calls))
+	for _, r := range responses {
+		if r.ID < 0 || r.ID >= len(calls) || out[r.ID] != nil {
+			return nil, fmt.Errorf("eth_call: unexpected response id %d", r.ID)
+		}
+
+		if r.Error != nil {
+			if !reverted(r.Error.Code, r.Error.Message) {
+				return nil, fmt.Errorf("eth_call to %s: %s (%d)", calls[r.ID].ToAddr, r.Error.Message, r.Error.Code)
+			}
+			out[r.ID] = &sdk.RPCResponse{CallError: errors.New(r.Error.Message)}
+			continue
+		}
+
+		raw, err := hex.DecodeString(strings.TrimPrefix(r.Result, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("eth_call to %s: invalid result %q: %w", calls[r.ID].ToAddr, r.Result, err)
+		}
+		out[r.ID] = &sdk.RPCResponse{Raw: raw}
+	}
+
+	for i, r := range out {
+		if r == nil {
+			return nil, fmt.Errorf("eth_call: no response for call %d to %s", i, calls[i].ToAddr)
+		}
+	}
+	return out, nil
+}
+
+// reverted tells if an error is the execution of the call failing, which is
+// deterministic, rather than the node failing to answer. Nodes report reverts
+// with code 3, or -32000 and a message about the execution.
+func reverted(code int, message string) bool {
+	if code == 3 {
+		return true
+	}
+	message = strings.ToLower(message)
+	return code == -32000 && (strings.Contains(message, "revert") || strings.Contains(message, "invalid opcode") || strings.Contains(message, "out of gas"))
+}
@@ -0,0 +1,183 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// node answers with the block tag of each call, reverting the calls to 0xdead
+// and failing the calls to 0xfail. Responses come in reverse order.
+func node(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requests))
+
+		var out []string
+		for i := len(requests) - 1; i >= 0; i-- {
+			req := requests[i]
+			assert.Equal(t, "eth_call", req.Method)
+
+			var call callParams
+			var block string
+			require.NoError(t, json.Unmarshal(req.Params[0], &call))
+			require.NoError(t, json.Unmarshal(req.Params[1], &block))
+
+			switch call.To {
+			case "0xdead":
+				out = append(out, fmt.Sprintf(`{"id":%d,"error":{"code":3,"message":"execution reverted"}}`, req.ID))
+			case "0xfail":
+				out = append(out, fmt.Sprintf(`{"id":%d,"error":{"code":-32000,"message":"header not found"}}`, req.ID))
+			default:
+				out = append(out, fmt.Sprintf(`{"id":%d,"result":"0x%x"}`, req.ID, block))
+			}
+		}
+		fmt.Fprintf(w, "[")
+		for i, response := range out {
+			if i > 0 {
+				fmt.Fprintf(w, ",")
+			}
+			fmt.Fprint(w, response)
+		}
+		fmt.Fprintf(w, "]")
+	}))
+}
+
+func TestClient_Call(t *testing.T) {
+	server := node(t)
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	responses, err := client.Call([]*sdk.RPCCall{
+		{ToAddr: "0xab", Data: []byte{0x31, 0x3c}, BlockNum: 255},
+		{ToAddr: "0xab", Data: []byte{0x31, 0x3c}, Latest: true},
+		{ToAddr: "0xdead", BlockNum: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, responses, 3)
+	assert.Equal(t, "0xff", string(responses[0].Raw))
+	assert.Equal(t, "latest", string(responses[1].Raw))
+	assert.EqualError(t, responses[2].CallError, "execution reverted")
+
+	_, err = client.Call([]*sdk.RPCCall{{ToAddr: "0xab", BlockNum: 1}, {ToAddr: "0xfail", BlockNum: 1}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "eth_call to 0xfail: header not found (-32000)")
+
+	responses, err = client.Call(nil)
+	require.NoError(t, err)
+	assert.Empty(t, responses)
+}
+
+func TestClient_Call_Concurrency(t *testing.T) {
+	backend := node(t)
+	defer backend.Close()
+
+	var inflight, maxInflight, requests int32
+	var throttle int32 = 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		current := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInflight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInflight, observed, current) {
+				break
+			}
+		}
+
+		if atomic.AddInt32(&throttle, -1) >= 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+		backend.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, nil)
+	require.NoError(t, client.SetConcurrency(Concurrency{BatchSize: 2, MinRequests: 1, MaxRequests: 3, MaxRetries: 2, Backoff: time.Millisecond}))
+
+	var calls []*sdk.RPCCall
+	for i := 1; i <= 15; i++ {
+		calls = append(calls, &sdk.RPCCall{ToAddr: "0xab", BlockNum: uint64(i)})
+	}
+	responses, err := client.Call(calls)
+	require.NoError(t, err)
+	require.Len(t, responses, 15)
+	for i, response := range responses {
+		assert.Equal(t, fmt.Sprintf("0x%x", i+1), string(response.Raw))
+	}
+	assert.Equal(t, int32(10), atomic.LoadInt32(&requests), "8 batches and 2 retries")
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInflight), int32(3))
+
+	// retries exhausted
+	atomic.StoreInt32(&throttle, 100)
+	_, err = client.Call(calls[:1])
+	assert.ErrorIs(t, err, errThrottled)
+}
+
+func TestLimiter(t *testing.T) {
+	_, err := NewLimiter(0, 4)
+	assert.EqualError(t, err, "invalid concurrency bounds [0, 4], expected 0 < min <= max")
+
+	l, err := NewLimiter(1, 4)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var permits []Permit
+	for i := 0; i < 4; i++ {
+		permit, err := l.Acquire(ctx)
+		require.NoError(t, err)
+		permits = append(permits, permit)
+	}
+
+	// the limit is reached
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(timeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the requests sent along the first throttled one don't halve the limit
+	// again
+	l.Release(permits[0], true)
+	l.Release(permits[1], true)
+	assert.Equal(t, 2, l.Limit())
+
+	// a request sent after the decrease does
+	l.Release(permits[2], false)
+	permit, err := l.Acquire(ctx)
+	require.NoError(t, err)
+	l.Release(permit, true)
+	assert.Equal(t, 1, l.Limit())
+	l.Release(permits[3], false)
+	assert.Equal(t, 2, l.Limit())
+
+	// never past the bounds
+	for i := 0; i < 20; i++ {
+		permit, err := l.Acquire(ctx)
+		require.NoError(t, err)
+		l.Release(permit, false)
+	}
+	assert.Equal(t, 4, l.Limit())
+}
+
+func TestThrottled(t *testing.T) {
+	assert.False(t, throttled(nil))
+	assert.True(t, throttled(fmt.Errorf("eth_call: %w", errThrottled)))
+	assert.True(t, throttled(fmt.Errorf("eth_call: %w", context.DeadlineExceeded)))
+	assert.False(t, throttled(errors.New("eth_call: 500 Internal Server Error")))
+}
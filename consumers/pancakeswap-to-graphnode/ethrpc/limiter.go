@@ -0,0 +1,101 @@
+package ethrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Limiter bounds the requests in flight to a node, adapting the bound to what
+// the node sustains: every request answered raises it by one over a round of
+// requests, a request throttled, rate limited or timing out, halves it. Only
+// the first throttled request of a round halves the bound, the requests sent
+// before it was halved don't halve it again.
+type Limiter struct {
+	min, max int
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	// epoch counts the decreases, a permit of an older epoch doesn't
+	// decrease the limit
+	epoch uint64
+	// wake is closed when a permit is released
+	wake chan struct{}
+}
+
+// Permit is a request allowed in flight by a `Limiter`, to be released once
+// answered.
+type Permit struct {
+	epoch uint64
+}
+
+// NewLimiter starts at `max` requests in flight, never going below `min`.
+func NewLimiter(min, max int) (*Limiter, error) {
+	if min <= 0 || max < min {
+		return nil, fmt.Errorf("invalid concurrency bounds [%d, %d], expected 0 < min <= max", min, max)
+	}
+
+	l := &Limiter{min: min, max: max, limit: float64(max), wake: make(chan struct{})}
+	concurrencyLimit.SetFloat64(l.limit)
+	return l, nil
+}
+
+// Acquire waits for a request to be allowed in flight.
+func (l *Limiter) Acquire(ctx context.Context) (Permit, error) {
+	for {
+		l.mu.Lock()
+		if l.inflight < int(l.limit) {
+			l.inflight++
+			requestsInFlight.SetUint64(uint64(l.inflight))
+			permit := Permit{epoch: l.epoch}
+			l.mu.Unlock()
+			return permit, nil
+		}
+		wake := l.wake
+		l.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return Permit{}, ctx.Err()
+		}
+	}
+}
+
+// Release ends the request of `permit`, `throttled` when the node rate
+// limited it or didn't answer in time.
+func (l *Limiter) Release(permit Permit, throttled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight--
+	requestsInFlight.SetUint64(uint64(l.inflight))
+	switch {
+	case throttled && permit.epoch == l.epoch:
+		l.limit /= 2
+		if l.limit < float64(l.min) {
+			l.limit = float64(l.min)
+		}
+		l.epoch++
+		throttledRequests.Inc()
+	case throttled:
+		throttledRequests.Inc()
+	default:
+		l.limit += 1 / l.limit
+		if l.limit > float64(l.max) {
+			l.limit = float64(l.max)
+		}
+	}
+	concurrencyLimit.SetFloat64(l.limit)
+
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// Limit is the number of requests currently allowed in flight.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
@@ -0,0 +1,7 @@
+package ethrpc
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.ethrpc", "github.com/streamingfast/substream-pancakeswap/ethrpc")
@@ -0,0 +1,17 @@
+package ethrpc
+
+import (
+	"github.com/streamingfast/dmetrics"
+)
+
+var metrics = dmetrics.NewSet()
+
+var (
+	concurrencyLimit  = metrics.NewGauge("eth_call_concurrency_limit", "eth_call requests allowed in flight to the node, adapted to its throttling")
+	requestsInFlight  = metrics.NewGauge("eth_call_requests_in_flight", "eth_call requests waiting on the node")
+	throttledRequests = metrics.NewCounter("eth_call_throttled_requests", "eth_call requests rate limited by the node or timing out, retried")
+)
+
+func init() {
+	metrics.Register()
+}
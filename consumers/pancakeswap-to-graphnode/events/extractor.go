@@ -0,0 +1,220 @@
+package events
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	eth "github.com/streamingfast/eth-go"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Extractor decodes the logs of a block matching the events declared in an ABI
+// into dynamic `Event` structures. It's the generic counterpart of the hand
+// written extractors: give it an ABI JSON and the contract addresses to watch,
+// and it emits every matching event without any custom decoding code.
+type Extractor struct {
+	definitions map[string]*definition
+	addresses   map[string]bool
+}
+
+type definition struct {
+	*eth.LogEventDef
+	signature string
+}
+
+// Event is a single decoded log. `Params` holds the decoded parameters keyed by
+// their ABI name, with values already converted to JSON friendly types. Hashes
+// and addresses are all written `0x` prefixed.
+type Event struct {
+	BlockNum      uint64                 `json:"block_num"`
+	BlockID       string                 `json:"block_id"`
+	TransactionID string                 `json:"transaction_id"`
+	LogIndex      uint32                 `json:"log_index"`
+	Ordinal       uint64                 `json:"ordinal"`
+	Address       string                 `json:"address"`
+	Name          string                 `json:"name"`
+	Signature     string                 `json:"signature"`
+	Params        map[string]interface{} `json:"params"`
+}
+
+// NewExtractor creates an extractor for the events found in `abi`. When no
+// addresses are provided, logs of all contracts are matched against the ABI.
+func NewExtractor(abi *eth.ABI, addresses ...string) (*Extractor, error) {
+	e := &Extractor{
+		definitions: map[string]*definition{},
+		addresses:   map[string]bool{},
+	}
+
+	for _, def := range abi.LogEventsByNameMap {
+		signature := eventSignature(def)
+		// We compute the topic ourself, `LogEventDef.Signature()` includes spaces between
+		// types which does not hash to the canonical topic.
+		e.definitions[string(eth.Keccak256([]byte(signature)))] = &definition{LogEventDef: def, signature: signature}
+	}
+
+	if len(e.definitions) == 0 {
+		return nil, fmt.Errorf("abi does not declare any event")
+	}
+
+	for _, addr := range addresses {
+		address, err := eth.NewAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		e.addresses[address.Pretty()] = true
+	}
+
+	return e, nil
+}
+
+// Topics returns the topic0 of every event known to the extractor, hex encoded.
+func (e *Extractor) Topics() (out []string) {
+	for topic := range e.definitions {
+		out = append(out, hex.EncodeToString([]byte(topic)))
+	}
+	return
+}
+
+func (e *Extractor) Extract(block *pbeth.Block) (out []*Event) {
+	for _, trx := range block.TransactionTraces {
+		if trx.Status != pbeth.TransactionTraceStatus_SUCCEEDED || trx.Receipt == nil {
+			continue
+		}
+
+		for _, log := range trx.Receipt.Logs {
+			ev, err := e.decode(log)
+			if err != nil {
+				zlog.Debug("skipping undecodable log",
+					zap.Uint64("block_num", block.Number),
+					zap.String("transaction_id", eth.Hash(trx.Hash).Pretty()),
+					zap.Uint32("log_index", log.Index),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			if ev == nil {
+				continue
+			}
+
+			ev.BlockNum = block.Number
+			ev.BlockID = eth.Hash(block.Hash).Pretty()
+			ev.TransactionID = eth.Hash(trx.Hash).Pretty()
+			out = append(out, ev)
+		}
+	}
+
+	return
+}
+
+func (e *Extractor) decode(log *pbeth.Log) (*Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, nil
+	}
+
+	address := eth.Address(log.Address).Pretty()
+	if len(e.addresses) > 0 && !e.addresses[address] {
+		return nil, nil
+	}
+
+	def, found := e.definitions[string(log.Topics[0])]
+	if !found {
+		return nil, nil
+	}
+
+	decoder := eth.NewLogDecoder(&eth.Log{Address: log.Address, Topics: log.Topics, Data: log.Data})
+	if _, err := decoder.ReadTopic(); err != nil {
+		return nil, fmt.Errorf("reading signature topic: %w", err)
+	}
+
+	params := make(map[string]interface{}, len(def.Parameters))
+	for i, param := range def.Parameters {
+		var value interface{}
+		var err error
+		if param.Indexed {
+			value, err = decoder.ReadTypedTopic(param.TypeName)
+		} else {
+			if decoder.DataDecoder == nil {
+				return nil, fmt.Errorf("event %s has no data to read parameter %q", def.Name, param.GetName(i))
+			}
+			value, err = decoder.ReadData(param.TypeName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading parameter %q of event %s: %w", param.GetName(i), def.Name, err)
+		}
+
+		params[param.GetName(i)] = jsonValue(value)
+	}
+
+	return &Event{
+		LogIndex:  log.Index,
+		Ordinal:   log.Ordinal,
+		Address:   address,
+		Name:      def.Name,
+		Signature: def.signature,
+		Params:    params,
+	}, nil
+}
+
+// Struct returns the event as a `google.protobuf.Struct`, for consumers that
+// need a protobuf payload instead of JSON.
+func (ev *Event) Struct() (*structpb.Struct, error) {
+	return structpb.NewStruct(map[string]interface{}{
+		"block_num":      float64(ev.BlockNum),
+		"block_id":       ev.BlockID,
+		"transaction_id": ev.TransactionID,
+		"log_index":      float64(ev.LogIndex),
+		"ordinal":        float64(ev.Ordinal),
+		"address":        ev.Address,
+		"name":           ev.Name,
+		"signature":      ev.Signature,
+		"params":         ev.Params,
+	})
+}
+
+func eventSignature(def *eth.LogEventDef) string {
+	types := make([]string, len(def.Parameters))
+	for i, param := range def.Parameters {
+		types[i] = param.TypeName
+	}
+	return fmt.Sprintf("%s(%s)", def.Name, strings.Join(types, ","))
+}
+
+// jsonValue converts the values returned by the eth-go decoder to types that
+// serialize nicely, big numbers are kept as decimal strings to avoid precision loss.
+func jsonValue(in interface{}) interface{} {
+	switch v := in.(type) {
+	case eth.Address:
+		return v.Pretty()
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case string, bool:
+		return v
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return new(big.Int).SetUint64(v).String()
+	}
+
+	rv := reflect.ValueOf(in)
+	if rv.Kind() == reflect.Slice {
+		out := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out[i] = jsonValue(rv.Index(i).Interface())
+		}
+		return out
+	}
+
+	return fmt.Sprintf("%v", in)
+}
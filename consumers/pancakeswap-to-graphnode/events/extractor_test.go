@@ -0,0 +1,139 @@
+package events
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	eth "github.com/streamingfast/eth-go"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pairABI = `[
+  {
+    "anonymous": false,
+    "inputs": [
+      {"indexed": true, "internalType": "address", "name": "sender", "type": "address"},
+      {"indexed": false, "internalType": "uint256", "name": "amount0In", "type": "uint256"},
+      {"indexed": false, "internalType": "uint256", "name": "amount1In", "type": "uint256"},
+      {"indexed": false, "internalType": "uint256", "name": "amount0Out", "type": "uint256"},
+      {"indexed": false, "internalType": "uint256", "name": "amount1Out", "type": "uint256"},
+      {"indexed": true, "internalType": "address", "name": "to", "type": "address"}
+    ],
+    "name": "Swap",
+    "type": "event"
+  },
+  {
+    "anonymous": false,
+    "inputs": [
+      {"indexed": false, "internalType": "uint112", "name": "reserve0", "type": "uint112"},
+      {"indexed": false, "internalType": "uint112", "name": "reserve1", "type": "uint112"}
+    ],
+    "name": "Sync",
+    "type": "event"
+  }
+]`
+
+const (
+	pairAddress  = "0x0ed7e52944161450477ee417de9cd3a859b14fd0"
+	otherAddress = "0x58f876857a02d6762e0101bb5c46a8c1ed44dc16"
+	sender       = "0x10ed43c718714eb63d5aa57b78b54704e256024e"
+	recipient    = "0x7ee058420e5937496f5a2096f04caa7721cf70cc"
+)
+
+func TestExtractor_Extract(t *testing.T) {
+	abi, err := eth.ParseABIFromBytes([]byte(pairABI))
+	require.NoError(t, err)
+
+	swap := &pbeth.Log{
+		Address: eth.MustNewAddress(pairAddress),
+		Topics: [][]byte{
+			hexBytes(t, "d78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822"),
+			topicAddress(sender),
+			topicAddress(recipient),
+		},
+		Data:    hexBytes(t, word("0de0b6b3a7640000")+word("00")+word("00")+word("1bc16d674ec80000")),
+		Index:   3,
+		Ordinal: 42,
+	}
+	unknown := &pbeth.Log{
+		Address: eth.MustNewAddress(pairAddress),
+		Topics:  [][]byte{hexBytes(t, strings.Repeat("ab", 32))},
+	}
+
+	tests := []struct {
+		name      string
+		addresses []string
+		logs      []*pbeth.Log
+		expected  []*Event
+	}{
+		{
+			"swap any address",
+			nil,
+			[]*pbeth.Log{unknown, swap},
+			[]*Event{{
+				BlockNum:      10,
+				BlockID:       "0x" + strings.Repeat("01", 32),
+				TransactionID: "0x" + strings.Repeat("02", 32),
+				LogIndex:      3,
+				Ordinal:       42,
+				Address:       pairAddress,
+				Name:          "Swap",
+				Signature:     "Swap(address,uint256,uint256,uint256,uint256,address)",
+				Params: map[string]interface{}{
+					"sender":     sender,
+					"amount0In":  "1000000000000000000",
+					"amount1In":  "0",
+					"amount0Out": "0",
+					"amount1Out": "2000000000000000000",
+					"to":         recipient,
+				},
+			}},
+		},
+		{
+			"swap filtered out by address",
+			[]string{otherAddress},
+			[]*pbeth.Log{swap},
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extractor, err := NewExtractor(abi, test.addresses...)
+			require.NoError(t, err)
+
+			block := &pbeth.Block{
+				Number: 10,
+				Hash:   hexBytes(t, strings.Repeat("01", 32)),
+				TransactionTraces: []*pbeth.TransactionTrace{{
+					Hash:    hexBytes(t, strings.Repeat("02", 32)),
+					Status:  pbeth.TransactionTraceStatus_SUCCEEDED,
+					Receipt: &pbeth.TransactionReceipt{Logs: test.logs},
+				}},
+			}
+
+			events := extractor.Extract(block)
+			assert.Equal(t, test.expected, events)
+			for _, event := range events {
+				assert.True(t, strings.HasPrefix(event.BlockID, "0x") && strings.HasPrefix(event.TransactionID, "0x"), "hashes are written the same way")
+			}
+		})
+	}
+}
+
+func hexBytes(t *testing.T, in string) []byte {
+	out, err := hex.DecodeString(in)
+	require.NoError(t, err)
+	return out
+}
+
+func topicAddress(address string) []byte {
+	return append(make([]byte, 12), eth.MustNewAddress(address)...)
+}
+
+func word(in string) string {
+	return strings.Repeat("0", 64-len(in)) + in
+}
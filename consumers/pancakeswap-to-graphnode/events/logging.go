@@ -0,0 +1,7 @@
+package events
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.events", "github.com/streamingfast/substream-pancakeswap/events")
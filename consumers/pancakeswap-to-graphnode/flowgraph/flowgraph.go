@@ -0,0 +1,213 @@
+// Package flowgraph builds the graph of the token flows recorded by the
+// `store_token_flows` module: tokens are the nodes, an edge goes from the token
+// sold to the token bought, weighted by the volume swapped between them. It's
+// written as DOT or GraphML for network analysis tools.
+package flowgraph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// Filter selects the days summed in the graph, `ToDay` included, and drops the
+// edges below `MinUSD`. Days are unix days, no upper bound when `ToDay` is 0.
+type Filter struct {
+	FromDay int64
+	ToDay   int64
+	MinUSD  float64
+}
+
+// Edge is the volume swapped from token `From` to token `To`, `In` in `From`
+// tokens and `Out` in `To` tokens.
+type Edge struct {
+	From  string
+	To    string
+	USD   *big.Float
+	In    *big.Float
+	Out   *big.Float
+	Swaps uint64
+}
+
+type Graph struct {
+	Tokens []string
+	Edges  []*Edge
+}
+
+// Build sums the `flow_day:<day>:<from>:<to>:<volume>` values of the store, as
+// read by `state.Get`, over the days of `filter`. Edges are sorted by
+// decreasing USD volume.
+func Build(values map[string]string, filter Filter) (*Graph, error) {
+	edges := map[[2]string]*Edge{}
+	for key, value := range values {
+		if !strings.HasPrefix(key, "flow_day:") {
+			continue
+		}
+
+		k, err := sdk.ParseKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if k.Len() != 5 {
+			return nil, fmt.Errorf("invalid flow key %q", key)
+		}
+		unixDay, err := k.Uint(1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flow key: %w", err)
+		}
+		day := int64(unixDay)
+		if day < filter.FromDay || (filter.ToDay != 0 && day > filter.ToDay) {
+			continue
+		}
+
+		amount, ok := new(big.Float).SetString(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %q of flow key %q", value, key)
+		}
+
+		from, to := k.Segment(2), k.Segment(3)
+		id := [2]string{from, to}
+		edge, found := edges[id]
+		if !found {
+			edge = &Edge{From: from, To: to, USD: new(big.Float), In: new(big.Float), Out: new(big.Float)}
+			edges[id] = edge
+		}
+
+		switch k.Segment(4) {
+		case "usd":
+			edge.USD.Add(edge.USD, amount)
+		case "in":
+			edge.In.Add(edge.In, amount)
+		case "out":
+			edge.Out.Add(edge.Out, amount)
+		case "swaps":
+			swaps, _ := amount.Uint64()
+			edge.Swaps += swaps
+		default:
+			return nil, fmt.Errorf("invalid flow key %q: unknown volume %q", key, k.Segment(4))
+		}
+	}
+
+	g := &Graph{}
+	tokens := map[string]bool{}
+	for _, edge := range edges {
+		if usd, _ := edge.USD.Float64(); usd < filter.MinUSD {
+			continue
+		}
+		g.Edges = append(g.Edges, edge)
+		tokens[edge.From], tokens[edge.To] = true, true
+	}
+	for token := range tokens {
+		g.Tokens = append(g.Tokens, token)
+	}
+	sort.Strings(g.Tokens)
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if cmp := g.Edges[i].USD.Cmp(g.Edges[j].USD); cmp != 0 {
+			return cmp > 0
+		}
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g, nil
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language, edges are labelled
+// with their USD volume.
+func WriteDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph token_flows {\n")
+	for _, token := range g.Tokens {
+		fmt.Fprintf(&b, "  %q;\n", token)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, usd=%q, in=%q, out=%q, swaps=%d];\n",
+			edge.From, edge.To, edge.USD.Text('f', 2), edge.USD.Text('f', 2), edge.In.Text('g', -1), edge.Out.Text('g', -1), edge.Swaps)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID string `xml:"id,attr"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as GraphML, the volumes are edge attributes.
+func WriteGraphML(w io.Writer, g *Graph) error {
+	doc := &graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "usd", For: "edge", Name: "usd", Type: "double"},
+			{ID: "in", For: "edge", Name: "in", Type: "double"},
+			{ID: "out", For: "edge", Name: "out", Type: "double"},
+			{ID: "swaps", For: "edge", Name: "swaps", Type: "long"},
+		},
+	}
+	doc.Graph.ID = "token_flows"
+	doc.Graph.EdgeDefault = "directed"
+	for _, token := range g.Tokens {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: token})
+	}
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: edge.From,
+			Target: edge.To,
+			Data: []graphMLData{
+				{Key: "usd", Value: edge.USD.Text('f', 2)},
+				{Key: "in", Value: edge.In.Text('g', -1)},
+				{Key: "out", Value: edge.Out.Text('g', -1)},
+				{Key: "swaps", Value: strconv.FormatUint(edge.Swaps, 10)},
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encode graphml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,55 @@
+package flowgraph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var values = map[string]string{
+	"flow_day:19000:0xa:0xb:usd":   "100.5",
+	"flow_day:19000:0xa:0xb:in":    "2",
+	"flow_day:19000:0xa:0xb:out":   "300",
+	"flow_day:19000:0xa:0xb:swaps": "2",
+	"flow_day:19001:0xa:0xb:usd":   "50",
+	"flow_day:19001:0xa:0xb:swaps": "1",
+	"flow_day:19001:0xb:0xc:usd":   "10",
+	"flow_day:19001:0xb:0xc:swaps": "1",
+	"flow:0xa:0xb:usd":             "150.5",
+}
+
+func TestBuild(t *testing.T) {
+	g, err := Build(values, Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xa", "0xb", "0xc"}, g.Tokens)
+	require.Len(t, g.Edges, 2)
+	assert.Equal(t, "0xa", g.Edges[0].From)
+	assert.Equal(t, "150.50", g.Edges[0].USD.Text('f', 2))
+	assert.Equal(t, uint64(3), g.Edges[0].Swaps)
+
+	g, err = Build(values, Filter{FromDay: 19001, MinUSD: 20})
+	require.NoError(t, err)
+	require.Len(t, g.Edges, 1)
+	assert.Equal(t, "50.00", g.Edges[0].USD.Text('f', 2))
+	assert.Equal(t, []string{"0xa", "0xb"}, g.Tokens)
+
+	_, err = Build(map[string]string{"flow_day:x:0xa:0xb:usd": "1"}, Filter{})
+	assert.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	g, err := Build(values, Filter{ToDay: 19000})
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteDOT(out, g))
+	assert.Contains(t, out.String(), `"0xa" -> "0xb" [label="100.50", usd="100.50", in="2", out="300", swaps=2];`)
+
+	out.Reset()
+	require.NoError(t, WriteGraphML(out, g))
+	assert.Contains(t, out.String(), `<edge source="0xa" target="0xb">`)
+	assert.Contains(t, out.String(), `<data key="usd">100.50</data>`)
+	assert.Contains(t, out.String(), `<node id="0xb"></node>`)
+}
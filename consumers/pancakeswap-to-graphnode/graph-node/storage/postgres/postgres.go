@@ -232,27 +232,31 @@ func (s *store) BatchSave(ctx context.Context, blockNum uint64, blockHash string
 		}
 	}()
 
-	trxs := []*sqlx.Tx{}
-	eg := llerrgroup.New(saveConcurrentUpdates)
+	// With transactions, the entities of every table and the cursor are
+	// written in a single transaction, a block is either fully saved along
+	// with its cursor or not at all, and resuming from the saved cursor never
+	// skips nor replays a block. The tables are then written one after the
+	// other since a transaction can't be shared between connections.
+	var tx *sqlx.Tx
+	concurrency := saveConcurrentUpdates
+	if s.withTransaction {
+		tx, err = s.db.BeginTxx(saveCtx, nil)
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		concurrency = 1
+	}
+
+	eg := llerrgroup.New(concurrency)
 	for tableName, entities := range updates {
 		if eg.Stop() {
 			continue // short-circuit the loop if we got an error
 		}
 
-		var tx *sqlx.Tx
-		if s.withTransaction {
-			tx, err = s.db.BeginTxx(saveCtx, nil)
-			if err != nil {
-				return fmt.Errorf("begin transaction: %w", err)
-			}
-			trxs = append(trxs, tx)
-		}
-
 		theTableName := tableName
 		theEntities := entities
 		eg.Go(func() error {
-			err = s.batchSave(saveCtx, tx, blockNum, theTableName, theEntities)
-			if err != nil {
+			if err := s.batchSave(saveCtx, tx, blockNum, theTableName, theEntities); err != nil {
 				return fmt.Errorf("batch saving: %w", err)
 			}
 			return nil
@@ -260,38 +264,27 @@ func (s *store) BatchSave(ctx context.Context, blockNum uint64, blockHash string
 	}
 
 	if err := eg.Wait(); err != nil {
-		s.rollback(saveCtx, trxs)
+		s.rollback(tx)
 		return fmt.Errorf("batch save: %w", err)
 	}
 
-	s.logger.Debug("all table flush", zap.Int("tx_count", len(trxs)), zap.Int("registered_entities", s.subgraph.Entities.Len()))
-
-	depTx, err := s.db.BeginTxx(saveCtx, nil)
-	if err != nil {
-		s.rollback(saveCtx, trxs)
-		return fmt.Errorf("begin transaction for deployment head tracking: %w", err)
-	}
-	trxs = append(trxs, depTx)
+	s.logger.Debug("all table flush", zap.Bool("with_transaction", tx != nil), zap.Int("registered_entities", s.subgraph.Entities.Len()))
 
-	if err = s.updateDeploymentHead(ctx, depTx, blockNum, blockHash); err != nil {
-		s.rollback(saveCtx, trxs)
+	if err = s.updateDeploymentHead(saveCtx, s.execer(tx), blockNum, blockHash); err != nil {
+		s.rollback(tx)
 		return fmt.Errorf("unable to save subgraph deployemnt head: %w", err)
 	}
 
-	curTx, err := s.db.BeginTxx(saveCtx, nil)
-	if err != nil {
-		s.rollback(saveCtx, trxs)
-		return fmt.Errorf("begin transaction for cursor: %w", err)
-	}
-
-	trxs = append(trxs, curTx)
-
-	if err = s.saveCursor(saveCtx, curTx, cursor); err != nil {
-		s.rollback(saveCtx, trxs)
+	if err = s.saveCursor(saveCtx, s.execer(tx), cursor); err != nil {
+		s.rollback(tx)
 		return fmt.Errorf("unable to save cursor: %w", err)
 	}
 
-	s.mustCommit(trxs)
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit block %d: %w", blockNum, err)
+		}
+	}
 
 	if s.withNotifications {
 		if err := s.notify(saveCtx, updates); err != nil {
@@ -337,30 +330,24 @@ func (s *store) notify(ctx context.Context, updates map[string]map[string]graphn
 	return nil
 }
 
-func (s *store) rollback(ctx context.Context, trxs []*sqlx.Tx) {
-	// If the context was canceled, transaction are already rolled back because it's the behavior
-	// of the sql driver to rollback active transaction on a canceled context, hence, we must skip it
-	// here.
-	// if ctx.Err() != nil {
-	// 	return
-	// }
+func (s *store) rollback(tx *sqlx.Tx) {
+	if tx == nil {
+		return
+	}
 
 	// If we roll back, it's because an error occurs, so we can afford an Info level heres
-	s.logger.Info("about to rollback transaction", zap.Int("count", len(trxs)))
-	for _, trx := range trxs {
-		if err := trx.Rollback(); err != nil {
-			s.logger.Panic("transaction rollback failed", zap.Error(err))
-		}
+	s.logger.Info("about to rollback transaction")
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		s.logger.Warn("transaction rollback failed", zap.Error(err))
 	}
 }
 
-func (s *store) mustCommit(trxs []*sqlx.Tx) {
-	s.logger.Debug("about to commit transaction", zap.Int("count", len(trxs)))
-	for _, trx := range trxs {
-		if err := trx.Commit(); err != nil {
-			s.logger.Panic("transaction commit failed", zap.Error(err))
-		}
+// execer returns `tx` when saving within a transaction, the database otherwise.
+func (s *store) execer(tx *sqlx.Tx) sqlx.ExecerContext {
+	if tx != nil {
+		return tx
 	}
+	return s.db
 }
 
 func (s *store) batchSave(ctx context.Context, dbTx *sqlx.Tx, blockNum uint64, tableName string, entities map[string]graphnode.Entity) (err error) {
@@ -655,8 +642,15 @@ func (s *store) UpdateBlockRange(ctx context.Context, dbTx *sqlx.Tx, tableName s
 	return nil
 }
 
-func (s *store) updateDeploymentHead(ctx context.Context, tx *sqlx.Tx, blockNumer uint64, blockHash string) error {
-	return nil //todo: fix this please
+// trackDeploymentHead is off until the deployment ID is the one graph-node
+// expects, see the FIXME on `saveCursor`.
+const trackDeploymentHead = false
+
+func (s *store) updateDeploymentHead(ctx context.Context, tx sqlx.ExecerContext, blockNumer uint64, blockHash string) error {
+	if !trackDeploymentHead {
+		return nil
+	}
+
 	updateDeploymentQuery := "update subgraphs.subgraph_deployment set latest_ethereum_block_number=$1, latest_ethereum_block_hash=$2 where deployment = $3"
 	result, err := tx.ExecContext(ctx, updateDeploymentQuery, blockNumer, blockHash, s.subgraphDeploymentID)
 	if err != nil {
@@ -673,7 +667,7 @@ func (s *store) updateDeploymentHead(ctx context.Context, tx *sqlx.Tx, blockNume
 }
 
 // FIXME: the `subgraphID` was the DEPLOYMENT ID with Qmhellowold.. not the `exchange` string.. FIX IT ABOURGET!
-func (s *store) saveCursor(ctx context.Context, tx *sqlx.Tx, cursor string) error {
+func (s *store) saveCursor(ctx context.Context, tx sqlx.ExecerContext, cursor string) error {
 	query := fmt.Sprintf("INSERT INTO %s.cursor (id, cursor) VALUES (1, $1) ON CONFLICT (id) DO UPDATE SET cursor = $2", s.schemaName)
 	_, err := tx.ExecContext(ctx, query, cursor, cursor)
 	if err != nil {
@@ -0,0 +1,162 @@
+// Package testfixture builds the blocks the consumers of the modules are tested
+// with, as the substreams server streams them, and records what a sink is
+// written. Its blocks are the ones of the PancakeSwap modules, it isn't part of
+// the module SDK, see `sdk/testing` for the module tests.
+package testfixture
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// BlockData builds the block `num` of a stream as the sinks receive it, its
+// timestamp is `num` times 3 seconds after the epoch. Its id is
+// the number in hex, `%08x`, and its cursor `cursor-<num>`, `cursor-<num>-undo`
+// when it's undone so the undo doesn't share the cursor of the block.
+func BlockData(num uint64, step pbsubstreams.ForkStep, outputs ...*pbsubstreams.ModuleOutput) *pbsubstreams.BlockScopedData {
+	cursor := fmt.Sprintf("cursor-%d", num)
+	if step == pbsubstreams.ForkStep_STEP_UNDO {
+		cursor += "-undo"
+	}
+
+	return &pbsubstreams.BlockScopedData{
+		Step:   step,
+		Cursor: cursor,
+		Clock: &pbsubstreams.Clock{
+			Number:    num,
+			Id:        fmt.Sprintf("%08x", num),
+			Timestamp: timestamppb.New(time.Unix(int64(num)*3, 0)),
+		},
+		Outputs: outputs,
+	}
+}
+
+// PairsBlock is the block `num` with an empty `map_pairs` output and a `store_pairs`
+// output creating the key `pair:<num>`.
+func PairsBlock(num uint64, step pbsubstreams.ForkStep) *pbsubstreams.BlockScopedData {
+	return BlockData(num, step,
+		MapOutput("map_pairs", nil),
+		StoreOutput("store_pairs", &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: fmt.Sprintf("pair:%d", num), NewValue: []byte("v")}),
+	)
+}
+
+// BlockResponse is the block of `BlockData` as received from the substreams server.
+func BlockResponse(num uint64, step pbsubstreams.ForkStep, outputs ...*pbsubstreams.ModuleOutput) *pbsubstreams.Response {
+	return &pbsubstreams.Response{Message: &pbsubstreams.Response_Data{Data: BlockData(num, step, outputs...)}}
+}
+
+// MapOutput is the output of the map `module`, an empty one when `msg` is nil.
+// It panics when `msg` can't be marshalled.
+func MapOutput(module string, msg proto.Message) *pbsubstreams.ModuleOutput {
+	output := &anypb.Any{}
+	if msg != nil {
+		var err error
+		if output, err = anypb.New(msg); err != nil {
+			panic(fmt.Errorf("marshal output of %q: %w", module, err))
+		}
+	}
+	return &pbsubstreams.ModuleOutput{Name: module, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: output}}
+}
+
+// StoreOutput is the output of the store `module` with `deltas`.
+func StoreOutput(module string, deltas ...*pbsubstreams.StoreDelta) *pbsubstreams.ModuleOutput {
+	return &pbsubstreams.ModuleOutput{Name: module, Data: &pbsubstreams.ModuleOutput_StoreDeltas{
+		StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas},
+	}}
+}
+
+// RecordingSink is a sink recording the blocks written to it, the blocks in
+// `Errs` fail with their error and are not recorded.
+type RecordingSink struct {
+	Errs map[uint64]error
+
+	lock    sync.Mutex
+	written []*pbsubstreams.BlockScopedData
+	flushes int
+	closed  bool
+}
+
+func (s *RecordingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	if err := s.Errs[data.Clock.Number]; err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.written = append(s.written, data)
+	return nil
+}
+
+func (s *RecordingSink) Flush(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.flushes++
+	return nil
+}
+
+func (s *RecordingSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	return nil
+}
+
+// Written returns the blocks written so far.
+func (s *RecordingSink) Written() []*pbsubstreams.BlockScopedData {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*pbsubstreams.BlockScopedData(nil), s.written...)
+}
+
+// Last returns the last block written, nil when there is none.
+func (s *RecordingSink) Last() *pbsubstreams.BlockScopedData {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.written) == 0 {
+		return nil
+	}
+	return s.written[len(s.written)-1]
+}
+
+// Numbers returns the numbers of the blocks written so far.
+func (s *RecordingSink) Numbers() (out []uint64) {
+	for _, data := range s.Written() {
+		out = append(out, data.Clock.Number)
+	}
+	return out
+}
+
+// Steps returns the blocks written so far as `<num>`, suffixed by `u` for the
+// undone blocks and `i` for the irreversible ones.
+func (s *RecordingSink) Steps() (out []string) {
+	for _, data := range s.Written() {
+		suffix := ""
+		switch data.Step {
+		case pbsubstreams.ForkStep_STEP_UNDO:
+			suffix = "u"
+		case pbsubstreams.ForkStep_STEP_IRREVERSIBLE:
+			suffix = "i"
+		}
+		out = append(out, fmt.Sprintf("%d%s", data.Clock.Number, suffix))
+	}
+	return out
+}
+
+func (s *RecordingSink) Flushes() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.flushes
+}
+
+func (s *RecordingSink) Closed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closed
+}
@@ -0,0 +1,36 @@
+package testfixture
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingSink(t *testing.T) {
+	ctx := context.Background()
+	crash := errors.New("crash")
+	s := &RecordingSink{Errs: map[uint64]error{3: crash}}
+
+	require.NoError(t, s.Write(ctx, PairsBlock(1, pbsubstreams.ForkStep_STEP_NEW)))
+	require.NoError(t, s.Write(ctx, PairsBlock(2, pbsubstreams.ForkStep_STEP_NEW)))
+	assert.Equal(t, crash, s.Write(ctx, PairsBlock(3, pbsubstreams.ForkStep_STEP_NEW)))
+	require.NoError(t, s.Write(ctx, PairsBlock(2, pbsubstreams.ForkStep_STEP_UNDO)))
+	require.NoError(t, s.Write(ctx, BlockData(1, pbsubstreams.ForkStep_STEP_IRREVERSIBLE)))
+	require.NoError(t, s.Flush(ctx))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, []uint64{1, 2, 2, 1}, s.Numbers(), "failed blocks are not recorded")
+	assert.Equal(t, []string{"1", "2", "2u", "1i"}, s.Steps())
+	assert.Equal(t, 1, s.Flushes())
+	assert.True(t, s.Closed())
+
+	undo := s.Written()[2]
+	assert.Equal(t, "cursor-2-undo", undo.Cursor)
+	assert.Equal(t, "00000002", undo.Clock.Id)
+	assert.Equal(t, "pair:2", undo.Outputs[1].GetStoreDeltas().Deltas[0].Key)
+	assert.Equal(t, "cursor-1", s.Last().Cursor)
+}
@@ -0,0 +1,248 @@
+// Package leader elects one instance out of several running the same live
+// indexing, the others standing by to take over. The election is a lease kept
+// in a lock file of a dstore, renewed by the leader and holding the checkpoint
+// from which a new leader resumes.
+//
+// Stores have no compare-and-swap, two instances racing for an expired lease
+// both write it and the last writer wins: each one reads the lease back once
+// writes settled and only the instance found in it leads. A leader that
+// loses its lease may still write the block it's processing before it stops.
+package leader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+)
+
+const leaseFile = "leader"
+
+var ErrLost = errors.New("leadership lost")
+
+// Lease is the content of the lock file.
+type Lease struct {
+	Holder     string      `json:"holder"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+}
+
+// Checkpoint is the last block the leader fully wrote to its outputs, the
+// next leader resumes right after it.
+type Checkpoint struct {
+	BlockNum uint64 `json:"block_num"`
+	Cursor   string `json:"cursor"`
+}
+
+type Elector struct {
+	store dstore.Store
+	id    string
+	ttl   time.Duration
+
+	lock        sync.Mutex
+	checkpoint  *Checkpoint
+	lastRenewal time.Time
+
+	lost     chan struct{}
+	lostOnce sync.Once
+}
+
+// New returns an elector for instance `id` using the lock file found at
+// `storeURL`, a lease is valid for `ttl` and renewed every third of it.
+func New(storeURL, id string, ttl time.Duration) (*Elector, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("lease TTL must be positive, got %s", ttl)
+	}
+
+	store, err := dstore.NewStore(storeURL, "json", "", true)
+	if err != nil {
+		return nil, fmt.Errorf("leader store %q: %w", storeURL, err)
+	}
+
+	return &Elector{store: store, id: id, ttl: ttl, lost: make(chan struct{})}, nil
+}
+
+// Acquire blocks until this instance leads, standing by while another instance
+// holds a valid lease. It returns the checkpoint of the previous leader, nil
+// when there's none.
+func (e *Elector) Acquire(ctx context.Context) (*Checkpoint, error) {
+	standingBy := false
+	for {
+		lease, err := e.read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if lease == nil || lease.Holder == e.id || time.Now().After(lease.ExpiresAt) {
+			e.lock.Lock()
+			if lease != nil {
+				e.checkpoint = lease.Checkpoint
+			}
+			err := e.write(ctx, time.Now().Add(e.ttl))
+			e.lock.Unlock()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := sleep(ctx, e.ttl/5); err != nil {
+				return nil, err
+			}
+			if lease, err = e.read(ctx); err != nil {
+				return nil, err
+			}
+			if lease != nil && lease.Holder == e.id {
+				zlog.Info("leadership acquired", zap.String("id", e.id), zap.Reflect("checkpoint", lease.Checkpoint))
+				return lease.Checkpoint, nil
+			}
+		}
+
+		if !standingBy && lease != nil {
+			zlog.Info("standing by, another instance leads", zap.String("id", e.id), zap.String("leader", lease.Holder))
+			standingBy = true
+		}
+		if err := sleep(ctx, e.ttl/3); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Keep renews the lease every third of its TTL until `ctx` is done. When the
+// lease is taken by another instance, or couldn't be renewed before it
+// expired, `Lost` is closed and Keep returns.
+func (e *Elector) Keep(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := e.renew(ctx); err != nil {
+			if err == ErrLost {
+				e.lose()
+				return
+			}
+
+			e.lock.Lock()
+			expired := time.Since(e.lastRenewal) > e.ttl
+			e.lock.Unlock()
+			zlog.Warn("renewing leader lease", zap.Bool("expired", expired), zap.Error(err))
+			if expired {
+				e.lose()
+				return
+			}
+		}
+	}
+}
+
+func (e *Elector) renew(ctx context.Context) error {
+	lease, err := e.read(ctx)
+	if err != nil {
+		return err
+	}
+	if lease == nil || lease.Holder != e.id {
+		return ErrLost
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.write(ctx, time.Now().Add(e.ttl))
+}
+
+func (e *Elector) lose() {
+	e.lostOnce.Do(func() {
+		zlog.Warn("leadership lost", zap.String("id", e.id))
+		close(e.lost)
+	})
+}
+
+// Lost is closed once this instance doesn't lead anymore.
+func (e *Elector) Lost() <-chan struct{} {
+	return e.lost
+}
+
+// Checkpoint records the last block fully written to the outputs, it's
+// persisted with the next renewal of the lease.
+func (e *Elector) Checkpoint(checkpoint Checkpoint) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.checkpoint = &checkpoint
+}
+
+// Release persists the last checkpoint and expires the lease, so a standby
+// instance takes over right away.
+func (e *Elector) Release(ctx context.Context) error {
+	lease, err := e.read(ctx)
+	if err != nil {
+		return err
+	}
+	if lease == nil || lease.Holder != e.id {
+		return ErrLost
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.write(ctx, time.Now())
+}
+
+func (e *Elector) read(ctx context.Context) (*Lease, error) {
+	exists, err := e.store.FileExists(ctx, leaseFile)
+	if err != nil {
+		return nil, fmt.Errorf("check leader lease: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	object, err := e.store.OpenObject(ctx, leaseFile)
+	if err != nil {
+		return nil, fmt.Errorf("open leader lease: %w", err)
+	}
+	defer object.Close()
+
+	content, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("read leader lease: %w", err)
+	}
+
+	lease := &Lease{}
+	if err := json.Unmarshal(content, lease); err != nil {
+		return nil, fmt.Errorf("decode leader lease: %w", err)
+	}
+	return lease, nil
+}
+
+// write must be called with the lock held.
+func (e *Elector) write(ctx context.Context, expiresAt time.Time) error {
+	content, err := json.Marshal(&Lease{Holder: e.id, ExpiresAt: expiresAt.UTC(), Checkpoint: e.checkpoint})
+	if err != nil {
+		return err
+	}
+	if err := e.store.WriteObject(ctx, leaseFile, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("write leader lease: %w", err)
+	}
+	e.lastRenewal = time.Now()
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ttl = 150 * time.Millisecond
+
+func TestElector_Failover(t *testing.T) {
+	ctx := context.Background()
+	storeURL := t.TempDir()
+
+	a, err := New(storeURL, "a", ttl)
+	require.NoError(t, err)
+	b, err := New(storeURL, "b", ttl)
+	require.NoError(t, err)
+
+	checkpoint, err := a.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	keepCtx, stopKeeping := context.WithCancel(ctx)
+	go a.Keep(keepCtx)
+
+	acquired := make(chan *Checkpoint, 1)
+	go func() {
+		checkpoint, err := b.Acquire(ctx)
+		require.NoError(t, err)
+		acquired <- checkpoint
+	}()
+
+	// the lease is renewed, b stands by past the TTL
+	a.Checkpoint(Checkpoint{BlockNum: 10, Cursor: "cursor-10"})
+	select {
+	case <-acquired:
+		t.Fatal("standby acquired the lease of a live leader")
+	case <-time.After(3 * ttl):
+	}
+
+	stopKeeping()
+	require.NoError(t, a.Release(ctx))
+
+	select {
+	case checkpoint := <-acquired:
+		assert.Equal(t, &Checkpoint{BlockNum: 10, Cursor: "cursor-10"}, checkpoint)
+	case <-time.After(3 * ttl):
+		t.Fatal("standby didn't take over a released lease")
+	}
+}
+
+func TestElector_Lost(t *testing.T) {
+	ctx := context.Background()
+	storeURL := t.TempDir()
+
+	a, err := New(storeURL, "a", ttl)
+	require.NoError(t, err)
+	b, err := New(storeURL, "b", ttl)
+	require.NoError(t, err)
+
+	_, err = a.Acquire(ctx)
+	require.NoError(t, err)
+
+	// a stalls past its TTL without renewing, b takes over
+	time.Sleep(ttl)
+	_, err = b.Acquire(ctx)
+	require.NoError(t, err)
+
+	go a.Keep(ctx)
+	select {
+	case <-a.Lost():
+	case <-time.After(ttl):
+		t.Fatal("leadership not lost")
+	}
+	assert.Equal(t, ErrLost, a.Release(ctx))
+}
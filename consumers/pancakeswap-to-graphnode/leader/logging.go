@@ -0,0 +1,7 @@
+package leader
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.leader", "github.com/streamingfast/substream-pancakeswap/leader")
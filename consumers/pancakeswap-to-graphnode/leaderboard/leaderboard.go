@@ -0,0 +1,407 @@
+// Package leaderboard ranks the pairs and the traders over a rolling window,
+// by USD volume and by number of swaps, from the swaps of
+// `map_burn_swaps_events` and the trades of `map_router_trades`.
+//
+// A rolling top-N needs the previous ranking to be updated, which a substreams
+// store can't read, so the boards are kept by the consumer and published as
+// the deltas of an extra store, `leaderboard`, added to the blocks: one key per
+// board, written with the current values whenever its ranking changes. Sinks
+// publishing store deltas (nats, pubsub) make it a topic subscribers can follow.
+//
+// Swaps and trades are counted once per ID: a block streamed again, after a
+// reconnection or on a fork replay without its undo, doesn't count them twice.
+package leaderboard
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	SwapsModule  = "map_burn_swaps_events"
+	TradesModule = "map_router_trades"
+
+	// Store is the name of the store deltas output holding the boards.
+	Store = "leaderboard"
+)
+
+// Names of the boards, the keys of `Store`.
+const (
+	PairsByVolume   = "pairs_by_volume_usd"
+	PairsBySwaps    = "pairs_by_swaps"
+	TradersByVolume = "traders_by_volume_usd"
+	TradersByTrades = "traders_by_trades"
+)
+
+// bucket is the granularity of the window, values are expired an hour at a
+// time.
+const bucket = time.Hour
+
+// segment holds the values added to a board during the hour starting at
+// `start`, in unix seconds.
+type segment struct {
+	start  int64
+	values map[string]*big.Float
+}
+
+// counted holds the IDs of the swaps and trades added to the boards during the
+// hour starting at `start`, a replayed block has the same timestamp so it's
+// enough to look there. They expire with the segments of the boards.
+type counted struct {
+	start int64
+	ids   map[string]bool
+}
+
+// Entry is the value of an address on a board.
+type Entry struct {
+	Address string
+	Value   *big.Float
+}
+
+// Leaderboards holds the boards, it isn't safe for concurrent use.
+type Leaderboards struct {
+	size    int
+	window  time.Duration
+	boards  map[string]*board
+	counted []*counted
+}
+
+// New creates boards of the `size` first addresses over the last `window`,
+// rounded up to the hour. They start empty: after a restart, they are
+// complete once `window` has been streamed again.
+func New(size int, window time.Duration) *Leaderboards {
+	return &Leaderboards{
+		size:   size,
+		window: window,
+		boards: map[string]*board{
+			PairsByVolume:   newBoard(2),
+			PairsBySwaps:    newBoard(0),
+			TradersByVolume: newBoard(2),
+			TradersByTrades: newBoard(0),
+		},
+	}
+}
+
+// Top returns the current ranking of `name`.
+func (l *Leaderboards) Top(name string) []Entry {
+	b, found := l.boards[name]
+	if !found {
+		return nil
+	}
+	return b.top
+}
+
+// Apply folds the swaps and trades of `data` into the boards, taking them
+// back on an undo step, and returns `data` with a `Store` output holding the
+// boards whose ranking changed, `data` itself when none did.
+func (l *Leaderboards) Apply(data *pbsubstreams.BlockScopedData) (*pbsubstreams.BlockScopedData, error) {
+	at := data.Clock.GetTimestamp().AsTime()
+	sign := 1
+	if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		sign = -1
+	}
+
+	for _, output := range data.Outputs {
+		mapOutput := output.GetMapOutput()
+		if mapOutput == nil || (output.Name != SwapsModule && output.Name != TradesModule) {
+			continue
+		}
+
+		msg, err := anypb.UnmarshalNew(mapOutput, proto.UnmarshalOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s output: %w", output.Name, err)
+		}
+
+		switch m := msg.(type) {
+		case *pbpcs.Events:
+			for _, event := range m.Events {
+				if swap := event.GetSwap(); swap != nil && l.count(at, "swap:"+event.Id, event.Id == "", sign) {
+					l.boards[PairsByVolume].add(at, event.PairAddress, parseUSD(swap.AmountUsd), sign)
+					l.boards[PairsBySwaps].add(at, event.PairAddress, one, sign)
+				}
+			}
+		case *pbpcs.Trades:
+			for _, trade := range m.Trades {
+				if !l.count(at, "trade:"+trade.Id, trade.Id == "", sign) {
+					continue
+				}
+				l.boards[TradersByVolume].add(at, trade.Trader, parseUSD(trade.AmountUsd), sign)
+				l.boards[TradersByTrades].add(at, trade.Trader, one, sign)
+			}
+		}
+	}
+
+	l.expire(at)
+
+	var deltas []*pbsubstreams.StoreDelta
+	for _, name := range []string{PairsByVolume, PairsBySwaps, TradersByVolume, TradersByTrades} {
+		b := l.boards[name]
+		b.expire(at, l.window)
+
+		old := b.encoded
+		if !b.rank(l.size) {
+			continue
+		}
+
+		delta := &pbsubstreams.StoreDelta{
+			Operation: pbsubstreams.StoreDelta_UPDATE,
+			Key:       name,
+			OldValue:  []byte(old),
+			NewValue:  []byte(b.encoded),
+		}
+		if !b.written {
+			delta.Operation = pbsubstreams.StoreDelta_CREATE
+			delta.OldValue = nil
+			b.written = true
+		}
+		deltas = append(deltas, delta)
+	}
+
+	if len(deltas) == 0 {
+		return data, nil
+	}
+
+	out := proto.Clone(data).(*pbsubstreams.BlockScopedData)
+	out.Outputs = append(out.Outputs, &pbsubstreams.ModuleOutput{
+		Name: Store,
+		Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}},
+	})
+	return out, nil
+}
+
+// count tells whether a swap or trade is to be added, or taken back on an
+// undo: only when it wasn't, or was, counted yet. Outputs of modules predating
+// the IDs, `anonymous`, are always counted.
+func (l *Leaderboards) count(at time.Time, id string, anonymous bool, sign int) bool {
+	if anonymous {
+		return true
+	}
+
+	start := at.Truncate(bucket).Unix()
+	i := len(l.counted)
+	for i > 0 && l.counted[i-1].start > start {
+		i--
+	}
+	var ids map[string]bool
+	if i > 0 && l.counted[i-1].start == start {
+		ids = l.counted[i-1].ids
+	}
+
+	if sign < 0 {
+		if !ids[id] {
+			return false
+		}
+		delete(ids, id)
+		return true
+	}
+
+	if ids == nil {
+		ids = map[string]bool{}
+		l.counted = append(l.counted, nil)
+		copy(l.counted[i+1:], l.counted[i:])
+		l.counted[i] = &counted{start: start, ids: ids}
+	} else if ids[id] {
+		return false
+	}
+	ids[id] = true
+	return true
+}
+
+func (l *Leaderboards) expire(at time.Time) {
+	from := at.Add(-l.window).Unix()
+	for len(l.counted) > 0 && l.counted[0].start+int64(bucket/time.Second) <= from {
+		l.counted[0] = nil
+		l.counted = l.counted[1:]
+	}
+}
+
+var one = big.NewFloat(1)
+
+// parseUSD returns 0 for the swaps and trades whose USD value is unknown.
+func parseUSD(value string) *big.Float {
+	usd, ok := new(big.Float).SetString(value)
+	if !ok {
+		return new(big.Float)
+	}
+	return usd
+}
+
+type board struct {
+	precision int
+
+	// segments of the window, oldest first, and the sum of their values per
+	// address
+	segments []*segment
+	totals   map[string]*big.Float
+
+	// addresses whose total changed since the last ranking, and whether one
+	// of them decreased
+	changed   map[string]bool
+	decreased bool
+
+	top     []Entry
+	encoded string
+	written bool
+}
+
+func newBoard(precision int) *board {
+	return &board{
+		precision: precision,
+		totals:    map[string]*big.Float{},
+		changed:   map[string]bool{},
+	}
+}
+
+func (b *board) add(at time.Time, address string, value *big.Float, sign int) {
+	if value.Sign() == 0 {
+		return
+	}
+
+	seg := b.segment(at.Truncate(bucket).Unix(), sign > 0)
+	if seg == nil {
+		// undoing a block of an expired segment
+		return
+	}
+	values := seg.values
+
+	delta := new(big.Float).Set(value)
+	if sign < 0 {
+		delta.Neg(delta)
+	}
+	if current, found := values[address]; found {
+		current.Add(current, delta)
+	} else {
+		values[address] = new(big.Float).Set(delta)
+	}
+	b.addTotal(address, delta)
+}
+
+func (b *board) addTotal(address string, delta *big.Float) {
+	total, found := b.totals[address]
+	if !found {
+		total = new(big.Float)
+		b.totals[address] = total
+	}
+	total.Add(total, delta)
+	if total.Sign() <= 0 {
+		delete(b.totals, address)
+	}
+
+	b.changed[address] = true
+	if delta.Sign() < 0 {
+		b.decreased = true
+	}
+}
+
+// segment returns the segment starting at `start`, creating it when `create`
+// is true. Blocks come in order, so it's almost always the last one.
+func (b *board) segment(start int64, create bool) *segment {
+	i := len(b.segments)
+	for ; i > 0 && b.segments[i-1].start >= start; i-- {
+		if b.segments[i-1].start == start {
+			return b.segments[i-1]
+		}
+	}
+	if !create {
+		return nil
+	}
+
+	seg := &segment{start: start, values: map[string]*big.Float{}}
+	b.segments = append(b.segments, nil)
+	copy(b.segments[i+1:], b.segments[i:])
+	b.segments[i] = seg
+	return seg
+}
+
+// expire removes the segments entirely before `at - window`. Only the oldest
+// segments are looked at, a block expiring nothing costs a comparison.
+func (b *board) expire(at time.Time, window time.Duration) {
+	from := at.Add(-window).Unix()
+	for len(b.segments) > 0 && b.segments[0].start+int64(bucket/time.Second) <= from {
+		for address, value := range b.segments[0].values {
+			b.addTotal(address, new(big.Float).Neg(value))
+		}
+		b.segments[0] = nil
+		b.segments = b.segments[1:]
+	}
+}
+
+// rank updates the top `size` addresses, it returns true when the ranking
+// changed. When no total decreased, the addresses out of the previous top
+// didn't move up, so only the previous top and the changed addresses are
+// ranked, all the addresses otherwise.
+func (b *board) rank(size int) bool {
+	if len(b.changed) == 0 {
+		return false
+	}
+
+	candidates := map[string]bool{}
+	if b.decreased {
+		for address := range b.totals {
+			candidates[address] = true
+		}
+	} else {
+		for _, entry := range b.top {
+			candidates[entry.Address] = true
+		}
+		for address := range b.changed {
+			candidates[address] = true
+		}
+	}
+	b.changed = map[string]bool{}
+	b.decreased = false
+
+	var top []Entry
+	for address := range candidates {
+		if total, found := b.totals[address]; found {
+			top = append(top, Entry{Address: address, Value: new(big.Float).Copy(total)})
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if c := top[i].Value.Cmp(top[j].Value); c != 0 {
+			return c > 0
+		}
+		return top[i].Address < top[j].Address
+	})
+	if len(top) > size {
+		top = top[:size]
+	}
+
+	previous := b.top
+	b.top = top
+	if sameRanking(previous, top) {
+		return false
+	}
+	b.encoded = encode(top, b.precision)
+	return true
+}
+
+func sameRanking(left, right []Entry) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i].Address != right[i].Address {
+			return false
+		}
+	}
+	return true
+}
+
+// encode writes a ranking as `<address>=<value>` entries separated by commas,
+// best first.
+func encode(top []Entry, precision int) string {
+	entries := make([]string, len(top))
+	for i, entry := range top {
+		entries[i] = entry.Address + "=" + entry.Value.Text('f', precision)
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,160 @@
+package leaderboard
+
+import (
+	"testing"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var start = time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func block(t *testing.T, num uint64, at time.Time, step pbsubstreams.ForkStep, swaps map[string]string, trades map[string]string) *pbsubstreams.BlockScopedData {
+	t.Helper()
+
+	events := &pbpcs.Events{}
+	for pair, usd := range swaps {
+		events.Events = append(events.Events, &pbpcs.Event{PairAddress: pair, Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: usd}}})
+	}
+	tradesOutput := &pbpcs.Trades{}
+	for trader, usd := range trades {
+		tradesOutput.Trades = append(tradesOutput.Trades, &pbpcs.Trade{Trader: trader, AmountUsd: usd})
+	}
+
+	data := &pbsubstreams.BlockScopedData{
+		Step:  step,
+		Clock: &pbsubstreams.Clock{Number: num, Timestamp: timestamppb.New(at)},
+	}
+	for name, msg := range map[string]proto.Message{SwapsModule: events, TradesModule: tradesOutput} {
+		any, err := anypb.New(msg)
+		require.NoError(t, err)
+		data.Outputs = append(data.Outputs, &pbsubstreams.ModuleOutput{Name: name, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: any}})
+	}
+	return data
+}
+
+func boardDeltas(data *pbsubstreams.BlockScopedData) map[string]*pbsubstreams.StoreDelta {
+	out := map[string]*pbsubstreams.StoreDelta{}
+	for _, output := range data.Outputs {
+		if output.Name != Store {
+			continue
+		}
+		for _, delta := range output.GetStoreDeltas().Deltas {
+			out[delta.Key] = delta
+		}
+	}
+	return out
+}
+
+func TestLeaderboards_Apply(t *testing.T) {
+	l := New(2, 24*time.Hour)
+	irreversible := pbsubstreams.ForkStep_STEP_IRREVERSIBLE
+
+	out, err := l.Apply(block(t, 1, start, irreversible, map[string]string{"0xa": "10", "0xb": "30", "0xc": "20"}, map[string]string{"0xt": "5"}))
+	require.NoError(t, err)
+	deltas := boardDeltas(out)
+	require.Len(t, deltas, 4)
+	assert.Equal(t, pbsubstreams.StoreDelta_CREATE, deltas[PairsByVolume].Operation)
+	assert.Equal(t, "0xb=30.00,0xc=20.00", string(deltas[PairsByVolume].NewValue))
+	assert.Equal(t, "0xa=1,0xb=1", string(deltas[PairsBySwaps].NewValue))
+	assert.Equal(t, "0xt=5.00", string(deltas[TradersByVolume].NewValue))
+
+	// only the ranking of the swaps changed
+	data := block(t, 2, start.Add(time.Minute), irreversible, map[string]string{"0xb": "1"}, nil)
+	out, err = l.Apply(data)
+	require.NoError(t, err)
+	assert.Len(t, boardDeltas(out), 1)
+	assert.Equal(t, "0xb=2,0xa=1", string(boardDeltas(out)[PairsBySwaps].NewValue))
+
+	data = block(t, 3, start.Add(2*time.Minute), pbsubstreams.ForkStep_STEP_NEW, map[string]string{"0xa": "25"}, nil)
+	out, err = l.Apply(data)
+	require.NoError(t, err)
+	assert.Equal(t, pbsubstreams.StoreDelta_UPDATE, boardDeltas(out)[PairsByVolume].Operation)
+	assert.Equal(t, "0xb=30.00,0xc=20.00", string(boardDeltas(out)[PairsByVolume].OldValue))
+	assert.Equal(t, "0xa=35.00,0xb=31.00", string(boardDeltas(out)[PairsByVolume].NewValue))
+
+	data.Step = pbsubstreams.ForkStep_STEP_UNDO
+	out, err = l.Apply(data)
+	require.NoError(t, err)
+	assert.Equal(t, "0xb=31.00,0xc=20.00", string(boardDeltas(out)[PairsByVolume].NewValue))
+
+	// the first hour is out of the window
+	out, err = l.Apply(block(t, 4, start.Add(25*time.Hour), irreversible, map[string]string{"0xd": "1"}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "0xd=1.00", string(boardDeltas(out)[PairsByVolume].NewValue))
+	assert.Equal(t, "", string(boardDeltas(out)[TradersByVolume].NewValue))
+	assert.Equal(t, []Entry(nil), l.Top(TradersByTrades))
+
+	unchanged := block(t, 5, start.Add(25*time.Hour), irreversible, nil, nil)
+	out, err = l.Apply(unchanged)
+	require.NoError(t, err)
+	assert.Same(t, unchanged, out)
+}
+
+func TestLeaderboards_Apply_Replays(t *testing.T) {
+	l := New(2, 24*time.Hour)
+
+	data := block(t, 1, start, pbsubstreams.ForkStep_STEP_NEW, nil, nil)
+	for _, output := range data.Outputs {
+		var msg proto.Message = &pbpcs.Trades{Trades: []*pbpcs.Trade{{Id: "bsc:0x01:0x02:3", Trader: "0xt", AmountUsd: "5"}}}
+		if output.Name == SwapsModule {
+			msg = &pbpcs.Events{Events: []*pbpcs.Event{{Id: "bsc:0x01:0x02:3", PairAddress: "0xa", Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: "10"}}}}}
+		}
+		any, err := anypb.New(msg)
+		require.NoError(t, err)
+		output.Data = &pbsubstreams.ModuleOutput_MapOutput{MapOutput: any}
+	}
+
+	_, err := l.Apply(data)
+	require.NoError(t, err)
+	// streamed again after a reconnection
+	out, err := l.Apply(data)
+	require.NoError(t, err)
+	assert.Same(t, data, out)
+	assert.Equal(t, "0xa=10.00", encode(l.Top(PairsByVolume), 2))
+	assert.Equal(t, "0xt=1", encode(l.Top(TradersByTrades), 0))
+
+	data.Step = pbsubstreams.ForkStep_STEP_UNDO
+	_, err = l.Apply(data)
+	require.NoError(t, err)
+	assert.Empty(t, l.Top(PairsByVolume))
+	// undone twice
+	out, err = l.Apply(data)
+	require.NoError(t, err)
+	assert.Same(t, data, out)
+
+	data.Step = pbsubstreams.ForkStep_STEP_NEW
+	_, err = l.Apply(data)
+	require.NoError(t, err)
+	assert.Equal(t, "0xa=10.00", encode(l.Top(PairsByVolume), 2))
+	assert.Equal(t, "0xt=5.00", encode(l.Top(TradersByVolume), 2))
+}
+
+func TestBoard_Segments(t *testing.T) {
+	b := newBoard(0)
+	b.add(start, "0xa", one, 1)
+	b.add(start.Add(2*time.Hour), "0xa", one, 1)
+	b.add(start.Add(time.Hour+time.Minute), "0xb", one, 1)
+	b.add(start.Add(2*time.Hour+time.Minute), "0xb", one, 1)
+
+	var starts []int64
+	for _, seg := range b.segments {
+		starts = append(starts, seg.start-start.Unix())
+	}
+	assert.Equal(t, []int64{0, 3600, 7200}, starts)
+
+	// undoing a block of a segment that doesn't exist is a no-op
+	b.add(start.Add(3*time.Hour), "0xa", one, -1)
+	assert.Len(t, b.segments, 3)
+
+	b.expire(start.Add(25*time.Hour), 24*time.Hour)
+	assert.Len(t, b.segments, 2)
+	assert.Equal(t, "1", b.totals["0xa"].String())
+	assert.Equal(t, "2", b.totals["0xb"].String())
+}
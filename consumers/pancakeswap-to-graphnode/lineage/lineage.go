@@ -0,0 +1,259 @@
+// Package lineage records what produced the state a run leaves on disk, the
+// commit journal, the undo buffer and the snapshots: the modules and their
+// hashes, the code version, the params and blocks of every run that wrote to
+// it. A run resuming from such state checks it was produced by the same
+// modules, state of different module versions is never mixed.
+package lineage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// Version is the code version recorded in the runs, set at build time with
+// `-ldflags "-X github.com/streamingfast/substream-pancakeswap/lineage.Version=$(git rev-parse HEAD)"`.
+var Version = "dev"
+
+// FileName is the name of the lineage file in the directories holding state.
+const FileName = "lineage.json"
+
+// Manifest is the lineage of some state. The modules are the ones that
+// produced it, the runs are all the runs that wrote to it, the current one
+// last.
+type Manifest struct {
+	Package       string   `json:"package"`
+	Chain         string   `json:"chain"`
+	OutputModules []string `json:"output_modules"`
+	Modules       []Module `json:"modules"`
+	Runs          []*Run   `json:"runs"`
+}
+
+// Module is a module of the package with the hash of its code, inputs and
+// ancestors, as computed by the substreams server. Kind is "map" or "store".
+type Module struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Hash string `json:"hash"`
+}
+
+type Run struct {
+	Version    string            `json:"version"`
+	GoVersion  string            `json:"go_version"`
+	StartBlock int64             `json:"start_block"`
+	StopBlock  uint64            `json:"stop_block,omitempty"`
+	Params     map[string]string `json:"params,omitempty"`
+	StartedAt  time.Time         `json:"started_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+
+	// LastBlock is the last block written to the state, zero until then
+	LastBlock uint64 `json:"last_block,omitempty"`
+}
+
+// New returns the lineage of a run of the `outputs` of `modules`, the modules
+// selected for them, with a single run started now.
+func New(pkg, chain string, modules *pbsubstreams.Modules, outputs []string, run *Run) (*Manifest, error) {
+	graph, err := manifest.NewModuleGraph(modules.Modules)
+	if err != nil {
+		return nil, fmt.Errorf("modules graph: %w", err)
+	}
+
+	m := &Manifest{
+		Package:       pkg,
+		Chain:         chain,
+		OutputModules: append([]string(nil), outputs...),
+	}
+	sort.Strings(m.OutputModules)
+	for _, module := range modules.Modules {
+		kind := "map"
+		if module.GetKindStore() != nil {
+			kind = "store"
+		}
+		m.Modules = append(m.Modules, Module{Name: module.Name, Kind: kind, Hash: manifest.HashModuleAsString(modules, graph, module)})
+	}
+	sort.Slice(m.Modules, func(i, j int) bool { return m.Modules[i].Name < m.Modules[j].Name })
+
+	now := time.Now().UTC()
+	run.GoVersion = runtime.Version()
+	run.StartedAt, run.UpdatedAt = now, now
+	if run.Version == "" {
+		run.Version = Version
+	}
+	m.Runs = []*Run{run}
+	return m, nil
+}
+
+// Run returns the current run.
+func (m *Manifest) Run() *Run {
+	return m.Runs[len(m.Runs)-1]
+}
+
+// MismatchError lists how the modules that produced some state differ from
+// the ones of the run. Stores are the store modules of the run whose state is
+// invalid, their module changed or didn't produce the state.
+type MismatchError struct {
+	Differences []string
+	Stores      []string
+}
+
+func (e *MismatchError) Error() string {
+	msg := fmt.Sprintf("state produced by other modules: %s", strings.Join(e.Differences, ", "))
+	if len(e.Stores) > 0 {
+		msg += fmt.Sprintf(", invalidating stores %s", strings.Join(e.Stores, ", "))
+	}
+	return msg
+}
+
+// Check tells if the state of `previous` can be resumed by the run of `m`,
+// it's a `*MismatchError` when the modules or outputs differ. Other code
+// versions and chains only log a warning, the modules define the state.
+func (m *Manifest) Check(previous *Manifest) error {
+	var differences []string
+
+	if strings.Join(previous.OutputModules, ",") != strings.Join(m.OutputModules, ",") {
+		differences = append(differences, fmt.Sprintf("output modules %v, now %v", previous.OutputModules, m.OutputModules))
+	}
+
+	previousHashes := map[string]string{}
+	for _, module := range previous.Modules {
+		previousHashes[module.Name] = module.Hash
+	}
+	current := map[string]bool{}
+	for _, module := range m.Modules {
+		current[module.Name] = true
+	}
+
+	for _, module := range previous.Modules {
+		if !current[module.Name] {
+			differences = append(differences, fmt.Sprintf("module %q not part of the run anymore", module.Name))
+		}
+	}
+
+	// The hash of a module covers its ancestors, the stores downstream of a
+	// changed module are invalid too.
+	var stores []string
+	for _, module := range m.Modules {
+		hash, found := previousHashes[module.Name]
+		switch {
+		case !found:
+			differences = append(differences, fmt.Sprintf("module %q added", module.Name))
+		case hash != module.Hash:
+			differences = append(differences, fmt.Sprintf("module %q hash %s, now %s", module.Name, hash, module.Hash))
+		default:
+			continue
+		}
+		if module.Kind == "store" {
+			stores = append(stores, module.Name)
+		}
+	}
+
+	if len(differences) > 0 {
+		return &MismatchError{Differences: differences, Stores: stores}
+	}
+
+	if previous.Chain != m.Chain {
+		zlog.Warn("resuming state produced from another chain endpoint", zap.String("previous", previous.Chain), zap.String("chain", m.Chain))
+	}
+	if len(previous.Runs) > 0 {
+		if version := previous.Run().Version; version != m.Run().Version {
+			zlog.Warn("resuming state produced by another code version", zap.String("previous", version), zap.String("version", m.Run().Version))
+		}
+	}
+	return nil
+}
+
+// Read reads the lineage file at `path`, nil when there is none.
+func Read(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read lineage %q: %w", path, err)
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("decode lineage %q: %w", path, err)
+	}
+	return m, nil
+}
+
+// File is the lineage file of some state, rewritten as the run progresses.
+type File struct {
+	path     string
+	manifest *Manifest
+}
+
+// Open checks the lineage at `path` against the run of `m` and records the
+// run in it, the previous runs are kept. When `force` is set, state produced
+// by other modules is taken over instead of failing, the lineage then holds
+// the modules of `m`.
+func Open(path string, m *Manifest, force bool) (*File, error) {
+	previous, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{path: path, manifest: m}
+	if previous != nil {
+		if err := m.Check(previous); err != nil {
+			if !force {
+				return nil, fmt.Errorf("lineage %q: %w", path, err)
+			}
+			zlog.Warn("taking over state produced by other modules", zap.String("path", path), zap.Error(err))
+		}
+
+		copied := *m
+		copied.Runs = append(append([]*Run(nil), previous.Runs...), m.Run())
+		f.manifest = &copied
+	}
+
+	if err := f.write(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Manifest returns the lineage as recorded in the file.
+func (f *File) Manifest() *Manifest {
+	return f.manifest
+}
+
+// Update records that the state holds the blocks up to `lastBlock`.
+func (f *File) Update(lastBlock uint64) error {
+	run := f.manifest.Run()
+	run.LastBlock = lastBlock
+	run.UpdatedAt = time.Now().UTC()
+	return f.write()
+}
+
+// write replaces the file atomically, it's never found half written.
+func (f *File) write() error {
+	content, err := json.MarshalIndent(f.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal lineage: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("create lineage directory: %w", err)
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("write lineage: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("replace lineage: %w", err)
+	}
+	return nil
+}
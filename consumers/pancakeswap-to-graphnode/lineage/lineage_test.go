@@ -0,0 +1,93 @@
+package lineage
+
+import (
+	"path/filepath"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testModules(code string) *pbsubstreams.Modules {
+	return &pbsubstreams.Modules{
+		Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1", Content: []byte(code)}},
+		Modules: []*pbsubstreams.Module{
+			{
+				Name:   "map_pairs",
+				Kind:   &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:pcs.types.v1.Pairs"}},
+				Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.ethereum.type.v1.Block"}}}},
+			},
+			{
+				Name:   "store_pairs",
+				Kind:   &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{UpdatePolicy: pbsubstreams.Module_KindStore_UPDATE_POLICY_SET}},
+				Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: "map_pairs"}}}},
+			},
+		},
+	}
+}
+
+func newManifest(t *testing.T, code string, outputs ...string) *Manifest {
+	t.Helper()
+	m, err := New("substreams.yaml", "bsc", testModules(code), outputs, &Run{Version: "abc", StartBlock: 100})
+	require.NoError(t, err)
+	return m
+}
+
+func TestManifest_Check(t *testing.T) {
+	current := newManifest(t, "v1", "store_pairs")
+	require.Len(t, current.Modules, 2)
+	assert.Equal(t, "map_pairs", current.Modules[0].Name)
+	assert.Len(t, current.Modules[0].Hash, 40)
+
+	assert.NoError(t, current.Check(newManifest(t, "v1", "store_pairs")))
+
+	err := current.Check(newManifest(t, "v2", "store_pairs"))
+	require.Error(t, err)
+	mismatch, ok := err.(*MismatchError)
+	require.True(t, ok)
+	assert.Len(t, mismatch.Differences, 2)
+	assert.Equal(t, []string{"store_pairs"}, mismatch.Stores)
+	assert.Contains(t, mismatch.Differences[0], `module "map_pairs" hash`)
+
+	previous := newManifest(t, "v1", "store_pairs")
+	previous.Modules = append(previous.Modules, Module{Name: "store_volumes", Hash: "aa"})
+	err = current.Check(previous)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `module "store_volumes" not part of the run anymore`)
+
+	err = current.Check(newManifest(t, "v1", "map_pairs", "store_pairs"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "output modules [map_pairs store_pairs], now [store_pairs]")
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", FileName)
+
+	first, err := Open(path, newManifest(t, "v1", "store_pairs"), false)
+	require.NoError(t, err)
+	require.NoError(t, first.Update(150))
+
+	second, err := Open(path, newManifest(t, "v1", "store_pairs"), false)
+	require.NoError(t, err)
+	require.NoError(t, second.Update(200))
+
+	read, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, read.Runs, 2)
+	assert.Equal(t, uint64(150), read.Runs[0].LastBlock)
+	assert.Equal(t, uint64(200), read.Runs[1].LastBlock)
+
+	_, err = Open(path, newManifest(t, "v2", "store_pairs"), false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "state produced by other modules")
+
+	forced, err := Open(path, newManifest(t, "v2", "store_pairs"), true)
+	require.NoError(t, err)
+	assert.Len(t, forced.Manifest().Runs, 3)
+	assert.NoError(t, newManifest(t, "v2", "store_pairs").Check(forced.Manifest()))
+
+	missing, err := Read(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
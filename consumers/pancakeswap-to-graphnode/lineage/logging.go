@@ -0,0 +1,7 @@
+package lineage
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.lineage", "github.com/streamingfast/substream-pancakeswap/lineage")
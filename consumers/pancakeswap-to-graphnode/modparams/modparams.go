@@ -0,0 +1,90 @@
+// Package modparams sets the parameters of the modules, the pinned substreams
+// has no module parameter input so they're written in the binaries sent with
+// the request. The modules embed a fixed size block starting with `Marker`,
+// followed by `name=value` lines read when a block is processed, see
+// `modules/pancakeswap/src/params.rs`.
+package modparams
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Marker starts the block of parameters in a binary, `Size` is the size of
+// the block, the marker included. Both match the ones of the modules.
+const (
+	Marker = "pcs-module-params:v1\n"
+	Size   = 32768
+)
+
+// ErrNotEmbedded is returned by `Set` when no binary embeds the block of
+// parameters, the modules were built without them.
+var ErrNotEmbedded = errors.New("no binary embeds a block of module parameters")
+
+// Encode returns the block holding `values`, one `name=value` line per value
+// sorted by name, padded with new lines to `Size`.
+func Encode(values map[string]string) ([]byte, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	block := bytes.NewBufferString(Marker)
+	for _, name := range names {
+		value := values[name]
+		if name == "" || strings.ContainsAny(name, "=\n") {
+			return nil, fmt.Errorf("invalid module parameter name %q", name)
+		}
+		if strings.Contains(value, "\n") {
+			return nil, fmt.Errorf("invalid value of module parameter %q, it holds a new line", name)
+		}
+		fmt.Fprintf(block, "%s=%s\n", name, value)
+	}
+
+	if block.Len() > Size {
+		return nil, fmt.Errorf("module parameters take %d bytes, more than the %d of the modules", block.Len(), Size)
+	}
+
+	return append(block.Bytes(), bytes.Repeat([]byte{'\n'}, Size-block.Len())...), nil
+}
+
+// Set returns a copy of `modules` whose binaries hold `values`, every binary
+// embedding the block of parameters gets them. It fails with `ErrNotEmbedded`
+// when no binary embeds it, the modules would silently run without them.
+func Set(modules *pbsubstreams.Modules, values map[string]string) (*pbsubstreams.Modules, error) {
+	block, err := Encode(values)
+	if err != nil {
+		return nil, err
+	}
+
+	out := proto.Clone(modules).(*pbsubstreams.Modules)
+	found := false
+	for i, binary := range out.Binaries {
+		offset := bytes.Index(binary.Content, []byte(Marker))
+		if offset < 0 {
+			continue
+		}
+		if bytes.Index(binary.Content[offset+1:], []byte(Marker)) >= 0 {
+			return nil, fmt.Errorf("binary %d embeds more than one block of module parameters", i)
+		}
+		if offset+Size > len(binary.Content) {
+			return nil, fmt.Errorf("binary %d ends within its block of module parameters", i)
+		}
+
+		copy(binary.Content[offset:], block)
+		found = true
+	}
+
+	if !found {
+		return nil, ErrNotEmbedded
+	}
+
+	return out, nil
+}
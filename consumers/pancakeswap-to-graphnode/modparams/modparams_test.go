@@ -0,0 +1,68 @@
+package modparams
+
+import (
+	"bytes"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// binary mimics a built module, the unset block of parameters between some code.
+func binary() []byte {
+	unset := append([]byte(Marker), bytes.Repeat([]byte{'\n'}, Size-len(Marker))...)
+	return append(append([]byte("\x00asm code"), unset...), "more code"...)
+}
+
+func TestEncode(t *testing.T) {
+	block, err := Encode(map[string]string{"block-pair": "0xaa,0xbb", "adjusted-accounting": "false"})
+	require.NoError(t, err)
+
+	assert.Len(t, block, Size)
+	assert.True(t, bytes.HasPrefix(block, []byte(Marker+"adjusted-accounting=false\nblock-pair=0xaa,0xbb\n\n")), "sorted by name")
+
+	_, err = Encode(map[string]string{"a=b": "c"})
+	assert.Error(t, err)
+
+	_, err = Encode(map[string]string{"block-pair": "0xaa\nallow-pair=0xbb"})
+	assert.Error(t, err)
+
+	_, err = Encode(map[string]string{"block-pair": string(bytes.Repeat([]byte{'a'}, Size))})
+	assert.Error(t, err)
+}
+
+func TestSet(t *testing.T) {
+	modules := &pbsubstreams.Modules{Binaries: []*pbsubstreams.Binary{
+		{Type: "wasm/rust-v1", Content: binary()},
+		{Type: "wasm/rust-v1", Content: []byte("\x00asm other code")},
+	}}
+
+	out, err := Set(modules, map[string]string{"block-pair": "0xaa"})
+	require.NoError(t, err)
+
+	assert.Equal(t, binary(), modules.Binaries[0].Content, "the given modules are left untouched")
+
+	content := out.Binaries[0].Content
+	assert.Len(t, content, len(binary()))
+	assert.True(t, bytes.HasPrefix(content, []byte("\x00asm code"+Marker+"block-pair=0xaa\n\n")))
+	assert.True(t, bytes.HasSuffix(content, []byte("\n\nmore code")))
+	assert.Equal(t, []byte("\x00asm other code"), out.Binaries[1].Content)
+
+	again, err := Set(out, map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, binary(), again.Binaries[0].Content, "parameters can be set again on set binaries")
+}
+
+func TestSet_Errors(t *testing.T) {
+	_, err := Set(&pbsubstreams.Modules{Binaries: []*pbsubstreams.Binary{{Content: []byte("\x00asm code")}}}, nil)
+	assert.Equal(t, ErrNotEmbedded, err)
+
+	twice := append(binary(), binary()...)
+	_, err = Set(&pbsubstreams.Modules{Binaries: []*pbsubstreams.Binary{{Content: twice}}}, nil)
+	assert.Error(t, err, "more than one block")
+
+	truncated := binary()[:len("\x00asm code")+Size-1]
+	_, err = Set(&pbsubstreams.Modules{Binaries: []*pbsubstreams.Binary{{Content: truncated}}}, nil)
+	assert.Error(t, err, "truncated block")
+}
@@ -0,0 +1,123 @@
+package modules
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// BloomStats counts the lookups of a `BloomState`. `FalsePositiveRate` is the
+// share of the lookups of missing keys the filter didn't answer.
+type BloomStats struct {
+	Keys              int64   `json:"keys"`
+	Lookups           int64   `json:"lookups"`
+	Skipped           int64   `json:"skipped"`
+	FalsePositives    int64   `json:"false_positives"`
+	FalsePositiveRate float64 `json:"false_positive_rate"`
+}
+
+// BloomState is a `State` answering the lookups of missing keys from a bloom
+// filter of its keys, without reaching the wrapped state, which pays off for
+// existence checks like "is this address a pair" made for every log against a
+// store that mostly doesn't have the key, all the more when it spills to disk.
+//
+// Deleted keys stay in the filter, looking them up reaches the wrapped state.
+// The filter is sized for `expectedKeys`, the false positive rate grows past
+// them.
+type BloomState struct {
+	State
+
+	bits   []uint64
+	hashes uint64
+
+	stats BloomStats
+}
+
+// NewBloomState wraps `state`, which must be empty, with a filter of
+// `expectedKeys` keys answering `falsePositiveRate` of the lookups of missing
+// keys wrong.
+func NewBloomState(state State, expectedKeys int, falsePositiveRate float64) (*BloomState, error) {
+	if expectedKeys <= 0 {
+		return nil, fmt.Errorf("expected keys must be positive, got %d", expectedKeys)
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("false positive rate must be between 0 and 1, got %v", falsePositiveRate)
+	}
+
+	// optimal number of bits and of hash functions, see
+	// https://en.wikipedia.org/wiki/Bloom_filter#Optimal_number_of_hash_functions
+	bits := math.Ceil(-float64(expectedKeys) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	hashes := math.Max(1, math.Round(bits/float64(expectedKeys)*math.Ln2))
+
+	return &BloomState{
+		State:  state,
+		bits:   make([]uint64, (uint64(bits)+63)/64),
+		hashes: uint64(hashes),
+	}, nil
+}
+
+func (s *BloomState) Get(key string) ([]byte, bool) {
+	s.stats.Lookups++
+	if !s.mayContain(key) {
+		s.stats.Skipped++
+		return nil, false
+	}
+
+	value, found := s.State.Get(key)
+	if !found {
+		s.stats.FalsePositives++
+	}
+	return value, found
+}
+
+func (s *BloomState) Set(key string, value []byte) {
+	if !s.mayContain(key) {
+		s.stats.Keys++
+		s.add(key)
+	}
+	s.State.Set(key, value)
+}
+
+func (s *BloomState) Len() int {
+	return s.State.(interface{ Len() int }).Len()
+}
+
+func (s *BloomState) Stats() BloomStats {
+	stats := s.stats
+	if missing := stats.Skipped + stats.FalsePositives; missing > 0 {
+		stats.FalsePositiveRate = float64(stats.FalsePositives) / float64(missing)
+	}
+	return stats
+}
+
+// positions derives the bits of `key` from two halves of its FNV hash, see
+// "Less Hashing, Same Performance" by Kirsch and Mitzenmacher.
+func (s *BloomState) positions(key string, fn func(bit uint64) bool) {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	sum := hash.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32|1
+
+	size := uint64(len(s.bits)) * 64
+	for i := uint64(0); i < s.hashes; i++ {
+		if !fn((h1 + i*h2) % size) {
+			return
+		}
+	}
+}
+
+func (s *BloomState) mayContain(key string) (found bool) {
+	found = true
+	s.positions(key, func(bit uint64) bool {
+		found = s.bits[bit/64]&(1<<(bit%64)) != 0
+		return found
+	})
+	return found
+}
+
+func (s *BloomState) add(key string) {
+	s.positions(key, func(bit uint64) bool {
+		s.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+}
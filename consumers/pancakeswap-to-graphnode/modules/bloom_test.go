@@ -0,0 +1,43 @@
+package modules
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomState(t *testing.T) {
+	_, err := NewBloomState(NewMemoryState(), 0, 0.01)
+	assert.Error(t, err)
+	_, err = NewBloomState(NewMemoryState(), 100, 1)
+	assert.Error(t, err)
+
+	s, err := NewBloomState(NewMemoryState(), 1000, 0.01)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		s.Set(fmt.Sprintf("pair:%d", i), []byte("x"))
+	}
+	for i := 0; i < 1000; i++ {
+		value, found := s.Get(fmt.Sprintf("pair:%d", i))
+		require.True(t, found, "pair:%d", i)
+		assert.Equal(t, []byte("x"), value)
+	}
+	for i := 0; i < 10000; i++ {
+		_, found := s.Get(fmt.Sprintf("token:%d", i))
+		require.False(t, found)
+	}
+
+	s.Delete("pair:1")
+	_, found := s.Get("pair:1")
+	assert.False(t, found)
+	assert.Equal(t, 999, s.Len())
+
+	stats := s.Stats()
+	assert.Equal(t, int64(11001), stats.Lookups)
+	assert.Equal(t, stats.Lookups-1000, stats.Skipped+stats.FalsePositives)
+	assert.GreaterOrEqual(t, stats.FalsePositives, int64(1), "pair:1 is still in the filter")
+	assert.Less(t, stats.FalsePositiveRate, 0.03)
+}
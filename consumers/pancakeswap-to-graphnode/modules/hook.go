@@ -0,0 +1,76 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+type (
+	BeforeBlockFunc = sdk.BeforeBlockFunc
+	AfterBlockFunc  = sdk.AfterBlockFunc
+)
+
+// Hook runs code around the modules of a pipeline for every block, without
+// being a module itself: `Before` runs before the first module, `After` once
+// all modules ran, both are optional. Hooks only run in the pipelines they
+// are added to, see `Pipeline.AddHook`.
+type Hook struct {
+	Name   string
+	Before BeforeBlockFunc
+	After  AfterBlockFunc
+}
+
+var hooks = map[string]*Hook{}
+
+// RegisterHook adds a hook to the registry, it panics if a hook with the same
+// name was already registered, it's meant to be called from `init()` functions.
+func RegisterHook(hook *Hook) {
+	if hook.Name == "" {
+		panic("hook name is required")
+	}
+
+	if hook.Before == nil && hook.After == nil {
+		panic(fmt.Sprintf("hook %q has neither a before nor an after function", hook.Name))
+	}
+
+	if _, found := hooks[hook.Name]; found {
+		panic(fmt.Sprintf("hook %q already registered", hook.Name))
+	}
+
+	hooks[hook.Name] = hook
+}
+
+func GetHook(name string) (*Hook, bool) {
+	hook, found := hooks[name]
+	return hook, found
+}
+
+// HookNames returns the name of all registered hooks, sorted.
+func HookNames() (out []string) {
+	for name := range hooks {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return
+}
+
+// blockResult is the `sdk.BlockResult` view of a `BlockOutput`.
+type blockResult struct {
+	out *BlockOutput
+}
+
+func (r blockResult) Output(module string) (interface{}, bool) {
+	output, found := r.out.Outputs[module]
+	return output, found
+}
+
+func (r blockResult) Deltas(module string) []*Delta {
+	return r.out.Deltas[module]
+}
+
+func (r blockResult) Duration(module string) time.Duration {
+	return r.out.Durations[module]
+}
@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"errors"
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seenByHook collects what test_observe saw after each block
+var seenByHook []interface{}
+
+func init() {
+	// test_skip_odd skips the odd blocks and bumps the number of the others,
+	// which the modules see
+	RegisterHook(&Hook{
+		Name: "test_skip_odd",
+		Before: func(block *pbeth.Block, logger sdk.Logger) error {
+			if block.Number%2 == 1 {
+				return sdk.ErrSkipBlock
+			}
+			block.Number += 100
+			return nil
+		},
+	})
+
+	RegisterHook(&Hook{
+		Name: "test_observe",
+		After: func(block *pbeth.Block, result sdk.BlockResult, logger sdk.Logger) error {
+			output, _ := result.Output("test_counter")
+			seenByHook = append(seenByHook, output, len(result.Deltas("test_counter")))
+			return nil
+		},
+	})
+
+	RegisterHook(&Hook{
+		Name: "test_failing",
+		Before: func(block *pbeth.Block, logger sdk.Logger) error {
+			return errors.New("boom")
+		},
+	})
+}
+
+func TestPipeline_Hooks(t *testing.T) {
+	seenByHook = nil
+
+	p, err := NewPipeline("test_counter")
+	require.NoError(t, err)
+	require.NoError(t, p.AddHook("test_skip_odd"))
+	require.NoError(t, p.AddHook("test_observe"))
+
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 2})
+	require.NoError(t, err)
+	assert.False(t, out.Skipped)
+	assert.Equal(t, uint64(102), out.Outputs["test_counter"])
+
+	out, err = p.ProcessBlock(&pbeth.Block{Number: 3})
+	require.NoError(t, err)
+	assert.True(t, out.Skipped)
+	assert.Empty(t, out.Outputs)
+
+	state, _ := p.State("test_counter")
+	count, _ := state.Get("count")
+	assert.Equal(t, "1", string(count))
+	assert.Equal(t, []interface{}{uint64(102), 2}, seenByHook, "after hooks don't run for skipped blocks")
+}
+
+func TestPipeline_HookErrors(t *testing.T) {
+	p, err := NewPipeline("test_counter")
+	require.NoError(t, err)
+
+	assert.EqualError(t, p.AddHook("test_unknown"), `hook "test_unknown" not registered`)
+	require.NoError(t, p.AddHook("test_failing"))
+	assert.EqualError(t, p.AddHook("test_failing"), `hook "test_failing" already added`)
+
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 1})
+	assert.EqualError(t, err, `hook "test_failing" before block 1: boom`)
+}
@@ -0,0 +1,136 @@
+package modules
+
+import (
+	"fmt"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sdk/evm"
+)
+
+// InputMode is how a module consumes the store of another module.
+type InputMode int
+
+const (
+	// InputGet gives key lookups into the upstream store, see `StoreView`.
+	InputGet InputMode = iota
+	// InputDeltas gives the changes the upstream store went through in the current block.
+	InputDeltas
+)
+
+func (m InputMode) String() string {
+	switch m {
+	case InputGet:
+		return "get"
+	case InputDeltas:
+		return "deltas"
+	}
+	return fmt.Sprintf("unknown(%d)", int(m))
+}
+
+// Input declares a dependency on the store of another module. A module can only
+// read the stores it declared, in the mode it declared them.
+type Input struct {
+	Module string
+	Mode   InputMode
+}
+
+// StoreView is a read-only view of an upstream store, see `sdk.StoreReader`.
+type StoreView = sdk.StoreReader
+
+// Inputs is the `sdk.Intrinsics` given to a module for the block being
+// processed, it holds the module's declared inputs.
+type Inputs struct {
+	module  string
+	chain   sdk.Chain
+	current sdk.CurrentBlock
+	// block and logs are only set on EVM chains
+	block  *pbeth.Block
+	logs   []*pbeth.Log
+	rpc    sdk.RPC
+	strict bool
+	native sdk.NativeCaller
+	logger sdk.Logger
+	stores map[string]StoreView
+	deltas map[string][]*Delta
+}
+
+var _ sdk.Intrinsics = (*Inputs)(nil)
+
+func (i *Inputs) Chain() sdk.Chain               { return i.chain }
+func (i *Inputs) CurrentBlock() sdk.CurrentBlock { return i.current }
+func (i *Inputs) Logs() []*pbeth.Log             { return i.logs }
+func (i *Inputs) RPC() sdk.RPC                   { return pinnedRPC{i.rpc, i.current.Number(), i.strict} }
+
+// Native makes `eth_call`s pinned like the RPC calls on the EVM chains, the
+// calls of other chains go to the native caller of the pipeline as is.
+func (i *Inputs) Native() sdk.NativeCaller {
+	if i.chain == evm.Chain {
+		return evm.NativeCaller(i.RPC())
+	}
+	return i.native
+}
+
+func (i *Inputs) Logger() sdk.Logger { return i.logger }
+
+// Store returns the view of the store of `module`, it fails when `module` was not
+// declared as an `InputGet` input.
+func (i *Inputs) Store(module string) (sdk.StoreReader, error) {
+	view, found := i.stores[module]
+	if !found {
+		return nil, fmt.Errorf("module %q did not declare a %q input on store %q", i.module, InputGet, module)
+	}
+	return view, nil
+}
+
+// Deltas returns the changes of the store of `module` in the current block, it
+// fails when `module` was not declared as an `InputDeltas` input.
+func (i *Inputs) Deltas(module string) ([]*Delta, error) {
+	deltas, found := i.deltas[module]
+	if !found {
+		return nil, fmt.Errorf("module %q did not declare a %q input on store %q", i.module, InputDeltas, module)
+	}
+	return deltas, nil
+}
+
+// pinnedRPC pins the calls of a module to the block being processed, so
+// backfills read the same state whenever they run. In strict mode, calls
+// against another block or the head of the chain fail.
+type pinnedRPC struct {
+	rpc    sdk.RPC
+	block  uint64
+	strict bool
+}
+
+func (r pinnedRPC) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	pinned := make([]*sdk.RPCCall, len(calls))
+	for i, call := range calls {
+		copied := *call
+		if !copied.Latest && copied.BlockNum == 0 {
+			copied.BlockNum = r.block
+		}
+		if r.strict && (copied.Latest || copied.BlockNum != r.block) {
+			at := "latest"
+			if !copied.Latest {
+				at = fmt.Sprintf("block %d", copied.BlockNum)
+			}
+			return nil, fmt.Errorf("call to %s at %s while processing block %d: %w", copied.ToAddr, at, r.block, sdk.ErrUnpinnedCall)
+		}
+		pinned[i] = &copied
+	}
+	return r.rpc.Call(pinned)
+}
+
+// noRPC is used when the pipeline has no JSON-RPC endpoint.
+type noRPC struct{}
+
+func (noRPC) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	return nil, sdk.ErrRPCUnavailable
+}
+
+// noNative is used when a pipeline of a non-EVM chain has no native caller.
+type noNative struct{}
+
+func (noNative) Call(calls []*sdk.NativeCall) ([]*sdk.NativeResponse, error) {
+	return nil, sdk.ErrRPCUnavailable
+}
@@ -0,0 +1,7 @@
+package modules
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.modules", "github.com/streamingfast/substream-pancakeswap/modules")
@@ -0,0 +1,23 @@
+package modules
+
+import (
+	"github.com/streamingfast/dmetrics"
+)
+
+var metrics = dmetrics.NewSet()
+
+var (
+	scans          = metrics.NewCounterVec("module_store_scans", []string{"module", "store"}, "prefix scans of an upstream store made by a module")
+	scannedKeys    = metrics.NewCounterVec("module_store_scanned_keys", []string{"module", "store"}, "keys iterated by the prefix scans of an upstream store made by a module")
+	scansOverLimit = metrics.NewCounterVec("module_store_scans_over_limit", []string{"module", "store"}, "prefix scans failed because more keys than the scan limit matched")
+	scanDuration   = metrics.NewHistogramVec("module_store_scan_duration", []string{"module", "store"}, "duration of the prefix scans of an upstream store made by a module, the time of the module callback included")
+
+	moduleErrors      = metrics.NewCounterVec("module_errors", []string{"module", "policy"}, "failures of a module on a block, retried ones included, by the error policy of the module")
+	moduleRetries     = metrics.NewCounterVec("module_retries", []string{"module"}, "times a module with a retry policy ran again on a block it failed on")
+	blockRollbacks    = metrics.NewCounter("module_block_rollbacks", "blocks whose store changes were rolled back, on a module or a hook failing or by the caller")
+	quarantinedBlocks = metrics.NewCounterVec("module_quarantined_blocks", []string{"module"}, "blocks a module with a skip policy failed on, recorded in quarantine and skipped")
+)
+
+func init() {
+	metrics.Register()
+}
@@ -0,0 +1,160 @@
+package modules
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/streamingfast/dbin"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// A recording is the deltas of a store module, block by block, written by a
+// `Recorder` and replayed by `Pipeline.Mock` in place of running the module.
+// It's a dbin file of `sf.substreams.v1.BlockScopedData` in a directory, one
+// message per block, blocks without deltas included.
+const (
+	recordingFile           = "deltas.dbin"
+	recordingContentType    = "SMR"
+	recordingContentVersion = 1
+)
+
+// Recorder writes the recording of a module to a directory, replacing the
+// recording it held.
+type Recorder struct {
+	file   *os.File
+	buffer *bufio.Writer
+	writer *dbin.Writer
+}
+
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create recording directory %q: %w", dir, err)
+	}
+
+	file, err := os.Create(filepath.Join(dir, recordingFile))
+	if err != nil {
+		return nil, fmt.Errorf("create recording in %q: %w", dir, err)
+	}
+
+	r := &Recorder{file: file, buffer: bufio.NewWriter(file)}
+	r.writer = dbin.NewWriter(r.buffer)
+	if err := r.writer.WriteHeader(recordingContentType, recordingContentVersion); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("write recording header: %w", err)
+	}
+	return r, nil
+}
+
+// Record appends the deltas of the module at `block`.
+func (r *Recorder) Record(block *pbeth.Block, deltas []*Delta) error {
+	out := &pbsubstreams.StoreDeltas{}
+	for i, delta := range deltas {
+		out.Deltas = append(out.Deltas, &pbsubstreams.StoreDelta{
+			Operation: storeDeltaOperations[delta.Operation],
+			Ordinal:   uint64(i + 1),
+			Key:       delta.Key,
+			OldValue:  delta.OldValue,
+			NewValue:  delta.NewValue,
+		})
+	}
+
+	content, err := proto.Marshal(&pbsubstreams.BlockScopedData{
+		Clock:   &pbsubstreams.Clock{Number: block.Number, Id: hex.EncodeToString(block.Hash)},
+		Outputs: []*pbsubstreams.ModuleOutput{{Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: out}}},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal deltas of block %d: %w", block.Number, err)
+	}
+	if err := r.writer.WriteMessage(content); err != nil {
+		return fmt.Errorf("record deltas of block %d: %w", block.Number, err)
+	}
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	if err := r.buffer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+var storeDeltaOperations = map[DeltaOperation]pbsubstreams.StoreDelta_Operation{
+	DeltaCreate: pbsubstreams.StoreDelta_CREATE,
+	DeltaUpdate: pbsubstreams.StoreDelta_UPDATE,
+	DeltaDelete: pbsubstreams.StoreDelta_DELETE,
+}
+
+// Recording reads a recording forward, as blocks are processed.
+type Recording struct {
+	dir    string
+	file   *os.File
+	reader *dbin.Reader
+	next   *pbsubstreams.BlockScopedData
+}
+
+func OpenRecording(dir string) (*Recording, error) {
+	file, err := os.Open(filepath.Join(dir, recordingFile))
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+
+	reader := dbin.NewReader(bufio.NewReader(file))
+	kind, _, err := reader.ReadHeader()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read recording %q header: %w", dir, err)
+	}
+	if kind != recordingContentType {
+		file.Close()
+		return nil, fmt.Errorf("recording %q holds %q, expected %q", dir, kind, recordingContentType)
+	}
+	return &Recording{dir: dir, file: file, reader: reader}, nil
+}
+
+// Deltas returns the recorded deltas of block `num`, skipping the blocks
+// recorded before it. Blocks must be asked in increasing order, it fails when
+// the recording doesn't hold `num`.
+func (r *Recording) Deltas(num uint64) ([]*Delta, error) {
+	for r.next == nil || r.next.Clock.Number < num {
+		content, err := r.reader.ReadMessage()
+		if err == io.EOF {
+			return nil, fmt.Errorf("recording %q ends before block %d", r.dir, num)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read recording %q: %w", r.dir, err)
+		}
+
+		r.next = &pbsubstreams.BlockScopedData{}
+		if err := proto.Unmarshal(content, r.next); err != nil {
+			return nil, fmt.Errorf("unmarshal recording %q message: %w", r.dir, err)
+		}
+	}
+	if r.next.Clock.Number > num {
+		return nil, fmt.Errorf("recording %q has no block %d, next recorded block is %d", r.dir, num, r.next.Clock.Number)
+	}
+
+	var out []*Delta
+	for _, output := range r.next.Outputs {
+		for _, delta := range output.GetStoreDeltas().GetDeltas() {
+			out = append(out, &Delta{Operation: deltaOperations[delta.Operation], Key: delta.Key, OldValue: delta.OldValue, NewValue: delta.NewValue})
+		}
+	}
+	return out, nil
+}
+
+func (r *Recording) Close() error {
+	return r.file.Close()
+}
+
+var deltaOperations = map[pbsubstreams.StoreDelta_Operation]DeltaOperation{
+	pbsubstreams.StoreDelta_CREATE: DeltaCreate,
+	pbsubstreams.StoreDelta_UPDATE: DeltaUpdate,
+	pbsubstreams.StoreDelta_DELETE: DeltaDelete,
+}
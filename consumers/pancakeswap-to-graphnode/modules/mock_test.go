@@ -0,0 +1,134 @@
+package modules
+
+import (
+	"path/filepath"
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// test_mock_last copies the last block of test_counter
+	Register(&Module{
+		Name:   "test_mock_last",
+		Inputs: []Input{{Module: "test_counter", Mode: InputGet}},
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			store, err := intr.Store("test_counter")
+			if err != nil {
+				return nil, err
+			}
+			last, _ := store.GetLast("last")
+			return last, nil
+		},
+		Store: func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error {
+			state.Set("last", output.([]byte))
+			return nil
+		},
+	})
+}
+
+func record(t *testing.T, module string, dir string, blocks ...uint64) {
+	t.Helper()
+
+	p, err := NewPipeline(module)
+	require.NoError(t, err)
+	defer p.Close()
+
+	recorder, err := NewRecorder(dir)
+	require.NoError(t, err)
+	for _, num := range blocks {
+		out, err := p.ProcessBlock(&pbeth.Block{Number: num})
+		require.NoError(t, err)
+		require.NoError(t, recorder.Record(&pbeth.Block{Number: num}, out.Deltas[module]))
+	}
+	require.NoError(t, recorder.Close())
+}
+
+func TestPipeline_Mock(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "test_counter")
+	record(t, "test_counter", dir, 10, 11, 12)
+
+	p, err := NewPipeline("test_reader")
+	require.NoError(t, err)
+	defer p.Close()
+	require.NoError(t, p.Mock("test_counter", dir))
+	assert.Equal(t, []string{"test_counter", "test_reader"}, p.Modules())
+
+	// The run starts after the beginning of the recording
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 11})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "11", "2"}, out.Outputs["test_reader"])
+	assert.Equal(t, DeltaCreate, out.Deltas["test_counter"][0].Operation)
+	assert.NotContains(t, out.Outputs, "test_counter")
+
+	out, err = p.ProcessBlock(&pbeth.Block{Number: 12})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"11", "12", "2"}, out.Outputs["test_reader"])
+
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 13})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ends before block 13")
+}
+
+func TestPipeline_MockSkipsDependencies(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "test_mock_last")
+	record(t, "test_mock_last", dir, 1, 2)
+
+	p, err := NewPipeline("test_mock_last")
+	require.NoError(t, err)
+	defer p.Close()
+	assert.Equal(t, []string{"test_counter", "test_mock_last"}, p.Modules())
+
+	require.NoError(t, p.Mock("test_mock_last", dir))
+	assert.Equal(t, []string{"test_mock_last"}, p.Modules())
+
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 2})
+	require.NoError(t, err)
+	assert.NotContains(t, out.Durations, "test_counter")
+
+	state, _ := p.State("test_mock_last")
+	last, _ := state.Get("last")
+	assert.Equal(t, "2", string(last))
+}
+
+func TestPipeline_MockErrors(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "test_counter")
+	record(t, "test_counter", dir, 10, 12)
+
+	tests := []struct {
+		name        string
+		module      string
+		dir         string
+		expectedErr string
+	}{
+		{"no store", "test_reader", dir, `module "test_reader" has no store`},
+		{"not in pipeline", "test_intrinsics", dir, `module "test_intrinsics" is not part of the pipeline`},
+		{"no recording", "test_counter", t.TempDir(), "open recording"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewPipeline("test_reader")
+			require.NoError(t, err)
+			defer p.Close()
+
+			err = p.Mock(test.module, test.dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedErr)
+		})
+	}
+
+	t.Run("missing block", func(t *testing.T) {
+		p, err := NewPipeline("test_reader")
+		require.NoError(t, err)
+		defer p.Close()
+		require.NoError(t, p.Mock("test_counter", dir))
+
+		_, err = p.ProcessBlock(&pbeth.Block{Number: 11})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no block 11, next recorded block is 12")
+	})
+}
@@ -0,0 +1,87 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sdk/evm"
+)
+
+// MapFunc and StoreFunc are defined in the `sdk` package, modules only need to
+// import `modules` to register themselves.
+type (
+	MapFunc   = sdk.MapFunc
+	StoreFunc = sdk.StoreFunc
+)
+
+// Module is a Go module made of a map step and an optional state builder step.
+// `Inputs` declares the stores of other modules it reads, see `Pipeline`.
+//
+// Modules of the EVM chains set `Map` and `Store`, the modules of other chains
+// set their `Chain` and `ChainMap` and `ChainStore` instead.
+type Module struct {
+	Name   string
+	Inputs []Input
+	Map    MapFunc
+	Store  StoreFunc
+
+	// Chain is the chain of the blocks the module processes, the EVM chains
+	// when nil.
+	Chain      sdk.Chain
+	ChainMap   sdk.ChainMapFunc
+	ChainStore sdk.ChainStoreFunc
+}
+
+func (m *Module) chain() sdk.Chain {
+	if m.Chain == nil {
+		return evm.Chain
+	}
+	return m.Chain
+}
+
+func (m *Module) hasStore() bool {
+	return m.Store != nil || m.ChainStore != nil
+}
+
+var registry = map[string]*Module{}
+
+// Register adds a module to the registry, it panics if a module with the same
+// name was already registered, it's meant to be called from `init()` functions.
+func Register(module *Module) {
+	if module.Name == "" {
+		panic("module name is required")
+	}
+
+	if module.Map == nil && module.ChainMap == nil {
+		panic(fmt.Sprintf("module %q has no map function", module.Name))
+	}
+
+	if (module.Map != nil || module.Store != nil) && (module.ChainMap != nil || module.ChainStore != nil) {
+		panic(fmt.Sprintf("module %q mixes EVM and chain functions", module.Name))
+	}
+
+	if module.Map != nil && module.chain() != evm.Chain {
+		panic(fmt.Sprintf("module %q of chain %q has EVM functions", module.Name, module.chain().Name()))
+	}
+
+	if _, found := registry[module.Name]; found {
+		panic(fmt.Sprintf("module %q already registered", module.Name))
+	}
+
+	registry[module.Name] = module
+}
+
+func Get(name string) (*Module, bool) {
+	module, found := registry[name]
+	return module, found
+}
+
+// Names returns the name of all registered modules, sorted.
+func Names() (out []string) {
+	for name := range registry {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return
+}
@@ -0,0 +1,558 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sdk/evm"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// Pipeline runs a set of registered modules block by block, in the order of their
+// declared store dependencies, so that a module reading a store always sees it
+// after the upstream module processed the current block.
+type Pipeline struct {
+	modules []*Module
+	names   []string
+	states  map[string]*trackedState
+	chain   sdk.Chain
+	rpc     sdk.RPC
+	strict  bool
+	native  sdk.NativeCaller
+	scans   *scanGuard
+
+	// mocks are the store modules replayed from a recording, skipped are the
+	// modules only feeding mocked modules, which don't run anymore
+	mocks   map[string]*Recording
+	skipped map[string]bool
+
+	// hooks run around the modules, in the order they were added
+	hooks []*pipelineHook
+
+	// policies handle the failures of the modules, the modules not in it
+	// halt, the blocks they skip are recorded in quarantine
+	policies   map[string]ErrorPolicy
+	quarantine *Quarantine
+
+	// inputs are reused from block to block, like the logs extracted once per
+	// block for all modules
+	inputs map[string]*Inputs
+	logs   []*pbeth.Log
+}
+
+// BlockOutput holds, per module name, what a block produced and how long the
+// module took to process it. Skipped is set when a hook skipped the block, no
+// module ran then. Quarantined are the modules that failed on the block and
+// skipped it, see `Skip`, they have no output nor deltas.
+type BlockOutput struct {
+	Outputs     map[string]interface{}
+	Deltas      map[string][]*Delta
+	Durations   map[string]time.Duration
+	Skipped     bool
+	Quarantined []string
+}
+
+type pipelineHook struct {
+	*Hook
+	logger sdk.Logger
+}
+
+// NewPipeline creates a pipeline running the modules `names` along with the
+// modules they depend on, each store starting empty. The modules must all be
+// of the same chain.
+func NewPipeline(names ...string) (*Pipeline, error) {
+	p := &Pipeline{states: map[string]*trackedState{}, inputs: map[string]*Inputs{}, rpc: noRPC{}, native: noNative{}, scans: &scanGuard{maxKeys: DefaultScanLimit}, mocks: map[string]*Recording{}, skipped: map[string]bool{}, policies: map[string]ErrorPolicy{}}
+
+	visiting := map[string]bool{}
+	visited := map[string]bool{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("dependency cycle: %v", append(path, name))
+		}
+
+		module, found := Get(name)
+		if !found {
+			return fmt.Errorf("module %q not registered", name)
+		}
+		if p.chain == nil {
+			p.chain = module.chain()
+		} else if module.chain() != p.chain {
+			return fmt.Errorf("module %q processes %s blocks, other modules of the pipeline %s blocks", name, module.chain().Name(), p.chain.Name())
+		}
+
+		visiting[name] = true
+		for _, input := range module.Inputs {
+			upstream, found := Get(input.Module)
+			if !found {
+				return fmt.Errorf("module %q: input module %q not registered", name, input.Module)
+			}
+			if !upstream.hasStore() {
+				return fmt.Errorf("module %q: input module %q has no store", name, input.Module)
+			}
+			if err := visit(input.Module, append(path, name)); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+
+		inputs := &Inputs{
+			module: name,
+			chain:  module.chain(),
+			logger: zlog.With(zap.String("module", name)),
+			stores: map[string]StoreView{},
+			deltas: map[string][]*Delta{},
+		}
+		for _, input := range module.Inputs {
+			switch input.Mode {
+			case InputGet:
+				inputs.stores[input.Module] = readOnlyView{state: p.states[input.Module], module: name, store: input.Module, scans: p.scans}
+			case InputDeltas:
+			default:
+				return fmt.Errorf("module %q: invalid mode %s for input %q", name, input.Mode, input.Module)
+			}
+		}
+
+		p.modules = append(p.modules, module)
+		p.inputs[name] = inputs
+		if module.hasStore() {
+			p.states[name] = newTrackedState(NewMemoryState())
+		}
+		return nil
+	}
+
+	p.names = append([]string(nil), names...)
+	sort.Strings(p.names)
+	for _, name := range p.names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Modules returns the name of the pipeline's modules, in execution order,
+// without the modules skipped because of mocks.
+func (p *Pipeline) Modules() (out []string) {
+	for _, module := range p.modules {
+		if !p.skipped[module.Name] {
+			out = append(out, module.Name)
+		}
+	}
+	return
+}
+
+// Mock replaces the execution of the store module `name` by the deltas
+// recorded in `dir`, see `Recorder`. The modules it depends on are not run
+// anymore unless another module needs them. It must be called before the
+// first block is processed.
+func (p *Pipeline) Mock(name string, dir string) error {
+	module, found := Get(name)
+	if !found || p.inputs[name] == nil {
+		return fmt.Errorf("module %q is not part of the pipeline", name)
+	}
+	if !module.hasStore() {
+		return fmt.Errorf("module %q has no store, only store modules can be mocked", name)
+	}
+	if _, found := p.mocks[name]; found {
+		return fmt.Errorf("module %q already mocked", name)
+	}
+
+	recording, err := OpenRecording(dir)
+	if err != nil {
+		return fmt.Errorf("mock %q: %w", name, err)
+	}
+	p.mocks[name] = recording
+
+	needed := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if needed[name] {
+			return
+		}
+		needed[name] = true
+		if _, mocked := p.mocks[name]; mocked {
+			return
+		}
+		module, _ := Get(name)
+		for _, input := range module.Inputs {
+			visit(input.Module)
+		}
+	}
+	for _, name := range p.names {
+		visit(name)
+	}
+
+	p.skipped = map[string]bool{}
+	for _, module := range p.modules {
+		if !needed[module.Name] {
+			p.skipped[module.Name] = true
+		}
+	}
+	return nil
+}
+
+// AddHook runs the registered hook `name` around the modules of every block,
+// after the hooks already added. It must be called before the first block is
+// processed.
+func (p *Pipeline) AddHook(name string) error {
+	if p.chain != evm.Chain {
+		return fmt.Errorf("hooks only run on the blocks of EVM chains, the pipeline processes %s blocks", p.chain.Name())
+	}
+	hook, found := GetHook(name)
+	if !found {
+		return fmt.Errorf("hook %q not registered", name)
+	}
+	for _, added := range p.hooks {
+		if added.Name == name {
+			return fmt.Errorf("hook %q already added", name)
+		}
+	}
+
+	p.hooks = append(p.hooks, &pipelineHook{Hook: hook, logger: zlog.With(zap.String("hook", name))})
+	return nil
+}
+
+// State returns the store of module `name`.
+func (p *Pipeline) State(name string) (State, bool) {
+	state, found := p.states[name]
+	if !found {
+		return nil, false
+	}
+	return state.State, true
+}
+
+// SetRPC sets the JSON-RPC endpoint used by the modules, calls fail with
+// `sdk.ErrRPCUnavailable` until it is set. Calls are pinned to the block being
+// processed, in strict mode the calls of a module against another block or the
+// head of the chain fail with `sdk.ErrUnpinnedCall`.
+func (p *Pipeline) SetRPC(rpc sdk.RPC, strict bool) {
+	p.rpc = rpc
+	p.strict = strict
+}
+
+// Chain returns the chain of the blocks the pipeline processes.
+func (p *Pipeline) Chain() sdk.Chain {
+	return p.chain
+}
+
+// SetNativeCaller sets the native caller of the modules of non-EVM chains,
+// calls fail with `sdk.ErrRPCUnavailable` until it is set. The native calls
+// of the EVM modules are `eth_call`s made through the RPC, see `SetRPC`.
+func (p *Pipeline) SetNativeCaller(native sdk.NativeCaller) {
+	p.native = native
+}
+
+// SetMemoryBudget backs every store of the pipeline with a `SpillingState`
+// holding at most `budget` bytes in memory, spilling to files in `dir`. It must
+// be called before the first block is processed.
+func (p *Pipeline) SetMemoryBudget(dir string, budget int64) error {
+	for name, state := range p.states {
+		if state.State.(interface{ Len() int }).Len() != 0 {
+			return fmt.Errorf("store %q is not empty, the memory budget must be set before processing blocks", name)
+		}
+		if _, ok := state.State.(*BloomState); ok {
+			return fmt.Errorf("store %q has a bloom filter, the memory budget must be set before the bloom filters", name)
+		}
+
+		spilling, err := NewSpillingState(filepath.Join(dir, name), budget)
+		if err != nil {
+			return fmt.Errorf("store %q: %w", name, err)
+		}
+		state.State = spilling
+	}
+	return nil
+}
+
+// SpillStats returns the spill activity of the stores, it's empty when no
+// memory budget is set.
+func (p *Pipeline) SpillStats() map[string]SpillStats {
+	out := map[string]SpillStats{}
+	for name, state := range p.states {
+		if spilling, ok := spillingState(state.State); ok {
+			out[name] = spilling.Stats()
+		}
+	}
+	return out
+}
+
+// SetBloomFilter puts a `BloomState` in front of the store of each module of
+// `stores`, sized for `expectedKeys`. It must be called before the first block
+// is processed, after `SetMemoryBudget`.
+func (p *Pipeline) SetBloomFilter(stores []string, expectedKeys int, falsePositiveRate float64) error {
+	for _, name := range stores {
+		state, found := p.states[name]
+		if !found {
+			return fmt.Errorf("unknown store %q, valid stores are: %s", name, p.storeNames())
+		}
+		if state.State.(interface{ Len() int }).Len() != 0 {
+			return fmt.Errorf("store %q is not empty, the bloom filters must be set before processing blocks", name)
+		}
+		if _, ok := state.State.(*BloomState); ok {
+			return fmt.Errorf("store %q already has a bloom filter", name)
+		}
+
+		bloom, err := NewBloomState(state.State, expectedKeys, falsePositiveRate)
+		if err != nil {
+			return fmt.Errorf("store %q: %w", name, err)
+		}
+		state.State = bloom
+	}
+	return nil
+}
+
+// BloomStats returns the lookups of the stores with a bloom filter.
+func (p *Pipeline) BloomStats() map[string]BloomStats {
+	out := map[string]BloomStats{}
+	for name, state := range p.states {
+		if bloom, ok := state.State.(*BloomState); ok {
+			out[name] = bloom.Stats()
+		}
+	}
+	return out
+}
+
+func (p *Pipeline) storeNames() string {
+	var names []string
+	for name := range p.states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// spillingState returns the `SpillingState` backing `state`, behind its bloom
+// filter if any.
+func spillingState(state State) (*SpillingState, bool) {
+	if bloom, ok := state.(*BloomState); ok {
+		state = bloom.State
+	}
+	spilling, ok := state.(*SpillingState)
+	return spilling, ok
+}
+
+// Close releases the resources held by the stores and the mocks.
+func (p *Pipeline) Close() error {
+	for name, recording := range p.mocks {
+		if err := recording.Close(); err != nil {
+			return fmt.Errorf("mock %q: %w", name, err)
+		}
+	}
+	for name, state := range p.states {
+		if spilling, ok := spillingState(state.State); ok {
+			if err := spilling.Close(); err != nil {
+				return fmt.Errorf("store %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ProcessBlock runs the modules of an EVM pipeline over `block`, see `Process`.
+func (p *Pipeline) ProcessBlock(block *pbeth.Block) (*BlockOutput, error) {
+	return p.Process(block)
+}
+
+// Process runs the modules over `block`, of the chain of the pipeline. The
+// block is a transaction over the stores: when a module or a hook fails, the
+// changes made to every store during the block are rolled back, the stores
+// being as they were before the block, for the block to be processed again.
+func (p *Pipeline) Process(block proto.Message) (out *BlockOutput, err error) {
+	current, err := p.chain.CurrentBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	// nil on other chains, the pipeline has no hook then
+	ethBlock, _ := block.(*pbeth.Block)
+
+	out = &BlockOutput{
+		Outputs:   map[string]interface{}{},
+		Deltas:    map[string][]*Delta{},
+		Durations: map[string]time.Duration{},
+	}
+
+	for _, state := range p.states {
+		state.reset()
+	}
+	defer func() {
+		if err != nil {
+			p.Rollback()
+		}
+	}()
+
+	for _, hook := range p.hooks {
+		if hook.Before == nil {
+			continue
+		}
+		if err := hook.Before(ethBlock, hook.logger); err != nil {
+			if errors.Is(err, sdk.ErrSkipBlock) {
+				out.Skipped = true
+				return out, nil
+			}
+			return nil, fmt.Errorf("hook %q before block %d: %w", hook.Name, current.Number(), err)
+		}
+	}
+
+	p.logs = p.logs[:0]
+	if ethBlock != nil {
+		p.logs = sdk.AppendSuccessfulLogs(p.logs, ethBlock)
+	}
+
+	for _, module := range p.modules {
+		if p.skipped[module.Name] {
+			continue
+		}
+
+		start := time.Now()
+		if recording, found := p.mocks[module.Name]; found {
+			deltas, err := recording.Deltas(current.Number())
+			if err != nil {
+				return nil, fmt.Errorf("module %q mock: %w", module.Name, err)
+			}
+
+			state := p.states[module.Name]
+			for _, delta := range deltas {
+				if delta.Operation == DeltaDelete {
+					state.Delete(delta.Key)
+					continue
+				}
+				state.Set(delta.Key, delta.NewValue)
+			}
+			out.Deltas[module.Name] = state.deltas
+			out.Durations[module.Name] = time.Since(start)
+			continue
+		}
+
+		inputs := p.inputs[module.Name]
+		inputs.current = current
+		inputs.block = ethBlock
+		inputs.logs = p.logs
+		inputs.rpc = p.rpc
+		inputs.strict = p.strict
+		inputs.native = p.native
+		for _, input := range module.Inputs {
+			if input.Mode == InputDeltas {
+				inputs.deltas[input.Module] = p.states[input.Module].deltas
+			}
+		}
+
+		output, quarantined, err := p.runModule(module, block, ethBlock, current, inputs)
+		if err != nil {
+			return nil, err
+		}
+		if quarantined {
+			out.Quarantined = append(out.Quarantined, module.Name)
+			out.Durations[module.Name] = time.Since(start)
+			continue
+		}
+		out.Outputs[module.Name] = output
+		if module.hasStore() {
+			out.Deltas[module.Name] = p.states[module.Name].deltas
+		}
+		out.Durations[module.Name] = time.Since(start)
+	}
+
+	for _, hook := range p.hooks {
+		if hook.After == nil {
+			continue
+		}
+		if err := hook.After(ethBlock, blockResult{out: out}, hook.logger); err != nil {
+			return nil, fmt.Errorf("hook %q after block %d: %w", hook.Name, current.Number(), err)
+		}
+	}
+
+	return out, nil
+}
+
+// Rollback undoes the changes made to the stores by the last block processed,
+// for a caller failing to commit the outputs of the block to process it again.
+// It must be called before the next block is processed, the blocks failing
+// are already rolled back.
+func (p *Pipeline) Rollback() {
+	rolledBack := false
+	for _, state := range p.states {
+		rolledBack = rolledBack || len(state.deltas) > 0
+		state.rollback()
+	}
+	if rolledBack {
+		blockRollbacks.Inc()
+	}
+}
+
+// runModule runs `module` over the block, handling its failures by its error
+// policy: the changes made to its store are rolled back before a retry or
+// when the block is skipped, `quarantined` then.
+func (p *Pipeline) runModule(module *Module, block proto.Message, ethBlock *pbeth.Block, current sdk.CurrentBlock, inputs *Inputs) (output interface{}, quarantined bool, err error) {
+	policy, found := p.policies[module.Name]
+	if !found {
+		policy = ErrorPolicy{Action: Halt}
+	}
+
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		output, err = p.tryModule(module, block, ethBlock, current, inputs)
+		if err == nil {
+			return output, false, nil
+		}
+		moduleErrors.Inc(module.Name, string(policy.Action))
+		if state, found := p.states[module.Name]; found {
+			state.rollback()
+		}
+
+		switch {
+		case policy.Action == Retry && attempt < policy.Retries:
+			zlog.Warn("module failed, retrying", zap.String("module", module.Name), zap.Uint64("block", current.Number()), zap.Int("attempt", attempt+1), zap.Duration("backoff", backoff), zap.Error(err))
+			moduleRetries.Inc(module.Name)
+			time.Sleep(backoff)
+			backoff *= 2
+			continue
+		case policy.Action == Skip:
+			if _, putErr := p.quarantine.Put(context.Background(), module.Name, block, current.Number(), current.ID(), err); putErr != nil {
+				return nil, false, fmt.Errorf("quarantine %v: %w", err, putErr)
+			}
+			zlog.Warn("module failed, block quarantined", zap.String("module", module.Name), zap.Uint64("block", current.Number()), zap.Error(err))
+			quarantinedBlocks.Inc(module.Name)
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+}
+
+func (p *Pipeline) tryModule(module *Module, block proto.Message, ethBlock *pbeth.Block, current sdk.CurrentBlock, inputs *Inputs) (output interface{}, err error) {
+	if module.ChainMap != nil {
+		output, err = module.ChainMap(block, inputs)
+	} else {
+		output, err = module.Map(ethBlock, inputs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("module %q map at block %d: %w", module.Name, current.Number(), err)
+	}
+	if !module.hasStore() {
+		return output, nil
+	}
+
+	state := p.states[module.Name]
+	if module.ChainStore != nil {
+		err = module.ChainStore(block, output, inputs, state)
+	} else {
+		err = module.Store(ethBlock, output, inputs, state)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("module %q store at block %d: %w", module.Name, current.Number(), err)
+	}
+	return output, nil
+}
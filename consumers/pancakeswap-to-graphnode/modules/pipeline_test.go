@@ -0,0 +1,323 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	pbsol "github.com/streamingfast/substream-pancakeswap/pb/sf/solana/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sdk/solana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// failLate makes test_late_failure fail
+var failLate bool
+
+func init() {
+	// test_counter stores the number of the last block and counts the blocks
+	Register(&Module{
+		Name: "test_counter",
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			return block.Number, nil
+		},
+		Store: func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error {
+			count := 0
+			if value, found := state.Get("count"); found {
+				count, _ = strconv.Atoi(string(value))
+			}
+			state.Set("count", []byte(strconv.Itoa(count+1)))
+			state.Set("last", []byte(fmt.Sprintf("%d", output.(uint64))))
+			return nil
+		},
+	})
+
+	// test_reader reads test_counter in both modes
+	Register(&Module{
+		Name: "test_reader",
+		Inputs: []Input{
+			{Module: "test_counter", Mode: InputGet},
+			{Module: "test_counter", Mode: InputDeltas},
+		},
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			store, err := intr.Store("test_counter")
+			if err != nil {
+				return nil, err
+			}
+			deltas, err := intr.Deltas("test_counter")
+			if err != nil {
+				return nil, err
+			}
+
+			last, _ := store.GetLast("last")
+			first, _ := store.GetFirst("last")
+			return []string{string(first), string(last), strconv.Itoa(len(deltas))}, nil
+		},
+	})
+
+	Register(&Module{
+		Name:   "test_undeclared",
+		Inputs: []Input{{Module: "test_counter", Mode: InputDeltas}},
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			return intr.Store("test_counter")
+		},
+	})
+
+	Register(&Module{
+		Name: "test_intrinsics",
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			_, err := intr.RPC().Call([]*sdk.RPCCall{{ToAddr: "0x"}})
+			return []interface{}{intr.CurrentBlock().ID(), intr.CurrentBlock().Number(), err}, nil
+		},
+	})
+
+	// test_rpc makes a call pinned by the runtime and a call against the head
+	// of the chain
+	Register(&Module{
+		Name: "test_rpc",
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			calls := []*sdk.RPCCall{{ToAddr: "0xa"}, {ToAddr: "0xb", Latest: true}}
+			_, err := intr.RPC().Call(calls)
+			return calls, err
+		},
+	})
+
+	// test_native only sees the chain agnostic intrinsics
+	Register(&Module{
+		Name: "test_native",
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			return chainAgnostic(intr)
+		},
+	})
+
+	Register(&Module{
+		Name:  "test_solana_native",
+		Chain: solana.Chain,
+		ChainMap: func(block proto.Message, intr sdk.ChainIntrinsics) (interface{}, error) {
+			return chainAgnostic(intr)
+		},
+	})
+
+	// test_late_failure runs after test_counter, failing while failLate is set
+	Register(&Module{
+		Name:   "test_late_failure",
+		Inputs: []Input{{Module: "test_counter", Mode: InputGet}},
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			return nil, nil
+		},
+		Store: func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error {
+			state.Set("seen", []byte("yes"))
+			if failLate {
+				return errors.New("late failure")
+			}
+			return nil
+		},
+	})
+
+	Register(&Module{
+		Name:   "test_cycle_a",
+		Inputs: []Input{{Module: "test_cycle_b", Mode: InputGet}},
+		Map:    func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) { return nil, nil },
+		Store:  func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error { return nil },
+	})
+	Register(&Module{
+		Name:   "test_cycle_b",
+		Inputs: []Input{{Module: "test_cycle_a", Mode: InputGet}},
+		Map:    func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) { return nil, nil },
+		Store:  func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error { return nil },
+	})
+}
+
+func TestPipeline_ReadSemantics(t *testing.T) {
+	p, err := NewPipeline("test_reader")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test_counter", "test_reader"}, p.Modules())
+
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "10", "2"}, out.Outputs["test_reader"])
+	require.Len(t, out.Deltas["test_counter"], 2)
+	assert.Equal(t, DeltaCreate, out.Deltas["test_counter"][0].Operation)
+
+	out, err = p.ProcessBlock(&pbeth.Block{Number: 11})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10", "11", "2"}, out.Outputs["test_reader"])
+	assert.Equal(t, DeltaUpdate, out.Deltas["test_counter"][0].Operation)
+
+	state, found := p.State("test_counter")
+	require.True(t, found)
+	count, _ := state.Get("count")
+	assert.Equal(t, "2", string(count))
+}
+
+func TestPipeline_Rollback(t *testing.T) {
+	p, err := NewPipeline("test_late_failure")
+	require.NoError(t, err)
+	counter, _ := p.State("test_counter")
+	late, _ := p.State("test_late_failure")
+
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 10})
+	require.NoError(t, err)
+
+	// test_counter ran before the failure, its changes are rolled back too
+	failLate = true
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 11})
+	failLate = false
+	assert.EqualError(t, err, `module "test_late_failure" store at block 11: late failure`)
+	count, _ := counter.Get("count")
+	assert.Equal(t, "1", string(count))
+	last, _ := counter.Get("last")
+	assert.Equal(t, "10", string(last))
+
+	// processing the block again gives the same deltas as a first success
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 11})
+	require.NoError(t, err)
+	require.Len(t, out.Deltas["test_counter"], 2)
+	assert.Equal(t, []byte("1"), out.Deltas["test_counter"][0].OldValue)
+	count, _ = counter.Get("count")
+	assert.Equal(t, "2", string(count))
+
+	// the caller rolls back the block it failed to commit
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 12})
+	require.NoError(t, err)
+	p.Rollback()
+	count, _ = counter.Get("count")
+	assert.Equal(t, "2", string(count))
+	_, found := late.Get("seen")
+	assert.True(t, found)
+
+	p, err = NewPipeline("test_late_failure")
+	require.NoError(t, err)
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 1})
+	require.NoError(t, err)
+	p.Rollback()
+	late, _ = p.State("test_late_failure")
+	_, found = late.Get("seen")
+	assert.False(t, found, "keys created by the block are deleted")
+}
+
+func TestPipeline_Intrinsics(t *testing.T) {
+	p, err := NewPipeline("test_intrinsics")
+	require.NoError(t, err)
+
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 12, Hash: []byte{0xab, 0xcd}})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"abcd", uint64(12), sdk.ErrRPCUnavailable}, out.Outputs["test_intrinsics"])
+}
+
+type recordingRPC struct {
+	calls []*sdk.RPCCall
+}
+
+func (r *recordingRPC) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	r.calls = append(r.calls, calls...)
+	responses := make([]*sdk.RPCResponse, len(calls))
+	for i := range responses {
+		responses[i] = &sdk.RPCResponse{}
+	}
+	return responses, nil
+}
+
+func TestPipeline_PinnedRPC(t *testing.T) {
+	p, err := NewPipeline("test_rpc")
+	require.NoError(t, err)
+
+	rpc := &recordingRPC{}
+	p.SetRPC(rpc, false)
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 7})
+	require.NoError(t, err)
+	assert.Equal(t, []*sdk.RPCCall{{ToAddr: "0xa", BlockNum: 7}, {ToAddr: "0xb", Latest: true}}, rpc.calls)
+	assert.Equal(t, []*sdk.RPCCall{{ToAddr: "0xa"}, {ToAddr: "0xb", Latest: true}}, out.Outputs["test_rpc"], "the calls of the module are left untouched")
+
+	rpc = &recordingRPC{}
+	p.SetRPC(rpc, true)
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 8})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, sdk.ErrUnpinnedCall))
+	assert.Contains(t, err.Error(), "call to 0xb at latest while processing block 8")
+	assert.Empty(t, rpc.calls)
+}
+
+func chainAgnostic(intr sdk.ChainIntrinsics) (interface{}, error) {
+	responses, err := intr.Native().Call([]*sdk.NativeCall{{Program: []byte{0x0a}, Data: []byte{0x01}}})
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{intr.Chain().Name(), intr.Chain().FormatHash([]byte{0xab}), len(responses)}, nil
+}
+
+func TestPipeline_NativeCalls(t *testing.T) {
+	p, err := NewPipeline("test_native")
+	require.NoError(t, err)
+
+	rpc := &recordingRPC{}
+	p.SetRPC(rpc, true)
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 9})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"evm", "0xab", 1}, out.Outputs["test_native"])
+	assert.Equal(t, []*sdk.RPCCall{{ToAddr: "0x0a", Data: []byte{0x01}, BlockNum: 9}}, rpc.calls)
+}
+
+type nativeFunc func(calls []*sdk.NativeCall) ([]*sdk.NativeResponse, error)
+
+func (f nativeFunc) Call(calls []*sdk.NativeCall) ([]*sdk.NativeResponse, error) {
+	return f(calls)
+}
+
+func TestPipeline_OtherChain(t *testing.T) {
+	p, err := NewPipeline("test_solana_native")
+	require.NoError(t, err)
+	assert.Equal(t, solana.Chain, p.Chain())
+	assert.EqualError(t, p.AddHook("test_observe"), "hooks only run on the blocks of EVM chains, the pipeline processes solana blocks")
+
+	_, err = p.Process(&pbsol.Block{Slot: 5})
+	assert.True(t, errors.Is(err, sdk.ErrRPCUnavailable))
+
+	var received []*sdk.NativeCall
+	p.SetNativeCaller(nativeFunc(func(calls []*sdk.NativeCall) ([]*sdk.NativeResponse, error) {
+		received = calls
+		return []*sdk.NativeResponse{{Raw: []byte{0x02}}}, nil
+	}))
+	out, err := p.Process(&pbsol.Block{Slot: 6})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"solana", "3x", 1}, out.Outputs["test_solana_native"])
+	assert.Equal(t, []*sdk.NativeCall{{Program: []byte{0x0a}, Data: []byte{0x01}}}, received)
+
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 7})
+	assert.EqualError(t, err, "expected a sf.solana.type.v1.Block block, got *pbeth.Block")
+
+	_, err = NewPipeline("test_native", "test_solana_native")
+	assert.EqualError(t, err, `module "test_solana_native" processes solana blocks, other modules of the pipeline evm blocks`)
+}
+
+func TestPipeline_UndeclaredInput(t *testing.T) {
+	p, err := NewPipeline("test_undeclared")
+	require.NoError(t, err)
+
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `did not declare a "get" input on store "test_counter"`)
+}
+
+func TestPipeline_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		module      string
+		expectedErr string
+	}{
+		{"unknown module", "test_unknown", `module "test_unknown" not registered`},
+		{"cycle", "test_cycle_a", "dependency cycle"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewPipeline(test.module)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorAction is what a pipeline does when a module fails on a block.
+type ErrorAction string
+
+const (
+	// Halt fails the block, and the run, it's the policy of every module
+	// unless set otherwise.
+	Halt ErrorAction = "halt"
+	// Retry runs the module again on the block, for transient failures like
+	// an RPC node timing out, the block fails once the retries are exhausted.
+	Retry ErrorAction = "retry"
+	// Skip writes a quarantine record of the block and goes on without the
+	// module's output for it, for failures specific to the data of a block,
+	// like a token returning garbage metadata.
+	Skip ErrorAction = "skip"
+)
+
+// DefaultRetries are the retries of a `Retry` policy parsed without a count.
+const DefaultRetries = 3
+
+// ErrorPolicy is how the failures of a module are handled, see
+// `Pipeline.SetErrorPolicy`. A retried module waits `Backoff` before its first
+// retry, doubling for the next ones.
+type ErrorPolicy struct {
+	Action  ErrorAction
+	Retries int
+	Backoff time.Duration
+}
+
+func (p ErrorPolicy) String() string {
+	if p.Action == Retry {
+		return fmt.Sprintf("retry:%d", p.Retries)
+	}
+	return string(p.Action)
+}
+
+// ParseErrorPolicy reads a policy written as `halt`, `skip` or
+// `retry[:<retries>]`, retrying `DefaultRetries` times when no count is
+// given, the backoff is left to the caller.
+func ParseErrorPolicy(in string) (ErrorPolicy, error) {
+	parts := strings.SplitN(in, ":", 2)
+	switch action := ErrorAction(parts[0]); action {
+	case Halt, Skip:
+		if len(parts) == 1 {
+			return ErrorPolicy{Action: action}, nil
+		}
+	case Retry:
+		if len(parts) == 1 {
+			return ErrorPolicy{Action: Retry, Retries: DefaultRetries}, nil
+		}
+		retries, err := strconv.Atoi(parts[1])
+		if err != nil || retries <= 0 {
+			return ErrorPolicy{}, fmt.Errorf("invalid error policy %q, the retries must be a positive number", in)
+		}
+		return ErrorPolicy{Action: Retry, Retries: retries}, nil
+	}
+	return ErrorPolicy{}, fmt.Errorf("invalid error policy %q, expected 'halt', 'skip' or 'retry[:<retries>]'", in)
+}
+
+// SetErrorPolicy sets how the failures of module `name` are handled, they halt
+// the pipeline unless set otherwise. A `Skip` policy needs the quarantine to
+// be set first, see `SetQuarantine`. Hooks and mocked modules always halt.
+func (p *Pipeline) SetErrorPolicy(name string, policy ErrorPolicy) error {
+	if p.inputs[name] == nil {
+		return fmt.Errorf("module %q is not part of the pipeline", name)
+	}
+
+	switch policy.Action {
+	case Halt:
+	case Retry:
+		if policy.Retries <= 0 {
+			return fmt.Errorf("module %q: a retry policy needs a positive number of retries", name)
+		}
+	case Skip:
+		if p.quarantine == nil {
+			return fmt.Errorf("module %q: a skip policy needs a quarantine to record the blocks skipped", name)
+		}
+	default:
+		return fmt.Errorf("module %q: invalid error policy %q", name, policy.Action)
+	}
+
+	p.policies[name] = policy
+	return nil
+}
+
+// SetQuarantine sets where the blocks skipped by the modules with a `Skip`
+// policy are recorded.
+func (p *Pipeline) SetQuarantine(quarantine *Quarantine) {
+	p.quarantine = quarantine
+}
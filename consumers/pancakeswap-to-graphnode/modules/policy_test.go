@@ -0,0 +1,162 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// flakyFailures is how many more times test_flaky fails
+var flakyFailures int
+
+func init() {
+	// test_flaky writes a key then fails, flakyFailures times
+	Register(&Module{
+		Name: "test_flaky",
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			return block.Number, nil
+		},
+		Store: func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error {
+			state.Set(fmt.Sprintf("block:%d", block.Number), []byte("done"))
+			if flakyFailures > 0 {
+				flakyFailures--
+				return errors.New("node timed out")
+			}
+			return nil
+		},
+	})
+
+	// test_poison updates a key, then fails on block 13
+	Register(&Module{
+		Name: "test_poison",
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			return block.Number, nil
+		},
+		Store: func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error {
+			state.Set("last", []byte(fmt.Sprint(block.Number)))
+			if block.Number == 13 {
+				return errors.New("invalid token decimals")
+			}
+			return nil
+		},
+	})
+
+	Register(&Module{
+		Name:   "test_poison_reader",
+		Inputs: []Input{{Module: "test_poison", Mode: InputDeltas}},
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			deltas, err := intr.Deltas("test_poison")
+			return len(deltas), err
+		},
+	})
+}
+
+func TestParseErrorPolicy(t *testing.T) {
+	tests := []struct {
+		in          string
+		expected    ErrorPolicy
+		expectedErr string
+	}{
+		{"halt", ErrorPolicy{Action: Halt}, ""},
+		{"skip", ErrorPolicy{Action: Skip}, ""},
+		{"retry", ErrorPolicy{Action: Retry, Retries: DefaultRetries}, ""},
+		{"retry:5", ErrorPolicy{Action: Retry, Retries: 5}, ""},
+		{"retry:0", ErrorPolicy{}, `invalid error policy "retry:0", the retries must be a positive number`},
+		{"skip:2", ErrorPolicy{}, `invalid error policy "skip:2", expected 'halt', 'skip' or 'retry[:<retries>]'`},
+		{"ignore", ErrorPolicy{}, `invalid error policy "ignore", expected 'halt', 'skip' or 'retry[:<retries>]'`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			policy, err := ParseErrorPolicy(test.in)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, policy)
+		})
+	}
+}
+
+func TestPipeline_ErrorPolicies(t *testing.T) {
+	p, err := NewPipeline("test_flaky")
+	require.NoError(t, err)
+
+	assert.EqualError(t, p.SetErrorPolicy("test_counter", ErrorPolicy{Action: Halt}), `module "test_counter" is not part of the pipeline`)
+	assert.EqualError(t, p.SetErrorPolicy("test_flaky", ErrorPolicy{Action: Skip}), `module "test_flaky": a skip policy needs a quarantine to record the blocks skipped`)
+	assert.EqualError(t, p.SetErrorPolicy("test_flaky", ErrorPolicy{Action: Retry}), `module "test_flaky": a retry policy needs a positive number of retries`)
+}
+
+func TestPipeline_RetryPolicy(t *testing.T) {
+	p, err := NewPipeline("test_flaky")
+	require.NoError(t, err)
+	require.NoError(t, p.SetErrorPolicy("test_flaky", ErrorPolicy{Action: Retry, Retries: 2}))
+
+	flakyFailures = 2
+	out, err := p.ProcessBlock(&pbeth.Block{Number: 10})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), out.Outputs["test_flaky"])
+	// the writes of the failed attempts were rolled back
+	require.Len(t, out.Deltas["test_flaky"], 1)
+	assert.Equal(t, DeltaCreate, out.Deltas["test_flaky"][0].Operation)
+
+	// retries exhausted, the block fails without leaving its writes behind
+	flakyFailures = 3
+	_, err = p.ProcessBlock(&pbeth.Block{Number: 11})
+	assert.EqualError(t, err, `module "test_flaky" store at block 11: node timed out`)
+	state, _ := p.State("test_flaky")
+	_, found := state.Get("block:11")
+	assert.False(t, found)
+	flakyFailures = 0
+}
+
+func TestPipeline_SkipPolicy(t *testing.T) {
+	quarantine, err := OpenQuarantine(t.TempDir())
+	require.NoError(t, err)
+
+	p, err := NewPipeline("test_poison_reader")
+	require.NoError(t, err)
+	p.SetQuarantine(quarantine)
+	require.NoError(t, p.SetErrorPolicy("test_poison", ErrorPolicy{Action: Skip}))
+
+	for num := uint64(12); num <= 14; num++ {
+		out, err := p.ProcessBlock(&pbeth.Block{Number: num, Hash: []byte{byte(num)}})
+		require.NoError(t, err)
+
+		if num != 13 {
+			assert.Empty(t, out.Quarantined)
+			assert.Equal(t, 1, out.Outputs["test_poison_reader"])
+			continue
+		}
+		// the downstream modules still run, without the deltas of the
+		// skipped module
+		assert.Equal(t, []string{"test_poison"}, out.Quarantined)
+		assert.NotContains(t, out.Outputs, "test_poison")
+		assert.Equal(t, 0, out.Outputs["test_poison_reader"])
+
+		state, _ := p.State("test_poison")
+		last, _ := state.Get("last")
+		assert.Equal(t, "12", string(last))
+	}
+
+	records, err := quarantine.List(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "test_poison", records[0].Module)
+	assert.Equal(t, `module "test_poison" store at block 13: invalid token decimals`, records[0].Error)
+	assert.Equal(t, uint64(13), records[0].BlockNum)
+	assert.Equal(t, "0d", records[0].BlockID)
+	assert.Equal(t, "sf.ethereum.type.v1.Block", records[0].BlockType)
+
+	block := &pbeth.Block{}
+	require.NoError(t, proto.Unmarshal(records[0].Block, block))
+	assert.Equal(t, uint64(13), block.Number)
+}
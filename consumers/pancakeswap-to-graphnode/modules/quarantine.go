@@ -0,0 +1,130 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"google.golang.org/protobuf/proto"
+)
+
+// Quarantine keeps the blocks a module with a `Skip` policy failed on, along
+// with the error, for the failure to be investigated and the block processed
+// again once the module is fixed.
+//
+// Records are JSON files named `<module>/<block num>-<block id>.json`, a block
+// failing again replaces its record.
+type Quarantine struct {
+	store dstore.Store
+}
+
+// QuarantineRecord is a block a module failed on.
+type QuarantineRecord struct {
+	Module        string    `json:"module"`
+	Error         string    `json:"error"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+	BlockNum      uint64    `json:"block_num"`
+	BlockID       string    `json:"block_id"`
+	// BlockType is the protobuf type of Block, like
+	// `sf.ethereum.type.v1.Block`, and Block the input of the module,
+	// protobuf encoded.
+	BlockType string `json:"block_type"`
+	Block     []byte `json:"block"`
+}
+
+// OpenQuarantine opens the quarantine at `storeURL`, a dstore URL like
+// `gs://bucket/quarantine` or a local directory.
+func OpenQuarantine(storeURL string) (*Quarantine, error) {
+	store, err := dstore.NewStore(storeURL, "json", "", true)
+	if err != nil {
+		return nil, fmt.Errorf("open quarantine %q: %w", storeURL, err)
+	}
+	return &Quarantine{store: store}, nil
+}
+
+// Put writes the record of `module` failing on `block` with `cause`.
+func (q *Quarantine) Put(ctx context.Context, module string, block proto.Message, num uint64, id string, cause error) (*QuarantineRecord, error) {
+	content, err := proto.Marshal(block)
+	if err != nil {
+		return nil, fmt.Errorf("marshal block %d: %w", num, err)
+	}
+
+	record := &QuarantineRecord{
+		Module:        module,
+		Error:         cause.Error(),
+		QuarantinedAt: time.Now().UTC(),
+		BlockNum:      num,
+		BlockID:       id,
+		BlockType:     string(proto.MessageName(block)),
+		Block:         content,
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s/%010d-%s", module, num, id)
+	if err := q.store.WriteObject(ctx, name, bytes.NewReader(encoded)); err != nil {
+		return nil, fmt.Errorf("write quarantine record %q: %w", name, err)
+	}
+	return record, nil
+}
+
+// List returns the records of `module`, of every module when empty, by block
+// number.
+func (q *Quarantine) List(ctx context.Context, module string) ([]*QuarantineRecord, error) {
+	prefix := ""
+	if module != "" {
+		prefix = module + "/"
+	}
+
+	var names []string
+	err := q.store.Walk(ctx, prefix, func(filename string) error {
+		names = append(names, filename)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list quarantine records: %w", err)
+	}
+
+	out := make([]*QuarantineRecord, 0, len(names))
+	for _, name := range names {
+		record, err := q.read(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, record)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].BlockNum != out[j].BlockNum {
+			return out[i].BlockNum < out[j].BlockNum
+		}
+		return out[i].Module < out[j].Module
+	})
+	return out, nil
+}
+
+func (q *Quarantine) read(ctx context.Context, name string) (*QuarantineRecord, error) {
+	object, err := q.store.OpenObject(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("open quarantine record %q: %w", name, err)
+	}
+	defer object.Close()
+
+	content, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("read quarantine record %q: %w", name, err)
+	}
+
+	record := &QuarantineRecord{}
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, fmt.Errorf("decode quarantine record %q: %w", name, err)
+	}
+	return record, nil
+}
@@ -0,0 +1,138 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// DefaultScanLimit is the number of keys a single `sdk.PrefixScan` may go
+// over, see `Pipeline.SetScanLimit`.
+const DefaultScanLimit = 100_000
+
+// keyLister is implemented by the states whose keys can be iterated, the
+// stores of a pipeline all are.
+type keyLister interface {
+	// Keys returns the keys starting with `prefix`, in no particular order.
+	Keys(prefix string) []string
+}
+
+// scanGuard holds the limit of the scans of a pipeline, shared by the views
+// of its stores.
+type scanGuard struct {
+	maxKeys int
+}
+
+// SetScanLimit sets the number of keys a single `sdk.PrefixScan` of a module
+// may go over, the scans over more keys fail with `sdk.ErrScanLimit` before
+// reading any value. It defaults to `DefaultScanLimit`.
+func (p *Pipeline) SetScanLimit(maxKeys int) error {
+	if maxKeys <= 0 {
+		return fmt.Errorf("scan limit must be positive, got %d", maxKeys)
+	}
+	p.scans.maxKeys = maxKeys
+	return nil
+}
+
+// PrefixScan iterates the keys of the upstream store as they were at the
+// start of the block: the keys created in the block are skipped, the ones
+// updated or deleted in the block are seen with their previous value.
+func (v readOnlyView) PrefixScan(prefix string, fn func(key string, value []byte) error) error {
+	start := time.Now()
+	defer func() { scanDuration.ObserveSince(start, v.module, v.store) }()
+
+	keys, first, err := v.state.firstKeys(prefix)
+	if err != nil {
+		return fmt.Errorf("store %q: %w", v.store, err)
+	}
+	if len(keys) > v.scans.maxKeys {
+		scansOverLimit.Inc(v.module, v.store)
+		return fmt.Errorf("store %q, prefix %q: %d keys over the limit of %d: %w", v.store, prefix, len(keys), v.scans.maxKeys, sdk.ErrScanLimit)
+	}
+
+	scans.Inc(v.module, v.store)
+	scannedKeys.AddInt(len(keys), v.module, v.store)
+	for _, key := range keys {
+		var value []byte
+		if delta, changed := first[key]; changed {
+			value = delta.OldValue
+		} else {
+			value, _ = v.state.State.Get(key)
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstKeys returns the keys starting with `prefix` at the start of the block,
+// sorted, along with the first change of the keys changed in the block, which
+// holds their value at its start.
+func (s *trackedState) firstKeys(prefix string) ([]string, map[string]*Delta, error) {
+	state := s.State
+	if bloom, ok := state.(*BloomState); ok {
+		state = bloom.State
+	}
+	lister, ok := state.(keyLister)
+	if !ok {
+		return nil, nil, sdk.ErrScanUnsupported
+	}
+
+	first := map[string]*Delta{}
+	for _, delta := range s.deltas {
+		if _, found := first[delta.Key]; !found && strings.HasPrefix(delta.Key, prefix) {
+			first[delta.Key] = delta
+		}
+	}
+
+	current := lister.Keys(prefix)
+	keys := make([]string, 0, len(current))
+	for _, key := range current {
+		if delta, changed := first[key]; !changed || delta.Operation != DeltaCreate {
+			keys = append(keys, key)
+		}
+	}
+	// the keys deleted in the block are gone from the state, not from its
+	// start
+	present := make(map[string]bool, len(current))
+	for _, key := range current {
+		present[key] = true
+	}
+	for key, delta := range first {
+		if !present[key] && delta.Operation != DeltaCreate {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys, first, nil
+}
+
+func (s *MemoryState) Keys(prefix string) (out []string) {
+	for key := range s.kv {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// Keys doesn't reload the spilled keys in memory, only their values are
+// spilled.
+func (s *SpillingState) Keys(prefix string) (out []string) {
+	for key := range s.hot {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, key)
+		}
+	}
+	for key := range s.cold {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
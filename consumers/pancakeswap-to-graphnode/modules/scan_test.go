@@ -0,0 +1,92 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// test_items creates `item:<n>` at block n, updates the item of the previous
+	// block and deletes the one before
+	Register(&Module{
+		Name: "test_items",
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			return block.Number, nil
+		},
+		Store: func(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, state State) error {
+			n := output.(uint64)
+			state.Set(fmt.Sprintf("item:%d", n), []byte(strconv.FormatUint(n, 10)))
+			state.Set(fmt.Sprintf("other:%d", n), []byte("other"))
+			if n > 1 {
+				state.Set(fmt.Sprintf("item:%d", n-1), []byte("updated"))
+			}
+			if n > 2 {
+				state.Delete(fmt.Sprintf("item:%d", n-2))
+			}
+			return nil
+		},
+	})
+
+	Register(&Module{
+		Name:   "test_scanner",
+		Inputs: []Input{{Module: "test_items", Mode: InputGet}},
+		Map: func(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+			store, err := intr.Store("test_items")
+			if err != nil {
+				return nil, err
+			}
+
+			var out []string
+			err = sdk.PrefixScan(store, "item:", func(key string, value []byte) error {
+				out = append(out, key+"="+string(value))
+				return nil
+			})
+			return out, err
+		},
+	})
+}
+
+func TestPipeline_PrefixScan(t *testing.T) {
+	for _, budget := range []int64{0, 32} {
+		t.Run(fmt.Sprintf("budget %d", budget), func(t *testing.T) {
+			p, err := NewPipeline("test_scanner")
+			require.NoError(t, err)
+			defer p.Close()
+			if budget > 0 {
+				require.NoError(t, p.SetMemoryBudget(t.TempDir(), budget))
+			}
+
+			expected := [][]string{
+				nil,
+				{"item:1=1"},
+				{"item:1=updated", "item:2=2"},
+				{"item:2=updated", "item:3=3"},
+			}
+			for i, scanned := range expected {
+				out, err := p.ProcessBlock(&pbeth.Block{Number: uint64(i + 1)})
+				require.NoError(t, err)
+				assert.Equal(t, scanned, out.Outputs["test_scanner"], "block %d", i+1)
+			}
+
+			require.NoError(t, p.SetScanLimit(1))
+			_, err = p.ProcessBlock(&pbeth.Block{Number: 5})
+			assert.ErrorIs(t, err, sdk.ErrScanLimit)
+		})
+	}
+}
+
+// unlistedState hides the keys of its state
+type unlistedState struct {
+	State
+}
+
+func TestPrefixScan_Unsupported(t *testing.T) {
+	err := sdk.PrefixScan(readOnlyView{state: newTrackedState(unlistedState{NewMemoryState()})}, "", nil)
+	assert.ErrorIs(t, err, sdk.ErrScanUnsupported)
+}
@@ -0,0 +1,230 @@
+package modules
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// entryOverhead approximates the memory used by a hot key besides its key and
+// value bytes, map bucket and list element.
+const entryOverhead = 96
+
+// compactionThreshold is the amount of dead bytes in the overflow file above
+// which it's rewritten, when they also are the majority of the file.
+var compactionThreshold int64 = 64 * 1024 * 1024
+
+// SpillStats counts the spill activity of a `SpillingState`.
+type SpillStats struct {
+	HotKeys      int   `json:"hot_keys"`
+	HotBytes     int64 `json:"hot_bytes"`
+	ColdKeys     int   `json:"cold_keys"`
+	Spills       int64 `json:"spills"`
+	SpilledBytes int64 `json:"spilled_bytes"`
+	Reloads      int64 `json:"reloads"`
+	Compactions  int64 `json:"compactions"`
+}
+
+// SpillingState is a `State` holding at most `budget` bytes of keys and values
+// in memory. When over budget, the least recently used keys are spilled to an
+// overflow file and transparently reloaded in memory when accessed again. Only
+// the keys and the position of their value in the file are kept in memory for
+// spilled keys.
+//
+// The overflow file is scratch space, it's removed by `Close` and is not meant
+// to survive restarts.
+type SpillingState struct {
+	budget int64
+
+	lru  *list.List
+	hot  map[string]*list.Element
+	cold map[string]coldEntry
+
+	file     *os.File
+	fileSize int64
+	garbage  int64
+
+	stats SpillStats
+}
+
+type hotEntry struct {
+	key   string
+	value []byte
+}
+
+type coldEntry struct {
+	offset int64
+	length int
+}
+
+// NewSpillingState creates an empty state whose overflow file is created in
+// `dir`.
+func NewSpillingState(dir string, budget int64) (*SpillingState, error) {
+	if budget <= 0 {
+		return nil, fmt.Errorf("memory budget must be positive, got %d", budget)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create spill directory %q: %w", dir, err)
+	}
+
+	file, err := os.CreateTemp(dir, "spill-*.bin")
+	if err != nil {
+		return nil, fmt.Errorf("create spill file: %w", err)
+	}
+
+	return &SpillingState{
+		budget: budget,
+		lru:    list.New(),
+		hot:    map[string]*list.Element{},
+		cold:   map[string]coldEntry{},
+		file:   file,
+	}, nil
+}
+
+// Get panics when a spilled value cannot be read back, the `State` interface has
+// no room for errors and a store missing values would silently corrupt outputs.
+func (s *SpillingState) Get(key string) ([]byte, bool) {
+	if element, found := s.hot[key]; found {
+		s.lru.MoveToFront(element)
+		return element.Value.(*hotEntry).value, true
+	}
+
+	entry, found := s.cold[key]
+	if !found {
+		return nil, false
+	}
+
+	value := make([]byte, entry.length)
+	if _, err := s.file.ReadAt(value, entry.offset); err != nil {
+		panic(fmt.Errorf("reading spilled key %q: %w", key, err))
+	}
+
+	delete(s.cold, key)
+	s.garbage += int64(entry.length)
+	s.stats.Reloads++
+
+	s.insertHot(key, value)
+	s.evict()
+	return value, true
+}
+
+func (s *SpillingState) Set(key string, value []byte) {
+	if entry, found := s.cold[key]; found {
+		delete(s.cold, key)
+		s.garbage += int64(entry.length)
+	}
+
+	if element, found := s.hot[key]; found {
+		hot := element.Value.(*hotEntry)
+		s.stats.HotBytes += int64(len(value) - len(hot.value))
+		hot.value = value
+		s.lru.MoveToFront(element)
+	} else {
+		s.insertHot(key, value)
+	}
+
+	s.evict()
+}
+
+func (s *SpillingState) Delete(key string) {
+	if entry, found := s.cold[key]; found {
+		delete(s.cold, key)
+		s.garbage += int64(entry.length)
+		return
+	}
+
+	if element, found := s.hot[key]; found {
+		s.removeHot(element)
+	}
+}
+
+func (s *SpillingState) Len() int {
+	return len(s.hot) + len(s.cold)
+}
+
+func (s *SpillingState) Stats() SpillStats {
+	stats := s.stats
+	stats.HotKeys = len(s.hot)
+	stats.ColdKeys = len(s.cold)
+	return stats
+}
+
+// Close removes the overflow file, the state must not be used afterwards.
+func (s *SpillingState) Close() error {
+	path := s.file.Name()
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("close spill file: %w", err)
+	}
+	return os.Remove(path)
+}
+
+func (s *SpillingState) insertHot(key string, value []byte) {
+	s.hot[key] = s.lru.PushFront(&hotEntry{key: key, value: value})
+	s.stats.HotBytes += entrySize(key, value)
+}
+
+func (s *SpillingState) removeHot(element *list.Element) {
+	hot := element.Value.(*hotEntry)
+	s.lru.Remove(element)
+	delete(s.hot, hot.key)
+	s.stats.HotBytes -= entrySize(hot.key, hot.value)
+}
+
+// evict spills the least recently used keys until the state is within budget,
+// the most recently used key always stays in memory.
+func (s *SpillingState) evict() {
+	for s.stats.HotBytes > s.budget && s.lru.Len() > 1 {
+		element := s.lru.Back()
+		hot := element.Value.(*hotEntry)
+
+		if _, err := s.file.WriteAt(hot.value, s.fileSize); err != nil {
+			panic(fmt.Errorf("spilling key %q: %w", hot.key, err))
+		}
+
+		s.cold[hot.key] = coldEntry{offset: s.fileSize, length: len(hot.value)}
+		s.fileSize += int64(len(hot.value))
+		s.stats.Spills++
+		s.stats.SpilledBytes += int64(len(hot.value))
+		s.removeHot(element)
+	}
+
+	if s.garbage > compactionThreshold && s.garbage > s.fileSize/2 {
+		s.compact()
+	}
+}
+
+// compact rewrites the overflow file with only the live values.
+func (s *SpillingState) compact() {
+	compacted, err := os.CreateTemp(filepath.Dir(s.file.Name()), "spill-*.bin")
+	if err != nil {
+		panic(fmt.Errorf("create compacted spill file: %w", err))
+	}
+
+	var offset int64
+	for key, entry := range s.cold {
+		value := make([]byte, entry.length)
+		if _, err := s.file.ReadAt(value, entry.offset); err != nil {
+			panic(fmt.Errorf("reading spilled key %q: %w", key, err))
+		}
+		if _, err := compacted.WriteAt(value, offset); err != nil {
+			panic(fmt.Errorf("compacting spilled key %q: %w", key, err))
+		}
+		s.cold[key] = coldEntry{offset: offset, length: entry.length}
+		offset += int64(entry.length)
+	}
+
+	old := s.file.Name()
+	s.file.Close()
+	os.Remove(old)
+
+	s.file = compacted
+	s.fileSize = offset
+	s.garbage = 0
+	s.stats.Compactions++
+}
+
+func entrySize(key string, value []byte) int64 {
+	return int64(len(key) + len(value) + entryOverhead)
+}
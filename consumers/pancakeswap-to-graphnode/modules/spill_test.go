@@ -0,0 +1,112 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillingState(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpillingState(dir, 10*(entryOverhead+11))
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("key:%02d", i), []byte(fmt.Sprintf("val%d", i)))
+	}
+
+	stats := s.Stats()
+	assert.Equal(t, 100, s.Len())
+	assert.LessOrEqual(t, stats.HotBytes, int64(10*(entryOverhead+11)))
+	assert.Equal(t, 10, stats.HotKeys)
+	assert.Equal(t, 90, stats.ColdKeys)
+	assert.Equal(t, int64(90), stats.Spills)
+
+	// cold keys are reloaded, pushing the least recently used ones out
+	value, found := s.Get("key:00")
+	require.True(t, found)
+	assert.Equal(t, "val0", string(value))
+	assert.Equal(t, int64(1), s.Stats().Reloads)
+	assert.Equal(t, 90, s.Stats().ColdKeys)
+
+	s.Set("key:01", []byte("updated"))
+	s.Delete("key:02")
+	s.Delete("key:99")
+
+	tests := []struct {
+		key           string
+		expectedValue string
+		expectedFound bool
+	}{
+		{"key:00", "val0", true},
+		{"key:01", "updated", true},
+		{"key:02", "", false},
+		{"key:50", "val50", true},
+		{"key:98", "val98", true},
+		{"key:99", "", false},
+		{"unknown", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			value, found := s.Get(test.key)
+			assert.Equal(t, test.expectedFound, found)
+			assert.Equal(t, test.expectedValue, string(value))
+		})
+	}
+
+	assert.Equal(t, 98, s.Len())
+
+	require.NoError(t, s.Close())
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestSpillingState_Compaction(t *testing.T) {
+	defer func(threshold int64) { compactionThreshold = threshold }(compactionThreshold)
+	compactionThreshold = 100
+
+	s, err := NewSpillingState(t.TempDir(), entryOverhead+20)
+	require.NoError(t, err)
+	defer s.Close()
+
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 20; i++ {
+			s.Set(fmt.Sprintf("key:%02d", i), []byte(fmt.Sprintf("round%d", round)))
+		}
+	}
+
+	assert.Greater(t, s.Stats().Compactions, int64(0))
+	for i := 0; i < 20; i++ {
+		value, found := s.Get(fmt.Sprintf("key:%02d", i))
+		require.True(t, found)
+		assert.Equal(t, "round4", string(value))
+	}
+}
+
+func TestPipeline_MemoryBudget(t *testing.T) {
+	p, err := NewPipeline("test_reader")
+	require.NoError(t, err)
+	require.NoError(t, p.SetMemoryBudget(t.TempDir(), 1))
+	defer p.Close()
+
+	for num := uint64(10); num < 20; num++ {
+		out, err := p.ProcessBlock(&pbeth.Block{Number: num})
+		require.NoError(t, err)
+		if num > 10 {
+			assert.Equal(t, []string{fmt.Sprint(num - 1), fmt.Sprint(num), "2"}, out.Outputs["test_reader"])
+		}
+	}
+
+	stats := p.SpillStats()
+	require.Contains(t, stats, "test_counter")
+	assert.Greater(t, stats["test_counter"].Spills, int64(0))
+	assert.Greater(t, stats["test_counter"].Reloads, int64(0))
+
+	require.Error(t, p.SetMemoryBudget(t.TempDir(), 1), "stores are not empty anymore")
+}
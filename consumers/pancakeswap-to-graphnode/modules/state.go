@@ -0,0 +1,122 @@
+package modules
+
+import (
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// State is the key/value storage a module's state builder writes to.
+type State = sdk.Store
+
+type MemoryState struct {
+	kv map[string][]byte
+}
+
+func NewMemoryState() *MemoryState {
+	return &MemoryState{kv: map[string][]byte{}}
+}
+
+func (s *MemoryState) Get(key string) ([]byte, bool) {
+	value, found := s.kv[key]
+	return value, found
+}
+
+func (s *MemoryState) Set(key string, value []byte) {
+	s.kv[key] = value
+}
+
+func (s *MemoryState) Delete(key string) {
+	delete(s.kv, key)
+}
+
+func (s *MemoryState) Len() int {
+	return len(s.kv)
+}
+
+type (
+	DeltaOperation = sdk.DeltaOperation
+	Delta          = sdk.Delta
+)
+
+const (
+	DeltaCreate = sdk.DeltaCreate
+	DeltaUpdate = sdk.DeltaUpdate
+	DeltaDelete = sdk.DeltaDelete
+)
+
+// trackedState records the changes made to a state during a block, it backs the
+// views and deltas given to downstream modules.
+type trackedState struct {
+	State
+	deltas []*Delta
+}
+
+func newTrackedState(state State) *trackedState {
+	return &trackedState{State: state}
+}
+
+func (s *trackedState) Set(key string, value []byte) {
+	old, found := s.State.Get(key)
+	delta := &Delta{Operation: DeltaCreate, Key: key, NewValue: value}
+	if found {
+		delta.Operation = DeltaUpdate
+		delta.OldValue = old
+	}
+
+	s.State.Set(key, value)
+	s.deltas = append(s.deltas, delta)
+}
+
+func (s *trackedState) Delete(key string) {
+	old, found := s.State.Get(key)
+	if !found {
+		return
+	}
+
+	s.State.Delete(key)
+	s.deltas = append(s.deltas, &Delta{Operation: DeltaDelete, Key: key, OldValue: old})
+}
+
+func (s *trackedState) GetLast(key string) ([]byte, bool) {
+	return s.State.Get(key)
+}
+
+func (s *trackedState) GetFirst(key string) ([]byte, bool) {
+	for _, delta := range s.deltas {
+		if delta.Key == key {
+			return delta.OldValue, delta.Operation != DeltaCreate
+		}
+	}
+	return s.State.Get(key)
+}
+
+// reset starts a new block.
+func (s *trackedState) reset() {
+	s.deltas = nil
+}
+
+// rollback undoes the changes made during the block, last first, for a module
+// failing halfway through its writes to be run again or skipped.
+func (s *trackedState) rollback() {
+	for i := len(s.deltas) - 1; i >= 0; i-- {
+		delta := s.deltas[i]
+		if delta.Operation == DeltaCreate {
+			s.State.Delete(delta.Key)
+			continue
+		}
+		s.State.Set(delta.Key, delta.OldValue)
+	}
+	s.deltas = nil
+}
+
+// readOnlyView prevents downstream modules from using type assertions to write
+// into a store they only read.
+type readOnlyView struct {
+	state *trackedState
+	// module reads `store`, they label the metrics of the scans
+	module string
+	store  string
+	scans  *scanGuard
+}
+
+func (v readOnlyView) GetLast(key string) ([]byte, bool)  { return v.state.GetLast(key) }
+func (v readOnlyView) GetFirst(key string) ([]byte, bool) { return v.state.GetFirst(key) }
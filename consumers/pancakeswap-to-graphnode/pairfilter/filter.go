@@ -0,0 +1,231 @@
+package pairfilter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var addressRegex = regexp.MustCompile(`^0x[0-9a-f]{40}$`)
+
+// Filter holds the pairs and tokens left out of the modules, like scam or
+// fee-on-transfer pairs whose prices and volumes are meaningless. The modules
+// skip them themselves, the lists are pushed to them as module parameters,
+// see `Params` and the `modparams` package.
+//
+// A pair is excluded when its address or one of its tokens is blocked or,
+// when an allow list is set, when none of them is allowed.
+//
+// Lists come from flags and, optionally, from a file that is reloaded whenever
+// it changes, see `Watch`.
+type Filter struct {
+	path        string
+	staticAllow []string
+	staticBlock []string
+
+	lock    sync.RWMutex
+	allow   map[string]bool
+	block   map[string]bool
+	modTime time.Time
+}
+
+// New creates a filter from the given lists and, when `path` is not empty, the
+// file at `path`. The file holds one entry per line, in the form `allow <address>`
+// or `block <address>`, blank lines and lines starting with `#` are ignored.
+func New(path string, allow, block []string) (*Filter, error) {
+	f := &Filter{
+		path:        path,
+		staticAllow: allow,
+		staticBlock: block,
+	}
+
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reload re-reads the lists file when it changed since the last load, it
+// returns true when the lists were reloaded.
+func (f *Filter) Reload() (bool, error) {
+	f.lock.RLock()
+	staticAllow, staticBlock := f.staticAllow, f.staticBlock
+	f.lock.RUnlock()
+
+	allow := map[string]bool{}
+	block := map[string]bool{}
+	for _, addr := range staticAllow {
+		if err := addAddress(allow, addr); err != nil {
+			return false, err
+		}
+	}
+	for _, addr := range staticBlock {
+		if err := addAddress(block, addr); err != nil {
+			return false, err
+		}
+	}
+
+	var modTime time.Time
+	if f.path != "" {
+		stat, err := os.Stat(f.path)
+		if err != nil {
+			return false, fmt.Errorf("stat pair filter file %q: %w", f.path, err)
+		}
+
+		f.lock.RLock()
+		unchanged := !f.modTime.IsZero() && stat.ModTime().Equal(f.modTime)
+		f.lock.RUnlock()
+		if unchanged {
+			return false, nil
+		}
+
+		modTime = stat.ModTime()
+		if err := readFile(f.path, allow, block); err != nil {
+			return false, err
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.allow = allow
+	f.block = block
+	f.modTime = modTime
+
+	return true, nil
+}
+
+// SetLists replaces the lists given to `New`, entries of the lists file are
+// kept. Lists holding an invalid address are refused.
+func (f *Filter) SetLists(allow, block []string) error {
+	if err := ValidateList(append(append([]string(nil), allow...), block...)); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	f.staticAllow, f.staticBlock = allow, block
+	f.modTime = time.Time{}
+	f.lock.Unlock()
+
+	_, err := f.Reload()
+	return err
+}
+
+// Watch reloads the lists file every `interval` until `ctx` is done. Reload
+// errors are logged and the previous lists are kept.
+func (f *Filter) Watch(ctx context.Context, interval time.Duration) {
+	if f.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := f.Reload()
+			if err != nil {
+				zlog.Warn("reloading pair filter, keeping previous lists", zap.String("path", f.path), zap.Error(err))
+				continue
+			}
+
+			if reloaded {
+				f.lock.RLock()
+				zlog.Info("pair filter reloaded", zap.String("path", f.path), zap.Int("allowed", len(f.allow)), zap.Int("blocked", len(f.block)))
+				f.lock.RUnlock()
+			}
+		}
+	}
+}
+
+// Params returns the lists as the module parameters read by the modules, the
+// addresses of each list sorted and comma separated.
+func (f *Filter) Params() map[string]string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return map[string]string{
+		"allow-pair": joinSorted(f.allow),
+		"block-pair": joinSorted(f.block),
+	}
+}
+
+func joinSorted(set map[string]bool) string {
+	list := make([]string, 0, len(set))
+	for addr := range set {
+		list = append(list, addr)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// ValidateList returns an error when one of `list` isn't an address.
+func ValidateList(list []string) error {
+	for _, addr := range list {
+		if err := addAddress(map[string]bool{}, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addAddress(list map[string]bool, addr string) error {
+	lower := strings.ToLower(addr)
+	if !addressRegex.MatchString(lower) {
+		return fmt.Errorf("invalid address %q", addr)
+	}
+	list[lower] = true
+	return nil
+}
+
+func readFile(path string, allow, block map[string]bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open pair filter file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.Fields(text)
+		if len(parts) != 2 {
+			return fmt.Errorf("%s:%d: expected '<allow|block> <address>', got %q", path, line, text)
+		}
+
+		addr := strings.ToLower(parts[1])
+		if !addressRegex.MatchString(addr) || len(addr) != 42 {
+			return fmt.Errorf("%s:%d: invalid address %q", path, line, parts[1])
+		}
+
+		switch parts[0] {
+		case "allow":
+			allow[addr] = true
+		case "block":
+			block[addr] = true
+		default:
+			return fmt.Errorf("%s:%d: unknown list %q, expected 'allow' or 'block'", path, line, parts[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read pair filter file %q: %w", path, err)
+	}
+
+	return nil
+}
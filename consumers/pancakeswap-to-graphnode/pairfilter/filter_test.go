@@ -0,0 +1,97 @@
+package pairfilter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	goodPair = "0x58f876857a02d6762e0101bb5c46a8c1ed44dc16"
+	scamPair = "0x1111111111111111111111111111111111111111"
+	token    = "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c"
+)
+
+func TestFilter_Params(t *testing.T) {
+	checksummed := "0xBB4CDB9CBD36B01BD1CBAEBF2DE08D9173BC095C"
+
+	tests := []struct {
+		name     string
+		allow    []string
+		block    []string
+		expected map[string]string
+	}{
+		{"no lists", nil, nil, map[string]string{"allow-pair": "", "block-pair": ""}},
+		{"sorted", nil, []string{token, scamPair}, map[string]string{"allow-pair": "", "block-pair": scamPair + "," + token}},
+		{"lowercased", []string{goodPair}, []string{checksummed}, map[string]string{"allow-pair": goodPair, "block-pair": token}},
+		{"deduplicated", nil, []string{token, checksummed}, map[string]string{"allow-pair": "", "block-pair": token}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := New("", test.allow, test.block)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, f.Params())
+		})
+	}
+}
+
+func TestNew_InvalidAddress(t *testing.T) {
+	_, err := New("", nil, []string{goodPair + "ff"})
+	require.Error(t, err)
+
+	_, err = New("", []string{"pancake"}, nil)
+	require.Error(t, err)
+}
+
+func TestFilter_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pairs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# scam pairs\nblock "+scamPair+"\n"), 0644))
+
+	f, err := New(path, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, scamPair, f.Params()["block-pair"])
+
+	reloaded, err := f.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("allow "+goodPair+"\n"), 0644))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	reloaded, err = f.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, map[string]string{"allow-pair": goodPair, "block-pair": ""}, f.Params())
+}
+
+func TestFilter_SetLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pairs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("block "+scamPair+"\n"), 0644))
+
+	f, err := New(path, nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, f.SetLists(nil, []string{goodPair}))
+	assert.Equal(t, scamPair+","+goodPair, f.Params()["block-pair"], "file entries are kept")
+
+	require.Error(t, f.SetLists(nil, []string{"pancake"}))
+	assert.Equal(t, scamPair+","+goodPair, f.Params()["block-pair"], "invalid lists are refused")
+
+	require.NoError(t, f.SetLists(nil, nil))
+	assert.Equal(t, scamPair, f.Params()["block-pair"])
+}
+
+func TestFilter_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pairs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("deny "+scamPair+"\n"), 0644))
+
+	_, err := New(path, nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,7 @@
+package pairfilter
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.pairfilter", "github.com/streamingfast/substream-pancakeswap/pairfilter")
@@ -0,0 +1,279 @@
+// Package pairstats tracks the processing of every pair, its events, store
+// deltas and a share of the processing time, across runs, for the hottest
+// pairs to be listed: the candidates for the blacklist, the pairs a sharding
+// key must spread and where optimizing pays off.
+package pairstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// The modules whose outputs the stats are counted from, added to the output
+// modules of a run tracking them.
+const (
+	PairsModule  = "map_pairs"
+	EventsModule = "map_burn_swaps_events"
+)
+
+var addressRegex = regexp.MustCompile(`0x[0-9a-f]{40}`)
+
+// Stats accumulates the processing statistics of the pairs over the blocks
+// observed, saved to a file and loaded back for the next run to add to them.
+type Stats struct {
+	FirstBlock uint64                `json:"first_block"`
+	LastBlock  uint64                `json:"last_block"`
+	Blocks     uint64                `json:"blocks"`
+	Pairs      map[string]*PairStats `json:"pairs"`
+}
+
+// PairStats is what processing a pair took.
+type PairStats struct {
+	// Events are the `pcs.types.v1.Events` of the pair, Swaps among them.
+	Events uint64 `json:"events"`
+	Swaps  uint64 `json:"swaps"`
+	// Deltas are the store deltas whose key starts with the pair address,
+	// once past its prefix, like `reserve:<pair>:0`.
+	Deltas uint64 `json:"deltas"`
+	// Time is the share of the processing time of the blocks the pair got,
+	// each block's split between its pairs by their events and deltas.
+	Time time.Duration `json:"time_ns"`
+}
+
+func (p *PairStats) work() uint64 {
+	return p.Events + p.Deltas
+}
+
+func New() *Stats {
+	return &Stats{Pairs: map[string]*PairStats{}}
+}
+
+// Load reads the stats saved to `path` by `Save`, empty stats when there's no
+// such file.
+func Load(path string) (*Stats, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pair stats: %w", err)
+	}
+
+	s := New()
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("decode pair stats %q: %w", path, err)
+	}
+	if s.Pairs == nil {
+		s.Pairs = map[string]*PairStats{}
+	}
+	return s, nil
+}
+
+// Save writes the stats to `path` through a rename.
+func (s *Stats) Save(path string) error {
+	content, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshal pair stats: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("write pair stats: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Observe adds the outputs of a block, which took `spent` to process, to the
+// stats. Events are counted from the `pcs.types.v1.Events` map outputs, pairs
+// are known from them and from the `pcs.types.v1.Pairs` ones, of
+// `EventsModule` and `PairsModule`. Undone blocks are not counted.
+// A nil `Stats` observes nothing.
+func (s *Stats) Observe(data *pbsubstreams.BlockScopedData, spent time.Duration) {
+	if s == nil || data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		return
+	}
+
+	num := data.Clock.GetNumber()
+	if s.Blocks == 0 {
+		s.FirstBlock = num
+	}
+	s.LastBlock = num
+	s.Blocks++
+
+	// map outputs first, for the deltas of pairs created in the block to be
+	// counted
+	block := map[string]*PairStats{}
+	for _, output := range data.Outputs {
+		if mapOutput := output.GetMapOutput(); mapOutput != nil {
+			s.observeMapOutput(mapOutput, block)
+		}
+	}
+	for _, output := range data.Outputs {
+		for _, delta := range output.GetStoreDeltas().GetDeltas() {
+			if pair := s.deltaPair(delta.Key); pair != "" {
+				blockPair(block, pair).Deltas++
+			}
+		}
+	}
+
+	var work uint64
+	for _, stats := range block {
+		work += stats.work()
+	}
+	for pair, stats := range block {
+		total := s.pair(pair)
+		total.Events += stats.Events
+		total.Swaps += stats.Swaps
+		total.Deltas += stats.Deltas
+		if work > 0 {
+			total.Time += time.Duration(uint64(spent) * stats.work() / work)
+		}
+	}
+}
+
+func (s *Stats) observeMapOutput(output *anypb.Any, block map[string]*PairStats) {
+	msg, err := anypb.UnmarshalNew(output, proto.UnmarshalOptions{})
+	if err != nil {
+		return
+	}
+
+	switch m := msg.(type) {
+	case *pbpcs.Pairs:
+		for _, pair := range m.Pairs {
+			s.pair(strings.ToLower(pair.Address))
+		}
+	case *pbpcs.Events:
+		for _, event := range m.Events {
+			if event.PairAddress == "" {
+				continue
+			}
+
+			pair := strings.ToLower(event.PairAddress)
+			s.pair(pair)
+			stats := blockPair(block, pair)
+			stats.Events++
+			if event.GetSwap() != nil {
+				stats.Swaps++
+			}
+		}
+	}
+}
+
+// deltaPair returns the known pair whose address comes first in `key`, past
+// its prefix.
+func (s *Stats) deltaPair(key string) string {
+	address := addressRegex.FindString(strings.ToLower(key))
+	if _, found := s.Pairs[address]; !found {
+		return ""
+	}
+	return address
+}
+
+func (s *Stats) pair(address string) *PairStats {
+	stats, found := s.Pairs[address]
+	if !found {
+		stats = &PairStats{}
+		s.Pairs[address] = stats
+	}
+	return stats
+}
+
+func blockPair(block map[string]*PairStats, address string) *PairStats {
+	stats, found := block[address]
+	if !found {
+		stats = &PairStats{}
+		block[address] = stats
+	}
+	return stats
+}
+
+// Order ranks the pairs of a report.
+type Order string
+
+const (
+	ByTime   Order = "time"
+	ByEvents Order = "events"
+	BySwaps  Order = "swaps"
+	ByDeltas Order = "deltas"
+)
+
+func ParseOrder(in string) (Order, error) {
+	switch order := Order(in); order {
+	case ByTime, ByEvents, BySwaps, ByDeltas:
+		return order, nil
+	}
+	return "", fmt.Errorf("invalid order %q, expected 'time', 'events', 'swaps' or 'deltas'", in)
+}
+
+func (o Order) value(stats *PairStats) uint64 {
+	switch o {
+	case ByEvents:
+		return stats.Events
+	case BySwaps:
+		return stats.Swaps
+	case ByDeltas:
+		return stats.Deltas
+	}
+	return uint64(stats.Time)
+}
+
+// HotPair is a pair of a report, `Share` is its part of the total of the
+// order of the report, over all pairs.
+type HotPair struct {
+	Address string  `json:"address"`
+	Share   float64 `json:"share"`
+	*PairStats
+}
+
+// Top returns the `n` pairs ranking first by `order`, all of them when `n` is
+// 0. Ties are broken by address.
+func (s *Stats) Top(n int, order Order) []*HotPair {
+	var total uint64
+	pairs := make([]*HotPair, 0, len(s.Pairs))
+	for address, stats := range s.Pairs {
+		total += order.value(stats)
+		pairs = append(pairs, &HotPair{Address: address, PairStats: stats})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		vi, vj := order.value(pairs[i].PairStats), order.value(pairs[j].PairStats)
+		if vi != vj {
+			return vi > vj
+		}
+		return pairs[i].Address < pairs[j].Address
+	})
+	if n > 0 && len(pairs) > n {
+		pairs = pairs[:n]
+	}
+
+	if total > 0 {
+		for _, pair := range pairs {
+			pair.Share = float64(order.value(pair.PairStats)) / float64(total)
+		}
+	}
+	return pairs
+}
+
+// PrintTop writes the hottest pairs as a table.
+func (s *Stats) PrintTop(w io.Writer, n int, order Order) error {
+	fmt.Fprintf(w, "Pairs by %s, %d blocks (#%d to #%d), %d pairs\n\n", order, s.Blocks, s.FirstBlock, s.LastBlock, len(s.Pairs))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "#\tPair\tShare\tEvents\tSwaps\tDeltas\tTime\t\n")
+	for i, pair := range s.Top(n, order) {
+		fmt.Fprintf(tw, "%d\t%s\t%.1f%%\t%d\t%d\t%d\t%s\t\n", i+1, pair.Address, pair.Share*100, pair.Events, pair.Swaps, pair.Deltas, pair.Time.Round(time.Microsecond))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,143 @@
+package pairstats
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	hotPair  = "0x58f876857a02d6762e0101bb5c46a8c1ed44dc16"
+	coldPair = "0x1111111111111111111111111111111111111111"
+	token    = "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c"
+)
+
+func mapOutput(t *testing.T, name string, msg proto.Message) *pbsubstreams.ModuleOutput {
+	t.Helper()
+	any, err := anypb.New(msg)
+	require.NoError(t, err)
+	return &pbsubstreams.ModuleOutput{Name: name, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: any}}
+}
+
+func deltasOutput(name string, keys ...string) *pbsubstreams.ModuleOutput {
+	deltas := &pbsubstreams.StoreDeltas{}
+	for _, key := range keys {
+		deltas.Deltas = append(deltas.Deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key})
+	}
+	return &pbsubstreams.ModuleOutput{Name: name, Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: deltas}}
+}
+
+func swap(pair string) *pbpcs.Event {
+	return &pbpcs.Event{PairAddress: pair, Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{}}}
+}
+
+func observe(t *testing.T, s *Stats) {
+	t.Helper()
+
+	s.Observe(&pbsubstreams.BlockScopedData{
+		Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Clock: &pbsubstreams.Clock{Number: 100},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			deltasOutput("store_pairs", "pair:"+coldPair),
+			mapOutput(t, "map_pairs", &pbpcs.Pairs{Pairs: []*pbpcs.Pair{{Address: coldPair, Token0Address: token}}}),
+		},
+	}, 10*time.Millisecond)
+	s.Observe(&pbsubstreams.BlockScopedData{
+		Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Clock: &pbsubstreams.Clock{Number: 101},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			mapOutput(t, "map_events", &pbpcs.Events{Events: []*pbpcs.Event{
+				swap(hotPair),
+				swap(hotPair),
+				{PairAddress: hotPair, Type: &pbpcs.Event_Mint{Mint: &pbpcs.Mint{}}},
+				swap(coldPair),
+			}}),
+			// the token isn't a pair, its delta isn't counted
+			deltasOutput("store_reserves", "reserve:"+hotPair+":0", "reserve:"+hotPair+":1", "price:"+token),
+		},
+	}, 80*time.Millisecond)
+	// undone blocks are ignored
+	s.Observe(&pbsubstreams.BlockScopedData{
+		Step:    pbsubstreams.ForkStep_STEP_UNDO,
+		Clock:   &pbsubstreams.Clock{Number: 101},
+		Outputs: []*pbsubstreams.ModuleOutput{mapOutput(t, "map_events", &pbpcs.Events{Events: []*pbpcs.Event{swap(hotPair)}})},
+	}, time.Second)
+}
+
+func TestStats_Observe(t *testing.T) {
+	s := New()
+	observe(t, s)
+
+	assert.Equal(t, uint64(100), s.FirstBlock)
+	assert.Equal(t, uint64(101), s.LastBlock)
+	assert.Equal(t, uint64(2), s.Blocks)
+	assert.Equal(t, map[string]*PairStats{
+		hotPair:  {Events: 3, Swaps: 2, Deltas: 2, Time: 66666666},
+		coldPair: {Events: 1, Swaps: 1, Deltas: 1, Time: 23333333},
+	}, s.Pairs)
+
+	var nilStats *Stats
+	nilStats.Observe(&pbsubstreams.BlockScopedData{}, time.Second)
+}
+
+func TestStats_Top(t *testing.T) {
+	s := New()
+	observe(t, s)
+
+	top := s.Top(0, ByTime)
+	require.Len(t, top, 2)
+	assert.Equal(t, hotPair, top[0].Address)
+	assert.InDelta(t, 0.74, top[0].Share, 0.001)
+
+	top = s.Top(1, BySwaps)
+	require.Len(t, top, 1)
+	assert.Equal(t, hotPair, top[0].Address)
+
+	// ties are broken by address
+	top = s.Top(0, ByDeltas)
+	assert.Equal(t, hotPair, top[0].Address)
+	s.Pairs[coldPair].Deltas = 2
+	top = s.Top(0, ByDeltas)
+	assert.Equal(t, coldPair, top[0].Address)
+	assert.Equal(t, 0.5, top[0].Share)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, s.PrintTop(out, 1, ByEvents))
+	assert.Contains(t, out.String(), "Pairs by events, 2 blocks (#100 to #101), 2 pairs")
+	assert.Contains(t, out.String(), hotPair)
+	assert.NotContains(t, out.String(), coldPair)
+
+	_, err := ParseOrder("volume")
+	assert.EqualError(t, err, `invalid order "volume", expected 'time', 'events', 'swaps' or 'deltas'`)
+}
+
+func TestStats_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pair-stats.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	observe(t, s)
+	require.NoError(t, s.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, s, loaded)
+
+	// the next run adds to the stats
+	loaded.Observe(&pbsubstreams.BlockScopedData{
+		Step:    pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Clock:   &pbsubstreams.Clock{Number: 200},
+		Outputs: []*pbsubstreams.ModuleOutput{mapOutput(t, "map_events", &pbpcs.Events{Events: []*pbpcs.Event{swap(coldPair)}})},
+	}, time.Millisecond)
+	assert.Equal(t, uint64(100), loaded.FirstBlock)
+	assert.Equal(t, uint64(3), loaded.Blocks)
+	assert.Equal(t, uint64(2), loaded.Pairs[coldPair].Swaps)
+}
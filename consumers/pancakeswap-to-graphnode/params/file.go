@@ -0,0 +1,75 @@
+package params
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// File stages the values of a JSON file, an object of param names to string
+// values like `{"block-pair": "0xa...,0xb...", "replay-speed": "2"}`, whenever
+// the file changes.
+type File struct {
+	path     string
+	registry *Registry
+	modTime  time.Time
+}
+
+func NewFile(path string, registry *Registry) *File {
+	return &File{path: path, registry: registry}
+}
+
+// Load stages the values of the file when it changed since the last load, it
+// returns true when they were staged.
+func (f *File) Load() (bool, error) {
+	stat, err := os.Stat(f.path)
+	if err != nil {
+		return false, fmt.Errorf("stat params file %q: %w", f.path, err)
+	}
+	if !f.modTime.IsZero() && stat.ModTime().Equal(f.modTime) {
+		return false, nil
+	}
+
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return false, fmt.Errorf("read params file %q: %w", f.path, err)
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(content, &values); err != nil {
+		return false, fmt.Errorf("decode params file %q: %w", f.path, err)
+	}
+	if err := f.registry.Stage(values); err != nil {
+		return false, fmt.Errorf("params file %q: %w", f.path, err)
+	}
+
+	f.modTime = stat.ModTime()
+	return true, nil
+}
+
+// Watch loads the file every `interval` until `ctx` is done. Load errors are
+// logged and the current values are kept.
+func (f *File) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			staged, err := f.Load()
+			if err != nil {
+				zlog.Warn("loading params file, keeping current values", zap.String("path", f.path), zap.Error(err))
+				continue
+			}
+			if staged {
+				zlog.Info("params file changed, applying at next block boundary", zap.String("path", f.path))
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package params
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.params", "github.com/streamingfast/substream-pancakeswap/params")
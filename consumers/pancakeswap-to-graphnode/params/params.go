@@ -0,0 +1,136 @@
+// Package params holds the parameters of a run that can be changed while it's
+// running, through the admin API or a watched file. Changes are staged and
+// only applied between two blocks, so a block is always processed with a
+// single set of values.
+package params
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Param is a parameter that can be changed while running, `Value` is its
+// value when registered. `Parse` validates a new value and returns the
+// function applying it, called at the next block boundary by the goroutine
+// processing blocks.
+type Param struct {
+	Name  string
+	Usage string
+	Value string
+	Parse func(value string) (apply func(), err error)
+}
+
+// Change records a parameter change, `AfterBlock` is the last block processed
+// with the previous value.
+type Change struct {
+	AfterBlock uint64 `json:"after_block"`
+	Name       string `json:"name"`
+	Old        string `json:"old"`
+	New        string `json:"new"`
+}
+
+type Registry struct {
+	lock   sync.Mutex
+	params map[string]*Param
+	values map[string]string
+	staged map[string]*staged
+}
+
+type staged struct {
+	value string
+	apply func()
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		params: map[string]*Param{},
+		values: map[string]string{},
+		staged: map[string]*staged{},
+	}
+}
+
+func (r *Registry) Register(param *Param) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, found := r.params[param.Name]; found {
+		panic(fmt.Sprintf("param %q already registered", param.Name))
+	}
+	r.params[param.Name] = param
+	r.values[param.Name] = param.Value
+}
+
+// Stage validates `values` and keeps them for the next call to `Apply`, no
+// value is staged when one of them is invalid. A value staged twice before
+// being applied only applies the last one.
+func (r *Registry) Stage(values map[string]string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	parsed := make(map[string]*staged, len(values))
+	for name, value := range values {
+		param, found := r.params[name]
+		if !found {
+			return fmt.Errorf("unknown param %q, valid params are: %s", name, r.names())
+		}
+
+		apply, err := param.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for param %q: %w", value, name, err)
+		}
+		parsed[name] = &staged{value: value, apply: apply}
+	}
+
+	for name, s := range parsed {
+		r.staged[name] = s
+	}
+	return nil
+}
+
+// Apply applies the staged values once block `afterBlock` is fully processed,
+// it returns the values that changed, sorted by name.
+func (r *Registry) Apply(afterBlock uint64) (changes []Change) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.staged) == 0 {
+		return nil
+	}
+
+	for name, s := range r.staged {
+		old := r.values[name]
+		if old == s.value {
+			continue
+		}
+
+		s.apply()
+		r.values[name] = s.value
+		changes = append(changes, Change{AfterBlock: afterBlock, Name: name, Old: old, New: s.value})
+	}
+	r.staged = map[string]*staged{}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// Values returns the current value of every param.
+func (r *Registry) Values() map[string]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	out := make(map[string]string, len(r.values))
+	for name, value := range r.values {
+		out[name] = value
+	}
+	return out
+}
+
+func (r *Registry) names() string {
+	var names []string
+	for name := range r.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%v", names)
+}
@@ -0,0 +1,93 @@
+package params
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(threshold *int) *Registry {
+	r := NewRegistry()
+	r.Register(&Param{
+		Name:  "whale-threshold",
+		Value: strconv.Itoa(*threshold),
+		Parse: func(value string) (func(), error) {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, err
+			}
+			return func() { *threshold = parsed }, nil
+		},
+	})
+	return r
+}
+
+func TestRegistry(t *testing.T) {
+	threshold := 100
+	r := newTestRegistry(&threshold)
+
+	tests := []struct {
+		name              string
+		stage             map[string]string
+		expectedErr       bool
+		expectedChanges   []Change
+		expectedThreshold int
+	}{
+		{"nothing staged", nil, false, nil, 100},
+		{"change", map[string]string{"whale-threshold": "500"}, false, []Change{{AfterBlock: 10, Name: "whale-threshold", Old: "100", New: "500"}}, 500},
+		{"same value", map[string]string{"whale-threshold": "500"}, false, nil, 500},
+		{"invalid value", map[string]string{"whale-threshold": "lots"}, true, nil, 500},
+		{"unknown param", map[string]string{"whale-threshold": "1", "stablecoins": "usdt"}, true, nil, 500},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := threshold
+			if test.stage != nil {
+				err := r.Stage(test.stage)
+				if test.expectedErr {
+					require.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+
+			assert.Equal(t, before, threshold, "not applied before the block boundary")
+			assert.Equal(t, test.expectedChanges, r.Apply(10))
+			assert.Equal(t, test.expectedThreshold, threshold)
+			assert.Equal(t, map[string]string{"whale-threshold": strconv.Itoa(threshold)}, r.Values())
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	threshold := 100
+	r := newTestRegistry(&threshold)
+
+	path := filepath.Join(t.TempDir(), "params.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"whale-threshold": "200"}`), 0644))
+
+	f := NewFile(path, r)
+	staged, err := f.Load()
+	require.NoError(t, err)
+	assert.True(t, staged)
+	assert.Len(t, r.Apply(1), 1)
+	assert.Equal(t, 200, threshold)
+
+	staged, err = f.Load()
+	require.NoError(t, err)
+	assert.False(t, staged, "unchanged file")
+
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.WriteFile(path, []byte(`{"whale-threshold": "many"}`), 0644))
+	require.NoError(t, os.Chtimes(path, later, later))
+	_, err = f.Load()
+	require.Error(t, err)
+	assert.Empty(t, r.Apply(2))
+	assert.Equal(t, 200, threshold)
+}
@@ -18,6 +18,7 @@ ROOT="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && cd .. && pwd )"
 # Protobuf definitions
 PROTO=${1:-"$ROOT/proto"}
 PROTO_ETHEREUM=${2:-"$ROOT/../proto-ethereum"}
+PROTO_PCS=${3:-"$ROOT/../../modules/pancakeswap/proto"}
 
 function main() {
   current_dir="`pwd`"
@@ -28,6 +29,8 @@ function main() {
   generate "sf/ethereum/codec/v1/codec.proto"
   generate "pcs/database/v1/database.proto"
   generate "pcs/database/v1/database.proto"
+  generate "pcs/v1/pcs.proto"
+  generate "sf/solana/type/v1/type.proto"
 
   echo "generate.sh - `date` - `whoami`" > $ROOT/pb/last_generate.txt
 }
@@ -43,7 +46,7 @@ function generate() {
 
     for file in "$@"; do
       echo $PROTO
-      protoc -I$PROTO -I$PROTO_ETHEREUM $base$file --go_out=paths=source_relative:.
+      protoc -I$PROTO -I$PROTO_ETHEREUM -I$PROTO_PCS $base$file --go_out=paths=source_relative:.
     done
 }
 
@@ -0,0 +1,95 @@
+package plan
+
+import (
+	"strings"
+)
+
+// JobList is a Kubernetes `List` of `batch/v1` Jobs, JSON being valid YAML it
+// can be given as is to `kubectl apply -f`.
+type JobList struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Items      []*Job `json:"items"`
+}
+
+type Job struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Metadata   JobMetadata `json:"metadata"`
+	Spec       JobSpec     `json:"spec"`
+}
+
+type JobMetadata struct {
+	Name        string            `json:"name,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type JobSpec struct {
+	BackoffLimit int         `json:"backoffLimit"`
+	Template     PodTemplate `json:"template"`
+}
+
+type PodTemplate struct {
+	Metadata JobMetadata `json:"metadata"`
+	Spec     PodSpec     `json:"spec"`
+}
+
+type PodSpec struct {
+	RestartPolicy string       `json:"restartPolicy"`
+	Containers    []*Container `json:"containers"`
+}
+
+type Container struct {
+	Name    string   `json:"name"`
+	Image   string   `json:"image"`
+	Command []string `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// Jobs returns a Job per shard and the merge Job, all labelled
+// `backfill=<name>` and `role=shard` or `role=merge`. Kubernetes doesn't order
+// Jobs, the merge Job is meant to be applied once the shards completed, like
+// after `kubectl wait --for=condition=complete job -l backfill=<name>,role=shard`,
+// its `after` annotation lists them.
+func (p *Plan) Jobs(image string, backoffLimit int) *JobList {
+	list := &JobList{APIVersion: "v1", Kind: "List"}
+	for _, shard := range p.Shards {
+		list.Items = append(list.Items, p.job(shard.Name, "shard", image, backoffLimit, shard.Args, nil))
+	}
+
+	annotations := map[string]string{"backfill/after": strings.Join(p.Merge.After, ",")}
+	list.Items = append(list.Items, p.job(p.Merge.Name, "merge", image, backoffLimit, p.Merge.Args, annotations))
+	return list
+}
+
+func (p *Plan) job(name, role, image string, backoffLimit int, args []string, annotations map[string]string) *Job {
+	metadata := JobMetadata{
+		Name:        name,
+		Labels:      map[string]string{"backfill": p.Name, "role": role},
+		Annotations: annotations,
+	}
+
+	return &Job{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Metadata:   metadata,
+		Spec: JobSpec{
+			BackoffLimit: backoffLimit,
+			Template: PodTemplate{
+				Metadata: JobMetadata{Labels: metadata.Labels},
+				Spec: PodSpec{
+					// Failed pods are replaced by the Job up to the backoff
+					// limit, each one running the whole range again.
+					RestartPolicy: "Never",
+					Containers: []*Container{{
+						Name:    role,
+						Image:   image,
+						Command: []string{"exchange"},
+						Args:    args,
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,116 @@
+// Package plan splits a backfill into jobs that can run side by side on any
+// infrastructure, each one running `exchange run` over its own block range and
+// writing its stores deltas to its own delta log, merged once they all
+// completed.
+package plan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type ShardConfig struct {
+	// Name prefixes the names of the jobs, like `backfill-shard-000`.
+	Name string
+
+	// Start is the first block of the backfill, Stop is exclusive.
+	Start, Stop uint64
+	Shards      int
+
+	Manifest string
+	Modules  []string
+
+	// StateURL is the dstore URL under which each shard writes its delta log,
+	// in `shard-000`, `shard-001`, ... and the merge step in `merged`.
+	StateURL string
+
+	// Args are passed as is to every shard's `exchange run`.
+	Args []string
+}
+
+// Plan is the list of shard jobs, they can run in any order and at the same
+// time, then the merge job runs once all of them completed.
+type Plan struct {
+	Name   string   `json:"name"`
+	Start  uint64   `json:"start_block"`
+	Stop   uint64   `json:"stop_block"`
+	Shards []*Shard `json:"shards"`
+	Merge  *Merge   `json:"merge"`
+}
+
+type Shard struct {
+	Name       string   `json:"name"`
+	Index      int      `json:"index"`
+	StartBlock uint64   `json:"start_block"`
+	StopBlock  uint64   `json:"stop_block"`
+	Output     string   `json:"output"`
+	Args       []string `json:"args"`
+}
+
+type Merge struct {
+	Name string `json:"name"`
+	// After are the names of the shard jobs that must complete first.
+	After  []string `json:"after"`
+	Inputs []string `json:"inputs"`
+	Output string   `json:"output"`
+	Args   []string `json:"args"`
+}
+
+// NewShardPlan splits [Start, Stop[ into `Shards` contiguous ranges of the
+// same size, the first ranges taking one more block when it doesn't divide
+// evenly.
+func NewShardPlan(config ShardConfig) (*Plan, error) {
+	if config.Stop <= config.Start {
+		return nil, fmt.Errorf("stop block %d must be after start block %d", config.Stop, config.Start)
+	}
+	if config.Shards <= 0 {
+		return nil, fmt.Errorf("shards must be positive, got %d", config.Shards)
+	}
+	if blocks := config.Stop - config.Start; uint64(config.Shards) > blocks {
+		return nil, fmt.Errorf("cannot split %d blocks in %d shards", blocks, config.Shards)
+	}
+	if config.Manifest == "" || len(config.Modules) == 0 {
+		return nil, fmt.Errorf("a manifest and at least one output module are required")
+	}
+	if config.StateURL == "" {
+		return nil, fmt.Errorf("a state URL is required")
+	}
+
+	stateURL := strings.TrimSuffix(config.StateURL, "/")
+	plan := &Plan{Name: config.Name, Start: config.Start, Stop: config.Stop}
+	merge := &Merge{Name: config.Name + "-merge", Output: stateURL + "/merged"}
+
+	size, remainder := (config.Stop-config.Start)/uint64(config.Shards), (config.Stop-config.Start)%uint64(config.Shards)
+	start := config.Start
+	for i := 0; i < config.Shards; i++ {
+		stop := start + size
+		if uint64(i) < remainder {
+			stop++
+		}
+
+		shard := &Shard{
+			Name:       fmt.Sprintf("%s-shard-%03d", config.Name, i),
+			Index:      i,
+			StartBlock: start,
+			StopBlock:  stop,
+			Output:     fmt.Sprintf("%s/shard-%03d", stateURL, i),
+		}
+		shard.Args = append([]string{"run", config.Manifest}, config.Modules...)
+		shard.Args = append(shard.Args,
+			"--start-block", strconv.FormatUint(shard.StartBlock, 10),
+			"--stop-block", strconv.FormatUint(shard.StopBlock, 10),
+			"--output", "deltalog:"+shard.Output,
+		)
+		shard.Args = append(shard.Args, config.Args...)
+
+		plan.Shards = append(plan.Shards, shard)
+		merge.After = append(merge.After, shard.Name)
+		merge.Inputs = append(merge.Inputs, shard.Output)
+		start = stop
+	}
+
+	merge.Args = append([]string{"deltalog", "merge", merge.Output}, merge.Inputs...)
+	plan.Merge = merge
+	return plan, nil
+}
@@ -0,0 +1,73 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShardPlan(t *testing.T) {
+	config := ShardConfig{
+		Name:     "backfill",
+		Start:    100,
+		Stop:     111,
+		Shards:   3,
+		Manifest: "substreams.yaml",
+		Modules:  []string{"store_pairs"},
+		StateURL: "gs://bucket/backfill/",
+		Args:     []string{"--plaintext"},
+	}
+
+	plan, err := NewShardPlan(config)
+	require.NoError(t, err)
+
+	var ranges [][2]uint64
+	for _, shard := range plan.Shards {
+		ranges = append(ranges, [2]uint64{shard.StartBlock, shard.StopBlock})
+	}
+	assert.Equal(t, [][2]uint64{{100, 104}, {104, 108}, {108, 111}}, ranges)
+
+	assert.Equal(t, []string{
+		"run", "substreams.yaml", "store_pairs",
+		"--start-block", "104", "--stop-block", "108",
+		"--output", "deltalog:gs://bucket/backfill/shard-001",
+		"--plaintext",
+	}, plan.Shards[1].Args)
+
+	assert.Equal(t, []string{"backfill-shard-000", "backfill-shard-001", "backfill-shard-002"}, plan.Merge.After)
+	assert.Equal(t, []string{
+		"deltalog", "merge", "gs://bucket/backfill/merged",
+		"gs://bucket/backfill/shard-000", "gs://bucket/backfill/shard-001", "gs://bucket/backfill/shard-002",
+	}, plan.Merge.Args)
+
+	jobs := plan.Jobs("exchange:latest", 4)
+	require.Len(t, jobs.Items, 4)
+	assert.Equal(t, "backfill-merge", jobs.Items[3].Metadata.Name)
+	assert.Equal(t, map[string]string{"backfill": "backfill", "role": "shard"}, jobs.Items[0].Spec.Template.Metadata.Labels)
+	assert.Equal(t, plan.Shards[2].Args, jobs.Items[2].Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestNewShardPlan_Invalid(t *testing.T) {
+	valid := ShardConfig{Start: 0, Stop: 10, Shards: 2, Manifest: "substreams.yaml", Modules: []string{"store_pairs"}, StateURL: "file:///tmp"}
+
+	tests := []struct {
+		name   string
+		change func(c *ShardConfig)
+	}{
+		{"stop before start", func(c *ShardConfig) { c.Start = 10 }},
+		{"no shards", func(c *ShardConfig) { c.Shards = 0 }},
+		{"more shards than blocks", func(c *ShardConfig) { c.Shards = 11 }},
+		{"no modules", func(c *ShardConfig) { c.Modules = nil }},
+		{"no state url", func(c *ShardConfig) { c.StateURL = "" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := valid
+			test.change(&config)
+			_, err := NewShardPlan(config)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package rebuild computes a store again from the swaps archived by the
+// `swaps` output, see `swaparchive`, instead of streaming the modules again:
+// no block is fetched, no log decoded and no RPC call made, only the builder
+// of the store runs. It's meant to rebuild a store quickly once its logic is
+// fixed, the builders mirror the store modules of the substreams.
+package rebuild
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/swaparchive"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Builder folds the swaps of the blocks into a store.
+type Builder interface {
+	// Warmup is the number of blocks before the rebuilt range whose swaps the
+	// store needs to hold its content at the first block of the range.
+	Warmup() uint64
+	// Apply folds the swaps of a block into the store and returns its
+	// deltas, in order.
+	Apply(clock *pbsubstreams.Clock, swaps []*pbpcs.Event) ([]*pbsubstreams.StoreDelta, error)
+	// State returns the content of the store, per key.
+	State() map[string][]byte
+}
+
+var builders = map[string]func() Builder{
+	Volume24hModule: newVolume24h,
+}
+
+// Stores returns the stores that can be rebuilt.
+func Stores() (out []string) {
+	for module := range builders {
+		out = append(out, module)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// New returns the builder of `store`, named after its module, the 'store_'
+// prefix can be omitted, like `volume24h`. It also returns the module name.
+func New(store string) (Builder, string, error) {
+	module := store
+	if !strings.HasPrefix(module, "store_") {
+		module = "store_" + module
+	}
+	newBuilder, found := builders[module]
+	if !found {
+		return nil, "", fmt.Errorf("store %q can't be rebuilt from the swaps, expected one of %s", store, strings.Join(Stores(), ", "))
+	}
+	return newBuilder(), module, nil
+}
+
+// Rebuild writes to `out` the deltas of `module` rebuilt by `builder` from the
+// swaps of `reader`, for the blocks in [start, stop[, until the end of the
+// archive when `stop` is 0. The blocks of the warmup aren't written, the
+// content of the store they produced is written as created at the first block
+// written instead. It returns the number of blocks written.
+func Rebuild(ctx context.Context, reader *swaparchive.Reader, builder Builder, module string, start, stop uint64, out sink.Sink) (blocks uint64, err error) {
+	from := uint64(0)
+	if start > builder.Warmup() {
+		from = start - builder.Warmup()
+	}
+
+	var clock *pbsubstreams.Clock
+	var swaps []*pbpcs.Event
+	started := false
+	apply := func() error {
+		if clock == nil {
+			return nil
+		}
+		deltas, err := builder.Apply(clock, swaps)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", clock.Number, err)
+		}
+		if clock.Number < start {
+			return nil
+		}
+
+		if !started {
+			deltas = append(created(builder.State(), deltas), deltas...)
+			started = true
+		}
+		if len(deltas) == 0 {
+			return nil
+		}
+
+		blocks++
+		return out.Write(ctx, &pbsubstreams.BlockScopedData{
+			Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+			Clock: clock,
+			Outputs: []*pbsubstreams.ModuleOutput{{
+				Name: module,
+				Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}},
+			}},
+		})
+	}
+
+	err = reader.Read(ctx, from, stop, func(swap *swaparchive.Swap) error {
+		if clock != nil && clock.Number == swap.BlockNum {
+			swaps = append(swaps, swap.Event)
+			return nil
+		}
+		if err := apply(); err != nil {
+			return err
+		}
+
+		clock = &pbsubstreams.Clock{Id: swap.BlockID, Number: swap.BlockNum}
+		if !swap.BlockTime.IsZero() {
+			clock.Timestamp = timestamppb.New(swap.BlockTime)
+		}
+		swaps = []*pbpcs.Event{swap.Event}
+		return nil
+	})
+	if err != nil {
+		return blocks, err
+	}
+	return blocks, apply()
+}
+
+// created returns the content of the store before `deltas` as created deltas,
+// by key.
+func created(state map[string][]byte, deltas []*pbsubstreams.StoreDelta) (out []*pbsubstreams.StoreDelta) {
+	before := make(map[string][]byte, len(state))
+	for key, value := range state {
+		before[key] = value
+	}
+	// deltas are reverted from the last
+	for i := len(deltas) - 1; i >= 0; i-- {
+		if delta := deltas[i]; delta.Operation == pbsubstreams.StoreDelta_CREATE {
+			delete(before, delta.Key)
+		} else {
+			before[delta.Key] = delta.OldValue
+		}
+	}
+
+	keys := make([]string, 0, len(before))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out = append(out, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: key, NewValue: before[key]})
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+package rebuild
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink/swaparchive"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type capture struct {
+	blocks []*pbsubstreams.BlockScopedData
+}
+
+func (c *capture) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	c.blocks = append(c.blocks, data)
+	return nil
+}
+
+func (c *capture) Close() error { return nil }
+
+// swapsBlock has a swap of `amount` USD on each of `pairs`, at `hour` hours
+// after the epoch.
+func swapsBlock(t *testing.T, num uint64, hour int64, amount string, pairs ...string) *pbsubstreams.BlockScopedData {
+	t.Helper()
+
+	events := &pbpcs.Events{}
+	for i, pair := range pairs {
+		events.Events = append(events.Events, &pbpcs.Event{
+			Id:          fmt.Sprintf("bsc:%d:%d", num, i),
+			LogOrdinal:  uint64(i + 1),
+			PairAddress: pair,
+			Type:        &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: amount}},
+		})
+	}
+	output, err := anypb.New(events)
+	require.NoError(t, err)
+
+	return &pbsubstreams.BlockScopedData{
+		Step:    pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Clock:   &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%08x", num), Timestamp: timestamppb.New(time.Unix(hour*3600, 0))},
+		Outputs: []*pbsubstreams.ModuleOutput{{Name: swaparchive.EventsModule, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: output}}},
+	}
+}
+
+type delta struct {
+	op, key, value string
+}
+
+func deltas(data *pbsubstreams.BlockScopedData) (out []delta) {
+	for _, d := range data.Outputs[0].GetStoreDeltas().Deltas {
+		out = append(out, delta{d.Operation.String(), d.Key, string(d.NewValue)})
+	}
+	return out
+}
+
+func TestRebuild_Volume24h(t *testing.T) {
+	storeURL := t.TempDir()
+	archive, err := swaparchive.New(&swaparchive.Config{StoreURL: storeURL, SegmentSize: 100_000})
+	require.NoError(t, err)
+	for _, data := range []*pbsubstreams.BlockScopedData{
+		swapsBlock(t, 10, 100, "1.5", "0xaa", "0xbb"),
+		swapsBlock(t, 20, 101, "2", "0xaa"),
+		swapsBlock(t, 40_010, 110, "3", "0xaa"),
+		swapsBlock(t, 40_020, 110, "1", "0xaa", "0xaa"),
+		swapsBlock(t, 40_030, 125, "5", "0xbb"),
+	} {
+		require.NoError(t, archive.Write(context.Background(), data))
+	}
+	require.NoError(t, archive.Close())
+
+	reader, err := swaparchive.NewReader(storeURL)
+	require.NoError(t, err)
+	builder, module, err := New("volume24h")
+	require.NoError(t, err)
+	require.Equal(t, Volume24hModule, module)
+
+	t.Run("whole archive", func(t *testing.T) {
+		out := &capture{}
+		blocks, err := Rebuild(context.Background(), reader, newVolume24h(), module, 0, 0, out)
+		require.NoError(t, err)
+		require.EqualValues(t, 5, blocks)
+
+		assert.Equal(t, []delta{
+			{"CREATE", "pair_hour:100:0xaa:usd", "1.5"},
+			{"CREATE", "pair_hour:100:0xbb:usd", "1.5"},
+		}, deltas(out.blocks[0]))
+		assert.Equal(t, []delta{
+			{"UPDATE", "pair_hour:110:0xaa:usd", "4"},
+			{"UPDATE", "pair_hour:110:0xaa:usd", "5"},
+		}, deltas(out.blocks[3]))
+		assert.Equal(t, []delta{
+			{"DELETE", "pair_hour:101:0xaa:usd", ""},
+			{"CREATE", "pair_hour:125:0xbb:usd", "5"},
+		}, deltas(out.blocks[4]), "the buckets past the window are deleted")
+	})
+
+	t.Run("range after the warmup", func(t *testing.T) {
+		out := &capture{}
+		blocks, err := Rebuild(context.Background(), reader, builder, module, 40_020, 40_030, out)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, blocks)
+
+		// blocks 10 and 20 are out of the warmup
+		assert.Equal(t, []delta{
+			{"CREATE", "pair_hour:110:0xaa:usd", "3"},
+			{"UPDATE", "pair_hour:110:0xaa:usd", "4"},
+			{"UPDATE", "pair_hour:110:0xaa:usd", "5"},
+		}, deltas(out.blocks[0]), "the store is created as of the start block")
+		assert.EqualValues(t, 40_020, out.blocks[0].Clock.Number)
+		assert.Equal(t, module, out.blocks[0].Outputs[0].Name)
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, module, err := New("store_volume24h")
+	require.NoError(t, err)
+	assert.Equal(t, Volume24hModule, module)
+
+	_, _, err = New("prices")
+	assert.EqualError(t, err, `store "prices" can't be rebuilt from the swaps, expected one of store_volume24h`)
+}
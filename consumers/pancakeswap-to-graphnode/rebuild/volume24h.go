@@ -0,0 +1,103 @@
+package rebuild
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Volume24hModule mirrors `store_volume24h`: the USD volume of the swaps per
+// pair and per hour, `pair_hour:<hour>:<pair>:usd`, kept for the last 24
+// hours.
+const Volume24hModule = "store_volume24h"
+
+const (
+	volumeWindowHours = 24
+	// a little over 24 hours of 3 seconds blocks
+	volumeWarmup = 30_000
+	// precision of the bigfloat stores of the substreams server, their values
+	// are written the same way
+	bigFloatPrecision = 100
+)
+
+type volume24h struct {
+	values map[string][]byte
+}
+
+func newVolume24h() Builder {
+	return &volume24h{values: map[string][]byte{}}
+}
+
+func (v *volume24h) Warmup() uint64 { return volumeWarmup }
+
+func (v *volume24h) State() map[string][]byte { return v.values }
+
+// Apply deletes the buckets past the window then adds the swaps, like the
+// module. The archive only holds the blocks with swaps, where the module
+// deletes them at every block: a bucket past the window can be deleted a few
+// blocks later here.
+func (v *volume24h) Apply(clock *pbsubstreams.Clock, swaps []*pbpcs.Event) ([]*pbsubstreams.StoreDelta, error) {
+	hour := clock.GetTimestamp().GetSeconds() / 3600
+	deltas := v.deletePrefix(fmt.Sprintf("pair_hour:%d:", hour-volumeWindowHours))
+
+	for _, event := range swaps {
+		swap := event.GetSwap()
+		if swap == nil || swap.AmountUsd == "" {
+			continue
+		}
+		amount, _, err := big.ParseFloat(swap.AmountUsd, 10, bigFloatPrecision, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount_usd %q of swap %s: %w", swap.AmountUsd, event.Id, err)
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("pair_hour:%d:%s:usd", hour, event.PairAddress)
+		delta, err := v.add(event.LogOrdinal, key, amount)
+		if err != nil {
+			return nil, err
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas, nil
+}
+
+func (v *volume24h) add(ordinal uint64, key string, amount *big.Float) (*pbsubstreams.StoreDelta, error) {
+	delta := &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Ordinal: ordinal, Key: key}
+	sum := amount
+	if previous, found := v.values[key]; found {
+		value, _, err := big.ParseFloat(string(previous), 10, bigFloatPrecision, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q of %s: %w", previous, key, err)
+		}
+		sum = new(big.Float).SetPrec(bigFloatPrecision).Add(value, amount)
+		delta.Operation = pbsubstreams.StoreDelta_UPDATE
+		delta.OldValue = previous
+	}
+
+	delta.NewValue = []byte(sum.Text('g', bigFloatPrecision))
+	v.values[key] = delta.NewValue
+	return delta, nil
+}
+
+// deletePrefix deletes the keys starting with `prefix`, in key order.
+func (v *volume24h) deletePrefix(prefix string) (deltas []*pbsubstreams.StoreDelta) {
+	var keys []string
+	for key := range v.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		deltas = append(deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_DELETE, Key: key, OldValue: v.values[key]})
+		delete(v.values, key)
+	}
+	return deltas
+}
@@ -0,0 +1,237 @@
+// Package reorg simulates chain reorganizations to test the undo paths: a
+// script like `advance 5, undo 2, advance 3` is played into a stream of new
+// and undo blocks carrying synthetic store deltas, and the state built by the
+// sinks out of it must be the one of the canonical chain alone.
+package reorg
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// StoreName is the store module of the synthetic deltas.
+const StoreName = "store_reorg"
+
+// Step is one instruction of a script, advancing the chain or undoing its
+// last blocks.
+type Step struct {
+	Undo   bool
+	Blocks int
+}
+
+func (s Step) String() string {
+	if s.Undo {
+		return fmt.Sprintf("undo %d", s.Blocks)
+	}
+	return fmt.Sprintf("advance %d", s.Blocks)
+}
+
+// ParseScript parses comma separated steps, like `advance 5, undo 2, advance 3`.
+func ParseScript(script string) ([]Step, error) {
+	var steps []Step
+	for _, raw := range strings.Split(script, ",") {
+		fields := strings.Fields(raw)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid step %q, expected 'advance <blocks>' or 'undo <blocks>'", strings.TrimSpace(raw))
+		}
+
+		blocks, err := strconv.Atoi(fields[1])
+		if err != nil || blocks <= 0 {
+			return nil, fmt.Errorf("invalid step %q, the number of blocks must be positive", strings.TrimSpace(raw))
+		}
+
+		switch fields[0] {
+		case "advance":
+			steps = append(steps, Step{Blocks: blocks})
+		case "undo":
+			steps = append(steps, Step{Undo: true, Blocks: blocks})
+		default:
+			return nil, fmt.Errorf("invalid step %q, expected 'advance <blocks>' or 'undo <blocks>'", strings.TrimSpace(raw))
+		}
+	}
+	return steps, nil
+}
+
+type Config struct {
+	StartBlock uint64
+	Seed       int64
+	// BareUndos sends the undo steps without their outputs, leaving the
+	// deltas to revert to the undo buffer of the consumer.
+	BareUndos bool
+}
+
+// Chain generates the blocks of a script. Blocks re-advanced after an undo
+// are on a new branch, with other ids and other deltas.
+type Chain struct {
+	config Config
+	rnd    *rand.Rand
+	branch int
+	blocks []*pbsubstreams.BlockScopedData
+	state  map[string]string
+}
+
+func NewChain(config Config) *Chain {
+	return &Chain{config: config, rnd: rand.New(rand.NewSource(config.Seed)), state: map[string]string{}}
+}
+
+// Play returns the stream of new and undo blocks of `steps`.
+func (c *Chain) Play(steps []Step) ([]*pbsubstreams.BlockScopedData, error) {
+	var stream []*pbsubstreams.BlockScopedData
+	for _, step := range steps {
+		if !step.Undo {
+			for i := 0; i < step.Blocks; i++ {
+				data := c.next()
+				c.blocks = append(c.blocks, data)
+				stream = append(stream, data)
+			}
+			continue
+		}
+
+		if step.Blocks > len(c.blocks) {
+			return nil, fmt.Errorf("%s: only %d blocks to undo", step, len(c.blocks))
+		}
+		for i := 0; i < step.Blocks; i++ {
+			data := c.blocks[len(c.blocks)-1]
+			c.blocks = c.blocks[:len(c.blocks)-1]
+			c.revert(data)
+
+			undo := proto.Clone(data).(*pbsubstreams.BlockScopedData)
+			undo.Step = pbsubstreams.ForkStep_STEP_UNDO
+			undo.Cursor = cursor(data.Clock, undo.Step)
+			if c.config.BareUndos {
+				undo.Outputs = nil
+			}
+			stream = append(stream, undo)
+		}
+		c.branch++
+	}
+	return stream, nil
+}
+
+// Canonical returns the blocks of the chain as it stands after the played
+// steps.
+func (c *Chain) Canonical() []*pbsubstreams.BlockScopedData {
+	return append([]*pbsubstreams.BlockScopedData(nil), c.blocks...)
+}
+
+// Expected returns the state of the store at the head of the chain.
+func (c *Chain) Expected() State {
+	out := State{StoreName: {}}
+	for key, value := range c.state {
+		out[StoreName][key] = value
+	}
+	return out
+}
+
+// next generates the block following the head: a counter updated by every
+// block, a key created per block and deleted two blocks later on every third
+// block, and a few keys updated at random.
+func (c *Chain) next() *pbsubstreams.BlockScopedData {
+	num := c.config.StartBlock + uint64(len(c.blocks))
+	clock := &pbsubstreams.Clock{
+		Number:    num,
+		Id:        fmt.Sprintf("%08x-b%d", num, c.branch),
+		Timestamp: timestamppb.New(time.Unix(int64(1_650_000_000+num*3), 0)),
+	}
+
+	var deltas []*pbsubstreams.StoreDelta
+	set := func(key, value string) {
+		delta := &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: key, NewValue: []byte(value)}
+		if old, found := c.state[key]; found {
+			delta.Operation = pbsubstreams.StoreDelta_UPDATE
+			delta.OldValue = []byte(old)
+		}
+		deltas = append(deltas, delta)
+	}
+
+	count, _ := strconv.Atoi(c.state["count"])
+	set("count", strconv.Itoa(count+1+c.rnd.Intn(10)))
+	set(fmt.Sprintf("block:%d", num), clock.Id)
+	if key := fmt.Sprintf("block:%d", num-2); num%3 == 0 && c.state[key] != "" {
+		deltas = append(deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_DELETE, Key: key, OldValue: []byte(c.state[key])})
+	}
+	set(fmt.Sprintf("pair:%d", c.rnd.Intn(5)), strconv.Itoa(c.rnd.Intn(1_000_000)))
+
+	for i, delta := range deltas {
+		delta.Ordinal = uint64(i + 1)
+		apply(c.state, delta)
+	}
+
+	return &pbsubstreams.BlockScopedData{
+		Clock:  clock,
+		Step:   pbsubstreams.ForkStep_STEP_NEW,
+		Cursor: cursor(clock, pbsubstreams.ForkStep_STEP_NEW),
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: StoreName, Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}}},
+		},
+	}
+}
+
+func (c *Chain) revert(data *pbsubstreams.BlockScopedData) {
+	deltas := data.Outputs[0].GetStoreDeltas().Deltas
+	for i := len(deltas) - 1; i >= 0; i-- {
+		apply(c.state, reverse(deltas[i]))
+	}
+}
+
+func cursor(clock *pbsubstreams.Clock, step pbsubstreams.ForkStep) string {
+	return fmt.Sprintf("%s:%s", clock.Id, step)
+}
+
+// State is the content of stores, per store and key.
+type State map[string]map[string]string
+
+// Diff returns the differences of `actual` from `expected`, sorted.
+func Diff(expected, actual State) (out []string) {
+	for store, keys := range expected {
+		for key, value := range keys {
+			got, found := actual[store][key]
+			switch {
+			case !found:
+				out = append(out, fmt.Sprintf("%s %q: missing, expected %q", store, key, value))
+			case got != value:
+				out = append(out, fmt.Sprintf("%s %q: got %q, expected %q", store, key, got, value))
+			}
+		}
+	}
+	for store, keys := range actual {
+		for key, value := range keys {
+			if _, found := expected[store][key]; !found {
+				out = append(out, fmt.Sprintf("%s %q: unexpected %q", store, key, value))
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func apply(state map[string]string, delta *pbsubstreams.StoreDelta) {
+	switch delta.Operation {
+	case pbsubstreams.StoreDelta_CREATE, pbsubstreams.StoreDelta_UPDATE:
+		state[delta.Key] = string(delta.NewValue)
+	case pbsubstreams.StoreDelta_DELETE:
+		delete(state, delta.Key)
+	}
+}
+
+// reverse returns the delta that undoes `delta`.
+func reverse(delta *pbsubstreams.StoreDelta) *pbsubstreams.StoreDelta {
+	out := &pbsubstreams.StoreDelta{Key: delta.Key, Ordinal: delta.Ordinal, OldValue: delta.NewValue, NewValue: delta.OldValue}
+	switch delta.Operation {
+	case pbsubstreams.StoreDelta_CREATE:
+		out.Operation = pbsubstreams.StoreDelta_DELETE
+	case pbsubstreams.StoreDelta_UPDATE:
+		out.Operation = pbsubstreams.StoreDelta_UPDATE
+	case pbsubstreams.StoreDelta_DELETE:
+		out.Operation = pbsubstreams.StoreDelta_CREATE
+	}
+	return out
+}
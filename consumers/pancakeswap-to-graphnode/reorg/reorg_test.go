@@ -0,0 +1,122 @@
+package reorg
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/undo"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		expected  []Step
+		expectErr bool
+	}{
+		{"advance only", "advance 3", []Step{{Blocks: 3}}, false},
+		{"fork", "advance 5, undo 2, advance 3", []Step{{Blocks: 5}, {Undo: true, Blocks: 2}, {Blocks: 3}}, false},
+		{"spaces", " advance  1 ,undo 1", []Step{{Blocks: 1}, {Undo: true, Blocks: 1}}, false},
+		{"unknown step", "rewind 2", nil, true},
+		{"missing blocks", "advance", nil, true},
+		{"zero blocks", "undo 0", nil, true},
+		{"empty", "", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			steps, err := ParseScript(test.script)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, steps)
+		})
+	}
+}
+
+func TestChain_Play(t *testing.T) {
+	steps, err := ParseScript("advance 5, undo 2, advance 3, undo 4, advance 6")
+	require.NoError(t, err)
+
+	chain := NewChain(Config{StartBlock: 100, Seed: 7})
+	stream, err := chain.Play(steps)
+	require.NoError(t, err)
+	require.Len(t, stream, 20)
+
+	canonical := chain.Canonical()
+	require.Len(t, canonical, 8)
+	for i, data := range canonical {
+		assert.Equal(t, uint64(100+i), data.Clock.Number)
+	}
+	assert.Equal(t, "00000065-b0", canonical[1].Clock.Id)
+	assert.Equal(t, "00000066-b2", canonical[2].Clock.Id)
+
+	live := NewStateSink()
+	for _, data := range stream {
+		require.NoError(t, live.Write(context.Background(), data))
+	}
+	replayed := NewStateSink()
+	for _, data := range canonical {
+		require.NoError(t, replayed.Write(context.Background(), data))
+	}
+
+	assert.Empty(t, Diff(chain.Expected(), live.State()))
+	assert.Empty(t, Diff(chain.Expected(), replayed.State()))
+
+	_, err = NewChain(Config{}).Play([]Step{{Blocks: 1}, {Undo: true, Blocks: 2}})
+	assert.Error(t, err)
+}
+
+func TestChain_BareUndos(t *testing.T) {
+	steps, err := ParseScript("advance 6, undo 3, advance 2")
+	require.NoError(t, err)
+
+	chain := NewChain(Config{Seed: 3, BareUndos: true})
+	stream, err := chain.Play(steps)
+	require.NoError(t, err)
+
+	bare := NewStateSink()
+	for _, data := range stream {
+		require.NoError(t, bare.Write(context.Background(), data))
+	}
+	assert.NotEmpty(t, Diff(chain.Expected(), bare.State()), "bare undos can't be reverted without an undo buffer")
+
+	undoLog, err := undo.Open(t.TempDir(), 10)
+	require.NoError(t, err)
+	buffered := NewStateSink()
+	out := undo.NewSink(undoLog, buffered)
+	for _, data := range stream {
+		require.NoError(t, out.Write(context.Background(), data))
+	}
+	assert.Empty(t, Diff(chain.Expected(), buffered.State()))
+}
+
+func TestStream(t *testing.T) {
+	blocks := []*pbsubstreams.BlockScopedData{{Cursor: "a"}, {Cursor: "b"}}
+	stream := NewStream(context.Background(), blocks)
+
+	for _, expected := range blocks {
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp.GetData())
+	}
+	_, err := stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDiff(t *testing.T) {
+	expected := State{"s": {"a": "1", "b": "2"}}
+	actual := State{"s": {"a": "1", "b": "3", "c": "4"}}
+
+	assert.Equal(t, []string{
+		`s "b": got "3", expected "2"`,
+		`s "c": unexpected "4"`,
+	}, Diff(expected, actual))
+	assert.Equal(t, []string{`s "c": missing, expected "4"`}, Diff(actual, State{"s": {"a": "1", "b": "3"}}))
+}
@@ -0,0 +1,50 @@
+package reorg
+
+import (
+	"context"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// StateSink builds the state of the stores out of the deltas it receives, as a
+// downstream consumer would: new blocks are applied and undo blocks reverted.
+type StateSink struct {
+	state State
+}
+
+func NewStateSink() *StateSink {
+	return &StateSink{state: State{}}
+}
+
+func (s *StateSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	for _, output := range data.Outputs {
+		deltas := output.GetStoreDeltas().GetDeltas()
+		if len(deltas) == 0 {
+			continue
+		}
+
+		store, found := s.state[output.Name]
+		if !found {
+			store = map[string]string{}
+			s.state[output.Name] = store
+		}
+
+		if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+			for i := len(deltas) - 1; i >= 0; i-- {
+				apply(store, reverse(deltas[i]))
+			}
+			continue
+		}
+		for _, delta := range deltas {
+			apply(store, delta)
+		}
+	}
+	return nil
+}
+
+// State returns the state built so far.
+func (s *StateSink) State() State {
+	return s.state
+}
+
+func (s *StateSink) Close() error { return nil }
@@ -0,0 +1,39 @@
+package reorg
+
+import (
+	"context"
+	"io"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/grpc"
+)
+
+// Stream serves blocks as a substreams server would, so they go through the
+// same handler as a live stream.
+type Stream struct {
+	grpc.ClientStream
+
+	ctx    context.Context
+	blocks []*pbsubstreams.BlockScopedData
+}
+
+func NewStream(ctx context.Context, blocks []*pbsubstreams.BlockScopedData) *Stream {
+	return &Stream{ctx: ctx, blocks: blocks}
+}
+
+func (s *Stream) Recv() (*pbsubstreams.Response, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(s.blocks) == 0 {
+		return nil, io.EOF
+	}
+
+	data := s.blocks[0]
+	s.blocks = s.blocks[1:]
+	return &pbsubstreams.Response{Message: &pbsubstreams.Response_Data{Data: data}}, nil
+}
+
+func (s *Stream) Context() context.Context {
+	return s.ctx
+}
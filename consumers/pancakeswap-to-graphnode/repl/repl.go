@@ -0,0 +1,315 @@
+// Package repl is an interactive console exploring the stores of a running
+// pipeline: keys are read from its state server, see `state.Server`, the
+// deltas of a store followed live from its WebSocket feed, see `wsfeed`.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/state"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"golang.org/x/net/websocket"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const help = `Commands:
+  stores                               list the stores of the state server
+  get <store> <key> [<block>]          print the value of a key
+  prefix <store> <prefix> [<block>]    print the keys starting with <prefix>, every key when '*'
+  subscribe <store> [<key prefix>]     print the deltas of a store as they come, until Enter
+  help                                 print this help
+  exit                                 leave
+
+Stores are named after their module, the 'store_' prefix can be omitted,
+like 'get pairs pair:0x...'. Keys are read at the head of the state server
+unless <block> is given.
+`
+
+// Config tells where the pipeline serves its stores.
+type Config struct {
+	// StateURL is the base URL of the state server, `state serve` or
+	// `follow`, like `http://localhost:8091`.
+	StateURL string
+	// Token is sent as `Authorization: Bearer <token>` to the state server
+	// when set.
+	Token string
+	// FeedURL is the WebSocket endpoint of the `ws` output of the run, like
+	// `ws://localhost:8095/`.
+	FeedURL string
+}
+
+// REPL reads commands, one per line, and prints their results.
+type REPL struct {
+	config Config
+	client *http.Client
+	out    io.Writer
+}
+
+func New(config Config, out io.Writer) *REPL {
+	return &REPL{config: config, client: http.DefaultClient, out: out}
+}
+
+// Run reads the commands from `in` until it ends, `exit` is entered or `ctx`
+// is done. A command failing prints its error, the next one is read.
+func (r *REPL) Run(ctx context.Context, in io.Reader) error {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		fmt.Fprint(r.out, "> ")
+		var line string
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				fmt.Fprintln(r.out)
+				return nil
+			}
+			line = l
+		case <-ctx.Done():
+			return nil
+		}
+
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+		if err := r.exec(ctx, args, lines); err != nil {
+			fmt.Fprintf(r.out, "error: %s\n", err)
+		}
+	}
+}
+
+func (r *REPL) exec(ctx context.Context, args []string, lines <-chan string) error {
+	command, args := args[0], args[1:]
+	switch command {
+	case "help":
+		fmt.Fprint(r.out, help)
+		return nil
+	case "stores":
+		return r.stores(ctx)
+	case "get":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: get <store> <key> [<block>]")
+		}
+		return r.get(ctx, args[0], args[1], blockArg(args, 2))
+	case "prefix":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: prefix <store> <prefix> [<block>]")
+		}
+		prefix := args[1]
+		if prefix == "*" {
+			prefix = ""
+		}
+		return r.prefix(ctx, args[0], prefix, blockArg(args, 2))
+	case "subscribe":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("usage: subscribe <store> [<key prefix>]")
+		}
+		prefix := ""
+		if len(args) == 2 {
+			prefix = args[1]
+		}
+		return r.subscribe(ctx, args[0], prefix, lines)
+	}
+	return fmt.Errorf("unknown command %q, see 'help'", command)
+}
+
+func blockArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return "head"
+}
+
+func (r *REPL) stores(ctx context.Context) error {
+	namespaces := map[string][]string{}
+	if err := r.query(ctx, "/topics", nil, &namespaces); err != nil {
+		return err
+	}
+
+	var names []string
+	for namespace := range namespaces {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+	for _, namespace := range names {
+		for _, store := range namespaces[namespace] {
+			fmt.Fprintln(r.out, store)
+		}
+	}
+	return nil
+}
+
+func (r *REPL) get(ctx context.Context, store, key, block string) error {
+	result := &state.Result{}
+	if err := r.query(ctx, "/state", url.Values{"store": {store}, "key": {key}, "block": {block}}, result); err != nil {
+		return err
+	}
+	if !result.Found {
+		fmt.Fprintf(r.out, "%s: not found in %s at #%d\n", key, result.Store, result.Block)
+		return nil
+	}
+	fmt.Fprintln(r.out, result.Value)
+	return nil
+}
+
+func (r *REPL) prefix(ctx context.Context, store, prefix, block string) error {
+	result := &state.Result{}
+	if err := r.query(ctx, "/state", url.Values{"store": {store}, "prefix": {prefix}, "block": {block}}, result); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(result.Values))
+	for key := range result.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", key, result.Values[key])
+	}
+	fmt.Fprintf(tw, "(%d keys in %s at #%d)\n", len(keys), result.Store, result.Block)
+	return tw.Flush()
+}
+
+func (r *REPL) query(ctx context.Context, path string, values url.Values, into interface{}) error {
+	if r.config.StateURL == "" {
+		return fmt.Errorf("no state server, see --state-url")
+	}
+
+	target := strings.TrimSuffix(r.config.StateURL, "/") + path
+	if values != nil {
+		target += "?" + values.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if r.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+// feedRecord is a record of the feed, see `sink.Record.MarshalJSON`.
+type feedRecord struct {
+	Module   string          `json:"module"`
+	BlockNum uint64          `json:"block_num"`
+	Step     string          `json:"step"`
+	Type     string          `json:"type"`
+	Entity   json.RawMessage `json:"entity"`
+}
+
+// subscribe prints the deltas of `store` whose key starts with `prefix` until
+// a line is entered.
+func (r *REPL) subscribe(ctx context.Context, store, prefix string, lines <-chan string) error {
+	if r.config.FeedURL == "" {
+		return fmt.Errorf("no feed, see --feed-url")
+	}
+	conn, err := websocket.Dial(r.config.FeedURL, "", "http://localhost/")
+	if err != nil {
+		return fmt.Errorf("connect to feed %q: %w", r.config.FeedURL, err)
+	}
+	defer conn.Close()
+
+	messages := make(chan string)
+	received := make(chan error, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			var message string
+			if err := websocket.Message.Receive(conn, &message); err != nil {
+				received <- err
+				return
+			}
+			select {
+			case messages <- message:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	fmt.Fprintf(r.out, "following %s, press Enter to stop\n", store)
+	for {
+		select {
+		case message := <-messages:
+			if err := r.printDelta(message, store, prefix); err != nil {
+				return err
+			}
+		case err := <-received:
+			if err == io.EOF {
+				return fmt.Errorf("feed closed")
+			}
+			return fmt.Errorf("feed: %w", err)
+		case <-lines:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *REPL) printDelta(message string, store, prefix string) error {
+	record := &feedRecord{}
+	if err := json.Unmarshal([]byte(message), record); err != nil {
+		return fmt.Errorf("decode feed record: %w", err)
+	}
+	if record.Module != store && record.Module != "store_"+store {
+		return nil
+	}
+	if record.Type != "sf.substreams.v1.StoreDelta" {
+		return nil
+	}
+
+	delta := &pbsubstreams.StoreDelta{}
+	if err := protojson.Unmarshal(record.Entity, delta); err != nil {
+		return fmt.Errorf("decode delta: %w", err)
+	}
+	if !strings.HasPrefix(delta.Key, prefix) {
+		return nil
+	}
+
+	return sink.ConsoleRenderer{}.Render(r.out, &sink.Record{
+		Module:   record.Module,
+		BlockNum: record.BlockNum,
+		Step:     pbsubstreams.ForkStep(pbsubstreams.ForkStep_value[record.Step]),
+		Entity:   delta,
+	})
+}
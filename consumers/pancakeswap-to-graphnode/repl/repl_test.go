@@ -0,0 +1,117 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	"github.com/streamingfast/substream-pancakeswap/sink/wsfeed"
+	"github.com/streamingfast/substream-pancakeswap/state"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func pairsData(num uint64, deltas ...*pbsubstreams.StoreDelta) *pbsubstreams.BlockScopedData {
+	return &pbsubstreams.BlockScopedData{
+		Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Clock: &pbsubstreams.Clock{Id: "abc", Number: num},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_pairs", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}}},
+		},
+	}
+}
+
+func TestREPL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storeURL := "file://" + filepath.Join(t.TempDir(), "log")
+	log, err := deltalog.New(&deltalog.Config{StoreURL: storeURL, SegmentSize: 100})
+	require.NoError(t, err)
+	require.NoError(t, log.Write(ctx, pairsData(10,
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "pair:0xaa", NewValue: []byte("WBNB/CAKE")},
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "pair:0xbb", NewValue: []byte("WBNB/BUSD")},
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "count", NewValue: []byte("2")},
+	)))
+	require.NoError(t, log.Close())
+
+	reader, err := deltalog.NewReader(storeURL)
+	require.NoError(t, err)
+	follower := state.NewFollower(reader)
+	require.NoError(t, follower.Poll(ctx))
+	server := state.NewServer(reader, follower)
+	require.NoError(t, server.Listen("127.0.0.1:0"))
+	defer server.Close()
+
+	feed, err := wsfeed.New(&wsfeed.Config{Addr: "127.0.0.1:0", Path: "/", Buffer: 16})
+	require.NoError(t, err)
+	defer feed.Close()
+
+	in, commands := io.Pipe()
+	out := &syncBuffer{}
+	r := New(Config{StateURL: "http://" + server.Addr(), FeedURL: "ws://" + feed.Addr() + "/"}, out)
+	done := make(chan error)
+	go func() { done <- r.Run(ctx, in) }()
+
+	send := func(line string, expected string) {
+		t.Helper()
+		_, err := io.WriteString(commands, line+"\n")
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return strings.Contains(out.String(), expected) }, 5*time.Second, time.Millisecond, "waiting for %q, got:\n%s", expected, out.String())
+	}
+
+	send("stores", "store_pairs\n")
+	send("get pairs pair:0xaa", "WBNB/CAKE\n")
+	send("get store_pairs pair:0xcc", "pair:0xcc: not found in store_pairs at #10\n")
+	send("prefix pairs pair:", "pair:0xaa  WBNB/CAKE\npair:0xbb  WBNB/BUSD\n(2 keys in store_pairs at #10)\n")
+	send("prefix volumes pair:", "error: 404 Not Found: store not found in the delta log")
+	send("get pairs", "error: usage: get <store> <key> [<block>]")
+	send("drop pairs", `error: unknown command "drop", see 'help'`)
+
+	send("subscribe pairs pair:", "following pairs, press Enter to stop\n")
+	// the feed broadcasts to the clients connected when writing
+	require.Eventually(t, func() bool {
+		err := feed.Write(ctx, pairsData(11,
+			&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "count", OldValue: []byte("2"), NewValue: []byte("3")},
+			&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "pair:0xcc", NewValue: []byte("CAKE/BUSD")},
+		))
+		return err == nil && strings.Contains(out.String(), `#11 store_pairs CREATE pair:0xcc: "CAKE/BUSD"`)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, out.String(), "UPDATE count", "only the keys of the prefix are printed")
+	// Enter stops the subscription
+	send("", "")
+	send("get pairs count", "> 2\n")
+
+	_, err = io.WriteString(commands, "exit\n")
+	require.NoError(t, err)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("repl didn't exit")
+	}
+}
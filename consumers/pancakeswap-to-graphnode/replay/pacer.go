@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Pacer delays the delivery of historical blocks so they arrive at the cadence
+// they were produced at on chain, as if the range was being followed live. The
+// wait between two blocks is the difference of their timestamps divided by the
+// speed, a speed of 2 replays twice as fast as the chain.
+type Pacer struct {
+	speed float64
+
+	lastBlockTime time.Time
+	lastRelease   time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewPacer(speed float64) (*Pacer, error) {
+	if err := ValidateSpeed(speed); err != nil {
+		return nil, err
+	}
+
+	return &Pacer{speed: speed, now: time.Now, sleep: sleep}, nil
+}
+
+func ValidateSpeed(speed float64) error {
+	if speed <= 0 {
+		return fmt.Errorf("replay speed must be positive, got %v", speed)
+	}
+	return nil
+}
+
+// SetSpeed changes the speed from the next block on, it must not be called
+// concurrently with `Wait`.
+func (p *Pacer) SetSpeed(speed float64) error {
+	if err := ValidateSpeed(speed); err != nil {
+		return err
+	}
+	p.speed = speed
+	return nil
+}
+
+func (p *Pacer) Speed() float64 {
+	return p.speed
+}
+
+// Wait blocks until the block of `clock` is due. The first block is released
+// right away.
+func (p *Pacer) Wait(ctx context.Context, clock *pbsubstreams.Clock) error {
+	blockTime := clock.GetTimestamp().AsTime()
+	defer func() {
+		p.lastBlockTime = blockTime
+		p.lastRelease = p.now()
+	}()
+
+	if p.lastRelease.IsZero() {
+		return nil
+	}
+
+	wait := p.delay(blockTime) - p.now().Sub(p.lastRelease)
+	if wait <= 0 {
+		return nil
+	}
+
+	return p.sleep(ctx, wait)
+}
+
+func (p *Pacer) delay(blockTime time.Time) time.Duration {
+	elapsed := blockTime.Sub(p.lastBlockTime)
+	if elapsed <= 0 {
+		return 0
+	}
+	return time.Duration(float64(elapsed) / p.speed)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPacer_Wait(t *testing.T) {
+	tests := []struct {
+		name          string
+		speed         float64
+		blockSeconds  []int64
+		processing    time.Duration
+		expectedSleep []time.Duration
+	}{
+		{"real time", 1, []int64{0, 3, 6}, 0, []time.Duration{3 * time.Second, 3 * time.Second}},
+		{"twice as fast", 2, []int64{0, 3, 9}, 0, []time.Duration{1500 * time.Millisecond, 3 * time.Second}},
+		{"processing time is deducted", 1, []int64{0, 3}, time.Second, []time.Duration{2 * time.Second}},
+		{"slower than the chain", 1, []int64{0, 3}, 5 * time.Second, nil},
+		{"same timestamp", 1, []int64{0, 0}, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewPacer(test.speed)
+			require.NoError(t, err)
+
+			now := time.Unix(1_000_000, 0)
+			var sleeps []time.Duration
+			p.now = func() time.Time { return now }
+			p.sleep = func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				now = now.Add(d)
+				return nil
+			}
+
+			for _, seconds := range test.blockSeconds {
+				require.NoError(t, p.Wait(context.Background(), &pbsubstreams.Clock{Timestamp: timestamppb.New(time.Unix(seconds, 0))}))
+				now = now.Add(test.processing)
+			}
+
+			assert.Equal(t, test.expectedSleep, sleeps)
+		})
+	}
+}
+
+func TestNewPacer_InvalidSpeed(t *testing.T) {
+	_, err := NewPacer(0)
+	require.Error(t, err)
+}
@@ -0,0 +1,342 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/params"
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/rpcusage"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Summary accumulates statistics over the blocks of a run, printed once the run
+// completes. Comparing the summaries of two code versions over the same range is
+// a cheap regression check.
+type Summary struct {
+	started time.Time
+	// reversible holds the counts of the last blocks of the new step, taken
+	// back when they are undone.
+	reversible map[string]*blockCounts
+
+	FirstBlock   uint64                 `json:"first_block"`
+	LastBlock    uint64                 `json:"last_block"`
+	Blocks       uint64                 `json:"blocks"`
+	Duration     time.Duration          `json:"duration_ns"`
+	PairsCreated uint64                 `json:"pairs_created"`
+	Swaps        uint64                 `json:"swaps"`
+	Mints        uint64                 `json:"mints"`
+	Burns        uint64                 `json:"burns"`
+	VolumeUSD    *big.Float             `json:"volume_usd"`
+	Stores       map[string]*StoreStats `json:"stores"`
+
+	// StopReason is set by the caller to why the run ended.
+	StopReason string `json:"stop_reason,omitempty"`
+
+	// ParamChanges are the params changed while running, in order.
+	ParamChanges []params.Change `json:"param_changes,omitempty"`
+
+	// RPC is set by the caller to the JSON-RPC calls made by the run, like
+	// the polling of the network head. The calls of the modules are made by
+	// the substreams server, there is no cache of them to report here, the
+	// Go modules pipeline of `bench run --rpc-cache-size` reports its cache.
+	RPC *rpcusage.Report `json:"rpc,omitempty"`
+
+	// UnknownEvents are the event signatures of `map_event_signatures` missing
+	// from the bundled signature database, by topic0.
+	UnknownEvents map[string]*UnknownEvent `json:"unknown_events,omitempty"`
+}
+
+// UnknownEvent is an event signature emitted by tracked contracts that could not
+// be resolved.
+type UnknownEvent struct {
+	Logs       uint64   `json:"logs"`
+	FirstBlock uint64   `json:"first_block"`
+	Contracts  []string `json:"contracts"`
+}
+
+// StoreStats counts the deltas of a store, `Keys` is the number of keys created
+// minus the number of keys deleted during the run.
+type StoreStats struct {
+	Deltas  uint64 `json:"deltas"`
+	Created uint64 `json:"created"`
+	Updated uint64 `json:"updated"`
+	Deleted uint64 `json:"deleted"`
+	Keys    int64  `json:"keys"`
+}
+
+// reversibleDepth is how many blocks below the last one an undo is still
+// expected, far deeper than the reorgs of the chains streamed.
+const reversibleDepth = 1000
+
+// blockCounts are the counts of a block, added to the summary and taken back
+// when the block is undone.
+type blockCounts struct {
+	num          uint64
+	pairsCreated uint64
+	swaps        uint64
+	mints        uint64
+	burns        uint64
+	volumeUSD    *big.Float
+	stores       map[string]*StoreStats
+}
+
+func NewSummary() *Summary {
+	return &Summary{
+		started:    time.Now(),
+		reversible: map[string]*blockCounts{},
+		VolumeUSD:  new(big.Float),
+		Stores:     map[string]*StoreStats{},
+	}
+}
+
+// Observe adds the outputs of a block to the summary. Pairs, swaps, mints and
+// burns are counted from the `pcs.types.v1.Pairs` and `pcs.types.v1.Events` map
+// outputs, unknown events from `pcs.types.v1.EventSignatures`, so the matching
+// modules must be part of the output modules. An undone block takes back the
+// counts of the block, unknown events excepted.
+func (s *Summary) Observe(data *pbsubstreams.BlockScopedData) {
+	num := data.Clock.GetNumber()
+	if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		if counts, found := s.reversible[data.Clock.GetId()]; found {
+			delete(s.reversible, data.Clock.GetId())
+			s.subtract(counts)
+			s.LastBlock = num - 1
+		}
+		return
+	}
+
+	if s.Blocks == 0 {
+		s.FirstBlock = num
+	}
+	s.LastBlock = num
+
+	counts := &blockCounts{num: num, volumeUSD: new(big.Float), stores: map[string]*StoreStats{}}
+	for _, output := range data.Outputs {
+		if deltas := output.GetStoreDeltas(); deltas != nil {
+			counts.observeDeltas(output.Name, deltas)
+			continue
+		}
+
+		if mapOutput := output.GetMapOutput(); mapOutput != nil {
+			s.observeMapOutput(counts, mapOutput)
+		}
+	}
+	s.add(counts)
+
+	if data.Step == pbsubstreams.ForkStep_STEP_NEW {
+		s.reversible[data.Clock.GetId()] = counts
+		for id, c := range s.reversible {
+			if c.num+reversibleDepth < num {
+				delete(s.reversible, id)
+			}
+		}
+	}
+}
+
+func (s *Summary) add(counts *blockCounts) {
+	s.Blocks++
+	s.PairsCreated += counts.pairsCreated
+	s.Swaps += counts.swaps
+	s.Mints += counts.mints
+	s.Burns += counts.burns
+	s.VolumeUSD.Add(s.VolumeUSD, counts.volumeUSD)
+	for store, counted := range counts.stores {
+		stats, found := s.Stores[store]
+		if !found {
+			stats = &StoreStats{}
+			s.Stores[store] = stats
+		}
+		stats.Deltas += counted.Deltas
+		stats.Created += counted.Created
+		stats.Updated += counted.Updated
+		stats.Deleted += counted.Deleted
+		stats.Keys += counted.Keys
+	}
+}
+
+func (s *Summary) subtract(counts *blockCounts) {
+	s.Blocks--
+	s.PairsCreated -= counts.pairsCreated
+	s.Swaps -= counts.swaps
+	s.Mints -= counts.mints
+	s.Burns -= counts.burns
+	s.VolumeUSD.Sub(s.VolumeUSD, counts.volumeUSD)
+	for store, counted := range counts.stores {
+		stats := s.Stores[store]
+		stats.Deltas -= counted.Deltas
+		stats.Created -= counted.Created
+		stats.Updated -= counted.Updated
+		stats.Deleted -= counted.Deleted
+		stats.Keys -= counted.Keys
+	}
+}
+
+func (c *blockCounts) observeDeltas(store string, deltas *pbsubstreams.StoreDeltas) {
+	stats, found := c.stores[store]
+	if !found {
+		stats = &StoreStats{}
+		c.stores[store] = stats
+	}
+
+	for _, delta := range deltas.Deltas {
+		stats.Deltas++
+		switch delta.Operation {
+		case pbsubstreams.StoreDelta_CREATE:
+			stats.Created++
+			stats.Keys++
+		case pbsubstreams.StoreDelta_UPDATE:
+			stats.Updated++
+		case pbsubstreams.StoreDelta_DELETE:
+			stats.Deleted++
+			stats.Keys--
+		}
+	}
+}
+
+func (s *Summary) observeMapOutput(counts *blockCounts, output *anypb.Any) {
+	msg, err := anypb.UnmarshalNew(output, proto.UnmarshalOptions{})
+	if err != nil {
+		return
+	}
+
+	switch m := msg.(type) {
+	case *pbpcs.Pairs:
+		counts.pairsCreated += uint64(len(m.Pairs))
+	case *pbpcs.EventSignatures:
+		s.observeSignatures(m)
+	case *pbpcs.Events:
+		for _, event := range m.Events {
+			switch {
+			case event.GetSwap() != nil:
+				counts.swaps++
+				if usd, ok := new(big.Float).SetString(event.GetSwap().AmountUsd); ok {
+					counts.volumeUSD.Add(counts.volumeUSD, usd)
+				}
+			case event.GetMint() != nil:
+				counts.mints++
+			case event.GetBurn() != nil:
+				counts.burns++
+			}
+		}
+	}
+}
+
+func (s *Summary) observeSignatures(signatures *pbpcs.EventSignatures) {
+	for _, signature := range signatures.Signatures {
+		if signature.Signature != "" {
+			continue
+		}
+
+		if s.UnknownEvents == nil {
+			s.UnknownEvents = map[string]*UnknownEvent{}
+		}
+		unknown, found := s.UnknownEvents[signature.Topic0]
+		if !found {
+			unknown = &UnknownEvent{FirstBlock: s.LastBlock}
+			s.UnknownEvents[signature.Topic0] = unknown
+		}
+
+		unknown.Logs += signature.Count
+		if !containsString(unknown.Contracts, signature.ContractAddress) {
+			unknown.Contracts = append(unknown.Contracts, signature.ContractAddress)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Done marks the end of the run.
+func (s *Summary) Done() {
+	s.Duration = time.Since(s.started)
+}
+
+func (s *Summary) Print(w io.Writer) {
+	fmt.Fprintf(w, "Run summary\n")
+	fmt.Fprintf(w, "  Blocks:        %d (#%d to #%d) in %s", s.Blocks, s.FirstBlock, s.LastBlock, s.Duration.Round(time.Millisecond))
+	if seconds := s.Duration.Seconds(); seconds > 0 {
+		fmt.Fprintf(w, ", %.1f blocks/s", float64(s.Blocks)/seconds)
+	}
+	fmt.Fprintf(w, "\n")
+	if s.StopReason != "" {
+		fmt.Fprintf(w, "  Stopped:       %s\n", s.StopReason)
+	}
+	fmt.Fprintf(w, "  Pairs created: %d\n", s.PairsCreated)
+	fmt.Fprintf(w, "  Swaps:         %d\n", s.Swaps)
+	fmt.Fprintf(w, "  Mints:         %d\n", s.Mints)
+	fmt.Fprintf(w, "  Burns:         %d\n", s.Burns)
+	fmt.Fprintf(w, "  Volume USD:    %s\n", s.VolumeUSD.Text('f', 2))
+	for _, change := range s.ParamChanges {
+		fmt.Fprintf(w, "  Param changed: %s %q -> %q after #%d\n", change.Name, change.Old, change.New, change.AfterBlock)
+	}
+	if s.RPC != nil {
+		fmt.Fprintf(w, "  RPC calls:     %d (%d bytes), cost %.4f\n", s.RPC.Calls, s.RPC.Bytes, s.RPC.Cost)
+	}
+	if len(s.UnknownEvents) > 0 {
+		var topics []string
+		for topic0 := range s.UnknownEvents {
+			topics = append(topics, topic0)
+		}
+		sort.Slice(topics, func(i, j int) bool {
+			left, right := s.UnknownEvents[topics[i]], s.UnknownEvents[topics[j]]
+			if left.Logs != right.Logs {
+				return left.Logs > right.Logs
+			}
+			return topics[i] < topics[j]
+		})
+
+		fmt.Fprintf(w, "  Unknown events:\n")
+		for _, topic0 := range topics {
+			unknown := s.UnknownEvents[topic0]
+			fmt.Fprintf(w, "    0x%s: %d logs from %d contracts since #%d\n", topic0, unknown.Logs, len(unknown.Contracts), unknown.FirstBlock)
+		}
+	}
+
+	if len(s.Stores) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range s.Stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "  Stores:\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "    \tdeltas\tcreated\tupdated\tdeleted\tkeys\t\n")
+	for _, name := range names {
+		stats := s.Stores[name]
+		fmt.Fprintf(tw, "    %s\t%d\t%d\t%d\t%d\t%d\t\n", name, stats.Deltas, stats.Created, stats.Updated, stats.Deleted, stats.Keys)
+	}
+	tw.Flush()
+}
+
+// WriteJSON writes the summary as JSON to `path`.
+func (s *Summary) WriteJSON(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal summary: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("write summary %q: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func mapOutput(t *testing.T, name string, msg proto.Message) *pbsubstreams.ModuleOutput {
+	t.Helper()
+	any, err := anypb.New(msg)
+	require.NoError(t, err)
+	return &pbsubstreams.ModuleOutput{Name: name, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: any}}
+}
+
+func deltasOutput(name string, ops ...pbsubstreams.StoreDelta_Operation) *pbsubstreams.ModuleOutput {
+	deltas := &pbsubstreams.StoreDeltas{}
+	for _, op := range ops {
+		deltas.Deltas = append(deltas.Deltas, &pbsubstreams.StoreDelta{Operation: op, Key: "k"})
+	}
+	return &pbsubstreams.ModuleOutput{Name: name, Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: deltas}}
+}
+
+func TestSummary_Observe(t *testing.T) {
+	s := NewSummary()
+
+	s.Observe(&pbsubstreams.BlockScopedData{
+		Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Clock: &pbsubstreams.Clock{Number: 100},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			mapOutput(t, "map_pairs", &pbpcs.Pairs{Pairs: []*pbpcs.Pair{{}, {}}}),
+			deltasOutput("store_pairs", pbsubstreams.StoreDelta_CREATE, pbsubstreams.StoreDelta_CREATE),
+		},
+	})
+	s.Observe(&pbsubstreams.BlockScopedData{
+		Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Clock: &pbsubstreams.Clock{Number: 101},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			mapOutput(t, "map_events", &pbpcs.Events{Events: []*pbpcs.Event{
+				{Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: "10.25"}}},
+				{Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: "4.75"}}},
+				{Type: &pbpcs.Event_Mint{Mint: &pbpcs.Mint{}}},
+				{Type: &pbpcs.Event_Burn{Burn: &pbpcs.Burn{}}},
+			}}),
+			mapOutput(t, "map_event_signatures", &pbpcs.EventSignatures{Signatures: []*pbpcs.EventSignature{
+				{Topic0: "1c411e9a96e071241c2f21f7726b17ae89e3cab4c78be50e062b03a9fffbbad1", Signature: "Sync(uint112,uint112)", ContractAddress: "0xa", Count: 3},
+				{Topic0: "feed", ContractAddress: "0xa", Count: 2},
+				{Topic0: "feed", ContractAddress: "0xb", Count: 1},
+			}}),
+			deltasOutput("store_pairs", pbsubstreams.StoreDelta_UPDATE, pbsubstreams.StoreDelta_DELETE),
+		},
+	})
+	s.Observe(&pbsubstreams.BlockScopedData{
+		Step:    pbsubstreams.ForkStep_STEP_UNDO,
+		Clock:   &pbsubstreams.Clock{Number: 101},
+		Outputs: []*pbsubstreams.ModuleOutput{deltasOutput("store_pairs", pbsubstreams.StoreDelta_CREATE)},
+	})
+	s.Done()
+
+	assert.Equal(t, uint64(100), s.FirstBlock)
+	assert.Equal(t, uint64(101), s.LastBlock)
+	assert.Equal(t, uint64(2), s.Blocks)
+	assert.Equal(t, uint64(2), s.PairsCreated)
+	assert.Equal(t, uint64(2), s.Swaps)
+	assert.Equal(t, uint64(1), s.Mints)
+	assert.Equal(t, uint64(1), s.Burns)
+	assert.Equal(t, "15.00", s.VolumeUSD.Text('f', 2))
+	assert.Equal(t, &StoreStats{Deltas: 4, Created: 2, Updated: 1, Deleted: 1, Keys: 1}, s.Stores["store_pairs"])
+	assert.Equal(t, map[string]*UnknownEvent{"feed": {Logs: 3, FirstBlock: 101, Contracts: []string{"0xa", "0xb"}}}, s.UnknownEvents)
+
+	out := &bytes.Buffer{}
+	s.Print(out)
+	assert.Contains(t, out.String(), "Volume USD:    15.00")
+	assert.Contains(t, out.String(), "store_pairs")
+	assert.Contains(t, out.String(), "0xfeed: 3 logs from 2 contracts since #101")
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	require.NoError(t, s.WriteJSON(path))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	assert.Equal(t, float64(2), decoded["swaps"])
+	assert.Equal(t, "15", decoded["volume_usd"])
+}
+
+func TestSummary_ObserveUndo(t *testing.T) {
+	s := NewSummary()
+
+	block := func(num uint64, id string, step pbsubstreams.ForkStep, amountUSD string) *pbsubstreams.BlockScopedData {
+		return &pbsubstreams.BlockScopedData{
+			Step:  step,
+			Clock: &pbsubstreams.Clock{Number: num, Id: id},
+			Outputs: []*pbsubstreams.ModuleOutput{
+				mapOutput(t, "map_events", &pbpcs.Events{Events: []*pbpcs.Event{
+					{Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{AmountUsd: amountUSD}}},
+				}}),
+				deltasOutput("store_pairs", pbsubstreams.StoreDelta_CREATE, pbsubstreams.StoreDelta_UPDATE),
+			},
+		}
+	}
+
+	s.Observe(block(100, "100a", pbsubstreams.ForkStep_STEP_NEW, "1"))
+	s.Observe(block(101, "101a", pbsubstreams.ForkStep_STEP_NEW, "10"))
+	// the undo as received carries no outputs
+	s.Observe(&pbsubstreams.BlockScopedData{Step: pbsubstreams.ForkStep_STEP_UNDO, Clock: &pbsubstreams.Clock{Number: 101, Id: "101a"}})
+
+	assert.Equal(t, uint64(100), s.LastBlock)
+	assert.Equal(t, uint64(1), s.Blocks)
+	assert.Equal(t, uint64(1), s.Swaps)
+	assert.Equal(t, "1", s.VolumeUSD.Text('f', -1))
+	assert.Equal(t, &StoreStats{Deltas: 2, Created: 1, Updated: 1, Keys: 1}, s.Stores["store_pairs"])
+
+	s.Observe(block(101, "101b", pbsubstreams.ForkStep_STEP_NEW, "2"))
+	assert.Equal(t, uint64(101), s.LastBlock)
+	assert.Equal(t, uint64(2), s.Blocks)
+	assert.Equal(t, "3", s.VolumeUSD.Text('f', -1))
+	assert.Equal(t, &StoreStats{Deltas: 4, Created: 2, Updated: 2, Keys: 2}, s.Stores["store_pairs"])
+}
@@ -0,0 +1,189 @@
+// Package rpccache keeps the responses of `eth_call`s in memory, in front of the
+// JSON-RPC backend of the modules. The least recently used responses are
+// evicted past the configured size, and concurrent modules asking for the same
+// call while it's in flight share the single backend call.
+package rpccache
+
+import (
+	"container/list"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// Stats counts the activity of a `Cache`, `Shared` are the calls answered by a
+// call already in flight and `Uncached` the calls against the head of the
+// chain, which always go to the backend.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Shared    uint64 `json:"shared"`
+	Uncached  uint64 `json:"uncached"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// Cache is a `sdk.RPC` holding up to `size` responses, keyed by the block the
+// calls are pinned to. Responses with a call error are cached as they are
+// deterministic, backend failures are not. The responses are shared between
+// callers and must not be modified.
+type Cache struct {
+	backend sdk.RPC
+	size    int
+
+	mu       sync.Mutex
+	lru      *list.List
+	entries  map[string]*list.Element
+	inflight map[string]*flight
+	stats    Stats
+}
+
+type entry struct {
+	key      string
+	response *sdk.RPCResponse
+}
+
+// flight is a backend call in progress, `done` is closed once `response` or
+// `err` is set.
+type flight struct {
+	done     chan struct{}
+	response *sdk.RPCResponse
+	err      error
+}
+
+func New(backend sdk.RPC, size int) (*Cache, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("cache size must be positive, got %d", size)
+	}
+	return &Cache{
+		backend:  backend,
+		size:     size,
+		lru:      list.New(),
+		entries:  map[string]*list.Element{},
+		inflight: map[string]*flight{},
+	}, nil
+}
+
+// Call answers the calls from the cache, the misses not in flight already being
+// sent to the backend in a single batch.
+func (c *Cache) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	out := make([]*sdk.RPCResponse, len(calls))
+	waiting := map[int]*flight{}
+
+	var missIndexes []int
+	var misses []*sdk.RPCCall
+	var owned []*flight
+
+	c.mu.Lock()
+	for i, call := range calls {
+		if call.Latest {
+			c.stats.Uncached++
+			missIndexes = append(missIndexes, i)
+			misses = append(misses, call)
+			owned = append(owned, nil)
+			continue
+		}
+
+		key := callKey(call)
+		if element, found := c.entries[key]; found {
+			c.lru.MoveToFront(element)
+			out[i] = element.Value.(*entry).response
+			c.stats.Hits++
+			continue
+		}
+
+		if f, found := c.inflight[key]; found {
+			waiting[i] = f
+			c.stats.Shared++
+			continue
+		}
+
+		f := &flight{done: make(chan struct{})}
+		c.inflight[key] = f
+		c.stats.Misses++
+		missIndexes = append(missIndexes, i)
+		misses = append(misses, call)
+		owned = append(owned, f)
+	}
+	c.mu.Unlock()
+
+	if len(misses) > 0 {
+		responses, err := c.backend.Call(misses)
+		if err == nil && len(responses) != len(misses) {
+			err = fmt.Errorf("backend returned %d responses for %d calls", len(responses), len(misses))
+		}
+
+		c.mu.Lock()
+		for j, f := range owned {
+			if f == nil {
+				if err == nil {
+					out[missIndexes[j]] = responses[j]
+				}
+				continue
+			}
+
+			key := callKey(misses[j])
+			delete(c.inflight, key)
+			if err != nil {
+				f.err = err
+				continue
+			}
+			f.response = responses[j]
+			out[missIndexes[j]] = responses[j]
+			c.add(key, responses[j])
+		}
+		c.mu.Unlock()
+
+		for _, f := range owned {
+			if f != nil {
+				close(f.done)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, f := range waiting {
+		<-f.done
+		if f.err != nil {
+			return nil, f.err
+		}
+		out[i] = f.response
+	}
+	return out, nil
+}
+
+// add must be called with the lock held.
+func (c *Cache) add(key string, response *sdk.RPCResponse) {
+	if element, found := c.entries[key]; found {
+		c.lru.MoveToFront(element)
+		element.Value.(*entry).response = response
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{key: key, response: response})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+func callKey(call *sdk.RPCCall) string {
+	return strconv.FormatUint(call.BlockNum, 10) + ":" + strings.ToLower(call.ToAddr) + ":" + hex.EncodeToString(call.Data)
+}
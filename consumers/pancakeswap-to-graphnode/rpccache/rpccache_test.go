@@ -0,0 +1,131 @@
+package rpccache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backend answers each call with its data, counting the calls it got.
+type backend struct {
+	mu      sync.Mutex
+	calls   int
+	err     error
+	release chan struct{}
+}
+
+func (b *backend) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	if b.release != nil {
+		<-b.release
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls += len(calls)
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var out []*sdk.RPCResponse
+	for _, call := range calls {
+		out = append(out, &sdk.RPCResponse{Raw: append([]byte(call.ToAddr+":"), call.Data...)})
+	}
+	return out, nil
+}
+
+func call(to string, data byte) *sdk.RPCCall {
+	return &sdk.RPCCall{ToAddr: to, Data: []byte{data}}
+}
+
+func TestCache(t *testing.T) {
+	b := &backend{}
+	cache, err := New(b, 2)
+	require.NoError(t, err)
+
+	responses, err := cache.Call([]*sdk.RPCCall{call("0xa", 1), call("0xb", 1)})
+	require.NoError(t, err)
+	assert.Equal(t, "0xa:\x01", string(responses[0].Raw))
+	assert.Equal(t, "0xb:\x01", string(responses[1].Raw))
+	assert.Equal(t, 2, b.calls)
+
+	// Addresses are matched regardless of their case
+	responses, err = cache.Call([]*sdk.RPCCall{call("0xA", 1)})
+	require.NoError(t, err)
+	assert.Equal(t, "0xa:\x01", string(responses[0].Raw))
+	assert.Equal(t, 2, b.calls)
+
+	// 0xb is the least recently used
+	_, err = cache.Call([]*sdk.RPCCall{call("0xc", 1)})
+	require.NoError(t, err)
+	_, err = cache.Call([]*sdk.RPCCall{call("0xa", 1), call("0xb", 1)})
+	require.NoError(t, err)
+	assert.Equal(t, 4, b.calls)
+
+	assert.Equal(t, Stats{Hits: 2, Misses: 4, Evictions: 2, Entries: 2}, cache.Stats())
+
+	// The same call at another block, or against the head of the chain, is
+	// another call
+	_, err = cache.Call([]*sdk.RPCCall{{ToAddr: "0xa", Data: []byte{1}, BlockNum: 10}, {ToAddr: "0xa", Data: []byte{1}, Latest: true}})
+	require.NoError(t, err)
+	_, err = cache.Call([]*sdk.RPCCall{{ToAddr: "0xa", Data: []byte{1}, Latest: true}})
+	require.NoError(t, err)
+	assert.Equal(t, 7, b.calls)
+	assert.Equal(t, Stats{Hits: 2, Misses: 5, Uncached: 2, Evictions: 3, Entries: 2}, cache.Stats())
+
+	_, err = New(b, 0)
+	assert.Error(t, err)
+}
+
+func TestCache_BackendFailure(t *testing.T) {
+	b := &backend{err: errors.New("unavailable")}
+	cache, err := New(b, 10)
+	require.NoError(t, err)
+
+	_, err = cache.Call([]*sdk.RPCCall{call("0xa", 1)})
+	require.Error(t, err)
+
+	b.err = nil
+	responses, err := cache.Call([]*sdk.RPCCall{call("0xa", 1)})
+	require.NoError(t, err)
+	assert.Equal(t, "0xa:\x01", string(responses[0].Raw))
+	assert.Equal(t, 2, b.calls)
+}
+
+func TestCache_SharedInFlight(t *testing.T) {
+	b := &backend{release: make(chan struct{})}
+	cache, err := New(b, 10)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	results := make([][]*sdk.RPCResponse, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses, err := cache.Call([]*sdk.RPCCall{call("0xa", 1)})
+			assert.NoError(t, err)
+			results[i] = responses
+		}(i)
+	}
+
+	// Wait for all the callers to be either calling the backend or waiting
+	// for the call in flight
+	for {
+		stats := cache.Stats()
+		if stats.Misses+stats.Shared == 5 {
+			break
+		}
+	}
+	close(b.release)
+	wg.Wait()
+
+	assert.Equal(t, 1, b.calls)
+	assert.Equal(t, Stats{Misses: 1, Shared: 4, Entries: 1}, cache.Stats())
+	for _, responses := range results {
+		assert.Equal(t, "0xa:\x01", string(responses[0].Raw))
+	}
+}
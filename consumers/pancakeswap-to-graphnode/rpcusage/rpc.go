@@ -0,0 +1,127 @@
+package rpcusage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// RPC counts the calls of the modules as `eth_call`s, responses with a call
+// error being successful calls.
+type RPC struct {
+	meter *Meter
+	rpc   sdk.RPC
+}
+
+func NewRPC(meter *Meter, rpc sdk.RPC) *RPC {
+	return &RPC{meter: meter, rpc: rpc}
+}
+
+func (r *RPC) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	responses, err := r.rpc.Call(calls)
+	for i, call := range calls {
+		responseBytes := 0
+		if err == nil && i < len(responses) {
+			responseBytes = len(responses[i].Raw)
+		}
+		r.meter.Record("eth_call", len(call.ToAddr)+len(call.Data), responseBytes, err != nil)
+	}
+	return responses, err
+}
+
+// Transport counts the JSON-RPC requests going through an HTTP client, batches
+// counting one call per request of the batch.
+type Transport struct {
+	meter *Meter
+	base  http.RoundTripper
+}
+
+// NewTransport returns a transport counting the calls going through `base`,
+// `http.DefaultTransport` when nil.
+func NewTransport(meter *Meter, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{meter: meter, base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	methods := jsonRPCMethods(body)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		for _, method := range methods {
+			t.meter.Record(method, len(body)/len(methods), 0, true)
+		}
+		return resp, err
+	}
+
+	resp.Body = &countingBody{ReadCloser: resp.Body, done: func(read int) {
+		for _, method := range methods {
+			t.meter.Record(method, len(body)/len(methods), read/len(methods), false)
+		}
+	}}
+	return resp, nil
+}
+
+// jsonRPCMethods returns the method of each request of `body`, a single request
+// or a batch.
+func jsonRPCMethods(body []byte) []string {
+	type request struct {
+		Method string `json:"method"`
+	}
+
+	var batch []request
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var single request
+		if err := json.Unmarshal(body, &single); err != nil || single.Method == "" {
+			return []string{"unknown"}
+		}
+		return []string{single.Method}
+	}
+	if len(batch) == 0 {
+		return []string{"unknown"}
+	}
+
+	out := make([]string, len(batch))
+	for i, req := range batch {
+		out[i] = req.Method
+		if out[i] == "" {
+			out[i] = "unknown"
+		}
+	}
+	return out
+}
+
+// countingBody calls `done` with the number of bytes read once closed.
+type countingBody struct {
+	io.ReadCloser
+	read int
+	done func(read int)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += n
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	if b.done != nil {
+		b.done(b.read)
+		b.done = nil
+	}
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,194 @@
+// Package rpcusage accounts the JSON-RPC calls made by a run, per method, and
+// prices them after the rates of the provider. A report sampled over a block
+// range is projected to longer ranges by `Estimate`, the calls per block being
+// assumed constant.
+package rpcusage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Prices is the cost of a single call per JSON-RPC method, `*` pricing the
+// methods not listed. The unit is the one of the provider, dollars or compute
+// units.
+type Prices map[string]float64
+
+// ParsePrices parses `<method>=<price>` values.
+func ParsePrices(values []string) (Prices, error) {
+	out := Prices{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid price %q, expected '<method>=<price>'", value)
+		}
+		price, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid price %q, the price must be a positive number", value)
+		}
+		out[parts[0]] = price
+	}
+	return out, nil
+}
+
+func (p Prices) Price(method string) float64 {
+	if price, found := p[method]; found {
+		return price
+	}
+	return p["*"]
+}
+
+// Meter counts the calls, it's safe for concurrent use.
+type Meter struct {
+	mu      sync.Mutex
+	started time.Time
+	methods map[string]*MethodUsage
+}
+
+// MethodUsage is the usage of a single method.
+type MethodUsage struct {
+	Calls         uint64  `json:"calls"`
+	Errors        uint64  `json:"errors"`
+	RequestBytes  uint64  `json:"request_bytes"`
+	ResponseBytes uint64  `json:"response_bytes"`
+	CallsPerBlock float64 `json:"calls_per_block"`
+	Cost          float64 `json:"cost"`
+}
+
+func NewMeter() *Meter {
+	return &Meter{started: time.Now(), methods: map[string]*MethodUsage{}}
+}
+
+// Record counts a call of `method`, `failed` being true when the call didn't
+// get a response.
+func (m *Meter) Record(method string, requestBytes, responseBytes int, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage, found := m.methods[method]
+	if !found {
+		usage = &MethodUsage{}
+		m.methods[method] = usage
+	}
+	usage.Calls++
+	usage.RequestBytes += uint64(requestBytes)
+	usage.ResponseBytes += uint64(responseBytes)
+	if failed {
+		usage.Errors++
+	}
+}
+
+// Report is the usage over the blocks `[StartBlock, StopBlock)`.
+type Report struct {
+	StartBlock     uint64                  `json:"start_block"`
+	StopBlock      uint64                  `json:"stop_block"`
+	Duration       time.Duration           `json:"duration_ns,omitempty"`
+	Calls          uint64                  `json:"calls"`
+	CallsPerSecond float64                 `json:"calls_per_second,omitempty"`
+	Bytes          uint64                  `json:"bytes"`
+	Cost           float64                 `json:"cost"`
+	Methods        map[string]*MethodUsage `json:"methods"`
+
+	// Estimated is true for the reports projected by `Estimate`.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// Report returns the usage since the meter was created, for the blocks
+// `[startBlock, stopBlock)` processed in the meantime.
+func (m *Meter) Report(prices Prices, startBlock, stopBlock uint64) *Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := &Report{StartBlock: startBlock, StopBlock: stopBlock, Duration: time.Since(m.started), Methods: map[string]*MethodUsage{}}
+	for method, usage := range m.methods {
+		copied := *usage
+		r.Methods[method] = &copied
+	}
+	r.total(prices)
+	if seconds := r.Duration.Seconds(); seconds > 0 {
+		r.CallsPerSecond = float64(r.Calls) / seconds
+	}
+	return r
+}
+
+func (r *Report) Blocks() uint64 {
+	if r.StopBlock < r.StartBlock {
+		return 0
+	}
+	return r.StopBlock - r.StartBlock
+}
+
+func (r *Report) total(prices Prices) {
+	r.Calls, r.Bytes, r.Cost = 0, 0, 0
+	for method, usage := range r.Methods {
+		usage.Cost = float64(usage.Calls) * prices.Price(method)
+		if blocks := r.Blocks(); blocks > 0 {
+			usage.CallsPerBlock = float64(usage.Calls) / float64(blocks)
+		}
+
+		r.Calls += usage.Calls
+		r.Bytes += usage.RequestBytes + usage.ResponseBytes
+		r.Cost += usage.Cost
+	}
+}
+
+// Estimate projects the usage of `sample` to the blocks `[startBlock,
+// stopBlock)`, keeping the calls and bytes per block of each method.
+func Estimate(sample *Report, startBlock, stopBlock uint64, prices Prices) (*Report, error) {
+	if sample.Blocks() == 0 {
+		return nil, fmt.Errorf("the sample covers no block")
+	}
+	if stopBlock <= startBlock {
+		return nil, fmt.Errorf("invalid range [%d, %d)", startBlock, stopBlock)
+	}
+
+	ratio := float64(stopBlock-startBlock) / float64(sample.Blocks())
+	scale := func(value uint64) uint64 { return uint64(float64(value)*ratio + 0.5) }
+
+	r := &Report{StartBlock: startBlock, StopBlock: stopBlock, Methods: map[string]*MethodUsage{}, Estimated: true}
+	for method, usage := range sample.Methods {
+		r.Methods[method] = &MethodUsage{
+			Calls:         scale(usage.Calls),
+			Errors:        scale(usage.Errors),
+			RequestBytes:  scale(usage.RequestBytes),
+			ResponseBytes: scale(usage.ResponseBytes),
+		}
+	}
+	r.total(prices)
+	return r, nil
+}
+
+func (r *Report) Print(w io.Writer) {
+	title := "RPC usage"
+	if r.Estimated {
+		title = "Estimated RPC usage"
+	}
+	fmt.Fprintf(w, "%s of blocks #%d to #%d: %d calls, %d bytes, cost %.4f", title, r.StartBlock, r.StopBlock, r.Calls, r.Bytes, r.Cost)
+	if r.CallsPerSecond > 0 {
+		fmt.Fprintf(w, ", %.1f calls/s", r.CallsPerSecond)
+	}
+	fmt.Fprintf(w, "\n")
+	if len(r.Methods) == 0 {
+		return
+	}
+
+	var methods []string
+	for method := range r.Methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "  \tcalls\terrors\tcalls/block\trequest bytes\tresponse bytes\tcost\t\n")
+	for _, method := range methods {
+		usage := r.Methods[method]
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%.2f\t%d\t%d\t%.4f\t\n", method, usage.Calls, usage.Errors, usage.CallsPerBlock, usage.RequestBytes, usage.ResponseBytes, usage.Cost)
+	}
+	tw.Flush()
+}
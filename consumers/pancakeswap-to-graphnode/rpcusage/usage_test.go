@@ -0,0 +1,138 @@
+package rpcusage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrices(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []string
+		expected    Prices
+		expectedErr string
+	}{
+		{"none", nil, Prices{}, ""},
+		{"methods", []string{"eth_call=0.5", "*=0.1"}, Prices{"eth_call": 0.5, "*": 0.1}, ""},
+		{"no price", []string{"eth_call"}, nil, "expected '<method>=<price>'"},
+		{"negative", []string{"eth_call=-1"}, nil, "must be a positive number"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prices, err := ParsePrices(test.values)
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, prices)
+		})
+	}
+
+	prices := Prices{"eth_call": 2, "*": 1}
+	assert.Equal(t, 2.0, prices.Price("eth_call"))
+	assert.Equal(t, 1.0, prices.Price("eth_getLogs"))
+	assert.Equal(t, 0.0, Prices{}.Price("eth_call"))
+}
+
+func TestMeter_ReportAndEstimate(t *testing.T) {
+	meter := NewMeter()
+	for i := 0; i < 10; i++ {
+		meter.Record("eth_call", 40, 32, false)
+	}
+	meter.Record("eth_blockNumber", 60, 40, true)
+
+	prices := Prices{"eth_call": 0.5, "*": 1}
+	report := meter.Report(prices, 100, 105)
+	assert.Equal(t, uint64(5), report.Blocks())
+	assert.Equal(t, uint64(11), report.Calls)
+	assert.Equal(t, uint64(10*72+100), report.Bytes)
+	assert.Equal(t, 6.0, report.Cost)
+	assert.Equal(t, &MethodUsage{Calls: 10, RequestBytes: 400, ResponseBytes: 320, CallsPerBlock: 2, Cost: 5}, report.Methods["eth_call"])
+	assert.Equal(t, uint64(1), report.Methods["eth_blockNumber"].Errors)
+
+	estimate, err := Estimate(report, 0, 1000, Prices{"eth_call": 1})
+	require.NoError(t, err)
+	assert.True(t, estimate.Estimated)
+	assert.Equal(t, &MethodUsage{Calls: 2000, RequestBytes: 80_000, ResponseBytes: 64_000, CallsPerBlock: 2, Cost: 2000}, estimate.Methods["eth_call"])
+	assert.Equal(t, uint64(200), estimate.Methods["eth_blockNumber"].Calls)
+	assert.Equal(t, uint64(2200), estimate.Calls)
+	assert.Equal(t, 2000.0, estimate.Cost)
+
+	_, err = Estimate(&Report{StartBlock: 10, StopBlock: 10}, 0, 1000, prices)
+	assert.Error(t, err)
+	_, err = Estimate(report, 1000, 1000, prices)
+	assert.Error(t, err)
+}
+
+type fakeRPC struct {
+	err error
+}
+
+func (r fakeRPC) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var out []*sdk.RPCResponse
+	for range calls {
+		out = append(out, &sdk.RPCResponse{Raw: make([]byte, 32)})
+	}
+	return out, nil
+}
+
+func TestRPC(t *testing.T) {
+	meter := NewMeter()
+	calls := []*sdk.RPCCall{{ToAddr: "0xab", Data: []byte{1, 2, 3, 4}}, {ToAddr: "0xcd", Data: []byte{5, 6, 7, 8}}}
+
+	_, err := NewRPC(meter, fakeRPC{}).Call(calls)
+	require.NoError(t, err)
+	_, err = NewRPC(meter, fakeRPC{err: errors.New("down")}).Call(calls)
+	require.Error(t, err)
+
+	report := meter.Report(Prices{}, 0, 1)
+	assert.Equal(t, &MethodUsage{Calls: 4, Errors: 2, RequestBytes: 32, ResponseBytes: 64, CallsPerBlock: 4}, report.Methods["eth_call"])
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer server.Close()
+
+	meter := NewMeter()
+	client := &http.Client{Transport: NewTransport(meter, nil)}
+	post := func(path, body string) {
+		resp, err := client.Post(server.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	post("/", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	post("/", `[{"method":"eth_call"},{"method":"eth_call"}]`)
+	post("/", `not json`)
+	post("/down", `{"method":"eth_getLogs"}`)
+
+	report := meter.Report(Prices{}, 0, 1)
+	assert.Equal(t, uint64(1), report.Methods["eth_blockNumber"].Calls)
+	assert.Equal(t, uint64(40), report.Methods["eth_blockNumber"].ResponseBytes)
+	assert.Equal(t, uint64(2), report.Methods["eth_call"].Calls)
+	assert.Equal(t, uint64(40), report.Methods["eth_call"].ResponseBytes)
+	assert.Equal(t, uint64(1), report.Methods["unknown"].Calls)
+	assert.Equal(t, &MethodUsage{Calls: 1, Errors: 1, RequestBytes: 24, CallsPerBlock: 1}, report.Methods["eth_getLogs"])
+}
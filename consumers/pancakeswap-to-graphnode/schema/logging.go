@@ -0,0 +1,7 @@
+package schema
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.schema", "github.com/streamingfast/substream-pancakeswap/schema")
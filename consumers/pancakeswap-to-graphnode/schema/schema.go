@@ -0,0 +1,369 @@
+// Package schema declares the keys and values of the stores of a substreams
+// package, like `pair:{pair}` holding a `proto:pcs.types.v1.Pair`, so the
+// conventions spread across modules are written down once, checked against
+// the deltas received and served to consumers.
+//
+// A schema is a YAML file kept next to the manifest:
+//
+//	stores:
+//	  store_pairs:
+//	    valueType: proto:pcs.types.v1.Pair
+//	    doc: pairs by address, once per factory
+//	    keys:
+//	      - pair:{pair}
+//	      - "{factory}:pair:{pair}"
+//
+// Keys are `:` separated segments, either literal or a `{name}` component
+// matching any segment, `{name=a|b}` limiting it to the listed values.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"gopkg.in/yaml.v3"
+)
+
+type Schema struct {
+	Stores map[string]*Store `json:"stores"`
+}
+
+type Store struct {
+	Name      string     `json:"name"`
+	ValueType string     `json:"value_type"`
+	Doc       string     `json:"doc,omitempty"`
+	Keys      []*Pattern `json:"keys"`
+}
+
+type file struct {
+	Stores map[string]struct {
+		ValueType string   `yaml:"valueType"`
+		Doc       string   `yaml:"doc"`
+		Keys      []string `yaml:"keys"`
+	} `yaml:"stores"`
+}
+
+func Load(path string) (*Schema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema %q: %w", path, err)
+	}
+
+	var in file
+	if err := yaml.Unmarshal(content, &in); err != nil {
+		return nil, fmt.Errorf("decode schema %q: %w", path, err)
+	}
+
+	s := &Schema{Stores: map[string]*Store{}}
+	for name, declared := range in.Stores {
+		if err := checkValueType(declared.ValueType); err != nil {
+			return nil, fmt.Errorf("schema %q, store %q: %w", path, name, err)
+		}
+		if len(declared.Keys) == 0 {
+			return nil, fmt.Errorf("schema %q, store %q: no keys declared", path, name)
+		}
+
+		store := &Store{Name: name, ValueType: declared.ValueType, Doc: declared.Doc}
+		for _, raw := range declared.Keys {
+			pattern, err := ParsePattern(raw)
+			if err != nil {
+				return nil, fmt.Errorf("schema %q, store %q: %w", path, name, err)
+			}
+			store.Keys = append(store.Keys, pattern)
+		}
+		s.Stores[name] = store
+	}
+	return s, nil
+}
+
+// Names returns the names of the stores, sorted.
+func (s *Schema) Names() (out []string) {
+	for name := range s.Stores {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// CheckModules verifies that the stores of the schema are store modules of
+// `modules` with the same value type.
+func (s *Schema) CheckModules(modules *pbsubstreams.Modules) error {
+	kinds := map[string]*pbsubstreams.Module_KindStore{}
+	for _, module := range modules.GetModules() {
+		if store := module.GetKindStore(); store != nil {
+			kinds[module.Name] = store
+		}
+	}
+
+	for _, name := range s.Names() {
+		kind, found := kinds[name]
+		if !found {
+			return fmt.Errorf("schema store %q is not a store module of the manifest", name)
+		}
+		if declared := s.Stores[name].ValueType; kind.ValueType != declared {
+			return fmt.Errorf("schema store %q holds %q, the manifest declares %q", name, declared, kind.ValueType)
+		}
+	}
+	return nil
+}
+
+// Validate checks that `key` matches one of the store's patterns and, unless
+// nil as for deletions, that `value` is of the store's value type.
+func (s *Store) Validate(key string, value []byte) error {
+	if s.Match(key) == nil {
+		return fmt.Errorf("key %q matches none of %s", key, s.patterns())
+	}
+	if value == nil {
+		return nil
+	}
+	if err := validateValue(s.ValueType, value); err != nil {
+		return fmt.Errorf("value of key %q: %w", key, err)
+	}
+	return nil
+}
+
+// Match returns the first pattern matching `key`, nil when none does.
+func (s *Store) Match(key string) *Pattern {
+	for _, pattern := range s.Keys {
+		if _, ok := pattern.Match(key); ok {
+			return pattern
+		}
+	}
+	return nil
+}
+
+func (s *Store) patterns() string {
+	raws := make([]string, len(s.Keys))
+	for i, pattern := range s.Keys {
+		raws[i] = pattern.Raw
+	}
+	return strings.Join(raws, ", ")
+}
+
+func checkValueType(valueType string) error {
+	switch valueType {
+	case "bytes", "string", "int64", "bigfloat", "bigint":
+		return nil
+	}
+	if strings.HasPrefix(valueType, "proto:") && len(valueType) > len("proto:") {
+		return nil
+	}
+	return fmt.Errorf("unknown value type %q, valid types are: bytes, string, int64, bigfloat, bigint, proto:<message type>", valueType)
+}
+
+// validateValue checks what can be checked without the protobuf descriptors,
+// `proto:` values are not decoded.
+func validateValue(valueType string, value []byte) error {
+	switch valueType {
+	case "string":
+		if !utf8.Valid(value) {
+			return fmt.Errorf("not valid UTF-8")
+		}
+	case "int64":
+		if _, err := strconv.ParseInt(string(value), 10, 64); err != nil {
+			return fmt.Errorf("not an int64: %q", value)
+		}
+	case "bigint":
+		if _, ok := new(big.Int).SetString(string(value), 10); !ok {
+			return fmt.Errorf("not a bigint: %q", value)
+		}
+	case "bigfloat":
+		if _, _, err := big.ParseFloat(string(value), 10, 256, big.ToNearestEven); err != nil {
+			return fmt.Errorf("not a bigfloat: %q", value)
+		}
+	}
+	return nil
+}
+
+// Decode returns `value` decoded as the value type of the store, see
+// `DecodeValue`.
+func (s *Store) Decode(value []byte) (interface{}, error) {
+	return DecodeValue(s.ValueType, value)
+}
+
+// DecodeValue returns `value` as a `[]byte`, a `string`, an `int64`, a
+// `*big.Int`, a `*big.Float` or a `proto.Message`, depending on `valueType`.
+// The types of `proto:` values must be linked in the binary, like the ones of
+// the `pb` packages.
+func DecodeValue(valueType string, value []byte) (interface{}, error) {
+	switch valueType {
+	case "bytes":
+		return value, nil
+	case "string":
+		if err := validateValue(valueType, value); err != nil {
+			return nil, err
+		}
+		return string(value), nil
+	case "int64":
+		v, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not an int64: %q", value)
+		}
+		return v, nil
+	case "bigint":
+		v, ok := new(big.Int).SetString(string(value), 10)
+		if !ok {
+			return nil, fmt.Errorf("not a bigint: %q", value)
+		}
+		return v, nil
+	case "bigfloat":
+		v, _, err := big.ParseFloat(string(value), 10, 256, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("not a bigfloat: %q", value)
+		}
+		return v, nil
+	}
+
+	if name := strings.TrimPrefix(valueType, "proto:"); name != valueType {
+		msgType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("message type %q: %w", name, err)
+		}
+		msg := msgType.New().Interface()
+		if err := proto.Unmarshal(value, msg); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", name, err)
+		}
+		return msg, nil
+	}
+	return nil, fmt.Errorf("unknown value type %q", valueType)
+}
+
+// Pattern is a key pattern, like `pair_day:{day}:{pair}:usd`.
+type Pattern struct {
+	Raw      string
+	segments []*segment
+}
+
+type segment struct {
+	literal   string
+	component string
+	values    []string
+}
+
+func ParsePattern(raw string) (*Pattern, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("empty key pattern")
+	}
+
+	p := &Pattern{Raw: raw}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ":") {
+		if !strings.HasPrefix(part, "{") {
+			if part == "" || strings.ContainsAny(part, "{}") {
+				return nil, fmt.Errorf("key pattern %q: invalid segment %q", raw, part)
+			}
+			p.segments = append(p.segments, &segment{literal: part})
+			continue
+		}
+
+		if !strings.HasSuffix(part, "}") {
+			return nil, fmt.Errorf("key pattern %q: unclosed component %q", raw, part)
+		}
+		name, values := part[1:len(part)-1], ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, values = name[:i], name[i+1:]
+		}
+		if name == "" || seen[name] {
+			return nil, fmt.Errorf("key pattern %q: components must have a unique name", raw)
+		}
+		seen[name] = true
+
+		seg := &segment{component: name}
+		if values != "" {
+			seg.values = strings.Split(values, "|")
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// Components returns the names of the pattern's components, in order.
+func (p *Pattern) Components() (out []string) {
+	for _, seg := range p.segments {
+		if seg.component != "" {
+			out = append(out, seg.component)
+		}
+	}
+	return out
+}
+
+// Position returns the 1-based position of the `component` segment in the
+// keys matching the pattern, 0 when the pattern doesn't have it.
+func (p *Pattern) Position(component string) int {
+	for i, seg := range p.segments {
+		if seg.component == component {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Regexp returns an anchored regular expression matching the same keys as
+// the pattern, usable by databases to select them.
+func (p *Pattern) Regexp() string {
+	parts := make([]string, len(p.segments))
+	for i, seg := range p.segments {
+		switch {
+		case seg.component == "":
+			parts[i] = regexp.QuoteMeta(seg.literal)
+		case len(seg.values) > 0:
+			quoted := make([]string, len(seg.values))
+			for j, value := range seg.values {
+				quoted[j] = regexp.QuoteMeta(value)
+			}
+			parts[i] = "(" + strings.Join(quoted, "|") + ")"
+		default:
+			parts[i] = "[^:]+"
+		}
+	}
+	return "^" + strings.Join(parts, ":") + "$"
+}
+
+// Match returns the value of each component when `key` matches the pattern.
+func (p *Pattern) Match(key string) (map[string]string, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+
+	components := map[string]string{}
+	for i, seg := range p.segments {
+		part := parts[i]
+		if seg.component == "" {
+			if part != seg.literal {
+				return nil, false
+			}
+			continue
+		}
+
+		if part == "" || (len(seg.values) > 0 && !contains(seg.values, part)) {
+			return nil, false
+		}
+		components[seg.component] = part
+	}
+	return components, true
+}
+
+func (p *Pattern) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Raw)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
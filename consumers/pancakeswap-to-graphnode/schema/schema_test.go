@@ -0,0 +1,178 @@
+package schema
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPattern_Match(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		key        string
+		expected   map[string]string
+		expectedOk bool
+	}{
+		{"pair:{pair}", "pair:0xab", map[string]string{"pair": "0xab"}, true},
+		{"pair:{pair}", "pair:", nil, false},
+		{"pair:{pair}", "pair:0xab:usd", nil, false},
+		{"dprice:usd:bnb", "dprice:usd:bnb", map[string]string{}, true},
+		{"pair_day:{day}:{pair}:{volume=usd|token0}", "pair_day:19000:0xab:token0", map[string]string{"day": "19000", "pair": "0xab", "volume": "token0"}, true},
+		{"pair_day:{day}:{pair}:{volume=usd|token0}", "pair_day:19000:0xab:bnb", nil, false},
+		{"{factory}:global:usd", "0xfa:global:usd", map[string]string{"factory": "0xfa"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.key, func(t *testing.T) {
+			pattern, err := ParsePattern(test.pattern)
+			require.NoError(t, err)
+
+			components, ok := pattern.Match(test.key)
+			assert.Equal(t, test.expectedOk, ok)
+			assert.Equal(t, test.expected, components)
+			assert.Equal(t, test.expectedOk, regexp.MustCompile(pattern.Regexp()).MatchString(test.key), "regexp")
+		})
+	}
+}
+
+func TestParsePattern_Invalid(t *testing.T) {
+	for _, raw := range []string{"", "pair::usd", "pair:{pair", "pair:{}", "{pair}:{pair}", "pa{ir"} {
+		_, err := ParsePattern(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestLoad_PancakeSwap(t *testing.T) {
+	s, err := Load("../../../modules/pancakeswap/schema.yaml")
+	require.NoError(t, err)
+
+	tests := []struct {
+		store string
+		key   string
+		value string
+	}{
+		{"store_pairs", "tokens:0xaa:0xbb", ""},
+		{"store_pairs", "0xfa:tokens:0xaa:0xbb", ""},
+		{"store_reserves", "price:0xab:0xaa:token0", "1.5"},
+		{"store_prices", "pair_hour:456000:dreserve:0xaa:usd", "10.25"},
+		{"store_totals", "0xfa:global_day:19000:transaction_count", "3"},
+		{"store_volumes", "pair:0xab:total_supply", "1e-9"},
+		{"store_fees", "pair_day:19000:0xab:lp_fees:usd", "0.0025"},
+		{"store_lp_positions", "position:0xab:0xcc:entry_weight", "12"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			store, found := s.Stores[test.store]
+			require.True(t, found)
+			assert.NoError(t, store.Validate(test.key, []byte(test.value)))
+		})
+	}
+
+	assert.Error(t, s.Stores["store_totals"].Validate("pair:0xab:swap_count", []byte("1.5")), "int64 store")
+	assert.Error(t, s.Stores["store_fees"].Validate("pair:0xab:fees:usd", []byte("1")), "unknown kind")
+}
+
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		valueType   string
+		value       string
+		expected    interface{}
+		expectedErr bool
+	}{
+		{"bytes", "\x01\x02", []byte{1, 2}, false},
+		{"string", "0xaa", "0xaa", false},
+		{"string", "\xff", nil, true},
+		{"int64", "-12", int64(-12), false},
+		{"int64", "1.5", nil, true},
+		{"bigint", "123456789012345678901234567890", bigInt("123456789012345678901234567890"), false},
+		{"bigint", "1e3", nil, true},
+		{"bigfloat", "abc", nil, true},
+		{"proto:pcs.types.v1.Unknown", "", nil, true},
+		{"json", "{}", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.valueType+" "+test.value, func(t *testing.T) {
+			value, err := DecodeValue(test.valueType, []byte(test.value))
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+
+	value, err := DecodeValue("bigfloat", []byte("0.0025"))
+	require.NoError(t, err)
+	assert.Equal(t, "0.0025", value.(*big.Float).Text('f', 4))
+}
+
+func bigInt(raw string) *big.Int {
+	v, _ := new(big.Int).SetString(raw, 10)
+	return v
+}
+
+func TestSchema_CheckModules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("stores:\n  store_totals:\n    valueType: int64\n    keys: [global:count]\n"), 0644))
+	s, err := Load(path)
+	require.NoError(t, err)
+
+	store := func(name, valueType string) *pbsubstreams.Module {
+		return &pbsubstreams.Module{Name: name, Kind: &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{ValueType: valueType}}}
+	}
+
+	assert.NoError(t, s.CheckModules(&pbsubstreams.Modules{Modules: []*pbsubstreams.Module{store("store_totals", "int64")}}))
+	assert.Error(t, s.CheckModules(&pbsubstreams.Modules{Modules: []*pbsubstreams.Module{store("store_totals", "bigfloat")}}))
+	assert.Error(t, s.CheckModules(&pbsubstreams.Modules{}))
+}
+
+type recordingSink struct {
+	written int
+}
+
+func (s *recordingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	s.written++
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestSink(t *testing.T) {
+	pattern, err := ParsePattern("global:{counter=swaps}")
+	require.NoError(t, err)
+	s := &Schema{Stores: map[string]*Store{"store_totals": {Name: "store_totals", ValueType: "int64", Keys: []*Pattern{pattern}}}}
+
+	data := &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Number: 10},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+				{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "global:swaps", NewValue: []byte("2")},
+				{Operation: pbsubstreams.StoreDelta_CREATE, Key: "global:burns", NewValue: []byte("1")},
+				{Operation: pbsubstreams.StoreDelta_DELETE, Key: "global:swaps", OldValue: []byte("2")},
+			}}}},
+			{Name: "store_unknown", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+				{Operation: pbsubstreams.StoreDelta_CREATE, Key: "anything", NewValue: []byte("x")},
+			}}}},
+		},
+	}
+
+	inner := &recordingSink{}
+	warn := NewSink(s, false, inner)
+	require.NoError(t, warn.Write(context.Background(), data))
+	assert.Equal(t, 1, inner.written)
+	assert.Equal(t, map[string]uint64{"store_totals": 1}, warn.Violations())
+
+	fail := NewSink(s, true, inner)
+	assert.Error(t, fail.Write(context.Background(), data))
+	assert.Equal(t, 1, inner.written, "invalid block not written")
+}
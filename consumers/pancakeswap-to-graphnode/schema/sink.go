@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// maxLoggedViolations is the number of violations logged per store, the
+// following ones are only counted.
+const maxLoggedViolations = 10
+
+// Sink validates the store deltas of every block against the schema before
+// writing the block to the wrapped sinks. Stores missing from the schema are
+// not validated.
+type Sink struct {
+	schema     *Schema
+	fail       bool
+	sinks      []sink.Sink
+	violations map[string]uint64
+}
+
+// NewSink returns a validating sink, a block with a delta violating the
+// schema fails the write when `fail` is set, it's only logged otherwise.
+func NewSink(schema *Schema, fail bool, sinks ...sink.Sink) *Sink {
+	return &Sink{schema: schema, fail: fail, sinks: sinks, violations: map[string]uint64{}}
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	for _, output := range data.Outputs {
+		store, found := s.schema.Stores[output.Name]
+		if !found {
+			continue
+		}
+
+		for _, delta := range output.GetStoreDeltas().GetDeltas() {
+			value := delta.NewValue
+			if delta.Operation == pbsubstreams.StoreDelta_DELETE {
+				value = nil
+			}
+
+			err := store.Validate(delta.Key, value)
+			if err == nil {
+				continue
+			}
+			if s.fail {
+				return fmt.Errorf("store %q at block %d: %w", output.Name, data.Clock.GetNumber(), err)
+			}
+
+			s.violations[output.Name]++
+			if count := s.violations[output.Name]; count <= maxLoggedViolations {
+				zlog.Warn("store delta violates schema",
+					zap.String("store", output.Name),
+					zap.Uint64("block_num", data.Clock.GetNumber()),
+					zap.Uint64("violations", count),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	for _, s := range s.sinks {
+		if err := s.Write(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Violations returns the number of deltas that violated the schema, per
+// store.
+func (s *Sink) Violations() map[string]uint64 {
+	return s.violations
+}
+
+func (s *Sink) Flush(ctx context.Context) error {
+	for _, s := range s.sinks {
+		if err := sink.Flush(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sink) Close() error {
+	for store, count := range s.violations {
+		zlog.Warn("store deltas violated schema", zap.String("store", store), zap.Uint64("violations", count))
+	}
+
+	var firstErr error
+	for _, s := range s.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
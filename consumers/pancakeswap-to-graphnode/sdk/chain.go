@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"google.golang.org/protobuf/proto"
+)
+
+// Chain is what the runtime knows of the chain whose blocks the modules
+// process: how to read the current block out of its block type, how its
+// addresses and hashes are written, and, through `ChainIntrinsics.Native`,
+// how modules read the state of its programs. The EVM chains are implemented
+// by the `sdk/evm` package, a Solana or NEAR module set implements it for its
+// own block type and reuses the stores and sinks.
+type Chain interface {
+	// Name is a short name of the chain family, like `evm`.
+	Name() string
+	// BlockType is the fully qualified protobuf type of its blocks, like
+	// `sf.ethereum.type.v1.Block`.
+	BlockType() string
+	// CurrentBlock returns the accessor of `block`, it fails when `block`
+	// isn't of the chain's block type.
+	CurrentBlock(block proto.Message) (CurrentBlock, error)
+
+	FormatAddress(address []byte) string
+	// ParseAddress parses an address written by `FormatAddress`, it fails
+	// when `address` isn't a valid address of the chain.
+	ParseAddress(address string) ([]byte, error)
+	FormatHash(hash []byte) string
+}
+
+// ChainMapFunc and ChainStoreFunc are the `MapFunc` and `StoreFunc` of the
+// modules of any chain, `block` being of the chain's block type.
+type ChainMapFunc func(block proto.Message, intr ChainIntrinsics) (interface{}, error)
+
+type ChainStoreFunc func(block proto.Message, output interface{}, intr ChainIntrinsics, store Store) error
+
+// ChainIntrinsics is the part of `Intrinsics` that doesn't depend on the
+// chain, the surface a module written for any chain sees.
+type ChainIntrinsics interface {
+	Chain() Chain
+	CurrentBlock() CurrentBlock
+	// Native performs read-only calls to the programs of the chain against
+	// the state at the current block.
+	Native() NativeCaller
+	Logger() Logger
+
+	// Store returns a read-only view of the store of `module`, it fails when
+	// `module` was not declared as a "get" input of the calling module.
+	Store(module string) (StoreReader, error)
+
+	// Deltas returns the changes of the store of `module` in the current block, it
+	// fails when `module` was not declared as a "deltas" input of the calling module.
+	Deltas(module string) ([]*Delta, error)
+}
+
+// NativeCaller performs the native calls of a chain, responses are in the same
+// order as `calls`.
+type NativeCaller interface {
+	Call(calls []*NativeCall) ([]*NativeResponse, error)
+}
+
+// NativeCall is a read-only call to a program: an `eth_call` to a contract on
+// EVM chains, a view function call on NEAR, an account read on Solana. `Data`
+// is the input encoded the way the chain expects it.
+type NativeCall struct {
+	Program []byte
+	Data    []byte
+}
+
+type NativeResponse struct {
+	Raw       []byte
+	CallError error // always deterministic
+}
@@ -0,0 +1,143 @@
+// Package evm is the `sdk.Chain` of the EVM chains, BSC and Ethereum, whose
+// blocks are `sf.ethereum.type.v1.Block`: addresses and hashes are `0x`
+// prefixed lowercase hex, native calls are `eth_call`s.
+package evm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	eth "github.com/streamingfast/eth-go"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"google.golang.org/protobuf/proto"
+)
+
+const addressLength = 20
+
+var Chain sdk.Chain = chain{}
+
+type chain struct{}
+
+func (chain) Name() string      { return "evm" }
+func (chain) BlockType() string { return "sf.ethereum.type.v1.Block" }
+
+func (chain) CurrentBlock(block proto.Message) (sdk.CurrentBlock, error) {
+	ethBlock, ok := block.(*pbeth.Block)
+	if !ok {
+		return nil, fmt.Errorf("expected a %s block, got %T", Chain.BlockType(), block)
+	}
+	return CurrentBlock(ethBlock), nil
+}
+
+func (chain) FormatAddress(address []byte) string {
+	return "0x" + hex.EncodeToString(address)
+}
+
+func (chain) ParseAddress(address string) ([]byte, error) {
+	out, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(address), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	if len(out) != addressLength {
+		return nil, fmt.Errorf("invalid address %q: %d bytes, expected %d", address, len(out), addressLength)
+	}
+	return out, nil
+}
+
+// BareHexAddresses writes the addresses of the keys in lowercase hex without
+// the `0x` prefix, like the keys of the bench modules, and parses them with or
+// without it.
+var BareHexAddresses sdk.AddressCodec = bareHexAddresses{}
+
+type bareHexAddresses struct{}
+
+func (bareHexAddresses) FormatAddress(address []byte) string {
+	return hex.EncodeToString(address)
+}
+
+func (bareHexAddresses) ParseAddress(address string) ([]byte, error) {
+	return Chain.ParseAddress(address)
+}
+
+// ChecksummedAddresses writes the addresses of the keys with the EIP-55 mixed
+// case checksum, for keys shown to users as explorers show addresses. It
+// parses addresses of any case, the checksum isn't verified.
+var ChecksummedAddresses sdk.AddressCodec = checksummedAddresses{}
+
+type checksummedAddresses struct{}
+
+func (checksummedAddresses) FormatAddress(address []byte) string {
+	lower := hex.EncodeToString(address)
+	hash := eth.Keccak256([]byte(lower))
+
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' {
+			continue
+		}
+		// uppercased when the matching nibble of the hash is 8 or more
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0xf
+		}
+		if nibble >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
+
+func (checksummedAddresses) ParseAddress(address string) ([]byte, error) {
+	return Chain.ParseAddress(address)
+}
+
+func (chain) FormatHash(hash []byte) string {
+	return "0x" + hex.EncodeToString(hash)
+}
+
+// CurrentBlock returns the accessor of `block`. Its ID is the hash without the
+// `0x` prefix, as it always was for modules.
+func CurrentBlock(block *pbeth.Block) sdk.CurrentBlock {
+	return blockRef{block}
+}
+
+type blockRef struct {
+	block *pbeth.Block
+}
+
+func (b blockRef) ID() string     { return hex.EncodeToString(b.block.Hash) }
+func (b blockRef) Number() uint64 { return b.block.Number }
+func (b blockRef) Timestamp() time.Time {
+	return b.block.GetHeader().GetTimestamp().AsTime()
+}
+
+// NativeCaller makes the native calls `eth_call`s through `rpc`, against the
+// block the runtime pins its calls to.
+func NativeCaller(rpc sdk.RPC) sdk.NativeCaller {
+	return nativeCaller{rpc}
+}
+
+type nativeCaller struct {
+	rpc sdk.RPC
+}
+
+func (c nativeCaller) Call(calls []*sdk.NativeCall) ([]*sdk.NativeResponse, error) {
+	rpcCalls := make([]*sdk.RPCCall, len(calls))
+	for i, call := range calls {
+		rpcCalls[i] = &sdk.RPCCall{ToAddr: Chain.FormatAddress(call.Program), Data: call.Data}
+	}
+
+	responses, err := c.rpc.Call(rpcCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*sdk.NativeResponse, len(responses))
+	for i, response := range responses {
+		out[i] = &sdk.NativeResponse{Raw: response.Raw, CallError: response.CallError}
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package evm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type rpcFunc func(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error)
+
+func (f rpcFunc) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	return f(calls)
+}
+
+func TestChain_CurrentBlock(t *testing.T) {
+	block := &pbeth.Block{Number: 42, Hash: []byte{0xab, 0xcd}, Header: &pbeth.BlockHeader{Timestamp: timestamppb.New(time.Unix(126, 0))}}
+	current, err := Chain.CurrentBlock(block)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", current.ID())
+	assert.Equal(t, uint64(42), current.Number())
+	assert.Equal(t, int64(126), current.Timestamp().Unix())
+
+	_, err = Chain.CurrentBlock(&pbsubstreams.Clock{Number: 42})
+	assert.EqualError(t, err, "expected a sf.ethereum.type.v1.Block block, got *pbsubstreams.Clock")
+}
+
+func TestChain_Addresses(t *testing.T) {
+	address, err := Chain.ParseAddress("0x0E09FaBB73Bd3Ade0a17ECC321fD13a19e81cE82")
+	require.NoError(t, err)
+	assert.Len(t, address, 20)
+	assert.Equal(t, "0x0e09fabb73bd3ade0a17ecc321fd13a19e81ce82", Chain.FormatAddress(address))
+
+	_, err = Chain.ParseAddress("0x0e09")
+	assert.EqualError(t, err, `invalid address "0x0e09": 2 bytes, expected 20`)
+	_, err = Chain.ParseAddress("cake")
+	assert.Error(t, err)
+
+	assert.Equal(t, "0x00ff", Chain.FormatHash([]byte{0x00, 0xff}))
+}
+
+func TestAddressCodecs(t *testing.T) {
+	for _, expected := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	} {
+		address, err := ChecksummedAddresses.ParseAddress(strings.ToLower(expected))
+		require.NoError(t, err)
+		assert.Equal(t, expected, ChecksummedAddresses.FormatAddress(address))
+	}
+
+	address, err := BareHexAddresses.ParseAddress("0E09FaBB73Bd3Ade0a17ECC321fD13a19e81cE82")
+	require.NoError(t, err)
+	assert.Equal(t, "0e09fabb73bd3ade0a17ecc321fd13a19e81ce82", BareHexAddresses.FormatAddress(address))
+
+	keys := sdk.NewKeyCodec(BareHexAddresses)
+	key, err := keys.Parse("pair:0e09fabb73bd3ade0a17ecc321fd13a19e81ce82")
+	require.NoError(t, err)
+	pair, err := key.Address(1)
+	require.NoError(t, err)
+	assert.Equal(t, address, pair)
+}
+
+func TestNativeCaller(t *testing.T) {
+	var received []*sdk.RPCCall
+	caller := NativeCaller(rpcFunc(func(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+		received = calls
+		return []*sdk.RPCResponse{{Raw: []byte{0x12}}, {CallError: assert.AnError}}, nil
+	}))
+
+	responses, err := caller.Call([]*sdk.NativeCall{
+		{Program: []byte{0xaa, 0xbb}, Data: []byte{0x01}},
+		{Program: []byte{0xcc}, Data: []byte{0x02}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*sdk.RPCCall{{ToAddr: "0xaabb", Data: []byte{0x01}}, {ToAddr: "0xcc", Data: []byte{0x02}}}, received)
+	assert.Equal(t, []*sdk.NativeResponse{{Raw: []byte{0x12}}, {CallError: assert.AnError}}, responses)
+}
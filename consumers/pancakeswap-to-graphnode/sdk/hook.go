@@ -0,0 +1,31 @@
+package sdk
+
+import (
+	"errors"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+)
+
+// ErrSkipBlock is returned by a `BeforeBlockFunc` for the modules not to
+// process the block, their stores are left untouched.
+var ErrSkipBlock = errors.New("block skipped by hook")
+
+// BeforeBlockFunc runs before the modules process a block. It may modify
+// `block` in place, to filter out transactions or enrich it, the modules see
+// the modified block.
+type BeforeBlockFunc func(block *pbeth.Block, logger Logger) error
+
+// AfterBlockFunc runs once the modules processed a block, like to emit custom
+// metrics. `result` is borrowed: it must not be modified nor retained past the
+// call.
+type AfterBlockFunc func(block *pbeth.Block, result BlockResult, logger Logger) error
+
+// BlockResult is what the modules produced for a block.
+type BlockResult interface {
+	// Output returns the output of the map step of `module`, false when the
+	// module didn't run.
+	Output(module string) (interface{}, bool)
+	Deltas(module string) []*Delta
+	Duration(module string) time.Duration
+}
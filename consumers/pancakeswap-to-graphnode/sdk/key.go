@@ -0,0 +1,195 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultDelimiter separates the segments of the keys of the stores, like in
+// `price:<pair>:<token>:token0`.
+const DefaultDelimiter = ':'
+
+// AddressCodec writes and parses the addresses of the keys, a `Chain` is one.
+// The `sdk/evm` package has the checksummed and bare hex variants of the EVM
+// addresses.
+type AddressCodec interface {
+	FormatAddress(address []byte) string
+	ParseAddress(address string) ([]byte, error)
+}
+
+// Address is a key segment holding an address, written by the `AddressCodec`
+// of the `KeyCodec` building the key.
+type Address []byte
+
+// KeyCodec builds and parses the keys of the stores, so modules and consumers
+// agree on how their segments are written instead of each concatenating and
+// splitting strings:
+//
+//	keys := sdk.NewKeyCodec(evm.Chain)
+//	store.Set(keys.Key("pair", sdk.Address(pair), "usd").String(), value)
+//
+//	key, err := keys.Parse(delta.Key)
+//	if key.Is("pair", 3) {
+//		pair, err := key.Address(1)
+//		...
+//	}
+//
+// A segment holding the delimiter, or `%`, has them percent-encoded, like
+// `%3A` for `:`, so a segment never splits in two.
+type KeyCodec struct {
+	Delimiter byte
+	// Addresses writes the `Address` segments, keys holding addresses can't
+	// be built without it.
+	Addresses AddressCodec
+}
+
+func NewKeyCodec(addresses AddressCodec) *KeyCodec {
+	return &KeyCodec{Delimiter: DefaultDelimiter, Addresses: addresses}
+}
+
+var plainKeys = &KeyCodec{Delimiter: DefaultDelimiter}
+
+// ParseKey parses `key` with the default delimiter, its addresses are only
+// readable as strings, see `KeyCodec.Parse` otherwise.
+func ParseKey(key string) (Key, error) {
+	return plainKeys.Parse(key)
+}
+
+// Key is a parsed or built key, its segments are kept unescaped.
+type Key struct {
+	codec    *KeyCodec
+	segments []string
+}
+
+// Key builds a key out of its segments, strings, `Address`es and integers,
+// written in decimal. It panics on segments of other types, a bug of the
+// module rather than of the data it processes.
+func (c *KeyCodec) Key(segments ...interface{}) Key {
+	k := Key{codec: c, segments: make([]string, len(segments))}
+	for i, segment := range segments {
+		switch v := segment.(type) {
+		case string:
+			k.segments[i] = v
+		case Address:
+			if c.Addresses == nil {
+				panic("sdk: key address segment without an address codec")
+			}
+			k.segments[i] = c.Addresses.FormatAddress(v)
+		case int:
+			k.segments[i] = strconv.Itoa(v)
+		case int64:
+			k.segments[i] = strconv.FormatInt(v, 10)
+		case uint64:
+			k.segments[i] = strconv.FormatUint(v, 10)
+		default:
+			panic(fmt.Sprintf("sdk: unsupported key segment type %T", segment))
+		}
+	}
+	return k
+}
+
+// Parse splits `key` in its segments, unescaping them.
+func (c *KeyCodec) Parse(key string) (Key, error) {
+	parts := strings.Split(key, string(c.Delimiter))
+	k := Key{codec: c, segments: make([]string, len(parts))}
+	for i, part := range parts {
+		segment, err := unescape(part)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid key %q: %w", key, err)
+		}
+		k.segments[i] = segment
+	}
+	return k, nil
+}
+
+func (k Key) String() string {
+	var b strings.Builder
+	for i, segment := range k.segments {
+		if i > 0 {
+			b.WriteByte(k.codec.Delimiter)
+		}
+		escape(&b, segment, k.codec.Delimiter)
+	}
+	return b.String()
+}
+
+func (k Key) Len() int {
+	return len(k.segments)
+}
+
+// Is tells whether the key has `length` segments, the first being `prefix`.
+func (k Key) Is(prefix string, length int) bool {
+	return len(k.segments) == length && k.segments[0] == prefix
+}
+
+// Segment returns the `i`th segment, an empty string past the last one.
+func (k Key) Segment(i int) string {
+	if i < 0 || i >= len(k.segments) {
+		return ""
+	}
+	return k.segments[i]
+}
+
+// Address parses the `i`th segment as an address.
+func (k Key) Address(i int) ([]byte, error) {
+	if k.codec.Addresses == nil {
+		return nil, fmt.Errorf("key %q: no address codec to parse segment %d", k, i)
+	}
+	if i < 0 || i >= len(k.segments) {
+		return nil, fmt.Errorf("key %q: no segment %d", k, i)
+	}
+	return k.codec.Addresses.ParseAddress(k.segments[i])
+}
+
+// Uint parses the `i`th segment as a decimal unsigned integer, like a day or
+// a block number.
+func (k Key) Uint(i int) (uint64, error) {
+	if i < 0 || i >= len(k.segments) {
+		return 0, fmt.Errorf("key %q: no segment %d", k, i)
+	}
+	v, err := strconv.ParseUint(k.segments[i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("key %q: segment %d: %w", k, i, err)
+	}
+	return v, nil
+}
+
+const hexDigits = "0123456789ABCDEF"
+
+func escape(b *strings.Builder, segment string, delimiter byte) {
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if c == delimiter || c == '%' {
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+			continue
+		}
+		b.WriteByte(c)
+	}
+}
+
+func unescape(part string) (string, error) {
+	if !strings.Contains(part, "%") {
+		return part, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(part); i++ {
+		if part[i] != '%' {
+			b.WriteByte(part[i])
+			continue
+		}
+		if i+2 >= len(part) {
+			return "", fmt.Errorf("truncated escape in segment %q", part)
+		}
+		v, err := strconv.ParseUint(part[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape %q in segment %q", part[i:i+3], part)
+		}
+		b.WriteByte(byte(v))
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,88 @@
+package sdk
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hexAddresses struct{}
+
+func (hexAddresses) FormatAddress(address []byte) string { return hex.EncodeToString(address) }
+func (hexAddresses) ParseAddress(address string) ([]byte, error) {
+	return hex.DecodeString(address)
+}
+
+func TestKeyCodec_Key(t *testing.T) {
+	keys := NewKeyCodec(hexAddresses{})
+
+	assert.Equal(t, "pair:abcd:usd", keys.Key("pair", Address{0xab, 0xcd}, "usd").String())
+	assert.Equal(t, "flow_day:19000:1:18446744073709551615", keys.Key("flow_day", int64(19000), 1, uint64(18446744073709551615)).String())
+	assert.Equal(t, "swaps:a%3Ab%25c", keys.Key("swaps", "a:b%c").String())
+
+	slashes := &KeyCodec{Delimiter: '/', Addresses: hexAddresses{}}
+	assert.Equal(t, "swaps/a:b%2Fc", slashes.Key("swaps", "a:b/c").String())
+
+	assert.PanicsWithValue(t, "sdk: unsupported key segment type float64", func() { keys.Key("price", 1.5) })
+	assert.Panics(t, func() { plainKeys.Key("pair", Address{0xab}) })
+}
+
+func TestKeyCodec_Parse(t *testing.T) {
+	keys := NewKeyCodec(hexAddresses{})
+
+	key, err := keys.Parse("pair:abcd:usd")
+	require.NoError(t, err)
+	assert.Equal(t, 3, key.Len())
+	assert.True(t, key.Is("pair", 3))
+	assert.False(t, key.Is("pair", 2))
+	assert.False(t, key.Is("token", 3))
+	assert.Equal(t, "usd", key.Segment(2))
+	assert.Equal(t, "", key.Segment(3))
+	assert.Equal(t, "pair:abcd:usd", key.String())
+
+	address, err := key.Address(1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xab, 0xcd}, address)
+	_, err = key.Address(3)
+	assert.EqualError(t, err, `key "pair:abcd:usd": no segment 3`)
+
+	_, err = key.Uint(2)
+	assert.Error(t, err)
+
+	key, err = keys.Parse("swaps:a%3Ab%25c:19000")
+	require.NoError(t, err)
+	assert.Equal(t, "a:b%c", key.Segment(1))
+	day, err := key.Uint(2)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(19000), day)
+	assert.Equal(t, "swaps:a%3Ab%25c:19000", key.String())
+
+	for _, invalid := range []string{"swaps:a%3", "swaps:a%zz"} {
+		_, err = keys.Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey("reserve0:abcd")
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", key.Segment(1))
+
+	_, err = key.Address(1)
+	assert.EqualError(t, err, `key "reserve0:abcd": no address codec to parse segment 1`)
+}
+
+func ExampleKeyCodec() {
+	keys := NewKeyCodec(hexAddresses{})
+	fmt.Println(keys.Key("pair", Address{0x0e, 0x09}, "token0").String())
+
+	key, _ := keys.Parse("pair:0e09:token0")
+	pair, _ := key.Address(1)
+	fmt.Printf("%x %s\n", pair, key.Segment(2))
+	// Output:
+	// pair:0e09:token0
+	// 0e09 token0
+}
@@ -0,0 +1,116 @@
+// Package sdk is the surface Go modules are written against. It only holds the
+// types a module sees while processing a block, the current block, the stores
+// it declared as inputs, JSON-RPC access and a logger, so modules do not depend
+// on how the exchange runs them.
+//
+// A module is a `MapFunc` and an optional `StoreFunc`:
+//
+//	func MapPairs(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+//		intr.Logger().Debug("extracting pairs", zap.Uint64("block_num", intr.CurrentBlock().Number()))
+//		...
+//	}
+//
+//	func StorePairs(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, store sdk.Store) error {
+//		for _, pair := range output.([]*Pair) {
+//			store.Set(keys.Key("pair", sdk.Address(pair.Address)).String(), pair.Bytes())
+//		}
+//		return nil
+//	}
+//
+// Modules only relying on `ChainIntrinsics`, the current block, native calls and
+// the stores, don't depend on the EVM: the chain specifics are behind `Chain`,
+// implemented for the EVM chains by the `sdk/evm` package.
+//
+// The keys of the stores are built and parsed by a `KeyCodec`, `keys` above,
+// modules and consumers sharing it to agree on how addresses are written.
+//
+// Hooks run around the modules of every block, a `BeforeBlockFunc` may filter or
+// enrich the block the modules see, an `AfterBlockFunc` sees what they produced.
+//
+// Types of this package are only ever extended, existing methods keep their
+// signature.
+package sdk
+
+import (
+	"errors"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"go.uber.org/zap"
+)
+
+// MapFunc extracts the output of a module from a single block.
+type MapFunc func(block *pbeth.Block, intr Intrinsics) (interface{}, error)
+
+// StoreFunc folds the output produced by the module's `MapFunc` into `store`.
+type StoreFunc func(block *pbeth.Block, output interface{}, intr Intrinsics, store Store) error
+
+// Intrinsics is what the runtime provides to a module for the block being
+// processed, the `ChainIntrinsics` of the EVM chains along with the logs and
+// JSON-RPC access. It must not be retained past the call it was given to.
+type Intrinsics interface {
+	ChainIntrinsics
+
+	// Logs returns the logs of the successful transactions of the block, in block
+	// order. It's extracted once per block and shared by all modules, the slice
+	// is borrowed: it must not be modified nor retained past the call.
+	Logs() []*pbeth.Log
+
+	RPC() RPC
+}
+
+type CurrentBlock interface {
+	ID() string
+	Number() uint64
+	Timestamp() time.Time
+}
+
+// ErrRPCUnavailable is returned by `RPC.Call`, and `NativeCaller.Call`, when
+// the runtime was started without a JSON-RPC endpoint.
+var ErrRPCUnavailable = errors.New("no JSON-RPC endpoint configured")
+
+// ErrUnpinnedCall is returned by `RPC.Call` in strict mode for the calls not
+// made against the state at the current block.
+var ErrUnpinnedCall = errors.New("eth_call not pinned to the current block")
+
+// RPC performs `eth_call`s against the state at the current block, responses
+// are in the same order as `calls`.
+type RPC interface {
+	Call(calls []*RPCCall) ([]*RPCResponse, error)
+}
+
+type RPCCall struct {
+	ToAddr string
+	Data   []byte
+
+	// BlockNum is the block whose state the call reads, runtimes set it to the
+	// current block when zero. Latest reads the head of the chain instead,
+	// such calls are not reproducible and are rejected in strict mode.
+	BlockNum uint64
+	Latest   bool
+}
+
+type RPCResponse struct {
+	Raw       []byte
+	CallError error // always deterministic
+}
+
+// Logger is satisfied by `*zap.Logger`.
+type Logger interface {
+	Debug(msg string, fields ...zap.Field)
+	Info(msg string, fields ...zap.Field)
+	Warn(msg string, fields ...zap.Field)
+	Error(msg string, fields ...zap.Field)
+}
+
+// AppendSuccessfulLogs appends the logs of the successful transactions of
+// `block` to `dst`, it's how runtimes build `Intrinsics.Logs`.
+func AppendSuccessfulLogs(dst []*pbeth.Log, block *pbeth.Block) []*pbeth.Log {
+	for _, trx := range block.TransactionTraces {
+		if trx.Status != pbeth.TransactionTraceStatus_SUCCEEDED || trx.Receipt == nil {
+			continue
+		}
+		dst = append(dst, trx.Receipt.Logs...)
+	}
+	return dst
+}
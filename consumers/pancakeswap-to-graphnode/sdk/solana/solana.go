@@ -0,0 +1,66 @@
+// Package solana is the `sdk.Chain` of Solana, whose blocks are
+// `sf.solana.type.v1.Block`: addresses and hashes are base58, the number of a
+// block is its slot.
+package solana
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mr-tron/base58"
+	pbsol "github.com/streamingfast/substream-pancakeswap/pb/sf/solana/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"google.golang.org/protobuf/proto"
+)
+
+const addressLength = 32
+
+var Chain sdk.Chain = chain{}
+
+type chain struct{}
+
+func (chain) Name() string      { return "solana" }
+func (chain) BlockType() string { return "sf.solana.type.v1.Block" }
+
+func (chain) CurrentBlock(block proto.Message) (sdk.CurrentBlock, error) {
+	solBlock, ok := block.(*pbsol.Block)
+	if !ok {
+		return nil, fmt.Errorf("expected a %s block, got %T", Chain.BlockType(), block)
+	}
+	return CurrentBlock(solBlock), nil
+}
+
+func (chain) FormatAddress(address []byte) string {
+	return base58.Encode(address)
+}
+
+func (chain) ParseAddress(address string) ([]byte, error) {
+	out, err := base58.Decode(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	if len(out) != addressLength {
+		return nil, fmt.Errorf("invalid address %q: %d bytes, expected %d", address, len(out), addressLength)
+	}
+	return out, nil
+}
+
+func (chain) FormatHash(hash []byte) string {
+	return base58.Encode(hash)
+}
+
+// CurrentBlock returns the accessor of `block`. Its ID is the blockhash, its
+// number the slot, the block height not counting the skipped slots.
+func CurrentBlock(block *pbsol.Block) sdk.CurrentBlock {
+	return blockRef{block}
+}
+
+type blockRef struct {
+	block *pbsol.Block
+}
+
+func (b blockRef) ID() string     { return b.block.Blockhash }
+func (b blockRef) Number() uint64 { return b.block.Slot }
+func (b blockRef) Timestamp() time.Time {
+	return time.Unix(b.block.GetBlockTime().GetTimestamp(), 0).UTC()
+}
@@ -0,0 +1,36 @@
+package solana
+
+import (
+	"testing"
+
+	pbsol "github.com/streamingfast/substream-pancakeswap/pb/sf/solana/type/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_CurrentBlock(t *testing.T) {
+	block := &pbsol.Block{Slot: 42, Blockhash: "4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZAMdL4VZHirAn", BlockTime: &pbsol.UnixTimestamp{Timestamp: 126}}
+	current, err := Chain.CurrentBlock(block)
+	require.NoError(t, err)
+	assert.Equal(t, "4sGjMW1sUnHzSxGspuhpqLDx6wiyjNtZAMdL4VZHirAn", current.ID())
+	assert.Equal(t, uint64(42), current.Number())
+	assert.Equal(t, int64(126), current.Timestamp().Unix())
+
+	_, err = Chain.CurrentBlock(&pbsubstreams.Clock{Number: 42})
+	assert.EqualError(t, err, "expected a sf.solana.type.v1.Block block, got *pbsubstreams.Clock")
+}
+
+func TestChain_Addresses(t *testing.T) {
+	address, err := Chain.ParseAddress("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	require.NoError(t, err)
+	assert.Len(t, address, 32)
+	assert.Equal(t, "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA", Chain.FormatAddress(address))
+
+	_, err = Chain.ParseAddress("2g")
+	assert.EqualError(t, err, `invalid address "2g": 1 bytes, expected 32`)
+	_, err = Chain.ParseAddress("0x0e09")
+	assert.Error(t, err)
+
+	assert.Equal(t, "1LQY", Chain.FormatHash([]byte{0x00, 0xff, 0x01}))
+}
@@ -0,0 +1,87 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Store is the key/value storage a module's `StoreFunc` writes to.
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// StoreReader is a read-only view of an upstream store while a block is processed.
+//
+// `GetLast` sees the store after the upstream module processed the current block,
+// which is always the case as modules run in dependency order. `GetFirst` sees the
+// store as it was at the start of the block, before any of the block's changes.
+type StoreReader interface {
+	GetLast(key string) ([]byte, bool)
+	GetFirst(key string) ([]byte, bool)
+}
+
+// StoreScanner is a `StoreReader` whose keys can be iterated, the views of the
+// upstream stores given by the runtime are, see `PrefixScan`.
+type StoreScanner interface {
+	// PrefixScan calls `fn` for every key starting with `prefix`, in key
+	// order, with the value it had at the start of the block, like `GetFirst`.
+	// Iterating stops at the first error of `fn`, which is returned.
+	PrefixScan(prefix string, fn func(key string, value []byte) error) error
+}
+
+// ErrScanUnsupported is returned by `PrefixScan` for the stores that can't be
+// iterated.
+var ErrScanUnsupported = errors.New("store can't be iterated")
+
+// ErrScanLimit is returned by `PrefixScan` when more keys than the runtime
+// allows in a single scan start with the prefix, before `fn` is called, so the
+// processing of a block stays bounded. A module aggregating that many keys
+// should keep its aggregate in a store of its own, updated from the deltas.
+var ErrScanLimit = errors.New("too many keys to scan")
+
+// PrefixScan iterates the keys of `store` starting with `prefix` as they were
+// at the start of the block, see `StoreScanner`: the changes the upstream
+// module made in the current block are not seen, whatever the order of the
+// calls, for aggregates like leaderboards and TVLs to be computed from a
+// consistent state.
+//
+//	err := sdk.PrefixScan(reserves, "reserve:", func(key string, value []byte) error {
+//		...
+//	})
+func PrefixScan(store StoreReader, prefix string, fn func(key string, value []byte) error) error {
+	scanner, ok := store.(StoreScanner)
+	if !ok {
+		return ErrScanUnsupported
+	}
+	return scanner.PrefixScan(prefix, fn)
+}
+
+type DeltaOperation int
+
+const (
+	DeltaCreate DeltaOperation = iota
+	DeltaUpdate
+	DeltaDelete
+)
+
+func (o DeltaOperation) String() string {
+	switch o {
+	case DeltaCreate:
+		return "create"
+	case DeltaUpdate:
+		return "update"
+	case DeltaDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("unknown(%d)", int(o))
+}
+
+// Delta is a single change of a store within a block.
+type Delta struct {
+	Operation DeltaOperation
+	Key       string
+	OldValue  []byte
+	NewValue  []byte
+}
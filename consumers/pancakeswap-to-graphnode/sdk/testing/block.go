@@ -0,0 +1,114 @@
+package testing
+
+import (
+	"encoding/binary"
+	"time"
+
+	eth "github.com/streamingfast/eth-go"
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// BlockBuilder builds synthetic blocks:
+//
+//	block := sdktesting.NewBlock(100).
+//		Trx("0x1111111111111111111111111111111111111111", factoryAddress).
+//		Log(factoryAddress, data, PairCreatedTopic, token0Topic, token1Topic).
+//		FailedTrx(from, to).
+//		Build()
+//
+// Addresses are hex strings, with or without the `0x` prefix.
+type BlockBuilder struct {
+	block *pbeth.Block
+
+	logIndex uint32
+	ordinal  uint64
+}
+
+// NewBlock starts a block `num` whose hash is derived from its number and
+// timestamp is `num` times 3 seconds after the epoch, the BSC block time.
+func NewBlock(num uint64) *BlockBuilder {
+	hash := make([]byte, 32)
+	binary.BigEndian.PutUint64(hash[24:], num)
+
+	parentHash := make([]byte, 32)
+	if num > 0 {
+		binary.BigEndian.PutUint64(parentHash[24:], num-1)
+	}
+
+	return &BlockBuilder{
+		block: &pbeth.Block{
+			Ver:    1,
+			Number: num,
+			Hash:   hash,
+			Header: &pbeth.BlockHeader{
+				Number:     num,
+				Hash:       hash,
+				ParentHash: parentHash,
+				Timestamp:  timestamppb.New(time.Unix(int64(num)*3, 0)),
+			},
+		},
+	}
+}
+
+func (b *BlockBuilder) At(timestamp time.Time) *BlockBuilder {
+	b.block.Header.Timestamp = timestamppb.New(timestamp)
+	return b
+}
+
+// Trx starts a successful transaction, logs added after it belong to it.
+func (b *BlockBuilder) Trx(from, to string) *BlockBuilder {
+	return b.trx(from, to, pbeth.TransactionTraceStatus_SUCCEEDED)
+}
+
+// FailedTrx starts a reverted transaction, its logs are kept in the receipt like
+// they are in firehose blocks, modules are expected to skip them.
+func (b *BlockBuilder) FailedTrx(from, to string) *BlockBuilder {
+	return b.trx(from, to, pbeth.TransactionTraceStatus_REVERTED)
+}
+
+func (b *BlockBuilder) trx(from, to string, status pbeth.TransactionTraceStatus) *BlockBuilder {
+	index := uint32(len(b.block.TransactionTraces))
+	hash := make([]byte, 32)
+	binary.BigEndian.PutUint64(hash[16:], b.block.Number)
+	binary.BigEndian.PutUint32(hash[28:], index)
+
+	b.ordinal++
+	b.block.TransactionTraces = append(b.block.TransactionTraces, &pbeth.TransactionTrace{
+		Hash:         hash,
+		Index:        index,
+		From:         eth.MustNewAddress(from),
+		To:           eth.MustNewAddress(to),
+		Status:       status,
+		BeginOrdinal: b.ordinal,
+		EndOrdinal:   b.ordinal,
+		Receipt:      &pbeth.TransactionReceipt{},
+	})
+	return b
+}
+
+// Log adds a log emitted by `address` to the current transaction, a transaction
+// from and to `address` is started when there is none.
+func (b *BlockBuilder) Log(address string, data []byte, topics ...[]byte) *BlockBuilder {
+	if len(b.block.TransactionTraces) == 0 {
+		b.Trx(address, address)
+	}
+
+	trx := b.block.TransactionTraces[len(b.block.TransactionTraces)-1]
+	b.ordinal++
+	trx.Receipt.Logs = append(trx.Receipt.Logs, &pbeth.Log{
+		Address:    eth.MustNewAddress(address),
+		Topics:     topics,
+		Data:       data,
+		Index:      uint32(len(trx.Receipt.Logs)),
+		BlockIndex: b.logIndex,
+		Ordinal:    b.ordinal,
+	})
+	trx.EndOrdinal = b.ordinal
+	b.logIndex++
+	return b
+}
+
+func (b *BlockBuilder) Build() *pbeth.Block {
+	return b.block
+}
@@ -0,0 +1,93 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// TB is the subset of `testing.TB` used by the assertions.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+func Created(key, value string) *sdk.Delta {
+	return &sdk.Delta{Operation: sdk.DeltaCreate, Key: key, NewValue: []byte(value)}
+}
+
+func Updated(key, oldValue, newValue string) *sdk.Delta {
+	return &sdk.Delta{Operation: sdk.DeltaUpdate, Key: key, OldValue: []byte(oldValue), NewValue: []byte(newValue)}
+}
+
+func Deleted(key, oldValue string) *sdk.Delta {
+	return &sdk.Delta{Operation: sdk.DeltaDelete, Key: key, OldValue: []byte(oldValue)}
+}
+
+// AssertDeltas checks that `actual` holds exactly the `expected` deltas, in order,
+// and reports both lists when they differ.
+func AssertDeltas(t TB, actual []*sdk.Delta, expected ...*sdk.Delta) bool {
+	t.Helper()
+
+	equal := len(actual) == len(expected)
+	for i := 0; equal && i < len(actual); i++ {
+		equal = formatDelta(actual[i]) == formatDelta(expected[i])
+	}
+
+	if !equal {
+		t.Errorf("deltas differ\nexpected:\n%s\nactual:\n%s", formatDeltas(expected), formatDeltas(actual))
+	}
+	return equal
+}
+
+// AssertDelta checks that `actual` holds `expected`, whatever the other deltas.
+func AssertDelta(t TB, actual []*sdk.Delta, expected *sdk.Delta) bool {
+	t.Helper()
+
+	for _, delta := range actual {
+		if formatDelta(delta) == formatDelta(expected) {
+			return true
+		}
+	}
+
+	t.Errorf("delta %s not found in:\n%s", formatDelta(expected), formatDeltas(actual))
+	return false
+}
+
+// AssertNoDelta checks that `key` was not changed.
+func AssertNoDelta(t TB, actual []*sdk.Delta, key string) bool {
+	t.Helper()
+
+	for _, delta := range actual {
+		if delta.Key == key {
+			t.Errorf("unexpected delta %s", formatDelta(delta))
+			return false
+		}
+	}
+	return true
+}
+
+func formatDelta(delta *sdk.Delta) string {
+	switch delta.Operation {
+	case sdk.DeltaCreate:
+		return fmt.Sprintf("create %s = %q", delta.Key, delta.NewValue)
+	case sdk.DeltaUpdate:
+		return fmt.Sprintf("update %s %q -> %q", delta.Key, delta.OldValue, delta.NewValue)
+	case sdk.DeltaDelete:
+		return fmt.Sprintf("delete %s (was %q)", delta.Key, delta.OldValue)
+	}
+	return fmt.Sprintf("%s %s", delta.Operation, delta.Key)
+}
+
+func formatDeltas(deltas []*sdk.Delta) string {
+	if len(deltas) == 0 {
+		return "  (none)"
+	}
+
+	lines := make([]string, len(deltas))
+	for i, delta := range deltas {
+		lines[i] = "  " + formatDelta(delta)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,87 @@
+package testing
+
+import (
+	"fmt"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sdk/evm"
+	"go.uber.org/zap"
+)
+
+// Intrinsics is an `sdk.Intrinsics` for tests, inputs are declared with the
+// `With*` methods. Reading an input that was not declared fails the same way it
+// does at runtime.
+type Intrinsics struct {
+	block  *pbeth.Block
+	logs   []*pbeth.Log
+	rpc    sdk.RPC
+	logger sdk.Logger
+	stores map[string]sdk.StoreReader
+	deltas map[string][]*sdk.Delta
+}
+
+var _ sdk.Intrinsics = (*Intrinsics)(nil)
+
+// NewIntrinsics creates the intrinsics of `block`, without RPC and with a no-op
+// logger.
+func NewIntrinsics(block *pbeth.Block) *Intrinsics {
+	return &Intrinsics{
+		block:  block,
+		logs:   sdk.AppendSuccessfulLogs(nil, block),
+		rpc:    RPCFunc(func(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) { return nil, sdk.ErrRPCUnavailable }),
+		logger: zap.NewNop(),
+		stores: map[string]sdk.StoreReader{},
+		deltas: map[string][]*sdk.Delta{},
+	}
+}
+
+func (i *Intrinsics) WithStore(module string, store sdk.StoreReader) *Intrinsics {
+	i.stores[module] = store
+	return i
+}
+
+func (i *Intrinsics) WithDeltas(module string, deltas ...*sdk.Delta) *Intrinsics {
+	i.deltas[module] = deltas
+	return i
+}
+
+func (i *Intrinsics) WithRPC(rpc sdk.RPC) *Intrinsics {
+	i.rpc = rpc
+	return i
+}
+
+func (i *Intrinsics) WithLogger(logger sdk.Logger) *Intrinsics {
+	i.logger = logger
+	return i
+}
+
+func (i *Intrinsics) Chain() sdk.Chain               { return evm.Chain }
+func (i *Intrinsics) CurrentBlock() sdk.CurrentBlock { return evm.CurrentBlock(i.block) }
+func (i *Intrinsics) Logs() []*pbeth.Log             { return i.logs }
+func (i *Intrinsics) RPC() sdk.RPC                   { return i.rpc }
+func (i *Intrinsics) Native() sdk.NativeCaller       { return evm.NativeCaller(i.rpc) }
+func (i *Intrinsics) Logger() sdk.Logger             { return i.logger }
+
+func (i *Intrinsics) Store(module string) (sdk.StoreReader, error) {
+	store, found := i.stores[module]
+	if !found {
+		return nil, fmt.Errorf("no %q input on store %q", "get", module)
+	}
+	return store, nil
+}
+
+func (i *Intrinsics) Deltas(module string) ([]*sdk.Delta, error) {
+	deltas, found := i.deltas[module]
+	if !found {
+		return nil, fmt.Errorf("no %q input on store %q", "deltas", module)
+	}
+	return deltas, nil
+}
+
+// RPCFunc adapts a function to `sdk.RPC`, to stub calls in tests.
+type RPCFunc func(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error)
+
+func (f RPCFunc) Call(calls []*sdk.RPCCall) ([]*sdk.RPCResponse, error) {
+	return f(calls)
+}
@@ -0,0 +1,129 @@
+// Package testing provides what is needed to unit test `sdk` modules without a
+// firehose: in-memory stores recording their deltas, an `sdk.Intrinsics`
+// implementation, delta assertions and a builder of synthetic blocks.
+//
+// It is meant to be imported under another name, next to the standard library
+// `testing` package:
+//
+//	import sdktesting "github.com/streamingfast/substream-pancakeswap/sdk/testing"
+package testing
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+)
+
+// TestStore is an in-memory store that records the deltas of the current block.
+// It can be given to a `StoreFunc` as its `sdk.Store`, and to downstream modules
+// through `Intrinsics.WithStore`.
+type TestStore struct {
+	kv     map[string][]byte
+	deltas []*sdk.Delta
+}
+
+var (
+	_ sdk.Store        = (*TestStore)(nil)
+	_ sdk.StoreReader  = (*TestStore)(nil)
+	_ sdk.StoreScanner = (*TestStore)(nil)
+)
+
+// NewTestStore creates a store holding `kv`, setting up the store does not
+// produce deltas.
+func NewTestStore(kv map[string]string) *TestStore {
+	s := &TestStore{kv: map[string][]byte{}}
+	for key, value := range kv {
+		s.kv[key] = []byte(value)
+	}
+	return s
+}
+
+func (s *TestStore) Get(key string) ([]byte, bool) {
+	value, found := s.kv[key]
+	return value, found
+}
+
+func (s *TestStore) Set(key string, value []byte) {
+	old, found := s.kv[key]
+	delta := &sdk.Delta{Operation: sdk.DeltaCreate, Key: key, NewValue: value}
+	if found {
+		delta.Operation = sdk.DeltaUpdate
+		delta.OldValue = old
+	}
+
+	s.kv[key] = value
+	s.deltas = append(s.deltas, delta)
+}
+
+func (s *TestStore) Delete(key string) {
+	old, found := s.kv[key]
+	if !found {
+		return
+	}
+
+	delete(s.kv, key)
+	s.deltas = append(s.deltas, &sdk.Delta{Operation: sdk.DeltaDelete, Key: key, OldValue: old})
+}
+
+func (s *TestStore) GetLast(key string) ([]byte, bool) {
+	return s.Get(key)
+}
+
+func (s *TestStore) GetFirst(key string) ([]byte, bool) {
+	for _, delta := range s.deltas {
+		if delta.Key == key {
+			return delta.OldValue, delta.Operation != sdk.DeltaCreate
+		}
+	}
+	return s.Get(key)
+}
+
+// PrefixScan iterates the keys as they were before the deltas of the current
+// block, like the runtime does, without its limit on the number of keys.
+func (s *TestStore) PrefixScan(prefix string, fn func(key string, value []byte) error) error {
+	seen := map[string]bool{}
+	var keys []string
+	for key := range s.kv {
+		if strings.HasPrefix(key, prefix) {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, delta := range s.deltas {
+		if strings.HasPrefix(delta.Key, prefix) && !seen[delta.Key] {
+			seen[delta.Key] = true
+			keys = append(keys, delta.Key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if value, found := s.GetFirst(key); found {
+			if err := fn(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Deltas returns the changes made to the store since it was created or since
+// the last `NextBlock` call.
+func (s *TestStore) Deltas() []*sdk.Delta {
+	return s.deltas
+}
+
+// NextBlock clears the recorded deltas, the content of the store is kept.
+func (s *TestStore) NextBlock() {
+	s.deltas = nil
+}
+
+// Snapshot returns the content of the store.
+func (s *TestStore) Snapshot() map[string]string {
+	out := make(map[string]string, len(s.kv))
+	for key, value := range s.kv {
+		out[key] = string(value)
+	}
+	return out
+}
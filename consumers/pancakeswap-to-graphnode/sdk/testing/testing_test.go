@@ -0,0 +1,181 @@
+package testing_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	pbeth "github.com/streamingfast/sf-ethereum/types/pb/sf/ethereum/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	sdktesting "github.com/streamingfast/substream-pancakeswap/sdk/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pairA = "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	pairB = "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	user  = "0x1111111111111111111111111111111111111111"
+)
+
+var topic = []byte{0x01}
+
+// mapEmitters returns the address of the contracts that emitted `topic` in
+// successful transactions.
+func mapEmitters(block *pbeth.Block, intr sdk.Intrinsics) (interface{}, error) {
+	var out []string
+	for _, trx := range block.TransactionTraces {
+		if trx.Status != pbeth.TransactionTraceStatus_SUCCEEDED {
+			continue
+		}
+		for _, log := range trx.Receipt.Logs {
+			if len(log.Topics) > 0 && string(log.Topics[0]) == string(topic) {
+				out = append(out, hex.EncodeToString(log.Address))
+			}
+		}
+	}
+	return out, nil
+}
+
+// storeEmitters counts the logs of each emitter, only for the emitters listed
+// in the `store_known` input.
+func storeEmitters(block *pbeth.Block, output interface{}, intr sdk.Intrinsics, store sdk.Store) error {
+	known, err := intr.Store("store_known")
+	if err != nil {
+		return err
+	}
+
+	for _, emitter := range output.([]string) {
+		if _, found := known.GetLast(emitter); !found {
+			continue
+		}
+
+		count := 0
+		if value, found := store.Get("count:" + emitter); found {
+			fmt.Sscan(string(value), &count)
+		}
+		store.Set("count:"+emitter, []byte(fmt.Sprint(count+1)))
+	}
+	return nil
+}
+
+func TestModule(t *testing.T) {
+	known := sdktesting.NewTestStore(map[string]string{
+		pairA[2:]: "1",
+	})
+
+	tests := []struct {
+		name           string
+		block          *pbeth.Block
+		expectedDeltas []*sdk.Delta
+	}{
+		{
+			"counts known emitters",
+			sdktesting.NewBlock(10).
+				Trx(user, pairA).Log(pairA, nil, topic).
+				Trx(user, pairB).Log(pairB, nil, topic).
+				Build(),
+			[]*sdk.Delta{sdktesting.Created("count:"+pairA[2:], "1")},
+		},
+		{
+			"skips failed transactions",
+			sdktesting.NewBlock(11).
+				FailedTrx(user, pairA).Log(pairA, nil, topic).
+				Build(),
+			nil,
+		},
+		{
+			"updates existing counts",
+			sdktesting.NewBlock(12).
+				Log(pairA, nil, topic).Log(pairA, nil, []byte{0x02}).
+				Build(),
+			[]*sdk.Delta{sdktesting.Updated("count:"+pairA[2:], "1", "2")},
+		},
+	}
+
+	store := sdktesting.NewTestStore(nil)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store.NextBlock()
+			intr := sdktesting.NewIntrinsics(test.block).WithStore("store_known", known)
+
+			output, err := mapEmitters(test.block, intr)
+			require.NoError(t, err)
+			require.NoError(t, storeEmitters(test.block, output, intr, store))
+
+			sdktesting.AssertDeltas(t, store.Deltas(), test.expectedDeltas...)
+		})
+	}
+
+	assert.Equal(t, map[string]string{"count:" + pairA[2:]: "2"}, store.Snapshot())
+}
+
+func TestIntrinsics_UndeclaredInput(t *testing.T) {
+	block := sdktesting.NewBlock(1).Build()
+	err := storeEmitters(block, []string{}, sdktesting.NewIntrinsics(block), sdktesting.NewTestStore(nil))
+	require.Error(t, err)
+}
+
+func TestTestStore_PrefixScan(t *testing.T) {
+	store := sdktesting.NewTestStore(map[string]string{"count:a": "1", "count:b": "2", "other": "3"})
+	store.Set("count:a", []byte("10"))
+	store.Set("count:c", []byte("1"))
+	store.Delete("count:b")
+
+	var scanned []string
+	err := sdk.PrefixScan(store, "count:", func(key string, value []byte) error {
+		scanned = append(scanned, key+"="+string(value))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"count:a=1", "count:b=2"}, scanned)
+}
+
+func TestNewBlock(t *testing.T) {
+	at := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	block := sdktesting.NewBlock(7).At(at).
+		Trx(user, pairA).Log(pairA, []byte{0xff}, topic).Log(pairA, nil).
+		Trx(user, pairB).Log(pairB, nil).
+		Build()
+
+	intr := sdktesting.NewIntrinsics(block)
+	assert.Equal(t, uint64(7), intr.CurrentBlock().Number())
+	assert.Equal(t, at, intr.CurrentBlock().Timestamp().UTC())
+
+	require.Len(t, block.TransactionTraces, 2)
+	logs := block.TransactionTraces[0].Receipt.Logs
+	require.Len(t, logs, 2)
+	assert.Equal(t, []uint32{0, 1}, []uint32{logs[0].Index, logs[1].Index})
+	assert.Equal(t, uint32(2), block.TransactionTraces[1].Receipt.Logs[0].BlockIndex)
+	assert.Less(t, logs[0].Ordinal, logs[1].Ordinal)
+	assert.NotEqual(t, block.TransactionTraces[0].Hash, block.TransactionTraces[1].Hash)
+
+	_, err := intr.RPC().Call(nil)
+	assert.Equal(t, sdk.ErrRPCUnavailable, err)
+}
+
+type recordingTB struct {
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	deltas := []*sdk.Delta{sdktesting.Created("a", "1"), sdktesting.Deleted("b", "2")}
+
+	tb := &recordingTB{}
+	assert.True(t, sdktesting.AssertDeltas(tb, deltas, sdktesting.Created("a", "1"), sdktesting.Deleted("b", "2")))
+	assert.True(t, sdktesting.AssertDelta(tb, deltas, sdktesting.Deleted("b", "2")))
+	assert.True(t, sdktesting.AssertNoDelta(tb, deltas, "c"))
+	assert.Empty(t, tb.errors)
+
+	assert.False(t, sdktesting.AssertDeltas(tb, deltas, sdktesting.Created("a", "2")))
+	assert.False(t, sdktesting.AssertDelta(tb, deltas, sdktesting.Updated("a", "0", "1")))
+	assert.False(t, sdktesting.AssertNoDelta(tb, deltas, "b"))
+	require.Len(t, tb.errors, 3)
+	assert.Contains(t, tb.errors[0], `create a = "2"`)
+}
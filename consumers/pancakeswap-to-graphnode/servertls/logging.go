@@ -0,0 +1,7 @@
+package servertls
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.servertls", "github.com/streamingfast/substream-pancakeswap/servertls")
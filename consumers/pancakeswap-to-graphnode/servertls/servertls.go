@@ -0,0 +1,212 @@
+// Package servertls serves the HTTP and gRPC servers over TLS, optionally
+// requiring client certificates signed by a given CA (mTLS). The certificate
+// and the client CA are reloaded when their files change, so they can be
+// rotated without restarting.
+package servertls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reloadInterval is how often, at most, the files are checked for changes,
+// they're checked on handshakes.
+const reloadInterval = 10 * time.Second
+
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the CA of the client certificates, they're not
+	// requested when empty.
+	ClientCAFile string
+}
+
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.ClientCAFile != ""
+}
+
+func (c Config) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("a TLS certificate requires its key, and the other way around")
+	}
+	if c.ClientCAFile != "" && c.CertFile == "" {
+		return fmt.Errorf("a client CA requires a TLS certificate")
+	}
+	return nil
+}
+
+// Load returns the TLS configuration of the servers, nil when `config` isn't
+// enabled.
+func Load(config Config) (*tls.Config, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return load(config, reloadInterval)
+}
+
+func load(config Config, interval time.Duration) (*tls.Config, error) {
+	r := &reloader{config: config, interval: interval}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	out := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: r.getCertificate}
+	if config.ClientCAFile != "" {
+		// verified against the current CA, not the one of the configuration
+		out.ClientAuth = tls.RequireAnyClientCert
+		out.VerifyPeerCertificate = r.verifyClient
+	}
+	return out, nil
+}
+
+type reloader struct {
+	config   Config
+	interval time.Duration
+
+	lock      sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+	checked   time.Time
+}
+
+func (r *reloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
+
+func (r *reloader) stat() ([]time.Time, error) {
+	var modTimes []time.Time
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("stat %q: %w", file, err)
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// load reads the files, it must be called with the lock held.
+func (r *reloader) load() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		content, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(content) {
+			return fmt.Errorf("no certificate found in client CA %q", r.config.ClientCAFile)
+		}
+	}
+
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	return nil
+}
+
+// maybeReload reloads the files when they changed, keeping the previous ones
+// when they can't be loaded, like a certificate written but not its key yet.
+func (r *reloader) maybeReload(now time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if now.Sub(r.checked) < r.interval {
+		return
+	}
+	r.checked = now
+
+	modTimes, err := r.stat()
+	if err != nil {
+		zlog.Warn("checking TLS files", zap.Error(err))
+		return
+	}
+	changed := false
+	for i := range modTimes {
+		changed = changed || !modTimes[i].Equal(r.modTimes[i])
+	}
+	if !changed {
+		return
+	}
+
+	if err := r.load(); err != nil {
+		zlog.Warn("reloading TLS files, the previous ones are kept", zap.Error(err))
+		return
+	}
+	zlog.Info("TLS files reloaded", zap.Strings("files", r.files()))
+}
+
+func (r *reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.maybeReload(time.Now())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.cert, nil
+}
+
+func (r *reloader) verifyClient(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("a client certificate is required")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("parse client certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+
+	r.lock.Lock()
+	roots := r.clientCAs
+	r.lock.Unlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+type configKey struct{}
+
+// WithConfig returns `ctx` carrying the TLS configuration of the servers, for
+// the ones created out of a context, like the outputs.
+func WithConfig(ctx context.Context, config *tls.Config) context.Context {
+	return context.WithValue(ctx, configKey{}, config)
+}
+
+// FromContext returns the TLS configuration of `ctx`, nil when none.
+func FromContext(ctx context.Context) *tls.Config {
+	config, _ := ctx.Value(configKey{}).(*tls.Config)
+	return config
+}
@@ -0,0 +1,160 @@
+package servertls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyPair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// issue returns a certificate for `name` signed by `parent`, self-signed when
+// nil.
+func issue(t *testing.T, name string, parent *keyPair, usage x509.ExtKeyUsage) *keyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{name},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &keyPair{cert: cert, key: key, der: der}
+}
+
+func (k *keyPair) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: k.der}), 0644))
+	if keyFile != "" {
+		der, err := x509.MarshalECPrivateKey(k.key)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+	}
+}
+
+func (k *keyPair) tls() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{k.der}, PrivateKey: k.key}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.False(t, Config{}.Enabled())
+	assert.NoError(t, Config{CertFile: "c", KeyFile: "k"}.Validate())
+	assert.NoError(t, Config{CertFile: "c", KeyFile: "k", ClientCAFile: "ca"}.Validate())
+	assert.Error(t, Config{CertFile: "c"}.Validate())
+	assert.Error(t, Config{ClientCAFile: "ca"}.Validate())
+
+	config, err := Load(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, config)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	}
+
+	ca := issue(t, "ca", nil, x509.ExtKeyUsageAny)
+	ca.write(t, config.ClientCAFile, "")
+	issue(t, "localhost", ca, x509.ExtKeyUsageServerAuth).write(t, config.CertFile, config.KeyFile)
+
+	serverConfig, err := load(config, 0)
+	require.NoError(t, err)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+				conn.Write([]byte("ok"))
+			}()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	dial := func(client *keyPair) (*x509.Certificate, error) {
+		clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+		if client != nil {
+			clientConfig.Certificates = []tls.Certificate{client.tls()}
+		}
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", listener.Addr().String(), clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		// TLS 1.3 reports rejected client certificates on the first read
+		if _, err := conn.Read(make([]byte, 2)); err != nil {
+			return nil, err
+		}
+		return conn.ConnectionState().PeerCertificates[0], nil
+	}
+
+	client := issue(t, "client", ca, x509.ExtKeyUsageClientAuth)
+	served, err := dial(client)
+	require.NoError(t, err)
+
+	_, err = dial(nil)
+	assert.Error(t, err, "client certificate required")
+	_, err = dial(issue(t, "stranger", issue(t, "other ca", nil, x509.ExtKeyUsageAny), x509.ExtKeyUsageClientAuth))
+	assert.Error(t, err, "client certificate of another CA")
+
+	// rotated, the next handshakes use the new certificate
+	time.Sleep(10 * time.Millisecond)
+	issue(t, "localhost", ca, x509.ExtKeyUsageServerAuth).write(t, config.CertFile, config.KeyFile)
+	rotated, err := dial(client)
+	require.NoError(t, err)
+	assert.NotEqual(t, served.SerialNumber, rotated.SerialNumber)
+
+	// a broken rotation keeps the previous certificate
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, os.WriteFile(config.KeyFile, []byte("garbage"), 0600))
+	kept, err := dial(client)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.SerialNumber, kept.SerialNumber)
+}
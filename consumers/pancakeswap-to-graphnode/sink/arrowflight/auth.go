@@ -0,0 +1,199 @@
+package arrowflight
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Auth identifies the clients of the server from the bearer token of their
+// calls, sent as the `authorization: Bearer <token>` metadata: a static token
+// or a JWT signed with HS256, whose `sub` claim is the client. Without any,
+// clients are anonymous and identified by their IP address.
+type Auth struct {
+	// Tokens are the static tokens, mapped to the client they identify.
+	Tokens map[string]string
+	// JWTSecret verifies the signature of the JWTs, they aren't accepted when
+	// empty.
+	JWTSecret []byte
+}
+
+func (a *Auth) enabled() bool {
+	return a != nil && (len(a.Tokens) > 0 || len(a.JWTSecret) > 0)
+}
+
+// ReadTokens reads a file of `<client> <token>` lines, empty lines and lines
+// starting with `#` are skipped.
+func ReadTokens(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open tokens file: %w", err)
+	}
+	defer file.Close()
+
+	tokens := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.Fields(text)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected '<client> <token>', got %q", path, line, text)
+		}
+		if _, found := tokens[parts[1]]; found {
+			return nil, fmt.Errorf("%s:%d: token of client %q already given to another client", path, line, parts[0])
+		}
+		tokens[parts[1]] = parts[0]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read tokens file %q: %w", path, err)
+	}
+	return tokens, nil
+}
+
+// client returns the client identified by `token`.
+func (a *Auth) client(token string, now time.Time) (string, error) {
+	for candidate, client := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+			return client, nil
+		}
+	}
+	if len(a.JWTSecret) > 0 && strings.Count(token, ".") == 2 {
+		return verifyJWT(token, a.JWTSecret, now)
+	}
+	return "", fmt.Errorf("unknown token")
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf"`
+}
+
+// verifyJWT checks the HS256 signature and the validity period of `token`, it
+// returns its subject.
+func verifyJWT(token string, secret []byte, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", fmt.Errorf("JWT header: %w", err)
+	}
+	if header.Alg != "HS256" {
+		return "", fmt.Errorf("JWT signed with %q, only HS256 is accepted", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("JWT signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", fmt.Errorf("invalid JWT signature")
+	}
+
+	claims := &jwtClaims{}
+	if err := decodeJWTPart(parts[1], claims); err != nil {
+		return "", fmt.Errorf("JWT claims: %w", err)
+	}
+	if claims.ExpiresAt != 0 && now.Unix() >= claims.ExpiresAt {
+		return "", fmt.Errorf("JWT expired")
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return "", fmt.Errorf("JWT not valid yet")
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("JWT without subject")
+	}
+	return claims.Subject, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+type clientKey struct{}
+
+// clientFrom returns the client of a call, set by the interceptors.
+func clientFrom(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
+
+// authenticate returns `ctx` with the client of the call.
+func (s *Sink) authenticate(ctx context.Context) (context.Context, error) {
+	if !s.auth.enabled() {
+		client := "anonymous"
+		if p, found := peer.FromContext(ctx); found {
+			client = p.Addr.String()
+			if host, _, err := net.SplitHostPort(client); err == nil {
+				client = host
+			}
+		}
+		return context.WithValue(ctx, clientKey{}, client), nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "a bearer token is required")
+	}
+
+	client, err := s.auth.client(strings.TrimPrefix(values[0], "Bearer "), time.Now())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %s", err)
+	}
+	return context.WithValue(ctx, clientKey{}, client), nil
+}
+
+type clientStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *clientStream) Context() context.Context { return s.ctx }
+
+func (s *Sink) unaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Sink) streamInterceptor(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &clientStream{ServerStream: stream, ctx: ctx})
+}
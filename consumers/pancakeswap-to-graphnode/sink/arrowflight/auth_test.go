@@ -0,0 +1,93 @@
+package arrowflight
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow/flight"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func signJWT(header, claims string, secret []byte) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyJWT(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1600000000, 0)
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+
+	tests := []struct {
+		name        string
+		token       string
+		expected    string
+		expectedErr bool
+	}{
+		{"valid", signJWT(hs256, `{"sub":"alice","exp":1600000100}`, secret), "alice", false},
+		{"no expiry", signJWT(hs256, `{"sub":"bob"}`, secret), "bob", false},
+		{"expired", signJWT(hs256, `{"sub":"alice","exp":1600000000}`, secret), "", true},
+		{"not valid yet", signJWT(hs256, `{"sub":"alice","nbf":1600000100}`, secret), "", true},
+		{"wrong secret", signJWT(hs256, `{"sub":"alice"}`, []byte("other")), "", true},
+		{"unsigned", signJWT(`{"alg":"none"}`, `{"sub":"alice"}`, secret), "", true},
+		{"no subject", signJWT(hs256, `{}`, secret), "", true},
+		{"malformed", "abc.def", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := verifyJWT(test.token, secret, now)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, client)
+		})
+	}
+}
+
+func TestSink_Auth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	secret := []byte("secret")
+	s, err := New(&Config{Addr: "127.0.0.1:0", BatchSize: 1, Auth: Auth{Tokens: map[string]string{"static-token": "alice"}, JWTSecret: secret}})
+	require.NoError(t, err)
+	defer s.Close()
+
+	client, err := flight.NewClientWithMiddleware(s.Addr(), nil, nil, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer client.Close()
+
+	listFlights := func(token string) codes.Code {
+		callCtx := ctx
+		if token != "" {
+			callCtx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		stream, err := client.ListFlights(callCtx, &flight.Criteria{})
+		require.NoError(t, err)
+		for {
+			if _, err := stream.Recv(); err != nil {
+				return status.Code(err)
+			}
+		}
+	}
+
+	assert.Equal(t, codes.Unauthenticated, listFlights(""))
+	assert.Equal(t, codes.Unauthenticated, listFlights("wrong"))
+	assert.Equal(t, codes.Unknown, listFlights("static-token"), "io.EOF once the flights are listed")
+	assert.Equal(t, codes.Unknown, listFlights(signJWT(`{"alg":"HS256"}`, `{"sub":"bob"}`, secret)))
+}
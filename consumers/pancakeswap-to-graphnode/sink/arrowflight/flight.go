@@ -0,0 +1,375 @@
+package arrowflight
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/flight"
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/streamingfast/substream-pancakeswap/servertls"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+const defaultBatchSize = 1024
+
+func init() {
+	sink.Register("flight", func(ctx context.Context, params string) (sink.Sink, error) {
+		addr, query := params, ""
+		if i := strings.Index(params, "?"); i >= 0 {
+			addr, query = params[:i], params[i+1:]
+		}
+		if addr == "" {
+			addr = ":8815"
+		}
+
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+		}
+
+		config := &Config{Addr: addr, BatchSize: defaultBatchSize, Limits: Limits{QuotaWindow: time.Hour}}
+		if v := values.Get("batch-size"); v != "" {
+			if config.BatchSize, err = strconv.Atoi(v); err != nil || config.BatchSize <= 0 {
+				return nil, fmt.Errorf("invalid batch-size %q", v)
+			}
+		}
+		if path := values.Get("tokens-file"); path != "" {
+			if config.Auth.Tokens, err = ReadTokens(path); err != nil {
+				return nil, err
+			}
+		}
+		if name := values.Get("jwt-secret-env"); name != "" {
+			if config.Auth.JWTSecret = []byte(os.Getenv(name)); len(config.Auth.JWTSecret) == 0 {
+				return nil, fmt.Errorf("jwt-secret-env %q is empty", name)
+			}
+		}
+		if v := values.Get("rate"); v != "" {
+			if config.Limits.Rate, err = strconv.ParseFloat(v, 64); err != nil || config.Limits.Rate < 0 {
+				return nil, fmt.Errorf("invalid rate %q, expected rows per second", v)
+			}
+		}
+		if v := values.Get("quota"); v != "" {
+			if config.Limits.Quota, err = strconv.ParseInt(v, 10, 64); err != nil || config.Limits.Quota < 0 {
+				return nil, fmt.Errorf("invalid quota %q, expected a number of rows", v)
+			}
+		}
+		if v := values.Get("quota-window"); v != "" {
+			if config.Limits.QuotaWindow, err = time.ParseDuration(v); err != nil || config.Limits.QuotaWindow <= 0 {
+				return nil, fmt.Errorf("invalid quota-window %q", v)
+			}
+		}
+
+		config.TLS = servertls.FromContext(ctx)
+
+		return New(config)
+	})
+}
+
+type Config struct {
+	Addr      string
+	BatchSize int
+	// TLS serves over TLS when set.
+	TLS *tls.Config
+
+	Auth   Auth
+	Limits Limits
+}
+
+// Sink serves module outputs as Arrow record batches over Arrow Flight. Each
+// output module is a flight, its ticket being the module name, and `DoGet`
+// streams the batches produced from the moment the client connects until the
+// sink is closed. Rows are buffered up to `batchSize` per module before being
+// sent, tune it higher for backfills and lower for live consumption.
+//
+// Clients are identified, and authenticated when the config has an `Auth`,
+// for their rows to be limited per client, see `Limits`.
+type Sink struct {
+	server    flight.Server
+	batchSize int
+	mem       memory.Allocator
+	auth      *Auth
+	limiter   *limiter
+
+	lock        sync.RWMutex
+	modules     map[string]*module
+	subscribers map[string][]*subscriber
+}
+
+type subscriber struct {
+	records chan arrow.Record
+	done    chan struct{}
+}
+
+type module struct {
+	schema  *arrow.Schema
+	builder *array.RecordBuilder
+	rows    int
+}
+
+func New(config *Config) (*Sink, error) {
+	s := &Sink{
+		batchSize:   config.BatchSize,
+		mem:         memory.DefaultAllocator,
+		auth:        &config.Auth,
+		limiter:     newLimiter(config.Limits, config.BatchSize),
+		modules:     map[string]*module{},
+		subscribers: map[string][]*subscriber{},
+	}
+	var opts []grpc.ServerOption
+	if config.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config.TLS)))
+	}
+	s.server = flight.NewServerWithMiddleware(nil, []flight.ServerMiddleware{{Unary: s.unaryInterceptor, Stream: s.streamInterceptor}}, opts...)
+
+	if err := s.server.Init(config.Addr); err != nil {
+		return nil, fmt.Errorf("listening on %q: %w", config.Addr, err)
+	}
+
+	s.server.RegisterFlightService(&flight.FlightServiceService{
+		ListFlights:   s.listFlights,
+		GetFlightInfo: s.getFlightInfo,
+		GetSchema:     s.getSchema,
+		DoGet:         s.doGet,
+	})
+
+	go func() {
+		if err := s.server.Serve(); err != nil {
+			zlog.Error("arrow flight server stopped", zap.Error(err))
+		}
+	}()
+
+	zlog.Info("serving arrow flight", zap.Stringer("addr", s.server.Addr()), zap.Bool("auth", s.auth.enabled()), zap.Bool("tls", config.TLS != nil))
+	return s, nil
+}
+
+func (s *Sink) Addr() string {
+	return s.server.Addr().String()
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	for _, record := range records {
+		mod := s.module(record)
+		if err := appendRecord(mod.builder, record); err != nil {
+			return fmt.Errorf("module %q: %w", record.Module, err)
+		}
+
+		mod.rows++
+		if mod.rows >= s.batchSize {
+			s.flush(record.Module, mod)
+		}
+	}
+
+	return nil
+}
+
+func (s *Sink) module(record *sink.Record) *module {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	mod, found := s.modules[record.Module]
+	if !found {
+		schema := schemaFor(record.Entity.ProtoReflect().Descriptor())
+		mod = &module{schema: schema, builder: array.NewRecordBuilder(s.mem, schema)}
+		s.modules[record.Module] = mod
+	}
+	return mod
+}
+
+// flush sends the buffered rows of a module to its current subscribers, it
+// blocks on slow subscribers so the stream is throttled by its consumers.
+func (s *Sink) flush(name string, mod *module) {
+	rec := mod.builder.NewRecord()
+	defer rec.Release()
+	mod.rows = 0
+
+	s.lock.RLock()
+	subscribers := s.subscribers[name]
+	s.lock.RUnlock()
+
+	for _, sub := range subscribers {
+		rec.Retain()
+		select {
+		case sub.records <- rec:
+		case <-sub.done:
+			rec.Release()
+		}
+	}
+}
+
+func (s *Sink) Close() error {
+	for name, mod := range s.modules {
+		if mod.rows > 0 {
+			s.flush(name, mod)
+		}
+	}
+
+	s.lock.Lock()
+	for _, subscribers := range s.subscribers {
+		for _, sub := range subscribers {
+			close(sub.records)
+		}
+	}
+	s.subscribers = map[string][]*subscriber{}
+	s.lock.Unlock()
+
+	s.server.Shutdown()
+	return nil
+}
+
+func (s *Sink) subscribe(name string) *subscriber {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sub := &subscriber{records: make(chan arrow.Record, 16), done: make(chan struct{})}
+	s.subscribers[name] = append(s.subscribers[name], sub)
+	return sub
+}
+
+func (s *Sink) unsubscribe(name string, sub *subscriber) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	subscribers := s.subscribers[name]
+	for i, candidate := range subscribers {
+		if candidate == sub {
+			s.subscribers[name] = append(subscribers[:i], subscribers[i+1:]...)
+			close(sub.done)
+			return
+		}
+	}
+}
+
+func (s *Sink) subscriberCount(name string) int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.subscribers[name])
+}
+
+func (s *Sink) schema(name string) (*arrow.Schema, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	mod, found := s.modules[name]
+	if !found {
+		return nil, false
+	}
+	return mod.schema, true
+}
+
+func (s *Sink) flightInfo(name string, schema *arrow.Schema) *flight.FlightInfo {
+	return &flight.FlightInfo{
+		Schema:           flight.SerializeSchema(schema, s.mem),
+		FlightDescriptor: &flight.FlightDescriptor{Type: flight.FlightDescriptor_PATH, Path: []string{name}},
+		Endpoint:         []*flight.FlightEndpoint{{Ticket: &flight.Ticket{Ticket: []byte(name)}}},
+		TotalRecords:     -1,
+		TotalBytes:       -1,
+	}
+}
+
+func (s *Sink) listFlights(_ *flight.Criteria, stream flight.FlightService_ListFlightsServer) error {
+	s.lock.RLock()
+	var infos []*flight.FlightInfo
+	for name, mod := range s.modules {
+		infos = append(infos, s.flightInfo(name, mod.schema))
+	}
+	s.lock.RUnlock()
+
+	for _, info := range infos {
+		if err := stream.Send(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func descriptorModule(desc *flight.FlightDescriptor) (string, error) {
+	if desc.GetType() != flight.FlightDescriptor_PATH || len(desc.GetPath()) != 1 {
+		return "", status.Error(codes.InvalidArgument, "descriptor must be a path made of the module name")
+	}
+	return desc.Path[0], nil
+}
+
+func (s *Sink) getFlightInfo(_ context.Context, desc *flight.FlightDescriptor) (*flight.FlightInfo, error) {
+	name, err := descriptorModule(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, found := s.schema(name)
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "module %q has not produced any output yet", name)
+	}
+	return s.flightInfo(name, schema), nil
+}
+
+func (s *Sink) getSchema(_ context.Context, desc *flight.FlightDescriptor) (*flight.SchemaResult, error) {
+	info, err := s.getFlightInfo(nil, desc)
+	if err != nil {
+		return nil, err
+	}
+	return &flight.SchemaResult{Schema: info.Schema}, nil
+}
+
+func (s *Sink) doGet(ticket *flight.Ticket, stream flight.FlightService_DoGetServer) error {
+	name := string(ticket.GetTicket())
+	client := clientFrom(stream.Context())
+	sub := s.subscribe(name)
+	zlog.Debug("flight subscribed", zap.String("module", name), zap.String("client", client))
+
+	var writer *flight.Writer
+	defer func() {
+		if writer != nil {
+			writer.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			s.unsubscribe(name, sub)
+			return stream.Context().Err()
+		case rec, ok := <-sub.records:
+			if !ok {
+				return nil
+			}
+
+			if err := s.limiter.take(stream.Context(), client, int(rec.NumRows())); err != nil {
+				rec.Release()
+				s.unsubscribe(name, sub)
+				return err
+			}
+
+			if writer == nil {
+				writer = flight.NewRecordWriter(stream, ipc.WithSchema(rec.Schema()))
+			}
+
+			err := writer.Write(rec)
+			rec.Release()
+			if err != nil {
+				s.unsubscribe(name, sub)
+				return fmt.Errorf("writing record batch: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package arrowflight
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/flight"
+	pcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSink_DoGet(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := New(&Config{Addr: "127.0.0.1:0", BatchSize: 1})
+	require.NoError(t, err)
+
+	client, err := flight.NewClientWithMiddleware(s.Addr(), nil, nil, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer client.Close()
+
+	stream, err := client.DoGet(ctx, &flight.Ticket{Ticket: []byte("map_pairs")})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return s.subscriberCount("map_pairs") == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	pairs, err := anypb.New(&pcs.Pairs{Pairs: []*pcs.Pair{
+		{Address: "0xaa", Token0Address: "0x01", Token1Address: "0x02", BlockNum: 10, LogOrdinal: 1},
+		{Address: "0xbb", Token0Address: "0x03", Token1Address: "0x04", BlockNum: 10, LogOrdinal: 2},
+	}})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Write(ctx, &pbsubstreams.BlockScopedData{
+		Clock:   &pbsubstreams.Clock{Id: "abc", Number: 10, Timestamp: &timestamppb.Timestamp{Seconds: 1600000000}},
+		Step:    pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Outputs: []*pbsubstreams.ModuleOutput{{Name: "map_pairs", Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: pairs}}},
+	}))
+	require.NoError(t, s.Close())
+
+	reader, err := flight.NewRecordReader(stream)
+	require.NoError(t, err)
+	defer reader.Release()
+
+	var addresses []string
+	var ordinals []uint64
+	for reader.Next() {
+		rec := reader.Record()
+		assert.Equal(t, "block_num", rec.ColumnName(0))
+		assert.Equal(t, uint64(10), rec.Column(0).(*array.Uint64).Value(0))
+
+		for i, field := range rec.Schema().Fields() {
+			switch field.Name {
+			case "address":
+				addresses = append(addresses, rec.Column(i).(*array.String).Value(0))
+			case "log_ordinal":
+				ordinals = append(ordinals, rec.Column(i).(*array.Uint64).Value(0))
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"0xaa", "0xbb"}, addresses)
+	assert.Equal(t, []uint64{1, 2}, ordinals)
+}
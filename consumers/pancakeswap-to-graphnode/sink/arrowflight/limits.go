@@ -0,0 +1,87 @@
+package arrowflight
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits bound the rows streamed to each client, across its streams.
+type Limits struct {
+	// Rate is the number of rows per second streamed to a client, streams
+	// going faster are paced, unlimited when 0. Like any slow consumer, a
+	// paced stream throttles the sink once its buffer is full.
+	Rate float64
+	// Quota is the number of rows a client receives per `QuotaWindow`, its
+	// streams end with RESOURCE_EXHAUSTED past it, unlimited when 0.
+	Quota       int64
+	QuotaWindow time.Duration
+}
+
+type limiter struct {
+	limits    Limits
+	batchSize int
+
+	lock    sync.Mutex
+	clients map[string]*clientUsage
+}
+
+type clientUsage struct {
+	rate        *rate.Limiter
+	windowStart time.Time
+	rows        int64
+}
+
+func newLimiter(limits Limits, batchSize int) *limiter {
+	return &limiter{limits: limits, batchSize: batchSize, clients: map[string]*clientUsage{}}
+}
+
+// take accounts `rows` streamed to `client`, waiting for its rate to allow
+// them, it fails once the quota of the client is exhausted.
+func (l *limiter) take(ctx context.Context, client string, rows int) error {
+	usage := l.usage(client, rows)
+	if usage == nil {
+		return status.Errorf(codes.ResourceExhausted, "client %q exhausted its quota of %d rows per %s", client, l.limits.Quota, l.limits.QuotaWindow)
+	}
+	if usage.rate != nil {
+		return usage.rate.WaitN(ctx, rows)
+	}
+	return nil
+}
+
+// usage counts `rows` in the quota of `client`, it returns nil when they
+// exceed it.
+func (l *limiter) usage(client string, rows int) *clientUsage {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	usage, found := l.clients[client]
+	if !found {
+		usage = &clientUsage{}
+		if l.limits.Rate > 0 {
+			// a batch is sent at once, the burst must hold one
+			burst := int(l.limits.Rate)
+			if burst < l.batchSize {
+				burst = l.batchSize
+			}
+			usage.rate = rate.NewLimiter(rate.Limit(l.limits.Rate), burst)
+		}
+		l.clients[client] = usage
+	}
+
+	if l.limits.Quota > 0 {
+		now := time.Now()
+		if now.Sub(usage.windowStart) >= l.limits.QuotaWindow {
+			usage.windowStart, usage.rows = now, 0
+		}
+		if usage.rows+int64(rows) > l.limits.Quota {
+			return nil
+		}
+		usage.rows += int64(rows)
+	}
+	return usage
+}
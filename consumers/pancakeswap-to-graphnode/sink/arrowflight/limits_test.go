@@ -0,0 +1,43 @@
+package arrowflight
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLimiter_Quota(t *testing.T) {
+	ctx := context.Background()
+	l := newLimiter(Limits{Quota: 10, QuotaWindow: 50 * time.Millisecond}, 4)
+
+	require.NoError(t, l.take(ctx, "alice", 4))
+	require.NoError(t, l.take(ctx, "alice", 4))
+	err := l.take(ctx, "alice", 4)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.NoError(t, l.take(ctx, "bob", 4), "quotas are per client")
+
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, l.take(ctx, "alice", 4), "the quota is renewed every window")
+}
+
+func TestLimiter_Rate(t *testing.T) {
+	ctx := context.Background()
+	l := newLimiter(Limits{Rate: 1000}, 10)
+
+	start := time.Now()
+	require.NoError(t, l.take(ctx, "alice", 1000))
+	require.NoError(t, l.take(ctx, "bob", 1000), "rates are per client")
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "within the burst")
+
+	require.NoError(t, l.take(ctx, "alice", 100))
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond, "the burst is spent, then 1000 rows per second")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, l.take(canceled, "alice", 100))
+}
@@ -0,0 +1,7 @@
+package arrowflight
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.arrowflight", "github.com/streamingfast/substream-pancakeswap/sink/arrowflight")
@@ -0,0 +1,143 @@
+package arrowflight
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// metadataFields are the block columns prepended to every module's schema.
+var metadataFields = []arrow.Field{
+	{Name: "block_num", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "block_id", Type: arrow.BinaryTypes.String},
+	{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ms},
+	{Name: "step", Type: arrow.BinaryTypes.String},
+}
+
+// schemaFor maps the fields of an entity message to Arrow columns. Scalar
+// fields keep their type, repeated and message fields (like the swap/burn/mint
+// oneof of `pcs.types.v1.Event`) are rendered as JSON strings.
+func schemaFor(desc protoreflect.MessageDescriptor) *arrow.Schema {
+	fields := append([]arrow.Field{}, metadataFields...)
+
+	protoFields := desc.Fields()
+	for i := 0; i < protoFields.Len(); i++ {
+		field := protoFields.Get(i)
+		fields = append(fields, arrow.Field{Name: string(field.Name()), Type: arrowType(field), Nullable: true})
+	}
+
+	return arrow.NewSchema(fields, nil)
+}
+
+func arrowType(field protoreflect.FieldDescriptor) arrow.DataType {
+	if field.IsList() || field.IsMap() {
+		return arrow.BinaryTypes.String
+	}
+
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return arrow.FixedWidthTypes.Boolean
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return arrow.PrimitiveTypes.Int32
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return arrow.PrimitiveTypes.Uint32
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return arrow.PrimitiveTypes.Int64
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return arrow.PrimitiveTypes.Uint64
+	case protoreflect.FloatKind:
+		return arrow.PrimitiveTypes.Float32
+	case protoreflect.DoubleKind:
+		return arrow.PrimitiveTypes.Float64
+	case protoreflect.BytesKind:
+		return arrow.BinaryTypes.Binary
+	}
+
+	return arrow.BinaryTypes.String
+}
+
+func appendRecord(builder *array.RecordBuilder, record *sink.Record) error {
+	builder.Field(0).(*array.Uint64Builder).Append(record.BlockNum)
+	builder.Field(1).(*array.StringBuilder).Append(record.BlockID)
+	builder.Field(2).(*array.TimestampBuilder).Append(arrow.Timestamp(record.Timestamp.UnixMilli()))
+	builder.Field(3).(*array.StringBuilder).Append(record.Step.String())
+
+	msg := record.Entity.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		fb := builder.Field(len(metadataFields) + i)
+
+		if field.ContainingOneof() != nil && !msg.Has(field) {
+			fb.AppendNull()
+			continue
+		}
+
+		value := msg.Get(field)
+		switch b := fb.(type) {
+		case *array.BooleanBuilder:
+			b.Append(value.Bool())
+		case *array.Int32Builder:
+			b.Append(int32(value.Int()))
+		case *array.Uint32Builder:
+			b.Append(uint32(value.Uint()))
+		case *array.Int64Builder:
+			b.Append(value.Int())
+		case *array.Uint64Builder:
+			b.Append(value.Uint())
+		case *array.Float32Builder:
+			b.Append(float32(value.Float()))
+		case *array.Float64Builder:
+			b.Append(value.Float())
+		case *array.BinaryBuilder:
+			b.Append(value.Bytes())
+		case *array.StringBuilder:
+			str, err := stringValue(msg, field)
+			if err != nil {
+				return fmt.Errorf("field %q: %w", field.Name(), err)
+			}
+			b.Append(str)
+		default:
+			return fmt.Errorf("field %q: unsupported arrow builder %T", field.Name(), fb)
+		}
+	}
+
+	return nil
+}
+
+func stringValue(msg protoreflect.Message, field protoreflect.FieldDescriptor) (string, error) {
+	value := msg.Get(field)
+	switch {
+	case field.IsList() || field.IsMap() || field.Kind() == protoreflect.MessageKind:
+		return fieldJSON(msg, field)
+	case field.Kind() == protoreflect.EnumKind:
+		if enum := field.Enum().Values().ByNumber(value.Enum()); enum != nil {
+			return string(enum.Name()), nil
+		}
+		return fmt.Sprintf("%d", value.Enum()), nil
+	}
+
+	return value.String(), nil
+}
+
+// fieldJSON renders a single field of `msg` with the protobuf JSON mapping.
+func fieldJSON(msg protoreflect.Message, field protoreflect.FieldDescriptor) (string, error) {
+	single := msg.New()
+	single.Set(field, msg.Get(field))
+
+	out, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(single.Interface())
+	if err != nil {
+		return "", err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(out, &fields); err != nil {
+		return "", err
+	}
+	return string(fields[string(field.Name())]), nil
+}
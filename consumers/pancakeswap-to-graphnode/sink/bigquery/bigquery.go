@@ -0,0 +1,312 @@
+// Package bigquery writes the stores deltas and the swaps to Google BigQuery
+// tables, partitioned by block date and clustered by pair address, for
+// warehouse analytics.
+package bigquery
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	bigquery "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// maxBatchSize is the maximum number of rows sent in a single insert call, the
+// one recommended by BigQuery.
+const maxBatchSize = 500
+
+func init() {
+	sink.Register("bigquery", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(ctx, config)
+	})
+}
+
+type Config struct {
+	Project string
+	Dataset string
+	// DeltasTable receives the stores deltas, the state of a store at a
+	// block being the last row of each key up to it.
+	DeltasTable string
+	// SwapsTable receives the swaps of the `pcs.types.v1.Event` outputs.
+	SwapsTable string
+	// Endpoint overrides the API endpoint, like the one of an emulator.
+	Endpoint string
+}
+
+// parseParams reads `<project>/<dataset>[?deltas-table=<table>&swaps-table=<table>&endpoint=<url>]`.
+func parseParams(params string) (*Config, error) {
+	path, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		path, query = params[:i], params[i+1:]
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid dataset %q, expected <project>/<dataset>", path)
+	}
+
+	config := &Config{
+		Project:     parts[0],
+		Dataset:     parts[1],
+		DeltasTable: "store_deltas",
+		SwapsTable:  "swaps",
+		Endpoint:    values.Get("endpoint"),
+	}
+	if v := values.Get("deltas-table"); v != "" {
+		config.DeltasTable = v
+	}
+	if v := values.Get("swaps-table"); v != "" {
+		config.SwapsTable = v
+	}
+	return config, nil
+}
+
+var deltasSchema = []*bigquery.TableFieldSchema{
+	{Name: "id", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "block_num", Type: "INTEGER", Mode: "REQUIRED"},
+	{Name: "block_id", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "block_date", Type: "DATE", Mode: "REQUIRED"},
+	{Name: "timestamp", Type: "TIMESTAMP", Mode: "REQUIRED"},
+	{Name: "step", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "store", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "ordinal", Type: "INTEGER", Mode: "REQUIRED"},
+	{Name: "operation", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "key", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "pair_address", Type: "STRING"},
+	{Name: "old_value", Type: "STRING"},
+	{Name: "new_value", Type: "STRING"},
+}
+
+var swapsSchema = []*bigquery.TableFieldSchema{
+	{Name: "id", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "block_num", Type: "INTEGER", Mode: "REQUIRED"},
+	{Name: "block_id", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "block_date", Type: "DATE", Mode: "REQUIRED"},
+	{Name: "timestamp", Type: "TIMESTAMP", Mode: "REQUIRED"},
+	{Name: "step", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "module", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "pair_address", Type: "STRING", Mode: "REQUIRED"},
+	{Name: "transaction_id", Type: "STRING"},
+	{Name: "log_ordinal", Type: "INTEGER"},
+	{Name: "token0", Type: "STRING"},
+	{Name: "token1", Type: "STRING"},
+	{Name: "sender", Type: "STRING"},
+	{Name: "from", Type: "STRING"},
+	{Name: "to", Type: "STRING"},
+	{Name: "amount0_in", Type: "BIGNUMERIC"},
+	{Name: "amount1_in", Type: "BIGNUMERIC"},
+	{Name: "amount0_out", Type: "BIGNUMERIC"},
+	{Name: "amount1_out", Type: "BIGNUMERIC"},
+	{Name: "amount_bnb", Type: "BIGNUMERIC"},
+	{Name: "amount_usd", Type: "BIGNUMERIC"},
+}
+
+// Sink streams the stores deltas and the swaps to two BigQuery tables, created
+// when missing, partitioned by day on `block_date` and clustered by
+// `pair_address`. The pair address of a delta is the first address segment of
+// its key, like `0x0ed7...` in `price:0x0ed7...:0x0e09...:token0`.
+//
+// The tables are append-only: undo steps are rows of their own, carrying the
+// `id` of the row they revert, see `sink.EntityID`, with `step` set to
+// `STEP_UNDO`. The insert ID of a row is its `id` and step, so BigQuery drops
+// the rows of a block written again shortly after, on a best-effort basis.
+type Sink struct {
+	config *Config
+	svc    *bigquery.Service
+}
+
+func New(ctx context.Context, config *Config) (*Sink, error) {
+	var opts []option.ClientOption
+	if config.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(config.Endpoint))
+	}
+
+	svc, err := bigquery.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("bigquery client: %w", err)
+	}
+
+	s := &Sink{config: config, svc: svc}
+	if err := s.ensureTable(ctx, config.DeltasTable, deltasSchema); err != nil {
+		return nil, err
+	}
+	if err := s.ensureTable(ctx, config.SwapsTable, swapsSchema); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Sink) ensureTable(ctx context.Context, table string, fields []*bigquery.TableFieldSchema) error {
+	_, err := s.svc.Tables.Get(s.config.Project, s.config.Dataset, table).Context(ctx).Do()
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		return fmt.Errorf("getting table %q: %w", table, err)
+	}
+
+	_, err = s.svc.Tables.Insert(s.config.Project, s.config.Dataset, &bigquery.Table{
+		TableReference:   &bigquery.TableReference{ProjectId: s.config.Project, DatasetId: s.config.Dataset, TableId: table},
+		Schema:           &bigquery.TableSchema{Fields: fields},
+		TimePartitioning: &bigquery.TimePartitioning{Type: "DAY", Field: "block_date"},
+		Clustering:       &bigquery.Clustering{Fields: []string{"pair_address"}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("creating table %q: %w", table, err)
+	}
+
+	zlog.Info("created bigquery table", zap.String("project", s.config.Project), zap.String("dataset", s.config.Dataset), zap.String("table", table))
+	return nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	deltas, swaps := rows(records)
+	if err := s.insert(ctx, s.config.DeltasTable, deltas); err != nil {
+		return err
+	}
+	return s.insert(ctx, s.config.SwapsTable, swaps)
+}
+
+func (s *Sink) insert(ctx context.Context, table string, rows []*bigquery.TableDataInsertAllRequestRows) error {
+	for start := 0; start < len(rows); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		resp, err := s.svc.Tabledata.InsertAll(s.config.Project, s.config.Dataset, table, &bigquery.TableDataInsertAllRequest{Rows: rows[start:end]}).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("inserting %d rows in %q: %w", end-start, table, err)
+		}
+		if len(resp.InsertErrors) > 0 {
+			// the rows rejected are rejected for good, like values not
+			// matching the schema
+			first := resp.InsertErrors[0]
+			return sink.Permanent(fmt.Errorf("%d rows rejected by %q, row %q: %s", len(resp.InsertErrors), table, rows[start+int(first.Index)].InsertId, insertErrors(first.Errors)))
+		}
+	}
+	return nil
+}
+
+func insertErrors(errs []*bigquery.ErrorProto) string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = fmt.Sprintf("%s: %s", err.Reason, err.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+func rows(records []*sink.Record) (deltas, swaps []*bigquery.TableDataInsertAllRequestRows) {
+	for _, record := range records {
+		switch entity := record.Entity.(type) {
+		case *pbsubstreams.StoreDelta:
+			deltas = append(deltas, row(record, map[string]bigquery.JsonValue{
+				"store":        record.Module,
+				"ordinal":      entity.Ordinal,
+				"operation":    entity.Operation.String(),
+				"key":          entity.Key,
+				"pair_address": nullable(pairAddress(entity.Key)),
+				"old_value":    value(entity.OldValue),
+				"new_value":    value(entity.NewValue),
+			}))
+		case *pbpcs.Event:
+			swap := entity.GetSwap()
+			if swap == nil {
+				continue
+			}
+			swaps = append(swaps, row(record, map[string]bigquery.JsonValue{
+				"module":         record.Module,
+				"pair_address":   entity.PairAddress,
+				"transaction_id": entity.TransactionId,
+				"log_ordinal":    entity.LogOrdinal,
+				"token0":         entity.Token0,
+				"token1":         entity.Token1,
+				"sender":         swap.Sender,
+				"from":           swap.From,
+				"to":             swap.To,
+				"amount0_in":     nullable(swap.Amount0In),
+				"amount1_in":     nullable(swap.Amount1In),
+				"amount0_out":    nullable(swap.Amount0Out),
+				"amount1_out":    nullable(swap.Amount1Out),
+				"amount_bnb":     nullable(swap.AmountBnb),
+				"amount_usd":     nullable(swap.AmountUsd),
+			}))
+		}
+	}
+	return
+}
+
+func row(record *sink.Record, fields map[string]bigquery.JsonValue) *bigquery.TableDataInsertAllRequestRows {
+	fields["id"] = record.ID
+	fields["block_num"] = record.BlockNum
+	fields["block_id"] = record.BlockID
+	fields["block_date"] = record.Timestamp.UTC().Format("2006-01-02")
+	fields["timestamp"] = record.Timestamp.UTC().Format(time.RFC3339Nano)
+	fields["step"] = record.Step.String()
+
+	return &bigquery.TableDataInsertAllRequestRows{
+		InsertId: record.ID + ":" + record.Step.String(),
+		Json:     fields,
+	}
+}
+
+var addressSegment = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+func pairAddress(key string) string {
+	for _, segment := range strings.Split(key, ":") {
+		if addressSegment.MatchString(segment) {
+			return strings.ToLower(segment)
+		}
+	}
+	return ""
+}
+
+func value(in []byte) bigquery.JsonValue {
+	if in == nil {
+		return nil
+	}
+	if utf8.Valid(in) {
+		return string(in)
+	}
+	return "0x" + hex.EncodeToString(in)
+}
+
+func nullable(in string) bigquery.JsonValue {
+	if in == "" {
+		return nil
+	}
+	return in
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,82 @@
+package bigquery
+
+import (
+	"testing"
+	"time"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+func Test_parseParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    *Config
+		expectedErr bool
+	}{
+		{"defaults", "my-project/pancake", &Config{Project: "my-project", Dataset: "pancake", DeltasTable: "store_deltas", SwapsTable: "swaps"}, false},
+		{"tables", "my-project/pancake?deltas-table=deltas&swaps-table=trades", &Config{Project: "my-project", Dataset: "pancake", DeltasTable: "deltas", SwapsTable: "trades"}, false},
+		{"missing dataset", "my-project", nil, true},
+		{"empty", "", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseParams(test.in)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_rows(t *testing.T) {
+	at := time.Date(2022, 6, 14, 23, 59, 58, 0, time.UTC)
+	deltas, swaps := rows([]*sink.Record{
+		{ID: "abc:3:price:0x0ed7e52944161450477ee417de9cd3a859b14fd0:token0", Module: "store_prices", BlockNum: 10, BlockID: "abc", Timestamp: at, Step: pbsubstreams.ForkStep_STEP_NEW, Entity: &pbsubstreams.StoreDelta{
+			Operation: pbsubstreams.StoreDelta_CREATE, Ordinal: 3, Key: "price:0x0ED7e52944161450477ee417de9cd3a859b14fd0:token0", NewValue: []byte("1.5"),
+		}},
+		{ID: "bsc:abc:0x01:4", Module: "map_events", BlockNum: 10, BlockID: "abc", Timestamp: at, Step: pbsubstreams.ForkStep_STEP_UNDO, Entity: &pbpcs.Event{
+			PairAddress: "0xaa", TransactionId: "0x01", LogOrdinal: 4, Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{Sender: "0xbb", Amount0In: "12.5"}},
+		}},
+		{ID: "bsc:abc:0x01:5", Module: "map_events", BlockNum: 10, Entity: &pbpcs.Event{Type: &pbpcs.Event_Mint{Mint: &pbpcs.Mint{}}}},
+	})
+
+	require.Len(t, deltas, 1)
+	assert.Equal(t, "abc:3:price:0x0ed7e52944161450477ee417de9cd3a859b14fd0:token0:STEP_NEW", deltas[0].InsertId)
+	assert.Equal(t, map[string]bigquery.JsonValue{
+		"id":           "abc:3:price:0x0ed7e52944161450477ee417de9cd3a859b14fd0:token0",
+		"block_num":    uint64(10),
+		"block_id":     "abc",
+		"block_date":   "2022-06-14",
+		"timestamp":    "2022-06-14T23:59:58Z",
+		"step":         "STEP_NEW",
+		"store":        "store_prices",
+		"ordinal":      uint64(3),
+		"operation":    "CREATE",
+		"key":          "price:0x0ED7e52944161450477ee417de9cd3a859b14fd0:token0",
+		"pair_address": "0x0ed7e52944161450477ee417de9cd3a859b14fd0",
+		"old_value":    nil,
+		"new_value":    "1.5",
+	}, deltas[0].Json)
+
+	require.Len(t, swaps, 1, "mints aren't swaps")
+	assert.Equal(t, "bsc:abc:0x01:4:STEP_UNDO", swaps[0].InsertId)
+	assert.Equal(t, "0xaa", swaps[0].Json["pair_address"])
+	assert.Equal(t, "12.5", swaps[0].Json["amount0_in"])
+	assert.Nil(t, swaps[0].Json["amount_usd"])
+}
+
+func Test_pairAddress(t *testing.T) {
+	assert.Equal(t, "0x0ed7e52944161450477ee417de9cd3a859b14fd0", pairAddress("reserve:0x0ed7e52944161450477ee417de9cd3a859b14fd0:token0"))
+	assert.Equal(t, "", pairAddress("pairs"))
+	assert.Equal(t, "", pairAddress("pair:0x0ed7"))
+}
@@ -0,0 +1,7 @@
+package bigquery
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.bigquery", "github.com/streamingfast/substream-pancakeswap/sink/bigquery")
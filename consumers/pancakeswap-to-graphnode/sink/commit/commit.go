@@ -0,0 +1,74 @@
+package commit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// Sink writes every block to `outputs` then to `store`, following the
+// protocol of the journal. The store is expected to persist each block
+// atomically, like the `sqlsink` does with its cursor.
+type Sink struct {
+	journal *Journal
+	store   sink.Sink
+	outputs sink.Sink
+}
+
+func NewSink(journal *Journal, store, outputs sink.Sink) *Sink {
+	if intent := journal.State().Intent; intent != nil {
+		zlog.Warn("previous run stopped while committing a block, outputs may receive it twice",
+			zap.Uint64("block_num", intent.Num),
+			zap.String("block_id", intent.ID),
+		)
+	}
+
+	return &Sink{journal: journal, store: store, outputs: outputs}
+}
+
+// ResumeCursor returns the cursor of the last block committed, empty when
+// none was.
+func (s *Sink) ResumeCursor() string {
+	if committed := s.journal.State().Committed; committed != nil {
+		return committed.Cursor
+	}
+	return ""
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	blockNum := data.Clock.GetNumber()
+	if err := s.journal.Intent(&Block{Num: blockNum, ID: data.Clock.GetId(), Cursor: data.Cursor}); err != nil {
+		return err
+	}
+
+	if err := s.outputs.Write(ctx, data); err != nil {
+		return err
+	}
+	if err := sink.Flush(ctx, s.outputs); err != nil {
+		return fmt.Errorf("flushing outputs: %w", err)
+	}
+
+	if err := s.store.Write(ctx, data); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	return s.journal.Commit()
+}
+
+func (s *Sink) Flush(ctx context.Context) error {
+	if err := sink.Flush(ctx, s.outputs); err != nil {
+		return err
+	}
+	return sink.Flush(ctx, s.store)
+}
+
+func (s *Sink) Close() error {
+	outputsErr := s.outputs.Close()
+	if err := s.store.Close(); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	return outputsErr
+}
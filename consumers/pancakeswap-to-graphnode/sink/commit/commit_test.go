@@ -0,0 +1,102 @@
+package commit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	written []uint64
+	flushes int
+	failAt  uint64
+}
+
+func (s *recordingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	if data.Clock.Number == s.failAt {
+		return errors.New("crash")
+	}
+	s.written = append(s.written, data.Clock.Number)
+	return nil
+}
+
+func (s *recordingSink) Flush(ctx context.Context) error {
+	s.flushes++
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func block(num uint64) *pbsubstreams.BlockScopedData {
+	return &pbsubstreams.BlockScopedData{
+		Step:   pbsubstreams.ForkStep_STEP_NEW,
+		Cursor: fmt.Sprintf("cursor-%d", num),
+		Clock:  &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%08x", num)},
+	}
+}
+
+func TestSink(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "commit.json")
+
+	tests := []struct {
+		name              string
+		storeFailAt       uint64
+		outputsFailAt     uint64
+		expectedStore     []uint64
+		expectedOutputs   []uint64
+		expectedFlushes   int
+		expectedCommitted uint64
+		expectedIntent    uint64
+	}{
+		{"all committed", 0, 0, []uint64{1, 2, 3}, []uint64{1, 2, 3}, 3, 3, 0},
+		{"outputs fail", 0, 2, []uint64{1}, []uint64{1}, 1, 1, 2},
+		{"store fails", 2, 0, []uint64{1}, []uint64{1, 2}, 2, 1, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			journal, err := OpenJournal(path + test.name)
+			require.NoError(t, err)
+
+			store := &recordingSink{failAt: test.storeFailAt}
+			outputs := &recordingSink{failAt: test.outputsFailAt}
+			s := NewSink(journal, store, outputs)
+			for num := uint64(1); num <= 3; num++ {
+				if err := s.Write(ctx, block(num)); err != nil {
+					break
+				}
+			}
+
+			assert.Equal(t, test.expectedStore, store.written)
+			assert.Equal(t, test.expectedOutputs, outputs.written)
+			assert.Equal(t, test.expectedFlushes, outputs.flushes, "outputs flushed before each store write")
+
+			reopened, err := OpenJournal(path + test.name)
+			require.NoError(t, err)
+			state := reopened.State()
+			assert.Equal(t, test.expectedCommitted, state.Committed.Num)
+			assert.Equal(t, fmt.Sprintf("cursor-%d", test.expectedCommitted), NewSink(reopened, store, outputs).ResumeCursor())
+			if test.expectedIntent == 0 {
+				assert.Nil(t, state.Intent)
+			} else {
+				require.NotNil(t, state.Intent)
+				assert.Equal(t, test.expectedIntent, state.Intent.Num)
+			}
+		})
+	}
+}
+
+func TestJournal_Empty(t *testing.T) {
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "commit.json"))
+	require.NoError(t, err)
+	assert.Equal(t, State{}, journal.State())
+	assert.Equal(t, "", NewSink(journal, &recordingSink{}, &recordingSink{}).ResumeCursor())
+	assert.Error(t, journal.Commit())
+}
@@ -0,0 +1,110 @@
+// Package commit keeps the stores mirror and the outputs of a run in step.
+// Each block goes through a two-phase commit: an intent record is written
+// ahead to the journal, the outputs are written and flushed, then the block is
+// committed to the store and the journal. After a crash, the outputs are at
+// most one block ahead of the store, the block of the pending intent, and the
+// run resumes from the last committed cursor.
+package commit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Block identifies a block in the journal.
+type Block struct {
+	Num    uint64 `json:"num"`
+	ID     string `json:"id"`
+	Cursor string `json:"cursor"`
+}
+
+// State is the content of the journal: the last block committed to the store
+// and outputs, and the block being committed when `Intent` is set.
+type State struct {
+	Committed *Block `json:"committed,omitempty"`
+	Intent    *Block `json:"intent,omitempty"`
+}
+
+// Journal persists the commit state in a single file, replaced atomically on
+// every change.
+type Journal struct {
+	path  string
+	state State
+}
+
+// OpenJournal loads the journal at `path`, it's empty when the file doesn't
+// exist yet.
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{path: path}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read commit journal %q: %w", path, err)
+	}
+
+	if err := json.Unmarshal(content, &j.state); err != nil {
+		return nil, fmt.Errorf("decode commit journal %q: %w", path, err)
+	}
+	return j, nil
+}
+
+func (j *Journal) State() State {
+	return j.state
+}
+
+// Intent records that `block` is about to be written to the outputs.
+func (j *Journal) Intent(block *Block) error {
+	j.state.Intent = block
+	return j.save()
+}
+
+// Commit records that the pending intent is written to the outputs and the
+// store.
+func (j *Journal) Commit() error {
+	if j.state.Intent == nil {
+		return fmt.Errorf("commit without intent")
+	}
+
+	j.state.Committed, j.state.Intent = j.state.Intent, nil
+	return j.save()
+}
+
+// save writes the state to a temporary file synced to disk then renames it,
+// the journal is never found half written.
+func (j *Journal) save() error {
+	content, err := json.Marshal(&j.state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("create commit journal directory: %w", err)
+	}
+
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("open commit journal: %w", err)
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return fmt.Errorf("write commit journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync commit journal: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close commit journal: %w", err)
+	}
+
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("replace commit journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package commit
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.commit", "github.com/streamingfast/substream-pancakeswap/sink/commit")
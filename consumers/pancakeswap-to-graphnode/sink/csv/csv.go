@@ -0,0 +1,232 @@
+package csv
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+var header = []string{"block_num", "block_id", "timestamp", "step", "ordinal", "operation", "key", "old_value", "new_value"}
+
+func init() {
+	sink.Register("csv", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(config)
+	})
+}
+
+type Config struct {
+	Dir string
+	// RotateBlocks starts a new file for every range of that many blocks, 0 disables it.
+	RotateBlocks uint64
+	// RotateSize starts a new file once the current one grows past that many bytes, 0 disables it.
+	RotateSize int64
+}
+
+// parseParams reads `<dir>[?rotate-blocks=<count>&rotate-size=<bytes>]`.
+func parseParams(params string) (*Config, error) {
+	dir, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		dir, query = params[:i], params[i+1:]
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	config := &Config{Dir: dir}
+	if config.Dir == "" {
+		config.Dir = "."
+	}
+
+	if v := values.Get("rotate-blocks"); v != "" {
+		if config.RotateBlocks, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid rotate-blocks %q: %w", v, err)
+		}
+	}
+
+	if v := values.Get("rotate-size"); v != "" {
+		if config.RotateSize, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid rotate-size %q: %w", v, err)
+		}
+	}
+
+	return config, nil
+}
+
+// Sink appends the store deltas to one CSV file per store. Files are named
+// after the store and the first block they may contain, `<store>-<block>.csv`,
+// and are only rotated between blocks so a block is never split across files.
+// When a file grows past its size within its block range, the next ones of the
+// range get a sequence suffix, `<store>-<block>-<n>.csv`.
+type Sink struct {
+	config *Config
+	files  map[string]*file
+}
+
+type file struct {
+	f          *os.File
+	writer     *csv.Writer
+	startBlock uint64
+	seq        int
+}
+
+func New(config *Config) (*Sink, error) {
+	if err := os.MkdirAll(config.Dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("creating %q: %w", config.Dir, err)
+	}
+
+	return &Sink{config: config, files: map[string]*file{}}, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	touched := map[string]*file{}
+	for _, record := range records {
+		delta, ok := record.Entity.(*pbsubstreams.StoreDelta)
+		if !ok {
+			continue
+		}
+
+		f, found := touched[record.Module]
+		if !found {
+			if f, err = s.file(record.Module, record.BlockNum); err != nil {
+				return err
+			}
+			touched[record.Module] = f
+		}
+
+		err := f.writer.Write([]string{
+			strconv.FormatUint(record.BlockNum, 10),
+			record.BlockID,
+			record.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
+			record.Step.String(),
+			strconv.FormatUint(delta.Ordinal, 10),
+			delta.Operation.String(),
+			delta.Key,
+			value(delta.OldValue),
+			value(delta.NewValue),
+		})
+		if err != nil {
+			return fmt.Errorf("writing delta %q of %q: %w", delta.Key, record.Module, err)
+		}
+	}
+
+	for store, f := range touched {
+		f.writer.Flush()
+		if err := f.writer.Error(); err != nil {
+			return fmt.Errorf("flushing %q: %w", store, err)
+		}
+	}
+
+	return nil
+}
+
+// file returns the file to append the deltas of `store` at `blockNum` to,
+// rotating the current one when it's past its block range or size.
+func (s *Sink) file(store string, blockNum uint64) (*file, error) {
+	current := s.files[store]
+	if current != nil && !s.shouldRotate(current, blockNum) {
+		return current, nil
+	}
+
+	if current != nil {
+		if err := current.f.Close(); err != nil {
+			return nil, fmt.Errorf("closing %q: %w", current.f.Name(), err)
+		}
+	}
+
+	startBlock := blockNum
+	if s.config.RotateBlocks > 0 {
+		startBlock = blockNum - blockNum%s.config.RotateBlocks
+	}
+
+	seq := 0
+	if current != nil && current.startBlock == startBlock {
+		seq = current.seq + 1
+	}
+	path := s.path(store, startBlock, seq)
+	// files of the range already full, like after a restart, are skipped
+	for s.config.RotateSize > 0 {
+		stat, err := os.Stat(path)
+		if err != nil || stat.Size() < s.config.RotateSize {
+			break
+		}
+		seq++
+		path = s.path(store, startBlock, seq)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", path, err)
+	}
+
+	next := &file{f: f, writer: csv.NewWriter(f), startBlock: startBlock, seq: seq}
+	if stat, err := f.Stat(); err == nil && stat.Size() == 0 {
+		if err := next.writer.Write(header); err != nil {
+			return nil, fmt.Errorf("writing header of %q: %w", path, err)
+		}
+	}
+
+	zlog.Debug("writing to new csv file", zap.String("path", path))
+	s.files[store] = next
+	return next, nil
+}
+
+func (s *Sink) path(store string, startBlock uint64, seq int) string {
+	if seq == 0 {
+		return filepath.Join(s.config.Dir, fmt.Sprintf("%s-%010d.csv", store, startBlock))
+	}
+	return filepath.Join(s.config.Dir, fmt.Sprintf("%s-%010d-%d.csv", store, startBlock, seq))
+}
+
+func (s *Sink) shouldRotate(f *file, blockNum uint64) bool {
+	if s.config.RotateBlocks > 0 && blockNum >= f.startBlock+s.config.RotateBlocks {
+		return true
+	}
+
+	if s.config.RotateSize > 0 {
+		if stat, err := f.f.Stat(); err == nil && stat.Size() >= s.config.RotateSize {
+			return true
+		}
+	}
+
+	return false
+}
+
+func value(in []byte) string {
+	if utf8.Valid(in) {
+		return string(in)
+	}
+	return "0x" + hex.EncodeToString(in)
+}
+
+func (s *Sink) Close() error {
+	for _, f := range s.files {
+		f.writer.Flush()
+		if err := f.f.Close(); err != nil {
+			return fmt.Errorf("closing %q: %w", f.f.Name(), err)
+		}
+	}
+	s.files = map[string]*file{}
+	return nil
+}
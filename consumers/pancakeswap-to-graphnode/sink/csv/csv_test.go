@@ -0,0 +1,110 @@
+package csv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSink_Rotation(t *testing.T) {
+	block := func(num uint64) *pbsubstreams.BlockScopedData {
+		return &pbsubstreams.BlockScopedData{
+			Clock: &pbsubstreams.Clock{Number: num},
+			Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+					Deltas: []*pbsubstreams.StoreDelta{{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", NewValue: []byte("1")}},
+				}}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		blocks   []uint64
+		expected map[string]int
+	}{
+		{"no rotation", Config{}, []uint64{5, 12, 13}, map[string]int{"store_totals-0000000005.csv": 3}},
+		{"by blocks", Config{RotateBlocks: 10}, []uint64{5, 12, 13, 31}, map[string]int{
+			"store_totals-0000000000.csv": 1,
+			"store_totals-0000000010.csv": 2,
+			"store_totals-0000000030.csv": 1,
+		}},
+		{"by size", Config{RotateSize: 1}, []uint64{5, 12}, map[string]int{
+			"store_totals-0000000005.csv": 1,
+			"store_totals-0000000012.csv": 1,
+		}},
+		{"by blocks and size", Config{RotateBlocks: 10, RotateSize: 1}, []uint64{5, 12, 13, 14, 31}, map[string]int{
+			"store_totals-0000000000.csv":   1,
+			"store_totals-0000000010.csv":   1,
+			"store_totals-0000000010-1.csv": 1,
+			"store_totals-0000000010-2.csv": 1,
+			"store_totals-0000000030.csv":   1,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Dir = t.TempDir()
+			s, err := New(&test.config)
+			require.NoError(t, err)
+
+			for _, num := range test.blocks {
+				require.NoError(t, s.Write(context.Background(), block(num)))
+			}
+			require.NoError(t, s.Close())
+
+			actual := map[string]int{}
+			entries, err := os.ReadDir(test.config.Dir)
+			require.NoError(t, err)
+			for _, entry := range entries {
+				content, err := os.ReadFile(filepath.Join(test.config.Dir, entry.Name()))
+				require.NoError(t, err)
+
+				lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+				assert.Equal(t, strings.Join(header, ","), lines[0])
+				actual[entry.Name()] = len(lines) - 1
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestSink_RotationAfterRestart(t *testing.T) {
+	config := &Config{Dir: t.TempDir(), RotateBlocks: 10, RotateSize: 1}
+	delta := &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Number: 12},
+		Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+				Deltas: []*pbsubstreams.StoreDelta{{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", NewValue: []byte("1")}},
+			}}},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		s, err := New(config)
+		require.NoError(t, err)
+		require.NoError(t, s.Write(context.Background(), delta))
+		require.NoError(t, s.Close())
+	}
+
+	_, err := os.Stat(filepath.Join(config.Dir, "store_totals-0000000010-1.csv"))
+	assert.NoError(t, err, "the full file of the range isn't appended to")
+}
+
+func Test_parseParams(t *testing.T) {
+	config, err := parseParams("./out?rotate-blocks=1000&rotate-size=1048576")
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Dir: "./out", RotateBlocks: 1000, RotateSize: 1048576}, config)
+
+	_, err = parseParams("./out?rotate-blocks=abc")
+	require.Error(t, err)
+}
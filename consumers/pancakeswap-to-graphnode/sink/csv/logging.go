@@ -0,0 +1,7 @@
+package csv
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.csv", "github.com/streamingfast/substream-pancakeswap/sink/csv")
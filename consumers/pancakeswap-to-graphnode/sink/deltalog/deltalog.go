@@ -0,0 +1,232 @@
+// Package deltalog persists the store deltas of a run into an append-only log,
+// one topic per store module, so history can be consumed again without
+// re-running the modules.
+//
+// A topic is a folder of segments named `<first block>-<last block>`, each a
+// dbin file of `sf.substreams.v1.BlockScopedData` holding only the deltas of
+// that store, one message per block with changes. Segments are not appended
+// to: a segment is written when the blocks cross a segment boundary or when the
+// sink is closed, a restarted run adds new segments next to the existing ones
+// and the reader skips the blocks it already delivered.
+//
+// Topics can be namespaced, see `ValidateNamespace`, for several pipelines to
+// share a log.
+package deltalog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/streamingfast/dbin"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentType        = "SDL"
+	contentVersion     = 1
+	defaultSegmentSize = 100
+)
+
+func init() {
+	sink.Register("deltalog", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(config)
+	})
+}
+
+type Config struct {
+	StoreURL string
+
+	// SegmentSize is the number of blocks covered by a full segment.
+	SegmentSize uint64
+
+	// Namespace prefixes the topics, like `bsc/pancake`, none when empty.
+	Namespace string
+}
+
+// parseParams reads `<store url>[?segment-size=<blocks>&namespace=<path>]`,
+// other query parameters are kept on the store URL.
+func parseParams(params string) (*Config, error) {
+	storeURL, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		storeURL, query = params[:i], params[i+1:]
+	}
+	if storeURL == "" {
+		return nil, fmt.Errorf("a store URL is required, like 'deltalog:file:///data/deltas'")
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	config := &Config{StoreURL: storeURL, SegmentSize: defaultSegmentSize}
+	if size := values.Get("segment-size"); size != "" {
+		config.SegmentSize, err = strconv.ParseUint(size, 10, 64)
+		if err != nil || config.SegmentSize == 0 {
+			return nil, fmt.Errorf("invalid segment-size %q, expected a positive number of blocks", size)
+		}
+		values.Del("segment-size")
+	}
+	if values.Has("namespace") {
+		config.Namespace = values.Get("namespace")
+		if err := ValidateNamespace(config.Namespace); err != nil {
+			return nil, err
+		}
+		values.Del("namespace")
+	}
+	if len(values) > 0 {
+		config.StoreURL += "?" + values.Encode()
+	}
+	return config, nil
+}
+
+func newStore(storeURL string) (dstore.Store, error) {
+	store, err := dstore.NewStore(storeURL, "dbin.zst", "zstd", false)
+	if err != nil {
+		return nil, fmt.Errorf("delta log store %q: %w", storeURL, err)
+	}
+	return store, nil
+}
+
+// Sink appends the store deltas of every block to the log of their store.
+type Sink struct {
+	config *Config
+	store  dstore.Store
+
+	// segments are the blocks of the current segment, per topic, not written
+	// yet
+	segments map[string]*segment
+	base     uint64
+	started  bool
+}
+
+type segment struct {
+	first, last uint64
+	buffer      bytes.Buffer
+	writer      *dbin.Writer
+}
+
+func New(config *Config) (*Sink, error) {
+	store, err := newStore(config.StoreURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sink{
+		config:   config,
+		store:    store,
+		segments: map[string]*segment{},
+	}, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	num := data.Clock.GetNumber()
+	if base := num - num%s.config.SegmentSize; !s.started || base != s.base {
+		if err := s.flush(ctx); err != nil {
+			return err
+		}
+		s.base, s.started = base, true
+	}
+
+	for _, output := range data.Outputs {
+		deltas := output.GetStoreDeltas()
+		if deltas == nil || len(deltas.Deltas) == 0 {
+			continue
+		}
+
+		content, err := proto.Marshal(&pbsubstreams.BlockScopedData{
+			Outputs: []*pbsubstreams.ModuleOutput{output},
+			Clock:   data.Clock,
+			Step:    data.Step,
+			Cursor:  data.Cursor,
+		})
+		if err != nil {
+			return fmt.Errorf("marshal deltas of %q at block %d: %w", output.Name, num, err)
+		}
+
+		seg, err := s.segment(JoinTopic(s.config.Namespace, output.Name), num)
+		if err != nil {
+			return err
+		}
+		if err := seg.writer.WriteMessage(content); err != nil {
+			return fmt.Errorf("append deltas of %q at block %d: %w", output.Name, num, err)
+		}
+		if num > seg.last {
+			seg.last = num
+		}
+	}
+
+	return nil
+}
+
+func (s *Sink) segment(topic string, num uint64) (*segment, error) {
+	if seg, found := s.segments[topic]; found {
+		return seg, nil
+	}
+
+	seg := &segment{first: num}
+	seg.writer = dbin.NewWriter(&seg.buffer)
+	if err := seg.writer.WriteHeader(contentType, contentVersion); err != nil {
+		return nil, fmt.Errorf("write segment header: %w", err)
+	}
+	s.segments[topic] = seg
+	return seg, nil
+}
+
+// flush writes the pending segment of every topic.
+func (s *Sink) flush(ctx context.Context) error {
+	for topic, seg := range s.segments {
+		name := segmentName(topic, seg.first, seg.last)
+		if err := s.store.WriteObject(ctx, name, &seg.buffer); err != nil {
+			return fmt.Errorf("write segment %q: %w", name, err)
+		}
+		zlog.Debug("segment written", zap.String("topic", topic), zap.Uint64("first", seg.first), zap.Uint64("last", seg.last))
+		delete(s.segments, topic)
+	}
+	return nil
+}
+
+// Flush writes the pending segments, the next blocks start new segments.
+func (s *Sink) Flush(ctx context.Context) error {
+	return s.flush(ctx)
+}
+
+func (s *Sink) Close() error {
+	return s.flush(context.Background())
+}
+
+func segmentName(topic string, first, last uint64) string {
+	return fmt.Sprintf("%s/%010d-%010d", topic, first, last)
+}
+
+func parseSegmentName(name string) (topic string, first, last uint64, err error) {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return "", 0, 0, fmt.Errorf("invalid segment name %q", name)
+	}
+	topic, blocks := name[:i], strings.TrimSuffix(name[i+1:], ".dbin.zst")
+
+	parts := strings.Split(blocks, "-")
+	if len(parts) != 2 {
+		return "", 0, 0, fmt.Errorf("invalid segment name %q", name)
+	}
+	if first, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return "", 0, 0, fmt.Errorf("invalid segment name %q: %w", name, err)
+	}
+	if last, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return "", 0, 0, fmt.Errorf("invalid segment name %q: %w", name, err)
+	}
+	return topic, first, last, nil
+}
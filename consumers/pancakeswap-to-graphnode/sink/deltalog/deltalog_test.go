@@ -0,0 +1,175 @@
+package deltalog
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/internal/testfixture"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// block is `testfixture.PairsBlock` with a `store_reserves` output on the even blocks.
+func block(num uint64, step pbsubstreams.ForkStep) *pbsubstreams.BlockScopedData {
+	data := testfixture.PairsBlock(num, step)
+	if num%2 == 0 {
+		data.Outputs = append(data.Outputs, testfixture.StoreOutput("store_reserves", &pbsubstreams.StoreDelta{Key: fmt.Sprintf("reserve:%d", num), NewValue: []byte("r")}))
+	}
+	return data
+}
+
+func writeBlocks(t *testing.T, storeURL string, blocks ...*pbsubstreams.BlockScopedData) {
+	t.Helper()
+
+	s, err := New(&Config{StoreURL: storeURL, SegmentSize: 10})
+	require.NoError(t, err)
+	for _, data := range blocks {
+		require.NoError(t, s.Write(context.Background(), data))
+	}
+	require.NoError(t, s.Close())
+}
+
+type step struct {
+	num  uint64
+	undo bool
+}
+
+func read(t *testing.T, r *Reader, topic string, start, stop uint64) (out []step) {
+	t.Helper()
+
+	_, module := SplitTopic(topic)
+	require.NoError(t, r.Read(context.Background(), topic, start, stop, func(data *pbsubstreams.BlockScopedData) error {
+		require.Len(t, data.Outputs, 1)
+		require.Equal(t, module, data.Outputs[0].Name)
+		out = append(out, step{data.Clock.Number, data.Step == pbsubstreams.ForkStep_STEP_UNDO})
+		return nil
+	}))
+	return out
+}
+
+func steps(from, to uint64, every uint64) (out []step) {
+	for num := from; num <= to; num++ {
+		if num%every == 0 {
+			out = append(out, step{num: num})
+		}
+	}
+	return out
+}
+
+func TestDeltaLog(t *testing.T) {
+	storeURL := t.TempDir()
+
+	var blocks []*pbsubstreams.BlockScopedData
+	for num := uint64(5); num < 35; num++ {
+		blocks = append(blocks, block(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	writeBlocks(t, storeURL, blocks...)
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+
+	topics, err := r.Topics(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"store_pairs", "store_reserves"}, topics, "map outputs are not logged")
+
+	tests := []struct {
+		name        string
+		topic       string
+		start, stop uint64
+		expected    []step
+	}{
+		{"all", "store_pairs", 0, 0, steps(5, 34, 1)},
+		{"sparse topic", "store_reserves", 0, 0, steps(5, 34, 2)},
+		{"range across segments", "store_pairs", 8, 22, steps(8, 21, 1)},
+		{"past the end", "store_pairs", 40, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, read(t, r, test.topic, test.start, test.stop))
+		})
+	}
+}
+
+func TestDeltaLog_RestartAndUndo(t *testing.T) {
+	storeURL := t.TempDir()
+
+	var first []*pbsubstreams.BlockScopedData
+	for num := uint64(1); num <= 14; num++ {
+		first = append(first, block(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	writeBlocks(t, storeURL, first...)
+
+	// the restarted run goes back a few blocks, then sees a reorg
+	var second []*pbsubstreams.BlockScopedData
+	for num := uint64(12); num <= 16; num++ {
+		second = append(second, block(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	second = append(second,
+		block(16, pbsubstreams.ForkStep_STEP_UNDO),
+		block(15, pbsubstreams.ForkStep_STEP_UNDO),
+		block(15, pbsubstreams.ForkStep_STEP_NEW),
+	)
+	writeBlocks(t, storeURL, second...)
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+
+	expected := append(steps(1, 16, 1), step{16, true}, step{15, true}, step{num: 15})
+	assert.Equal(t, expected, read(t, r, "store_pairs", 0, 0))
+}
+
+func TestMerge(t *testing.T) {
+	shards := []string{t.TempDir(), t.TempDir()}
+	var first, second []*pbsubstreams.BlockScopedData
+	for num := uint64(1); num < 15; num++ {
+		first = append(first, block(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	for num := uint64(15); num < 30; num++ {
+		second = append(second, block(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	writeBlocks(t, shards[0], first...)
+	writeBlocks(t, shards[1], second...)
+
+	merged := t.TempDir()
+	copied, err := Merge(context.Background(), merged, shards)
+	require.NoError(t, err)
+	assert.Equal(t, 8, copied, "two segments per topic and shard")
+
+	r, err := NewReader(merged)
+	require.NoError(t, err)
+	assert.Equal(t, steps(1, 29, 1), read(t, r, "store_pairs", 0, 0))
+	assert.Equal(t, steps(1, 29, 2), read(t, r, "store_reserves", 0, 0))
+}
+
+func Test_parseParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    *Config
+		expectedErr bool
+	}{
+		{"url only", "file:///data/deltas", &Config{StoreURL: "file:///data/deltas", SegmentSize: 100}, false},
+		{"segment size", "file:///data/deltas?segment-size=1000", &Config{StoreURL: "file:///data/deltas", SegmentSize: 1000}, false},
+		{"store parameters kept", "s3://bucket/deltas?region=us-east-1&segment-size=10", &Config{StoreURL: "s3://bucket/deltas?region=us-east-1", SegmentSize: 10}, false},
+		{"namespace", "file:///data/deltas?namespace=bsc/pancake", &Config{StoreURL: "file:///data/deltas", SegmentSize: 100, Namespace: "bsc/pancake"}, false},
+		{"no url", "", nil, true},
+		{"invalid segment size", "file:///data/deltas?segment-size=0", nil, true},
+		{"invalid namespace", "file:///data/deltas?namespace=bsc//pancake", nil, true},
+		{"reserved namespace", "file:///data/deltas?namespace=_snapshots", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseParams(test.in)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package deltalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// groupsPrefix is where groups offsets live in the log, it can't clash with a
+// topic as module names don't start with an underscore.
+const groupsPrefix = "_groups"
+
+type GroupConfig struct {
+	Group string
+	Topic string
+
+	// Start is the block a group with no committed offset starts at.
+	Start uint64
+
+	// AckTimeout is how long a delivered message can stay unacknowledged
+	// before it's delivered again, to any subscriber of the group.
+	AckTimeout time.Duration
+
+	// MaxInFlight bounds the number of delivered messages not acknowledged
+	// yet, the log isn't read further until older messages are acknowledged.
+	MaxInFlight int
+
+	// CommitInterval is the minimum time between two writes of the group
+	// offset, it's always written on `Close`.
+	CommitInterval time.Duration
+}
+
+func (c *GroupConfig) setDefaults() {
+	if c.AckTimeout <= 0 {
+		c.AckTimeout = 30 * time.Second
+	}
+	if c.MaxInFlight <= 0 {
+		c.MaxInFlight = 1000
+	}
+	if c.CommitInterval <= 0 {
+		c.CommitInterval = 5 * time.Second
+	}
+}
+
+// Group shares a topic among subscribers, each message of the topic is
+// delivered to a single subscriber, any number of goroutines calling `Next`.
+// Delivery is at least once: a message is delivered again when it's not
+// acknowledged within the ack timeout, and the group offset only moves past
+// messages that were all acknowledged, so a restarted group redelivers what
+// was in flight.
+//
+// Messages are delivered in log order but processed concurrently, so
+// subscribers must not depend on the deltas of a block being applied before
+// the ones of the next block. Groups are independent of each other, each has
+// its own offset per topic.
+type Group struct {
+	config  *GroupConfig
+	offsets dstore.Store
+
+	cancel context.CancelFunc
+	fed    chan struct{}
+	slots  chan struct{}
+
+	lock      sync.Mutex
+	changed   chan struct{}
+	inFlight  []*Message // in log order, acknowledged messages are removed once all older ones are
+	pending   []*Message // read, not delivered yet
+	readDone  bool
+	readErr   error
+	committed uint64
+	written   uint64
+	writtenAt time.Time
+}
+
+// Message is the deltas of a single block of the topic.
+type Message struct {
+	Data *pbsubstreams.BlockScopedData
+
+	// Deliveries is the number of times the message was delivered, including
+	// this one.
+	Deliveries int
+
+	group    *Group
+	next     uint64
+	acked    bool
+	deadline time.Time
+}
+
+type groupOffset struct {
+	NextBlock uint64 `json:"next_block"`
+}
+
+// Group starts consuming `config.Topic` as `config.Group`, from the offset the
+// group committed last or from `config.Start`.
+func (r *Reader) Group(ctx context.Context, config GroupConfig) (*Group, error) {
+	config.setDefaults()
+
+	offsets, err := dstore.NewStore(r.storeURL, "json", "", true)
+	if err != nil {
+		return nil, fmt.Errorf("group offsets store: %w", err)
+	}
+
+	g := &Group{
+		config:    &config,
+		offsets:   offsets,
+		fed:       make(chan struct{}),
+		slots:     make(chan struct{}, config.MaxInFlight),
+		changed:   make(chan struct{}),
+		committed: config.Start,
+	}
+
+	offset, found, err := g.readOffset(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		g.committed = offset
+	}
+	g.written = g.committed
+	zlog.Info("group started", zap.String("group", config.Group), zap.String("topic", config.Topic), zap.Uint64("start_block", g.committed), zap.Bool("resumed", found))
+
+	feedCtx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	go g.feed(feedCtx, r)
+
+	return g, nil
+}
+
+func (g *Group) offsetName() string {
+	return fmt.Sprintf("%s/%s/%s", groupsPrefix, g.config.Group, g.config.Topic)
+}
+
+func (g *Group) readOffset(ctx context.Context) (uint64, bool, error) {
+	name := g.offsetName()
+	exists, err := g.offsets.FileExists(ctx, name)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+
+	object, err := g.offsets.OpenObject(ctx, name)
+	if err != nil {
+		return 0, false, fmt.Errorf("open group offset %q: %w", name, err)
+	}
+	defer object.Close()
+
+	var offset groupOffset
+	if err := json.NewDecoder(object).Decode(&offset); err != nil {
+		return 0, false, fmt.Errorf("decode group offset %q: %w", name, err)
+	}
+	return offset.NextBlock, true, nil
+}
+
+func (g *Group) writeOffset(ctx context.Context, offset uint64) error {
+	content, err := json.Marshal(&groupOffset{NextBlock: offset})
+	if err != nil {
+		return err
+	}
+
+	name := g.offsetName()
+	if err := g.offsets.WriteObject(ctx, name, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("write group offset %q: %w", name, err)
+	}
+	return nil
+}
+
+func (g *Group) feed(ctx context.Context, r *Reader) {
+	defer close(g.fed)
+
+	next := g.committed
+	err := r.Read(ctx, g.config.Topic, g.committed, 0, func(data *pbsubstreams.BlockScopedData) error {
+		select {
+		case g.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		next = data.Clock.GetNumber() + 1
+		if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+			next = data.Clock.GetNumber()
+		}
+
+		g.lock.Lock()
+		msg := &Message{Data: data, group: g, next: next}
+		g.inFlight = append(g.inFlight, msg)
+		g.pending = append(g.pending, msg)
+		g.notify()
+		g.lock.Unlock()
+		return nil
+	})
+
+	g.lock.Lock()
+	g.readDone, g.readErr = true, err
+	g.notify()
+	g.lock.Unlock()
+}
+
+// notify wakes up the subscribers waiting in `Next`, `lock` must be held.
+func (g *Group) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// Next returns the next message to process, which must be acknowledged once
+// processed. It returns `io.EOF` once every message of the topic was
+// delivered and acknowledged.
+func (g *Group) Next(ctx context.Context) (*Message, error) {
+	for {
+		g.lock.Lock()
+		now := time.Now()
+
+		var wakeUp time.Time
+		for _, msg := range g.inFlight {
+			if msg.acked || msg.deadline.IsZero() {
+				continue
+			}
+			if !now.Before(msg.deadline) {
+				zlog.Debug("redelivering message", zap.String("group", g.config.Group), zap.Uint64("block_num", msg.Data.Clock.GetNumber()), zap.Int("deliveries", msg.Deliveries))
+				g.deliver(msg, now)
+				g.lock.Unlock()
+				return msg, nil
+			}
+			if wakeUp.IsZero() || msg.deadline.Before(wakeUp) {
+				wakeUp = msg.deadline
+			}
+		}
+
+		if len(g.pending) > 0 {
+			msg := g.pending[0]
+			g.pending = g.pending[1:]
+			g.deliver(msg, now)
+			g.lock.Unlock()
+			return msg, nil
+		}
+
+		if g.readDone && len(g.inFlight) == 0 {
+			err := g.readErr
+			g.lock.Unlock()
+			if err == nil || err == context.Canceled {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("reading topic %q: %w", g.config.Topic, err)
+		}
+
+		changed := g.changed
+		g.lock.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !wakeUp.IsZero() {
+			timer = time.NewTimer(wakeUp.Sub(now))
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (g *Group) deliver(msg *Message, now time.Time) {
+	msg.Deliveries++
+	msg.deadline = now.Add(g.config.AckTimeout)
+}
+
+// Ack marks the message as processed, acknowledging a message twice, or after
+// it was redelivered, is a no-op.
+func (m *Message) Ack() error {
+	g := m.group
+
+	g.lock.Lock()
+	if m.acked {
+		g.lock.Unlock()
+		return nil
+	}
+	m.acked = true
+
+	for len(g.inFlight) > 0 && g.inFlight[0].acked {
+		g.committed = g.inFlight[0].next
+		g.inFlight = g.inFlight[1:]
+		<-g.slots
+	}
+
+	var commit bool
+	offset := g.committed
+	if offset != g.written && time.Since(g.writtenAt) >= g.config.CommitInterval {
+		g.written, g.writtenAt, commit = offset, time.Now(), true
+	}
+	g.notify()
+	g.lock.Unlock()
+
+	if commit {
+		return g.writeOffset(context.Background(), offset)
+	}
+	return nil
+}
+
+// Committed returns the block the group would restart from.
+func (g *Group) Committed() uint64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.committed
+}
+
+// Close stops reading the topic and writes the group offset, messages not
+// acknowledged yet are delivered again by the next group on this topic.
+func (g *Group) Close() error {
+	g.cancel()
+	<-g.fed
+
+	g.lock.Lock()
+	offset := g.committed
+	g.written, g.writtenAt = offset, time.Now()
+	g.lock.Unlock()
+
+	return g.writeOffset(context.Background(), offset)
+}
@@ -0,0 +1,136 @@
+package deltalog
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLog(t *testing.T, from, to uint64) string {
+	storeURL := t.TempDir()
+
+	var blocks []*pbsubstreams.BlockScopedData
+	for num := from; num <= to; num++ {
+		blocks = append(blocks, block(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	writeBlocks(t, storeURL, blocks...)
+	return storeURL
+}
+
+func TestGroup_SharedTopic(t *testing.T) {
+	r, err := NewReader(writeLog(t, 1, 40))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	g, err := r.Group(ctx, GroupConfig{Group: "indexers", Topic: "store_pairs", MaxInFlight: 4})
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	var received []uint64
+	perSubscriber := make([]int, 3)
+
+	wg := sync.WaitGroup{}
+	for i := range perSubscriber {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				msg, err := g.Next(ctx)
+				if err == io.EOF || !assert.NoError(t, err) {
+					return
+				}
+
+				lock.Lock()
+				received = append(received, msg.Data.Clock.Number)
+				perSubscriber[i]++
+				lock.Unlock()
+
+				time.Sleep(time.Millisecond)
+				assert.NoError(t, msg.Ack())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(received, func(i, j int) bool { return received[i] < received[j] })
+	assert.Equal(t, numbers(1, 40), received, "every message is delivered once")
+	for i, count := range perSubscriber {
+		assert.Greater(t, count, 0, "subscriber %d got messages", i)
+	}
+
+	assert.Equal(t, uint64(41), g.Committed())
+	require.NoError(t, g.Close())
+}
+
+func TestGroup_RedeliveryAndRestart(t *testing.T) {
+	r, err := NewReader(writeLog(t, 1, 10))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	config := GroupConfig{Group: "indexers", Topic: "store_pairs", AckTimeout: 20 * time.Millisecond}
+
+	g, err := r.Group(ctx, config)
+	require.NoError(t, err)
+
+	var unacked *Message
+	for i := 0; i < 4; i++ {
+		msg, err := g.Next(ctx)
+		require.NoError(t, err)
+		if msg.Data.Clock.Number == 2 {
+			unacked = msg
+			continue
+		}
+		require.NoError(t, msg.Ack())
+	}
+	assert.Equal(t, uint64(2), g.Committed(), "offset stops at the oldest message not acknowledged")
+
+	// remaining messages come first, then the expired one
+	var redelivered *Message
+	for redelivered == nil {
+		msg, err := g.Next(ctx)
+		require.NoError(t, err)
+		if msg.Data.Clock.Number == 2 {
+			redelivered = msg
+			break
+		}
+		require.NoError(t, msg.Ack())
+	}
+	assert.Same(t, unacked, redelivered)
+	assert.Equal(t, 2, redelivered.Deliveries)
+	require.NoError(t, g.Close())
+
+	// never acknowledged, a restarted group delivers it again
+	g, err = r.Group(ctx, config)
+	require.NoError(t, err)
+	msg, err := g.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), msg.Data.Clock.Number)
+	require.NoError(t, msg.Ack())
+	require.NoError(t, g.Close())
+
+	// groups have their own offsets
+	other, err := r.Group(ctx, GroupConfig{Group: "analytics", Topic: "store_pairs"})
+	require.NoError(t, err)
+	msg, err = other.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), msg.Data.Clock.Number)
+	require.NoError(t, other.Close())
+
+	topics, err := r.Topics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"store_pairs", "store_reserves"}, topics, "offsets are not topics")
+}
+
+func numbers(from, to uint64) (out []uint64) {
+	for num := from; num <= to; num++ {
+		out = append(out, num)
+	}
+	return out
+}
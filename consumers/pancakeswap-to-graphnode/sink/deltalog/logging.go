@@ -0,0 +1,7 @@
+package deltalog
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.deltalog", "github.com/streamingfast/substream-pancakeswap/sink/deltalog")
@@ -0,0 +1,58 @@
+package deltalog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Merge copies the segments of the logs at `inputURLs`, like the logs written
+// by the shards of a backfill, into the log at `outputURL`. Segments keep
+// their names, the reader orders them by block and skips the blocks found in
+// more than one. Consumer group offsets are not copied.
+func Merge(ctx context.Context, outputURL string, inputURLs []string) (copied int, err error) {
+	output, err := newStore(outputURL)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, inputURL := range inputURLs {
+		input, err := newStore(inputURL)
+		if err != nil {
+			return copied, err
+		}
+
+		var names []string
+		err = input.Walk(ctx, "", func(filename string) error {
+			if strings.HasPrefix(filename, groupsPrefix+"/") || strings.HasPrefix(filename, snapshotsPrefix+"/") {
+				return nil
+			}
+			if _, _, _, err := parseSegmentName(filename); err != nil {
+				return nil
+			}
+			names = append(names, filename)
+			return nil
+		})
+		if err != nil {
+			return copied, fmt.Errorf("list segments of %q: %w", inputURL, err)
+		}
+
+		for _, name := range names {
+			object, err := input.OpenObject(ctx, name)
+			if err != nil {
+				return copied, fmt.Errorf("open segment %q of %q: %w", name, inputURL, err)
+			}
+			err = output.WriteObject(ctx, name, object)
+			object.Close()
+			if err != nil {
+				return copied, fmt.Errorf("write segment %q: %w", name, err)
+			}
+			copied++
+		}
+		zlog.Info("log merged", zap.String("input", inputURL), zap.Int("segments", len(names)))
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,62 @@
+package deltalog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namespaceSegmentRegex matches a segment of a namespace, it can't start with
+// an underscore so namespaces don't clash with the groups offsets and the
+// snapshots.
+var namespaceSegmentRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ValidateNamespace checks `namespace` is a `/` separated path, like
+// `bsc/pancake`, the root namespace being empty.
+//
+// Namespaces let the pipelines of different protocols or chains share a log:
+// the topics of a sink writing in namespace `bsc/pancake` are
+// `bsc/pancake/<store module>`.
+func ValidateNamespace(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	for _, segment := range strings.Split(namespace, "/") {
+		if !namespaceSegmentRegex.MatchString(segment) {
+			return fmt.Errorf("invalid namespace %q, expected '/' separated names of letters, digits, '.', '_' and '-', like 'bsc/pancake'", namespace)
+		}
+	}
+	return nil
+}
+
+// JoinTopic returns the topic of store module `module` in `namespace`.
+func JoinTopic(namespace, module string) string {
+	if namespace == "" {
+		return module
+	}
+	return namespace + "/" + module
+}
+
+// SplitTopic returns the namespace and the store module of `topic`.
+func SplitTopic(topic string) (namespace, module string) {
+	if i := strings.LastIndex(topic, "/"); i >= 0 {
+		return topic[:i], topic[i+1:]
+	}
+	return "", topic
+}
+
+// Namespaces returns the store modules of the log per namespace, sorted.
+func (r *Reader) Namespaces(ctx context.Context) (map[string][]string, error) {
+	topics, err := r.Topics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string][]string{}
+	for _, topic := range topics {
+		namespace, module := SplitTopic(topic)
+		out[namespace] = append(out[namespace], module)
+	}
+	return out, nil
+}
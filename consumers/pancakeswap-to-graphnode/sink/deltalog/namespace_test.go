@@ -0,0 +1,50 @@
+package deltalog
+
+import (
+	"context"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaces(t *testing.T) {
+	ctx := context.Background()
+	storeURL := t.TempDir()
+
+	for _, namespace := range []string{"bsc/pancake", "eth/uniswap", ""} {
+		s, err := New(&Config{StoreURL: storeURL, SegmentSize: 10, Namespace: namespace})
+		require.NoError(t, err)
+		for num := uint64(1); num <= 3; num++ {
+			require.NoError(t, s.Write(ctx, block(num, pbsubstreams.ForkStep_STEP_NEW)))
+		}
+		require.NoError(t, s.Close())
+	}
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+
+	namespaces, err := r.Namespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"":            {"store_pairs", "store_reserves"},
+		"bsc/pancake": {"store_pairs", "store_reserves"},
+		"eth/uniswap": {"store_pairs", "store_reserves"},
+	}, namespaces)
+
+	assert.Equal(t, steps(1, 3, 1), read(t, r, "store_pairs", 0, 0))
+	assert.Equal(t, steps(1, 3, 1), read(t, r, "bsc/pancake/store_pairs", 0, 0))
+	assert.Equal(t, steps(1, 3, 2), read(t, r, "eth/uniswap/store_reserves", 0, 0))
+}
+
+func TestSplitTopic(t *testing.T) {
+	namespace, module := SplitTopic("bsc/pancake/store_pairs")
+	assert.Equal(t, "bsc/pancake", namespace)
+	assert.Equal(t, "store_pairs", module)
+	assert.Equal(t, "bsc/pancake/store_pairs", JoinTopic(namespace, module))
+
+	namespace, module = SplitTopic("store_pairs")
+	assert.Equal(t, "", namespace)
+	assert.Equal(t, "store_pairs", JoinTopic(namespace, module))
+}
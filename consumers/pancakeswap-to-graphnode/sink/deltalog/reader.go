@@ -0,0 +1,182 @@
+package deltalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/streamingfast/dbin"
+	"github.com/streamingfast/dstore"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Reader reads back the deltas persisted by the `deltalog` sink.
+type Reader struct {
+	storeURL string
+	store    dstore.Store
+
+	compressAbove int
+}
+
+func NewReader(storeURL string) (*Reader, error) {
+	store, err := newStore(storeURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{storeURL: storeURL, store: store}, nil
+}
+
+type segmentRef struct {
+	name        string
+	first, last uint64
+}
+
+// Topics returns the stores that have deltas in the log, sorted.
+func (r *Reader) Topics(ctx context.Context) ([]string, error) {
+	seen := map[string]bool{}
+	err := r.store.Walk(ctx, "", func(filename string) error {
+		if strings.HasPrefix(filename, groupsPrefix+"/") || strings.HasPrefix(filename, snapshotsPrefix+"/") {
+			return nil
+		}
+
+		topic, _, _, err := parseSegmentName(filename)
+		if err != nil {
+			return nil
+		}
+		seen[topic] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list topics: %w", err)
+	}
+
+	topics := make([]string, 0, len(seen))
+	for topic := range seen {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Read calls `handle` with the deltas of `topic` in [start, stop[, in the order
+// they were written, until the end of the log when `stop` is 0.
+//
+// Segments written by restarted runs overlap, blocks are delivered once: a
+// block is skipped when it was already delivered and not undone since.
+func (r *Reader) Read(ctx context.Context, topic string, start, stop uint64, handle func(data *pbsubstreams.BlockScopedData) error) error {
+	segments, err := r.segments(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	pos := &position{start: start, stop: stop, next: start}
+	for _, seg := range segments {
+		if !pos.covers(seg) {
+			continue
+		}
+
+		err := r.readSegment(ctx, seg.name, func(data *pbsubstreams.BlockScopedData) error {
+			if !pos.accept(data) {
+				return nil
+			}
+			return handle(data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// position is how far a read of [start, stop[ went, `next` being the first
+// block it didn't deliver yet.
+type position struct {
+	start, stop, next uint64
+}
+
+func (p *position) covers(seg *segmentRef) bool {
+	return seg.last >= p.next && (p.stop == 0 || seg.first < p.stop)
+}
+
+// accept tells whether `data` is delivered, moving the position past it.
+func (p *position) accept(data *pbsubstreams.BlockScopedData) bool {
+	num := data.Clock.GetNumber()
+	if p.stop != 0 && num >= p.stop {
+		return false
+	}
+
+	if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		if num < p.start || num >= p.next {
+			return false
+		}
+		p.next = num
+		return true
+	}
+
+	if num < p.next {
+		return false
+	}
+	p.next = num + 1
+	return true
+}
+
+func (r *Reader) segments(ctx context.Context, topic string) (out []*segmentRef, err error) {
+	err = r.store.Walk(ctx, topic+"/", func(filename string) error {
+		segTopic, first, last, err := parseSegmentName(filename)
+		if err != nil || segTopic != topic {
+			return nil
+		}
+		out = append(out, &segmentRef{name: segmentName(topic, first, last), first: first, last: last})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list segments of %q: %w", topic, err)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].first != out[j].first {
+			return out[i].first < out[j].first
+		}
+		return out[i].last < out[j].last
+	})
+	return out, nil
+}
+
+func (r *Reader) readSegment(ctx context.Context, name string, handle func(data *pbsubstreams.BlockScopedData) error) error {
+	object, err := r.store.OpenObject(ctx, name)
+	if err != nil {
+		return fmt.Errorf("open segment %q: %w", name, err)
+	}
+	defer object.Close()
+
+	reader := dbin.NewReader(object)
+	kind, _, err := reader.ReadHeader()
+	if err != nil {
+		return fmt.Errorf("read segment %q header: %w", name, err)
+	}
+	if kind != contentType {
+		return fmt.Errorf("segment %q holds %q, expected %q", name, kind, contentType)
+	}
+
+	for {
+		content, err := reader.ReadMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read segment %q: %w", name, err)
+		}
+
+		data := &pbsubstreams.BlockScopedData{}
+		if err := proto.Unmarshal(content, data); err != nil {
+			return fmt.Errorf("unmarshal segment %q message: %w", name, err)
+		}
+		if err := handle(data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package deltalog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/streamingfast/dbin"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// snapshotsPrefix is where the snapshots of the topics live in the log,
+	// like the groups offsets.
+	snapshotsPrefix     = "_snapshots"
+	snapshotContentType = "SDS"
+
+	// Values of version 2 snapshots start with a byte telling how they're
+	// encoded, version 1 snapshots hold the raw values.
+	snapshotVersion = 2
+	valueRaw        = 0
+	valueSnappy     = 1
+)
+
+// State is the content of a store, per key.
+type State map[string][]byte
+
+// Apply applies the deltas of `data`, reverting them in reverse order when
+// it's an undo.
+func (s State) Apply(data *pbsubstreams.BlockScopedData) {
+	for _, output := range data.Outputs {
+		deltas := output.GetStoreDeltas().GetDeltas()
+		if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+			for i := len(deltas) - 1; i >= 0; i-- {
+				s.revert(deltas[i])
+			}
+			continue
+		}
+		for _, delta := range deltas {
+			s.apply(delta)
+		}
+	}
+}
+
+func (s State) apply(delta *pbsubstreams.StoreDelta) {
+	switch delta.Operation {
+	case pbsubstreams.StoreDelta_DELETE:
+		delete(s, delta.Key)
+	default:
+		s[delta.Key] = delta.NewValue
+	}
+}
+
+func (s State) revert(delta *pbsubstreams.StoreDelta) {
+	switch delta.Operation {
+	case pbsubstreams.StoreDelta_CREATE:
+		delete(s, delta.Key)
+	default:
+		s[delta.Key] = delta.OldValue
+	}
+}
+
+// StateAt returns the content of the store of `topic` once block `block` is
+// applied, starting from the closest snapshot at or before `block` and applying
+// the deltas logged after it, from the beginning of the log when there is no
+// such snapshot. `from` is the block of the snapshot used, 0 when none.
+func (r *Reader) StateAt(ctx context.Context, topic string, block uint64) (state State, from uint64, err error) {
+	snapshots, err := r.Snapshots(ctx, topic)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	state = State{}
+	start := uint64(0)
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i] <= block {
+			from = snapshots[i]
+			if state, err = r.readSnapshot(ctx, topic, from); err != nil {
+				return nil, 0, err
+			}
+			start = from + 1
+			break
+		}
+	}
+
+	err = r.Read(ctx, topic, start, block+1, func(data *pbsubstreams.BlockScopedData) error {
+		state.Apply(data)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return state, from, nil
+}
+
+// Snapshots returns the blocks at which `topic` has a snapshot, sorted.
+func (r *Reader) Snapshots(ctx context.Context, topic string) (out []uint64, err error) {
+	prefix := snapshotsPrefix + "/" + topic + "/"
+	err = r.store.Walk(ctx, prefix, func(filename string) error {
+		name := strings.TrimSuffix(strings.TrimPrefix(filename, prefix), ".dbin.zst")
+		num, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			return nil
+		}
+		out = append(out, num)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots of %q: %w", topic, err)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
+
+// WriteSnapshots records the state of `topic` every `interval` blocks, from
+// its last snapshot to the end of the log, so `StateAt` applies at most
+// `interval` blocks of deltas. Snapshots must only cover irreversible blocks,
+// undos of blocks before a snapshot aren't reverted from it.
+func (r *Reader) WriteSnapshots(ctx context.Context, topic string, interval uint64) (written int, err error) {
+	if interval == 0 {
+		return 0, fmt.Errorf("snapshot interval must be positive")
+	}
+
+	snapshots, err := r.Snapshots(ctx, topic)
+	if err != nil {
+		return 0, err
+	}
+
+	state, start := State{}, uint64(0)
+	if len(snapshots) > 0 {
+		last := snapshots[len(snapshots)-1]
+		if state, err = r.readSnapshot(ctx, topic, last); err != nil {
+			return 0, err
+		}
+		start = last + 1
+	}
+
+	// Snapshots are taken at multiples of `interval`, once a later block
+	// shows every block up to it is in the log.
+	next := (start/interval + 1) * interval
+	err = r.Read(ctx, topic, start, 0, func(data *pbsubstreams.BlockScopedData) error {
+		for data.Clock.GetNumber() > next {
+			if err := r.writeSnapshot(ctx, topic, next, state); err != nil {
+				return err
+			}
+			written++
+			next += interval
+		}
+		state.Apply(data)
+		return nil
+	})
+	return written, err
+}
+
+func snapshotName(topic string, block uint64) string {
+	return fmt.Sprintf("%s/%s/%010d", snapshotsPrefix, topic, block)
+}
+
+// SetCompressionThreshold makes the snapshots written from now on compress
+// the values larger than `size` bytes with snappy, when it makes them
+// smaller, which pays off for stores of serialized entities. Compression is
+// disabled when 0, readers handle both.
+func (r *Reader) SetCompressionThreshold(size int) {
+	r.compressAbove = size
+}
+
+// writeSnapshot writes `state` as a single `sf.substreams.v1.StoreDeltas`
+// message creating every key, sorted.
+func (r *Reader) writeSnapshot(ctx context.Context, topic string, block uint64, state State) error {
+	keys := make([]string, 0, len(state))
+	for key := range state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	deltas := &pbsubstreams.StoreDeltas{Deltas: make([]*pbsubstreams.StoreDelta, 0, len(keys))}
+	for _, key := range keys {
+		deltas.Deltas = append(deltas.Deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: key, NewValue: r.encodeValue(state[key])})
+	}
+	content, err := proto.Marshal(deltas)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot of %q at block %d: %w", topic, block, err)
+	}
+
+	var buffer bytes.Buffer
+	writer := dbin.NewWriter(&buffer)
+	if err := writer.WriteHeader(snapshotContentType, snapshotVersion); err != nil {
+		return fmt.Errorf("write snapshot header: %w", err)
+	}
+	if err := writer.WriteMessage(content); err != nil {
+		return fmt.Errorf("write snapshot of %q at block %d: %w", topic, block, err)
+	}
+
+	name := snapshotName(topic, block)
+	if err := r.store.WriteObject(ctx, name, &buffer); err != nil {
+		return fmt.Errorf("write snapshot %q: %w", name, err)
+	}
+	zlog.Debug("snapshot written", zap.String("topic", topic), zap.Uint64("block", block), zap.Int("keys", len(keys)))
+	return nil
+}
+
+func (r *Reader) readSnapshot(ctx context.Context, topic string, block uint64) (State, error) {
+	name := snapshotName(topic, block)
+	object, err := r.store.OpenObject(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot %q: %w", name, err)
+	}
+	defer object.Close()
+
+	reader := dbin.NewReader(object)
+	kind, version, err := reader.ReadHeader()
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot %q header: %w", name, err)
+	}
+	if kind != snapshotContentType {
+		return nil, fmt.Errorf("snapshot %q holds %q, expected %q", name, kind, snapshotContentType)
+	}
+
+	content, err := reader.ReadMessage()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read snapshot %q: %w", name, err)
+	}
+
+	deltas := &pbsubstreams.StoreDeltas{}
+	if err := proto.Unmarshal(content, deltas); err != nil {
+		return nil, fmt.Errorf("unmarshal snapshot %q: %w", name, err)
+	}
+
+	state := make(State, len(deltas.Deltas))
+	for _, delta := range deltas.Deltas {
+		value := delta.NewValue
+		if version >= 2 {
+			if value, err = decodeValue(value); err != nil {
+				return nil, fmt.Errorf("snapshot %q key %q: %w", name, delta.Key, err)
+			}
+		}
+		state[delta.Key] = value
+	}
+	return state, nil
+}
+
+func (r *Reader) encodeValue(value []byte) []byte {
+	if r.compressAbove > 0 && len(value) > r.compressAbove {
+		if compressed := snappy.Encode(nil, value); len(compressed) < len(value) {
+			return append([]byte{valueSnappy}, compressed...)
+		}
+	}
+	return append([]byte{valueRaw}, value...)
+}
+
+func decodeValue(value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, fmt.Errorf("empty value, missing its encoding")
+	}
+
+	switch value[0] {
+	case valueRaw:
+		return value[1:], nil
+	case valueSnappy:
+		decoded, err := snappy.Decode(nil, value[1:])
+		if err != nil {
+			return nil, fmt.Errorf("decompress value: %w", err)
+		}
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("unknown value encoding %d", value[0])
+}
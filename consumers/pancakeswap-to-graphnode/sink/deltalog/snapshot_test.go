@@ -0,0 +1,134 @@
+package deltalog
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counterBlock updates `count` to `num` and creates `block:<num>`.
+func counterBlock(num uint64, step pbsubstreams.ForkStep) *pbsubstreams.BlockScopedData {
+	return &pbsubstreams.BlockScopedData{
+		Step:  step,
+		Clock: &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%08x", num)},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_counts", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+				Deltas: []*pbsubstreams.StoreDelta{
+					{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "count", OldValue: []byte(fmt.Sprint(num - 1)), NewValue: []byte(fmt.Sprint(num))},
+					{Operation: pbsubstreams.StoreDelta_CREATE, Key: fmt.Sprintf("block:%d", num), NewValue: []byte("x")},
+				},
+			}}},
+		},
+	}
+}
+
+func TestReader_StateAt(t *testing.T) {
+	ctx := context.Background()
+	storeURL := "file://" + filepath.Join(t.TempDir(), "log")
+
+	var blocks []*pbsubstreams.BlockScopedData
+	for num := uint64(1); num <= 25; num++ {
+		blocks = append(blocks, counterBlock(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	// Blocks 25 and 24 are undone, 24 comes back
+	blocks = append(blocks,
+		counterBlock(25, pbsubstreams.ForkStep_STEP_UNDO),
+		counterBlock(24, pbsubstreams.ForkStep_STEP_UNDO),
+		counterBlock(24, pbsubstreams.ForkStep_STEP_NEW),
+	)
+	writeBlocks(t, storeURL, blocks...)
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+
+	expected := func(block uint64) State {
+		out := State{"count": []byte(fmt.Sprint(block))}
+		for num := uint64(1); num <= block; num++ {
+			out[fmt.Sprintf("block:%d", num)] = []byte("x")
+		}
+		return out
+	}
+
+	state, from, err := r.StateAt(ctx, "store_counts", 7)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), from)
+	assert.Equal(t, expected(7), state)
+
+	written, err := r.WriteSnapshots(ctx, "store_counts", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+
+	snapshots, err := r.Snapshots(ctx, "store_counts")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{10, 20}, snapshots)
+
+	topics, err := r.Topics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"store_counts"}, topics)
+
+	for _, test := range []struct{ block, from uint64 }{{7, 0}, {10, 10}, {15, 10}, {24, 20}, {30, 20}} {
+		state, from, err := r.StateAt(ctx, "store_counts", test.block)
+		require.NoError(t, err)
+		assert.Equal(t, test.from, from, "block %d", test.block)
+
+		block := test.block
+		if block > 24 {
+			block = 24
+		}
+		assert.Equal(t, expected(block), state, "block %d", test.block)
+	}
+
+	// Nothing new to snapshot
+	written, err = r.WriteSnapshots(ctx, "store_counts", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, written)
+}
+
+func TestReader_SnapshotCompression(t *testing.T) {
+	ctx := context.Background()
+	storeURL := "file://" + filepath.Join(t.TempDir(), "log")
+
+	large := []byte(strings.Repeat("pair:0x0ed7e52944161450477ee417de9cd3a859b14fd0;", 20))
+	block := func(num uint64) *pbsubstreams.BlockScopedData {
+		return &pbsubstreams.BlockScopedData{
+			Step:  pbsubstreams.ForkStep_STEP_NEW,
+			Clock: &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%08x", num)},
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_pairs", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+					Deltas: []*pbsubstreams.StoreDelta{
+						{Operation: pbsubstreams.StoreDelta_CREATE, Key: fmt.Sprintf("large:%d", num), NewValue: large},
+						{Operation: pbsubstreams.StoreDelta_CREATE, Key: fmt.Sprintf("small:%d", num), NewValue: []byte("x")},
+					},
+				}}},
+			},
+		}
+	}
+	writeBlocks(t, storeURL, block(1), block(2), block(3))
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+	r.SetCompressionThreshold(64)
+
+	written, err := r.WriteSnapshots(ctx, "store_pairs", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+
+	state, from, err := r.StateAt(ctx, "store_pairs", 3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), from)
+	assert.Equal(t, State{
+		"large:1": large, "small:1": []byte("x"),
+		"large:2": large, "small:2": []byte("x"),
+		"large:3": large, "small:3": []byte("x"),
+	}, state)
+
+	assert.Equal(t, []byte{valueRaw, 'x'}, r.encodeValue([]byte("x")))
+	assert.Equal(t, byte(valueSnappy), r.encodeValue(large)[0])
+	assert.Less(t, len(r.encodeValue(large)), len(large))
+}
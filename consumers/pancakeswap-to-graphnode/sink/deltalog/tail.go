@@ -0,0 +1,68 @@
+package deltalog
+
+import (
+	"context"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Tail reads a topic as the log grows, each `Next` delivering the deltas of
+// the segments written since the previous one, blocks being delivered once
+// like `Read`. Segments are written whole, a tail sees the blocks of a running
+// sink once their segment is written, at a segment boundary.
+//
+// Tail isn't safe for concurrent use.
+type Tail struct {
+	reader *Reader
+	topic  string
+	pos    *position
+	read   map[string]bool
+}
+
+// Tail reads `topic` from `start`.
+func (r *Reader) Tail(topic string, start uint64) *Tail {
+	return &Tail{
+		reader: r,
+		topic:  topic,
+		pos:    &position{start: start, next: start},
+		read:   map[string]bool{},
+	}
+}
+
+// Next calls `handle` with the deltas of the segments written since the
+// previous call, in log order, it returns the number of blocks delivered.
+func (t *Tail) Next(ctx context.Context, handle func(data *pbsubstreams.BlockScopedData) error) (delivered int, err error) {
+	segments, err := t.reader.segments(ctx, t.topic)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, seg := range segments {
+		if t.read[seg.name] {
+			continue
+		}
+		if !t.pos.covers(seg) {
+			t.read[seg.name] = true
+			continue
+		}
+
+		err := t.reader.readSegment(ctx, seg.name, func(data *pbsubstreams.BlockScopedData) error {
+			if !t.pos.accept(data) {
+				return nil
+			}
+			delivered++
+			return handle(data)
+		})
+		if err != nil {
+			// the blocks delivered are skipped when the segment is read again
+			return delivered, err
+		}
+		t.read[seg.name] = true
+	}
+	return delivered, nil
+}
+
+// Position is the first block not delivered yet.
+func (t *Tail) Position() uint64 {
+	return t.pos.next
+}
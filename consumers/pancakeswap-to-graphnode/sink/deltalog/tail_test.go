@@ -0,0 +1,50 @@
+package deltalog
+
+import (
+	"context"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTail(t *testing.T) {
+	ctx := context.Background()
+	storeURL := t.TempDir()
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+	tail := r.Tail("store_pairs", 3)
+
+	next := func() (out []step) {
+		t.Helper()
+		_, err := tail.Next(ctx, func(data *pbsubstreams.BlockScopedData) error {
+			out = append(out, step{data.Clock.Number, data.Step == pbsubstreams.ForkStep_STEP_UNDO})
+			return nil
+		})
+		require.NoError(t, err)
+		return out
+	}
+
+	assert.Nil(t, next(), "empty log")
+
+	var blocks []*pbsubstreams.BlockScopedData
+	for num := uint64(1); num <= 12; num++ {
+		blocks = append(blocks, block(num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	writeBlocks(t, storeURL, blocks...)
+	assert.Equal(t, steps(3, 12, 1), next())
+	assert.Nil(t, next(), "nothing written since")
+
+	// a restarted run overlapping the blocks delivered, then a reorg
+	writeBlocks(t, storeURL,
+		block(11, pbsubstreams.ForkStep_STEP_NEW),
+		block(12, pbsubstreams.ForkStep_STEP_NEW),
+		block(13, pbsubstreams.ForkStep_STEP_NEW),
+		block(13, pbsubstreams.ForkStep_STEP_UNDO),
+		block(13, pbsubstreams.ForkStep_STEP_NEW),
+	)
+	assert.Equal(t, []step{{num: 13}, {13, true}, {num: 13}}, next())
+	assert.Equal(t, uint64(14), tail.Position())
+}
@@ -0,0 +1,205 @@
+// Package dlq keeps the blocks an output permanently fails to write (see
+// `sink.Permanent`), like a row the database rejects, in a dead-letter store
+// instead of stopping the run. Each block is a letter, along with the error,
+// written to the output again by `sink replay-dlq` once the cause is fixed.
+//
+// Letters are JSON files named `<output>/<block num>-<block id>-<step>.json`,
+// a block failing again replaces its letter.
+package dlq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// Letter is a block an output failed to write.
+type Letter struct {
+	Output   string    `json:"output"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+	BlockNum uint64    `json:"block_num"`
+	BlockID  string    `json:"block_id"`
+	Step     string    `json:"step"`
+	// Block is the `sf.substreams.v1.BlockScopedData` that failed, protobuf
+	// encoded.
+	Block []byte `json:"block"`
+
+	name string
+}
+
+// Data decodes the block of the letter.
+func (l *Letter) Data() (*pbsubstreams.BlockScopedData, error) {
+	data := &pbsubstreams.BlockScopedData{}
+	if err := proto.Unmarshal(l.Block, data); err != nil {
+		return nil, fmt.Errorf("unmarshal block %d of letter %q: %w", l.BlockNum, l.name, err)
+	}
+	return data, nil
+}
+
+// Name is the name of the letter in the store.
+func (l *Letter) Name() string {
+	return l.name
+}
+
+// Store holds the letters of the outputs.
+type Store struct {
+	store dstore.Store
+}
+
+// Open opens the dead-letter store at `storeURL`, a dstore URL like
+// `gs://bucket/dlq` or a local directory.
+func Open(storeURL string) (*Store, error) {
+	store, err := dstore.NewStore(storeURL, "json", "", true)
+	if err != nil {
+		return nil, fmt.Errorf("open dead-letter store %q: %w", storeURL, err)
+	}
+	return &Store{store: store}, nil
+}
+
+// Put writes the letter of `data` failing to be written to `output` with
+// `cause`.
+func (s *Store) Put(ctx context.Context, output string, data *pbsubstreams.BlockScopedData, cause error) (*Letter, error) {
+	block, err := proto.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal block %d: %w", data.Clock.GetNumber(), err)
+	}
+
+	letter := &Letter{
+		Output:   output,
+		Error:    cause.Error(),
+		FailedAt: time.Now().UTC(),
+		BlockNum: data.Clock.GetNumber(),
+		BlockID:  data.Clock.GetId(),
+		Step:     data.Step.String(),
+		Block:    block,
+		name:     fmt.Sprintf("%s/%010d-%s-%s", output, data.Clock.GetNumber(), data.Clock.GetId(), strings.ToLower(strings.TrimPrefix(data.Step.String(), "STEP_"))),
+	}
+
+	content, err := json.Marshal(letter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.WriteObject(ctx, letter.name, bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("write letter %q: %w", letter.name, err)
+	}
+	return letter, nil
+}
+
+// List returns the letters of `output`, of every output when empty, in the
+// order they were written.
+func (s *Store) List(ctx context.Context, output string) ([]*Letter, error) {
+	prefix := ""
+	if output != "" {
+		prefix = output + "/"
+	}
+
+	var names []string
+	err := s.store.Walk(ctx, prefix, func(filename string) error {
+		names = append(names, filename)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list letters: %w", err)
+	}
+
+	out := make([]*Letter, 0, len(names))
+	for _, name := range names {
+		letter, err := s.read(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, letter)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].FailedAt.Equal(out[j].FailedAt) {
+			return out[i].FailedAt.Before(out[j].FailedAt)
+		}
+		return out[i].name < out[j].name
+	})
+	return out, nil
+}
+
+func (s *Store) read(ctx context.Context, name string) (*Letter, error) {
+	object, err := s.store.OpenObject(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("open letter %q: %w", name, err)
+	}
+	defer object.Close()
+
+	content, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("read letter %q: %w", name, err)
+	}
+
+	letter := &Letter{name: name}
+	if err := json.Unmarshal(content, letter); err != nil {
+		return nil, fmt.Errorf("decode letter %q: %w", name, err)
+	}
+	return letter, nil
+}
+
+// Delete removes a letter, like once replayed.
+func (s *Store) Delete(ctx context.Context, letter *Letter) error {
+	if err := s.store.DeleteObject(ctx, letter.name); err != nil {
+		return fmt.Errorf("delete letter %q: %w", letter.name, err)
+	}
+	return nil
+}
+
+// Sink writes to another sink, putting the blocks it permanently fails to
+// write in the dead-letter store, other errors are returned.
+type Sink struct {
+	store  *Store
+	output string
+	sink   sink.Sink
+
+	letters int
+}
+
+// NewSink writes to `s`, its letters are filed under `output`.
+func NewSink(store *Store, output string, s sink.Sink) *Sink {
+	return &Sink{store: store, output: output, sink: s}
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	err := s.sink.Write(ctx, data)
+	if err == nil || !sink.IsPermanent(err) {
+		return err
+	}
+
+	letter, putErr := s.store.Put(ctx, s.output, data, err)
+	if putErr != nil {
+		return fmt.Errorf("%w, and putting it in the dead-letter store failed: %s", err, putErr)
+	}
+	s.letters++
+	zlog.Warn("block put in the dead-letter store", zap.String("output", s.output), zap.Uint64("block_num", letter.BlockNum), zap.String("letter", letter.name), zap.Error(err))
+	return nil
+}
+
+func (s *Sink) Flush(ctx context.Context) error {
+	return sink.Flush(ctx, s.sink)
+}
+
+func (s *Sink) SetBatching(enabled bool) {
+	sink.SetBatching(s.sink, enabled)
+}
+
+func (s *Sink) Close() error {
+	if s.letters > 0 {
+		zlog.Warn("blocks put in the dead-letter store, see 'sink replay-dlq'", zap.String("output", s.output), zap.Int("letters", s.letters))
+	}
+	return s.sink.Close()
+}
@@ -0,0 +1,87 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+type failingSink struct {
+	errs    map[uint64]error
+	written []uint64
+	closed  bool
+}
+
+func (s *failingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	if err := s.errs[data.Clock.Number]; err != nil {
+		return err
+	}
+	s.written = append(s.written, data.Clock.Number)
+	return nil
+}
+
+func (s *failingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func block(num uint64, step pbsubstreams.ForkStep) *pbsubstreams.BlockScopedData {
+	return &pbsubstreams.BlockScopedData{Clock: &pbsubstreams.Clock{Number: num, Id: "0a"}, Step: step, Cursor: "cursor"}
+}
+
+func TestSink(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	require.NoError(t, err)
+
+	unreachable := errors.New("connection refused")
+	inner := &failingSink{errs: map[uint64]error{
+		2: sink.Permanent(errors.New(`UPDATE key "pairs": CHECK constraint failed`)),
+		3: unreachable,
+		4: sink.Permanent(errors.New("no such column: ordinal")),
+	}}
+	s := NewSink(store, "sqlite", inner)
+
+	ctx := context.Background()
+	require.NoError(t, s.Write(ctx, block(1, pbsubstreams.ForkStep_STEP_NEW)))
+	require.NoError(t, s.Write(ctx, block(2, pbsubstreams.ForkStep_STEP_NEW)))
+	assert.ErrorIs(t, s.Write(ctx, block(3, pbsubstreams.ForkStep_STEP_NEW)), unreachable)
+	require.NoError(t, s.Write(ctx, block(4, pbsubstreams.ForkStep_STEP_UNDO)))
+	require.NoError(t, s.Close())
+	assert.Equal(t, []uint64{1}, inner.written)
+	assert.True(t, inner.closed)
+
+	letters, err := store.List(ctx, "sqlite")
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	assert.Equal(t, "sqlite/0000000002-0a-new", letters[0].Name())
+	assert.Equal(t, "sqlite/0000000004-0a-undo", letters[1].Name())
+	assert.FileExists(t, filepath.Join(dir, "sqlite", "0000000002-0a-new.json"))
+
+	assert.Equal(t, "sqlite", letters[0].Output)
+	assert.Equal(t, `UPDATE key "pairs": CHECK constraint failed`, letters[0].Error)
+	assert.Equal(t, "STEP_UNDO", letters[1].Step)
+	data, err := letters[1].Data()
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(block(4, pbsubstreams.ForkStep_STEP_UNDO), data))
+
+	all, err := store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	none, err := store.List(ctx, "jsonl")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	require.NoError(t, store.Delete(ctx, letters[0]))
+	letters, err = store.List(ctx, "sqlite")
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, uint64(4), letters[0].BlockNum)
+}
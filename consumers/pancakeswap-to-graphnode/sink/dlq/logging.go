@@ -0,0 +1,7 @@
+package dlq
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.dlq", "github.com/streamingfast/substream-pancakeswap/sink/dlq")
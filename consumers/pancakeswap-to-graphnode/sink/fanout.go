@@ -0,0 +1,107 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Fanout writes each block to several sinks concurrently, at most `concurrency`
+// at a time, so the latency of a block is the one of the slowest sink rather
+// than the sum of all of them. A block is fully written to every sink before
+// the next one is accepted, each sink still sees the blocks in order and is
+// never called concurrently.
+type Fanout struct {
+	sinks       []Sink
+	concurrency int
+}
+
+// NewFanout creates a fanout over `sinks`, a `concurrency` below 1 writes to
+// all sinks at once.
+func NewFanout(concurrency int, sinks ...Sink) *Fanout {
+	return &Fanout{sinks: sinks, concurrency: concurrency}
+}
+
+// Add adds a sink to the fanout, it must not be called once blocks are written.
+func (f *Fanout) Add(s Sink) {
+	f.sinks = append(f.sinks, s)
+}
+
+// Write fails when any sink fails, with the errors of all failing sinks.
+func (f *Fanout) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	return f.each(func(s Sink) error { return s.Write(ctx, data) })
+}
+
+// Flush flushes the sinks buffering outputs.
+func (f *Fanout) Flush(ctx context.Context) error {
+	return f.each(func(s Sink) error { return Flush(ctx, s) })
+}
+
+// Close closes every sink, even when some of them fail.
+func (f *Fanout) Close() error {
+	return f.each(func(s Sink) error { return s.Close() })
+}
+
+func (f *Fanout) each(fn func(s Sink) error) error {
+	errs := make([]error, len(f.sinks))
+
+	concurrency := f.concurrency
+	if concurrency < 1 || concurrency > len(f.sinks) {
+		concurrency = len(f.sinks)
+	}
+
+	if concurrency <= 1 {
+		for i, s := range f.sinks {
+			errs[i] = fn(s)
+		}
+		return newErrors(f.sinks, errs)
+	}
+
+	slots := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, s := range f.sinks {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, s Sink) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			errs[i] = fn(s)
+		}(i, s)
+	}
+	wg.Wait()
+
+	return newErrors(f.sinks, errs)
+}
+
+// Errors holds the errors of the sinks that failed during a fanout operation.
+type Errors []error
+
+func newErrors(sinks []Sink, errs []error) error {
+	var out Errors
+	for i, err := range errs {
+		if err != nil {
+			out = append(out, fmt.Errorf("sink #%d (%T): %w", i, sinks[i], err))
+		}
+	}
+
+	switch len(out) {
+	case 0:
+		return nil
+	case 1:
+		return out[0]
+	}
+	return out
+}
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d sinks failed: %s", len(e), strings.Join(messages, "; "))
+}
@@ -0,0 +1,108 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowSink struct {
+	inFlight    *int32
+	maxInFlight *int32
+	err         error
+
+	lock    sync.Mutex
+	written []uint64
+	closed  bool
+}
+
+func (s *slowSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	current := atomic.AddInt32(s.inFlight, 1)
+	defer atomic.AddInt32(s.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(s.maxInFlight)
+		if current <= max || atomic.CompareAndSwapInt32(s.maxInFlight, max, current) {
+			break
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.written = append(s.written, data.Clock.Number)
+	return s.err
+}
+
+func (s *slowSink) Close() error {
+	s.closed = true
+	return s.err
+}
+
+func TestFanout(t *testing.T) {
+	tests := []struct {
+		name                string
+		concurrency         int
+		expectedMaxInFlight int32
+	}{
+		{"sequential", 1, 1},
+		{"bounded", 2, 2},
+		{"unbounded", 0, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var inFlight, maxInFlight int32
+			var sinks []Sink
+			for i := 0; i < 4; i++ {
+				sinks = append(sinks, &slowSink{inFlight: &inFlight, maxInFlight: &maxInFlight})
+			}
+
+			fanout := NewFanout(test.concurrency, sinks...)
+			for num := uint64(1); num <= 3; num++ {
+				require.NoError(t, fanout.Write(context.Background(), &pbsubstreams.BlockScopedData{Clock: &pbsubstreams.Clock{Number: num}}))
+			}
+			require.NoError(t, fanout.Close())
+
+			assert.Equal(t, test.expectedMaxInFlight, maxInFlight)
+			for _, s := range sinks {
+				assert.Equal(t, []uint64{1, 2, 3}, s.(*slowSink).written)
+				assert.True(t, s.(*slowSink).closed)
+			}
+		})
+	}
+}
+
+func TestFanout_Errors(t *testing.T) {
+	var inFlight, maxInFlight int32
+	failing := []Sink{
+		&slowSink{inFlight: &inFlight, maxInFlight: &maxInFlight},
+		&slowSink{inFlight: &inFlight, maxInFlight: &maxInFlight, err: errors.New("disk full")},
+		&slowSink{inFlight: &inFlight, maxInFlight: &maxInFlight, err: errors.New("timeout")},
+	}
+
+	fanout := NewFanout(2, failing...)
+	err := fanout.Write(context.Background(), &pbsubstreams.BlockScopedData{Clock: &pbsubstreams.Clock{Number: 1}})
+	require.Error(t, err)
+
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	assert.Contains(t, err.Error(), "sink #1")
+	assert.Contains(t, err.Error(), "disk full")
+	assert.Contains(t, err.Error(), "timeout")
+	assert.Equal(t, []uint64{1}, failing[0].(*slowSink).written, "healthy sinks are still written")
+
+	// every sink is closed whatever the errors
+	require.Error(t, fanout.Close())
+	for _, s := range failing {
+		assert.True(t, s.(*slowSink).closed)
+	}
+}
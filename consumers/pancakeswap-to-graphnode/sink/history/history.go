@@ -0,0 +1,213 @@
+// Package history keeps every value taken by a few tracked store keys in an
+// append-only file, to follow the evolution of a single pair or price without
+// logging the deltas of whole stores.
+//
+// The file is a dbin file of `sf.substreams.v1.BlockScopedData`, one message
+// per block changing a tracked key holding only the deltas of tracked keys.
+// Undos are recorded as well, readers drop the entries they revert.
+package history
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/streamingfast/dbin"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentType    = "SKH"
+	contentVersion = 1
+)
+
+// Matcher matches keys against exact keys and prefixes, written with a
+// trailing `*` like `price:0xab*`.
+type Matcher struct {
+	keys     map[string]bool
+	prefixes []string
+}
+
+func NewMatcher(patterns []string) *Matcher {
+	m := &Matcher{keys: map[string]bool{}}
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			m.prefixes = append(m.prefixes, strings.TrimSuffix(pattern, "*"))
+			continue
+		}
+		m.keys[pattern] = true
+	}
+	return m
+}
+
+func (m *Matcher) Match(key string) bool {
+	if m.keys[key] {
+		return true
+	}
+	for _, prefix := range m.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sink appends the deltas of the tracked keys to the history file.
+type Sink struct {
+	matcher *Matcher
+	file    *os.File
+	buffer  *bufio.Writer
+	writer  *dbin.Writer
+}
+
+// NewSink opens the history file at `path`, appending to it when it exists.
+func NewSink(path string, patterns []string) (*Sink, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no key tracked")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open history file %q: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("stat history file %q: %w", path, err)
+	}
+
+	s := &Sink{matcher: NewMatcher(patterns), file: file, buffer: bufio.NewWriter(file)}
+	s.writer = dbin.NewWriter(s.buffer)
+	if info.Size() == 0 {
+		if err := s.writer.WriteHeader(contentType, contentVersion); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("write history file header: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	var outputs []*pbsubstreams.ModuleOutput
+	for _, output := range data.Outputs {
+		var tracked []*pbsubstreams.StoreDelta
+		for _, delta := range output.GetStoreDeltas().GetDeltas() {
+			if s.matcher.Match(delta.Key) {
+				tracked = append(tracked, delta)
+			}
+		}
+		if len(tracked) > 0 {
+			outputs = append(outputs, &pbsubstreams.ModuleOutput{
+				Name: output.Name,
+				Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: tracked}},
+			})
+		}
+	}
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	content, err := proto.Marshal(&pbsubstreams.BlockScopedData{Outputs: outputs, Clock: data.Clock, Step: data.Step})
+	if err != nil {
+		return fmt.Errorf("marshal history of block %d: %w", data.Clock.GetNumber(), err)
+	}
+	if err := s.writer.WriteMessage(content); err != nil {
+		return fmt.Errorf("append history of block %d: %w", data.Clock.GetNumber(), err)
+	}
+	return nil
+}
+
+func (s *Sink) Flush(ctx context.Context) error {
+	return s.buffer.Flush()
+}
+
+func (s *Sink) Close() error {
+	if err := s.buffer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// Entry is a value taken by a key.
+type Entry struct {
+	BlockNum  uint64
+	BlockID   string
+	Timestamp int64
+	Store     string
+	Delta     *pbsubstreams.StoreDelta
+}
+
+// Read returns the entries of the history file at `path` for the keys matched
+// by `patterns`, in block order, without the entries that were undone. Blocks
+// appended again by a restarted run are read once.
+func Read(path string, patterns []string) ([]*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open history file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	reader := dbin.NewReader(bufio.NewReader(file))
+	kind, _, err := reader.ReadHeader()
+	if err != nil {
+		return nil, fmt.Errorf("read history file %q header: %w", path, err)
+	}
+	if kind != contentType {
+		return nil, fmt.Errorf("history file %q holds %q, expected %q", path, kind, contentType)
+	}
+
+	matcher := NewMatcher(patterns)
+	var out []*Entry
+	var next uint64
+	for {
+		content, err := reader.ReadMessage()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read history file %q: %w", path, err)
+		}
+
+		data := &pbsubstreams.BlockScopedData{}
+		if err := proto.Unmarshal(content, data); err != nil {
+			return nil, fmt.Errorf("unmarshal history file %q message: %w", path, err)
+		}
+
+		num := data.Clock.GetNumber()
+		if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+			// Undos come from the head down, the entries of later blocks are
+			// gone already.
+			for len(out) > 0 && out[len(out)-1].BlockNum >= num {
+				out = out[:len(out)-1]
+			}
+			if num < next {
+				next = num
+			}
+			continue
+		}
+		if num < next {
+			continue
+		}
+		next = num + 1
+
+		for _, output := range data.Outputs {
+			for _, delta := range output.GetStoreDeltas().GetDeltas() {
+				if !matcher.Match(delta.Key) {
+					continue
+				}
+				out = append(out, &Entry{
+					BlockNum:  num,
+					BlockID:   data.Clock.GetId(),
+					Timestamp: data.Clock.GetTimestamp().GetSeconds(),
+					Store:     output.Name,
+					Delta:     delta,
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	m := NewMatcher([]string{"price:0xaa:usd", "reserve:0xbb*"})
+
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{"price:0xaa:usd", true},
+		{"price:0xaa:bnb", false},
+		{"reserve:0xbb:0x01", true},
+		{"reserve:0xcc:0x01", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, m.Match(test.key), test.key)
+	}
+}
+
+func prices(num uint64, step pbsubstreams.ForkStep, values map[string]string) *pbsubstreams.BlockScopedData {
+	var deltas []*pbsubstreams.StoreDelta
+	for key, value := range values {
+		deltas = append(deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key, NewValue: []byte(value)})
+	}
+	return &pbsubstreams.BlockScopedData{
+		Step:  step,
+		Clock: &pbsubstreams.Clock{Number: num},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_prices", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}}},
+		},
+	}
+}
+
+func TestSink(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "history.dbin")
+	tracked := []string{"price:0xaa:usd"}
+
+	s, err := NewSink(path, tracked)
+	require.NoError(t, err)
+	require.NoError(t, s.Write(ctx, prices(10, pbsubstreams.ForkStep_STEP_NEW, map[string]string{"price:0xaa:usd": "1", "price:0xbb:usd": "5"})))
+	require.NoError(t, s.Write(ctx, prices(11, pbsubstreams.ForkStep_STEP_NEW, map[string]string{"price:0xbb:usd": "6"})))
+	require.NoError(t, s.Write(ctx, prices(12, pbsubstreams.ForkStep_STEP_NEW, map[string]string{"price:0xaa:usd": "2"})))
+	require.NoError(t, s.Write(ctx, prices(12, pbsubstreams.ForkStep_STEP_UNDO, map[string]string{"price:0xaa:usd": "2"})))
+	require.NoError(t, s.Close())
+
+	// A restarted run appends to the file, re-delivering block 10
+	s, err = NewSink(path, tracked)
+	require.NoError(t, err)
+	require.NoError(t, s.Write(ctx, prices(10, pbsubstreams.ForkStep_STEP_NEW, map[string]string{"price:0xaa:usd": "1"})))
+	require.NoError(t, s.Write(ctx, prices(12, pbsubstreams.ForkStep_STEP_NEW, map[string]string{"price:0xaa:usd": "3"})))
+	require.NoError(t, s.Close())
+
+	entries, err := Read(path, []string{"price:*"})
+	require.NoError(t, err)
+
+	var values []string
+	for _, entry := range entries {
+		assert.Equal(t, "store_prices", entry.Store)
+		assert.Equal(t, "price:0xaa:usd", entry.Delta.Key)
+		values = append(values, string(entry.Delta.NewValue))
+	}
+	assert.Equal(t, []string{"1", "3"}, values)
+	assert.Equal(t, uint64(12), entries[1].BlockNum)
+}
@@ -0,0 +1,7 @@
+package history
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.history", "github.com/streamingfast/substream-pancakeswap/sink/history")
@@ -0,0 +1,89 @@
+package jsonl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func init() {
+	sink.Register("jsonl", func(ctx context.Context, params string) (sink.Sink, error) {
+		if params == "" || params == "-" {
+			return New(os.Stdout), nil
+		}
+
+		f, err := os.OpenFile(params, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open %q: %w", params, err)
+		}
+		s := New(f)
+		s.closer = f
+		return s, nil
+	})
+}
+
+// Sink writes one JSON object per entity of the module outputs, one per line,
+// unless given another renderer, see `SetRenderer`.
+type Sink struct {
+	writer   *bufio.Writer
+	renderer sink.Renderer
+	closer   io.Closer
+	batching bool
+}
+
+func New(w io.Writer) *Sink {
+	return &Sink{
+		writer:   bufio.NewWriter(w),
+		renderer: sink.JSONRenderer{},
+	}
+}
+
+// SetRenderer changes how the entities are written, like
+// `sink.ConsoleRenderer` for a terminal.
+func (s *Sink) SetRenderer(renderer sink.Renderer) {
+	s.renderer = renderer
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	for _, record := range records {
+		if err := s.renderer.Render(s.writer, record); err != nil {
+			return fmt.Errorf("rendering record of module %q: %w", record.Module, err)
+		}
+	}
+
+	// Flushed at each block so `| jq` consumers see outputs as they come in
+	if s.batching {
+		return nil
+	}
+	return s.writer.Flush()
+}
+
+// SetBatching stops flushing the output at each block while enabled.
+func (s *Sink) SetBatching(enabled bool) {
+	s.batching = enabled
+}
+
+func (s *Sink) Flush(ctx context.Context) error {
+	return s.writer.Flush()
+}
+
+func (s *Sink) Close() error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package jsonl
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSink_Write(t *testing.T) {
+	pairs, err := anypb.New(&pcs.Pairs{Pairs: []*pcs.Pair{
+		{Address: "0xaa", Token0Address: "0x01", Token1Address: "0x02", BlockNum: 10},
+		{Address: "0xbb", Token0Address: "0x03", Token1Address: "0x04", BlockNum: 10},
+	}})
+	require.NoError(t, err)
+
+	data := &pbsubstreams.BlockScopedData{
+		Clock:  &pbsubstreams.Clock{Id: "abc", Number: 10, Timestamp: &timestamppb.Timestamp{Seconds: 1600000000}},
+		Step:   pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+		Cursor: "cursor",
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "map_pairs", Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: pairs}},
+			{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+				Deltas: []*pbsubstreams.StoreDelta{{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", NewValue: []byte("2")}},
+			}}},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	s := New(buf)
+	require.NoError(t, s.Write(context.Background(), data))
+	require.NoError(t, s.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"id":"abc:0","module":"map_pairs","block_num":10,"block_id":"abc","timestamp":"2020-09-13T12:26:40Z","step":"STEP_IRREVERSIBLE","cursor":"cursor","type":"pcs.types.v1.Pair","entity":{"address":"0xaa","token0_address":"0x01","token1_address":"0x02","block_num":"10"}}`, lines[0])
+	assert.JSONEq(t, `{"id":"abc:1","module":"map_pairs","block_num":10,"block_id":"abc","timestamp":"2020-09-13T12:26:40Z","step":"STEP_IRREVERSIBLE","cursor":"cursor","type":"pcs.types.v1.Pair","entity":{"address":"0xbb","token0_address":"0x03","token1_address":"0x04","block_num":"10"}}`, lines[1])
+	assert.JSONEq(t, `{"id":"abc:0:pairs","module":"store_totals","block_num":10,"block_id":"abc","timestamp":"2020-09-13T12:26:40Z","step":"STEP_IRREVERSIBLE","cursor":"cursor","type":"sf.substreams.v1.StoreDelta","entity":{"operation":"UPDATE","key":"pairs","new_value":"Mg=="}}`, lines[2])
+}
+
+func TestSink_Batching(t *testing.T) {
+	data := &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Id: "abc", Number: 10},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+				Deltas: []*pbsubstreams.StoreDelta{{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", NewValue: []byte("2")}},
+			}}},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	s := New(buf)
+	s.SetBatching(true)
+	require.NoError(t, s.Write(context.Background(), data))
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, s.Flush(context.Background()))
+	assert.Contains(t, buf.String(), `"key":"pairs"`)
+}
+
+func TestSink_SetRenderer(t *testing.T) {
+	data := &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Id: "abc", Number: 10},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+				Deltas: []*pbsubstreams.StoreDelta{{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", OldValue: []byte("1"), NewValue: []byte("2")}},
+			}}},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	s := New(buf)
+	sink.SetRenderer(s, sink.ConsoleRenderer{})
+	require.NoError(t, s.Write(context.Background(), data))
+	assert.Equal(t, "#10 store_totals UPDATE pairs: \"1\" -> \"2\"\n", buf.String())
+}
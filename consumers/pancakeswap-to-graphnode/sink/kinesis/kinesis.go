@@ -0,0 +1,283 @@
+// Package kinesis delivers the stores deltas to AWS, either to a Kinesis data
+// stream or to a Kinesis Data Firehose delivery stream.
+package kinesis
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxBatchRecords is the maximum number of records of a single put call,
+	// for both Kinesis and Firehose.
+	maxBatchRecords = 500
+	// maxBatchBytes is the maximum size of a single put call, Kinesis accepts
+	// 5 MiB and Firehose 4 MiB.
+	maxBatchBytes = 4 << 20
+	// maxPartitionKey is the maximum length of a Kinesis partition key.
+	maxPartitionKey = 256
+
+	maxAttempts = 5
+	retryDelay  = 100 * time.Millisecond
+)
+
+func init() {
+	sink.Register("kinesis", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(config)
+	})
+	sink.Register("firehose", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		config.Firehose = true
+		return New(config)
+	})
+}
+
+type Config struct {
+	// Stream is the name of the data stream, or of the delivery stream when
+	// `Firehose` is set.
+	Stream   string
+	Firehose bool
+	// Region and Endpoint override the ones of the AWS configuration of the
+	// environment, Endpoint being meant for local stacks.
+	Region   string
+	Endpoint string
+}
+
+// parseParams reads `<stream>[?region=<region>&endpoint=<url>]`.
+func parseParams(params string) (*Config, error) {
+	stream, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		stream, query = params[:i], params[i+1:]
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	if stream == "" {
+		return nil, fmt.Errorf("missing stream name")
+	}
+	return &Config{Stream: stream, Region: values.Get("region"), Endpoint: values.Get("endpoint")}, nil
+}
+
+// entry is a record to put, the partition key is ignored by Firehose.
+type entry struct {
+	partitionKey string
+	data         []byte
+}
+
+// putter puts a batch of records, returning the indexes of the records that
+// failed to be put, throttled ones among others, to put them again.
+type putter interface {
+	put(ctx context.Context, entries []*entry) (failed []int, err error)
+}
+
+// Sink puts every store delta, as the JSON of its `sink.Record`, in a record of
+// a Kinesis data stream, partitioned by store and key so the changes of a key
+// land on the same shard, in order. With Firehose, the records are newline
+// terminated for the objects delivered to be JSON lines.
+//
+// The deltas of a block are sent in as few batches as the limits of the API
+// allow, the records failing to be put, throttled ones among others, are sent
+// again a few times before failing the block.
+type Sink struct {
+	config *Config
+	putter putter
+}
+
+func New(config *Config) (*Sink, error) {
+	awsConfig := aws.NewConfig()
+	if config.Region != "" {
+		awsConfig = awsConfig.WithRegion(config.Region)
+	}
+	if config.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(config.Endpoint)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{Config: *awsConfig, SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return nil, fmt.Errorf("aws session: %w", err)
+	}
+
+	s := &Sink{config: config}
+	if config.Firehose {
+		s.putter = &firehosePutter{client: firehose.New(sess), stream: config.Stream}
+	} else {
+		s.putter = &kinesisPutter{client: kinesis.New(sess), stream: config.Stream}
+	}
+	return s, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	entries, err := s.entries(records)
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range batches(entries) {
+		if err := s.put(ctx, batch); err != nil {
+			return fmt.Errorf("block %d: %w", data.Clock.GetNumber(), err)
+		}
+	}
+	return nil
+}
+
+func (s *Sink) entries(records []*sink.Record) (out []*entry, err error) {
+	for _, record := range records {
+		delta, ok := record.Entity.(*pbsubstreams.StoreDelta)
+		if !ok {
+			continue
+		}
+
+		payload, err := record.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshal delta %q of %q: %w", delta.Key, record.Module, err)
+		}
+		if s.config.Firehose {
+			payload = append(payload, '\n')
+		}
+
+		out = append(out, &entry{partitionKey: partitionKey(record.Module, delta.Key), data: payload})
+	}
+	return out, nil
+}
+
+func (s *Sink) put(ctx context.Context, batch []*entry) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		failed, err := s.putter.put(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("putting %d records to %q: %w", len(batch), s.config.Stream, err)
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		if attempt == maxAttempts {
+			return fmt.Errorf("%d records still failing to be put to %q after %d attempts", len(failed), s.config.Stream, attempt)
+		}
+
+		zlog.Debug("putting failed records again", zap.String("stream", s.config.Stream), zap.Int("failed", len(failed)), zap.Duration("delay", delay))
+		retried := make([]*entry, len(failed))
+		for i, index := range failed {
+			retried[i] = batch[index]
+		}
+		batch = retried
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// batches splits `entries` in batches within the record count and size limits
+// of a put call.
+func batches(entries []*entry) (out [][]*entry) {
+	var batch []*entry
+	size := 0
+	for _, e := range entries {
+		entrySize := len(e.partitionKey) + len(e.data)
+		if len(batch) == maxBatchRecords || (len(batch) > 0 && size+entrySize > maxBatchBytes) {
+			out = append(out, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, e)
+		size += entrySize
+	}
+	if len(batch) > 0 {
+		out = append(out, batch)
+	}
+	return out
+}
+
+func partitionKey(store, key string) string {
+	partitionKey := store + ":" + key
+	if len(partitionKey) > maxPartitionKey {
+		// the shard is picked from the hash of the key, keys sharing a long
+		// prefix only share shards
+		partitionKey = partitionKey[:maxPartitionKey]
+	}
+	return partitionKey
+}
+
+type kinesisPutter struct {
+	client *kinesis.Kinesis
+	stream string
+}
+
+func (p *kinesisPutter) put(ctx context.Context, entries []*entry) (failed []int, err error) {
+	records := make([]*kinesis.PutRecordsRequestEntry, len(entries))
+	for i, e := range entries {
+		records[i] = &kinesis.PutRecordsRequestEntry{PartitionKey: aws.String(e.partitionKey), Data: e.data}
+	}
+
+	out, err := p.client.PutRecordsWithContext(ctx, &kinesis.PutRecordsInput{StreamName: aws.String(p.stream), Records: records})
+	if err != nil {
+		return nil, err
+	}
+	if aws.Int64Value(out.FailedRecordCount) == 0 {
+		return nil, nil
+	}
+	for i, result := range out.Records {
+		if result.ErrorCode != nil {
+			failed = append(failed, i)
+		}
+	}
+	return failed, nil
+}
+
+type firehosePutter struct {
+	client *firehose.Firehose
+	stream string
+}
+
+func (p *firehosePutter) put(ctx context.Context, entries []*entry) (failed []int, err error) {
+	records := make([]*firehose.Record, len(entries))
+	for i, e := range entries {
+		records[i] = &firehose.Record{Data: e.data}
+	}
+
+	out, err := p.client.PutRecordBatchWithContext(ctx, &firehose.PutRecordBatchInput{DeliveryStreamName: aws.String(p.stream), Records: records})
+	if err != nil {
+		return nil, err
+	}
+	if aws.Int64Value(out.FailedPutCount) == 0 {
+		return nil, nil
+	}
+	for i, result := range out.RequestResponses {
+		if result.ErrorCode != nil {
+			failed = append(failed, i)
+		}
+	}
+	return failed, nil
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,98 @@
+package kinesis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyPutter fails the first record of every batch put while `failures` is
+// positive.
+type flakyPutter struct {
+	failures int
+	calls    [][]string
+}
+
+func (p *flakyPutter) put(ctx context.Context, entries []*entry) (failed []int, err error) {
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.partitionKey)
+	}
+	p.calls = append(p.calls, keys)
+
+	if p.failures > 0 {
+		p.failures--
+		return []int{0}, nil
+	}
+	return nil, nil
+}
+
+func blockData(keys ...string) *pbsubstreams.BlockScopedData {
+	deltas := &pbsubstreams.StoreDeltas{}
+	for _, key := range keys {
+		deltas.Deltas = append(deltas.Deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key, NewValue: []byte("1")})
+	}
+	return &pbsubstreams.BlockScopedData{
+		Clock:   &pbsubstreams.Clock{Id: "abc", Number: 10},
+		Step:    pbsubstreams.ForkStep_STEP_NEW,
+		Outputs: []*pbsubstreams.ModuleOutput{{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: deltas}}},
+	}
+}
+
+func TestSink_Write(t *testing.T) {
+	putter := &flakyPutter{failures: 2}
+	s := &Sink{config: &Config{Stream: "deltas"}, putter: putter}
+
+	require.NoError(t, s.Write(context.Background(), blockData("pairs", "swaps")))
+	assert.Equal(t, [][]string{
+		{"store_totals:pairs", "store_totals:swaps"},
+		{"store_totals:pairs"},
+		{"store_totals:pairs"},
+	}, putter.calls)
+}
+
+func TestSink_Write_GivesUp(t *testing.T) {
+	s := &Sink{config: &Config{Stream: "deltas"}, putter: &flakyPutter{failures: maxAttempts}}
+	require.Error(t, s.Write(context.Background(), blockData("pairs")))
+}
+
+func TestSink_Firehose(t *testing.T) {
+	s := &Sink{config: &Config{Stream: "deltas", Firehose: true}}
+	records, err := sink.Records(blockData("pairs"))
+	require.NoError(t, err)
+	entries, err := s.entries(records)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, strings.HasSuffix(string(entries[0].data), "}\n"))
+}
+
+func Test_batches(t *testing.T) {
+	var entries []*entry
+	for i := 0; i < maxBatchRecords+1; i++ {
+		entries = append(entries, &entry{partitionKey: "k", data: []byte("v")})
+	}
+	assert.Len(t, batches(entries), 2)
+
+	large := &entry{partitionKey: "k", data: make([]byte, maxBatchBytes/2-1)}
+	assert.Len(t, batches([]*entry{large, large, large}), 2)
+	assert.Len(t, batches(nil), 0)
+}
+
+func Test_partitionKey(t *testing.T) {
+	assert.Equal(t, "store_prices:price:0xaa:usd", partitionKey("store_prices", "price:0xaa:usd"))
+	assert.Len(t, partitionKey("store_prices", strings.Repeat("a", 300)), maxPartitionKey)
+}
+
+func Test_parseParams(t *testing.T) {
+	config, err := parseParams("deltas?region=us-east-1&endpoint=http://localhost:4566")
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Stream: "deltas", Region: "us-east-1", Endpoint: "http://localhost:4566"}, config)
+
+	_, err = parseParams("")
+	require.Error(t, err)
+}
@@ -0,0 +1,7 @@
+package kinesis
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.kinesis", "github.com/streamingfast/substream-pancakeswap/sink/kinesis")
@@ -0,0 +1,7 @@
+package mqtt
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.mqtt", "github.com/streamingfast/substream-pancakeswap/sink/mqtt")
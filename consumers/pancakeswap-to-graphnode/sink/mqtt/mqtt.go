@@ -0,0 +1,228 @@
+// Package mqtt publishes the stores deltas to an MQTT broker, for dashboards
+// and lightweight consumers subscribing to the keys they follow, like the
+// prices of a pair, without gRPC or a streaming platform.
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBroker      = "tcp://localhost:1883"
+	defaultTopicPrefix = "substreams"
+	defaultClientID    = "substreams-pancakeswap"
+
+	connectTimeout = 30 * time.Second
+)
+
+func init() {
+	sink.Register("mqtt", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(config)
+	})
+}
+
+type Config struct {
+	Broker      string
+	ClientID    string
+	TopicPrefix string
+	// KeyLevels is the number of leading segments of the keys kept as topic
+	// levels, all of them when negative, none when 0 for a topic per store.
+	KeyLevels int
+	QoS       byte
+	// Retain has the broker keep the last message of every topic, for the
+	// clients subscribing later to start from the current values.
+	Retain bool
+	// Modules are the stores whose deltas are published, all of them when
+	// empty.
+	Modules  []string
+	Username string
+	Password string
+}
+
+// parseParams reads `<broker url>[?topic-prefix=<prefix>&key-levels=<count>&qos=0|1|2&retain=true&modules=<store>,...&client-id=<id>&username=<name>&password-env=<var>]`,
+// the url defaults to a local broker.
+func parseParams(params string) (*Config, error) {
+	broker, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		broker, query = params[:i], params[i+1:]
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	config := &Config{
+		Broker:      broker,
+		ClientID:    values.Get("client-id"),
+		TopicPrefix: values.Get("topic-prefix"),
+		KeyLevels:   -1,
+		Username:    values.Get("username"),
+	}
+	if config.Broker == "" {
+		config.Broker = defaultBroker
+	}
+	if config.ClientID == "" {
+		config.ClientID = defaultClientID
+	}
+	if config.TopicPrefix == "" {
+		config.TopicPrefix = defaultTopicPrefix
+	}
+
+	if v := values.Get("key-levels"); v != "" {
+		if config.KeyLevels, err = strconv.Atoi(v); err != nil || config.KeyLevels < 0 {
+			return nil, fmt.Errorf("invalid key-levels %q", v)
+		}
+	}
+	if v := values.Get("qos"); v != "" {
+		qos, err := strconv.ParseUint(v, 10, 8)
+		if err != nil || qos > 2 {
+			return nil, fmt.Errorf("invalid qos %q, expected 0, 1 or 2", v)
+		}
+		config.QoS = byte(qos)
+	}
+	if v := values.Get("retain"); v != "" {
+		if config.Retain, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid retain %q: %w", v, err)
+		}
+	}
+	if v := values.Get("modules"); v != "" {
+		config.Modules = strings.Split(v, ",")
+	}
+	if name := values.Get("password-env"); name != "" {
+		if config.Password = os.Getenv(name); config.Password == "" {
+			return nil, fmt.Errorf("password-env %q is empty", name)
+		}
+	}
+	return config, nil
+}
+
+// Sink publishes every store delta, as the JSON of its `sink.Record`, to the
+// topic `<prefix>/<store>/<key segments>`, the `:` separated segments of the
+// key becoming topic levels: the prices of a pair are received by subscribing
+// to `substreams/store_prices/price/<pair>/#`. Undo steps are published to the
+// topic of the delta they revert.
+type Sink struct {
+	config  *Config
+	client  paho.Client
+	modules map[string]bool
+}
+
+type message struct {
+	topic   string
+	payload []byte
+}
+
+func New(config *Config) (*Sink, error) {
+	opts := paho.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetAutoReconnect(true).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			zlog.Warn("mqtt connection lost", zap.String("broker", config.Broker), zap.Error(err))
+		})
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		return nil, fmt.Errorf("connecting to %q: timed out after %s", config.Broker, connectTimeout)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("connecting to %q: %w", config.Broker, err)
+	}
+
+	s := &Sink{config: config, client: client}
+	if len(config.Modules) > 0 {
+		s.modules = map[string]bool{}
+		for _, module := range config.Modules {
+			s.modules[module] = true
+		}
+	}
+
+	zlog.Info("publishing to mqtt broker", zap.String("broker", config.Broker), zap.String("topic_prefix", config.TopicPrefix), zap.Uint8("qos", config.QoS))
+	return s, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	msgs, err := s.messages(records)
+	if err != nil {
+		return err
+	}
+
+	tokens := make([]paho.Token, len(msgs))
+	for i, msg := range msgs {
+		tokens[i] = s.client.Publish(msg.topic, s.config.QoS, s.config.Retain, msg.payload)
+	}
+
+	for i, token := range tokens {
+		select {
+		case <-token.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := token.Error(); err != nil {
+			return fmt.Errorf("publishing to %q: %w", msgs[i].topic, err)
+		}
+	}
+	return nil
+}
+
+func (s *Sink) messages(records []*sink.Record) (out []*message, err error) {
+	for _, record := range records {
+		delta, ok := record.Entity.(*pbsubstreams.StoreDelta)
+		if !ok || (s.modules != nil && !s.modules[record.Module]) {
+			continue
+		}
+
+		payload, err := record.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshal delta %q of %q: %w", delta.Key, record.Module, err)
+		}
+		out = append(out, &message{topic: topic(s.config.TopicPrefix, record.Module, delta.Key, s.config.KeyLevels), payload: payload})
+	}
+	return out, nil
+}
+
+// topicEscaper replaces the wildcards, not allowed in the topics published to.
+var topicEscaper = strings.NewReplacer("+", "_", "#", "_", "/", "_")
+
+func topic(prefix, store, key string, levels int) string {
+	segments := strings.Split(key, ":")
+	if levels >= 0 && levels < len(segments) {
+		segments = segments[:levels]
+	}
+
+	topic := prefix + "/" + topicEscaper.Replace(store)
+	for _, segment := range segments {
+		topic += "/" + topicEscaper.Replace(segment)
+	}
+	return topic
+}
+
+func (s *Sink) Close() error {
+	// leaves a second to the messages in flight
+	s.client.Disconnect(1000)
+	return nil
+}
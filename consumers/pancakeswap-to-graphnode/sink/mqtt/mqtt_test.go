@@ -0,0 +1,63 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func Test_parseParams(t *testing.T) {
+	t.Setenv("MQTT_PASSWORD", "secret")
+
+	tests := []struct {
+		name        string
+		in          string
+		expected    *Config
+		expectedErr bool
+	}{
+		{"defaults", "", &Config{Broker: "tcp://localhost:1883", ClientID: "substreams-pancakeswap", TopicPrefix: "substreams", KeyLevels: -1}, false},
+		{"all", "ssl://broker:8883?topic-prefix=bsc&key-levels=2&qos=1&retain=true&modules=store_prices,store_reserves&client-id=edge&username=dash&password-env=MQTT_PASSWORD", &Config{
+			Broker: "ssl://broker:8883", ClientID: "edge", TopicPrefix: "bsc", KeyLevels: 2, QoS: 1, Retain: true,
+			Modules: []string{"store_prices", "store_reserves"}, Username: "dash", Password: "secret",
+		}, false},
+		{"invalid qos", "?qos=3", nil, true},
+		{"invalid key levels", "?key-levels=-1", nil, true},
+		{"empty password", "?password-env=MQTT_UNSET_PASSWORD", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseParams(test.in)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_topic(t *testing.T) {
+	assert.Equal(t, "substreams/store_prices/price/0xaa/usd", topic("substreams", "store_prices", "price:0xaa:usd", -1))
+	assert.Equal(t, "substreams/store_prices/price", topic("substreams", "store_prices", "price:0xaa:usd", 1))
+	assert.Equal(t, "substreams/store_prices", topic("substreams", "store_prices", "price:0xaa:usd", 0))
+	assert.Equal(t, "substreams/store_totals/a_b_c_", topic("substreams", "store_totals", "a+b#c/", -1))
+}
+
+func TestSink_messages(t *testing.T) {
+	s := &Sink{config: &Config{TopicPrefix: "substreams", KeyLevels: -1}, modules: map[string]bool{"store_prices": true}}
+	msgs, err := s.messages([]*sink.Record{
+		{Module: "map_pairs", Entity: wrapperspb.Bytes([]byte("ignored"))},
+		{Module: "store_totals", Entity: &pbsubstreams.StoreDelta{Key: "pairs"}},
+		{ID: "abc:1:price:0xaa:usd", Module: "store_prices", Entity: &pbsubstreams.StoreDelta{Key: "price:0xaa:usd", NewValue: []byte("1.5")}},
+	})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "substreams/store_prices/price/0xaa/usd", msgs[0].topic)
+	assert.Contains(t, string(msgs[0].payload), `"id":"abc:1:price:0xaa:usd"`)
+}
@@ -0,0 +1,7 @@
+package natsjs
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.natsjs", "github.com/streamingfast/substream-pancakeswap/sink/natsjs")
@@ -0,0 +1,160 @@
+package natsjs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStream        = "SUBSTREAMS"
+	defaultSubjectPrefix = "substreams"
+)
+
+func init() {
+	sink.Register("nats", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(config)
+	})
+}
+
+type Config struct {
+	URL           string
+	Stream        string
+	SubjectPrefix string
+}
+
+// parseParams reads `<nats url>[?stream=<name>&subject-prefix=<prefix>]`, the
+// url defaults to the local NATS server.
+func parseParams(params string) (*Config, error) {
+	addr, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		addr, query = params[:i], params[i+1:]
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	config := &Config{URL: addr, Stream: values.Get("stream"), SubjectPrefix: values.Get("subject-prefix")}
+	if config.URL == "" {
+		config.URL = nats.DefaultURL
+	}
+	if config.Stream == "" {
+		config.Stream = defaultStream
+	}
+	if config.SubjectPrefix == "" {
+		config.SubjectPrefix = defaultSubjectPrefix
+	}
+	return config, nil
+}
+
+// Sink publishes store deltas to NATS JetStream, one subject per store
+// (`<prefix>.<store module>`). Each message carries a `Nats-Msg-Id` made of the
+// store, the step and the record ID, see `sink.EntityID`, so JetStream drops
+// the duplicates sent when a block is replayed after a restart.
+type Sink struct {
+	config *Config
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+}
+
+func New(config *Config) (*Sink, error) {
+	conn, err := nats.Connect(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %q: %w", config.URL, err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("jetstream context: %w", err)
+	}
+
+	_, err = js.StreamInfo(config.Stream)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		zlog.Info("creating jetstream stream", zap.String("stream", config.Stream), zap.String("subjects", config.SubjectPrefix+".>"))
+		_, err = js.AddStream(&nats.StreamConfig{Name: config.Stream, Subjects: []string{config.SubjectPrefix + ".>"}})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("stream %q: %w", config.Stream, err)
+	}
+
+	return &Sink{config: config, conn: conn, js: js}, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	var futures []nats.PubAckFuture
+	for _, record := range records {
+		delta, ok := record.Entity.(*pbsubstreams.StoreDelta)
+		if !ok {
+			continue
+		}
+
+		msg, err := s.message(record, delta)
+		if err != nil {
+			return err
+		}
+
+		future, err := s.js.PublishMsgAsync(msg, nats.MsgId(dedupeID(record)))
+		if err != nil {
+			return fmt.Errorf("publishing to %q: %w", msg.Subject, err)
+		}
+		futures = append(futures, future)
+	}
+
+	for _, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			return fmt.Errorf("publishing to %q: %w", future.Msg().Subject, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (s *Sink) message(record *sink.Record, delta *pbsubstreams.StoreDelta) (*nats.Msg, error) {
+	payload, err := record.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal delta %q of %q: %w", delta.Key, record.Module, err)
+	}
+
+	msg := nats.NewMsg(s.config.SubjectPrefix + "." + record.Module)
+	msg.Data = payload
+	msg.Header.Set("Block-Num", strconv.FormatUint(record.BlockNum, 10))
+	msg.Header.Set("Block-Id", record.BlockID)
+	msg.Header.Set("Step", record.Step.String())
+	return msg, nil
+}
+
+// dedupeID tells the undo of a delta apart from the delta, they share the
+// record ID.
+func dedupeID(record *sink.Record) string {
+	return fmt.Sprintf("%s:%s:%s", record.Module, record.Step, record.ID)
+}
+
+func (s *Sink) Close() error {
+	s.conn.Close()
+	return nil
+}
@@ -0,0 +1,45 @@
+package natsjs
+
+import (
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    *Config
+		expectedErr bool
+	}{
+		{"defaults", "", &Config{URL: "nats://127.0.0.1:4222", Stream: "SUBSTREAMS", SubjectPrefix: "substreams"}, false},
+		{"url only", "nats://nats:4222", &Config{URL: "nats://nats:4222", Stream: "SUBSTREAMS", SubjectPrefix: "substreams"}, false},
+		{"all", "nats://nats:4222?stream=PCS&subject-prefix=pcs", &Config{URL: "nats://nats:4222", Stream: "PCS", SubjectPrefix: "pcs"}, false},
+		{"invalid query", "nats://nats:4222?stream=%zz", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseParams(test.in)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_dedupeID(t *testing.T) {
+	record := &sink.Record{ID: "0a:1:pair:1", Module: "store_pairs", Step: pbsubstreams.ForkStep_STEP_NEW}
+	undo := *record
+	undo.Step = pbsubstreams.ForkStep_STEP_UNDO
+
+	assert.Equal(t, "store_pairs:STEP_NEW:0a:1:pair:1", dedupeID(record))
+	assert.NotEqual(t, dedupeID(record), dedupeID(&undo), "the undo of a delta isn't dropped as its duplicate")
+}
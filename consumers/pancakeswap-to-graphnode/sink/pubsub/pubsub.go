@@ -0,0 +1,143 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+// maxBatchSize is the maximum number of messages accepted by a single publish call.
+const maxBatchSize = 1000
+
+func init() {
+	sink.Register("pubsub", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(ctx, config)
+	})
+}
+
+type Config struct {
+	// Topic is the fully qualified topic name, `projects/<project>/topics/<topic>`.
+	Topic string
+	// Endpoint overrides the API endpoint, ordering keys are only honored by
+	// regional endpoints like `https://us-east1-pubsub.googleapis.com/`.
+	Endpoint string
+}
+
+// parseParams reads `<project>/<topic>[?endpoint=<url>]`, the fully qualified
+// `projects/<project>/topics/<topic>` form is also accepted.
+func parseParams(params string) (*Config, error) {
+	topic, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		topic, query = params[:i], params[i+1:]
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	parts := strings.Split(topic, "/")
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		topic = fmt.Sprintf("projects/%s/topics/%s", parts[0], parts[1])
+	case len(parts) == 4 && parts[0] == "projects" && parts[2] == "topics":
+	default:
+		return nil, fmt.Errorf("invalid topic %q, expected <project>/<topic>", topic)
+	}
+
+	return &Config{Topic: topic, Endpoint: values.Get("endpoint")}, nil
+}
+
+// Sink publishes store deltas to a Google Cloud Pub/Sub topic. The ordering key
+// of each message is the store name and delta key so subscriptions with message
+// ordering enabled receive the changes of a given key in order. Block number,
+// block ID, step and store name are set as attributes for subscription filters,
+// the record ID, see `sink.EntityID`, as `id` for subscribers to drop the
+// duplicates of replayed blocks.
+type Sink struct {
+	config *Config
+	topics *pubsub.ProjectsTopicsService
+}
+
+func New(ctx context.Context, config *Config) (*Sink, error) {
+	var opts []option.ClientOption
+	if config.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(config.Endpoint))
+	}
+
+	svc, err := pubsub.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("pubsub client: %w", err)
+	}
+
+	return &Sink{config: config, topics: svc.Projects.Topics}, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	msgs, err := messages(records)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(msgs); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+
+		_, err := s.topics.Publish(s.config.Topic, &pubsub.PublishRequest{Messages: msgs[start:end]}).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("publishing %d messages to %q: %w", end-start, s.config.Topic, err)
+		}
+	}
+
+	return nil
+}
+
+func messages(records []*sink.Record) (out []*pubsub.PubsubMessage, err error) {
+	for _, record := range records {
+		delta, ok := record.Entity.(*pbsubstreams.StoreDelta)
+		if !ok {
+			continue
+		}
+
+		payload, err := record.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshal delta %q of %q: %w", delta.Key, record.Module, err)
+		}
+
+		out = append(out, &pubsub.PubsubMessage{
+			Data:        base64.StdEncoding.EncodeToString(payload),
+			OrderingKey: record.Module + ":" + delta.Key,
+			Attributes: map[string]string{
+				"id":        record.ID,
+				"block_num": strconv.FormatUint(record.BlockNum, 10),
+				"block_id":  record.BlockID,
+				"step":      record.Step.String(),
+				"store":     record.Module,
+			},
+		})
+	}
+	return out, nil
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,50 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func Test_parseParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		expected    *Config
+		expectedErr bool
+	}{
+		{"short", "my-project/deltas", &Config{Topic: "projects/my-project/topics/deltas"}, false},
+		{"qualified", "projects/my-project/topics/deltas", &Config{Topic: "projects/my-project/topics/deltas"}, false},
+		{"endpoint", "my-project/deltas?endpoint=https://us-east1-pubsub.googleapis.com/", &Config{Topic: "projects/my-project/topics/deltas", Endpoint: "https://us-east1-pubsub.googleapis.com/"}, false},
+		{"missing topic", "my-project", nil, true},
+		{"empty", "", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseParams(test.in)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_messages(t *testing.T) {
+	msgs, err := messages([]*sink.Record{
+		{Module: "map_pairs", BlockNum: 10, Entity: wrapperspb.Bytes([]byte("ignored"))},
+		{ID: "abc:3:pairs", Module: "store_totals", BlockNum: 10, BlockID: "abc", Step: pbsubstreams.ForkStep_STEP_NEW, Entity: &pbsubstreams.StoreDelta{Key: "pairs", Ordinal: 3}},
+	})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, "store_totals:pairs", msgs[0].OrderingKey)
+	assert.Equal(t, map[string]string{"id": "abc:3:pairs", "block_num": "10", "block_id": "abc", "step": "STEP_NEW", "store": "store_totals"}, msgs[0].Attributes)
+}
@@ -0,0 +1,7 @@
+package queue
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.queue", "github.com/streamingfast/substream-pancakeswap/sink/queue")
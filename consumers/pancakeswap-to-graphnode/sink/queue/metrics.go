@@ -0,0 +1,17 @@
+package queue
+
+import (
+	"github.com/streamingfast/dmetrics"
+)
+
+var metrics = dmetrics.NewSet()
+
+var (
+	queuedBlocks  = metrics.NewGaugeVec("sink_queue_blocks", []string{"output"}, "blocks waiting in memory to be written to an output")
+	spilledBlocks = metrics.NewGaugeVec("sink_queue_spilled_blocks", []string{"output"}, "blocks spilled to disk waiting to be written to an output")
+	droppedBlocks = metrics.NewCounterVec("sink_queue_dropped_blocks", []string{"output"}, "blocks dropped because an output fell behind")
+)
+
+func init() {
+	metrics.Register()
+}
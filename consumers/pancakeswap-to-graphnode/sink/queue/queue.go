@@ -0,0 +1,400 @@
+// Package queue decouples the outputs from the stream: a Queue accepts blocks
+// right away and writes them to its sink from a goroutine of its own, so a slow
+// output doesn't hold back the others nor the stream until its queue is full.
+// What happens then is up to the Policy.
+//
+// Blocks accepted by a queue aren't written yet: they are lost when the
+// process dies, `Flush` waits for them. Outputs kept in step with a database
+// through a commit journal, or checkpointed by the leader election, are
+// flushed before the cursor is recorded, so they don't skip blocks.
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// Policy tells what a full queue does with a new block.
+type Policy string
+
+const (
+	// Block makes the writer wait for room, holding back the stream, and so
+	// every other output, like unqueued outputs do.
+	Block Policy = "block"
+	// Drop discards the block, meant for best effort outputs tolerating gaps,
+	// like a live feed.
+	Drop Policy = "drop"
+	// Spill appends the block to a file, read back once the output caught up.
+	Spill Policy = "spill"
+)
+
+// ParsePolicy returns the policy named `name`.
+func ParsePolicy(name string) (Policy, error) {
+	switch policy := Policy(name); policy {
+	case Block, Drop, Spill:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid queue policy %q, expected one of: block, drop, spill", name)
+}
+
+type Config struct {
+	// Size is the number of blocks held in memory.
+	Size   int
+	Policy Policy
+	// SpillDir is where the Spill policy writes its file, the temporary
+	// directory when empty.
+	SpillDir string
+
+	// The sink is flushed every `FlushBlocks` blocks and every `FlushInterval`,
+	// either of them can be 0. When one is set, the sink batches its writes
+	// in between, see `sink.Batcher`, it flushes at each block otherwise.
+	FlushBlocks   int
+	FlushInterval time.Duration
+}
+
+// Queue is a sink writing to another one asynchronously. Like any sink, it
+// must not be called concurrently.
+type Queue struct {
+	name   string
+	sink   sink.Sink
+	config Config
+
+	lock     sync.Mutex
+	items    []*pbsubstreams.BlockScopedData
+	spill    *spillFile
+	spilled  int
+	inFlight bool
+	err      error
+
+	dropped  uint64
+	dropping bool
+
+	// sinkLock serializes the calls to the sink made by the worker and by
+	// `Flush`, `SetBatching` and `Close`.
+	sinkLock  sync.Mutex
+	unflushed int
+
+	// wake tells the worker a block was queued, progress tells the writer a
+	// block was written.
+	wake     chan struct{}
+	progress chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New starts a queue in front of `s`, `name` identifies it in the logs and
+// metrics.
+func New(name string, s sink.Sink, config Config) (*Queue, error) {
+	if config.Size < 1 {
+		return nil, fmt.Errorf("queue size must be positive, got %d", config.Size)
+	}
+	if _, err := ParsePolicy(string(config.Policy)); err != nil {
+		return nil, err
+	}
+
+	q := &Queue{
+		name:     name,
+		sink:     s,
+		config:   config,
+		wake:     make(chan struct{}, 1),
+		progress: make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if config.Policy == Spill {
+		spill, err := newSpillFile(config.SpillDir, name)
+		if err != nil {
+			return nil, err
+		}
+		q.spill = spill
+	}
+	if q.batching() {
+		sink.SetBatching(s, true)
+	}
+
+	go q.run()
+	return q, nil
+}
+
+// Write queues `data`, applying the policy when the queue is full. It fails
+// with the error of the sink once a previous block failed to be written.
+func (q *Queue) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	for {
+		q.lock.Lock()
+		if q.err != nil {
+			q.lock.Unlock()
+			return q.err
+		}
+
+		// once spilling, blocks go to the file until it's read back, to keep
+		// them in order
+		if q.spilled == 0 && len(q.items) < q.config.Size {
+			q.items = append(q.items, data)
+			queuedBlocks.SetInt(len(q.items), q.name)
+			q.endDropping()
+			q.lock.Unlock()
+			notify(q.wake)
+			return nil
+		}
+
+		switch q.config.Policy {
+		case Drop:
+			q.drop(data)
+			q.lock.Unlock()
+			return nil
+		case Spill:
+			err := q.spill.append(data)
+			if err == nil {
+				q.spilled++
+				spilledBlocks.SetInt(q.spilled, q.name)
+			}
+			q.lock.Unlock()
+			notify(q.wake)
+			return err
+		}
+		q.lock.Unlock()
+
+		select {
+		case <-q.progress:
+		case <-q.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Flush waits for the queued blocks to be written and flushes the sink.
+func (q *Queue) Flush(ctx context.Context) error {
+	if err := q.wait(ctx); err != nil {
+		return err
+	}
+
+	q.sinkLock.Lock()
+	defer q.sinkLock.Unlock()
+	q.unflushed = 0
+	return sink.Flush(ctx, q.sink)
+}
+
+// SetBatching waits for the queued blocks to be written and turns batching on
+// or off for the sink. It stays on when the queue flushes the sink itself.
+func (q *Queue) SetBatching(enabled bool) {
+	if err := q.wait(context.Background()); err != nil {
+		// reported by the next write
+		return
+	}
+
+	q.sinkLock.Lock()
+	defer q.sinkLock.Unlock()
+	sink.SetBatching(q.sink, enabled || q.batching())
+}
+
+// Close writes the queued blocks and closes the sink, which is closed even
+// when they fail to be written.
+func (q *Queue) Close() error {
+	err := q.wait(context.Background())
+	close(q.stop)
+	<-q.done
+
+	q.sinkLock.Lock()
+	closeErr := q.sink.Close()
+	q.sinkLock.Unlock()
+
+	if q.spill != nil {
+		if spillErr := q.spill.remove(); spillErr != nil {
+			zlog.Warn("removing spill file", zap.String("output", q.name), zap.Error(spillErr))
+		}
+	}
+	if q.dropped > 0 {
+		zlog.Warn("blocks dropped by the output queue", zap.String("output", q.name), zap.Uint64("dropped", q.dropped))
+	}
+
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Dropped returns the number of blocks dropped by the Drop policy.
+func (q *Queue) Dropped() uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.dropped
+}
+
+func (q *Queue) batching() bool {
+	return q.config.FlushBlocks > 0 || q.config.FlushInterval > 0
+}
+
+// wait returns once every queued block is written, or one failed to be.
+func (q *Queue) wait(ctx context.Context) error {
+	for {
+		q.lock.Lock()
+		err, idle := q.err, len(q.items) == 0 && q.spilled == 0 && !q.inFlight
+		q.lock.Unlock()
+		if err != nil {
+			return err
+		}
+		if idle {
+			return nil
+		}
+
+		select {
+		case <-q.progress:
+		case <-q.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (q *Queue) drop(data *pbsubstreams.BlockScopedData) {
+	q.dropped++
+	droppedBlocks.Inc(q.name)
+	if !q.dropping {
+		q.dropping = true
+		zlog.Warn("output queue full, dropping blocks", zap.String("output", q.name), zap.Uint64("block_num", data.Clock.GetNumber()))
+	}
+}
+
+func (q *Queue) endDropping() {
+	if q.dropping {
+		q.dropping = false
+		zlog.Info("output queue caught up, blocks are queued again", zap.String("output", q.name), zap.Uint64("dropped", q.dropped))
+	}
+}
+
+// run writes the queued blocks to the sink until the queue is closed or a
+// block fails to be written.
+func (q *Queue) run() {
+	defer close(q.done)
+
+	var tick <-chan time.Time
+	if q.config.FlushInterval > 0 {
+		ticker := time.NewTicker(q.config.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		data, err := q.next()
+		if err != nil {
+			q.fail(err)
+			return
+		}
+
+		if data == nil {
+			select {
+			case <-q.wake:
+			case <-tick:
+				if err := q.flush(); err != nil {
+					q.fail(err)
+					return
+				}
+			case <-q.stop:
+				return
+			}
+			continue
+		}
+
+		if err := q.write(data, tick); err != nil {
+			q.fail(err)
+			return
+		}
+
+		q.lock.Lock()
+		q.inFlight = false
+		q.lock.Unlock()
+		notify(q.progress)
+	}
+}
+
+// next pops the next block, from memory first since the spill file only holds
+// blocks queued after them. It's nil when the queue is empty.
+func (q *Queue) next() (*pbsubstreams.BlockScopedData, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.items) > 0 {
+		data := q.items[0]
+		q.items[0] = nil
+		q.items = q.items[1:]
+		queuedBlocks.SetInt(len(q.items), q.name)
+		q.inFlight = true
+		return data, nil
+	}
+
+	if q.spilled > 0 {
+		data, err := q.spill.next()
+		if err != nil {
+			return nil, err
+		}
+		q.spilled--
+		spilledBlocks.SetInt(q.spilled, q.name)
+		if q.spilled == 0 {
+			if err := q.spill.reset(); err != nil {
+				return nil, err
+			}
+		}
+		q.inFlight = true
+		return data, nil
+	}
+
+	return nil, nil
+}
+
+func (q *Queue) write(data *pbsubstreams.BlockScopedData, tick <-chan time.Time) error {
+	q.sinkLock.Lock()
+	err := q.sink.Write(context.Background(), data)
+	q.unflushed++
+	full := q.config.FlushBlocks > 0 && q.unflushed >= q.config.FlushBlocks
+	q.sinkLock.Unlock()
+	if err != nil {
+		return fmt.Errorf("writing block %d: %w", data.Clock.GetNumber(), err)
+	}
+
+	if full {
+		return q.flush()
+	}
+	select {
+	case <-tick:
+		return q.flush()
+	default:
+	}
+	return nil
+}
+
+func (q *Queue) flush() error {
+	q.sinkLock.Lock()
+	defer q.sinkLock.Unlock()
+	if q.unflushed == 0 {
+		return nil
+	}
+
+	if err := sink.Flush(context.Background(), q.sink); err != nil {
+		return fmt.Errorf("flushing: %w", err)
+	}
+	q.unflushed = 0
+	return nil
+}
+
+func (q *Queue) fail(err error) {
+	zlog.Error("output failed, its queue is stopped", zap.String("output", q.name), zap.Error(err))
+
+	q.lock.Lock()
+	q.err = fmt.Errorf("%s output: %w", q.name, err)
+	q.lock.Unlock()
+}
+
+// notify signals `ch` without blocking, a pending signal is enough.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,215 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedSink writes a block once it's let through `gate`.
+type gatedSink struct {
+	gate chan struct{}
+	err  error
+
+	lock     sync.Mutex
+	written  []uint64
+	flushes  int
+	batching bool
+	closed   bool
+}
+
+func newGatedSink() *gatedSink {
+	return &gatedSink{gate: make(chan struct{}, 100)}
+}
+
+func (s *gatedSink) open(blocks int) {
+	for i := 0; i < blocks; i++ {
+		s.gate <- struct{}{}
+	}
+}
+
+func (s *gatedSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	<-s.gate
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.written = append(s.written, data.Clock.Number)
+	return nil
+}
+
+func (s *gatedSink) Flush(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.flushes++
+	return nil
+}
+
+func (s *gatedSink) SetBatching(enabled bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batching = enabled
+}
+
+func (s *gatedSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *gatedSink) blocks() []uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]uint64(nil), s.written...)
+}
+
+// queued returns the number of blocks in memory and spilled.
+func queued(q *Queue) (int, int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.items), q.spilled
+}
+
+func block(num uint64) *pbsubstreams.BlockScopedData {
+	return &pbsubstreams.BlockScopedData{Clock: &pbsubstreams.Clock{Number: num}}
+}
+
+// writeAll writes blocks `from` to `to` included, it fails the test when a
+// write doesn't return in time.
+func writeAll(t *testing.T, q *Queue, from, to uint64) {
+	t.Helper()
+	for num := from; num <= to; num++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		require.NoError(t, q.Write(ctx, block(num)))
+		cancel()
+	}
+}
+
+func TestQueue_Block(t *testing.T) {
+	s := newGatedSink()
+	q, err := New("jsonl", s, Config{Size: 2, Policy: Block})
+	require.NoError(t, err)
+
+	// one block in flight, two queued
+	writeAll(t, q, 1, 1)
+	require.Eventually(t, func() bool { inMemory, _ := queued(q); return inMemory == 0 }, time.Second, time.Millisecond)
+	writeAll(t, q, 2, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Write(ctx, block(4)), context.DeadlineExceeded, "full")
+
+	go s.open(1)
+	writeAll(t, q, 4, 4)
+
+	s.open(3)
+	require.NoError(t, q.Flush(context.Background()))
+	assert.Equal(t, []uint64{1, 2, 3, 4}, s.blocks())
+	assert.Equal(t, 1, s.flushes)
+	assert.False(t, s.batching)
+
+	require.NoError(t, q.Close())
+	assert.True(t, s.closed)
+}
+
+func TestQueue_Drop(t *testing.T) {
+	s := newGatedSink()
+	q, err := New("nats", s, Config{Size: 1, Policy: Drop})
+	require.NoError(t, err)
+
+	writeAll(t, q, 1, 1)
+	require.Eventually(t, func() bool { inMemory, _ := queued(q); return inMemory == 0 }, time.Second, time.Millisecond)
+	writeAll(t, q, 2, 5)
+	assert.Equal(t, uint64(3), q.Dropped())
+
+	s.open(2)
+	require.NoError(t, q.Flush(context.Background()))
+	writeAll(t, q, 6, 6)
+	s.open(1)
+	require.NoError(t, q.Close())
+	assert.Equal(t, []uint64{1, 2, 6}, s.blocks())
+}
+
+func TestQueue_Spill(t *testing.T) {
+	dir := t.TempDir()
+	s := newGatedSink()
+	q, err := New("flight::8815", s, Config{Size: 2, Policy: Spill, SpillDir: dir})
+	require.NoError(t, err)
+
+	writeAll(t, q, 1, 6)
+	_, spilled := queued(q)
+	assert.Greater(t, spilled, 0)
+
+	s.open(6)
+	require.NoError(t, q.Flush(context.Background()))
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, s.blocks())
+
+	// the file is reused once read back
+	writeAll(t, q, 7, 12)
+	s.open(6)
+	require.NoError(t, q.Close())
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, s.blocks())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Empty(t, files, "spill file removed")
+}
+
+func TestQueue_FlushPolicy(t *testing.T) {
+	s := newGatedSink()
+	q, err := New("sqlite", s, Config{Size: 10, Policy: Block, FlushBlocks: 3})
+	require.NoError(t, err)
+	assert.True(t, s.batching)
+
+	s.open(7)
+	writeAll(t, q, 1, 7)
+	require.Eventually(t, func() bool { return len(s.blocks()) == 7 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, s.flushes)
+
+	// batching stays on when --live-after-backfill turns it off
+	q.SetBatching(false)
+	assert.True(t, s.batching)
+	require.NoError(t, q.Close())
+
+	s = newGatedSink()
+	q, err = New("sqlite", s, Config{Size: 10, Policy: Block, FlushInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	s.open(1)
+	writeAll(t, q, 1, 1)
+	require.Eventually(t, func() bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return s.flushes == 1
+	}, time.Second, time.Millisecond)
+	require.NoError(t, q.Close())
+}
+
+func TestQueue_Errors(t *testing.T) {
+	s := newGatedSink()
+	s.err = errors.New("connection refused")
+	q, err := New("pubsub", s, Config{Size: 2, Policy: Block})
+	require.NoError(t, err)
+
+	s.open(1)
+	writeAll(t, q, 1, 1)
+	<-q.done
+
+	err = q.Write(context.Background(), block(2))
+	assert.ErrorIs(t, err, s.err)
+	assert.Contains(t, err.Error(), "pubsub output: writing block 1")
+	assert.ErrorIs(t, q.Flush(context.Background()), s.err)
+	assert.ErrorIs(t, q.Close(), s.err)
+	assert.True(t, s.closed)
+
+	_, err = New("jsonl", s, Config{Size: 1, Policy: "wait"})
+	assert.EqualError(t, err, `invalid queue policy "wait", expected one of: block, drop, spill`)
+	_, err = New("jsonl", s, Config{Policy: Block})
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/streamingfast/dbin"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// spillFile holds the blocks of a full queue, dbin messages of
+// `sf.substreams.v1.BlockScopedData` without header: it's only read back by
+// the process writing it, and removed on close. It's emptied every time all
+// its blocks are read.
+type spillFile struct {
+	path   string
+	file   *os.File
+	writer *dbin.Writer
+
+	readFile *os.File
+	reader   *dbin.Reader
+}
+
+func newSpillFile(dir string, name string) (*spillFile, error) {
+	file, err := os.CreateTemp(dir, "sink-queue-"+sanitize(name)+"-*.dbin")
+	if err != nil {
+		return nil, fmt.Errorf("create spill file: %w", err)
+	}
+
+	readFile, err := os.Open(file.Name())
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("open spill file: %w", err)
+	}
+
+	return &spillFile{
+		path:     file.Name(),
+		file:     file,
+		writer:   dbin.NewWriter(file),
+		readFile: readFile,
+		reader:   dbin.NewReader(bufio.NewReader(readFile)),
+	}, nil
+}
+
+func (f *spillFile) append(data *pbsubstreams.BlockScopedData) error {
+	content, err := proto.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal block %d: %w", data.Clock.GetNumber(), err)
+	}
+	if err := f.writer.WriteMessage(content); err != nil {
+		return fmt.Errorf("spill block %d: %w", data.Clock.GetNumber(), err)
+	}
+	return nil
+}
+
+// next reads the next block, there must be one: the file is written without
+// buffering, so everything appended so far can be read.
+func (f *spillFile) next() (*pbsubstreams.BlockScopedData, error) {
+	content, err := f.reader.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("read spill file: %w", err)
+	}
+
+	data := &pbsubstreams.BlockScopedData{}
+	if err := proto.Unmarshal(content, data); err != nil {
+		return nil, fmt.Errorf("unmarshal spilled block: %w", err)
+	}
+	return data, nil
+}
+
+// reset empties the file once all its blocks were read.
+func (f *spillFile) reset() error {
+	if err := f.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate spill file: %w", err)
+	}
+	if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind spill file: %w", err)
+	}
+	if _, err := f.readFile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind spill file: %w", err)
+	}
+	f.reader = dbin.NewReader(bufio.NewReader(f.readFile))
+	return nil
+}
+
+func (f *spillFile) remove() error {
+	f.readFile.Close()
+	f.file.Close()
+	return os.Remove(f.path)
+}
+
+// sanitize keeps the letters and digits of `name`, for it to be part of a
+// file name.
+func sanitize(name string) string {
+	out := []rune(name)
+	for i, r := range out {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,154 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/streamingfast/substream-pancakeswap/pb/pcs/database/v1"
+	_ "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Record is a single entity of a module output along with the metadata of the
+// block it was produced at. Outputs wrapping a single repeated message field
+// (like `pcs.types.v1.Pairs` or store deltas) are split in one record per element.
+type Record struct {
+	// ID identifies the entity within the outputs of its module, see `EntityID`.
+	ID        string
+	Module    string
+	BlockNum  uint64
+	BlockID   string
+	Timestamp time.Time
+	Step      pbsubstreams.ForkStep
+	Cursor    string
+	Entity    proto.Message
+}
+
+// Records flattens the outputs of a block into records. Map outputs of a type
+// that is not known to this binary are kept as raw bytes.
+func Records(data *pbsubstreams.BlockScopedData) (out []*Record, err error) {
+	for _, output := range data.Outputs {
+		var msg proto.Message
+		switch o := output.Data.(type) {
+		case *pbsubstreams.ModuleOutput_MapOutput:
+			if o.MapOutput == nil {
+				continue
+			}
+
+			msg, err = anypb.UnmarshalNew(o.MapOutput, proto.UnmarshalOptions{})
+			if err != nil {
+				msg = wrapperspb.Bytes(o.MapOutput.Value)
+			}
+		case *pbsubstreams.ModuleOutput_StoreDeltas:
+			if o.StoreDeltas == nil {
+				continue
+			}
+			msg = o.StoreDeltas
+		default:
+			continue
+		}
+
+		blockID := data.Clock.GetId()
+		for i, entity := range entities(msg) {
+			record := &Record{
+				ID:       EntityID(blockID, i, entity),
+				Module:   output.Name,
+				BlockNum: data.Clock.GetNumber(),
+				BlockID:  blockID,
+				Step:     data.Step,
+				Cursor:   data.Cursor,
+				Entity:   entity,
+			}
+			if ts := data.Clock.GetTimestamp(); ts != nil {
+				record.Timestamp = ts.AsTime()
+			}
+			out = append(out, record)
+		}
+	}
+
+	return out, nil
+}
+
+// EntityID returns the idempotency key of the `index`th entity of a module
+// output at block `blockID`, the same every time the block is streamed so
+// sinks upsert or deduplicate by it, and a retried or replayed block never
+// counts an entity twice. It's, in order of preference:
+//
+//   - the `id` field of the entity, like `<chain>:<block hash>:<trx hash>:<log index>`
+//     for the swaps, mints and burns of `pcs.types.v1.Event`,
+//   - `<block id>:<ordinal>:<key>` for store deltas,
+//   - `<block id>:<index>` otherwise.
+//
+// Undo steps carry the ID of what they revert.
+func EntityID(blockID string, index int, entity proto.Message) string {
+	if delta, ok := entity.(*pbsubstreams.StoreDelta); ok {
+		return fmt.Sprintf("%s:%d:%s", blockID, delta.Ordinal, delta.Key)
+	}
+
+	m := entity.ProtoReflect()
+	if field := m.Descriptor().Fields().ByName("id"); field != nil && field.Kind() == protoreflect.StringKind && !field.IsList() {
+		if id := m.Get(field).String(); id != "" {
+			return id
+		}
+	}
+	return fmt.Sprintf("%s:%d", blockID, index)
+}
+
+func entities(msg proto.Message) []proto.Message {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	if fields.Len() != 1 {
+		return []proto.Message{msg}
+	}
+
+	field := fields.Get(0)
+	if !field.IsList() || field.Kind() != protoreflect.MessageKind {
+		return []proto.Message{msg}
+	}
+
+	list := m.Get(field).List()
+	out := make([]proto.Message, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		out[i] = list.Get(i).Message().Interface()
+	}
+	return out
+}
+
+type jsonRecord struct {
+	ID        string          `json:"id"`
+	Module    string          `json:"module"`
+	BlockNum  uint64          `json:"block_num"`
+	BlockID   string          `json:"block_id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Step      string          `json:"step"`
+	Cursor    string          `json:"cursor,omitempty"`
+	Type      string          `json:"type"`
+	Entity    json.RawMessage `json:"entity"`
+}
+
+// MarshalJSON renders the record as a flat JSON object, the entity is rendered
+// with the protobuf JSON mapping.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	entity, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(r.Entity)
+	if err != nil {
+		return nil, fmt.Errorf("marshal entity: %w", err)
+	}
+
+	return json.Marshal(&jsonRecord{
+		ID:        r.ID,
+		Module:    r.Module,
+		BlockNum:  r.BlockNum,
+		BlockID:   r.BlockID,
+		Timestamp: r.Timestamp,
+		Step:      r.Step.String(),
+		Cursor:    r.Cursor,
+		Type:      string(r.Entity.ProtoReflect().Descriptor().FullName()),
+		Entity:    entity,
+	})
+}
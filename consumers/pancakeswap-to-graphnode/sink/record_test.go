@@ -0,0 +1,18 @@
+package sink
+
+import (
+	"testing"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestEntityID(t *testing.T) {
+	assert.Equal(t, "bsc:0x01:0x02:3", EntityID("abc", 4, &pbpcs.Event{Id: "bsc:0x01:0x02:3"}))
+	assert.Equal(t, "abc:4", EntityID("abc", 4, &pbpcs.Event{}), "no id")
+	assert.Equal(t, "abc:4", EntityID("abc", 4, &pbpcs.Pair{Address: "0xaa"}), "no id field")
+	assert.Equal(t, "abc:0", EntityID("abc", 0, wrapperspb.Bytes([]byte{1})))
+	assert.Equal(t, "abc:7:pair:0xaa", EntityID("abc", 2, &pbsubstreams.StoreDelta{Ordinal: 7, Key: "pair:0xaa"}))
+}
@@ -0,0 +1,115 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// Renderer writes records for people or programs to read, like the records
+// printed to stdout by the `jsonl` output, see `NewRenderer`.
+type Renderer interface {
+	Render(w io.Writer, record *Record) error
+}
+
+// The formats of `NewRenderer`.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+	FormatText    = "text"
+)
+
+// NewRenderer returns the renderer of `format`:
+//
+//   - `json`, a JSON object per line, see `Record.MarshalJSON`,
+//   - `console`, a line per record for a terminal, store deltas printed as
+//     `<key>: <old value> -> <new value>`,
+//   - `text`, the protobuf text format of the entity under a comment line
+//     with the metadata of the record.
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case FormatJSON:
+		return JSONRenderer{}, nil
+	case FormatConsole:
+		return ConsoleRenderer{}, nil
+	case FormatText:
+		return TextRenderer{}, nil
+	}
+	return nil, fmt.Errorf("invalid format %q, expected %q, %q or %q", format, FormatJSON, FormatConsole, FormatText)
+}
+
+// Rendering is implemented by the sinks writing records in the format of a
+// renderer, like the `jsonl` output.
+type Rendering interface {
+	SetRenderer(renderer Renderer)
+}
+
+// SetRenderer makes `s` write its records with `renderer` when it supports it,
+// it's a no-op otherwise.
+func SetRenderer(s Sink, renderer Renderer) {
+	if rendering, ok := s.(Rendering); ok {
+		rendering.SetRenderer(renderer)
+	}
+}
+
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(w io.Writer, record *Record) error {
+	return json.NewEncoder(w).Encode(record)
+}
+
+type ConsoleRenderer struct{}
+
+func (ConsoleRenderer) Render(w io.Writer, record *Record) error {
+	step := ""
+	if record.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		step = " (undo)"
+	}
+
+	if delta, ok := record.Entity.(*pbsubstreams.StoreDelta); ok {
+		var change string
+		switch delta.Operation {
+		case pbsubstreams.StoreDelta_CREATE:
+			change = displayValue(delta.NewValue)
+		case pbsubstreams.StoreDelta_DELETE:
+			change = displayValue(delta.OldValue) + " -> (deleted)"
+		default:
+			change = displayValue(delta.OldValue) + " -> " + displayValue(delta.NewValue)
+		}
+		_, err := fmt.Fprintf(w, "#%d%s %s %s %s: %s\n", record.BlockNum, step, record.Module, delta.Operation, delta.Key, change)
+		return err
+	}
+
+	entity, err := prototext.MarshalOptions{}.Marshal(record.Entity)
+	if err != nil {
+		return fmt.Errorf("marshal entity: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "#%d%s %s %s {%s}\n", record.BlockNum, step, record.Module, record.Entity.ProtoReflect().Descriptor().Name(), entity)
+	return err
+}
+
+// displayValue quotes the store values that are text, most of them being
+// numbers, and prints the others as hex.
+func displayValue(value []byte) string {
+	if utf8.Valid(value) {
+		return strconv.Quote(string(value))
+	}
+	return fmt.Sprintf("0x%x", value)
+}
+
+type TextRenderer struct{}
+
+func (TextRenderer) Render(w io.Writer, record *Record) error {
+	entity, err := prototext.MarshalOptions{Multiline: true}.Marshal(record.Entity)
+	if err != nil {
+		return fmt.Errorf("marshal entity: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "# %s #%d %s %s %s %s\n%s\n", record.Module, record.BlockNum, record.BlockID, record.Step, record.Entity.ProtoReflect().Descriptor().FullName(), record.ID, entity)
+	return err
+}
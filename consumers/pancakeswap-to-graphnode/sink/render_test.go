@@ -0,0 +1,70 @@
+package sink
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	pcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+func renderAll(t *testing.T, renderer Renderer, records ...*Record) string {
+	t.Helper()
+	out := &bytes.Buffer{}
+	for _, record := range records {
+		require.NoError(t, renderer.Render(out, record))
+	}
+	return out.String()
+}
+
+func TestConsoleRenderer(t *testing.T) {
+	delta := func(op pbsubstreams.StoreDelta_Operation, key string, old, new []byte) *Record {
+		return &Record{Module: "store_reserves", BlockNum: 10, Entity: &pbsubstreams.StoreDelta{Operation: op, Key: key, OldValue: old, NewValue: new}}
+	}
+
+	undo := delta(pbsubstreams.StoreDelta_UPDATE, "price:0xaa", []byte("1.5"), []byte("1.2"))
+	undo.Step = pbsubstreams.ForkStep_STEP_UNDO
+
+	assert.Equal(t, `#10 store_reserves CREATE reserve:0xaa:0: "100"
+#10 store_reserves UPDATE reserve:0xaa:0: "100" -> "90.5"
+#10 store_reserves DELETE pair:0xaa: 0x00ff -> (deleted)
+#10 (undo) store_reserves UPDATE price:0xaa: "1.5" -> "1.2"
+`, renderAll(t, ConsoleRenderer{},
+		delta(pbsubstreams.StoreDelta_CREATE, "reserve:0xaa:0", nil, []byte("100")),
+		delta(pbsubstreams.StoreDelta_UPDATE, "reserve:0xaa:0", []byte("100"), []byte("90.5")),
+		delta(pbsubstreams.StoreDelta_DELETE, "pair:0xaa", []byte{0x00, 0xff}, nil),
+		undo,
+	))
+
+	out := renderAll(t, ConsoleRenderer{}, &Record{Module: "map_pairs", BlockNum: 11, Entity: &pcs.Pair{Address: "0xaa"}})
+	assert.True(t, strings.HasPrefix(out, "#11 map_pairs Pair {"), out)
+	assert.Contains(t, out, `"0xaa"`)
+}
+
+func TestTextRenderer(t *testing.T) {
+	pair := &pcs.Pair{Address: "0xaa", Token0Address: "0x01", BlockNum: 11}
+	out := renderAll(t, TextRenderer{}, &Record{ID: "abc:0", Module: "map_pairs", BlockNum: 11, BlockID: "abc", Step: pbsubstreams.ForkStep_STEP_NEW, Entity: pair})
+
+	lines := strings.SplitN(out, "\n", 2)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "# map_pairs #11 abc STEP_NEW pcs.types.v1.Pair abc:0", lines[0])
+
+	decoded := &pcs.Pair{}
+	require.NoError(t, prototext.Unmarshal([]byte(lines[1]), decoded))
+	assert.True(t, proto.Equal(pair, decoded))
+}
+
+func TestNewRenderer(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatConsole, FormatText} {
+		_, err := NewRenderer(format)
+		assert.NoError(t, err)
+	}
+
+	_, err := NewRenderer("yaml")
+	assert.EqualError(t, err, `invalid format "yaml", expected "json", "console" or "text"`)
+}
@@ -0,0 +1,132 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Sink receives the module outputs of every block streamed from substreams.
+type Sink interface {
+	Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error
+	Close() error
+}
+
+// Flusher is implemented by sinks buffering outputs across blocks, `Flush`
+// makes everything written so far durable, like `Close` does, without closing
+// the sink.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Flush flushes `s` when it buffers outputs, it's a no-op otherwise.
+func Flush(ctx context.Context, s Sink) error {
+	if flusher, ok := s.(Flusher); ok {
+		return flusher.Flush(ctx)
+	}
+	return nil
+}
+
+// Batcher is implemented by sinks able to write blocks in bulk while
+// backfilling, trading latency for throughput: while batching, blocks are
+// only guaranteed to be written once flushed.
+type Batcher interface {
+	SetBatching(enabled bool)
+}
+
+// SetBatching turns batching on or off for `s` when it supports it, it's a
+// no-op otherwise. Blocks batched so far are written on the next flush.
+func SetBatching(s Sink, enabled bool) {
+	if batcher, ok := s.(Batcher); ok {
+		batcher.SetBatching(enabled)
+	}
+}
+
+// PermanentError marks the errors writing the same block again can't fix,
+// like a row the database rejects or a schema mismatch, as opposed to the
+// database being unreachable. See the `dlq` package.
+type PermanentError struct {
+	Err error
+}
+
+// Permanent marks `err` as permanent, nil stays nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent tells whether `err` was marked as permanent.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+type replayKey struct{}
+
+// WithReplay marks the blocks written with the returned context as replayed,
+// blocks written before that failed to be: sinks keeping a cursor don't move
+// it back, and the values written since by later blocks are kept.
+func WithReplay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayKey{}, true)
+}
+
+// IsReplay tells whether the block written with `ctx` is replayed, see
+// `WithReplay`.
+func IsReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
+
+// Factory creates a sink out of the parameters found after the scheme of an
+// output specification, `params` is empty when none were provided.
+type Factory func(ctx context.Context, params string) (Sink, error)
+
+var registry = map[string]Factory{}
+
+// Register makes a sink available under `scheme`, it's meant to be called from
+// the `init()` function of the sink's package.
+func Register(scheme string, factory Factory) {
+	if _, found := registry[scheme]; found {
+		panic(fmt.Sprintf("sink %q already registered", scheme))
+	}
+
+	registry[scheme] = factory
+}
+
+// New creates a sink from an output specification of the form `<scheme>[:<params>]`,
+// for example `jsonl` or `jsonl:./out.jsonl`.
+func New(ctx context.Context, spec string) (Sink, error) {
+	scheme, params := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		scheme, params = spec[:i], spec[i+1:]
+	}
+
+	factory, found := registry[scheme]
+	if !found {
+		return nil, fmt.Errorf("unknown output %q, valid outputs are: %s", scheme, strings.Join(Schemes(), ", "))
+	}
+
+	s, err := factory(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s sink: %w", scheme, err)
+	}
+
+	return s, nil
+}
+
+func Schemes() (out []string) {
+	for scheme := range registry {
+		out = append(out, scheme)
+	}
+	sort.Strings(out)
+	return
+}
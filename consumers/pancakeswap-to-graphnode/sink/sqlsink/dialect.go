@@ -0,0 +1,27 @@
+package sqlsink
+
+// Dialect holds the statements that differ between the SQL databases the sink
+// writes to. Every store table has the `key`, `value`, `block_num` and `ordinal`
+// columns, statements take their parameters in that order.
+type Dialect interface {
+	// DriverName is the `database/sql` driver to open the DSN with.
+	DriverName() string
+	CreateCursorTable() string
+	// SaveCursor takes the cursor and the block number.
+	SaveCursor() string
+	LoadCursor() string
+	CreateTable(table string) string
+	Upsert(table string) string
+	// Delete takes the key only.
+	Delete(table string) string
+	// BlockNum takes the key and selects the `block_num` of its row.
+	BlockNum(table string) string
+	// Value converts a store value to the parameter written to the `value`
+	// column, `valueType` is the value type of the store in the store schema,
+	// empty when unknown.
+	Value(valueType string, value []byte) interface{}
+	// Permanent tells whether a statement failed because of what it writes,
+	// like a value its column rejects or a table not matching the sink,
+	// rather than because of the database.
+	Permanent(err error) bool
+}
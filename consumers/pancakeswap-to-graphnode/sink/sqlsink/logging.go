@@ -0,0 +1,7 @@
+package sqlsink
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.sql", "github.com/streamingfast/substream-pancakeswap/sink/sqlsink")
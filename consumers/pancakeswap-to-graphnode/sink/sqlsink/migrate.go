@@ -0,0 +1,97 @@
+package sqlsink
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"go.uber.org/zap"
+)
+
+// Migration is the DDL bringing a Postgres database to a store schema, its
+// version is derived from the statements so a schema change yields a new
+// migration.
+type Migration struct {
+	Version    string
+	Statements []string
+}
+
+// NewPostgresMigration returns the migration creating the tables of all the
+// stores of `s`, see `Postgres.StoreDDL`.
+func NewPostgresMigration(s *schema.Schema) (*Migration, error) {
+	m := &Migration{Statements: []string{Postgres{}.CreateCursorTable()}}
+	for _, name := range s.Names() {
+		statements, err := Postgres{}.StoreDDL(s.Stores[name])
+		if err != nil {
+			return nil, err
+		}
+		m.Statements = append(m.Statements, statements...)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(m.Statements, ";\n")))
+	m.Version = hex.EncodeToString(sum[:8])
+	return m, nil
+}
+
+// SQL returns the statements as a script.
+func (m *Migration) SQL() string {
+	return strings.Join(m.Statements, ";\n") + ";\n"
+}
+
+// Apply runs the migration in a single transaction, recording it in the
+// `_migrations` table. It returns false when the migration was already
+// applied.
+func (m *Migration) Apply(ctx context.Context, dsn string) (applied bool, err error) {
+	db, err := sql.Open(Postgres{}.DriverName(), dsn)
+	if err != nil {
+		return false, fmt.Errorf("open postgres database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS "_migrations" (version TEXT PRIMARY KEY, applied_at TIMESTAMPTZ NOT NULL DEFAULT now(), statements TEXT NOT NULL)`); err != nil {
+		return false, fmt.Errorf("creating migrations table: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil || !applied {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				zlog.Warn("rollback failed", zap.Error(rollbackErr))
+			}
+		}
+	}()
+
+	// Concurrent inits wait for each other instead of both migrating
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE "_migrations" IN EXCLUSIVE MODE`); err != nil {
+		return false, fmt.Errorf("locking migrations table: %w", err)
+	}
+
+	var found int
+	if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM "_migrations" WHERE version = $1`, m.Version).Scan(&found); err != nil {
+		return false, fmt.Errorf("checking migration %s: %w", m.Version, err)
+	}
+	if found > 0 {
+		return false, nil
+	}
+
+	for _, statement := range m.Statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return false, fmt.Errorf("migration %s, statement %q: %w", m.Version, statement, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO "_migrations" (version, statements) VALUES ($1, $2)`, m.Version, m.SQL()); err != nil {
+		return false, fmt.Errorf("recording migration %s: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit migration %s: %w", m.Version, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,60 @@
+package sqlsink
+
+import (
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgres_StoreDDL(t *testing.T) {
+	pattern := func(raw string) *schema.Pattern {
+		p, err := schema.ParsePattern(raw)
+		require.NoError(t, err)
+		return p
+	}
+
+	store := &schema.Store{
+		Name:      "store_volumes",
+		ValueType: "bigfloat",
+		Doc:       "volumes in the pair's tokens",
+		Keys:      []*schema.Pattern{pattern("pair:{pair}:{volume=usd|token0}"), pattern("pair_day:{day}:{pair}:usd")},
+	}
+
+	statements, err := Postgres{}.StoreDDL(store)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "store_volumes" (key TEXT PRIMARY KEY, value NUMERIC, block_num BIGINT NOT NULL, ordinal BIGINT NOT NULL)`,
+		`COMMENT ON TABLE "store_volumes" IS 'volumes in the pair''s tokens'`,
+		`ALTER TABLE "store_volumes" DROP COLUMN IF EXISTS "pair"`,
+		`ALTER TABLE "store_volumes" ADD COLUMN "pair" TEXT GENERATED ALWAYS AS (CASE WHEN key ~ '^pair:[^:]+:(usd|token0)$' THEN split_part(key, ':', 2) WHEN key ~ '^pair_day:[^:]+:[^:]+:usd$' THEN split_part(key, ':', 3) END) STORED`,
+		`CREATE INDEX IF NOT EXISTS "store_volumes_pair_idx" ON "store_volumes" ("pair")`,
+		`ALTER TABLE "store_volumes" DROP COLUMN IF EXISTS "volume"`,
+		`ALTER TABLE "store_volumes" ADD COLUMN "volume" TEXT GENERATED ALWAYS AS (CASE WHEN key ~ '^pair:[^:]+:(usd|token0)$' THEN split_part(key, ':', 3) END) STORED`,
+		`CREATE INDEX IF NOT EXISTS "store_volumes_volume_idx" ON "store_volumes" ("volume")`,
+		`ALTER TABLE "store_volumes" DROP COLUMN IF EXISTS "day"`,
+		`ALTER TABLE "store_volumes" ADD COLUMN "day" TEXT GENERATED ALWAYS AS (CASE WHEN key ~ '^pair_day:[^:]+:[^:]+:usd$' THEN split_part(key, ':', 2) END) STORED`,
+		`CREATE INDEX IF NOT EXISTS "store_volumes_day_idx" ON "store_volumes" ("day")`,
+	}, statements)
+
+	_, err = Postgres{}.StoreDDL(&schema.Store{Name: "store_x", ValueType: "string", Keys: []*schema.Pattern{pattern("x:{value}")}})
+	assert.Error(t, err, "component clashing with the value column")
+}
+
+func TestNewPostgresMigration(t *testing.T) {
+	s, err := schema.Load("../../../../modules/pancakeswap/schema.yaml")
+	require.NoError(t, err)
+
+	first, err := NewPostgresMigration(s)
+	require.NoError(t, err)
+	second, err := NewPostgresMigration(s)
+	require.NoError(t, err)
+	assert.Equal(t, first.Version, second.Version, "stable version")
+	assert.Contains(t, first.SQL(), `CREATE TABLE IF NOT EXISTS "store_totals" (key TEXT PRIMARY KEY, value BIGINT`)
+
+	s.Stores["store_totals"].ValueType = "bigint"
+	changed, err := NewPostgresMigration(s)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Version, changed.Version)
+}
@@ -0,0 +1,84 @@
+package sqlsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func init() {
+	sink.Register("sqlite", func(ctx context.Context, params string) (sink.Sink, error) {
+		if params == "" {
+			return nil, fmt.Errorf("sqlite database path is required, e.g. 'sqlite:./out.db'")
+		}
+		return New(ctx, SQLite{}, params)
+	})
+}
+
+// SQLite writes the stores in a local database file, handy to inspect the
+// stores of a short backfill with the `sqlite3` shell. The driver is pure Go,
+// the sink is available in the builds without cgo.
+type SQLite struct{}
+
+func (SQLite) DriverName() string { return "sqlite" }
+
+func (SQLite) CreateCursorTable() string {
+	return `CREATE TABLE IF NOT EXISTS "_cursor" (id INTEGER PRIMARY KEY, cursor TEXT NOT NULL, block_num INTEGER NOT NULL)`
+}
+
+func (SQLite) SaveCursor() string {
+	return `INSERT INTO "_cursor" (id, cursor, block_num) VALUES (1, ?, ?) ON CONFLICT (id) DO UPDATE SET cursor = excluded.cursor, block_num = excluded.block_num`
+}
+
+func (SQLite) LoadCursor() string {
+	return `SELECT cursor FROM "_cursor" WHERE id = 1`
+}
+
+// CreateTable leaves the `value` column without type so values are kept as
+// TEXT when the store holds strings and as BLOB otherwise.
+func (SQLite) CreateTable(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q (key TEXT PRIMARY KEY, value, block_num INTEGER NOT NULL, ordinal INTEGER NOT NULL)`, table)
+}
+
+func (SQLite) Upsert(table string) string {
+	return fmt.Sprintf(`INSERT INTO %q (key, value, block_num, ordinal) VALUES (?, ?, ?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value, block_num = excluded.block_num, ordinal = excluded.ordinal`, table)
+}
+
+func (SQLite) Delete(table string) string {
+	return fmt.Sprintf(`DELETE FROM %q WHERE key = ?`, table)
+}
+
+func (SQLite) BlockNum(table string) string {
+	return fmt.Sprintf(`SELECT block_num FROM %q WHERE key = ?`, table)
+}
+
+// Permanent is true for constraint violations, values too big, and SQL
+// errors, which are about the tables, like a missing column.
+func (SQLite) Permanent(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	// the primary code of the extended ones, like SQLITE_CONSTRAINT_PRIMARYKEY
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_CONSTRAINT, sqlite3.SQLITE_MISMATCH, sqlite3.SQLITE_TOOBIG, sqlite3.SQLITE_ERROR:
+		return true
+	}
+	return false
+}
+
+// Value keeps textual store values (string, int64 and bigfloat stores) as
+// strings so they are readable and comparable in SQL, binary stores are kept
+// as BLOB even when a value happens to be valid UTF-8.
+func (SQLite) Value(valueType string, in []byte) interface{} {
+	if valueType == "bytes" || strings.HasPrefix(valueType, "proto:") || !utf8.Valid(in) {
+		return in
+	}
+	return string(in)
+}
@@ -0,0 +1,180 @@
+package sqlsink
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSink_SQLite(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := New(ctx, SQLite{}, filepath.Join(t.TempDir(), "out.db"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	block := func(num uint64, step pbsubstreams.ForkStep, deltas ...*pbsubstreams.StoreDelta) *pbsubstreams.BlockScopedData {
+		return &pbsubstreams.BlockScopedData{
+			Clock:  &pbsubstreams.Clock{Number: num},
+			Step:   step,
+			Cursor: "cursor-" + step.String(),
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}}},
+			},
+		}
+	}
+
+	require.NoError(t, s.Write(ctx, block(10, pbsubstreams.ForkStep_STEP_NEW,
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "pairs", NewValue: []byte("1"), Ordinal: 1},
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "swaps", NewValue: []byte("1"), Ordinal: 2},
+	)))
+	update := block(11, pbsubstreams.ForkStep_STEP_NEW,
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", OldValue: []byte("1"), NewValue: []byte("2"), Ordinal: 1},
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_DELETE, Key: "swaps", OldValue: []byte("1"), Ordinal: 2},
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "burns", NewValue: []byte{0xff, 0x00}, Ordinal: 3},
+	)
+	require.NoError(t, s.Write(ctx, update))
+
+	assert.Equal(t, map[string]interface{}{"pairs": "2", "burns": []byte{0xff, 0x00}}, rows(t, s))
+
+	update.Step = pbsubstreams.ForkStep_STEP_UNDO
+	update.Cursor = "cursor-undo"
+	require.NoError(t, s.Write(ctx, update))
+	assert.Equal(t, map[string]interface{}{"pairs": "1", "swaps": "1"}, rows(t, s))
+
+	cursor, err := s.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cursor-undo", cursor)
+}
+
+func TestSink_UndoOrder(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := New(ctx, SQLite{}, filepath.Join(t.TempDir(), "out.db"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	output := func(deltas ...*pbsubstreams.StoreDelta) *pbsubstreams.ModuleOutput {
+		return &pbsubstreams.ModuleOutput{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}}}
+	}
+	require.NoError(t, s.Write(ctx, &pbsubstreams.BlockScopedData{
+		Clock:   &pbsubstreams.Clock{Number: 10},
+		Step:    pbsubstreams.ForkStep_STEP_NEW,
+		Outputs: []*pbsubstreams.ModuleOutput{output(&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "pairs", NewValue: []byte("1")})},
+	}))
+
+	// the key is written by both outputs, the last one written is undone first
+	update := &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Number: 11},
+		Step:  pbsubstreams.ForkStep_STEP_NEW,
+		Outputs: []*pbsubstreams.ModuleOutput{
+			output(&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", OldValue: []byte("1"), NewValue: []byte("2")}),
+			output(&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", OldValue: []byte("2"), NewValue: []byte("3")}),
+		},
+	}
+	require.NoError(t, s.Write(ctx, update))
+	assert.Equal(t, map[string]interface{}{"pairs": "3"}, rows(t, s))
+
+	update.Step = pbsubstreams.ForkStep_STEP_UNDO
+	require.NoError(t, s.Write(ctx, update))
+	assert.Equal(t, map[string]interface{}{"pairs": "1"}, rows(t, s))
+}
+
+func TestSink_Batching(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := New(ctx, SQLite{}, filepath.Join(t.TempDir(), "out.db"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	s.SetBatching(true)
+	for i := uint64(1); i <= 3; i++ {
+		require.NoError(t, s.Write(ctx, &pbsubstreams.BlockScopedData{
+			Clock:  &pbsubstreams.Clock{Number: i},
+			Step:   pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+			Cursor: fmt.Sprintf("cursor-%d", i),
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+					{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "pairs", NewValue: []byte(fmt.Sprint(i))},
+				}}}},
+			},
+		}))
+	}
+
+	cursor, err := s.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", cursor, "blocks are only committed on flush")
+
+	require.NoError(t, s.Flush(ctx))
+	cursor, err = s.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cursor-3", cursor)
+	assert.Equal(t, map[string]interface{}{"pairs": "3"}, rows(t, s))
+}
+
+func TestSink_PermanentErrors(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := New(ctx, SQLite{}, filepath.Join(t.TempDir(), "out.db"))
+	require.NoError(t, err)
+	defer s.Close()
+	_, err = s.db.Exec(`CREATE TABLE "store_totals" (key TEXT PRIMARY KEY, value CHECK (value != 'bad'), block_num INTEGER NOT NULL, ordinal INTEGER NOT NULL)`)
+	require.NoError(t, err)
+
+	block := func(num uint64, key, value string) *pbsubstreams.BlockScopedData {
+		return &pbsubstreams.BlockScopedData{
+			Clock:  &pbsubstreams.Clock{Number: num},
+			Step:   pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+			Cursor: fmt.Sprintf("cursor-%d", num),
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_totals", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+					{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key, NewValue: []byte(value)},
+				}}}},
+			},
+		}
+	}
+
+	s.SetBatching(true)
+	require.NoError(t, s.Write(ctx, block(1, "swaps", "1")))
+	err = s.Write(ctx, block(2, "pairs", "bad"))
+	require.Error(t, err)
+	assert.True(t, sink.IsPermanent(err))
+	require.NoError(t, s.Write(ctx, block(3, "pairs", "3")))
+	require.NoError(t, s.Flush(ctx))
+	assert.Equal(t, map[string]interface{}{"swaps": "1", "pairs": "3"}, rows(t, s), "block 1 kept")
+
+	// replayed once fixed, block 3 wrote pairs since
+	replay := block(2, "pairs", "2")
+	replay.Outputs[0].GetStoreDeltas().Deltas = append(replay.Outputs[0].GetStoreDeltas().Deltas, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "burns", NewValue: []byte("2")})
+	require.NoError(t, s.Write(sink.WithReplay(ctx), replay))
+	require.NoError(t, s.Flush(ctx))
+	assert.Equal(t, map[string]interface{}{"swaps": "1", "pairs": "3", "burns": "2"}, rows(t, s))
+
+	cursor, err := s.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cursor-3", cursor)
+}
+
+func rows(t *testing.T, s *Sink) map[string]interface{} {
+	t.Helper()
+
+	res, err := s.db.Query(`SELECT key, value FROM "store_totals"`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	out := map[string]interface{}{}
+	for res.Next() {
+		var key string
+		var value interface{}
+		require.NoError(t, res.Scan(&key, &value))
+		out[key] = value
+	}
+	require.NoError(t, res.Err())
+	return out
+}
@@ -0,0 +1,298 @@
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/streamingfast/substream-pancakeswap/schema"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// Sink mirrors the stores it receives deltas for into SQL tables, one table
+// per store module keyed by the store key. Each block is applied in a single
+// transaction along with the cursor, so a restart resumes from the last block
+// fully written.
+//
+// While batching, blocks are applied in a transaction spanning up to
+// `BatchBlocks` blocks, committed once full or on `Flush`. A block failing on
+// its rows, a permanent error (see `sink.Permanent`), is rolled back alone:
+// the blocks batched before it stay in the transaction.
+//
+// Replayed blocks (see `sink.WithReplay`) don't save their cursor, and skip
+// the keys written by a later block.
+type Sink struct {
+	db         *sql.DB
+	dialect    Dialect
+	tables     map[string]bool
+	valueTypes map[string]string
+
+	batching bool
+	tx       *sql.Tx
+	txBlocks int
+}
+
+// BatchBlocks is the number of blocks per transaction while batching.
+const BatchBlocks = 1000
+
+func New(ctx context.Context, dialect Dialect, dsn string) (*Sink, error) {
+	db, err := sql.Open(dialect.DriverName(), dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s database: %w", dialect.DriverName(), err)
+	}
+
+	if _, err := db.ExecContext(ctx, dialect.CreateCursorTable()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating cursor table: %w", err)
+	}
+
+	return &Sink{db: db, dialect: dialect, tables: map[string]bool{}, valueTypes: map[string]string{}}, nil
+}
+
+// SetSchema writes the values of the stores declared in `s` as their value
+// type, see `Dialect.Value`.
+func (s *Sink) SetSchema(storeSchema *schema.Schema) {
+	for name, store := range storeSchema.Stores {
+		s.valueTypes[name] = store.ValueType
+	}
+}
+
+// Cursor returns the cursor of the last block written, empty when nothing was written yet.
+func (s *Sink) Cursor(ctx context.Context) (string, error) {
+	var cursor string
+	err := s.db.QueryRowContext(ctx, s.dialect.LoadCursor()).Scan(&cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return cursor, err
+}
+
+// State returns the rows of the tables written by the sink, per table and key.
+func (s *Sink) State(ctx context.Context) (map[string]map[string]string, error) {
+	out := map[string]map[string]string{}
+	for table := range s.tables {
+		rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT key, value FROM %q`, table))
+		if err != nil {
+			return nil, fmt.Errorf("reading table %q: %w", table, err)
+		}
+
+		values := map[string]string{}
+		for rows.Next() {
+			var key string
+			var value []byte
+			if err := rows.Scan(&key, &value); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("reading table %q: %w", table, err)
+			}
+			values[key] = string(value)
+		}
+		err = rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading table %q: %w", table, err)
+		}
+		out[table] = values
+	}
+	return out, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) (err error) {
+	if s.tx == nil {
+		if s.tx, err = s.db.BeginTx(ctx, nil); err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+	}
+	tx := s.tx
+
+	savepoint := s.txBlocks > 0
+	if savepoint {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT block`); err != nil {
+			s.rollback()
+			return fmt.Errorf("savepoint: %w", err)
+		}
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if savepoint && sink.IsPermanent(err) && s.rollbackBlock(ctx) {
+			return
+		}
+		s.rollback()
+	}()
+
+	for _, output := range data.Outputs {
+		if output.GetStoreDeltas() != nil {
+			if err := s.ensureTable(ctx, tx, output.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	blockNum := data.Clock.GetNumber()
+	if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		// Undo in reverse order, restoring the old values, the outputs too so
+		// the stores depending on others are undone first
+		for j := len(data.Outputs) - 1; j >= 0; j-- {
+			output := data.Outputs[j]
+			deltas := output.GetStoreDeltas().GetDeltas()
+			for i := len(deltas) - 1; i >= 0; i-- {
+				if err := s.apply(ctx, tx, output.Name, blockNum, reverse(deltas[i])); err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		for _, output := range data.Outputs {
+			for _, delta := range output.GetStoreDeltas().GetDeltas() {
+				if err := s.apply(ctx, tx, output.Name, blockNum, delta); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if !sink.IsReplay(ctx) {
+		if _, err := tx.ExecContext(ctx, s.dialect.SaveCursor(), data.Cursor, blockNum); err != nil {
+			return fmt.Errorf("saving cursor: %w", err)
+		}
+	}
+	if savepoint {
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT block`); err != nil {
+			return fmt.Errorf("release savepoint: %w", err)
+		}
+	}
+
+	s.txBlocks++
+	if s.batching && s.txBlocks < BatchBlocks {
+		return nil
+	}
+	return s.commit()
+}
+
+// SetBatching applies the next blocks in transactions of `BatchBlocks`
+// blocks while enabled.
+func (s *Sink) SetBatching(enabled bool) {
+	s.batching = enabled
+}
+
+// Flush commits the blocks batched so far.
+func (s *Sink) Flush(ctx context.Context) error {
+	return s.commit()
+}
+
+func (s *Sink) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+
+	tx, blocks := s.tx, s.txBlocks
+	s.tx, s.txBlocks = nil, 0
+	if err := tx.Commit(); err != nil {
+		s.tables = map[string]bool{}
+		return fmt.Errorf("commit %d blocks: %w", blocks, err)
+	}
+	return nil
+}
+
+// rollbackBlock rolls the transaction back to the savepoint of the block,
+// false when it fails.
+func (s *Sink) rollbackBlock(ctx context.Context) bool {
+	for _, statement := range []string{`ROLLBACK TO SAVEPOINT block`, `RELEASE SAVEPOINT block`} {
+		if _, err := s.tx.ExecContext(ctx, statement); err != nil {
+			zlog.Warn("rollback to savepoint failed", zap.Error(err))
+			return false
+		}
+	}
+	// Tables created by the block are gone as well
+	s.tables = map[string]bool{}
+	return true
+}
+
+func (s *Sink) rollback() {
+	if s.tx == nil {
+		return
+	}
+
+	if err := s.tx.Rollback(); err != nil {
+		zlog.Warn("rollback failed", zap.Error(err))
+	}
+	s.tx, s.txBlocks = nil, 0
+	// Tables created in the transaction are gone as well
+	s.tables = map[string]bool{}
+}
+
+func (s *Sink) ensureTable(ctx context.Context, tx *sql.Tx, table string) error {
+	if s.tables[table] {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, s.dialect.CreateTable(table)); err != nil {
+		return s.statementError(fmt.Errorf("creating table %q: %w", table, err))
+	}
+	s.tables[table] = true
+	return nil
+}
+
+func (s *Sink) apply(ctx context.Context, tx *sql.Tx, table string, blockNum uint64, delta *pbsubstreams.StoreDelta) error {
+	if sink.IsReplay(ctx) {
+		var written uint64
+		err := tx.QueryRowContext(ctx, s.dialect.BlockNum(table), delta.Key).Scan(&written)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return s.statementError(fmt.Errorf("block of key %q of %q: %w", delta.Key, table, err))
+		}
+		if err == nil && written > blockNum {
+			return nil
+		}
+	}
+
+	var err error
+	switch delta.Operation {
+	case pbsubstreams.StoreDelta_CREATE, pbsubstreams.StoreDelta_UPDATE:
+		_, err = tx.ExecContext(ctx, s.dialect.Upsert(table), delta.Key, s.dialect.Value(s.valueTypes[table], delta.NewValue), blockNum, delta.Ordinal)
+	case pbsubstreams.StoreDelta_DELETE:
+		_, err = tx.ExecContext(ctx, s.dialect.Delete(table), delta.Key)
+	default:
+		return fmt.Errorf("unknown operation %s on key %q of %q", delta.Operation, delta.Key, table)
+	}
+
+	if err != nil {
+		return s.statementError(fmt.Errorf("%s key %q of %q: %w", delta.Operation, delta.Key, table, err))
+	}
+	return nil
+}
+
+// statementError marks `err` as permanent when the dialect tells it is.
+func (s *Sink) statementError(err error) error {
+	if s.dialect.Permanent(err) {
+		return sink.Permanent(err)
+	}
+	return err
+}
+
+// reverse returns the delta that undoes `delta`.
+func reverse(delta *pbsubstreams.StoreDelta) *pbsubstreams.StoreDelta {
+	out := &pbsubstreams.StoreDelta{Key: delta.Key, Ordinal: delta.Ordinal, OldValue: delta.NewValue, NewValue: delta.OldValue}
+	switch delta.Operation {
+	case pbsubstreams.StoreDelta_CREATE:
+		out.Operation = pbsubstreams.StoreDelta_DELETE
+	case pbsubstreams.StoreDelta_UPDATE:
+		out.Operation = pbsubstreams.StoreDelta_UPDATE
+	case pbsubstreams.StoreDelta_DELETE:
+		out.Operation = pbsubstreams.StoreDelta_CREATE
+	}
+	return out
+}
+
+func (s *Sink) Close() error {
+	if err := s.commit(); err != nil {
+		s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,7 @@
+package swaparchive
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.swaparchive", "github.com/streamingfast/substream-pancakeswap/sink/swaparchive")
@@ -0,0 +1,164 @@
+package swaparchive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/streamingfast/dbin"
+	"github.com/streamingfast/dstore"
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Swap is an archived swap event along with its block.
+type Swap struct {
+	BlockNum  uint64
+	BlockID   string
+	BlockTime time.Time
+	Event     *pbpcs.Event
+}
+
+// Reader reads back the swaps archived by the `swaps` sink.
+type Reader struct {
+	store dstore.Store
+}
+
+func NewReader(storeURL string) (*Reader, error) {
+	store, err := newStore(storeURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{store: store}, nil
+}
+
+type segmentRef struct {
+	name        string
+	first, last uint64
+}
+
+// Read calls `handle` with the swaps of the blocks in [start, stop[, in block
+// order, then in the order of the block, until the end of the archive when
+// `stop` is 0. A block archived by several runs is read once.
+func (r *Reader) Read(ctx context.Context, start, stop uint64, handle func(swap *Swap) error) error {
+	segments, err := r.segments(ctx)
+	if err != nil {
+		return err
+	}
+
+	next := start
+	for _, seg := range segments {
+		if seg.last < next || (stop != 0 && seg.first >= stop) {
+			continue
+		}
+
+		err := r.readSegment(ctx, seg.name, func(data *pbsubstreams.BlockScopedData, events *pbpcs.Events) error {
+			num := data.Clock.GetNumber()
+			if num < next || (stop != 0 && num >= stop) {
+				return nil
+			}
+			next = num + 1
+
+			for _, event := range events.Events {
+				swap := &Swap{BlockNum: num, BlockID: data.Clock.GetId(), Event: event}
+				if ts := data.Clock.GetTimestamp(); ts != nil {
+					swap.BlockTime = ts.AsTime()
+				}
+				if err := handle(swap); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range returns the first and last blocks with swaps in the archive, `found`
+// is false when it's empty.
+func (r *Reader) Range(ctx context.Context) (first, last uint64, found bool, err error) {
+	segments, err := r.segments(ctx)
+	if err != nil || len(segments) == 0 {
+		return 0, 0, false, err
+	}
+
+	first = segments[0].first
+	for _, seg := range segments {
+		if seg.last > last {
+			last = seg.last
+		}
+	}
+	return first, last, true, nil
+}
+
+func (r *Reader) segments(ctx context.Context) (out []*segmentRef, err error) {
+	err = r.store.Walk(ctx, "", func(filename string) error {
+		first, last, err := parseSegmentName(filename)
+		if err != nil {
+			return nil
+		}
+		out = append(out, &segmentRef{name: segmentName(first, last), first: first, last: last})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list segments: %w", err)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].first != out[j].first {
+			return out[i].first < out[j].first
+		}
+		return out[i].last < out[j].last
+	})
+	return out, nil
+}
+
+func (r *Reader) readSegment(ctx context.Context, name string, handle func(data *pbsubstreams.BlockScopedData, events *pbpcs.Events) error) error {
+	object, err := r.store.OpenObject(ctx, name)
+	if err != nil {
+		return fmt.Errorf("open segment %q: %w", name, err)
+	}
+	defer object.Close()
+
+	reader := dbin.NewReader(object)
+	kind, _, err := reader.ReadHeader()
+	if err != nil {
+		return fmt.Errorf("read segment %q header: %w", name, err)
+	}
+	if kind != contentType {
+		return fmt.Errorf("segment %q holds %q, expected %q", name, kind, contentType)
+	}
+
+	for {
+		content, err := reader.ReadMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read segment %q: %w", name, err)
+		}
+
+		data := &pbsubstreams.BlockScopedData{}
+		if err := proto.Unmarshal(content, data); err != nil {
+			return fmt.Errorf("unmarshal segment %q message: %w", name, err)
+		}
+		events := &pbpcs.Events{}
+		for _, output := range data.Outputs {
+			if output.GetMapOutput() == nil {
+				continue
+			}
+			if err := output.GetMapOutput().UnmarshalTo(events); err != nil {
+				return fmt.Errorf("unmarshal swaps of block %d in segment %q: %w", data.Clock.GetNumber(), name, err)
+			}
+		}
+		if err := handle(data, events); err != nil {
+			return err
+		}
+	}
+}
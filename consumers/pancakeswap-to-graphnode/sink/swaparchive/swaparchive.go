@@ -0,0 +1,257 @@
+// Package swaparchive persists every swap extracted by a run into an
+// append-only archive, for the analytics derived from swaps to be computed
+// again without extracting them from the logs of the chain, see `Reader`.
+//
+// The archive is a folder of segments named `<first block>-<last block>`, each
+// a dbin file of `sf.substreams.v1.BlockScopedData` holding the swap events
+// of a block as the `pcs.types.v1.Events` output of `EventsModule`, one
+// message per block with swaps, zstd compressed. Like the segments of the
+// `deltalog` output, a segment is written when the blocks cross a segment
+// boundary or when the sink is closed, a restarted run adds segments next to
+// the existing ones and the reader skips the blocks it already delivered.
+//
+// Archived blocks can't be undone, a run following the head of the chain must
+// archive the swaps through `--confirmed-output`.
+package swaparchive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/streamingfast/dbin"
+	"github.com/streamingfast/dstore"
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// EventsModule is the module whose swaps are archived, added to the output
+// modules of a run writing the archive.
+const EventsModule = "map_burn_swaps_events"
+
+const (
+	contentType        = "SWP"
+	contentVersion     = 1
+	defaultSegmentSize = 10_000
+)
+
+func init() {
+	sink.Register("swaps", func(ctx context.Context, params string) (sink.Sink, error) {
+		config, err := parseParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return New(config)
+	})
+}
+
+type Config struct {
+	StoreURL string
+
+	// SegmentSize is the number of blocks covered by a full segment.
+	SegmentSize uint64
+}
+
+// parseParams reads `<store url>[?segment-size=<blocks>]`, other query
+// parameters are kept on the store URL.
+func parseParams(params string) (*Config, error) {
+	storeURL, query := params, ""
+	if i := strings.Index(params, "?"); i >= 0 {
+		storeURL, query = params[:i], params[i+1:]
+	}
+	if storeURL == "" {
+		return nil, fmt.Errorf("a store URL is required, like 'swaps:file:///data/swaps'")
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+	}
+
+	config := &Config{StoreURL: storeURL, SegmentSize: defaultSegmentSize}
+	if size := values.Get("segment-size"); size != "" {
+		config.SegmentSize, err = strconv.ParseUint(size, 10, 64)
+		if err != nil || config.SegmentSize == 0 {
+			return nil, fmt.Errorf("invalid segment-size %q, expected a positive number of blocks", size)
+		}
+		values.Del("segment-size")
+	}
+	if len(values) > 0 {
+		config.StoreURL += "?" + values.Encode()
+	}
+	return config, nil
+}
+
+func newStore(storeURL string) (dstore.Store, error) {
+	store, err := dstore.NewStore(storeURL, "dbin.zst", "zstd", false)
+	if err != nil {
+		return nil, fmt.Errorf("swaps archive %q: %w", storeURL, err)
+	}
+	return store, nil
+}
+
+// Sink appends the swaps of every block to the archive.
+type Sink struct {
+	config *Config
+	store  dstore.Store
+
+	// pending are the blocks of the current segment, not written yet, they
+	// can still be undone
+	pending []*pendingBlock
+	base    uint64
+	started bool
+	// written is the last block of the segments written, 0 when none
+	written uint64
+}
+
+type pendingBlock struct {
+	num     uint64
+	content []byte
+}
+
+func New(config *Config) (*Sink, error) {
+	store, err := newStore(config.StoreURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{config: config, store: store}, nil
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	num := data.Clock.GetNumber()
+	if data.Step == pbsubstreams.ForkStep_STEP_UNDO {
+		return s.undo(num)
+	}
+
+	if base := num - num%s.config.SegmentSize; !s.started || base != s.base {
+		if err := s.flush(ctx); err != nil {
+			return err
+		}
+		s.base, s.started = base, true
+	}
+
+	swaps, err := blockSwaps(data)
+	if err != nil || swaps == nil {
+		return err
+	}
+	output, err := anypb.New(swaps)
+	if err != nil {
+		return fmt.Errorf("marshal swaps of block %d: %w", num, err)
+	}
+	content, err := proto.Marshal(&pbsubstreams.BlockScopedData{
+		Outputs: []*pbsubstreams.ModuleOutput{{Name: EventsModule, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: output}}},
+		Clock:   data.Clock,
+		Step:    data.Step,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal swaps of block %d: %w", num, err)
+	}
+
+	s.pending = append(s.pending, &pendingBlock{num: num, content: content})
+	return nil
+}
+
+// blockSwaps returns the swap events of the block, nil when it has none.
+func blockSwaps(data *pbsubstreams.BlockScopedData) (*pbpcs.Events, error) {
+	for _, output := range data.Outputs {
+		if output.Name != EventsModule || output.GetMapOutput() == nil {
+			continue
+		}
+
+		events := &pbpcs.Events{}
+		if err := output.GetMapOutput().UnmarshalTo(events); err != nil {
+			return nil, fmt.Errorf("unmarshal %s output of block %d: %w", EventsModule, data.Clock.GetNumber(), err)
+		}
+
+		swaps := &pbpcs.Events{}
+		for _, event := range events.Events {
+			if event.GetSwap() != nil {
+				swaps.Events = append(swaps.Events, event)
+			}
+		}
+		if len(swaps.Events) > 0 {
+			return swaps, nil
+		}
+	}
+	return nil, nil
+}
+
+// undo drops the swaps of the pending blocks from `num` on, the written ones
+// are final.
+func (s *Sink) undo(num uint64) error {
+	if s.written != 0 && num <= s.written {
+		return sink.Permanent(fmt.Errorf("block %d undone after its segment was archived, archive the swaps through --confirmed-output", num))
+	}
+
+	kept := s.pending[:0]
+	for _, block := range s.pending {
+		if block.num < num {
+			kept = append(kept, block)
+		}
+	}
+	s.pending = kept
+	return nil
+}
+
+// flush writes the pending segment.
+func (s *Sink) flush(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	first, last := s.pending[0].num, s.pending[len(s.pending)-1].num
+	var buffer bytes.Buffer
+	writer := dbin.NewWriter(&buffer)
+	if err := writer.WriteHeader(contentType, contentVersion); err != nil {
+		return fmt.Errorf("write segment header: %w", err)
+	}
+	for _, block := range s.pending {
+		if err := writer.WriteMessage(block.content); err != nil {
+			return fmt.Errorf("append swaps of block %d: %w", block.num, err)
+		}
+	}
+
+	name := segmentName(first, last)
+	if err := s.store.WriteObject(ctx, name, &buffer); err != nil {
+		return fmt.Errorf("write segment %q: %w", name, err)
+	}
+	zlog.Debug("swaps segment written", zap.Uint64("first", first), zap.Uint64("last", last), zap.Int("blocks", len(s.pending)))
+
+	s.pending = nil
+	s.written = last
+	return nil
+}
+
+// Flush writes the pending segment, the next blocks start a new one.
+func (s *Sink) Flush(ctx context.Context) error {
+	return s.flush(ctx)
+}
+
+func (s *Sink) Close() error {
+	return s.flush(context.Background())
+}
+
+func segmentName(first, last uint64) string {
+	return fmt.Sprintf("%010d-%010d", first, last)
+}
+
+func parseSegmentName(name string) (first, last uint64, err error) {
+	parts := strings.Split(strings.TrimSuffix(name, ".dbin.zst"), "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid segment name %q", name)
+	}
+	if first, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid segment name %q: %w", name, err)
+	}
+	if last, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid segment name %q: %w", name, err)
+	}
+	return first, last, nil
+}
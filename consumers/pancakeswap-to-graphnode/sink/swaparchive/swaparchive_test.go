@@ -0,0 +1,147 @@
+package swaparchive
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pbpcs "github.com/streamingfast/substream-pancakeswap/pb/pcs/v1"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// block has a swap on every block, and a burn on the odd ones.
+func block(t *testing.T, num uint64, step pbsubstreams.ForkStep) *pbsubstreams.BlockScopedData {
+	t.Helper()
+
+	events := &pbpcs.Events{Events: []*pbpcs.Event{
+		{LogOrdinal: 1, PairAddress: "0xaa", Type: &pbpcs.Event_Swap{Swap: &pbpcs.Swap{Id: fmt.Sprintf("swap-%d", num)}}},
+	}}
+	if num%2 == 1 {
+		events.Events = append(events.Events, &pbpcs.Event{LogOrdinal: 2, PairAddress: "0xaa", Type: &pbpcs.Event_Burn{Burn: &pbpcs.Burn{}}})
+	}
+	output, err := anypb.New(events)
+	require.NoError(t, err)
+
+	return &pbsubstreams.BlockScopedData{
+		Step:  step,
+		Clock: &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%08x", num)},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_pairs", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{}}},
+			{Name: EventsModule, Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: output}},
+		},
+	}
+}
+
+func writeBlocks(t *testing.T, s *Sink, blocks ...*pbsubstreams.BlockScopedData) {
+	t.Helper()
+	for _, data := range blocks {
+		require.NoError(t, s.Write(context.Background(), data))
+	}
+}
+
+func blocks(t *testing.T, from, to uint64) (out []*pbsubstreams.BlockScopedData) {
+	for num := from; num <= to; num++ {
+		out = append(out, block(t, num, pbsubstreams.ForkStep_STEP_NEW))
+	}
+	return out
+}
+
+func read(t *testing.T, storeURL string, start, stop uint64) (out []string) {
+	t.Helper()
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+	require.NoError(t, r.Read(context.Background(), start, stop, func(swap *Swap) error {
+		require.NotNil(t, swap.Event.GetSwap(), "only swaps are archived")
+		require.Equal(t, fmt.Sprintf("%08x", swap.BlockNum), swap.BlockID)
+		out = append(out, swap.Event.GetSwap().Id)
+		return nil
+	}))
+	return out
+}
+
+func swapIDs(from, to uint64) (out []string) {
+	for num := from; num <= to; num++ {
+		out = append(out, fmt.Sprintf("swap-%d", num))
+	}
+	return out
+}
+
+func TestArchive(t *testing.T) {
+	storeURL := t.TempDir()
+
+	s, err := New(&Config{StoreURL: storeURL, SegmentSize: 10})
+	require.NoError(t, err)
+	writeBlocks(t, s, blocks(t, 5, 34)...)
+	require.NoError(t, s.Close())
+
+	r, err := NewReader(storeURL)
+	require.NoError(t, err)
+	first, last, found, err := r.Range(context.Background())
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []uint64{5, 34}, []uint64{first, last})
+
+	tests := []struct {
+		name        string
+		start, stop uint64
+		expected    []string
+	}{
+		{"all", 0, 0, swapIDs(5, 34)},
+		{"range across segments", 8, 22, swapIDs(8, 21)},
+		{"past the end", 40, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, read(t, storeURL, test.start, test.stop))
+		})
+	}
+}
+
+func TestArchive_RestartAndUndo(t *testing.T) {
+	storeURL := t.TempDir()
+
+	s, err := New(&Config{StoreURL: storeURL, SegmentSize: 10})
+	require.NoError(t, err)
+	writeBlocks(t, s, blocks(t, 1, 14)...)
+	require.NoError(t, s.Close())
+
+	// the restarted run goes back a few blocks, then sees a reorg of pending
+	// blocks
+	s, err = New(&Config{StoreURL: storeURL, SegmentSize: 10})
+	require.NoError(t, err)
+	writeBlocks(t, s, blocks(t, 12, 16)...)
+	writeBlocks(t, s,
+		block(t, 16, pbsubstreams.ForkStep_STEP_UNDO),
+		block(t, 15, pbsubstreams.ForkStep_STEP_UNDO),
+	)
+	writeBlocks(t, s, blocks(t, 15, 21)...)
+
+	// block 15 is in a written segment now
+	err = s.Write(context.Background(), block(t, 15, pbsubstreams.ForkStep_STEP_UNDO))
+	require.Error(t, err)
+	assert.True(t, sink.IsPermanent(err))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, swapIDs(1, 21), read(t, storeURL, 0, 0))
+}
+
+func TestParseParams(t *testing.T) {
+	config, err := parseParams("file:///data/swaps?segment-size=500")
+	require.NoError(t, err)
+	assert.Equal(t, &Config{StoreURL: "file:///data/swaps", SegmentSize: 500}, config)
+
+	config, err = parseParams("s3://bucket/swaps?region=us-east-1")
+	require.NoError(t, err)
+	assert.Equal(t, &Config{StoreURL: "s3://bucket/swaps?region=us-east-1", SegmentSize: defaultSegmentSize}, config)
+
+	_, err = parseParams("file:///data/swaps?segment-size=0")
+	assert.Error(t, err)
+	_, err = parseParams("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,7 @@
+package undo
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.undo", "github.com/streamingfast/substream-pancakeswap/sink/undo")
@@ -0,0 +1,228 @@
+package undo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const fileSuffix = ".binpb"
+
+// Log persists the store deltas of the last blocks applied to the sinks, one
+// file per block named `<block_num>-<block_id>.binpb`. When an undo arrives,
+// the exact deltas that were applied for the block are available to roll it
+// back, even across restarts.
+type Log struct {
+	dir    string
+	size   int
+	blocks []*entry
+}
+
+type entry struct {
+	num  uint64
+	id   string
+	path string
+}
+
+// Open loads the log found in `dir`, creating the directory if needed. At most
+// `size` blocks are kept, the oldest ones being dropped first.
+func Open(dir string, size int) (*Log, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("undo log size must be positive, got %d", size)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create undo log directory %q: %w", dir, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read undo log directory %q: %w", dir, err)
+	}
+
+	l := &Log{dir: dir, size: size}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(name, fileSuffix), "-", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		num, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		l.blocks = append(l.blocks, &entry{num: num, id: parts[1], path: filepath.Join(dir, name)})
+	}
+
+	sort.Slice(l.blocks, func(i, j int) bool { return l.blocks[i].num < l.blocks[j].num })
+
+	return l, l.prune()
+}
+
+func (l *Log) Len() int {
+	return len(l.blocks)
+}
+
+// Append records the store deltas of `data`, map outputs are not kept.
+func (l *Log) Append(data *pbsubstreams.BlockScopedData) error {
+	logged := &pbsubstreams.BlockScopedData{
+		Clock:  data.Clock,
+		Step:   data.Step,
+		Cursor: data.Cursor,
+	}
+	for _, output := range data.Outputs {
+		if output.GetStoreDeltas() != nil {
+			logged.Outputs = append(logged.Outputs, output)
+		}
+	}
+
+	content, err := proto.Marshal(logged)
+	if err != nil {
+		return fmt.Errorf("marshal block %d: %w", data.Clock.GetNumber(), err)
+	}
+
+	e := &entry{num: data.Clock.GetNumber(), id: data.Clock.GetId()}
+	e.path = filepath.Join(l.dir, fmt.Sprintf("%010d-%s%s", e.num, e.id, fileSuffix))
+	if err := os.WriteFile(e.path, content, 0644); err != nil {
+		return fmt.Errorf("write undo log entry: %w", err)
+	}
+
+	l.blocks = append(l.blocks, e)
+	return l.prune()
+}
+
+// Pop removes and returns the deltas recorded for block `id`, `found` is false
+// when the block is not, or no longer, in the log.
+func (l *Log) Pop(id string) (data *pbsubstreams.BlockScopedData, found bool, err error) {
+	for i := len(l.blocks) - 1; i >= 0; i-- {
+		e := l.blocks[i]
+		if e.id != id {
+			continue
+		}
+
+		content, err := os.ReadFile(e.path)
+		if err != nil {
+			return nil, false, fmt.Errorf("read undo log entry: %w", err)
+		}
+
+		data = &pbsubstreams.BlockScopedData{}
+		if err := proto.Unmarshal(content, data); err != nil {
+			return nil, false, fmt.Errorf("unmarshal undo log entry %q: %w", e.path, err)
+		}
+
+		if err := os.Remove(e.path); err != nil {
+			return nil, false, fmt.Errorf("remove undo log entry: %w", err)
+		}
+
+		l.blocks = append(l.blocks[:i], l.blocks[i+1:]...)
+		return data, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// Truncate removes the blocks after `blockNum`, like when the blocks are
+// streamed again from an earlier block.
+func (l *Log) Truncate(blockNum uint64) error {
+	for len(l.blocks) > 0 && l.blocks[len(l.blocks)-1].num > blockNum {
+		last := l.blocks[len(l.blocks)-1]
+		if err := os.Remove(last.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove undo log entry: %w", err)
+		}
+		l.blocks = l.blocks[:len(l.blocks)-1]
+	}
+	return nil
+}
+
+func (l *Log) prune() error {
+	for len(l.blocks) > l.size {
+		if err := os.Remove(l.blocks[0].path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove undo log entry: %w", err)
+		}
+		l.blocks = l.blocks[1:]
+	}
+	return nil
+}
+
+// Sink records every new block in the log before handing it to the wrapped
+// sinks. On undo, the wrapped sinks receive the deltas that were recorded for
+// the block, with the undo step and cursor, so they can revert exactly what
+// they applied. Only the sql outputs revert them, the others, like jsonl, csv,
+// pubsub or flight, write them as records of the undo step like any other,
+// leaving the revert to their consumers.
+type Sink struct {
+	log   *Log
+	sinks []sink.Sink
+}
+
+func NewSink(log *Log, sinks ...sink.Sink) *Sink {
+	return &Sink{log: log, sinks: sinks}
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	switch data.Step {
+	case pbsubstreams.ForkStep_STEP_NEW:
+		if err := s.log.Append(data); err != nil {
+			return err
+		}
+
+	case pbsubstreams.ForkStep_STEP_UNDO:
+		logged, found, err := s.log.Pop(data.Clock.GetId())
+		if err != nil {
+			return err
+		}
+
+		if found {
+			logged.Step = data.Step
+			logged.Cursor = data.Cursor
+			data = logged
+		} else {
+			zlog.Warn("undo of a block not in the undo log, forwarding it as received",
+				zap.Uint64("block_num", data.Clock.GetNumber()),
+				zap.String("block_id", data.Clock.GetId()),
+			)
+		}
+	}
+
+	for _, s := range s.sinks {
+		if err := s.Write(ctx, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Sink) Flush(ctx context.Context) error {
+	for _, s := range s.sinks {
+		if err := sink.Flush(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sink) Close() error {
+	var firstErr error
+	for _, s := range s.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
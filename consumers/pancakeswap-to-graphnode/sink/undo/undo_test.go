@@ -0,0 +1,131 @@
+package undo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	"github.com/streamingfast/substream-pancakeswap/sink/jsonl"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type recordingSink struct {
+	written []*pbsubstreams.BlockScopedData
+}
+
+func (s *recordingSink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	s.written = append(s.written, data)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func block(num uint64, step pbsubstreams.ForkStep) *pbsubstreams.BlockScopedData {
+	return &pbsubstreams.BlockScopedData{
+		Step:   step,
+		Cursor: fmt.Sprintf("cursor-%d-%s", num, step),
+		Clock:  &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("%08x", num)},
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "map_pairs", Data: &pbsubstreams.ModuleOutput_MapOutput{MapOutput: &anypb.Any{}}},
+			{Name: "store_pairs", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+				Deltas: []*pbsubstreams.StoreDelta{{Key: fmt.Sprintf("pair:%d", num), NewValue: []byte("v")}},
+			}}},
+		},
+	}
+}
+
+func TestSink_Undo(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	log, err := Open(dir, 2)
+	require.NoError(t, err)
+
+	inner := &recordingSink{}
+	var s sink.Sink = NewSink(log, inner)
+
+	for num := uint64(1); num <= 3; num++ {
+		require.NoError(t, s.Write(ctx, block(num, pbsubstreams.ForkStep_STEP_NEW)))
+	}
+	assert.Equal(t, 2, log.Len(), "oldest block is pruned")
+
+	// reopening picks up the persisted blocks
+	log, err = Open(dir, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, log.Len())
+	s = NewSink(log, inner)
+
+	// the undo as received carries no outputs, the recorded deltas are sent instead
+	undo := block(3, pbsubstreams.ForkStep_STEP_UNDO)
+	undo.Outputs = nil
+	require.NoError(t, s.Write(ctx, undo))
+
+	written := inner.written[len(inner.written)-1]
+	assert.Equal(t, pbsubstreams.ForkStep_STEP_UNDO, written.Step)
+	assert.Equal(t, undo.Cursor, written.Cursor)
+	require.Len(t, written.Outputs, 1, "map outputs are not recorded")
+	assert.Equal(t, "pair:3", written.Outputs[0].GetStoreDeltas().Deltas[0].Key)
+	assert.Equal(t, 1, log.Len())
+
+	// block 1 was pruned, it's forwarded as received
+	undo = block(1, pbsubstreams.ForkStep_STEP_UNDO)
+	require.NoError(t, s.Write(ctx, undo))
+	assert.Same(t, undo, inner.written[len(inner.written)-1])
+}
+
+func TestSink_UndoRecords(t *testing.T) {
+	ctx := context.Background()
+
+	log, err := Open(t.TempDir(), 2)
+	require.NoError(t, err)
+
+	// outputs that don't revert get the recorded deltas as records of the undo step
+	buffer := &bytes.Buffer{}
+	s := NewSink(log, jsonl.New(buffer))
+	require.NoError(t, s.Write(ctx, block(1, pbsubstreams.ForkStep_STEP_NEW)))
+	undo := block(1, pbsubstreams.ForkStep_STEP_UNDO)
+	undo.Outputs = nil
+	require.NoError(t, s.Write(ctx, undo))
+	require.NoError(t, s.Close())
+
+	var steps, keys []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		record := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		if record["module"] != "store_pairs" {
+			continue
+		}
+		steps = append(steps, record["step"].(string))
+		keys = append(keys, record["entity"].(map[string]interface{})["key"].(string))
+	}
+	assert.Equal(t, []string{"STEP_NEW", "STEP_UNDO"}, steps)
+	assert.Equal(t, []string{"pair:1", "pair:1"}, keys)
+}
+
+func TestLog_Truncate(t *testing.T) {
+	dir := t.TempDir()
+
+	log, err := Open(dir, 10)
+	require.NoError(t, err)
+	for num := uint64(1); num <= 4; num++ {
+		require.NoError(t, log.Append(block(num, pbsubstreams.ForkStep_STEP_NEW)))
+	}
+
+	require.NoError(t, log.Truncate(2))
+	assert.Equal(t, 2, log.Len())
+
+	_, found, err := log.Pop(fmt.Sprintf("%08x", 3))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	log, err = Open(dir, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, log.Len(), "truncated blocks are removed from disk")
+}
@@ -0,0 +1,7 @@
+package wsfeed
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.sink.wsfeed", "github.com/streamingfast/substream-pancakeswap/sink/wsfeed")
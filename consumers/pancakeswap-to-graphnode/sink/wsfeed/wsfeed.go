@@ -0,0 +1,242 @@
+// Package wsfeed broadcasts the module outputs to WebSocket clients, like the
+// page served by `demo ui`, for live views of the stream.
+package wsfeed
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/streamingfast/substream-pancakeswap/servertls"
+	"github.com/streamingfast/substream-pancakeswap/sink"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+const defaultBuffer = 1024
+
+func init() {
+	sink.Register("ws", func(ctx context.Context, params string) (sink.Sink, error) {
+		addr, query := params, ""
+		if i := strings.Index(params, "?"); i >= 0 {
+			addr, query = params[:i], params[i+1:]
+		}
+		if addr == "" {
+			addr = ":8095"
+		}
+
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters %q: %w", query, err)
+		}
+
+		config := &Config{Addr: addr, Path: "/", Buffer: defaultBuffer}
+		if v := values.Get("path"); v != "" {
+			config.Path = v
+		}
+		if v := values.Get("buffer"); v != "" {
+			if config.Buffer, err = strconv.Atoi(v); err != nil || config.Buffer <= 0 {
+				return nil, fmt.Errorf("invalid buffer %q", v)
+			}
+		}
+		if v := values.Get("modules"); v != "" {
+			config.Modules = strings.Split(v, ",")
+		}
+
+		config.TLS = servertls.FromContext(ctx)
+
+		return New(config)
+	})
+}
+
+type Config struct {
+	Addr string
+	Path string
+	// Buffer is the number of records queued for each client, the records
+	// of a client too slow to keep up are dropped past it.
+	Buffer int
+	// Modules are the modules whose outputs are broadcast, all of them when
+	// empty.
+	Modules []string
+	// TLS serves over TLS, `wss://`, when set.
+	TLS *tls.Config
+}
+
+// Sink broadcasts every record of the module outputs, see `sink.Record`, to
+// the clients connected to its WebSocket endpoint, as a JSON text message. A
+// client receives the records written from the moment it connects.
+//
+// It's a live feed: writing never waits on the clients, the records a slow
+// client has no room for are dropped for it, the stream and the other clients
+// going on.
+type Sink struct {
+	server   *http.Server
+	listener net.Listener
+	buffer   int
+	modules  map[string]bool
+
+	lock    sync.Mutex
+	clients map[*client]bool
+	closed  bool
+}
+
+type client struct {
+	messages chan []byte
+	dropped  uint64
+}
+
+func New(config *Config) (*Sink, error) {
+	s := &Sink{
+		buffer:  config.Buffer,
+		clients: map[*client]bool{},
+	}
+	if len(config.Modules) > 0 {
+		s.modules = map[string]bool{}
+		for _, module := range config.Modules {
+			s.modules[module] = true
+		}
+	}
+
+	listener, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %q: %w", config.Addr, err)
+	}
+	if config.TLS != nil {
+		listener = tls.NewListener(listener, config.TLS)
+	}
+	s.listener = listener
+
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, websocket.Handler(s.serve))
+	s.server = &http.Server{Handler: mux}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zlog.Error("websocket feed server stopped", zap.Error(err))
+		}
+	}()
+
+	zlog.Info("serving websocket feed", zap.Stringer("addr", listener.Addr()), zap.String("path", config.Path), zap.Bool("tls", config.TLS != nil))
+	return s, nil
+}
+
+func (s *Sink) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Sink) Write(ctx context.Context, data *pbsubstreams.BlockScopedData) error {
+	records, err := sink.Records(data)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	for _, record := range records {
+		if s.modules != nil && !s.modules[record.Module] {
+			continue
+		}
+
+		message, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encoding record of module %q: %w", record.Module, err)
+		}
+		s.broadcast(message)
+	}
+	return nil
+}
+
+func (s *Sink) broadcast(message []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for c := range s.clients {
+		select {
+		case c.messages <- message:
+		default:
+			if c.dropped == 0 {
+				zlog.Warn("websocket client too slow, dropping records")
+			}
+			c.dropped++
+		}
+	}
+}
+
+func (s *Sink) serve(conn *websocket.Conn) {
+	c := s.subscribe()
+	if c == nil {
+		conn.Close()
+		return
+	}
+	defer s.unsubscribe(c)
+
+	// clients only listen, reading notices when they go away
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.messages:
+			if !ok {
+				conn.Close()
+				return
+			}
+			if err := websocket.Message.Send(conn, string(message)); err != nil {
+				zlog.Debug("websocket client write failed", zap.Error(err))
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+func (s *Sink) subscribe() *client {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	c := &client{messages: make(chan []byte, s.buffer)}
+	s.clients[c] = true
+	return c
+}
+
+func (s *Sink) unsubscribe(c *client) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.clients[c] {
+		return
+	}
+	delete(s.clients, c)
+	if c.dropped > 0 {
+		zlog.Info("websocket client left", zap.Uint64("dropped_records", c.dropped))
+	}
+}
+
+// Close disconnects the clients once they received the records queued for
+// them and stops serving.
+func (s *Sink) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	for c := range s.clients {
+		close(c.messages)
+		delete(s.clients, c)
+	}
+	s.lock.Unlock()
+
+	return s.server.Close()
+}
@@ -0,0 +1,72 @@
+package wsfeed
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func blockData(num uint64) *pbsubstreams.BlockScopedData {
+	deltas := func(key string) *pbsubstreams.ModuleOutput_StoreDeltas {
+		return &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{
+			Deltas: []*pbsubstreams.StoreDelta{{Operation: pbsubstreams.StoreDelta_UPDATE, Key: key, NewValue: []byte("2")}},
+		}}
+	}
+	return &pbsubstreams.BlockScopedData{
+		Clock: &pbsubstreams.Clock{Id: "abc", Number: num},
+		Step:  pbsubstreams.ForkStep_STEP_NEW,
+		Outputs: []*pbsubstreams.ModuleOutput{
+			{Name: "store_reserves", Data: deltas("price:0xaa:0x01:token0")},
+			{Name: "store_totals", Data: deltas("pairs")},
+		},
+	}
+}
+
+func connect(t *testing.T, s *Sink) *websocket.Conn {
+	t.Helper()
+	conn, err := websocket.Dial("ws://"+s.Addr()+"/prices", "", "http://localhost/")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return len(s.clients) == 1
+	}, time.Second, time.Millisecond)
+	return conn
+}
+
+func TestSink(t *testing.T) {
+	s, err := New(&Config{Addr: "127.0.0.1:0", Path: "/prices", Buffer: 16, Modules: []string{"store_reserves"}})
+	require.NoError(t, err)
+	conn := connect(t, s)
+	defer conn.Close()
+
+	require.NoError(t, s.Write(context.Background(), blockData(10)))
+
+	var message string
+	require.NoError(t, websocket.Message.Receive(conn, &message))
+	assert.JSONEq(t, `{"id":"abc:0:price:0xaa:0x01:token0","module":"store_reserves","block_num":10,"block_id":"abc","timestamp":"0001-01-01T00:00:00Z","step":"STEP_NEW","type":"sf.substreams.v1.StoreDelta","entity":{"operation":"UPDATE","key":"price:0xaa:0x01:token0","new_value":"Mg=="}}`, message)
+
+	require.NoError(t, s.Close())
+	assert.Equal(t, io.EOF, websocket.Message.Receive(conn, &message), "the store_totals record isn't broadcast")
+}
+
+func TestSink_SlowClient(t *testing.T) {
+	s, err := New(&Config{Addr: "127.0.0.1:0", Path: "/", Buffer: 1})
+	require.NoError(t, err)
+	defer s.Close()
+
+	// a client whose records are never sent
+	c := s.subscribe()
+	require.NoError(t, s.Write(context.Background(), blockData(10)))
+	require.NoError(t, s.Write(context.Background(), blockData(11)))
+
+	assert.Len(t, c.messages, 1)
+	assert.Equal(t, uint64(3), c.dropped)
+}
@@ -0,0 +1,7 @@
+package solswap
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.solswap", "github.com/streamingfast/substream-pancakeswap/solswap")
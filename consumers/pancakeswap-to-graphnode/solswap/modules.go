@@ -0,0 +1,253 @@
+// Package solswap is a demo module set of the Solana chain: it extracts the
+// token swaps of the Orca and Raydium pools out of the firehose Solana blocks
+// and sums their volumes per mint, the same way the PancakeSwap modules do on
+// BSC, with the same pipeline, stores and sinks.
+package solswap
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/mr-tron/base58"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	pbsol "github.com/streamingfast/substream-pancakeswap/pb/sf/solana/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk"
+	"github.com/streamingfast/substream-pancakeswap/sdk/solana"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	SwapsModule   = "solana_swaps"
+	VolumesModule = "solana_volumes"
+)
+
+// The programs of the pools, a transaction invoking one of them, directly or
+// through an aggregator, is a swap of that DEX.
+const (
+	OrcaWhirlpool   = "whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"
+	OrcaTokenSwapV2 = "9W959DqEETiGZocYWCQPaJ6sBmUzgfxXfqGeTEdp3aQP"
+	RaydiumAMMV4    = "675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8"
+)
+
+var programs = map[string]string{
+	OrcaWhirlpool:   "orca",
+	OrcaTokenSwapV2: "orca",
+	RaydiumAMMV4:    "raydium",
+}
+
+func init() {
+	modules.Register(&modules.Module{
+		Name:     SwapsModule,
+		Chain:    solana.Chain,
+		ChainMap: mapSwaps,
+	})
+	// the output of a module only feeds its own store, the volumes module
+	// extracts the swaps again, it's the one to run for both
+	modules.Register(&modules.Module{
+		Name:       VolumesModule,
+		Chain:      solana.Chain,
+		ChainMap:   mapSwaps,
+		ChainStore: storeVolumes,
+	})
+}
+
+// Swap is a swap of a trader on one of the pools, the amounts are in the base
+// units of the mints.
+type Swap struct {
+	Dex         string `json:"dex"`
+	Transaction string `json:"transaction"`
+	Trader      string `json:"trader"`
+	MintIn      string `json:"mint_in"`
+	AmountIn    string `json:"amount_in"`
+	MintOut     string `json:"mint_out"`
+	AmountOut   string `json:"amount_out"`
+}
+
+func mapSwaps(block proto.Message, intr sdk.ChainIntrinsics) (interface{}, error) {
+	var swaps []*Swap
+	for _, trx := range block.(*pbsol.Block).Transactions {
+		if trx.GetMeta() == nil || trx.Meta.Err != nil {
+			continue
+		}
+		message := trx.GetTransaction().GetMessage()
+		if message == nil || len(message.AccountKeys) == 0 {
+			continue
+		}
+
+		dex := invokedDex(message, trx.Meta)
+		if dex == "" {
+			continue
+		}
+
+		swap, err := traderSwap(message, trx.Meta)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", transactionID(trx), err)
+		}
+		if swap == nil {
+			// not a one token for another swap, like a deposit or a route
+			// ending with the token it started with
+			continue
+		}
+		swap.Dex = dex
+		swap.Transaction = transactionID(trx)
+		swaps = append(swaps, swap)
+	}
+	return swaps, nil
+}
+
+func transactionID(trx *pbsol.ConfirmedTransaction) string {
+	signatures := trx.GetTransaction().GetSignatures()
+	if len(signatures) == 0 {
+		return ""
+	}
+	return base58.Encode(signatures[0])
+}
+
+// invokedDex returns the DEX of the first pool program invoked by the
+// transaction, "" when it doesn't invoke any.
+func invokedDex(message *pbsol.Message, meta *pbsol.TransactionStatusMeta) string {
+	instructions := message.Instructions
+	for _, inner := range meta.InnerInstructions {
+		instructions = append(instructions[:len(instructions):len(instructions)], inner.Instructions...)
+	}
+
+	for _, instruction := range instructions {
+		if int(instruction.ProgramIdIndex) >= len(message.AccountKeys) {
+			continue
+		}
+		if dex, found := programs[base58.Encode(message.AccountKeys[instruction.ProgramIdIndex])]; found {
+			return dex
+		}
+	}
+	return ""
+}
+
+// traderSwap derives the swap from the changes of the token balances of the fee
+// payer, the trader: the mint it spent and the mint it received. It's nil when
+// the balances don't show exactly one of each.
+func traderSwap(message *pbsol.Message, meta *pbsol.TransactionStatusMeta) (*Swap, error) {
+	trader := base58.Encode(message.AccountKeys[0])
+
+	changes := map[string]*big.Int{}
+	var mints []string
+	apply := func(balances []*pbsol.TokenBalance, sign int) error {
+		for _, balance := range balances {
+			if balance.Owner != trader {
+				continue
+			}
+			amount, ok := new(big.Int).SetString(balance.GetUiTokenAmount().GetAmount(), 10)
+			if !ok {
+				return fmt.Errorf("invalid amount %q of mint %s", balance.GetUiTokenAmount().GetAmount(), balance.Mint)
+			}
+			change, found := changes[balance.Mint]
+			if !found {
+				change = new(big.Int)
+				changes[balance.Mint] = change
+				mints = append(mints, balance.Mint)
+			}
+			if sign < 0 {
+				change.Sub(change, amount)
+			} else {
+				change.Add(change, amount)
+			}
+		}
+		return nil
+	}
+	// a balance missing on one side is an account opened or closed by the
+	// transaction, its balance was or is 0
+	if err := apply(meta.PreTokenBalances, -1); err != nil {
+		return nil, err
+	}
+	if err := apply(meta.PostTokenBalances, 1); err != nil {
+		return nil, err
+	}
+
+	swap := &Swap{Trader: trader}
+	for _, mint := range mints {
+		switch changes[mint].Sign() {
+		case -1:
+			if swap.MintIn != "" {
+				return nil, nil
+			}
+			swap.MintIn, swap.AmountIn = mint, new(big.Int).Neg(changes[mint]).String()
+		case 1:
+			if swap.MintOut != "" {
+				return nil, nil
+			}
+			swap.MintOut, swap.AmountOut = mint, changes[mint].String()
+		}
+	}
+	if swap.MintIn == "" || swap.MintOut == "" {
+		return nil, nil
+	}
+	return swap, nil
+}
+
+// storeVolumes sums the amounts swapped per mint, in and out, under
+// `volume:<mint>` and counts the swaps per DEX under `swaps:<dex>`.
+func storeVolumes(block proto.Message, output interface{}, intr sdk.ChainIntrinsics, store sdk.Store) error {
+	for _, swap := range output.([]*Swap) {
+		if err := addVolume(store, swap.MintIn, swap.AmountIn); err != nil {
+			return err
+		}
+		if err := addVolume(store, swap.MintOut, swap.AmountOut); err != nil {
+			return err
+		}
+
+		count := 0
+		if value, found := store.Get(SwapsKey(swap.Dex)); found {
+			previous, err := strconv.Atoi(string(value))
+			if err != nil {
+				return fmt.Errorf("invalid swaps count %q of %s: %w", value, swap.Dex, err)
+			}
+			count = previous
+		}
+		store.Set(SwapsKey(swap.Dex), []byte(strconv.Itoa(count+1)))
+	}
+	return nil
+}
+
+// keys parses the mints of the keys as Solana addresses, in base58.
+var keys = sdk.NewKeyCodec(solana.Chain)
+
+// VolumeKey is the key of the volume of `mint`, `volume:<mint>`, the mint
+// being written in base58 already.
+func VolumeKey(mint string) string {
+	return keys.Key("volume", mint).String()
+}
+
+// ParseVolumeKey returns the mint of a key of the volumes store, nil when the
+// key is the one of a swaps count.
+func ParseVolumeKey(key string) ([]byte, error) {
+	k, err := keys.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+	if !k.Is("volume", 2) {
+		return nil, nil
+	}
+	return k.Address(1)
+}
+
+// SwapsKey is the key of the swaps count of `dex`, `swaps:<dex>`.
+func SwapsKey(dex string) string {
+	return keys.Key("swaps", dex).String()
+}
+
+func addVolume(store sdk.Store, mint, amount string) error {
+	volume, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %q of mint %s", amount, mint)
+	}
+	key := VolumeKey(mint)
+	if value, found := store.Get(key); found {
+		previous, ok := new(big.Int).SetString(string(value), 10)
+		if !ok {
+			return fmt.Errorf("invalid volume %q of mint %s", value, mint)
+		}
+		volume.Add(volume, previous)
+	}
+	store.Set(key, []byte(volume.String()))
+	return nil
+}
@@ -0,0 +1,98 @@
+package solswap
+
+import (
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/streamingfast/substream-pancakeswap/modules"
+	pbsol "github.com/streamingfast/substream-pancakeswap/pb/sf/solana/type/v1"
+	"github.com/streamingfast/substream-pancakeswap/sdk/solana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	trader = "7xKXtg2CW87d97TXJSDpbD5jBkheTqA83TZRuJosgAsU"
+	usdc   = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	wsol   = "So11111111111111111111111111111111111111112"
+	ray    = "4k3Dyjzvzp8eMZWUXbBCjEvwSkkk59S5iCNLY3QrkX6R"
+)
+
+func mustDecode(t *testing.T, address string) []byte {
+	t.Helper()
+	out, err := base58.Decode(address)
+	require.NoError(t, err)
+	return out
+}
+
+func balance(mint, amount string) *pbsol.TokenBalance {
+	return &pbsol.TokenBalance{Mint: mint, Owner: trader, UiTokenAmount: &pbsol.UiTokenAmount{Amount: amount}}
+}
+
+// transaction invokes `program`, from an inner instruction when `inner`.
+func transaction(t *testing.T, signature byte, program string, inner bool, pre, post []*pbsol.TokenBalance) *pbsol.ConfirmedTransaction {
+	keys := [][]byte{mustDecode(t, trader), mustDecode(t, "JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4"), mustDecode(t, program)}
+	trx := &pbsol.ConfirmedTransaction{
+		Transaction: &pbsol.Transaction{
+			Signatures: [][]byte{{signature}},
+			Message:    &pbsol.Message{AccountKeys: keys},
+		},
+		Meta: &pbsol.TransactionStatusMeta{PreTokenBalances: pre, PostTokenBalances: post},
+	}
+	if inner {
+		trx.Transaction.Message.Instructions = []*pbsol.CompiledInstruction{{ProgramIdIndex: 1}}
+		trx.Meta.InnerInstructions = []*pbsol.InnerInstructions{{Instructions: []*pbsol.CompiledInstruction{{ProgramIdIndex: 2}}}}
+	} else {
+		trx.Transaction.Message.Instructions = []*pbsol.CompiledInstruction{{ProgramIdIndex: 2}}
+	}
+	return trx
+}
+
+func TestModules(t *testing.T) {
+	failed := transaction(t, 3, RaydiumAMMV4, false, []*pbsol.TokenBalance{balance(usdc, "10")}, []*pbsol.TokenBalance{balance(wsol, "10")})
+	failed.Meta.Err = &pbsol.TransactionError{Err: []byte{0x01}}
+
+	block := &pbsol.Block{Slot: 100, Blockhash: "hash", Transactions: []*pbsol.ConfirmedTransaction{
+		transaction(t, 1, RaydiumAMMV4, false,
+			[]*pbsol.TokenBalance{balance(usdc, "5000000"), balance(ray, "7")},
+			[]*pbsol.TokenBalance{balance(usdc, "1000000"), balance(ray, "7"), balance(wsol, "25000000")},
+		),
+		transaction(t, 2, OrcaWhirlpool, true,
+			[]*pbsol.TokenBalance{balance(wsol, "25000000")},
+			[]*pbsol.TokenBalance{balance(usdc, "900000")},
+		),
+		failed,
+		// not a pool
+		transaction(t, 4, "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA", false, []*pbsol.TokenBalance{balance(usdc, "10")}, []*pbsol.TokenBalance{balance(wsol, "10")}),
+		// a deposit, only spending
+		transaction(t, 5, OrcaTokenSwapV2, false, []*pbsol.TokenBalance{balance(usdc, "10"), balance(wsol, "10")}, nil),
+	}}
+
+	p, err := modules.NewPipeline(VolumesModule)
+	require.NoError(t, err)
+	out, err := p.Process(block)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*Swap{
+		{Dex: "raydium", Transaction: "2", Trader: trader, MintIn: usdc, AmountIn: "4000000", MintOut: wsol, AmountOut: "25000000"},
+		{Dex: "orca", Transaction: "3", Trader: trader, MintIn: wsol, AmountIn: "25000000", MintOut: usdc, AmountOut: "900000"},
+	}, out.Outputs[VolumesModule])
+
+	values := map[string]string{}
+	for _, delta := range out.Deltas[VolumesModule] {
+		values[delta.Key] = string(delta.NewValue)
+	}
+	assert.Equal(t, map[string]string{
+		"volume:" + usdc: "4900000",
+		"volume:" + wsol: "50000000",
+		"swaps:raydium":  "1",
+		"swaps:orca":     "1",
+	}, values)
+
+	mint, err := ParseVolumeKey("volume:" + usdc)
+	require.NoError(t, err)
+	assert.Equal(t, usdc, solana.Chain.FormatAddress(mint))
+	mint, err = ParseVolumeKey("swaps:orca")
+	require.NoError(t, err)
+	assert.Nil(t, mint)
+}
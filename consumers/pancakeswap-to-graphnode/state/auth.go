@@ -0,0 +1,70 @@
+package state
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+)
+
+// Tokens are the bearer tokens required to read the stores of a namespace and
+// of the namespaces under it, the closest namespace with a token applies, the
+// root namespace being empty. Namespaces without a token are open to any
+// reader.
+type Tokens map[string]string
+
+// ReadTokens reads a file of `<namespace> <token>` lines, `/` being the root
+// namespace, empty lines and lines starting with `#` are skipped.
+func ReadTokens(path string) (Tokens, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open tokens file: %w", err)
+	}
+	defer file.Close()
+
+	tokens := Tokens{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.Fields(text)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected '<namespace> <token>', got %q", path, line, text)
+		}
+
+		namespace := strings.Trim(parts[0], "/")
+		if err := deltalog.ValidateNamespace(namespace); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if _, found := tokens[namespace]; found {
+			return nil, fmt.Errorf("%s:%d: namespace %q has more than one token", path, line, parts[0])
+		}
+		tokens[namespace] = parts[1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read tokens file %q: %w", path, err)
+	}
+	return tokens, nil
+}
+
+// Allows tells whether `r` can read the stores of `namespace`.
+func (t Tokens) Allows(namespace string, r *http.Request) bool {
+	for {
+		if token, found := t[namespace]; found {
+			given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+		}
+		if namespace == "" {
+			return true
+		}
+		namespace, _ = deltalog.SplitTopic(namespace)
+	}
+}
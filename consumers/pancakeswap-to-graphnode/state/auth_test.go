@@ -0,0 +1,91 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	require.NoError(t, os.WriteFile(path, []byte("# tenants\nbsc secret\n/eth/uniswap/ other\n/ root\n"), 0644))
+
+	tokens, err := ReadTokens(path)
+	require.NoError(t, err)
+	assert.Equal(t, Tokens{"bsc": "secret", "eth/uniswap": "other", "": "root"}, tokens)
+
+	for _, content := range []string{"bsc\n", "_groups secret\n", "bsc a\nbsc b\n"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := ReadTokens(path)
+		assert.Error(t, err, content)
+	}
+}
+
+func TestServer_Namespaces(t *testing.T) {
+	ctx := context.Background()
+	storeURL := "file://" + filepath.Join(t.TempDir(), "log")
+
+	for _, namespace := range []string{"bsc/pancake", "eth/uniswap"} {
+		s, err := deltalog.New(&deltalog.Config{StoreURL: storeURL, SegmentSize: 100, Namespace: namespace})
+		require.NoError(t, err)
+		require.NoError(t, s.Write(ctx, &pbsubstreams.BlockScopedData{
+			Clock: &pbsubstreams.Clock{Number: 10},
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_pairs", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{
+					{Operation: pbsubstreams.StoreDelta_CREATE, Key: "pair:0xaa", NewValue: []byte(namespace)},
+				}}}},
+			},
+		}))
+		require.NoError(t, s.Close())
+	}
+	reader, err := deltalog.NewReader(storeURL)
+	require.NoError(t, err)
+
+	s := NewServer(reader, nil)
+	s.RequireTokens(Tokens{"bsc": "secret"})
+	require.NoError(t, s.Listen("127.0.0.1:0"))
+	defer s.Close()
+
+	get := func(path, token string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, "http://"+s.Addr()+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var body json.RawMessage
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := get("/state?store=eth/uniswap/pairs&key=pair:0xaa&block=10", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"store":"eth/uniswap/store_pairs","block":10,"snapshot":0,"key":"pair:0xaa","found":true,"value":"eth/uniswap"}`, body)
+
+	code, _ = get("/state?store=bsc/pancake/pairs&key=pair:0xaa&block=10", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = get("/state?store=bsc/pancake/pairs&key=pair:0xaa&block=10", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, body = get("/state?store=bsc/pancake/pairs&key=pair:0xaa&block=10", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"value":"bsc/pancake"`)
+
+	_, body = get("/topics", "")
+	assert.JSONEq(t, `{"eth/uniswap":["store_pairs"]}`, body)
+	_, body = get("/topics", "secret")
+	assert.JSONEq(t, `{"bsc/pancake":["store_pairs"],"eth/uniswap":["store_pairs"]}`, body)
+}
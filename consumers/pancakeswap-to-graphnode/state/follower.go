@@ -0,0 +1,146 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// Follower keeps the latest state of the stores of a delta log written by an
+// indexer running elsewhere, loading each store from its last snapshot then
+// applying the segments appended to the log, so reads at the head answer from
+// memory instead of rebuilding the state. Any number of followers can serve
+// the same log.
+//
+// The head lags the indexer by up to a segment, the deltalog sink writes a
+// segment once the blocks cross its boundary.
+type Follower struct {
+	reader *deltalog.Reader
+
+	lock   sync.RWMutex
+	stores map[string]*followedStore
+	head   uint64
+}
+
+type followedStore struct {
+	tail     *deltalog.Tail
+	state    deltalog.State
+	snapshot uint64
+}
+
+func NewFollower(reader *deltalog.Reader) *Follower {
+	return &Follower{reader: reader, stores: map[string]*followedStore{}}
+}
+
+// Run polls the log every `interval` until `ctx` is done, a failed poll is
+// logged and retried at the next one.
+func (f *Follower) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.Poll(ctx); err != nil && ctx.Err() == nil {
+			zlog.Warn("polling the delta log failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll loads the stores that appeared in the log and applies the blocks
+// appended to it since the previous poll.
+func (f *Follower) Poll(ctx context.Context) error {
+	topics, err := f.reader.Topics(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, topic := range topics {
+		f.lock.RLock()
+		store, found := f.stores[topic]
+		f.lock.RUnlock()
+
+		if !found {
+			if store, err = f.load(ctx, topic); err != nil {
+				return err
+			}
+		}
+
+		// read outside of the lock, reads aren't blocked by the log store
+		var blocks []*pbsubstreams.BlockScopedData
+		_, err := store.tail.Next(ctx, func(data *pbsubstreams.BlockScopedData) error {
+			blocks = append(blocks, data)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("follow %q: %w", topic, err)
+		}
+
+		f.lock.Lock()
+		f.stores[topic] = store
+		for _, data := range blocks {
+			store.state.Apply(data)
+		}
+		if position := store.tail.Position(); position > 0 && position-1 > f.head {
+			f.head = position - 1
+		}
+		f.lock.Unlock()
+
+		if len(blocks) > 0 {
+			zlog.Debug("store followed", zap.String("store", topic), zap.Int("blocks", len(blocks)), zap.Uint64("position", store.tail.Position()))
+		}
+	}
+	return nil
+}
+
+func (f *Follower) load(ctx context.Context, topic string) (*followedStore, error) {
+	snapshots, err := f.reader.Snapshots(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return &followedStore{tail: f.reader.Tail(topic, 0), state: deltalog.State{}}, nil
+	}
+
+	last := snapshots[len(snapshots)-1]
+	content, from, err := f.reader.StateAt(ctx, topic, last)
+	if err != nil {
+		return nil, err
+	}
+	zlog.Info("store loaded from its snapshot", zap.String("store", topic), zap.Uint64("snapshot", from), zap.Int("keys", len(content)))
+	return &followedStore{tail: f.reader.Tail(topic, last+1), state: content, snapshot: from}, nil
+}
+
+// Head is the last block of the log applied, across the stores.
+func (f *Follower) Head() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.head
+}
+
+// Get answers `query` at the head, its block is ignored.
+func (f *Follower) Get(query Query) (*Result, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	var topics []string
+	for topic := range f.stores {
+		topics = append(topics, topic)
+	}
+	name, err := matchStore(topics, query.Store)
+	if err != nil {
+		return nil, err
+	}
+	store := f.stores[name]
+
+	return result(Query{Store: name, Key: query.Key, Prefix: query.Prefix, Block: f.head}, store.state, store.snapshot), nil
+}
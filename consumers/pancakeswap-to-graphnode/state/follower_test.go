@@ -0,0 +1,89 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollower(t *testing.T) {
+	ctx := context.Background()
+	storeURL := "file://" + filepath.Join(t.TempDir(), "log")
+
+	type block struct {
+		num   uint64
+		step  pbsubstreams.ForkStep
+		delta *pbsubstreams.StoreDelta
+	}
+	write := func(blocks ...block) {
+		t.Helper()
+		s, err := deltalog.New(&deltalog.Config{StoreURL: storeURL, SegmentSize: 100})
+		require.NoError(t, err)
+		for _, b := range blocks {
+			require.NoError(t, s.Write(ctx, &pbsubstreams.BlockScopedData{
+				Step:  b.step,
+				Clock: &pbsubstreams.Clock{Number: b.num},
+				Outputs: []*pbsubstreams.ModuleOutput{
+					{Name: "store_prices", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: []*pbsubstreams.StoreDelta{b.delta}}}},
+				},
+			}))
+		}
+		require.NoError(t, s.Close())
+	}
+	create := &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "price:0xaa", NewValue: []byte("1")}
+	update := &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "price:0xaa", OldValue: []byte("1"), NewValue: []byte("2")}
+
+	write(block{10, pbsubstreams.ForkStep_STEP_IRREVERSIBLE, create}, block{150, pbsubstreams.ForkStep_STEP_IRREVERSIBLE, update})
+	reader, err := deltalog.NewReader(storeURL)
+	require.NoError(t, err)
+	_, err = reader.WriteSnapshots(ctx, "store_prices", 100)
+	require.NoError(t, err)
+
+	f := NewFollower(reader)
+	_, err = f.Get(Query{Store: "prices"})
+	assert.ErrorIs(t, err, ErrStoreNotFound, "not polled yet")
+
+	require.NoError(t, f.Poll(ctx))
+	result, err := f.Get(Query{Store: "prices", Key: "price:0xaa"})
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Store: "store_prices", Block: 150, Snapshot: 100, Key: "price:0xaa", Found: true, Value: "2"}, result)
+
+	// the indexer moves on, then a block is undone
+	bump := &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "price:0xaa", OldValue: []byte("2"), NewValue: []byte("3")}
+	write(block{210, pbsubstreams.ForkStep_STEP_NEW, bump})
+	require.NoError(t, f.Poll(ctx))
+	assert.Equal(t, uint64(210), f.Head())
+	result, err = f.Get(Query{Store: "store_prices"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"price:0xaa": "3"}, result.Values)
+
+	write(block{210, pbsubstreams.ForkStep_STEP_UNDO, bump}, block{211, pbsubstreams.ForkStep_STEP_NEW, &pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "price:0xbb", NewValue: []byte("5")}})
+	require.NoError(t, f.Poll(ctx))
+	result, err = f.Get(Query{Store: "prices", Key: "price:0xaa"})
+	require.NoError(t, err)
+	assert.Equal(t, "2", result.Value)
+
+	s := NewServer(reader, f)
+	require.NoError(t, s.Listen("127.0.0.1:0"))
+	defer s.Close()
+
+	for query, expected := range map[string]string{
+		"store=prices&key=price:0xaa":            `{"store":"store_prices","block":211,"snapshot":100,"key":"price:0xaa","found":true,"value":"2"}`,
+		"store=prices&key=price:0xaa&block=head": `{"store":"store_prices","block":211,"snapshot":100,"key":"price:0xaa","found":true,"value":"2"}`,
+		"store=prices&key=price:0xaa&block=50":   `{"store":"store_prices","block":50,"snapshot":0,"key":"price:0xaa","found":true,"value":"1"}`,
+	} {
+		resp, err := http.Get("http://" + s.Addr() + "/state?" + query)
+		require.NoError(t, err)
+		var body json.RawMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+		assert.JSONEq(t, expected, string(body), query)
+	}
+}
@@ -0,0 +1,7 @@
+package state
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.state", "github.com/streamingfast/substream-pancakeswap/state")
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	"go.uber.org/zap"
+)
+
+// Server serves the reads over HTTP:
+//
+//	GET /state?store=prices&key=price:0x..:usd&block=7000000
+//
+// Without `key`, every key of the store is returned, or the ones starting with
+// `prefix`, like `prefix=price:0x..`. With a follower, reads
+// without `block`, or with `block=head`, are answered from the state it keeps.
+//
+//	GET /topics
+//
+// returns the store modules of the log per namespace, the ones the request is
+// allowed to read, see `RequireTokens`.
+type Server struct {
+	reader   *deltalog.Reader
+	follower *Follower
+	tokens   Tokens
+	tls      *tls.Config
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewServer serves the reads of `reader`, `follower` can be nil.
+func NewServer(reader *deltalog.Reader, follower *Follower) *Server {
+	s := &Server{reader: reader, follower: follower}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", s.state)
+	mux.HandleFunc("/topics", s.topics)
+	s.server = &http.Server{Handler: mux}
+
+	return s
+}
+
+// RequireTokens makes the reads of the namespaces with a token require it as
+// `Authorization: Bearer <token>`, it must be called before `Listen`.
+func (s *Server) RequireTokens(tokens Tokens) {
+	s.tokens = tokens
+}
+
+// SetTLS serves over TLS, it must be called before `Listen`.
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tls = config
+}
+
+// Listen starts serving on `addr` in the background.
+func (s *Server) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("state listen on %q: %w", addr, err)
+	}
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+	s.listener = listener
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			zlog.Warn("state server failed", zap.Error(err))
+		}
+	}()
+	zlog.Info("state server listening", zap.String("addr", listener.Addr().String()), zap.Bool("tls", s.tls != nil))
+	return nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) state(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, fmt.Sprintf("method %s not allowed, use %s", r.Method, http.MethodGet), http.StatusMethodNotAllowed)
+		return
+	}
+
+	values := r.URL.Query()
+	if values.Get("store") == "" {
+		http.Error(w, "store is required", http.StatusBadRequest)
+		return
+	}
+	query := Query{Store: values.Get("store"), Key: values.Get("key"), Prefix: values.Get("prefix")}
+	if namespace, _ := deltalog.SplitTopic(query.Store); !s.tokens.Allows(namespace, r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, fmt.Sprintf("namespace %q requires a token", namespace), http.StatusUnauthorized)
+		return
+	}
+
+	var result *Result
+	var err error
+	if block := values.Get("block"); s.follower != nil && (block == "" || block == "head") {
+		result, err = s.follower.Get(query)
+	} else {
+		if query.Block, err = strconv.ParseUint(block, 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("invalid block %q", block), http.StatusBadRequest)
+			return
+		}
+		result, err = Get(r.Context(), s.reader, query)
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, ErrStoreNotFound) {
+			code = http.StatusNotFound
+		}
+		zlog.Warn("state request failed", zap.String("query", r.URL.RawQuery), zap.Error(err))
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		zlog.Debug("writing state response", zap.Error(err))
+	}
+}
+
+func (s *Server) topics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, fmt.Sprintf("method %s not allowed, use %s", r.Method, http.MethodGet), http.StatusMethodNotAllowed)
+		return
+	}
+
+	namespaces, err := s.reader.Namespaces(r.Context())
+	if err != nil {
+		zlog.Warn("topics request failed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for namespace := range namespaces {
+		if !s.tokens.Allows(namespace, r) {
+			delete(namespaces, namespace)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(namespaces); err != nil {
+		zlog.Debug("writing topics response", zap.Error(err))
+	}
+}
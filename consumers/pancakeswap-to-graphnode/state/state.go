@@ -0,0 +1,112 @@
+// Package state answers reads of the stores at any past block out of a delta
+// log, see the `deltalog` output, from its closest snapshot and the deltas
+// logged after it instead of replaying the modules.
+package state
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+)
+
+// ErrStoreNotFound is returned for stores without deltas in the log.
+var ErrStoreNotFound = errors.New("store not found in the delta log")
+
+type Query struct {
+	// Store is the store module, the `store_` prefix can be omitted,
+	// prefixed by its namespace when it's in one, like `bsc/pancake/pairs`.
+	Store string
+	// Key is the key read, every key of the store starting with Prefix when
+	// empty.
+	Key    string
+	Prefix string
+	Block  uint64
+}
+
+type Result struct {
+	Store string `json:"store"`
+	Block uint64 `json:"block"`
+	// Snapshot is the block of the snapshot the state was built from, 0 when
+	// built from the beginning of the log.
+	Snapshot uint64 `json:"snapshot"`
+
+	Key   string `json:"key,omitempty"`
+	Found bool   `json:"found,omitempty"`
+	Value string `json:"value,omitempty"`
+
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// Get answers `query`, values are strings when valid UTF-8 and `0x` prefixed
+// hex otherwise.
+func Get(ctx context.Context, reader *deltalog.Reader, query Query) (*Result, error) {
+	store, err := resolveStore(ctx, reader, query.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	content, from, err := reader.StateAt(ctx, store, query.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	query.Store = store
+	return result(query, content, from), nil
+}
+
+func result(query Query, content deltalog.State, snapshot uint64) *Result {
+	result := &Result{Store: query.Store, Block: query.Block, Snapshot: snapshot}
+	if query.Key != "" {
+		result.Key = query.Key
+		value, found := content[query.Key]
+		if found {
+			result.Found, result.Value = true, FormatValue(value)
+		}
+		return result
+	}
+
+	result.Values = map[string]string{}
+	for key, value := range content {
+		if strings.HasPrefix(key, query.Prefix) {
+			result.Values[key] = FormatValue(value)
+		}
+	}
+	return result
+}
+
+func resolveStore(ctx context.Context, reader *deltalog.Reader, name string) (string, error) {
+	topics, err := reader.Topics(ctx)
+	if err != nil {
+		return "", err
+	}
+	return matchStore(topics, name)
+}
+
+// matchStore returns the topic of store `name`, the `store_` prefix being
+// optional.
+func matchStore(topics []string, name string) (string, error) {
+	sort.Strings(topics)
+	namespace, module := deltalog.SplitTopic(name)
+	for _, candidate := range []string{name, deltalog.JoinTopic(namespace, "store_"+module)} {
+		i := sort.SearchStrings(topics, candidate)
+		if i < len(topics) && topics[i] == candidate {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q, the log has %v", ErrStoreNotFound, name, topics)
+}
+
+// FormatValue returns `value` as a string when valid UTF-8, as `0x` prefixed
+// hex otherwise.
+func FormatValue(value []byte) string {
+	if utf8.Valid(value) {
+		return string(value)
+	}
+	return "0x" + hex.EncodeToString(value)
+}
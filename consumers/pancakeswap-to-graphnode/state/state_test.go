@@ -0,0 +1,109 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/substream-pancakeswap/sink/deltalog"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReader(t *testing.T) *deltalog.Reader {
+	t.Helper()
+	ctx := context.Background()
+	storeURL := "file://" + filepath.Join(t.TempDir(), "log")
+
+	s, err := deltalog.New(&deltalog.Config{StoreURL: storeURL, SegmentSize: 100})
+	require.NoError(t, err)
+	prices := func(num uint64, deltas ...*pbsubstreams.StoreDelta) *pbsubstreams.BlockScopedData {
+		return &pbsubstreams.BlockScopedData{
+			Step:  pbsubstreams.ForkStep_STEP_IRREVERSIBLE,
+			Clock: &pbsubstreams.Clock{Number: num},
+			Outputs: []*pbsubstreams.ModuleOutput{
+				{Name: "store_prices", Data: &pbsubstreams.ModuleOutput_StoreDeltas{StoreDeltas: &pbsubstreams.StoreDeltas{Deltas: deltas}}},
+			},
+		}
+	}
+	require.NoError(t, s.Write(ctx, prices(10,
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "price:0xaa:usd", NewValue: []byte("1.5")},
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_CREATE, Key: "raw:0xaa", NewValue: []byte{0xff, 0x01}},
+	)))
+	require.NoError(t, s.Write(ctx, prices(20,
+		&pbsubstreams.StoreDelta{Operation: pbsubstreams.StoreDelta_UPDATE, Key: "price:0xaa:usd", OldValue: []byte("1.5"), NewValue: []byte("2.25")},
+	)))
+	require.NoError(t, s.Close())
+
+	reader, err := deltalog.NewReader(storeURL)
+	require.NoError(t, err)
+	return reader
+}
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	reader := newReader(t)
+
+	tests := []struct {
+		name      string
+		query     Query
+		expected  *Result
+		expectErr error
+	}{
+		{"before creation", Query{Store: "prices", Key: "price:0xaa:usd", Block: 9}, &Result{Store: "store_prices", Block: 9, Key: "price:0xaa:usd"}, nil},
+		{"created", Query{Store: "store_prices", Key: "price:0xaa:usd", Block: 15}, &Result{Store: "store_prices", Block: 15, Key: "price:0xaa:usd", Found: true, Value: "1.5"}, nil},
+		{"updated", Query{Store: "prices", Key: "price:0xaa:usd", Block: 20}, &Result{Store: "store_prices", Block: 20, Key: "price:0xaa:usd", Found: true, Value: "2.25"}, nil},
+		{"all keys", Query{Store: "prices", Block: 10}, &Result{Store: "store_prices", Block: 10, Values: map[string]string{"price:0xaa:usd": "1.5", "raw:0xaa": "0xff01"}}, nil},
+		{"prefix", Query{Store: "prices", Prefix: "price:", Block: 20}, &Result{Store: "store_prices", Block: 20, Values: map[string]string{"price:0xaa:usd": "2.25"}}, nil},
+		{"unknown store", Query{Store: "volumes", Block: 10}, nil, ErrStoreNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Get(ctx, reader, test.query)
+			if test.expectErr != nil {
+				assert.ErrorIs(t, err, test.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(newReader(t), nil)
+	require.NoError(t, s.Listen("127.0.0.1:0"))
+	defer s.Close()
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		{"key", "store=prices&key=price:0xaa:usd&block=25", 200, `{"store":"store_prices","block":25,"snapshot":0,"key":"price:0xaa:usd","found":true,"value":"2.25"}`},
+		{"prefix", "store=prices&prefix=raw:&block=25", 200, `{"store":"store_prices","block":25,"snapshot":0,"values":{"raw:0xaa":"0xff01"}}`},
+		{"missing store", "block=25", 400, ""},
+		{"invalid block", "store=prices&block=head", 400, ""},
+		{"unknown store", "store=volumes&block=25", 404, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Get("http://" + s.Addr() + "/state?" + test.query)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+			if test.expectedBody != "" {
+				var body json.RawMessage
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.JSONEq(t, test.expectedBody, string(body))
+			}
+		})
+	}
+}
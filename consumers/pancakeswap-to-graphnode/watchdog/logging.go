@@ -0,0 +1,7 @@
+package watchdog
+
+import (
+	"github.com/streamingfast/logging"
+)
+
+var zlog, _ = logging.PackageLogger("substreams.watchdog", "github.com/streamingfast/substream-pancakeswap/watchdog")
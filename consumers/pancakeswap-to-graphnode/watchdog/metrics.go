@@ -0,0 +1,17 @@
+package watchdog
+
+import (
+	"github.com/streamingfast/dmetrics"
+)
+
+var metrics = dmetrics.NewSet()
+
+var (
+	secondsSinceLastBlock = metrics.NewGauge("stream_seconds_since_last_block", "seconds the stream has been waited on without receiving a block, 0 while the block received is processed")
+	stalled               = metrics.NewGauge("stream_stalled", "1 from the moment the stream is found stalled until it delivers a block again, to alert on")
+	stalls                = metrics.NewCounter("stream_stalls", "streams torn down and opened again from the last cursor because they stalled")
+)
+
+func init() {
+	metrics.Register()
+}
@@ -0,0 +1,131 @@
+// Package watchdog detects a stalled stream, a firehose that stopped sending
+// blocks without closing the connection or a server waiting on an RPC node
+// that hangs, and tears the stream down for it to be opened again from the
+// last cursor instead of leaving the process idle.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"go.uber.org/zap"
+)
+
+// Watchdog times how long the stream it watches is waited on without
+// delivering a block. Past `timeout`, the stream is cancelled and `Stalled`
+// tells the error it ended with is the watchdog's doing.
+//
+// Only the time spent waiting on the stream counts, the time the outputs take
+// to write a block doesn't, and the progress messages of the server don't
+// count as blocks.
+type Watchdog struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu sync.Mutex
+	// waitingSince is when the stream started to be waited on for a block,
+	// zero while a block is processed
+	waitingSince time.Time
+	cancel       context.CancelFunc
+	stalled      bool
+}
+
+func New(timeout time.Duration) (*Watchdog, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("stall timeout must be positive, got %s", timeout)
+	}
+	return &Watchdog{timeout: timeout, now: time.Now}, nil
+}
+
+// Watch returns `stream` watched, `cancel` cancelling the context it was
+// opened with. It replaces the stream watched before.
+func (w *Watchdog) Watch(stream pbsubstreams.Stream_BlocksClient, cancel context.CancelFunc) pbsubstreams.Stream_BlocksClient {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.cancel = cancel
+	w.waitingSince = time.Time{}
+	return &watchedStream{Stream_BlocksClient: stream, watchdog: w}
+}
+
+// Stalled tells whether the stream watched was cancelled because it stalled,
+// the flag is cleared for the next stream.
+func (w *Watchdog) Stalled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stalled := w.stalled
+	w.stalled = false
+	return stalled
+}
+
+// Run checks the stream every tenth of the timeout until `ctx` is done.
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.timeout / 10)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *Watchdog) check() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.waitingSince.IsZero() {
+		secondsSinceLastBlock.SetFloat64(0)
+		return
+	}
+
+	waited := w.now().Sub(w.waitingSince)
+	secondsSinceLastBlock.SetFloat64(waited.Seconds())
+	if waited < w.timeout || w.stalled || w.cancel == nil {
+		return
+	}
+
+	zlog.Warn("stream stalled, tearing it down", zap.Duration("waited", waited), zap.Duration("timeout", w.timeout))
+	stalls.Inc()
+	stalled.SetUint64(1)
+	w.stalled = true
+	w.cancel()
+}
+
+func (w *Watchdog) waiting() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.waitingSince.IsZero() {
+		w.waitingSince = w.now()
+	}
+}
+
+func (w *Watchdog) received() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.waitingSince = time.Time{}
+	stalled.SetUint64(0)
+}
+
+type watchedStream struct {
+	pbsubstreams.Stream_BlocksClient
+	watchdog *Watchdog
+}
+
+func (s *watchedStream) Recv() (*pbsubstreams.Response, error) {
+	s.watchdog.waiting()
+	resp, err := s.Stream_BlocksClient.Recv()
+	if err == nil && resp.GetData() != nil {
+		s.watchdog.received()
+	}
+	return resp, err
+}
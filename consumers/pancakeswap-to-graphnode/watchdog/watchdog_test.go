@@ -0,0 +1,96 @@
+package watchdog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStream delivers the responses of `responses`, then blocks until its
+// context is cancelled.
+type fakeStream struct {
+	pbsubstreams.Stream_BlocksClient
+	ctx       context.Context
+	responses chan *pbsubstreams.Response
+}
+
+func (s *fakeStream) Recv() (*pbsubstreams.Response, error) {
+	select {
+	case resp := <-s.responses:
+		return resp, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	w, err := New(time.Minute)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	w.now = func() time.Time { return now }
+	// w.now is called with the lock held
+	advance := func(d time.Duration) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		now = now.Add(d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	responses := make(chan *pbsubstreams.Response, 2)
+	stream := w.Watch(&fakeStream{ctx: ctx, responses: responses}, cancel)
+
+	responses <- &pbsubstreams.Response{Message: &pbsubstreams.Response_Data{Data: &pbsubstreams.BlockScopedData{}}}
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	// processing a block doesn't count
+	advance(2 * time.Minute)
+	w.check()
+	assert.False(t, w.Stalled())
+
+	// neither do progress messages
+	responses <- &pbsubstreams.Response{Message: &pbsubstreams.Response_Progress{}}
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := stream.Recv()
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return !w.waitingSince.IsZero()
+	}, time.Second, time.Millisecond)
+
+	advance(59 * time.Second)
+	w.check()
+	select {
+	case err := <-done:
+		t.Fatalf("stream ended before the timeout: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	advance(time.Second)
+	w.check()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("stalled stream not cancelled")
+	}
+	assert.True(t, w.Stalled())
+	assert.False(t, w.Stalled())
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(0)
+	assert.EqualError(t, err, "stall timeout must be positive, got 0s")
+}
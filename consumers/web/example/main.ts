@@ -0,0 +1,126 @@
+import { decodeString, loadPackage, storeDeltas, streamBlocks } from '../src';
+import { ForkStep, StoreDelta, StoreDelta_Operation } from '../src/generated/sf/substreams/v1/substreams';
+
+// USD prices of the tokens are kept by store_prices under
+// `dprice:{token}:usd`, see the store schema of the exchange.
+const pricePattern = /^dprice:(0x[0-9a-f]+):usd$/;
+
+interface Row {
+  element: HTMLTableRowElement;
+  price: string;
+}
+
+const rows = new Map<string, Row>();
+const form = document.querySelector<HTMLFormElement>('#connect')!;
+const status = document.querySelector<HTMLParagraphElement>('#status')!;
+const prices = document.querySelector<HTMLTableSectionElement>('#prices')!;
+
+let controller: AbortController | undefined;
+
+form.addEventListener('submit', (event) => {
+  event.preventDefault();
+  controller?.abort();
+  controller = new AbortController();
+
+  const fields = new FormData(form);
+  run(
+    String(fields.get('endpoint')),
+    String(fields.get('token')),
+    String(fields.get('package')),
+    String(fields.get('start')),
+    controller.signal,
+  ).catch((error) => {
+    if (!controller?.signal.aborted) {
+      status.textContent = `Stream failed: ${error}`;
+    }
+  });
+});
+
+async function run(endpoint: string, apiToken: string, packageUrl: string, startBlock: string, signal: AbortSignal) {
+  const pkg = await loadPackage(packageUrl);
+  if (!pkg.modules) {
+    throw new Error(`no modules in ${packageUrl}`);
+  }
+
+  rows.clear();
+  prices.replaceChildren();
+  status.textContent = 'Connecting...';
+
+  const stream = streamBlocks({
+    endpoint,
+    apiToken,
+    modules: pkg.modules,
+    outputModules: ['store_prices'],
+    startBlock,
+    signal,
+  });
+
+  for await (const data of stream) {
+    const blockNum = data.clock?.number ?? '';
+    status.textContent = `Block ${blockNum}`;
+
+    const deltas = storeDeltas(data, 'store_prices');
+    if (data.step === ForkStep.STEP_UNDO) {
+      status.textContent = `Block ${blockNum} undone`;
+      for (const delta of [...deltas].reverse()) {
+        revert(delta, blockNum);
+      }
+      continue;
+    }
+    for (const delta of deltas) {
+      apply(delta, blockNum);
+    }
+  }
+  status.textContent = 'Stream ended.';
+}
+
+function apply(delta: StoreDelta, blockNum: string) {
+  if (delta.operation === StoreDelta_Operation.DELETE) {
+    remove(delta.key);
+  } else {
+    render(delta.key, decodeString(delta.newValue), blockNum);
+  }
+}
+
+function revert(delta: StoreDelta, blockNum: string) {
+  if (delta.operation === StoreDelta_Operation.CREATE) {
+    remove(delta.key);
+  } else {
+    render(delta.key, decodeString(delta.oldValue), blockNum);
+  }
+}
+
+function render(key: string, price: string, blockNum: string) {
+  const token = key.match(pricePattern)?.[1];
+  if (!token) {
+    return;
+  }
+
+  let row = rows.get(token);
+  if (!row) {
+    const element = prices.insertRow();
+    element.insertCell().textContent = token;
+    element.insertCell().className = 'price';
+    element.insertCell();
+    row = { element, price };
+    rows.set(token, row);
+  }
+
+  const [, priceCell, blockCell] = Array.from(row.element.cells);
+  priceCell.textContent = Number(price).toPrecision(8);
+  blockCell.textContent = blockNum;
+
+  if (price !== row.price) {
+    row.element.className = Number(price) > Number(row.price) ? 'up' : 'down';
+    row.price = price;
+  }
+}
+
+function remove(key: string) {
+  const token = key.match(pricePattern)?.[1];
+  if (!token) {
+    return;
+  }
+  rows.get(token)?.element.remove();
+  rows.delete(token);
+}
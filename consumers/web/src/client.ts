@@ -0,0 +1,182 @@
+import { GrpcWebFetchTransport } from '@protobuf-ts/grpcweb-transport';
+import type { IMessageType } from '@protobuf-ts/runtime';
+import { RpcError } from '@protobuf-ts/runtime-rpc';
+import { Any } from './generated/google/protobuf/any';
+import type { Modules } from './generated/sf/substreams/v1/modules';
+import { Package } from './generated/sf/substreams/v1/package';
+import { StreamClient } from './generated/sf/substreams/v1/substreams.client';
+import { BlockScopedData, ForkStep, Request, StoreDelta } from './generated/sf/substreams/v1/substreams';
+
+export interface StreamOptions {
+  // Base URL of a gRPC-web endpoint, like an Envoy proxy in front of the
+  // substreams server, see example/envoy.yaml.
+  endpoint: string;
+  apiToken?: string;
+
+  modules: Modules;
+  outputModules: string[];
+  startBlock?: string;
+  // Exclusive, streams forever when unset.
+  stopBlock?: string;
+  // Resumes after the block of the cursor, `startBlock` is then ignored.
+  cursor?: string;
+  // Defaults to new and undo blocks.
+  forkSteps?: ForkStep[];
+
+  // Consecutive reconnections before giving up, 0 retries forever. The count
+  // resets once a block is received.
+  maxRetries?: number;
+  // Milliseconds before the first reconnection, 1s by default, doubled on each
+  // further one up to `maxRetryDelay`, 30s by default.
+  retryDelay?: number;
+  maxRetryDelay?: number;
+
+  signal?: AbortSignal;
+}
+
+const retryableCodes = ['UNAVAILABLE', 'DEADLINE_EXCEEDED', 'RESOURCE_EXHAUSTED', 'ABORTED', 'INTERNAL', 'UNKNOWN'];
+
+// streamBlocks yields the blocks of the substreams Blocks stream, reconnecting
+// on transient errors. A block is handled once the next one is asked for: a
+// reconnection resumes after it, and `cursor()` of the returned stream is its
+// cursor, the one to persist to resume later.
+export function streamBlocks(options: StreamOptions): BlockStream {
+  return new BlockStream(options);
+}
+
+export class BlockStream implements AsyncIterable<BlockScopedData> {
+  private lastCursor: string;
+
+  constructor(private readonly options: StreamOptions) {
+    this.lastCursor = options.cursor ?? '';
+  }
+
+  cursor(): string {
+    return this.lastCursor;
+  }
+
+  async *[Symbol.asyncIterator](): AsyncIterator<BlockScopedData> {
+    const client = new StreamClient(new GrpcWebFetchTransport({ baseUrl: this.options.endpoint }));
+    const meta: Record<string, string> = {};
+    if (this.options.apiToken) {
+      meta.authorization = `Bearer ${this.options.apiToken}`;
+    }
+
+    // the call is cancelled when the iteration stops early, like on a break
+    const abort = new AbortController();
+    const onAbort = () => abort.abort();
+    this.options.signal?.addEventListener('abort', onAbort);
+    try {
+      yield* this.stream(client, meta, abort.signal);
+    } finally {
+      this.options.signal?.removeEventListener('abort', onAbort);
+      abort.abort();
+    }
+  }
+
+  private async *stream(
+    client: StreamClient,
+    meta: Record<string, string>,
+    signal: AbortSignal,
+  ): AsyncGenerator<BlockScopedData> {
+    const initialDelay = this.options.retryDelay ?? 1000;
+    const maxDelay = this.options.maxRetryDelay ?? 30000;
+    let delay = initialDelay;
+    let retries = 0;
+
+    for (;;) {
+      const call = client.blocks(
+        Request.create({
+          startBlockNum: this.options.startBlock ?? '0',
+          startCursor: this.lastCursor,
+          stopBlockNum: this.options.stopBlock ?? '0',
+          forkSteps: this.options.forkSteps ?? [ForkStep.STEP_NEW, ForkStep.STEP_UNDO],
+          modules: this.options.modules,
+          outputModules: this.options.outputModules,
+        }),
+        { meta, abort: signal },
+      );
+
+      try {
+        for await (const response of call.responses) {
+          if (response.message.oneofKind !== 'data') {
+            continue;
+          }
+          retries = 0;
+          delay = initialDelay;
+
+          const data = response.message.data;
+          yield data;
+          this.lastCursor = data.cursor;
+        }
+        return;
+      } catch (error) {
+        if (signal.aborted || !retryable(error)) {
+          throw error;
+        }
+
+        retries++;
+        if (this.options.maxRetries && retries > this.options.maxRetries) {
+          throw new Error(`giving up after ${this.options.maxRetries} reconnections: ${error}`);
+        }
+        console.warn(`stream failed, reconnecting in ${delay}ms`, error);
+        await sleep(delay);
+        delay = Math.min(delay * 2, maxDelay);
+      }
+    }
+  }
+}
+
+// retryable tells transient errors apart, the fetch of the browser failing on
+// network errors with a TypeError.
+function retryable(error: unknown): boolean {
+  if (error instanceof RpcError) {
+    return retryableCodes.includes(error.code);
+  }
+  return error instanceof TypeError;
+}
+
+function sleep(ms: number): Promise<void> {
+  return new Promise((resolve) => setTimeout(resolve, ms));
+}
+
+// loadPackage fetches a substreams package, built with `substreams pack`.
+export async function loadPackage(url: string): Promise<Package> {
+  const response = await fetch(url);
+  if (!response.ok) {
+    throw new Error(`fetching package ${url}: ${response.status} ${response.statusText}`);
+  }
+  return Package.fromBinary(new Uint8Array(await response.arrayBuffer()));
+}
+
+// mapOutput decodes the output of the map `module` as `type`, undefined when
+// the module has no output for the block.
+export function mapOutput<T extends object>(
+  data: BlockScopedData,
+  module: string,
+  type: IMessageType<T>,
+): T | undefined {
+  const output = data.outputs.find((output) => output.name === module);
+  if (!output || output.data.oneofKind !== 'mapOutput') {
+    return undefined;
+  }
+  return Any.unpack(output.data.mapOutput, type);
+}
+
+// storeDeltas returns the changes of the store `module` for the block.
+// The deltas of undone blocks are to be reverted, from last to first.
+export function storeDeltas(data: BlockScopedData, module: string): StoreDelta[] {
+  const output = data.outputs.find((output) => output.name === module);
+  if (!output || output.data.oneofKind !== 'storeDeltas') {
+    return [];
+  }
+  return output.data.storeDeltas.deltas;
+}
+
+const textDecoder = new TextDecoder();
+
+// decodeString decodes the values of `string`, `int64`, `bigint` and
+// `bigfloat` stores, see the store schema of the exchange.
+export function decodeString(value: Uint8Array): string {
+  return textDecoder.decode(value);
+}
@@ -0,0 +1,5 @@
+export * from './client';
+export * as substreams from './generated/sf/substreams/v1/substreams';
+export * as modules from './generated/sf/substreams/v1/modules';
+export * as pcs from './generated/pcs/v1/pcs';
+export * as database from './generated/pcs/v1/database';
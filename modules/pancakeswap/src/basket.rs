@@ -0,0 +1,71 @@
+use bigdecimal::BigDecimal;
+use substreams::{proto, store};
+
+use crate::decimal;
+use crate::pcs;
+
+/// Tokens of the USD price index, bundled from `basket.csv` at build time: one
+/// `symbol,token,pair` line per pair the price of a token is taken from, the first line is
+/// the header, lines starting with `#` are comments.
+const BASKET: &str = include_str!("../basket.csv");
+
+pub struct Entry {
+    pub symbol: &'static str,
+    pub token: &'static str,
+    pub pair: &'static str,
+}
+
+pub fn entries() -> Vec<Entry> {
+    BASKET
+        .lines()
+        .filter(|line| !line.is_empty() && !line.starts_with('#'))
+        .skip(1)
+        .filter_map(|line| {
+            let fields: Vec<&str> = line.split(',').map(|field| field.trim()).collect();
+            if fields.len() != 3 {
+                return None;
+            }
+
+            Some(Entry {
+                symbol: fields[0],
+                token: fields[1],
+                pair: fields[2],
+            })
+        })
+        .collect()
+}
+
+/// Returns the USD price of `token` in `pair_address`: its price in the other token of the
+/// pair, from the reserves, times the USD price of the other token. `None` while either is
+/// unknown, or when `token` isn't a token of the pair.
+pub fn pair_price_usd(
+    pairs: &store::StoreGet,
+    reserves: &store::StoreGet,
+    prices: &store::StoreGet,
+    pair_address: &str,
+    token: &str,
+) -> Option<BigDecimal> {
+    let pair: pcs::Pair = proto::decode(&pairs.get_last(&format!("pair:{}", pair_address))?).unwrap();
+    let reserve_of = |token_address: &str, side: &str| -> Option<BigDecimal> {
+        reserves
+            .get_last(&format!("reserve:{}:{}:{}", pair_address, token_address, side))
+            .map(|reserve_bytes| decimal::from_store(&reserve_bytes))
+    };
+
+    let reserve0 = reserve_of(&pair.token0_address, "reserve0")?;
+    let reserve1 = reserve_of(&pair.token1_address, "reserve1")?;
+    let (token_reserve, other_reserve, other_address) = if pair.token0_address == token {
+        (reserve0, reserve1, pair.token1_address)
+    } else if pair.token1_address == token {
+        (reserve1, reserve0, pair.token0_address)
+    } else {
+        return None;
+    };
+
+    let other_price_usd = decimal::from_store(&prices.get_last(&format!("dprice:{}:usd", other_address))?);
+    let price_usd = decimal::mul(&decimal::div(&other_reserve, &token_reserve), &other_price_usd);
+    if price_usd.eq(&decimal::zero()) {
+        return None;
+    }
+    Some(price_usd)
+}
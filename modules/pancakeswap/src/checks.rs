@@ -0,0 +1,196 @@
+use std::str::FromStr;
+
+use bigdecimal::{BigDecimal, Zero};
+
+use crate::ids;
+use crate::pcs;
+use crate::pcs::event::Type;
+
+/// Cross-checks the data extracted from a block against invariants of the exchange: an
+/// AMM pair never holds negative reserves, its tokens never change, and a swap takes one
+/// token in and gives the other out. None of them can be broken on chain, a violation is
+/// a decoder bug, reported with the block and transaction to reproduce it.
+pub struct Checker {
+    block_num: u64,
+    block_id: String,
+    pub violations: Vec<pcs::Violation>,
+}
+
+impl Checker {
+    pub fn new(block_num: u64, block_id: &str) -> Checker {
+        Checker {
+            block_num,
+            block_id: block_id.to_string(),
+            violations: vec![],
+        }
+    }
+
+    /// Checks a pair created in the block against `previous`, the pair stored at the
+    /// same address before the block, if any.
+    pub fn check_pair(&mut self, pair: &pcs::Pair, previous: Option<&pcs::Pair>) {
+        if let Some(previous) = previous {
+            if previous.token0_address != pair.token0_address || previous.token1_address != pair.token1_address {
+                self.report(
+                    "pair_tokens_changed",
+                    format!(
+                        "pair tokens {}/{} became {}/{}",
+                        previous.token0_address, previous.token1_address, pair.token0_address, pair.token1_address
+                    ),
+                    &pair.creation_transaction_id,
+                    pair.log_ordinal,
+                    &pair.address,
+                    "",
+                );
+            }
+        }
+    }
+
+    pub fn check_reserve(&mut self, reserve: &pcs::Reserve) {
+        let context = Context {
+            transaction_id: ids::transaction_id(&reserve.id),
+            log_ordinal: reserve.log_ordinal,
+            pair_address: &reserve.pair_address,
+            entity_id: &reserve.id,
+        };
+        self.check_amounts("negative_reserve", &context, &[("reserve0", &reserve.reserve0), ("reserve1", &reserve.reserve1)]);
+    }
+
+    /// Checks an event of `map_burn_swaps_events` against `pair`, the pair at its address
+    /// in `store_pairs`.
+    pub fn check_event(&mut self, event: &pcs::Event, pair: Option<&pcs::Pair>) {
+        let context = Context {
+            transaction_id: &event.transaction_id,
+            log_ordinal: event.log_ordinal,
+            pair_address: &event.pair_address,
+            entity_id: &event.id,
+        };
+
+        if let Some(pair) = pair {
+            if event.token0 != pair.token0_address || event.token1 != pair.token1_address {
+                self.report_in(
+                    &context,
+                    "event_tokens_mismatch",
+                    format!(
+                        "event tokens {}/{} aren't the pair tokens {}/{}",
+                        event.token0, event.token1, pair.token0_address, pair.token1_address
+                    ),
+                );
+            }
+        }
+
+        match event.r#type.as_ref() {
+            Some(Type::Swap(swap)) => self.check_swap(&context, event, swap),
+            Some(Type::Mint(mint)) => {
+                self.check_amounts("negative_amount", &context, &[("amount0", &mint.amount0), ("amount1", &mint.amount1)]);
+            }
+            Some(Type::Burn(burn)) => {
+                self.check_amounts("negative_amount", &context, &[("amount0", &burn.amount0), ("amount1", &burn.amount1)]);
+            }
+            None => {}
+        }
+    }
+
+    fn check_swap(&mut self, context: &Context, event: &pcs::Event, swap: &pcs::Swap) {
+        let amounts = [
+            ("amount0_in", &swap.amount0_in),
+            ("amount1_in", &swap.amount1_in),
+            ("amount0_out", &swap.amount0_out),
+            ("amount1_out", &swap.amount1_out),
+        ];
+        if !self.check_amounts("negative_amount", context, &amounts) {
+            return;
+        }
+
+        let positive = |value: &str| parse(value).map_or(false, |amount| amount > BigDecimal::zero());
+        let (amount0_in, amount1_in) = (positive(&swap.amount0_in), positive(&swap.amount1_in));
+        let (amount0_out, amount1_out) = (positive(&swap.amount0_out), positive(&swap.amount1_out));
+
+        if !(amount0_in && amount1_out || amount1_in && amount0_out) {
+            self.report_in(
+                context,
+                "swap_direction",
+                format!(
+                    "swap takes {} token0 and {} token1 in, gives {} token0 and {} token1 out, no token goes in for the other",
+                    swap.amount0_in, swap.amount1_in, swap.amount0_out, swap.amount1_out
+                ),
+            );
+            return;
+        }
+
+        // token_in is empty when the price impact is unknown
+        let token_in_goes_in = if swap.token_in == event.token0 {
+            amount0_in
+        } else if swap.token_in == event.token1 {
+            amount1_in
+        } else {
+            false
+        };
+        if !swap.token_in.is_empty() && !token_in_goes_in {
+            self.report_in(
+                context,
+                "swap_token_in",
+                format!("swap token in {} isn't the pair token with an amount in", swap.token_in),
+            );
+        }
+    }
+
+    /// Reports the negative and unparsable values of `amounts`, named after their field,
+    /// under `check`. Returns whether they all are valid.
+    fn check_amounts(&mut self, check: &str, context: &Context, amounts: &[(&str, &String)]) -> bool {
+        let mut valid = true;
+        for (field, value) in amounts {
+            match parse(value) {
+                None => {
+                    self.report_in(context, "invalid_amount", format!("{} {:?} isn't a decimal", field, value));
+                    valid = false;
+                }
+                Some(amount) if amount < BigDecimal::zero() => {
+                    self.report_in(context, check, format!("{} is negative: {}", field, value));
+                    valid = false;
+                }
+                Some(_) => {}
+            }
+        }
+        valid
+    }
+
+    fn report_in(&mut self, context: &Context, check: &str, message: String) {
+        self.report(
+            check,
+            message,
+            context.transaction_id,
+            context.log_ordinal,
+            context.pair_address,
+            context.entity_id,
+        );
+    }
+
+    fn report(&mut self, check: &str, message: String, transaction_id: &str, log_ordinal: u64, pair_address: &str, entity_id: &str) {
+        self.violations.push(pcs::Violation {
+            check: check.to_string(),
+            message,
+            block_num: self.block_num,
+            block_id: self.block_id.clone(),
+            transaction_id: transaction_id.to_string(),
+            log_ordinal,
+            pair_address: pair_address.to_string(),
+            entity_id: entity_id.to_string(),
+        });
+    }
+}
+
+/// Where a checked entity comes from.
+struct Context<'a> {
+    transaction_id: &'a str,
+    log_ordinal: u64,
+    pair_address: &'a str,
+    entity_id: &'a str,
+}
+
+// empty amounts are left unset by the decoder, zero
+fn parse(value: &str) -> Option<BigDecimal> {
+    if value.is_empty() {
+        return Some(BigDecimal::zero());
+    }
+    BigDecimal::from_str(value).ok()
+}
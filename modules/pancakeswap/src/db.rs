@@ -6,7 +6,7 @@ use substreams::{log, proto, store};
 use crate::pb::database::table_change::Operation;
 use crate::pb::database::{DatabaseChanges, Field, TableChange};
 use crate::pcs::{Burn, Event, Events, Mint, Swap};
-use crate::{field, field_create_string, field_from_strings, pb, pcs, utils, Type};
+use crate::{factory, field, field_create_string, field_from_strings, pb, pcs, utils, Type};
 
 const PANCAKE_FACTORY: &str = "ca143ce32fe78f1f7019d7d551a6402fc5350c73";
 
@@ -29,6 +29,7 @@ pub fn process(
     reserves_deltas: store::Deltas,
     events: Events,
     pcs_tokens_store: &store::StoreGet,
+    pairs_store: &store::StoreGet,
 ) -> DatabaseChanges {
     let items = join_sort_deltas(
         pair_deltas,
@@ -51,9 +52,15 @@ pub fn process(
                 handle_pair_delta(delta, &block, &mut database_changes, pcs_tokens_store)
             }
             Item::PcsTokenDelta(delta) => handle_token_delta(delta, &mut database_changes, block),
-            Item::TotalDelta(delta) => handle_total_delta(delta, &mut database_changes, block),
-            Item::VolumeDelta(delta) => handle_volume_delta(delta, &mut database_changes, block),
-            Item::ReserveDelta(delta) => handle_reserves_delta(delta, &mut database_changes, block),
+            Item::TotalDelta(delta) => {
+                handle_total_delta(delta, &mut database_changes, block, pairs_store)
+            }
+            Item::VolumeDelta(delta) => {
+                handle_volume_delta(delta, &mut database_changes, block, pairs_store)
+            }
+            Item::ReserveDelta(delta) => {
+                handle_reserves_delta(delta, &mut database_changes, block, pairs_store)
+            }
             Item::Event(event) => handle_events(event, &mut database_changes, block),
         }
     }
@@ -71,7 +78,15 @@ fn handle_pair_delta(
         return;
     }
 
+    // the same pair is also stored under its `tokens:` and factory namespaced keys
+    if !delta.key.starts_with("pair:") {
+        return;
+    }
+
     let pair: pcs::Pair = proto::decode(&delta.new_value).unwrap();
+    if pair.factory_address != factory::PANCAKESWAP_V2_FACTORY {
+        return;
+    }
 
     let token0 = utils::get_last_token(&pcs_tokens_store, pair.token0_address.as_str());
     let token1 = utils::get_last_token(&pcs_tokens_store, pair.token1_address.as_str());
@@ -115,9 +130,20 @@ fn handle_token_delta(delta: StoreDelta, changes: &mut DatabaseChanges, block: &
     });
 }
 
-fn handle_total_delta(delta: StoreDelta, changes: &mut DatabaseChanges, block: &Clock) {
-    let parts: Vec<&str> = delta.key.split(":").collect();
+fn handle_total_delta(
+    delta: StoreDelta,
+    changes: &mut DatabaseChanges,
+    block: &Clock,
+    pairs_store: &store::StoreGet,
+) {
+    let parts = match pancake_factory_key_parts(&delta.key) {
+        None => return,
+        Some(parts) => parts,
+    };
     let prefix = parts[0];
+    if !pancake_pair_key(&parts, pairs_store) {
+        return;
+    }
     let mut operation = delta.operation;
     let (table, pk, fields) = match prefix {
         "pair" => {
@@ -187,9 +213,20 @@ fn handle_total_delta(delta: StoreDelta, changes: &mut DatabaseChanges, block: &
     })
 }
 
-fn handle_volume_delta(delta: StoreDelta, changes: &mut DatabaseChanges, block: &Clock) {
-    let parts: Vec<&str> = delta.key.split(":").collect();
+fn handle_volume_delta(
+    delta: StoreDelta,
+    changes: &mut DatabaseChanges,
+    block: &Clock,
+    pairs_store: &store::StoreGet,
+) {
+    let parts = match pancake_factory_key_parts(&delta.key) {
+        None => return,
+        Some(parts) => parts,
+    };
     let prefix = parts[0];
+    if !pancake_pair_key(&parts, pairs_store) {
+        return;
+    }
 
     let mut operation = delta.operation;
     let (table, pk, fields) = match prefix {
@@ -330,9 +367,17 @@ fn handle_volume_delta(delta: StoreDelta, changes: &mut DatabaseChanges, block:
     })
 }
 
-fn handle_reserves_delta(delta: StoreDelta, changes: &mut DatabaseChanges, block: &Clock) {
+fn handle_reserves_delta(
+    delta: StoreDelta,
+    changes: &mut DatabaseChanges,
+    block: &Clock,
+    pairs_store: &store::StoreGet,
+) {
     let parts: Vec<&str> = delta.key.split(":").collect();
     let prefix = parts[0];
+    if !pancake_pair_key(&parts, pairs_store) {
+        return;
+    }
 
     let mut operation = delta.operation;
 
@@ -423,6 +468,10 @@ fn handle_reserves_delta(delta: StoreDelta, changes: &mut DatabaseChanges, block
 }
 
 fn handle_events(event: Event, changes: &mut DatabaseChanges, block: &Clock) {
+    if event.factory_address != factory::PANCAKESWAP_V2_FACTORY {
+        return;
+    }
+
     match event.r#type.as_ref().unwrap() {
         Type::Swap(swap) => handle_swap_event(&swap, &event, changes, block),
         Type::Burn(burn) => handle_burn_event(&burn, &event, changes, block),
@@ -507,6 +556,39 @@ fn handle_mint_event(mint: &Mint, event: &Event, changes: &mut DatabaseChanges,
     });
 }
 
+// The `pancake_factory` and `pancake_day_data` tables only cover PancakeSwap v2, their
+// `global` and `global_day` values are read from the keys namespaced by that factory,
+// the un-namespaced ones aggregate all factories and are skipped.
+fn pancake_factory_key_parts(key: &String) -> Option<Vec<&str>> {
+    let parts: Vec<&str> = key.split(":").collect();
+    match parts[0] {
+        "global" | "global_day" => None,
+        factory::PANCAKESWAP_V2_FACTORY => Some(parts[1..].to_vec()),
+        namespace if namespace.starts_with("0x") => None,
+        _ => Some(parts),
+    }
+}
+
+// Like the `pancake_factory` tables, the `pair`, `pair_day_data` and `pair_hour_data` tables
+// only hold the pairs of PancakeSwap v2, `false` when the key of `parts` is about a pair of
+// another factory. The keys of the other entities are kept.
+fn pancake_pair_key(parts: &Vec<&str>, pairs_store: &store::StoreGet) -> bool {
+    let pair_address = match parts[0] {
+        "pair" | "price" | "reserve" => parts[1],
+        "pair_day" | "pair_hour" => parts[2],
+        _ => return true,
+    };
+    is_pancake_pair(pair_address, pairs_store)
+}
+
+fn is_pancake_pair(pair_address: &str, pairs_store: &store::StoreGet) -> bool {
+    let key = factory::namespaced(
+        factory::PANCAKESWAP_V2_FACTORY,
+        format!("pair:{}", pair_address),
+    );
+    pairs_store.get_last(&key).is_some()
+}
+
 fn join_sort_deltas(
     pair_deltas: store::Deltas,
     pcs_token_deltas: store::Deltas,
@@ -0,0 +1,65 @@
+use std::ops::{Add, Div, Mul};
+use std::str;
+use std::str::FromStr;
+
+use bigdecimal::{BigDecimal, FromPrimitive, One, Zero};
+use num_bigint::{BigInt, BigUint, Sign};
+
+/// Significant digits of the prices and volumes. Token amounts are scaled by their
+/// decimals exactly, every product and quotient is then brought to this precision so
+/// modules computing the same value write the same string to their stores.
+pub const PRECISION: u64 = 100;
+
+pub fn zero() -> BigDecimal {
+    round(BigDecimal::zero())
+}
+
+pub fn one() -> BigDecimal {
+    round(BigDecimal::one())
+}
+
+pub fn round(value: BigDecimal) -> BigDecimal {
+    value.with_prec(PRECISION)
+}
+
+/// Converts the big-endian raw `amount` of a token with `decimals` decimals, 1.5 being
+/// 1500000000000000000 for a token with 18 decimals.
+pub fn to_decimal(amount: &[u8], decimals: u64) -> BigDecimal {
+    let digits = BigInt::from_biguint(Sign::Plus, BigUint::from_bytes_be(amount));
+    round(BigDecimal::new(digits, decimals as i64))
+}
+
+/// Parses a decimal written by a module, panics on anything else.
+pub fn parse(value: &str) -> BigDecimal {
+    round(BigDecimal::from_str(value).unwrap())
+}
+
+/// Parses a decimal read from a store.
+pub fn from_store(value: &[u8]) -> BigDecimal {
+    parse(str::from_utf8(value).unwrap())
+}
+
+pub fn mul(a: &BigDecimal, b: &BigDecimal) -> BigDecimal {
+    round(a.mul(b))
+}
+
+/// Divides `a` by `b`, zero when `b` is zero: a price against an empty reserve is
+/// reported as zero, which every module already treats as unknown.
+pub fn div(a: &BigDecimal, b: &BigDecimal) -> BigDecimal {
+    if b.is_zero() {
+        return zero();
+    }
+    round(a.div(b))
+}
+
+/// Averages the known values, zero when none is.
+pub fn average(values: &[Option<BigDecimal>]) -> BigDecimal {
+    let known: Vec<&BigDecimal> = values.iter().flatten().collect();
+    if known.is_empty() {
+        return zero();
+    }
+
+    let count = BigDecimal::from_usize(known.len()).unwrap();
+    let sum = known.into_iter().fold(zero(), |sum, value| sum.add(value));
+    div(&sum, &count)
+}
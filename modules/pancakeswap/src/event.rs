@@ -1,6 +1,4 @@
-use std::ops::{Add, Div, Mul};
-use std::str;
-use std::str::FromStr;
+use std::ops::Add;
 
 use bigdecimal::{BigDecimal, FromPrimitive};
 use num_bigint::BigUint;
@@ -8,8 +6,7 @@ use substreams::store;
 
 use crate::event::pcs_event::Event;
 use crate::pcs::event::Type::{Burn, Mint, Swap};
-use crate::utils::{convert_token_to_decimal, zero_big_decimal};
-use crate::{address_pretty, pb, pcs};
+use crate::{address_pretty, decimal, impact, pb, pcs};
 
 pub fn is_pair_created_event(sig: &str) -> bool {
     /* keccak value for PairCreated(address,address,address,uint256) */
@@ -112,7 +109,7 @@ pub fn process_mint(
         amount0: amount0.to_string(),
         amount1: amount1.to_string(),
         amount_usd: amount_usd.to_string(),
-        liquidity: convert_token_to_decimal(tr2.unwrap().value.as_slice(), &18).to_string(),
+        liquidity: decimal::to_decimal(tr2.unwrap().value.as_slice(), 18).to_string(),
         fee_liquidity: "".to_string(),
     };
 
@@ -122,7 +119,7 @@ pub fn process_mint(
         {
             mint.fee_to = address_pretty(tr1.unwrap().to.as_slice());
             mint.fee_liquidity =
-                convert_token_to_decimal(tr1.unwrap().value.as_slice(), &18).to_string();
+                decimal::to_decimal(tr1.unwrap().value.as_slice(), 18).to_string();
         }
     }
 
@@ -159,14 +156,14 @@ pub fn process_burn(
         amount0: amount0.to_string(),
         amount1: amount1.to_string(),
         amount_usd: amount_usd.to_string(),
-        liquidity: convert_token_to_decimal(tr2.unwrap().value.as_slice(), &18).to_string(),
+        liquidity: decimal::to_decimal(tr2.unwrap().value.as_slice(), 18).to_string(),
         fee_liquidity: "".to_string(),
     };
 
     if tr1.is_some() {
         burn.fee_to = address_pretty(tr1.unwrap().to.as_slice());
         burn.fee_liquidity =
-            convert_token_to_decimal(tr1.unwrap().value.as_slice(), &18).to_string();
+            decimal::to_decimal(tr1.unwrap().value.as_slice(), 18).to_string();
     }
 
     base_event.r#type = Some(Burn(burn));
@@ -186,22 +183,10 @@ pub fn process_swap(
     let log_ordinal = pair_swap_event.unwrap().log_index;
     let swap_event = pair_swap_event.unwrap();
 
-    let amount0_in = convert_token_to_decimal(
-        pair_swap_event.unwrap().amount0_in.as_slice(),
-        &token0_decimals,
-    );
-    let amount1_in = convert_token_to_decimal(
-        pair_swap_event.unwrap().amount1_in.as_slice(),
-        &token1_decimals,
-    );
-    let amount0_out = convert_token_to_decimal(
-        pair_swap_event.unwrap().amount0_out.as_slice(),
-        &token0_decimals,
-    );
-    let amount1_out = convert_token_to_decimal(
-        pair_swap_event.unwrap().amount1_out.as_slice(),
-        &token1_decimals,
-    );
+    let amount0_in = decimal::to_decimal(pair_swap_event.unwrap().amount0_in.as_slice(), token0_decimals);
+    let amount1_in = decimal::to_decimal(pair_swap_event.unwrap().amount1_in.as_slice(), token1_decimals);
+    let amount0_out = decimal::to_decimal(pair_swap_event.unwrap().amount0_out.as_slice(), token0_decimals);
+    let amount1_out = decimal::to_decimal(pair_swap_event.unwrap().amount1_out.as_slice(), token1_decimals);
 
     let amount0_total = amount0_out.clone().add(amount0_in.clone());
     let amount1_total = amount1_out.clone().add(amount1_in.clone());
@@ -238,8 +223,8 @@ pub fn process_swap(
         &pair.token1_address,
     ));
 
-    let derived_amount_bnb = average_floats(&big_decimals_bnb);
-    let tracked_amount_usd = average_floats(&big_decimals_usd);
+    let derived_amount_bnb = decimal::average(&big_decimals_bnb);
+    let tracked_amount_usd = decimal::average(&big_decimals_usd);
 
     let token0_trade_volume: BigDecimal = amount1_in.clone().add(&amount0_out);
     let token1_trade_volume: BigDecimal = amount0_in.clone().add(&amount1_out);
@@ -251,7 +236,18 @@ pub fn process_swap(
     let token0_volume = &amount0_total;
     let token1_volume = &amount1_total;
 
-    let swap = pcs::Swap {
+    // `prices_store` is `store_reserves`, see the inputs of `map_burn_swaps_events`
+    let impact = impact::compute_price_impact(
+        prices_store,
+        log_ordinal,
+        pair,
+        &amount0_in,
+        &amount1_in,
+        &amount0_out,
+        &amount1_out,
+    );
+
+    let mut swap = pcs::Swap {
         id: swap_id.to_string(),
         sender: address_pretty(pair_swap_event.unwrap().sender.as_slice()),
         to: address_pretty(pair_swap_event.unwrap().to.as_slice()),
@@ -270,7 +266,16 @@ pub fn process_swap(
         volume_token0: token0_volume.to_string(),
         volume_token1: token1_volume.to_string(),
         log_address: String::from_utf8(swap_event.clone().log_address).unwrap(),
+        ..Default::default()
     };
+    if let Some(impact) = impact {
+        swap.token_in = impact.token_in;
+        swap.mid_price = impact.mid_price.to_string();
+        swap.execution_price = impact.execution_price.to_string();
+        swap.post_trade_mid_price = impact.post_trade_mid_price.to_string();
+        swap.price_impact = impact.price_impact.to_string();
+        swap.slippage = impact.slippage.to_string();
+    }
 
     base_event.r#type = Some(Swap(swap));
     base_event.log_ordinal = log_ordinal;
@@ -285,43 +290,37 @@ fn convert_prices(
     token0_decimals: u64,
     token1_decimals: u64,
 ) -> (BigDecimal, BigDecimal, BigDecimal) {
-    let token0_amount = convert_token_to_decimal(amount0, &token0_decimals);
-    let token1_amount = convert_token_to_decimal(amount1, &token1_decimals);
+    let token0_amount = decimal::to_decimal(amount0, token0_decimals);
+    let token1_amount = decimal::to_decimal(amount1, token1_decimals);
 
     let derived_bnb0_big_decimal = match prices_store.get_at(
         *log_ordinal,
         &format!("dprice:{}:bnb", pair.token0_address),
     ) {
-        None => zero_big_decimal(),
-        Some(derived_bnb0_bytes) => {
-            BigDecimal::from_str(str::from_utf8(derived_bnb0_bytes.as_slice()).unwrap()).unwrap()
-        }
+        None => decimal::zero(),
+        Some(derived_bnb0_bytes) => decimal::from_store(&derived_bnb0_bytes)
     };
 
     let derived_bnb1_big_decimal = match prices_store.get_at(
         *log_ordinal,
         &format!("dprice:{}:bnb", pair.token1_address),
     ) {
-        None => zero_big_decimal(),
-        Some(derived_bnb1_bytes) => {
-            BigDecimal::from_str(str::from_utf8(derived_bnb1_bytes.as_slice()).unwrap()).unwrap()
-        }
+        None => decimal::zero(),
+        Some(derived_bnb1_bytes) => decimal::from_store(&derived_bnb1_bytes)
     };
 
     let usd_price_big_decimal =
         match prices_store.get_at(*log_ordinal, &format!("dprice:usd:bnb")) {
-            None => zero_big_decimal(),
-            Some(usd_price_bytes) => {
-                BigDecimal::from_str(str::from_utf8(usd_price_bytes.as_slice()).unwrap()).unwrap()
-            }
+            None => decimal::zero(),
+            Some(usd_price_bytes) => decimal::from_store(&usd_price_bytes)
         };
 
-    let derived_bnb0_mul_token0_amount = derived_bnb0_big_decimal.mul(&token0_amount);
-    let derived_bnb1_mul_token1_amount = derived_bnb1_big_decimal.mul(&token1_amount);
+    let derived_bnb0_mul_token0_amount = decimal::mul(&derived_bnb0_big_decimal, &token0_amount);
+    let derived_bnb1_mul_token1_amount = decimal::mul(&derived_bnb1_big_decimal, &token1_amount);
 
     let sum_derived_bnb = derived_bnb0_mul_token0_amount.add(derived_bnb1_mul_token1_amount);
 
-    let amount_total_usd = sum_derived_bnb.mul(usd_price_big_decimal);
+    let amount_total_usd = decimal::mul(&sum_derived_bnb, &usd_price_big_decimal);
 
     return (token0_amount, token1_amount, amount_total_usd);
 }
@@ -339,31 +338,12 @@ fn get_derived_price(
             &format!("dprice:{}:{}", *token_addr, derived_token),
         )
         .unwrap();
-    let usd_price =
-        BigDecimal::from_str(str::from_utf8(usd_price_bytes.as_slice()).unwrap()).unwrap();
-    if usd_price.eq(&zero_big_decimal()) {
+    let usd_price = decimal::from_store(&usd_price_bytes);
+    if usd_price.eq(&decimal::zero()) {
         return None;
     }
 
-    return Some((token_amount).mul(usd_price));
-}
-
-fn average_floats(big_decimals: &Vec<Option<BigDecimal>>) -> BigDecimal {
-    let mut sum = zero_big_decimal();
-    let mut count: f64 = 0.0;
-    for big_decimal_option in big_decimals {
-        if (*big_decimal_option).is_none() {
-            continue;
-        }
-        sum = sum.add((*big_decimal_option).as_ref().unwrap());
-        count = count + 1.0;
-    }
-
-    if count.eq(&0.0) {
-        return sum;
-    }
-
-    return sum.div(BigDecimal::from_f64(count).unwrap());
+    return Some(decimal::mul(token_amount, &usd_price));
 }
 
 fn new_pair_created_event(log: pb::eth::Log) -> PcsEvent {
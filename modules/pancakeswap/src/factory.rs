@@ -0,0 +1,27 @@
+/// Factories whose pairs are tracked, all are UniswapV2 forks emitting the same
+/// PairCreated, Sync, Swap, Mint and Burn events.
+pub const PANCAKESWAP_V1_FACTORY: &str = "0xbcfccbde45ce874adcb698cc183debcf17952812";
+pub const PANCAKESWAP_V2_FACTORY: &str = "0xca143ce32fe78f1f7019d7d551a6402fc5350c73";
+pub const APESWAP_FACTORY: &str = "0x0841bd0b734e4f5853f0dd8d7ea041c241fb0da6";
+pub const BISWAP_FACTORY: &str = "0x858e3312ed3a876947ea49d572a7c42de08af7ee";
+
+pub const FACTORIES: [&str; 4] = [
+    PANCAKESWAP_V1_FACTORY,
+    PANCAKESWAP_V2_FACTORY,
+    APESWAP_FACTORY,
+    BISWAP_FACTORY,
+];
+
+/// Only the pairs of this factory are used to route token prices, the same token
+/// pair exists on several factories and the `tokens:` keys can only point to one.
+pub const PRICING_FACTORY: &str = PANCAKESWAP_V2_FACTORY;
+
+pub fn is_factory(address: &str) -> bool {
+    FACTORIES.contains(&address)
+}
+
+/// Prefixes `key` with the factory, `<factory>:<key>`. Keys without a namespace
+/// aggregate all the factories.
+pub fn namespaced(factory_address: &str, key: String) -> String {
+    format!("{}:{}", factory_address, key)
+}
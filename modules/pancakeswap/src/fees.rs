@@ -0,0 +1,17 @@
+use bigdecimal::BigDecimal;
+
+use crate::decimal;
+
+/// PancakeSwap v2 charges 0.25% on the input amount of every swap, 0.17% goes back
+/// to the liquidity providers and the remaining 0.08% to the protocol (treasury and
+/// CAKE buyback).
+pub const LP_FEE_RATE: &str = "0.0017";
+pub const PROTOCOL_FEE_RATE: &str = "0.0008";
+
+/// Splits the fees paid on `amount` into the (lp, protocol) parts.
+pub fn compute_fees(amount: &BigDecimal) -> (BigDecimal, BigDecimal) {
+    let lp_fee = decimal::mul(amount, &decimal::parse(LP_FEE_RATE));
+    let protocol_fee = decimal::mul(amount, &decimal::parse(PROTOCOL_FEE_RATE));
+
+    (lp_fee, protocol_fee)
+}
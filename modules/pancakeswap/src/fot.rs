@@ -0,0 +1,278 @@
+use std::collections::HashMap;
+use std::ops::Sub;
+use std::str::FromStr;
+
+use bigdecimal::BigDecimal;
+use num_bigint::BigUint;
+use substreams::{proto, store};
+
+use crate::{address_pretty, decimal, event, ids, params, pb, pcs, utils};
+
+/// Parameter telling whether prices derived through a fee-on-transfer token are adjusted by its
+/// tax rate, a holder only realizes `(1 - tax_rate)` of the reserve based price when selling.
+/// Set it to `false` to keep the raw reserve based prices, they're adjusted when it isn't set.
+pub const ADJUSTED_ACCOUNTING_PARAM: &str = "adjusted-accounting";
+
+/// Differences under this rate are rounding, not taxes.
+const TAX_RATE_TOLERANCE: &str = "0.0001";
+
+/// Returns whether prices are adjusted by tax rates, see `ADJUSTED_ACCOUNTING_PARAM`.
+pub fn adjusted_accounting() -> bool {
+    params::get_bool(ADJUSTED_ACCOUNTING_PARAM, true)
+}
+
+/// Compares, for each swap of a transaction, the amount of the input token that was
+/// sent for it with the amount the pair accounted for (`amountIn` of the Swap, derived
+/// by the pair from its balance). A transfer tax makes the pair receive less than what
+/// was sent, see `TransferTracker` for what is taken as sent.
+pub fn detect_token_taxes(blk: &pb::eth::Block, pairs_store: &store::StoreGet, token_decimals: &store::StoreGet) -> Vec<pcs::TokenTax> {
+    let mut token_taxes: Vec<pcs::TokenTax> = vec![];
+
+    for trx in &blk.transaction_traces {
+        let receipt = match trx.receipt.as_ref() {
+            None => continue,
+            Some(receipt) => receipt,
+        };
+
+        let mut tracker = TransferTracker::default();
+
+        for log in &receipt.logs {
+            if log.topics.len() == 0 {
+                continue;
+            }
+            let sig = hex::encode(&log.topics[0]);
+
+            if event::is_pair_transfer_event(sig.as_str()) && log.topics.len() == 3 && log.data.len() == 32 {
+                let to = address_pretty(&log.topics[2][12..]);
+                let to_pair = pairs_store.get_last(&format!("pair:{}", to)).is_some();
+
+                tracker.transfer(
+                    &address_pretty(&log.address),
+                    &address_pretty(&log.topics[1][12..]),
+                    &to,
+                    to_pair,
+                    BigUint::from_bytes_be(&log.data),
+                );
+                continue;
+            }
+
+            if event::is_pair_mint_event(sig.as_str()) {
+                tracker.mint(&address_pretty(&log.address));
+                continue;
+            }
+
+            if !event::is_pair_swap_event(sig.as_str()) || log.data.len() != 128 {
+                continue;
+            }
+
+            let pair_address = address_pretty(&log.address);
+            let pair: pcs::Pair = match pairs_store.get_last(&format!("pair:{}", pair_address)) {
+                None => continue,
+                Some(pair_bytes) => proto::decode(&pair_bytes).unwrap(),
+            };
+
+            let inputs = vec![
+                (pair.token0_address.clone(), &log.data[0..32]),
+                (pair.token1_address.clone(), &log.data[32..64]),
+            ];
+            for (token_address, amount_in) in inputs {
+                let sent = match tracker.swap(&pair_address, &token_address) {
+                    None => continue,
+                    Some(sent) => sent,
+                };
+                let received = BigUint::from_bytes_be(amount_in);
+                let tax_rate = match tax_rate(&sent, &received) {
+                    None => continue,
+                    Some(tax_rate) => tax_rate,
+                };
+
+                let decimals = match utils::get_token_decimals(token_decimals, &token_address) {
+                    None => continue,
+                    Some(decimals) => decimals,
+                };
+
+                token_taxes.push(pcs::TokenTax {
+                    id: format!("{}:{}", ids::log_id(&blk.hash, &trx.hash, log.index), token_address),
+                    token_address,
+                    pair_address: pair_address.clone(),
+                    transaction_id: address_pretty(&trx.hash),
+                    transferred: decimal::to_decimal(&sent.to_bytes_be(), decimals).to_string(),
+                    received: decimal::to_decimal(&received.to_bytes_be(), decimals).to_string(),
+                    tax_rate: tax_rate.to_string(),
+                    log_ordinal: log.block_index as u64,
+                });
+            }
+        }
+    }
+
+    token_taxes
+}
+
+/// Follows the Transfer events of a transaction to tell, at each swap, how much of the
+/// input token was sent for it. That's what was transferred to the pair since its last
+/// swap, plus what the senders of these transfers sent to addresses that aren't pairs:
+/// some taxed tokens log the net amount as the transfer to the pair, the tax being a
+/// transfer of its own from the sender to a fee wallet or to the token. Transfers to other
+/// pairs are left out, a swap split across pairs isn't taken for a tax.
+#[derive(Default)]
+struct TransferTracker {
+    // (pair, token) -> amount transferred to the pair since its last swap, and the senders
+    to_pairs: HashMap<(String, String), (BigUint, Vec<String>)>,
+    // (sender, token) -> amount sent to addresses that aren't pairs
+    elsewhere: HashMap<(String, String), BigUint>,
+}
+
+impl TransferTracker {
+    fn transfer(&mut self, token: &str, from: &str, to: &str, to_pair: bool, amount: BigUint) {
+        if !to_pair {
+            *self
+                .elsewhere
+                .entry((from.to_string(), token.to_string()))
+                .or_insert(BigUint::from(0u32)) += amount;
+            return;
+        }
+
+        let (transferred, senders) = self
+            .to_pairs
+            .entry((to.to_string(), token.to_string()))
+            .or_insert((BigUint::from(0u32), vec![]));
+        *transferred += amount;
+        if !senders.iter().any(|sender| sender == from) {
+            senders.push(from.to_string());
+        }
+    }
+
+    /// Forgets what was sent to `pair`, tokens sent to add liquidity are not part of a swap.
+    fn mint(&mut self, pair: &str) {
+        let tokens: Vec<(String, String)> = self
+            .to_pairs
+            .keys()
+            .filter(|(to, _)| to == pair)
+            .cloned()
+            .collect();
+        for key in tokens {
+            self.take(&key);
+        }
+    }
+
+    /// Returns the amount of `token` sent for a swap of `pair`, `None` when none was.
+    fn swap(&mut self, pair: &str, token: &str) -> Option<BigUint> {
+        self.take(&(pair.to_string(), token.to_string()))
+    }
+
+    fn take(&mut self, key: &(String, String)) -> Option<BigUint> {
+        let (mut sent, senders) = self.to_pairs.remove(key)?;
+        for sender in senders {
+            if let Some(amount) = self.elsewhere.remove(&(sender, key.1.clone())) {
+                sent += amount;
+            }
+        }
+        Some(sent)
+    }
+}
+
+/// Returns the tax rate making the pair receive `received` out of `sent`, `None` when
+/// the difference is rounding.
+fn tax_rate(sent: &BigUint, received: &BigUint) -> Option<BigDecimal> {
+    if *received == BigUint::from(0u32) || sent <= received {
+        return None;
+    }
+
+    let tax_rate = decimal::one().sub(decimal::div(
+        &decimal::parse(received.to_string().as_str()),
+        &decimal::parse(sent.to_string().as_str()),
+    ));
+    if tax_rate.le(&BigDecimal::from_str(TAX_RATE_TOLERANCE).unwrap()) {
+        return None;
+    }
+
+    Some(tax_rate)
+}
+
+/// Applies the tax rate detected for `token_address`, if any, to `price` when `adjusted`,
+/// see `adjusted_accounting`.
+pub fn adjust_price(token_taxes_store: &store::StoreGet, token_address: &str, price: BigDecimal, adjusted: bool) -> BigDecimal {
+    if !adjusted {
+        return price;
+    }
+
+    match token_taxes_store.get_last(&format!("token:{}:tax_rate", token_address)) {
+        None => price,
+        Some(rate_bytes) => {
+            let tax_rate = decimal::from_store(&rate_bytes);
+            if tax_rate.eq(&decimal::zero()) {
+                return price;
+            }
+            decimal::mul(&price, &decimal::one().sub(tax_rate))
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const USER: &str = "0x00000000000000000000000000000000000000aa";
+    const FEE_WALLET: &str = "0x00000000000000000000000000000000000000fe";
+    const PAIR: &str = "0x58f876857a02d6762e0101bb5c46a8c1ed44dc16";
+    const OTHER_PAIR: &str = "0x16b9a82891338f9ba80e2d6970fdda79d1eb0dae";
+    const TOKEN: &str = "0x0000000000000000000000000000000000000071";
+
+    fn amount(value: u32) -> BigUint {
+        BigUint::from(value)
+    }
+
+    fn rate(value: &str) -> Option<BigDecimal> {
+        Some(BigDecimal::from_str(value).unwrap())
+    }
+
+    fn detected_rate(tracker: &mut TransferTracker, pair: &str, received: u32) -> Option<BigDecimal> {
+        let sent = tracker.swap(pair, TOKEN)?;
+        tax_rate(&sent, &amount(received))
+    }
+
+    #[test]
+    fn test_gross_transfer_to_pair() {
+        // the pair is credited with less than the Transfer event tells
+        let mut tracker = TransferTracker::default();
+        tracker.transfer(TOKEN, USER, PAIR, true, amount(1000));
+
+        assert_eq!(detected_rate(&mut tracker, PAIR, 900), rate("0.1"));
+    }
+
+    #[test]
+    fn test_net_transfer_to_pair() {
+        // the Transfer to the pair holds the net amount, the tax is sent to a fee wallet
+        let mut tracker = TransferTracker::default();
+        tracker.transfer(TOKEN, USER, FEE_WALLET, false, amount(50));
+        tracker.transfer(TOKEN, USER, PAIR, true, amount(950));
+
+        assert_eq!(detected_rate(&mut tracker, PAIR, 950), rate("0.05"));
+        assert_eq!(tracker.swap(PAIR, TOKEN), None, "the transfers are consumed by the swap");
+    }
+
+    #[test]
+    fn test_untaxed_transfers() {
+        let mut tracker = TransferTracker::default();
+        tracker.transfer(TOKEN, USER, PAIR, true, amount(1000));
+        assert_eq!(detected_rate(&mut tracker, PAIR, 1000), None);
+
+        // a swap split across two pairs
+        tracker.transfer(TOKEN, USER, PAIR, true, amount(600));
+        tracker.transfer(TOKEN, USER, OTHER_PAIR, true, amount(400));
+        assert_eq!(detected_rate(&mut tracker, PAIR, 600), None);
+        assert_eq!(detected_rate(&mut tracker, OTHER_PAIR, 400), None);
+    }
+
+    #[test]
+    fn test_mint() {
+        // tokens and taxes sent to add liquidity are not part of the next swap
+        let mut tracker = TransferTracker::default();
+        tracker.transfer(TOKEN, USER, FEE_WALLET, false, amount(50));
+        tracker.transfer(TOKEN, USER, PAIR, true, amount(950));
+        tracker.mint(PAIR);
+
+        tracker.transfer(TOKEN, USER, PAIR, true, amount(1000));
+        assert_eq!(detected_rate(&mut tracker, PAIR, 1000), None);
+    }
+}
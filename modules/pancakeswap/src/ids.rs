@@ -0,0 +1,17 @@
+use crate::eth::address_pretty;
+
+/// Chain the module indexes, the first component of the entity IDs.
+pub const CHAIN: &str = "bsc";
+
+/// Returns the ID of the entity extracted from a log, `<chain>:<block hash>:<trx hash>:<log index>`,
+/// hashes `0x` prefixed and the log index within its transaction. It's the same every time the
+/// block is processed and differs on the blocks of other forks, so sinks upsert the entities by
+/// ID: a retried or replayed block never counts an entity twice.
+pub fn log_id(block_hash: &[u8], trx_hash: &[u8], log_index: u32) -> String {
+    format!("{}:{}:{}:{}", CHAIN, address_pretty(block_hash), address_pretty(trx_hash), log_index)
+}
+
+/// Returns the transaction hash of an ID built by `log_id`, empty when `id` isn't one.
+pub fn transaction_id(id: &str) -> &str {
+    id.split(':').nth(2).unwrap_or("")
+}
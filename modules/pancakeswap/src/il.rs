@@ -0,0 +1,30 @@
+use std::ops::{Add, Div, Mul, Sub};
+
+use bigdecimal::{BigDecimal, One};
+
+use crate::decimal;
+
+/// Impermanent loss of a constant product position versus holding the tokens, for a
+/// price that moved from `entry_price` to `current_price`:
+///
+///   r = current_price / entry_price
+///   il = 2 * sqrt(r) / (1 + r) - 1
+///
+/// The result is always <= 0, -0.05 meaning the position is worth 5% less than HODL.
+pub fn compute_impermanent_loss(entry_price: &BigDecimal, current_price: &BigDecimal) -> Option<BigDecimal> {
+    let zero = decimal::zero();
+    if entry_price.eq(&zero) || current_price.eq(&zero) {
+        return None;
+    }
+
+    let one = BigDecimal::one();
+    let ratio = decimal::div(current_price, entry_price);
+    let sqrt_ratio = ratio.sqrt()?;
+
+    Some(decimal::round(
+        sqrt_ratio
+            .mul(BigDecimal::from(2))
+            .div(one.clone().add(ratio))
+            .sub(one),
+    ))
+}
@@ -0,0 +1,74 @@
+use std::ops::{Add, Sub};
+
+use bigdecimal::{BigDecimal, One};
+use substreams::store;
+
+use crate::{decimal, pcs};
+
+/// Prices of a swap, in amount of the bought token per sold token.
+pub struct PriceImpact {
+    pub token_in: String,
+    pub mid_price: BigDecimal,
+    pub execution_price: BigDecimal,
+    pub post_trade_mid_price: BigDecimal,
+    pub price_impact: BigDecimal,
+    pub slippage: BigDecimal,
+}
+
+/// Computes the price impact of a swap of `pair` from the reserves of `store_reserves` at
+/// the swap's ordinal. They were set by the `Sync` the pair emits just before the `Swap`,
+/// so they are the reserves after the trade, the reserves before it are recovered from
+/// the swapped amounts:
+///
+///   mid_price = reserve_out_before / reserve_in_before
+///   execution_price = amount_out / amount_in
+///   price_impact = 1 - execution_price / mid_price, the LP fee included
+///   slippage = 1 - post_trade_mid_price / mid_price, how far the trade moved the pool
+///
+/// `None` when the reserves are unknown or empty.
+pub fn compute_price_impact(
+    reserves: &store::StoreGet,
+    ordinal: u64,
+    pair: &pcs::Pair,
+    amount0_in: &BigDecimal,
+    amount1_in: &BigDecimal,
+    amount0_out: &BigDecimal,
+    amount1_out: &BigDecimal,
+) -> Option<PriceImpact> {
+    let reserve0_after = decimal::from_store(&reserves.get_at(
+        ordinal,
+        &format!("reserve:{}:{}:reserve0", pair.address, pair.token0_address),
+    )?);
+    let reserve1_after = decimal::from_store(&reserves.get_at(
+        ordinal,
+        &format!("reserve:{}:{}:reserve1", pair.address, pair.token1_address),
+    )?);
+
+    let reserve0_before = reserve0_after.clone().sub(amount0_in).add(amount0_out);
+    let reserve1_before = reserve1_after.clone().sub(amount1_in).add(amount1_out);
+
+    let (token_in, amount_in, amount_out, reserve_in_before, reserve_out_before, reserve_in_after, reserve_out_after) =
+        if amount0_in > amount1_in {
+            (&pair.token0_address, amount0_in, amount1_out, reserve0_before, reserve1_before, reserve0_after, reserve1_after)
+        } else {
+            (&pair.token1_address, amount1_in, amount0_out, reserve1_before, reserve0_before, reserve1_after, reserve0_after)
+        };
+
+    let zero = decimal::zero();
+    if reserve_in_before <= zero || reserve_out_before <= zero || amount_in.eq(&zero) {
+        return None;
+    }
+
+    let mid_price = decimal::div(&reserve_out_before, &reserve_in_before);
+    let execution_price = decimal::div(amount_out, amount_in);
+    let post_trade_mid_price = decimal::div(&reserve_out_after, &reserve_in_after);
+
+    Some(PriceImpact {
+        token_in: token_in.clone(),
+        price_impact: decimal::round(BigDecimal::one().sub(decimal::div(&execution_price, &mid_price))),
+        slippage: decimal::round(BigDecimal::one().sub(decimal::div(&post_trade_mid_price, &mid_price))),
+        mid_price,
+        execution_price,
+        post_trade_mid_price,
+    })
+}
@@ -0,0 +1,30 @@
+/// Labels of known addresses, exchanges, routers, aggregators and bots, bundled from
+/// `labels.csv` at build time: one `address,label,category` line per address, the first
+/// line is the header, lines starting with `#` are comments. Labels can't contain commas.
+const LABELS: &str = include_str!("../labels.csv");
+
+pub struct Label {
+    pub label: &'static str,
+    pub category: &'static str,
+}
+
+/// Returns the label of `address`, `0x` prefixed, `None` when it isn't labelled.
+pub fn lookup(address: &str) -> Option<Label> {
+    let address = address.to_lowercase();
+
+    LABELS
+        .lines()
+        .filter(|line| !line.is_empty() && !line.starts_with('#'))
+        .skip(1)
+        .find_map(|line| {
+            let fields: Vec<&str> = line.splitn(3, ',').map(|field| field.trim()).collect();
+            if fields.len() != 3 || fields[0] != address {
+                return None;
+            }
+
+            Some(Label {
+                label: fields[1],
+                category: fields[2],
+            })
+        })
+}
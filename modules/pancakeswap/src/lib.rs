@@ -1,6 +1,7 @@
 extern crate core;
 
-use std::ops::{Mul, Neg};
+use std::collections::{BTreeMap, HashMap};
+use std::ops::{Add, Neg};
 use std::str::FromStr;
 
 use bigdecimal::BigDecimal;
@@ -16,28 +17,49 @@ use crate::pb::database::DatabaseChanges;
 use crate::pb::pcs;
 use crate::pb::tokens::Token;
 use crate::pcs::event::Type;
-use crate::utils::zero_big_decimal;
 
+mod basket;
+mod checks;
 mod db;
+mod decimal;
 mod eth;
+mod factory;
 mod event;
+mod fees;
+mod fot;
+mod ids;
+mod il;
+mod impact;
+mod labels;
 mod macros;
+mod mev;
+mod oracle;
+mod pair_filter;
+mod params;
 mod pb;
+mod reserves;
 mod rpc;
+mod signatures;
+mod trades;
 mod utils;
+mod volume24h;
 
 #[substreams::handlers::map]
 pub fn map_pairs(blk: pb::eth::Block) -> Result<pcs::Pairs, Error> {
     let mut pairs = pcs::Pairs { pairs: vec![] };
 
     for trx in blk.transaction_traces {
-        /* PCS Factory address */
-        //0xbcfccbde45ce874adcb698cc183debcf17952812
-        if hex::encode(&trx.to) != "ca143ce32fe78f1f7019d7d551a6402fc5350c73" {
-            continue;
-        }
+        let receipt = match trx.receipt {
+            None => continue,
+            Some(receipt) => receipt,
+        };
+
+        for log in receipt.logs {
+            let factory_address = address_pretty(&log.address);
+            if !factory::is_factory(factory_address.as_str()) {
+                continue;
+            }
 
-        for log in trx.receipt.unwrap().logs {
             let sig = hex::encode(&log.topics[0]);
 
             if !event::is_pair_created_event(sig.as_str()) {
@@ -51,6 +73,7 @@ pub fn map_pairs(blk: pb::eth::Block) -> Result<pcs::Pairs, Error> {
                 creation_transaction_id: address_pretty(&trx.hash),
                 block_num: blk.number,
                 log_ordinal: log.block_index as u64,
+                factory_address,
             })
         }
     }
@@ -62,28 +85,178 @@ pub fn map_pairs(blk: pb::eth::Block) -> Result<pcs::Pairs, Error> {
 pub fn store_pairs(pairs: pcs::Pairs, output: store::StoreSet) {
     log::info!("Building pair state");
     for pair in pairs.pairs {
-        output.set(
+        let tokens_key = format!(
+            "tokens:{}",
+            utils::generate_tokens_key(
+                pair.token0_address.as_str(),
+                pair.token1_address.as_str(),
+            )
+        );
+
+        // pair addresses are unique across factories, `pair:` is the aggregate
+        output.set_many(
             pair.log_ordinal,
-            format!("pair:{}", pair.address),
+            &vec![
+                format!("pair:{}", pair.address),
+                factory::namespaced(&pair.factory_address, format!("pair:{}", pair.address)),
+                factory::namespaced(&pair.factory_address, tokens_key.clone()),
+            ],
             &proto::encode(&pair).unwrap(),
         );
+
+        if pair.factory_address == factory::PRICING_FACTORY {
+            output.set(
+                pair.log_ordinal as u64,
+                tokens_key,
+                &proto::encode(&pair).unwrap(),
+            );
+        }
+    }
+}
+
+/// Tracks the lifecycle of the pair contracts, created by the factories of `map_pairs`
+/// and destroyed by a `selfdestruct` that wasn't reverted.
+#[substreams::handlers::map]
+pub fn map_contracts(blk: pb::eth::Block, pairs: pcs::Pairs, pairs_store: store::StoreGet) -> Result<pcs::Contracts, Error> {
+    let mut contracts = pcs::Contracts { contracts: vec![] };
+
+    for pair in &pairs.pairs {
+        contracts.contracts.push(pcs::Contract {
+            address: pair.address.clone(),
+            factory_address: pair.factory_address.clone(),
+            created_block_num: pair.block_num,
+            creation_transaction_id: pair.creation_transaction_id.clone(),
+            destroyed_block_num: 0,
+            destruction_transaction_id: "".to_string(),
+            ordinal: pair.log_ordinal,
+        });
+    }
+
+    for trx in blk.transaction_traces {
+        // destructions are ordered after the last log of their transaction, which
+        // also comes after the creation of a pair created by the same transaction
+        let ordinal = match &trx.receipt {
+            Some(receipt) => receipt.logs.last().map_or(0, |log| log.block_index as u64),
+            None => 0,
+        };
+
+        for call in trx.calls {
+            if !call.suicide || call.state_reverted {
+                continue;
+            }
+
+            let address = address_pretty(&call.address);
+            let pair: pcs::Pair = match pairs_store.get_last(&format!("pair:{}", address)) {
+                Some(pair_bytes) => proto::decode(&pair_bytes).unwrap(),
+                None => continue,
+            };
+
+            log::info!("pair {} destroyed at block {}", address, blk.number);
+            contracts.contracts.push(pcs::Contract {
+                address,
+                factory_address: pair.factory_address,
+                created_block_num: pair.block_num,
+                creation_transaction_id: pair.creation_transaction_id,
+                destroyed_block_num: blk.number,
+                destruction_transaction_id: address_pretty(&trx.hash),
+                ordinal,
+            });
+        }
+    }
+
+    Ok(contracts)
+}
+
+/// The lifecycle of the pair contracts as `contract:<address>`, sinks bound the
+/// processing of a pair to `[created_block_num, destroyed_block_num]`.
+#[substreams::handlers::store]
+pub fn store_contracts(contracts: pcs::Contracts, output: store::StoreSet) {
+    for contract in contracts.contracts {
         output.set(
-            pair.log_ordinal as u64,
-            format!(
-                "tokens:{}",
-                utils::generate_tokens_key(
-                    pair.token0_address.as_str(),
-                    pair.token1_address.as_str(),
-                )
-            ),
-            &proto::encode(&pair).unwrap(),
+            contract.ordinal,
+            format!("contract:{}", contract.address),
+            &proto::encode(&contract).unwrap(),
         );
     }
 }
 
+/// Records the topic0 of the logs emitted by the factories and the pairs, resolved against
+/// the bundled signature database, one entry per contract and topic0 of the block.
 #[substreams::handlers::map]
-pub fn map_reserves(blk: pb::eth::Block, pairs: store::StoreGet, tokens: store::StoreGet) -> Result<pcs::Reserves, Error> {
+pub fn map_event_signatures(blk: pb::eth::Block, pairs_store: store::StoreGet) -> Result<pcs::EventSignatures, Error> {
+    let mut event_signatures = pcs::EventSignatures { signatures: vec![] };
+
+    for trx in blk.transaction_traces {
+        let receipt = match trx.receipt {
+            Some(receipt) => receipt,
+            None => continue,
+        };
+
+        for log in receipt.logs {
+            if log.topics.len() == 0 {
+                continue;
+            }
+
+            let address = address_pretty(&log.address);
+            if !factory::is_factory(address.as_str()) && pairs_store.get_last(&format!("pair:{}", address)).is_none() {
+                continue;
+            }
+
+            let topic0 = hex::encode(&log.topics[0]);
+            if let Some(seen) = event_signatures
+                .signatures
+                .iter_mut()
+                .find(|seen| seen.contract_address == address && seen.topic0 == topic0)
+            {
+                seen.count += 1;
+                continue;
+            }
+
+            let signature = match signatures::resolve(topic0.as_str()) {
+                Some(signature) => signature.to_string(),
+                None => {
+                    log::info!("unknown event signature {} emitted by {} at block {}", topic0, address, blk.number);
+                    "".to_string()
+                }
+            };
+
+            event_signatures.signatures.push(pcs::EventSignature {
+                topic0,
+                signature,
+                contract_address: address,
+                transaction_id: address_pretty(&trx.hash),
+                log_ordinal: log.block_index as u64,
+                count: 1,
+            });
+        }
+    }
+
+    Ok(event_signatures)
+}
+
+/// Counts the logs by signature as `signature:<topic0>` and `contract:<address>:<topic0>`,
+/// the signatures missing from the database also as `unknown:<topic0>`.
+#[substreams::handlers::store]
+pub fn store_event_signatures(signatures: pcs::EventSignatures, output: store::StoreAddInt64) {
+    for signature in signatures.signatures {
+        let count = signature.count as i64;
+
+        output.add(signature.log_ordinal, format!("signature:{}", signature.topic0), count);
+        output.add(
+            signature.log_ordinal,
+            format!("contract:{}:{}", signature.contract_address, signature.topic0),
+            count,
+        );
+        if signature.signature.is_empty() {
+            output.add(signature.log_ordinal, format!("unknown:{}", signature.topic0), count);
+        }
+    }
+}
+
+#[substreams::handlers::map]
+pub fn map_reserves(blk: pb::eth::Block, pairs: store::StoreGet, token_decimals: store::StoreGet) -> Result<pcs::Reserves, Error> {
     let mut reserves = pcs::Reserves { reserves: vec![] };
+    let filter = pair_filter::PairFilter::from_params();
 
     for trx in blk.transaction_traces {
         for log in trx.receipt.unwrap().logs {
@@ -98,18 +271,25 @@ pub fn map_reserves(blk: pb::eth::Block, pairs: store::StoreGet, tokens: store::
                     }
 
                     let pair: pcs::Pair = proto::decode(&pair_bytes).unwrap();
+                    if !filter.allowed_pair(&pair) {
+                        continue;
+                    }
 
-                    let token0: Token = utils::get_last_token(&tokens, &pair.token0_address);
-                    let reserve0 =
-                        utils::convert_token_to_decimal(&log.data[0..32], &token0.decimals);
-                    let token1: Token = utils::get_last_token(&tokens, &pair.token1_address);
-                    let reserve1 =
-                        utils::convert_token_to_decimal(&log.data[32..64], &token1.decimals);
+                    let (token0_decimals, token1_decimals) = match (
+                        utils::get_token_decimals(&token_decimals, &pair.token0_address),
+                        utils::get_token_decimals(&token_decimals, &pair.token1_address),
+                    ) {
+                        (Some(token0_decimals), Some(token1_decimals)) => (token0_decimals, token1_decimals),
+                        _ => continue,
+                    };
+                    let reserve0 = decimal::to_decimal(&log.data[0..32], token0_decimals);
+                    let reserve1 = decimal::to_decimal(&log.data[32..64], token1_decimals);
 
-                    let token0_price = utils::get_token_price(reserve0.clone(), reserve1.clone());
-                    let token1_price = utils::get_token_price(reserve1.clone(), reserve0.clone());
+                    let token0_price = decimal::div(&reserve0, &reserve1);
+                    let token1_price = decimal::div(&reserve1, &reserve0);
 
                     reserves.reserves.push(pcs::Reserve {
+                        id: ids::log_id(&blk.hash, &trx.hash, log.index),
                         pair_address: pair.address,
                         reserve0: reserve0.to_string(),
                         reserve1: reserve1.to_string(),
@@ -181,8 +361,43 @@ pub fn store_reserves(clock: substreams::pb::substreams::Clock, reserves: pcs::R
     }
 }
 
+#[substreams::handlers::map]
+pub fn map_token_taxes(blk: pb::eth::Block, pairs: store::StoreGet, token_decimals: store::StoreGet) -> Result<pcs::TokenTaxes, Error> {
+    let token_taxes = pcs::TokenTaxes {
+        token_taxes: fot::detect_token_taxes(&blk, &pairs, &token_decimals),
+    };
+
+    Ok(token_taxes)
+}
+
+#[substreams::handlers::store]
+pub fn store_token_taxes(token_taxes: pcs::TokenTaxes, output: store::StoreSet) {
+    // sets:
+    // * token:%s:tax_rate (token)  - latest detected tax rate
+    // * token:%s:fee_on_transfer (token)  - pair and transaction of the latest detection
+    for token_tax in token_taxes.token_taxes {
+        log::info!(
+            "token {} detected as fee-on-transfer in transaction {}, tax rate {}",
+            token_tax.token_address,
+            token_tax.transaction_id,
+            token_tax.tax_rate
+        );
+
+        output.set(
+            token_tax.log_ordinal,
+            format!("token:{}:tax_rate", token_tax.token_address),
+            &Vec::from(token_tax.tax_rate.clone()),
+        );
+        output.set(
+            token_tax.log_ordinal,
+            format!("token:{}:fee_on_transfer", token_tax.token_address),
+            &Vec::from(format!("{}:{}", token_tax.pair_address, token_tax.transaction_id)),
+        );
+    }
+}
+
 #[substreams::handlers::store]
-pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Reserves, pairs: store::StoreGet, reserves_store: store::StoreGet, output: store::StoreSet) {
+pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Reserves, pairs: store::StoreGet, reserves_store: store::StoreGet, token_taxes: store::StoreGet, output: store::StoreSet) {
     let timestamp_seconds = clock.timestamp.unwrap().seconds;
     let day_id: i64 = timestamp_seconds / 86400;
     let hour_id: i64 = timestamp_seconds / 3600;
@@ -191,11 +406,17 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
     output.delete_prefix(0, &format!("pair_hour:{}:", hour_id - 1));
     output.delete_prefix(0, &format!("token_day:{}:", day_id - 1));
 
+    let filter = pair_filter::PairFilter::from_params();
+    let adjusted_accounting = fot::adjusted_accounting();
+
     for reserve in reserves.reserves {
         match pairs.get_last(&format!("pair:{}", reserve.pair_address)) {
             None => continue,
             Some(pair_bytes) => {
                 let pair: pcs::Pair = proto::decode(&pair_bytes).unwrap();
+                if !filter.allowed_pair(&pair) {
+                    continue;
+                }
 
                 let latest_usd_price: BigDecimal =
                     utils::compute_usd_price(&reserves_store, &reserve);
@@ -219,28 +440,33 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
                 // derived from:
                 // * price:%s:%s (tokenA, tokenB)
                 // * reserve:%s:%s (pair, tokenA)
-                let usd_price_valid: bool = latest_usd_price.ne(&zero_big_decimal());
+                let usd_price_valid: bool = latest_usd_price.ne(&decimal::zero());
 
+                // fee-on-transfer tokens are priced net of their transfer tax, see fot::adjusted_accounting
                 let t0_derived_bnb_price = utils::find_bnb_price_per_token(
                     &reserve.log_ordinal,
                     pair.token0_address.as_str(),
                     &pairs,
                     &reserves_store,
-                );
+                    &filter,
+                )
+                .map(|price| fot::adjust_price(&token_taxes, pair.token0_address.as_str(), price, adjusted_accounting));
 
                 let t1_derived_bnb_price = utils::find_bnb_price_per_token(
                     &reserve.log_ordinal,
                     pair.token1_address.as_str(),
                     &pairs,
                     &reserves_store,
-                );
+                    &filter,
+                )
+                .map(|price| fot::adjust_price(&token_taxes, pair.token1_address.as_str(), price, adjusted_accounting));
 
                 let apply = |token_derived_bnb_price: Option<BigDecimal>,
                              token_addr: String,
                              reserve_amount: String|
                  -> BigDecimal {
                     if token_derived_bnb_price.is_none() {
-                        return zero_big_decimal();
+                        return decimal::zero();
                     }
 
                     output.set(
@@ -248,9 +474,10 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
                         format!("dprice:{}:bnb", token_addr),
                         &Vec::from(token_derived_bnb_price.clone().unwrap().to_string()),
                     );
-                    let reserve_in_bnb = BigDecimal::from_str(reserve_amount.as_str())
-                        .unwrap()
-                        .mul(token_derived_bnb_price.clone().unwrap());
+                    let reserve_in_bnb = decimal::mul(
+                        &decimal::parse(reserve_amount.as_str()),
+                        token_derived_bnb_price.as_ref().unwrap(),
+                    );
                     output.set(
                         reserve.log_ordinal,
                         format!("dreserve:{}:{}:bnb", reserve.pair_address, token_addr),
@@ -258,9 +485,8 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
                     );
 
                     if usd_price_valid {
-                        let derived_usd_price = token_derived_bnb_price
-                            .unwrap()
-                            .mul(latest_usd_price.clone());
+                        let derived_usd_price =
+                            decimal::mul(token_derived_bnb_price.as_ref().unwrap(), &latest_usd_price);
                         output.set_many(
                             reserve.log_ordinal,
                             &vec![
@@ -270,7 +496,7 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
                             &Vec::from(derived_usd_price.to_string()),
                         );
 
-                        let reserve_in_usd = reserve_in_bnb.clone().mul(latest_usd_price.clone());
+                        let reserve_in_usd = decimal::mul(&reserve_in_bnb, &latest_usd_price);
 
                         output.set_many(
                             reserve.log_ordinal,
@@ -305,8 +531,8 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
                     reserve.reserve1.clone(),
                 );
 
-                let reserves_bnb_sum = reserve0_bnb.mul(reserve1_bnb);
-                if reserves_bnb_sum.ne(&zero_big_decimal()) {
+                let reserves_bnb_sum = decimal::mul(&reserve0_bnb, &reserve1_bnb);
+                if reserves_bnb_sum.ne(&decimal::zero()) {
                     output.set(
                         reserve.log_ordinal,
                         format!("dreserves:{}:bnb", reserve.pair_address),
@@ -318,6 +544,105 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
     }
 }
 
+#[substreams::handlers::map]
+pub fn map_oracle_prices(blk: pb::eth::Block) -> Result<pcs::OraclePrices, Error> {
+    let mut oracle_prices = pcs::OraclePrices { oracle_prices: vec![] };
+
+    if blk.number % oracle::SAMPLE_INTERVAL != 0 {
+        return Ok(oracle_prices);
+    }
+
+    let feeds: Vec<String> = oracle::CHAINLINK_FEEDS
+        .iter()
+        .map(|(feed, _)| feed.to_string())
+        .collect();
+
+    let rounds = rpc::latest_round_data(&feeds);
+    for (i, round) in rounds.into_iter().enumerate() {
+        let (feed, token) = oracle::CHAINLINK_FEEDS[i];
+        match round {
+            None => {
+                log::info!("chainlink feed {} returned no valid answer", feed);
+                continue;
+            }
+            Some(round) => {
+                let price = decimal::to_decimal(&round.answer, oracle::CHAINLINK_DECIMALS);
+
+                oracle_prices.oracle_prices.push(pcs::OraclePrice {
+                    feed_address: feed.to_string(),
+                    token_address: token.to_string(),
+                    price_usd: price.to_string(),
+                    round_id: round.round_id,
+                    updated_at: round.updated_at,
+                    block_num: blk.number,
+                });
+            }
+        }
+    }
+
+    Ok(oracle_prices)
+}
+
+#[substreams::handlers::store]
+pub fn store_oracle_reconciliation(clock: substreams::pb::substreams::Clock, oracle_prices: pcs::OraclePrices, prices: store::StoreGet, output: store::StoreSet) {
+    let timestamp_seconds = clock.timestamp.unwrap().seconds;
+    let day_id: i64 = timestamp_seconds / 86400;
+
+    output.delete_prefix(0, &format!("token_day:{}:", day_id - 1));
+
+    // sets:
+    // * oracle:%s:usd (token)  - latest oracle answer
+    // * deviation:%s (token)  - |amm - oracle| / oracle
+    // * flagged:%s (token)  - "amm_price:oracle_price" when the deviation is above the threshold
+    // * token_day:%d:flagged:%s (day, token)  - same, scoped to the day
+    // derived from:
+    // * dprice:%s:usd (token)  - as set by store_prices
+    for oracle_price in oracle_prices.oracle_prices {
+        let oracle_usd_price = BigDecimal::from_str(oracle_price.price_usd.as_str()).unwrap();
+        output.set(
+            0,
+            format!("oracle:{}:usd", oracle_price.token_address),
+            &Vec::from(oracle_price.price_usd.clone()),
+        );
+
+        let amm_usd_price = match prices.get_last(&format!("dprice:{}:usd", oracle_price.token_address)) {
+            None => continue,
+            Some(price_bytes) => decimal::from_store(&price_bytes),
+        };
+
+        let deviation = match oracle::compute_deviation(&amm_usd_price, &oracle_usd_price) {
+            None => continue,
+            Some(deviation) => deviation,
+        };
+
+        output.set(
+            0,
+            format!("deviation:{}", oracle_price.token_address),
+            &Vec::from(deviation.to_string()),
+        );
+
+        if oracle::is_deviation_flagged(&deviation) {
+            log::info!(
+                "token {} amm price {} deviates from oracle price {} by {}",
+                oracle_price.token_address,
+                amm_usd_price,
+                oracle_usd_price,
+                deviation
+            );
+            output.set_many(
+                0,
+                &vec![
+                    format!("flagged:{}", oracle_price.token_address),
+                    format!("token_day:{}:flagged:{}", day_id, oracle_price.token_address),
+                ],
+                &Vec::from(format!("{}:{}", amm_usd_price, oracle_usd_price)),
+            );
+        } else {
+            output.delete_prefix(0, &format!("flagged:{}", oracle_price.token_address));
+        }
+    }
+}
+
 // pub extern "C" fn build_twap_transient_store(clock, prices_deltas) {
 //     let deltas: pcs::StoreDeltas;
 //     // TODO: flatten the deltas
@@ -362,13 +687,9 @@ pub fn store_prices(clock: substreams::pb::substreams::Clock, reserves: pcs::Res
 // }
 
 #[substreams::handlers::map]
-pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet, prices_store: store::StoreGet, tokens_store: store::StoreGet) -> Result<pcs::Events, Error> {
+pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet, prices_store: store::StoreGet, token_decimals: store::StoreGet) -> Result<pcs::Events, Error> {
     let mut events: pcs::Events = pcs::Events { events: vec![] };
 
-    let mut burn_count: i32 = 0;
-    let mut mint_count: i32 = 0;
-    let mut swap_count: i32 = 0;
-
     for trx in blk.transaction_traces {
         let trx_id = address_pretty(trx.hash.as_slice());
         for call in trx.calls {
@@ -388,6 +709,17 @@ pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet,
                 Some(pair_bytes) => pair = proto::decode(&pair_bytes).unwrap(),
             }
 
+            let (token0_decimals, token1_decimals) = match (
+                utils::get_token_decimals(&token_decimals, &pair.token0_address),
+                utils::get_token_decimals(&token_decimals, &pair.token1_address),
+            ) {
+                (Some(token0_decimals), Some(token1_decimals)) => (token0_decimals, token1_decimals),
+                _ => continue,
+            };
+
+            // the swap, mint or burn is the last log of the call
+            let event_id = ids::log_id(&blk.hash, &trx.hash, call.logs.last().unwrap().index);
+
             let mut pcs_events: Vec<PcsEvent> = Vec::new();
 
             for log in call.logs {
@@ -395,11 +727,13 @@ pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet,
             }
 
             let mut base_event = pcs::Event {
+                id: event_id.clone(),
                 log_ordinal: 0,
                 pair_address: pair_addr,
                 token0: pair.token0_address.clone(),
                 token1: pair.token1_address.clone(),
                 transaction_id: trx_id.to_string(),
+                factory_address: pair.factory_address.clone(),
                 timestamp: blk
                     .header
                     .as_ref()
@@ -424,39 +758,31 @@ pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet,
 
                 match pcs_events[3].event.as_ref().unwrap() {
                     Event::PairMintEvent(pair_mint_event) => {
-                        let mint_id = format!("{}-{}", trx_id, mint_count);
-                        mint_count += 1;
 
                         event::process_mint(
-                            mint_id.as_str(),
+                            event_id.as_str(),
                             &mut base_event,
                             &prices_store,
                             &pair,
                             ev_tr1,
                             ev_tr2,
                             pair_mint_event,
-                            utils::get_last_token(&tokens_store, pair.token0_address.as_str())
-                                .decimals,
-                            utils::get_last_token(&tokens_store, pair.token1_address.as_str())
-                                .decimals,
+                            token0_decimals,
+                            token1_decimals,
                         )
                     }
                     Event::PairBurnEvent(pair_burn_event) => {
-                        let burn_id = format!("{}-{}", trx_id, burn_count);
-                        burn_count = burn_count + 1;
 
                         event::process_burn(
-                            burn_id.as_str(),
+                            event_id.as_str(),
                             &mut base_event,
                             &prices_store,
                             &pair,
                             ev_tr1,
                             ev_tr2,
                             pair_burn_event,
-                            utils::get_last_token(&tokens_store, pair.token0_address.as_str())
-                                .decimals,
-                            utils::get_last_token(&tokens_store, pair.token1_address.as_str())
-                                .decimals,
+                            token0_decimals,
+                            token1_decimals,
                         );
                     }
                     _ => {
@@ -472,39 +798,31 @@ pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet,
 
                 match pcs_events[2].event.as_ref().unwrap() {
                     Event::PairMintEvent(pair_mint_event) => {
-                        let mint_id = format!("{}-{}", trx_id, mint_count);
-                        mint_count += 1;
 
                         event::process_mint(
-                            mint_id.as_str(),
+                            event_id.as_str(),
                             &mut base_event,
                             &prices_store,
                             &pair,
                             None,
                             ev_tr2,
                             pair_mint_event,
-                            utils::get_last_token(&tokens_store, pair.token0_address.as_str())
-                                .decimals,
-                            utils::get_last_token(&tokens_store, pair.token1_address.as_str())
-                                .decimals,
+                            token0_decimals,
+                            token1_decimals,
                         )
                     }
                     Event::PairBurnEvent(pair_burn_event) => {
-                        let burn_id = format!("{}-{}", trx_id, burn_count);
-                        burn_count += 1;
 
                         event::process_burn(
-                            burn_id.as_str(),
+                            event_id.as_str(),
                             &mut base_event,
                             &prices_store,
                             &pair,
                             None,
                             ev_tr2,
                             pair_burn_event,
-                            utils::get_last_token(&tokens_store, pair.token0_address.as_str())
-                                .decimals,
-                            utils::get_last_token(&tokens_store, pair.token1_address.as_str())
-                                .decimals,
+                            token0_decimals,
+                            token1_decimals,
                         );
                     }
                     _ => {
@@ -515,18 +833,16 @@ pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet,
             } else if pcs_events.len() == 2 {
                 match pcs_events[1].event.as_ref().unwrap() {
                     Event::PairSwapEvent(pair_swap_event) => {
-                        let swap_id = format!("{}-{}", trx_id, swap_count);
-                        swap_count += 1;
 
                         event::process_swap(
-                            swap_id.as_str(),
+                            event_id.as_str(),
                             &mut base_event,
                             &prices_store,
                             &pair,
                             Some(pair_swap_event),
                             address_pretty(trx.from.as_slice()),
-                            utils::get_last_token(&tokens_store, &pair.token0_address).decimals,
-                            utils::get_last_token(&tokens_store, &pair.token1_address).decimals,
+                            token0_decimals,
+                            token1_decimals,
                         );
                     }
                     _ => {
@@ -557,6 +873,222 @@ pub fn map_burn_swaps_events(blk: pb::eth::Block, pairs_store: store::StoreGet,
     Ok(events)
 }
 
+#[substreams::handlers::map]
+pub fn map_router_trades(events: pcs::Events) -> Result<pcs::Trades, Error> {
+    let trades = pcs::Trades {
+        trades: trades::build_trades(&events.events),
+    };
+
+    Ok(trades)
+}
+
+#[substreams::handlers::map]
+pub fn map_mev(events: pcs::Events, reserves: pcs::Reserves) -> Result<pcs::Sandwiches, Error> {
+    let sandwiches = pcs::Sandwiches {
+        sandwiches: mev::detect_sandwiches(&events.events, &reserves::BlockReserves::new(&reserves)),
+    };
+
+    Ok(sandwiches)
+}
+
+/// Labelled addresses, see `labels`, trading or providing liquidity in the block, once
+/// per address.
+#[substreams::handlers::map]
+pub fn map_address_labels(events: pcs::Events) -> Result<pcs::AddressLabels, Error> {
+    let mut address_labels = pcs::AddressLabels { labels: vec![] };
+
+    for event in &events.events {
+        let addresses = match event.r#type.as_ref() {
+            Some(Type::Swap(swap)) => vec![&swap.sender, &swap.to, &swap.from],
+            Some(Type::Mint(mint)) => vec![&mint.sender, &mint.to],
+            Some(Type::Burn(burn)) => vec![&burn.sender, &burn.to],
+            None => continue,
+        };
+
+        for address in addresses {
+            if address_labels.labels.iter().any(|label| label.address.eq(address)) {
+                continue;
+            }
+
+            if let Some(label) = labels::lookup(address) {
+                address_labels.labels.push(pcs::AddressLabel {
+                    address: address.clone(),
+                    label: label.label.to_string(),
+                    category: label.category.to_string(),
+                    transaction_id: event.transaction_id.clone(),
+                    log_ordinal: event.log_ordinal,
+                });
+            }
+        }
+    }
+
+    Ok(address_labels)
+}
+
+#[substreams::handlers::store]
+pub fn store_address_labels(address_labels: pcs::AddressLabels, output: store::StoreSetIfNotExists) {
+    for label in address_labels.labels {
+        output.set_if_not_exists(
+            label.log_ordinal,
+            format!("label:{}", label.address),
+            &proto::encode(&label).unwrap(),
+        );
+        output.set_if_not_exists(
+            label.log_ordinal,
+            format!("category:{}:{}", label.category, label.address),
+            &proto::encode(&label).unwrap(),
+        );
+    }
+}
+
+/// The events of `map_burn_swaps_events`, the swaps annotated with the labels of their
+/// addresses from `store_address_labels`, for the sinks.
+#[substreams::handlers::map]
+pub fn map_labeled_events(events: pcs::Events, address_labels: store::StoreGet) -> Result<pcs::Events, Error> {
+    let label_of = |address: &String| -> String {
+        match address_labels.get_last(&format!("label:{}", address)) {
+            Some(label_bytes) => {
+                let label: pcs::AddressLabel = proto::decode(&label_bytes).unwrap();
+                label.label
+            }
+            None => String::new(),
+        }
+    };
+
+    let mut labeled_events = events;
+    for event in labeled_events.events.iter_mut() {
+        if let Some(Type::Swap(swap)) = event.r#type.as_mut() {
+            swap.sender_label = label_of(&swap.sender);
+            swap.to_label = label_of(&swap.to);
+            swap.from_label = label_of(&swap.from);
+        }
+    }
+
+    Ok(labeled_events)
+}
+
+/// Violations of the exchange invariants in the data extracted from the block, see
+/// `checks`. Optional: nothing depends on it, run it next to the other modules to catch
+/// decoder bugs early, every violation is logged as well.
+#[substreams::handlers::map]
+pub fn map_sanity_checks(clock: substreams::pb::substreams::Clock, pairs: pcs::Pairs, reserves: pcs::Reserves, events: pcs::Events, pairs_store: store::StoreGet) -> Result<pcs::Violations, Error> {
+    let pair_at = |address: &String, first: bool| -> Option<pcs::Pair> {
+        let key = format!("pair:{}", address);
+        let pair_bytes = if first { pairs_store.get_first(&key) } else { pairs_store.get_last(&key) };
+        pair_bytes.map(|pair_bytes| proto::decode(&pair_bytes).unwrap())
+    };
+
+    let mut checker = checks::Checker::new(clock.number, &clock.id);
+    for pair in &pairs.pairs {
+        // the pair as stored before the block
+        checker.check_pair(pair, pair_at(&pair.address, true).as_ref());
+    }
+    for reserve in &reserves.reserves {
+        checker.check_reserve(reserve);
+    }
+    for event in &events.events {
+        checker.check_event(event, pair_at(&event.pair_address, false).as_ref());
+    }
+
+    for violation in &checker.violations {
+        log::info!(
+            "{} at block {} trx {} ordinal {}: {}",
+            violation.check,
+            violation.block_num,
+            violation.transaction_id,
+            violation.log_ordinal,
+            violation.message
+        );
+    }
+
+    Ok(pcs::Violations {
+        violations: checker.violations,
+    })
+}
+
+#[substreams::handlers::store]
+pub fn store_mev(sandwiches: pcs::Sandwiches, output: store::StoreSet) {
+    for sandwich in sandwiches.sandwiches {
+        log::info!(
+            "sandwich on pair {} by {}, front-run {} back-run {}",
+            sandwich.pair_address,
+            sandwich.attacker,
+            sandwich.front_transaction_id,
+            sandwich.back_transaction_id
+        );
+
+        output.set_many(
+            sandwich.log_ordinal,
+            &vec![
+                format!("sandwich:{}:{}", sandwich.pair_address, sandwich.front_transaction_id),
+                format!("attacker:{}:last", sandwich.attacker),
+                format!("pair:{}:last_sandwich", sandwich.pair_address),
+            ],
+            &proto::encode(&sandwich).unwrap(),
+        );
+    }
+}
+
+#[substreams::handlers::store]
+pub fn store_traders(clock: substreams::pb::substreams::Clock, trades: pcs::Trades, output: store::StoreSetIfNotExists) {
+    let timestamp_seconds = clock.timestamp.unwrap().seconds;
+    let day_id: i64 = timestamp_seconds / 86400;
+
+    output.delete_prefix(0, &format!("trader_day:{}:", day_id - 1));
+    output.delete_prefix(0, &format!("pair_trader_day:{}:", day_id - 1));
+
+    // one key per trader, the value is the timestamp it was first seen at, only the
+    // creation deltas are meaningful to store_unique_traders
+    for trade in trades.trades {
+        let first_seen = Vec::from(timestamp_seconds.to_string());
+
+        output.set_if_not_exists(trade.log_ordinal, format!("trader:{}", trade.trader), &first_seen);
+        output.set_if_not_exists(
+            trade.log_ordinal,
+            format!("trader_day:{}:{}", day_id, trade.trader),
+            &first_seen,
+        );
+        for pair_address in trade.pairs {
+            output.set_if_not_exists(
+                trade.log_ordinal,
+                format!("pair_trader_day:{}:{}:{}", day_id, pair_address, trade.trader),
+                &first_seen,
+            );
+        }
+    }
+}
+
+#[substreams::handlers::store]
+pub fn store_unique_traders(clock: substreams::pb::substreams::Clock, traders_deltas: store::Deltas, output: store::StoreAddInt64) {
+    let timestamp_seconds = clock.timestamp.unwrap().seconds;
+    let day_id: i64 = timestamp_seconds / 86400;
+
+    output.delete_prefix(0, &format!("global_day:{}:", day_id - 1));
+    output.delete_prefix(0, &format!("pair_day:{}:", day_id - 1));
+
+    for delta in traders_deltas {
+        if delta.operation != substreams::pb::substreams::store_delta::Operation::Create as i32 {
+            continue;
+        }
+
+        let parts: Vec<&str> = delta.key.split(":").collect();
+        match parts[0] {
+            "trader" => output.add(delta.ordinal, "global:unique_traders".to_string(), 1),
+            "trader_day" => output.add(
+                delta.ordinal,
+                format!("global_day:{}:unique_traders", parts[1]),
+                1,
+            ),
+            "pair_trader_day" => output.add(
+                delta.ordinal,
+                format!("pair_day:{}:{}:unique_traders", parts[1], parts[2]),
+                1,
+            ),
+            _ => continue,
+        }
+    }
+}
+
 #[substreams::handlers::store]
 pub fn store_totals(
     clock: substreams::pb::substreams::Clock,
@@ -571,11 +1103,28 @@ pub fn store_totals(
         return;
     }
 
+    let filter = pair_filter::PairFilter::from_params();
+
     for pair in pairs.pairs {
-        output.add(pair.log_ordinal, "global:pair_count".to_string(), 1);
+        if !filter.allowed_pair(&pair) {
+            continue;
+        }
+
+        output.add_many(
+            pair.log_ordinal,
+            &vec![
+                "global:pair_count".to_string(),
+                factory::namespaced(&pair.factory_address, "global:pair_count".to_string()),
+            ],
+            1,
+        );
     }
 
     for event in events.events {
+        if !filter.allowed_event(&event) {
+            continue;
+        }
+
         output.add_many(
             event.log_ordinal,
             &vec![
@@ -584,6 +1133,8 @@ pub fn store_totals(
                 format!("pair:{}:transaction_count", event.pair_address),
                 format!("global_day:{}:transaction_count", day_id),
                 format!("global:transaction_count"),
+                factory::namespaced(&event.factory_address, format!("global_day:{}:transaction_count", day_id)),
+                factory::namespaced(&event.factory_address, format!("global:transaction_count")),
             ],
             1,
         );
@@ -635,18 +1186,30 @@ pub fn store_volumes(
     output.delete_prefix(0, &format!("token_day:{}:", day_id - 1));
     output.delete_prefix(0, &format!("pair_hour:{}:", hour_id - 1));
     output.delete_prefix(0, &format!("global_day:{}", day_id - 1));
+    for factory_address in factory::FACTORIES {
+        output.delete_prefix(0, &factory::namespaced(factory_address, format!("global_day:{}", day_id - 1)));
+    }
+
+    let filter = pair_filter::PairFilter::from_params();
 
     for event in events.events {
+        if !filter.allowed_event(&event) {
+            continue;
+        }
+
         if event.r#type.is_some() {
             match event.r#type.unwrap() {
                 Type::Mint(mint) => {
-                    let amount_usd = BigDecimal::from_str(mint.amount_usd.as_str()).unwrap();
-                    if amount_usd.eq(&zero_big_decimal()) {
+                    let amount_usd = decimal::parse(mint.amount_usd.as_str());
+                    if amount_usd.eq(&decimal::zero()) {
                         continue;
                     }
-                    output.add(
+                    output.add_many(
                         event.log_ordinal,
-                        format!("global:liquidity_usd"),
+                        &vec![
+                            format!("global:liquidity_usd"),
+                            factory::namespaced(&event.factory_address, format!("global:liquidity_usd")),
+                        ],
                         &amount_usd,
                     );
 
@@ -656,17 +1219,20 @@ pub fn store_volumes(
                             format!("token:{}:liquidity", mint.to),
                             format!("pair:{}:total_supply", event.pair_address),
                         ],
-                        &BigDecimal::from_str(mint.liquidity.as_str()).unwrap(),
+                        &decimal::parse(mint.liquidity.as_str()),
                     );
                 }
                 Type::Burn(burn) => {
-                    let amount_usd = BigDecimal::from_str(burn.amount_usd.as_str()).unwrap();
-                    if amount_usd.eq(&zero_big_decimal()) {
+                    let amount_usd = decimal::parse(burn.amount_usd.as_str());
+                    if amount_usd.eq(&decimal::zero()) {
                         continue;
                     }
-                    output.add(
+                    output.add_many(
                         event.log_ordinal,
-                        format!("global:liquidity_usd"),
+                        &vec![
+                            format!("global:liquidity_usd"),
+                            factory::namespaced(&event.factory_address, format!("global:liquidity_usd")),
+                        ],
                         &amount_usd.neg(),
                     );
 
@@ -676,18 +1242,18 @@ pub fn store_volumes(
                             format!("token:{}:liquidity", burn.to),
                             format!("pair:{}:total_supply", event.pair_address),
                         ],
-                        &BigDecimal::from_str(burn.liquidity.as_str()).unwrap().neg(),
+                        &decimal::parse(burn.liquidity.as_str()).neg(),
                     );
                 }
                 Type::Swap(swap) => {
                     if swap.amount_usd.is_empty() {
                         continue;
                     }
-                    let amount_usd = BigDecimal::from_str(swap.amount_usd.as_str()).unwrap();
-                    if amount_usd.eq(&zero_big_decimal()) {
+                    let amount_usd = decimal::parse(swap.amount_usd.as_str());
+                    if amount_usd.eq(&decimal::zero()) {
                         continue;
                     }
-                    let amount_bnb = BigDecimal::from_str(swap.amount_bnb.as_str()).unwrap();
+                    let amount_bnb = decimal::parse(swap.amount_bnb.as_str());
 
                     let amount_0_total: BigDecimal =
                         utils::compute_amount_total(swap.amount0_out, swap.amount0_in);
@@ -704,13 +1270,20 @@ pub fn store_volumes(
                             format!("token_day:{}:{}:usd", day_id, event.token1),
                             format!("global:usd"),
                             format!("global_day:{}:usd", day_id),
+                            factory::namespaced(&event.factory_address, format!("global:usd")),
+                            factory::namespaced(&event.factory_address, format!("global_day:{}:usd", day_id)),
                         ],
                         &amount_usd,
                     );
 
                     output.add_many(
                         event.log_ordinal,
-                        &vec![format!("global:bnb"), format!("global_day:{}:bnb", day_id)],
+                        &vec![
+                            format!("global:bnb"),
+                            format!("global_day:{}:bnb", day_id),
+                            factory::namespaced(&event.factory_address, format!("global:bnb")),
+                            factory::namespaced(&event.factory_address, format!("global_day:{}:bnb", day_id)),
+                        ],
                         &amount_bnb,
                     );
 
@@ -737,22 +1310,22 @@ pub fn store_volumes(
                     output.add(
                         event.log_ordinal,
                         format!("token:{}:trade", event.token0),
-                        &BigDecimal::from_str(swap.trade_volume0.as_str()).unwrap(),
+                        &decimal::parse(swap.trade_volume0.as_str()),
                     );
                     output.add(
                         event.log_ordinal,
                         format!("token:{}:trade", event.token1),
-                        &BigDecimal::from_str(swap.trade_volume1.as_str()).unwrap(),
+                        &decimal::parse(swap.trade_volume1.as_str()),
                     );
                     output.add(
                         event.log_ordinal,
                         format!("token:{}:trade_usd", event.token0),
-                        &BigDecimal::from_str(swap.trade_volume_usd0.as_str()).unwrap(),
+                        &decimal::parse(swap.trade_volume_usd0.as_str()),
                     );
                     output.add(
                         event.log_ordinal,
                         format!("token:{}:trade_usd", event.token1),
-                        &BigDecimal::from_str(swap.trade_volume_usd1.as_str()).unwrap(),
+                        &decimal::parse(swap.trade_volume_usd1.as_str()),
                     );
 
                     //todo: token[0,1]Day.dailyVolumeToken, tokenDay[0,1].dailyVolumeBnb ? what about these
@@ -762,6 +1335,477 @@ pub fn store_volumes(
     }
 }
 
+/// USD volume of the swaps per pair and per hour, `pair_hour:<hour>:<pair>:usd`, kept for the
+/// last `volume24h::WINDOW_HOURS` hours. The hour past the window is deleted at every block,
+/// swaps or not, `store_volume24h_totals` takes it back from the running totals.
+#[substreams::handlers::store]
+pub fn store_volume24h(
+    clock: substreams::pb::substreams::Clock,
+    events: pcs::Events,
+    output: store::StoreAddBigFloat,
+) {
+    let hour_id: i64 = clock.timestamp.unwrap().seconds / 3600;
+
+    output.delete_prefix(0, &format!("pair_hour:{}:", hour_id - volume24h::WINDOW_HOURS));
+
+    for event in events.events {
+        if let Some(Type::Swap(swap)) = event.r#type {
+            if swap.amount_usd.is_empty() {
+                continue;
+            }
+            let amount_usd = decimal::parse(swap.amount_usd.as_str());
+            if amount_usd.eq(&decimal::zero()) {
+                continue;
+            }
+            output.add(event.log_ordinal, volume24h::key(hour_id, &event.pair_address), &amount_usd);
+        }
+    }
+}
+
+/// USD volume of the pairs over the last 24 hours, `pair:<pair>:usd`, a running total of the
+/// buckets of `store_volume24h` following its deltas: a swap adds to it, a bucket deleted past
+/// the window is subtracted from it, a block costs one add per bucket changed.
+#[substreams::handlers::store]
+pub fn store_volume24h_totals(volume24h_deltas: store::Deltas, output: store::StoreAddBigFloat) {
+    for delta in volume24h_deltas {
+        if let Some((pair, change)) = volume24h::bucket_change(&delta) {
+            output.add(delta.ordinal, volume24h::total_key(pair), &change);
+        }
+    }
+}
+
+/// Summaries of the pairs whose reserves changed in the block, joining the pairs, prices and
+/// volume24h totals stores into a single message per pair so sinks and their clients don't
+/// have to.
+#[substreams::handlers::map]
+pub fn map_pair_summaries(
+    clock: substreams::pb::substreams::Clock,
+    reserves: pcs::Reserves,
+    pairs: store::StoreGet,
+    tokens: store::StoreGet,
+    prices: store::StoreGet,
+    volumes: store::StoreGet,
+) -> Result<pcs::PairSummaries, Error> {
+    let price_of = |key: String| -> Option<BigDecimal> {
+        prices.get_last(&key).map(|price_bytes| decimal::from_store(&price_bytes))
+    };
+    let symbol_of = |token_address: &String| -> String {
+        match tokens.get_last(&format!("token:{}", token_address)) {
+            None => String::new(),
+            Some(token_bytes) => {
+                let token: Token = proto::decode(&token_bytes).unwrap();
+                token.symbol
+            }
+        }
+    };
+
+    // a pair syncs several times in a block, its summary is at the last Sync
+    let mut summaries: Vec<pcs::PairSummary> = vec![];
+    let mut positions: HashMap<String, usize> = HashMap::new();
+    for reserve in reserves.reserves {
+        let pair: pcs::Pair = match pairs.get_last(&format!("pair:{}", reserve.pair_address)) {
+            None => continue,
+            Some(pair_bytes) => proto::decode(&pair_bytes).unwrap(),
+        };
+
+        let token0_price_usd = price_of(format!("dprice:{}:usd", pair.token0_address));
+        let token1_price_usd = price_of(format!("dprice:{}:usd", pair.token1_address));
+        let reserve0_usd = price_of(format!("dreserve:{}:{}:usd", pair.address, pair.token0_address));
+        let reserve1_usd = price_of(format!("dreserve:{}:{}:usd", pair.address, pair.token1_address));
+        let reserve_usd = match (reserve0_usd, reserve1_usd) {
+            (None, None) => None,
+            (reserve0_usd, reserve1_usd) => Some(decimal::round(
+                reserve0_usd.unwrap_or_else(decimal::zero).add(reserve1_usd.unwrap_or_else(decimal::zero)),
+            )),
+        };
+        let to_string = |value: Option<BigDecimal>| value.map(|value| value.to_string()).unwrap_or_default();
+
+        let summary = pcs::PairSummary {
+            pair_address: pair.address.clone(),
+            factory_address: pair.factory_address.clone(),
+            token0_symbol: symbol_of(&pair.token0_address),
+            token1_symbol: symbol_of(&pair.token1_address),
+            token0_address: pair.token0_address,
+            token1_address: pair.token1_address,
+            reserve0: reserve.reserve0,
+            reserve1: reserve.reserve1,
+            token0_price: reserve.token0_price,
+            token1_price: reserve.token1_price,
+            token0_price_usd: to_string(token0_price_usd),
+            token1_price_usd: to_string(token1_price_usd),
+            reserve_usd: to_string(reserve_usd),
+            volume_usd_24h: volume24h::pair_volume(&volumes, &pair.address).to_string(),
+            block_num: clock.number,
+            log_ordinal: reserve.log_ordinal,
+        };
+
+        match positions.get(&pair.address) {
+            Some(&position) => summaries[position] = summary,
+            None => {
+                positions.insert(pair.address, summaries.len());
+                summaries.push(summary);
+            }
+        }
+    }
+
+    Ok(pcs::PairSummaries { summaries })
+}
+
+/// USD price index of the tokens of `basket.csv`, `index:<symbol>`: the USD price of the
+/// token in each of its pairs weighted by the USD volume of the pair over the last 24 hours,
+/// their average while none of them traded. Updated at the blocks where one of the pairs
+/// syncs, at the ordinal of its last `Sync`.
+#[substreams::handlers::store]
+pub fn store_price_index(
+    reserves: pcs::Reserves,
+    pairs: store::StoreGet,
+    reserves_store: store::StoreGet,
+    prices: store::StoreGet,
+    volumes: store::StoreGet,
+    output: store::StoreSet,
+) {
+    let entries = basket::entries();
+
+    let mut updated: BTreeMap<&str, u64> = BTreeMap::new();
+    for reserve in &reserves.reserves {
+        for entry in entries.iter().filter(|entry| entry.pair == reserve.pair_address) {
+            updated.insert(entry.symbol, reserve.log_ordinal);
+        }
+    }
+
+    for (symbol, log_ordinal) in updated {
+        let mut pair_prices: Vec<Option<BigDecimal>> = vec![];
+        let mut weighted_sum = decimal::zero();
+        let mut volume_sum = decimal::zero();
+        for entry in entries.iter().filter(|entry| entry.symbol == symbol) {
+            let price_usd = match basket::pair_price_usd(&pairs, &reserves_store, &prices, entry.pair, entry.token) {
+                None => continue,
+                Some(price_usd) => price_usd,
+            };
+            let volume_usd = volume24h::pair_volume(&volumes, entry.pair);
+
+            weighted_sum = decimal::round(weighted_sum.add(decimal::mul(&price_usd, &volume_usd)));
+            volume_sum = decimal::round(volume_sum.add(volume_usd));
+            pair_prices.push(Some(price_usd));
+        }
+        if pair_prices.is_empty() {
+            continue;
+        }
+
+        let index = if volume_sum.eq(&decimal::zero()) {
+            decimal::average(&pair_prices)
+        } else {
+            decimal::div(&weighted_sum, &volume_sum)
+        };
+        output.set(log_ordinal, format!("index:{}", symbol), &Vec::from(index.to_string()));
+    }
+}
+
+/// Token flows of the swaps, from the token sold to the token bought, per day and in total:
+/// `flow_day:<day>:<from>:<to>:<usd|in|out|swaps>` and `flow:<from>:<to>:<usd|swaps>`, `in` being
+/// the amount of the sold token and `out` the amount of the bought one.
+#[substreams::handlers::store]
+pub fn store_token_flows(
+    clock: substreams::pb::substreams::Clock,
+    events: pcs::Events,
+    output: store::StoreAddBigFloat,
+) {
+    let day_id: i64 = clock.timestamp.unwrap().seconds / 86400;
+
+    for event in events.events {
+        let swap = match event.r#type {
+            Some(Type::Swap(swap)) => swap,
+            _ => continue,
+        };
+
+        let amount0_in = decimal::parse(swap.amount0_in.as_str());
+        let amount1_in = decimal::parse(swap.amount1_in.as_str());
+        let (from, to, amount_in, amount_out) = if amount0_in > amount1_in {
+            (&event.token0, &event.token1, amount0_in, decimal::parse(swap.amount1_out.as_str()))
+        } else {
+            (&event.token1, &event.token0, amount1_in, decimal::parse(swap.amount0_out.as_str()))
+        };
+        if amount_in.eq(&decimal::zero()) {
+            continue;
+        }
+
+        if !swap.amount_usd.is_empty() {
+            output.add_many(
+                event.log_ordinal,
+                &vec![
+                    format!("flow_day:{}:{}:{}:usd", day_id, from, to),
+                    format!("flow:{}:{}:usd", from, to),
+                ],
+                &decimal::parse(swap.amount_usd.as_str()),
+            );
+        }
+        output.add(event.log_ordinal, format!("flow_day:{}:{}:{}:in", day_id, from, to), &amount_in);
+        output.add(event.log_ordinal, format!("flow_day:{}:{}:{}:out", day_id, from, to), &amount_out);
+        output.add_many(
+            event.log_ordinal,
+            &vec![
+                format!("flow_day:{}:{}:{}:swaps", day_id, from, to),
+                format!("flow:{}:{}:swaps", from, to),
+            ],
+            &decimal::one(),
+        );
+    }
+}
+
+/// Price impact of the swaps per pair and day, as sums: `pair_day:<day>:<pair>:<swaps|price_impact|
+/// slippage>` average over the swaps, `usd_price_impact` over `usd` is the volume weighted average.
+/// Swaps without known reserves are not counted.
+#[substreams::handlers::store]
+pub fn store_price_impact(
+    clock: substreams::pb::substreams::Clock,
+    events: pcs::Events,
+    output: store::StoreAddBigFloat,
+) {
+    let day_id: i64 = clock.timestamp.unwrap().seconds / 86400;
+
+    for event in events.events {
+        let swap = match event.r#type {
+            Some(Type::Swap(swap)) => swap,
+            _ => continue,
+        };
+        if swap.price_impact.is_empty() {
+            continue;
+        }
+
+        let price_impact = decimal::parse(swap.price_impact.as_str());
+        let prefix = format!("pair_day:{}:{}", day_id, event.pair_address);
+
+        output.add(event.log_ordinal, format!("{}:swaps", prefix), &decimal::one());
+        output.add(event.log_ordinal, format!("{}:price_impact", prefix), &price_impact);
+        output.add(
+            event.log_ordinal,
+            format!("{}:slippage", prefix),
+            &decimal::parse(swap.slippage.as_str()),
+        );
+
+        if !swap.amount_usd.is_empty() {
+            let amount_usd = decimal::parse(swap.amount_usd.as_str());
+            output.add(
+                event.log_ordinal,
+                format!("{}:usd_price_impact", prefix),
+                &decimal::mul(&price_impact, &amount_usd),
+            );
+            output.add(event.log_ordinal, format!("{}:usd", prefix), &amount_usd);
+        }
+    }
+}
+
+#[substreams::handlers::store]
+pub fn store_fees(
+    clock: substreams::pb::substreams::Clock,
+    events: pcs::Events,
+    output: store::StoreAddBigFloat,
+) {
+    let timestamp_seconds = clock.timestamp.unwrap().seconds;
+    let day_id: i64 = timestamp_seconds / 86400;
+
+    if events.events.len() == 0 {
+        return;
+    }
+
+    output.delete_prefix(0, &format!("pair_day:{}:", day_id - 1));
+    output.delete_prefix(0, &format!("global_day:{}:", day_id - 1));
+
+    // sets, for both lp_fees and protocol_fees:
+    // * pair:%s:%s:token0|token1|usd (pair, kind)
+    // * pair_day:%d:%s:%s:token0|token1|usd (day, pair, kind)
+    // * global:%s:usd (kind)
+    // * global_day:%d:%s:usd (day, kind)
+    for event in events.events {
+        let swap = match event.r#type {
+            Some(Type::Swap(swap)) => swap,
+            _ => continue,
+        };
+
+        // fees are taken on the input side of the swap only
+        let amounts = vec![
+            ("token0", BigDecimal::from_str(swap.amount0_in.as_str()).unwrap()),
+            ("token1", BigDecimal::from_str(swap.amount1_in.as_str()).unwrap()),
+        ];
+        for (token, amount) in amounts {
+            if amount.eq(&decimal::zero()) {
+                continue;
+            }
+
+            let (lp_fee, protocol_fee) = fees::compute_fees(&amount);
+            for (kind, fee) in vec![("lp_fees", lp_fee), ("protocol_fees", protocol_fee)] {
+                output.add_many(
+                    event.log_ordinal,
+                    &vec![
+                        format!("pair:{}:{}:{}", event.pair_address, kind, token),
+                        format!("pair_day:{}:{}:{}:{}", day_id, event.pair_address, kind, token),
+                    ],
+                    &fee,
+                );
+            }
+        }
+
+        if swap.amount_usd.is_empty() {
+            continue;
+        }
+        let amount_usd = BigDecimal::from_str(swap.amount_usd.as_str()).unwrap();
+        if amount_usd.eq(&decimal::zero()) {
+            continue;
+        }
+
+        let (lp_fee_usd, protocol_fee_usd) = fees::compute_fees(&amount_usd);
+        for (kind, fee) in vec![("lp_fees", lp_fee_usd), ("protocol_fees", protocol_fee_usd)] {
+            output.add_many(
+                event.log_ordinal,
+                &vec![
+                    format!("pair:{}:{}:usd", event.pair_address, kind),
+                    format!("pair_day:{}:{}:{}:usd", day_id, event.pair_address, kind),
+                    format!("global:{}:usd", kind),
+                    format!("global_day:{}:{}:usd", day_id, kind),
+                ],
+                &fee,
+            );
+        }
+    }
+}
+
+#[substreams::handlers::store]
+pub fn store_lp_positions(events: pcs::Events, output: store::StoreAddBigFloat) {
+    // sets:
+    // * position:%s:%s:liquidity (pair, provider)  - net LP tokens held
+    // * position:%s:%s:minted_liquidity (pair, provider)  - LP tokens ever minted
+    // * position:%s:%s:entry_weight (pair, provider)  - sum of minted liquidity * token0 price at mint
+    // the entry price of a position is entry_weight / minted_liquidity
+    for event in events.events {
+        match event.r#type {
+            Some(Type::Mint(mint)) => {
+                let liquidity = BigDecimal::from_str(mint.liquidity.as_str()).unwrap();
+                let amount0 = BigDecimal::from_str(mint.amount0.as_str()).unwrap();
+                let amount1 = BigDecimal::from_str(mint.amount1.as_str()).unwrap();
+                if amount1.eq(&decimal::zero()) {
+                    continue;
+                }
+
+                output.add_many(
+                    event.log_ordinal,
+                    &vec![
+                        format!("position:{}:{}:liquidity", event.pair_address, mint.to),
+                        format!("position:{}:{}:minted_liquidity", event.pair_address, mint.to),
+                    ],
+                    &liquidity,
+                );
+                output.add(
+                    event.log_ordinal,
+                    format!("position:{}:{}:entry_weight", event.pair_address, mint.to),
+                    &decimal::mul(&liquidity, &decimal::div(&amount0, &amount1)),
+                );
+            }
+            Some(Type::Burn(burn)) => {
+                output.add(
+                    event.log_ordinal,
+                    format!("position:{}:{}:liquidity", event.pair_address, burn.to),
+                    &BigDecimal::from_str(burn.liquidity.as_str()).unwrap().neg(),
+                );
+            }
+            _ => continue,
+        }
+    }
+}
+
+#[substreams::handlers::store]
+pub fn store_lp_provider_count(positions_deltas: store::Deltas, output: store::StoreAddInt64) {
+    for delta in positions_deltas {
+        if delta.operation != substreams::pb::substreams::store_delta::Operation::Create as i32 {
+            continue;
+        }
+
+        // position:%s:%s:minted_liquidity is only created once per provider
+        let parts: Vec<&str> = delta.key.split(":").collect();
+        if parts[3] != "minted_liquidity" {
+            continue;
+        }
+
+        output.add(delta.ordinal, format!("pair:{}:provider_count", parts[1]), 1);
+    }
+}
+
+#[substreams::handlers::store]
+pub fn store_lp_provider_index(positions_deltas: store::Deltas, provider_counts: store::StoreGet, output: store::StoreSet) {
+    // sets:
+    // * provider:%s:%d (pair, index)  - index starts at 1, up to pair:%s:provider_count
+    for delta in positions_deltas {
+        if delta.operation != substreams::pb::substreams::store_delta::Operation::Create as i32 {
+            continue;
+        }
+
+        let parts: Vec<&str> = delta.key.split(":").collect();
+        if parts[3] != "minted_liquidity" {
+            continue;
+        }
+
+        let index = match provider_counts.get_at(delta.ordinal, &format!("pair:{}:provider_count", parts[1])) {
+            None => continue,
+            Some(count_bytes) => String::from_utf8(count_bytes).unwrap(),
+        };
+
+        output.set(
+            delta.ordinal,
+            format!("provider:{}:{}", parts[1], index),
+            &Vec::from(parts[2]),
+        );
+    }
+}
+
+#[substreams::handlers::store]
+pub fn store_impermanent_loss(
+    reserves: pcs::Reserves,
+    positions: store::StoreGet,
+    provider_counts: store::StoreGet,
+    providers: store::StoreGet,
+    output: store::StoreSet,
+) {
+    // Every price update walks all the providers of the pair, fine for a demonstration
+    // but it gets expensive on the most active pairs.
+    for reserve in reserves.reserves {
+        let current_price = BigDecimal::from_str(reserve.token0_price.as_str()).unwrap();
+
+        let provider_count: i64 = match provider_counts.get_last(&format!("pair:{}:provider_count", reserve.pair_address)) {
+            None => continue,
+            Some(count_bytes) => String::from_utf8(count_bytes).unwrap().parse().unwrap(),
+        };
+
+        for index in 1..=provider_count {
+            let provider = match providers.get_last(&format!("provider:{}:{}", reserve.pair_address, index)) {
+                None => continue,
+                Some(provider_bytes) => String::from_utf8(provider_bytes).unwrap(),
+            };
+
+            let position_value = |name: &str| -> BigDecimal {
+                match positions.get_last(&format!("position:{}:{}:{}", reserve.pair_address, provider, name)) {
+                    None => decimal::zero(),
+                    Some(value_bytes) => decimal::from_store(&value_bytes),
+                }
+            };
+
+            let liquidity = position_value("liquidity");
+            let minted_liquidity = position_value("minted_liquidity");
+            if liquidity.le(&decimal::zero()) || minted_liquidity.eq(&decimal::zero()) {
+                continue;
+            }
+
+            let entry_price = decimal::div(&position_value("entry_weight"), &minted_liquidity);
+            match il::compute_impermanent_loss(&entry_price, &current_price) {
+                None => continue,
+                Some(impermanent_loss) => output.set(
+                    reserve.log_ordinal,
+                    format!("il:{}:{}", reserve.pair_address, provider),
+                    &Vec::from(impermanent_loss.to_string()),
+                ),
+            }
+        }
+    }
+}
+
 // todo: create pcs-token proto
 #[substreams::handlers::store]
 pub fn store_pcs_tokens(
@@ -851,6 +1895,24 @@ pub fn store_pcs_tokens(
     }
 }
 
+#[substreams::handlers::store]
+pub fn store_token_decimals(pcs_tokens_deltas: store::Deltas, output: store::StoreSetIfNotExists) {
+    // sets:
+    // * token:%s (token)  - decimals of the token, set once when the token is first stored
+    for delta in pcs_tokens_deltas {
+        if delta.operation != substreams::pb::substreams::store_delta::Operation::Create as i32 {
+            continue;
+        }
+
+        let token: Token = proto::decode(&delta.new_value).unwrap();
+        output.set_if_not_exists(
+            delta.ordinal,
+            format!("token:{}", token.address),
+            &Vec::from(token.decimals.to_string()),
+        );
+    }
+}
+
 #[substreams::handlers::map]
 pub fn db_out(
     block: substreams::pb::substreams::Clock,
@@ -861,6 +1923,7 @@ pub fn db_out(
     reserves_deltas: store::Deltas,
     events: pcs::Events,
     pcs_tokens_store: store::StoreGet,
+    pairs_store: store::StoreGet,
 ) -> Result<DatabaseChanges, Error> {
     substreams::register_panic_hook();
 
@@ -879,6 +1942,7 @@ pub fn db_out(
         reserves_deltas,
         events,
         &pcs_tokens_store,
+        &pairs_store,
     );
 
     return Ok(changes);
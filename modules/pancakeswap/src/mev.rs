@@ -0,0 +1,167 @@
+use std::collections::HashMap;
+use std::ops::{Add, Sub};
+use std::str::FromStr;
+
+use bigdecimal::BigDecimal;
+
+use crate::decimal;
+use crate::pcs;
+use crate::pcs::event::Type;
+use crate::reserves::BlockReserves;
+
+struct PairSwap<'a> {
+    event: &'a pcs::Event,
+    swap: &'a pcs::Swap,
+    // true when token0 goes in the pair, i.e. token1 is bought
+    zero_for_one: bool,
+    amount_in: BigDecimal,
+    amount_out: BigDecimal,
+}
+
+/// Detects classic sandwiches in the swaps of a block: on a given pair, an attacker
+/// swaps in a direction (front-run), one or more other transactions swap in the same
+/// direction (victims), then the attacker swaps back (back-run). Swaps are considered
+/// in block order, each swap is used in at most one sandwich. `reserves` gives the state
+/// of the pair the victims traded against.
+pub fn detect_sandwiches(events: &Vec<pcs::Event>, reserves: &BlockReserves) -> Vec<pcs::Sandwich> {
+    let mut sandwiches: Vec<pcs::Sandwich> = vec![];
+
+    let mut swaps_per_pair: HashMap<&str, Vec<PairSwap>> = HashMap::new();
+    for event in events {
+        if let Some(swap) = new_pair_swap(event) {
+            swaps_per_pair
+                .entry(event.pair_address.as_str())
+                .or_insert(vec![])
+                .push(swap);
+        }
+    }
+
+    let mut pairs: Vec<&str> = swaps_per_pair.keys().cloned().collect();
+    pairs.sort();
+
+    for pair in pairs {
+        let swaps = swaps_per_pair.get_mut(pair).unwrap();
+        swaps.sort_by(|a, b| a.event.log_ordinal.cmp(&b.event.log_ordinal));
+
+        let mut used: Vec<bool> = vec![false; swaps.len()];
+        for front in 0..swaps.len() {
+            if used[front] {
+                continue;
+            }
+
+            if let Some((back, victims)) = find_back_run(swaps, &used, front) {
+                used[front] = true;
+                used[back] = true;
+                for victim in victims.iter() {
+                    used[*victim] = true;
+                }
+
+                sandwiches.push(new_sandwich(swaps, front, back, &victims, reserves));
+            }
+        }
+    }
+
+    sandwiches.sort_by(|a, b| a.log_ordinal.cmp(&b.log_ordinal));
+    sandwiches
+}
+
+fn find_back_run(swaps: &Vec<PairSwap>, used: &Vec<bool>, front: usize) -> Option<(usize, Vec<usize>)> {
+    let front_swap = &swaps[front];
+    let mut victims: Vec<usize> = vec![];
+
+    for i in (front + 1)..swaps.len() {
+        if used[i] {
+            continue;
+        }
+
+        let candidate = &swaps[i];
+        if candidate.event.transaction_id == front_swap.event.transaction_id {
+            continue;
+        }
+
+        if candidate.swap.from == front_swap.swap.from {
+            if candidate.zero_for_one != front_swap.zero_for_one && !victims.is_empty() {
+                return Some((i, victims));
+            }
+            continue;
+        }
+
+        if candidate.zero_for_one == front_swap.zero_for_one {
+            victims.push(i);
+        }
+    }
+
+    None
+}
+
+fn new_sandwich(swaps: &Vec<PairSwap>, front: usize, back: usize, victims: &Vec<usize>, reserves: &BlockReserves) -> pcs::Sandwich {
+    let front_swap = &swaps[front];
+    let back_swap = &swaps[back];
+
+    let token = if front_swap.zero_for_one {
+        front_swap.event.token0.clone()
+    } else {
+        front_swap.event.token1.clone()
+    };
+
+    let mut victims_amount_usd = decimal::zero();
+    for victim in victims {
+        if swaps[*victim].swap.amount_usd.is_empty() {
+            continue;
+        }
+        victims_amount_usd = victims_amount_usd.add(BigDecimal::from_str(swaps[*victim].swap.amount_usd.as_str()).unwrap());
+    }
+
+    // the `Sync` of the front-run comes right before its `Swap`
+    let (front_run_reserve0, front_run_reserve1) =
+        match reserves.at(&front_swap.event.pair_address, front_swap.event.log_ordinal) {
+            Some(snapshot) => (snapshot.reserve0.to_string(), snapshot.reserve1.to_string()),
+            None => (String::new(), String::new()),
+        };
+
+    pcs::Sandwich {
+        id: front_swap.event.id.clone(),
+        pair_address: front_swap.event.pair_address.clone(),
+        attacker: front_swap.swap.from.clone(),
+        front_transaction_id: front_swap.event.transaction_id.clone(),
+        back_transaction_id: back_swap.event.transaction_id.clone(),
+        victim_transaction_ids: victims
+            .iter()
+            .map(|victim| swaps[*victim].event.transaction_id.clone())
+            .collect(),
+        token,
+        profit: back_swap.amount_out.clone().sub(&front_swap.amount_in).to_string(),
+        victims_amount_usd: victims_amount_usd.to_string(),
+        log_ordinal: front_swap.event.log_ordinal,
+        timestamp: front_swap.event.timestamp,
+        front_run_reserve0,
+        front_run_reserve1,
+    }
+}
+
+fn new_pair_swap(event: &pcs::Event) -> Option<PairSwap> {
+    let swap = match event.r#type.as_ref() {
+        Some(Type::Swap(swap)) => swap,
+        _ => return None,
+    };
+
+    let amount0_in = BigDecimal::from_str(swap.amount0_in.as_str()).unwrap();
+    let zero_for_one = amount0_in.ne(&decimal::zero());
+
+    let (amount_in, amount_out) = if zero_for_one {
+        (amount0_in, BigDecimal::from_str(swap.amount1_out.as_str()).unwrap())
+    } else {
+        (
+            BigDecimal::from_str(swap.amount1_in.as_str()).unwrap(),
+            BigDecimal::from_str(swap.amount0_out.as_str()).unwrap(),
+        )
+    };
+
+    Some(PairSwap {
+        event,
+        swap,
+        zero_for_one,
+        amount_in,
+        amount_out,
+    })
+}
@@ -0,0 +1,41 @@
+use std::ops::Sub;
+use std::str::FromStr;
+
+use bigdecimal::BigDecimal;
+
+use crate::decimal;
+
+/// Chainlink USD feeds on BSC, (proxy address, token address). All of these
+/// feeds report their answer with 8 decimals.
+pub const CHAINLINK_FEEDS: [(&str, &str); 6] = [
+    ("0x0567f2323251f0aab15c8dfb1967e4e8a7d42aee", "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c"), // BNB / USD
+    ("0x264990fbd0a4796a3e3d8e37c4d5f87a3aca5ebf", "0x7130d2a12b9bcbfae4f2634d864a1ee1ce3ead9c"), // BTC / USD
+    ("0x9ef1b8c0e4f7dc8bf5719ea496883dc6401d5b2e", "0x2170ed0880ac9a755fd29b2688956bd959f933f8"), // ETH / USD
+    ("0xcbb98864ef56e9042e7d2efef76141f15731b82f", "0xe9e7cea3dedca5984780bafc599bd69add087d56"), // BUSD / USD
+    ("0xb97ad0e74fa7d920791e90258a6e2085088b4320", "0x55d398326f99059ff775485246999027b3197955"), // USDT / USD
+    ("0xb6064ed41d4f67e353768aa239ca86f4f73665a1", "0x0e09fabb73bd3ade0a17ecc321fd13a19e81ce82"), // CAKE / USD
+];
+
+pub const CHAINLINK_DECIMALS: u64 = 8;
+
+/// Feeds are sampled every N blocks (~10 minutes on BSC), querying them on every
+/// block would multiply the number of `eth_call` for little benefit.
+pub const SAMPLE_INTERVAL: u64 = 200;
+
+/// Relative deviation between the AMM derived price and the oracle price above
+/// which a token gets flagged, 0.05 is 5%.
+pub const DEVIATION_THRESHOLD: &str = "0.05";
+
+/// Returns the relative deviation `|amm - oracle| / oracle`, or `None` when the
+/// oracle price is zero.
+pub fn compute_deviation(amm_price: &BigDecimal, oracle_price: &BigDecimal) -> Option<BigDecimal> {
+    if oracle_price.eq(&decimal::zero()) {
+        return None;
+    }
+
+    Some(decimal::div(&amm_price.sub(oracle_price).abs(), oracle_price))
+}
+
+pub fn is_deviation_flagged(deviation: &BigDecimal) -> bool {
+    deviation.gt(&BigDecimal::from_str(DEVIATION_THRESHOLD).unwrap())
+}
@@ -0,0 +1,136 @@
+use std::collections::HashSet;
+
+use crate::params;
+use crate::pb::pcs;
+
+/// Parameter holding the comma separated allowed pair or token addresses.
+pub const ALLOW_PARAM: &str = "allow-pair";
+
+/// Parameter holding the comma separated blocked pair or token addresses.
+pub const BLOCK_PARAM: &str = "block-pair";
+
+/// Pairs left out of reserves, prices, volumes and totals, like scam or fee-on-transfer pairs
+/// whose prices and volumes are meaningless. A pair is excluded when its address or one of its
+/// tokens is blocked or, when an allow list is set, when none of them is allowed.
+///
+/// The lists are module parameters set by the consumer, see `params`.
+pub struct PairFilter {
+    allow: HashSet<String>,
+    block: HashSet<String>,
+}
+
+impl PairFilter {
+    pub fn new(allow: Vec<String>, block: Vec<String>) -> PairFilter {
+        PairFilter {
+            allow: allow.iter().map(|addr| addr.to_lowercase()).collect(),
+            block: block.iter().map(|addr| addr.to_lowercase()).collect(),
+        }
+    }
+
+    pub fn from_params() -> PairFilter {
+        PairFilter::new(params::get_list(ALLOW_PARAM), params::get_list(BLOCK_PARAM))
+    }
+
+    /// Returns whether something referencing the given addresses passes the filter.
+    pub fn allowed(&self, addresses: &[&str]) -> bool {
+        if addresses.is_empty() {
+            return true;
+        }
+
+        let mut allowed = self.allow.is_empty();
+        for addr in addresses {
+            if self.block.contains(*addr) {
+                return false;
+            }
+            if self.allow.contains(*addr) {
+                allowed = true;
+            }
+        }
+
+        allowed
+    }
+
+    pub fn allowed_pair(&self, pair: &pcs::Pair) -> bool {
+        self.allowed(&[
+            pair.address.as_str(),
+            pair.token0_address.as_str(),
+            pair.token1_address.as_str(),
+        ])
+    }
+
+    pub fn allowed_event(&self, event: &pcs::Event) -> bool {
+        self.allowed(&[
+            event.pair_address.as_str(),
+            event.token0.as_str(),
+            event.token1.as_str(),
+        ])
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const GOOD_PAIR: &str = "0x58f876857a02d6762e0101bb5c46a8c1ed44dc16";
+    const SCAM_PAIR: &str = "0x1111111111111111111111111111111111111111";
+    const TOKEN: &str = "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c";
+
+    fn list(addresses: &[&str]) -> Vec<String> {
+        addresses.iter().map(|addr| addr.to_string()).collect()
+    }
+
+    #[test]
+    fn test_allowed() {
+        let tests: Vec<(&str, Vec<String>, Vec<String>, Vec<&str>, bool)> = vec![
+            ("no lists", list(&[]), list(&[]), vec![SCAM_PAIR], true),
+            ("no addresses", list(&[]), list(&[SCAM_PAIR]), vec![], true),
+            (
+                "blocked",
+                list(&[]),
+                list(&[SCAM_PAIR]),
+                vec![TOKEN, SCAM_PAIR],
+                false,
+            ),
+            (
+                "not blocked",
+                list(&[]),
+                list(&[SCAM_PAIR]),
+                vec![GOOD_PAIR],
+                true,
+            ),
+            (
+                "allowed",
+                list(&[GOOD_PAIR]),
+                list(&[]),
+                vec![GOOD_PAIR, TOKEN],
+                true,
+            ),
+            (
+                "not allowed",
+                list(&[GOOD_PAIR]),
+                list(&[]),
+                vec![SCAM_PAIR],
+                false,
+            ),
+            (
+                "blocked wins over allowed",
+                list(&[GOOD_PAIR]),
+                list(&[TOKEN]),
+                vec![GOOD_PAIR, TOKEN],
+                false,
+            ),
+            (
+                "lists are lowercased",
+                list(&[]),
+                list(&["0xBB4CDB9CBD36B01BD1CBAEBF2DE08D9173BC095C"]),
+                vec![GOOD_PAIR, TOKEN],
+                false,
+            ),
+        ];
+
+        for (name, allow, block, addresses, expected) in tests {
+            let filter = PairFilter::new(allow, block);
+            assert_eq!(filter.allowed(&addresses), expected, "{}", name);
+        }
+    }
+}
@@ -0,0 +1,101 @@
+use std::ptr;
+
+/// Start of the block of parameters embedded in the binary, the consumer finds the block by
+/// it and writes the parameters of the run after it in the binary it sends with its request,
+/// see the `modparams` package of the consumer. Keep both in sync.
+const MARKER: &[u8] = b"pcs-module-params:v1\n";
+
+/// Size of the block of parameters, the marker included.
+const SIZE: usize = 32768;
+
+/// Block of parameters, `name=value` lines after `MARKER`. It's padded with new lines rather
+/// than zeros so the whole block is kept in the data of the binary, where the consumer
+/// overwrites it. Without a consumer setting them, no parameter is set.
+#[used]
+#[no_mangle]
+static PCS_MODULE_PARAMS: [u8; SIZE] = block();
+
+const fn block() -> [u8; SIZE] {
+    let mut block = [b'\n'; SIZE];
+    let mut i = 0;
+    while i < MARKER.len() {
+        block[i] = MARKER[i];
+        i += 1;
+    }
+    block
+}
+
+/// Returns the value of the parameter `name`, `None` when it isn't set.
+pub fn get(name: &str) -> Option<String> {
+    parse(&read(), name)
+}
+
+/// Returns whether the boolean parameter `name` is set to `true`, `default` when it isn't set.
+pub fn get_bool(name: &str, default: bool) -> bool {
+    match get(name) {
+        None => default,
+        Some(value) => value == "true",
+    }
+}
+
+/// Returns the comma separated values of the parameter `name`, empty when it isn't set.
+pub fn get_list(name: &str) -> Vec<String> {
+    match get(name) {
+        None => vec![],
+        Some(value) => value
+            .split(',')
+            .map(|item| item.trim())
+            .filter(|item| !item.is_empty())
+            .map(|item| item.to_string())
+            .collect(),
+    }
+}
+
+/// Reads the block as it is in the binary: the reads are volatile, the block is overwritten
+/// after the build and can't be assumed to hold what it's initialized with.
+fn read() -> Vec<u8> {
+    let start = PCS_MODULE_PARAMS.as_ptr();
+    (0..SIZE)
+        .map(|i| unsafe { ptr::read_volatile(start.add(i)) })
+        .collect()
+}
+
+/// Returns the value of `name` in `block`, the marker is skipped rather than compared: comparing
+/// it would put a second copy of it in the binary, where the consumer expects a single one.
+fn parse(block: &[u8], name: &str) -> Option<String> {
+    String::from_utf8_lossy(&block[MARKER.len()..])
+        .lines()
+        .filter_map(|line| line.split_once('='))
+        .find(|(key, _)| key.trim() == name)
+        .map(|(_, value)| value.trim().to_string())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn params(lines: &str) -> Vec<u8> {
+        let mut block = MARKER.to_vec();
+        block.extend_from_slice(lines.as_bytes());
+        block.resize(SIZE, b'\n');
+        block
+    }
+
+    #[test]
+    fn test_parse() {
+        let block = params("adjusted-accounting=false\nblock-pair=0xaa,0xbb\n");
+
+        assert_eq!(
+            parse(&block, "adjusted-accounting"),
+            Some("false".to_string())
+        );
+        assert_eq!(parse(&block, "block-pair"), Some("0xaa,0xbb".to_string()));
+        assert_eq!(parse(&block, "allow-pair"), None);
+    }
+
+    #[test]
+    fn test_default_block() {
+        assert_eq!(block().len(), SIZE);
+        assert_eq!(parse(&block(), "block-pair"), None);
+    }
+}
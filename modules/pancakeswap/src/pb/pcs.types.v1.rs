@@ -17,6 +17,8 @@ pub struct Pair {
     pub block_num: u64,
     #[prost(uint64, tag="6")]
     pub log_ordinal: u64,
+    #[prost(string, tag="7")]
+    pub factory_address: ::prost::alloc::string::String,
 }
 //message ERC20Token {
 //  string address = 1;
@@ -44,6 +46,9 @@ pub struct Reserve {
     pub token0_price: ::prost::alloc::string::String,
     #[prost(string, tag="6")]
     pub token1_price: ::prost::alloc::string::String,
+    /// ID of the Sync log, see Event.id
+    #[prost(string, tag="7")]
+    pub id: ::prost::alloc::string::String,
 }
 #[derive(Clone, PartialEq, ::prost::Message)]
 pub struct Events {
@@ -64,6 +69,13 @@ pub struct Event {
     pub transaction_id: ::prost::alloc::string::String,
     #[prost(uint64, tag="105")]
     pub timestamp: u64,
+    #[prost(string, tag="106")]
+    pub factory_address: ::prost::alloc::string::String,
+    /// ID of the swap, mint or burn log, `<chain>:<block hash>:<trx hash>:<log index>`,
+    /// the log index within its transaction. It's stable across reprocessings of the
+    /// block, sinks upsert by it. The swap, mint or burn carries the same ID.
+    #[prost(string, tag="107")]
+    pub id: ::prost::alloc::string::String,
     #[prost(oneof="event::Type", tags="1, 2, 3")]
     pub r#type: ::core::option::Option<event::Type>,
 }
@@ -121,6 +133,29 @@ pub struct Swap {
     pub volume_token1: ::prost::alloc::string::String,
     #[prost(string, tag="20")]
     pub log_address: ::prost::alloc::string::String,
+    /// Prices in amount of the bought token per token_in sold, from the reserves
+    /// at the swap, empty when unknown. price_impact includes the LP fee,
+    /// slippage is how far the trade moved the pool price.
+    #[prost(string, tag="21")]
+    pub token_in: ::prost::alloc::string::String,
+    #[prost(string, tag="22")]
+    pub mid_price: ::prost::alloc::string::String,
+    #[prost(string, tag="23")]
+    pub execution_price: ::prost::alloc::string::String,
+    #[prost(string, tag="24")]
+    pub post_trade_mid_price: ::prost::alloc::string::String,
+    #[prost(string, tag="25")]
+    pub price_impact: ::prost::alloc::string::String,
+    #[prost(string, tag="26")]
+    pub slippage: ::prost::alloc::string::String,
+    /// labels of the addresses from address_labels, set by map_labeled_events,
+    /// empty when unlabelled
+    #[prost(string, tag="27")]
+    pub sender_label: ::prost::alloc::string::String,
+    #[prost(string, tag="28")]
+    pub to_label: ::prost::alloc::string::String,
+    #[prost(string, tag="29")]
+    pub from_label: ::prost::alloc::string::String,
 }
 #[derive(Clone, PartialEq, ::prost::Message)]
 pub struct Burn {
@@ -164,3 +199,280 @@ pub struct Mint {
     #[prost(string, tag="9")]
     pub fee_liquidity: ::prost::alloc::string::String,
 }
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct OraclePrices {
+    #[prost(message, repeated, tag="1")]
+    pub oracle_prices: ::prost::alloc::vec::Vec<OraclePrice>,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct OraclePrice {
+    #[prost(string, tag="1")]
+    pub feed_address: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub token_address: ::prost::alloc::string::String,
+    #[prost(string, tag="3")]
+    pub price_usd: ::prost::alloc::string::String,
+    #[prost(uint64, tag="4")]
+    pub round_id: u64,
+    #[prost(uint64, tag="5")]
+    pub updated_at: u64,
+    #[prost(uint64, tag="6")]
+    pub block_num: u64,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Trades {
+    #[prost(message, repeated, tag="1")]
+    pub trades: ::prost::alloc::vec::Vec<Trade>,
+}
+/// Trade is a trader level swap, reconstructed from the pair level swaps of a
+/// transaction. A multi-hop swap through the router is a single trade whose
+/// `route` lists every token it went through.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Trade {
+    /// ID of the event of the first hop, see Event.id
+    #[prost(string, tag="1")]
+    pub id: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub transaction_id: ::prost::alloc::string::String,
+    #[prost(string, tag="3")]
+    pub trader: ::prost::alloc::string::String,
+    #[prost(string, tag="4")]
+    pub recipient: ::prost::alloc::string::String,
+    #[prost(string, tag="5")]
+    pub router: ::prost::alloc::string::String,
+    #[prost(string, tag="6")]
+    pub token_in: ::prost::alloc::string::String,
+    #[prost(string, tag="7")]
+    pub token_out: ::prost::alloc::string::String,
+    #[prost(string, tag="8")]
+    pub amount_in: ::prost::alloc::string::String,
+    #[prost(string, tag="9")]
+    pub amount_out: ::prost::alloc::string::String,
+    #[prost(string, tag="10")]
+    pub amount_usd: ::prost::alloc::string::String,
+    #[prost(string, repeated, tag="11")]
+    pub route: ::prost::alloc::vec::Vec<::prost::alloc::string::String>,
+    #[prost(string, repeated, tag="12")]
+    pub pairs: ::prost::alloc::vec::Vec<::prost::alloc::string::String>,
+    #[prost(uint64, tag="13")]
+    pub log_ordinal: u64,
+    #[prost(uint64, tag="14")]
+    pub timestamp: u64,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Sandwiches {
+    #[prost(message, repeated, tag="1")]
+    pub sandwiches: ::prost::alloc::vec::Vec<Sandwich>,
+}
+/// Sandwich is a front-run / back-run pair of swaps from the same attacker on a pair,
+/// surrounding one or more victim swaps in the same direction as the front-run.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Sandwich {
+    #[prost(string, tag="1")]
+    pub pair_address: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub attacker: ::prost::alloc::string::String,
+    #[prost(string, tag="3")]
+    pub front_transaction_id: ::prost::alloc::string::String,
+    #[prost(string, tag="4")]
+    pub back_transaction_id: ::prost::alloc::string::String,
+    #[prost(string, repeated, tag="5")]
+    pub victim_transaction_ids: ::prost::alloc::vec::Vec<::prost::alloc::string::String>,
+    /// token the attacker put in the front-run and got back in the back-run, the
+    /// profit is expressed in that token
+    #[prost(string, tag="6")]
+    pub token: ::prost::alloc::string::String,
+    #[prost(string, tag="7")]
+    pub profit: ::prost::alloc::string::String,
+    #[prost(string, tag="8")]
+    pub victims_amount_usd: ::prost::alloc::string::String,
+    #[prost(uint64, tag="9")]
+    pub log_ordinal: u64,
+    #[prost(uint64, tag="10")]
+    pub timestamp: u64,
+    /// reserves of the pair right after the front-run, the ones the victims traded
+    /// against, empty when unknown
+    #[prost(string, tag="11")]
+    pub front_run_reserve0: ::prost::alloc::string::String,
+    #[prost(string, tag="12")]
+    pub front_run_reserve1: ::prost::alloc::string::String,
+    /// ID of the event of the front-run, see Event.id
+    #[prost(string, tag="13")]
+    pub id: ::prost::alloc::string::String,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct TokenTaxes {
+    #[prost(message, repeated, tag="1")]
+    pub token_taxes: ::prost::alloc::vec::Vec<TokenTax>,
+}
+/// TokenTax is a fee-on-transfer detection: the amount transferred to a pair in a
+/// transaction is larger than the amount the pair accounted for in its Swap.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct TokenTax {
+    #[prost(string, tag="1")]
+    pub token_address: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub pair_address: ::prost::alloc::string::String,
+    #[prost(string, tag="3")]
+    pub transaction_id: ::prost::alloc::string::String,
+    #[prost(string, tag="4")]
+    pub transferred: ::prost::alloc::string::String,
+    #[prost(string, tag="5")]
+    pub received: ::prost::alloc::string::String,
+    /// 1 - received / transferred
+    #[prost(string, tag="6")]
+    pub tax_rate: ::prost::alloc::string::String,
+    #[prost(uint64, tag="7")]
+    pub log_ordinal: u64,
+    /// ID of the Swap log followed by the token address, `<swap log id>:<token>`, see
+    /// Event.id
+    #[prost(string, tag="8")]
+    pub id: ::prost::alloc::string::String,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Contracts {
+    #[prost(message, repeated, tag="1")]
+    pub contracts: ::prost::alloc::vec::Vec<Contract>,
+}
+/// Contract is the lifecycle of a pair contract, from the block it was created at
+/// to the block it self-destructed at, outputs of the pair only exist in between.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Contract {
+    #[prost(string, tag="1")]
+    pub address: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub factory_address: ::prost::alloc::string::String,
+    #[prost(uint64, tag="3")]
+    pub created_block_num: u64,
+    #[prost(string, tag="4")]
+    pub creation_transaction_id: ::prost::alloc::string::String,
+    /// zero while the contract is alive
+    #[prost(uint64, tag="5")]
+    pub destroyed_block_num: u64,
+    #[prost(string, tag="6")]
+    pub destruction_transaction_id: ::prost::alloc::string::String,
+    #[prost(uint64, tag="7")]
+    pub ordinal: u64,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct EventSignatures {
+    #[prost(message, repeated, tag="1")]
+    pub signatures: ::prost::alloc::vec::Vec<EventSignature>,
+}
+/// EventSignature is an event signature seen in the logs of a tracked contract,
+/// once per contract and topic0 in a block.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct EventSignature {
+    /// hex encoded, without 0x
+    #[prost(string, tag="1")]
+    pub topic0: ::prost::alloc::string::String,
+    /// text signature from the bundled database, empty when unknown
+    #[prost(string, tag="2")]
+    pub signature: ::prost::alloc::string::String,
+    #[prost(string, tag="3")]
+    pub contract_address: ::prost::alloc::string::String,
+    /// first transaction of the block that emitted the event
+    #[prost(string, tag="4")]
+    pub transaction_id: ::prost::alloc::string::String,
+    #[prost(uint64, tag="5")]
+    pub log_ordinal: u64,
+    /// number of logs of the contract with this topic0 in the block
+    #[prost(uint64, tag="6")]
+    pub count: u64,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct AddressLabels {
+    #[prost(message, repeated, tag="1")]
+    pub labels: ::prost::alloc::vec::Vec<AddressLabel>,
+}
+/// AddressLabel is a labelled address, from labels.csv, seen trading or
+/// providing liquidity in a block.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct AddressLabel {
+    #[prost(string, tag="1")]
+    pub address: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub label: ::prost::alloc::string::String,
+    /// exchange, router, aggregator or bot
+    #[prost(string, tag="3")]
+    pub category: ::prost::alloc::string::String,
+    #[prost(string, tag="4")]
+    pub transaction_id: ::prost::alloc::string::String,
+    #[prost(uint64, tag="5")]
+    pub log_ordinal: u64,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Violations {
+    #[prost(message, repeated, tag="1")]
+    pub violations: ::prost::alloc::vec::Vec<Violation>,
+}
+/// Violation is extracted data breaking an invariant of the exchange, like a
+/// negative reserve, which points to a decoder bug rather than to the chain.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct Violation {
+    /// name of the broken invariant, like negative_reserve
+    #[prost(string, tag="1")]
+    pub check: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub message: ::prost::alloc::string::String,
+    #[prost(uint64, tag="3")]
+    pub block_num: u64,
+    #[prost(string, tag="4")]
+    pub block_id: ::prost::alloc::string::String,
+    #[prost(string, tag="5")]
+    pub transaction_id: ::prost::alloc::string::String,
+    #[prost(uint64, tag="6")]
+    pub log_ordinal: u64,
+    #[prost(string, tag="7")]
+    pub pair_address: ::prost::alloc::string::String,
+    /// ID of the offending entity, empty for a pair
+    #[prost(string, tag="8")]
+    pub entity_id: ::prost::alloc::string::String,
+}
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct PairSummaries {
+    #[prost(message, repeated, tag="1")]
+    pub summaries: ::prost::alloc::vec::Vec<PairSummary>,
+}
+/// PairSummary joins what the stores hold about a pair at the end of a block,
+/// for sinks to serve without joining them: the pair from store_pairs, the
+/// symbols of its tokens from store_pcs_tokens, its prices and reserves in USD
+/// from store_prices and its volume of the last 24 hours from
+/// store_volume24h_totals. USD values are empty while unknown.
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct PairSummary {
+    #[prost(string, tag="1")]
+    pub pair_address: ::prost::alloc::string::String,
+    #[prost(string, tag="2")]
+    pub factory_address: ::prost::alloc::string::String,
+    #[prost(string, tag="3")]
+    pub token0_address: ::prost::alloc::string::String,
+    #[prost(string, tag="4")]
+    pub token0_symbol: ::prost::alloc::string::String,
+    #[prost(string, tag="5")]
+    pub token1_address: ::prost::alloc::string::String,
+    #[prost(string, tag="6")]
+    pub token1_symbol: ::prost::alloc::string::String,
+    #[prost(string, tag="7")]
+    pub reserve0: ::prost::alloc::string::String,
+    #[prost(string, tag="8")]
+    pub reserve1: ::prost::alloc::string::String,
+    /// amount of token0 per token1, and of token1 per token0, see Reserve
+    #[prost(string, tag="9")]
+    pub token0_price: ::prost::alloc::string::String,
+    #[prost(string, tag="10")]
+    pub token1_price: ::prost::alloc::string::String,
+    #[prost(string, tag="11")]
+    pub token0_price_usd: ::prost::alloc::string::String,
+    #[prost(string, tag="12")]
+    pub token1_price_usd: ::prost::alloc::string::String,
+    #[prost(string, tag="13")]
+    pub reserve_usd: ::prost::alloc::string::String,
+    #[prost(string, tag="14")]
+    pub volume_usd_24h: ::prost::alloc::string::String,
+    #[prost(uint64, tag="15")]
+    pub block_num: u64,
+    /// ordinal of the last Sync log of the pair in the block
+    #[prost(uint64, tag="16")]
+    pub log_ordinal: u64,
+}
@@ -0,0 +1,60 @@
+use std::collections::HashMap;
+
+use crate::pcs;
+
+/// Reserves of a pair as set by one of its `Sync` logs.
+pub struct Snapshot<'a> {
+    pub log_ordinal: u64,
+    pub reserve0: &'a str,
+    pub reserve1: &'a str,
+}
+
+/// Reserves of the pairs at every log of a block, indexed from the `Sync` logs of
+/// `map_reserves`, answering "what were the reserves of the pair when this log was
+/// emitted" for any log of the block without going through `store_reserves`. It
+/// borrows the `map_reserves` output, so it's built anew, and empty, for every block.
+pub struct BlockReserves<'a> {
+    // per pair, sorted by log ordinal
+    snapshots: HashMap<&'a str, Vec<Snapshot<'a>>>,
+}
+
+impl<'a> BlockReserves<'a> {
+    pub fn new(reserves: &'a pcs::Reserves) -> BlockReserves<'a> {
+        let mut snapshots: HashMap<&str, Vec<Snapshot>> = HashMap::new();
+        for reserve in &reserves.reserves {
+            snapshots
+                .entry(reserve.pair_address.as_str())
+                .or_insert(vec![])
+                .push(Snapshot {
+                    log_ordinal: reserve.log_ordinal,
+                    reserve0: reserve.reserve0.as_str(),
+                    reserve1: reserve.reserve1.as_str(),
+                });
+        }
+
+        for pair_snapshots in snapshots.values_mut() {
+            pair_snapshots.sort_by(|a, b| a.log_ordinal.cmp(&b.log_ordinal));
+        }
+
+        BlockReserves { snapshots }
+    }
+
+    /// Returns the reserves of `pair` as of the log at `ordinal`, the ones set by the
+    /// last `Sync` at or before it. `None` when the pair didn't sync yet in the block.
+    pub fn at(&self, pair: &str, ordinal: u64) -> Option<&Snapshot<'a>> {
+        let pair_snapshots = self.snapshots.get(pair)?;
+        match pair_snapshots.binary_search_by(|snapshot| snapshot.log_ordinal.cmp(&ordinal)) {
+            Ok(index) => Some(&pair_snapshots[index]),
+            Err(0) => None,
+            Err(index) => Some(&pair_snapshots[index - 1]),
+        }
+    }
+
+    /// Returns the reserves of `pair` just before the log at `ordinal`, see `at`.
+    pub fn before(&self, pair: &str, ordinal: u64) -> Option<&Snapshot<'a>> {
+        if ordinal == 0 {
+            return None;
+        }
+        self.at(pair, ordinal - 1)
+    }
+}
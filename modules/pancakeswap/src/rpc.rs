@@ -1,3 +1,5 @@
+use std::convert::TryInto;
+
 use hex;
 use substreams::{Hex};
 use substreams_ethereum::pb::eth;
@@ -65,3 +67,50 @@ pub fn retry_rpc_calls(pair_token_address: &String) -> Result<Token, String> {
         decimals: decoded_decimals.unwrap() as u64,
     })
 }
+
+pub struct RoundData {
+    pub round_id: u64,
+    pub answer: Vec<u8>,
+    pub updated_at: u64,
+}
+
+/// Calls `latestRoundData()` on each Chainlink feed, a `None` entry is returned for
+/// feeds whose call failed or returned a negative answer.
+pub fn latest_round_data(feeds: &Vec<String>) -> Vec<Option<RoundData>> {
+    let latest_round_data = hex::decode("feaf968c").unwrap();
+
+    let rpc_calls = eth::rpc::RpcCalls {
+        calls: feeds
+            .iter()
+            .map(|feed| eth::rpc::RpcCall {
+                to_addr: address_decode(feed),
+                method_signature: latest_round_data.clone(),
+            })
+            .collect(),
+    };
+
+    let rpc_responses_unmarshalled: eth::rpc::RpcResponses =
+        substreams_ethereum::rpc::eth_call(&rpc_calls);
+
+    rpc_responses_unmarshalled
+        .responses
+        .iter()
+        .map(|response| {
+            // (uint80 roundId, int256 answer, uint256 startedAt, uint256 updatedAt, uint80 answeredInRound)
+            if response.failed || response.raw.len() != 160 || response.raw[32] & 0x80 != 0 {
+                return None;
+            }
+
+            Some(RoundData {
+                round_id: read_uint64(&response.raw[0..32]),
+                answer: response.raw[32..64].to_vec(),
+                updated_at: read_uint64(&response.raw[96..128]),
+            })
+        })
+        .collect()
+}
+
+fn read_uint64(input: &[u8]) -> u64 {
+    let as_array: [u8; 8] = input[24..32].try_into().unwrap();
+    u64::from_be_bytes(as_array)
+}
@@ -0,0 +1,116 @@
+use std::str::FromStr;
+
+use bigdecimal::BigDecimal;
+
+use crate::decimal;
+use crate::pcs;
+use crate::pcs::event::Type;
+
+struct Hop<'a> {
+    event: &'a pcs::Event,
+    swap: &'a pcs::Swap,
+    token_in: String,
+    token_out: String,
+    amount_in: BigDecimal,
+    amount_out: BigDecimal,
+}
+
+/// Groups the swaps of each transaction into trader level trades. Consecutive swaps
+/// are part of the same route when the output of a hop is sent to the next pair and
+/// the token it outputs is the one the next pair takes in, which is how the router
+/// executes `swapExactTokensForTokens` and friends.
+pub fn build_trades(events: &Vec<pcs::Event>) -> Vec<pcs::Trade> {
+    let mut trades: Vec<pcs::Trade> = vec![];
+
+    let mut hops: Vec<Hop> = events.iter().filter_map(new_hop).collect();
+    hops.sort_by(|a, b| a.event.log_ordinal.cmp(&b.event.log_ordinal));
+
+    let mut route: Vec<Hop> = vec![];
+    for hop in hops {
+        let continues_route = match route.last() {
+            None => false,
+            Some(previous) => {
+                previous.event.transaction_id == hop.event.transaction_id
+                    && previous.swap.to == hop.event.pair_address
+                    && previous.token_out == hop.token_in
+            }
+        };
+
+        if !continues_route && !route.is_empty() {
+            trades.push(new_trade(&route));
+            route.clear();
+        }
+
+        route.push(hop);
+    }
+
+    if !route.is_empty() {
+        trades.push(new_trade(&route));
+    }
+
+    trades
+}
+
+fn new_hop(event: &pcs::Event) -> Option<Hop> {
+    let swap = match event.r#type.as_ref() {
+        Some(Type::Swap(swap)) => swap,
+        _ => return None,
+    };
+
+    let zero = decimal::zero();
+    let amount0_in = BigDecimal::from_str(swap.amount0_in.as_str()).unwrap();
+    let amount1_in = BigDecimal::from_str(swap.amount1_in.as_str()).unwrap();
+    let amount0_out = BigDecimal::from_str(swap.amount0_out.as_str()).unwrap();
+    let amount1_out = BigDecimal::from_str(swap.amount1_out.as_str()).unwrap();
+
+    let (token_in, amount_in) = if amount0_in.ne(&zero) {
+        (event.token0.clone(), amount0_in)
+    } else {
+        (event.token1.clone(), amount1_in)
+    };
+
+    let (token_out, amount_out) = if amount0_out.ne(&zero) {
+        (event.token0.clone(), amount0_out)
+    } else {
+        (event.token1.clone(), amount1_out)
+    };
+
+    Some(Hop {
+        event,
+        swap,
+        token_in,
+        token_out,
+        amount_in,
+        amount_out,
+    })
+}
+
+fn new_trade(route: &Vec<Hop>) -> pcs::Trade {
+    let first = route.first().unwrap();
+    let last = route.last().unwrap();
+
+    let mut tokens: Vec<String> = vec![first.token_in.clone()];
+    tokens.extend(route.iter().map(|hop| hop.token_out.clone()));
+
+    pcs::Trade {
+        // a swap is the first hop of a single trade
+        id: first.event.id.clone(),
+        transaction_id: first.event.transaction_id.clone(),
+        trader: first.swap.from.clone(),
+        recipient: last.swap.to.clone(),
+        // the pair's `sender` is whoever called `swap` on the first pair, the router
+        // when going through it, the trader or an aggregator contract otherwise
+        router: first.swap.sender.clone(),
+        token_in: first.token_in.clone(),
+        token_out: last.token_out.clone(),
+        amount_in: first.amount_in.to_string(),
+        amount_out: last.amount_out.to_string(),
+        // every hop carries the same value, the first one is the best priced as it's
+        // the closest to what the trader actually put in
+        amount_usd: first.swap.amount_usd.clone(),
+        route: tokens,
+        pairs: route.iter().map(|hop| hop.event.pair_address.clone()).collect(),
+        log_ordinal: first.event.log_ordinal,
+        timestamp: first.event.timestamp,
+    }
+}
@@ -1,13 +1,12 @@
-use std::ops::{Add, Div, Mul};
+use std::ops::Add;
 use std::str;
 use std::str::FromStr;
 
-use bigdecimal::{BigDecimal, One, Zero};
-use num_bigint::BigUint;
-use pad::PadStr;
+use bigdecimal::BigDecimal;
 use substreams::{proto, store};
 
-use crate::pb;
+use crate::pair_filter::PairFilter;
+use crate::{decimal, pb};
 
 pub const WBNB_ADDRESS: &str = "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c";
 pub const BUSD_WBNB_PAIR: &str = "0x58f876857a02d6762e0101bb5c46a8c1ed44dc16";
@@ -26,19 +25,6 @@ const WHITELIST_TOKENS: [&str; 6] = [
     "0x2170ed0880ac9a755fd29b2688956bd959f933f8", // WETH
 ];
 
-pub fn convert_token_to_decimal(amount: &[u8], decimals: &u64) -> BigDecimal {
-    let big_uint_amount = BigUint::from_bytes_be(amount);
-    let big_float_amount = BigDecimal::from_str(big_uint_amount.to_string().as_str())
-        .unwrap()
-        .with_prec(100);
-
-    return divide_by_decimals(big_float_amount, decimals);
-}
-
-pub fn get_token_price(bf0: BigDecimal, bf1: BigDecimal) -> BigDecimal {
-    return bf0.div(bf1).with_prec(100);
-}
-
 pub fn generate_tokens_key(token0: &str, token1: &str) -> String {
     if token0 > token1 {
         return format!("{}:{}", token1, token0);
@@ -55,9 +41,9 @@ pub fn compute_usd_price(reserves_store: &store::StoreGet, reserve: &pb::pcs::Re
         reserve.log_ordinal,
         &format!("reserve:{}:{}", BUSD_WBNB_PAIR, WBNB_ADDRESS),
     ) {
-        None => busd_bnb_reserve_big_decimal = zero_big_decimal(),
+        None => busd_bnb_reserve_big_decimal = decimal::zero(),
         Some(reserve_bytes) => {
-            busd_bnb_reserve_big_decimal = decode_reserve_bytes_to_big_decimal(reserve_bytes)
+            busd_bnb_reserve_big_decimal = decimal::from_store(&reserve_bytes)
         }
     }
 
@@ -65,13 +51,13 @@ pub fn compute_usd_price(reserves_store: &store::StoreGet, reserve: &pb::pcs::Re
         reserve.log_ordinal,
         &format!("reserve:{}:{}", USDT_WBNB_PAIR, WBNB_ADDRESS),
     ) {
-        None => usdt_bnb_reserve_big_decimal = zero_big_decimal(),
+        None => usdt_bnb_reserve_big_decimal = decimal::zero(),
         Some(reserve_bytes) => {
-            usdt_bnb_reserve_big_decimal = decode_reserve_bytes_to_big_decimal(reserve_bytes)
+            usdt_bnb_reserve_big_decimal = decimal::from_store(&reserve_bytes)
         }
     }
 
-    let mut total_liquidity_bnb = zero_big_decimal();
+    let mut total_liquidity_bnb = decimal::zero();
     total_liquidity_bnb = total_liquidity_bnb
         .clone()
         .add(busd_bnb_reserve_big_decimal.clone());
@@ -79,7 +65,7 @@ pub fn compute_usd_price(reserves_store: &store::StoreGet, reserve: &pb::pcs::Re
         .clone()
         .add(usdt_bnb_reserve_big_decimal.clone());
 
-    let zero = zero_big_decimal();
+    let zero = decimal::zero();
 
     if total_liquidity_bnb.eq(&zero) {
         return zero;
@@ -91,7 +77,7 @@ pub fn compute_usd_price(reserves_store: &store::StoreGet, reserve: &pb::pcs::Re
             &USDT_PRICE_KEY.to_string(),
         ) {
             None => zero,
-            Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes),
+            Some(reserve_bytes) => decimal::from_store(&reserve_bytes),
         };
     } else if usdt_bnb_reserve_big_decimal.eq(&zero) {
         return match reserves_store.get_at(
@@ -99,38 +85,34 @@ pub fn compute_usd_price(reserves_store: &store::StoreGet, reserve: &pb::pcs::Re
             &BUSD_PRICE_KEY.to_string(),
         ) {
             None => zero,
-            Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes),
+            Some(reserve_bytes) => decimal::from_store(&reserve_bytes),
         };
     }
 
     // both found and not equal to zero, average out
-    let busd_weight = busd_bnb_reserve_big_decimal
-        .div(total_liquidity_bnb.clone())
-        .with_prec(100);
-    let usdt_weight = usdt_bnb_reserve_big_decimal
-        .div(total_liquidity_bnb)
-        .with_prec(100);
+    let busd_weight = decimal::div(&busd_bnb_reserve_big_decimal, &total_liquidity_bnb);
+    let usdt_weight = decimal::div(&usdt_bnb_reserve_big_decimal, &total_liquidity_bnb);
 
     let busd_price = match reserves_store.get_at(
         reserve.log_ordinal,
         &USDT_PRICE_KEY.to_string(),
     ) {
-        None => zero_big_decimal(),
-        Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes),
+        None => decimal::zero(),
+        Some(reserve_bytes) => decimal::from_store(&reserve_bytes),
     };
 
     let usdt_price = match reserves_store.get_at(
         reserve.log_ordinal,
         &BUSD_PRICE_KEY.to_string(),
     ) {
-        None => zero_big_decimal(),
-        Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes),
+        None => decimal::zero(),
+        Some(reserve_bytes) => decimal::from_store(&reserve_bytes),
     };
 
-    let busd_price_over_weight = busd_price.mul(busd_weight).with_prec(100);
-    let usdt_price_over_weight = usdt_price.mul(usdt_weight).with_prec(100);
+    let busd_price_over_weight = decimal::mul(&busd_price, &busd_weight);
+    let usdt_price_over_weight = decimal::mul(&usdt_price, &usdt_weight);
 
-    let mut usd_price = zero_big_decimal();
+    let mut usd_price = decimal::zero();
     usd_price = usd_price.add(busd_price_over_weight);
     usd_price = usd_price.add(usdt_price_over_weight);
 
@@ -142,19 +124,20 @@ pub fn find_bnb_price_per_token(
     erc20_token_address: &str,
     pairs_store: &store::StoreGet,
     reserves_store: &store::StoreGet,
+    filter: &PairFilter,
 ) -> Option<BigDecimal> {
     if erc20_token_address.eq(WBNB_ADDRESS) {
-        return Some(one_big_decimal()); // BNB price of a BNB is always 1
+        return Some(decimal::one()); // BNB price of a BNB is always 1
     }
 
     let direct_to_bnb_price = match reserves_store.get_last(
         &format!("price:{}:{}", WBNB_ADDRESS, erc20_token_address),
     ) {
-        None => zero_big_decimal(),
-        Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes),
+        None => decimal::zero(),
+        Some(reserve_bytes) => decimal::from_store(&reserve_bytes),
     };
 
-    if direct_to_bnb_price.ne(&zero_big_decimal()) {
+    if direct_to_bnb_price.ne(&decimal::zero()) {
         return Some(direct_to_bnb_price);
     }
 
@@ -171,12 +154,17 @@ pub fn find_bnb_price_per_token(
             Some(pair_bytes) => decode_pair_bytes(pair_bytes),
         };
 
+        // an excluded pair doesn't price the tokens routed through it
+        if !filter.allowed(&[tiny_to_major_pair.as_str(), erc20_token_address, major_token]) {
+            continue;
+        }
+
         let major_to_bnb_price = match reserves_store.get_at(
             *log_ordinal,
             &format!("price:{}:{}", major_token, WBNB_ADDRESS),
         ) {
             None => continue,
-            Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes),
+            Some(reserve_bytes) => decimal::from_store(&reserve_bytes),
         };
 
         let tiny_to_major_price = match reserves_store.get_at(
@@ -184,17 +172,17 @@ pub fn find_bnb_price_per_token(
             &format!("price:{}:{}", erc20_token_address, major_token),
         ) {
             None => continue,
-            Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes),
+            Some(reserve_bytes) => decimal::from_store(&reserve_bytes),
         };
 
         let major_reserve =
             //todo: not sure about tiny_to_major_pair.erc20_token0.addr, maybe its the token1 ?
             match reserves_store.get_at(*log_ordinal, &format!("reserve:{}:{}", tiny_to_major_pair, major_token)) {
                 None => continue,
-                Some(reserve_bytes) => decode_reserve_bytes_to_big_decimal(reserve_bytes)
+                Some(reserve_bytes) => decimal::from_store(&reserve_bytes)
             };
 
-        let bnb_reserve_in_major_pair = major_to_bnb_price.clone().mul(major_reserve);
+        let bnb_reserve_in_major_pair = decimal::mul(&major_to_bnb_price, &major_reserve);
         // We're checking for half of it, because `reserves_bnb` would have both sides in it.
         // We could very well check the other reserve's BNB value, would be a bit more heavy, but we can do it.
         if bnb_reserve_in_major_pair.le(&BigDecimal::from_str("5").unwrap()) {
@@ -202,21 +190,14 @@ pub fn find_bnb_price_per_token(
             continue; // Not enough liquidity
         }
 
-        return Some(tiny_to_major_price.mul(major_to_bnb_price));
+        return Some(decimal::mul(&tiny_to_major_price, &major_to_bnb_price));
     }
 
     return None;
 }
 
-pub fn zero_big_decimal() -> BigDecimal {
-    BigDecimal::zero().with_prec(100)
-}
-
 pub fn compute_amount_total(amount1: String, amount2: String) -> BigDecimal {
-    let amount1_bd: BigDecimal = BigDecimal::from_str(amount1.as_str()).unwrap();
-    let amount2_bd: BigDecimal = BigDecimal::from_str(amount2.as_str()).unwrap();
-
-    amount1_bd.add(amount2_bd)
+    decimal::parse(amount1.as_str()).add(decimal::parse(amount2.as_str()))
 }
 
 pub fn get_last_token(tokens: &store::StoreGet, token_address: &str) -> pb::tokens::Token {
@@ -224,28 +205,15 @@ pub fn get_last_token(tokens: &store::StoreGet, token_address: &str) -> pb::toke
         .unwrap()
 }
 
-fn one_big_decimal() -> BigDecimal {
-    BigDecimal::one().with_prec(100)
-}
-
-fn divide_by_decimals(big_float_amount: BigDecimal, decimals: &u64) -> BigDecimal {
-    let bd = BigDecimal::from_str(
-        "1".pad_to_width_with_char((*decimals + 1) as usize, '0')
-            .as_str(),
-    )
-    .unwrap()
-    .with_prec(100);
-    return big_float_amount.div(bd).with_prec(100);
+/// Returns the decimals of `token_address` from the `store_token_decimals` store,
+/// `None` when the token couldn't be resolved.
+pub fn get_token_decimals(token_decimals: &store::StoreGet, token_address: &str) -> Option<u64> {
+    token_decimals
+        .get_last(&format!("token:{}", token_address))
+        .map(|decimals_bytes| str::from_utf8(decimals_bytes.as_slice()).unwrap().parse().unwrap())
 }
 
 fn decode_pair_bytes(pair_bytes: Vec<u8>) -> String {
     let pair_from_store_decoded = str::from_utf8(pair_bytes.as_slice()).unwrap();
     return pair_from_store_decoded.to_string();
 }
-
-fn decode_reserve_bytes_to_big_decimal(reserve_bytes: Vec<u8>) -> BigDecimal {
-    let reserve_from_store_decoded = str::from_utf8(reserve_bytes.as_slice()).unwrap();
-    return BigDecimal::from_str(reserve_from_store_decoded)
-        .unwrap()
-        .with_prec(100);
-}
@@ -0,0 +1,48 @@
+use std::ops::Sub;
+
+use bigdecimal::BigDecimal;
+use substreams::pb::substreams::StoreDelta;
+use substreams::store;
+
+use crate::decimal;
+
+/// Hours of the rolling window of `store_volume24h`, the current one included.
+pub const WINDOW_HOURS: i64 = 24;
+
+/// Key of the USD volume of `pair` during the hour `hour_id`.
+pub fn key(hour_id: i64, pair: &str) -> String {
+    format!("pair_hour:{}:{}:usd", hour_id, pair)
+}
+
+/// Key of the USD volume of `pair` over the window in `store_volume24h_totals`.
+pub fn total_key(pair: &str) -> String {
+    format!("pair:{}:usd", pair)
+}
+
+/// Returns the pair of an hourly bucket of `store_volume24h` and by how much the bucket
+/// changed, the whole bucket taken back when it's deleted past the window.
+pub fn bucket_change(delta: &StoreDelta) -> Option<(&str, BigDecimal)> {
+    let parts: Vec<&str> = delta.key.split(":").collect();
+    if parts.len() != 4 || parts[0] != "pair_hour" {
+        return None;
+    }
+
+    let value = |bytes: &Vec<u8>| {
+        if bytes.is_empty() {
+            decimal::zero()
+        } else {
+            decimal::from_store(bytes)
+        }
+    };
+    let change = decimal::round(value(&delta.new_value).sub(value(&delta.old_value)));
+    Some((parts[2], change))
+}
+
+/// USD volume of `pair` over the window ending with the current hour, read from the running
+/// totals of `store_volume24h_totals`.
+pub fn pair_volume(totals: &store::StoreGet, pair: &str) -> BigDecimal {
+    match totals.get_last(&total_key(pair)) {
+        None => decimal::zero(),
+        Some(volume_bytes) => decimal::from_store(&volume_bytes),
+    }
+}